@@ -21,6 +21,7 @@ package data
 import (
 	"google.golang.org/protobuf/proto"
 
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
@@ -30,14 +31,21 @@ import (
 var (
 	// TopicMap is the map of topic name to topic.
 	TopicMap = map[string]bus.Topic{
-		TopicStreamWrite.String():    TopicStreamWrite,
-		TopicStreamQuery.String():    TopicStreamQuery,
-		TopicMeasureWrite.String():   TopicMeasureWrite,
-		TopicMeasureQuery.String():   TopicMeasureQuery,
-		TopicTopNQuery.String():      TopicTopNQuery,
-		TopicPropertyDelete.String(): TopicPropertyDelete,
-		TopicPropertyQuery.String():  TopicPropertyQuery,
-		TopicPropertyUpdate.String(): TopicPropertyUpdate,
+		TopicStreamWrite.String():         TopicStreamWrite,
+		TopicStreamQuery.String():         TopicStreamQuery,
+		TopicMeasureWrite.String():        TopicMeasureWrite,
+		TopicMeasureQuery.String():        TopicMeasureQuery,
+		TopicTopNQuery.String():           TopicTopNQuery,
+		TopicPropertyDelete.String():      TopicPropertyDelete,
+		TopicPropertyQuery.String():       TopicPropertyQuery,
+		TopicPropertyUpdate.String():      TopicPropertyUpdate,
+		TopicSlowQueryList.String():       TopicSlowQueryList,
+		TopicStreamMigrateShard.String():  TopicStreamMigrateShard,
+		TopicMeasureMigrateShard.String(): TopicMeasureMigrateShard,
+		TopicStreamCountShard.String():    TopicStreamCountShard,
+		TopicMeasureCountShard.String():   TopicMeasureCountShard,
+		TopicStreamGroupSize.String():     TopicStreamGroupSize,
+		TopicMeasureGroupSize.String():    TopicMeasureGroupSize,
 	}
 
 	// TopicRequestMap is the map of topic name to request message.
@@ -67,6 +75,27 @@ var (
 		TopicPropertyDelete: func() proto.Message {
 			return &propertyv1.InternalDeleteRequest{}
 		},
+		TopicSlowQueryList: func() proto.Message {
+			return &databasev1.SlowQueryServiceListRequest{}
+		},
+		TopicStreamMigrateShard: func() proto.Message {
+			return &databasev1.MigrateShardRequest{}
+		},
+		TopicMeasureMigrateShard: func() proto.Message {
+			return &databasev1.MigrateShardRequest{}
+		},
+		TopicStreamCountShard: func() proto.Message {
+			return &databasev1.CountShardRequest{}
+		},
+		TopicMeasureCountShard: func() proto.Message {
+			return &databasev1.CountShardRequest{}
+		},
+		TopicStreamGroupSize: func() proto.Message {
+			return &databasev1.GroupSizeRequest{}
+		},
+		TopicMeasureGroupSize: func() proto.Message {
+			return &databasev1.GroupSizeRequest{}
+		},
 	}
 
 	// TopicResponseMap is the map of topic name to response message.
@@ -90,6 +119,27 @@ var (
 		TopicPropertyUpdate: func() proto.Message {
 			return &propertyv1.ApplyResponse{}
 		},
+		TopicSlowQueryList: func() proto.Message {
+			return &databasev1.SlowQueryServiceListResponse{}
+		},
+		TopicStreamMigrateShard: func() proto.Message {
+			return &databasev1.MigrateShardResponse{}
+		},
+		TopicMeasureMigrateShard: func() proto.Message {
+			return &databasev1.MigrateShardResponse{}
+		},
+		TopicStreamCountShard: func() proto.Message {
+			return &databasev1.CountShardResponse{}
+		},
+		TopicMeasureCountShard: func() proto.Message {
+			return &databasev1.CountShardResponse{}
+		},
+		TopicStreamGroupSize: func() proto.Message {
+			return &databasev1.GroupSizeResponse{}
+		},
+		TopicMeasureGroupSize: func() proto.Message {
+			return &databasev1.GroupSizeResponse{}
+		},
 	}
 
 	// TopicCommon is the common topic for data transmission.
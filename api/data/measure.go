@@ -57,3 +57,30 @@ var MeasureDeleteExpiredSegmentsKindVersion = common.KindVersion{
 
 // TopicMeasureDeleteExpiredSegments is the measure delete topic.
 var TopicMeasureDeleteExpiredSegments = bus.BiTopic(MeasureDeleteExpiredSegmentsKindVersion.String())
+
+// MeasureMigrateShardKindVersion is the version tag of measure migrate shard kind.
+var MeasureMigrateShardKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-migrate-shard",
+}
+
+// TopicMeasureMigrateShard is the topic to send the data points of a shard to its new nodes.
+var TopicMeasureMigrateShard = bus.BiTopic(MeasureMigrateShardKindVersion.String())
+
+// MeasureCountShardKindVersion is the version tag of measure count shard kind.
+var MeasureCountShardKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-count-shard",
+}
+
+// TopicMeasureCountShard is the topic to count the data points of a shard.
+var TopicMeasureCountShard = bus.BiTopic(MeasureCountShardKindVersion.String())
+
+// MeasureGroupSizeKindVersion is the version tag of measure group size kind.
+var MeasureGroupSizeKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-group-size",
+}
+
+// TopicMeasureGroupSize is the topic to measure the disk size of the groups.
+var TopicMeasureGroupSize = bus.BiTopic(MeasureGroupSizeKindVersion.String())
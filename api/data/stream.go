@@ -48,3 +48,30 @@ var StreamDeleteExpiredSegmentsKindVersion = common.KindVersion{
 
 // TopicDeleteExpiredStreamSegments is the delete stream segments topic.
 var TopicDeleteExpiredStreamSegments = bus.BiTopic(StreamDeleteExpiredSegmentsKindVersion.String())
+
+// StreamMigrateShardKindVersion is the version tag of stream migrate shard kind.
+var StreamMigrateShardKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-migrate-shard",
+}
+
+// TopicStreamMigrateShard is the topic to send the elements of a shard to its new nodes.
+var TopicStreamMigrateShard = bus.BiTopic(StreamMigrateShardKindVersion.String())
+
+// StreamCountShardKindVersion is the version tag of stream count shard kind.
+var StreamCountShardKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-count-shard",
+}
+
+// TopicStreamCountShard is the topic to count the elements of a shard.
+var TopicStreamCountShard = bus.BiTopic(StreamCountShardKindVersion.String())
+
+// StreamGroupSizeKindVersion is the version tag of stream group size kind.
+var StreamGroupSizeKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-group-size",
+}
+
+// TopicStreamGroupSize is the topic to measure the disk size of the groups.
+var TopicStreamGroupSize = bus.BiTopic(StreamGroupSizeKindVersion.String())
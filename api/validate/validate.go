@@ -273,3 +273,30 @@ func TopNAggregation(topNAggregation *databasev1.TopNAggregation) error {
 	}
 	return nil
 }
+
+// Shard validates the provided Shard object.
+// It checks for nil values, empty strings, and unspecified enum values.
+func Shard(shard *databasev1.Shard) error {
+	if shard == nil {
+		return errors.New("shard is nil")
+	}
+	if shard.Metadata == nil {
+		return errors.New("shard metadata is nil")
+	}
+	if shard.Metadata.Name == "" {
+		return errors.New("shard name is empty")
+	}
+	if shard.Metadata.Group == "" {
+		return errors.New("shard group is empty")
+	}
+	if shard.Catalog == commonv1.Catalog_CATALOG_UNSPECIFIED {
+		return errors.New("shard catalog is unspecified")
+	}
+	if shard.Node == "" {
+		return errors.New("shard node is empty")
+	}
+	if shard.Id >= uint64(shard.Total) {
+		return errors.New("shard id is out of the total")
+	}
+	return nil
+}
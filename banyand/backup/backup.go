@@ -51,6 +51,8 @@ func NewBackupCommand() *cobra.Command {
 		enableTLS    bool
 		insecure     bool
 		cert         string
+		clientCert   string
+		clientKey    string
 		streamRoot   string
 		measureRoot  string
 		propertyRoot string
@@ -68,7 +70,7 @@ func NewBackupCommand() *cobra.Command {
 				return err
 			}
 			if schedule == "" {
-				return backupAction(dest, gRPCAddr, enableTLS, insecure, cert,
+				return backupAction(dest, gRPCAddr, enableTLS, insecure, cert, clientCert, clientKey,
 					streamRoot, measureRoot, propertyRoot, timeStyle)
 			}
 			schedLogger := logger.GetLogger().Named("backup-scheduler")
@@ -76,7 +78,7 @@ func NewBackupCommand() *cobra.Command {
 			clockInstance := clock.New()
 			sch := timestamp.NewScheduler(schedLogger, clockInstance)
 			err := sch.Register("backup", cron.Descriptor, schedule, func(_ time.Time, l *logger.Logger) bool {
-				err := backupAction(dest, gRPCAddr, enableTLS, insecure, cert,
+				err := backupAction(dest, gRPCAddr, enableTLS, insecure, cert, clientCert, clientKey,
 					streamRoot, measureRoot, propertyRoot, timeStyle)
 				if err != nil {
 					l.Error().Err(err).Msg("backup failed")
@@ -103,6 +105,8 @@ func NewBackupCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&enableTLS, "enable-tls", false, "Enable TLS for gRPC connection")
 	cmd.Flags().BoolVar(&insecure, "insecure", false, "Skip server certificate verification")
 	cmd.Flags().StringVar(&cert, "cert", "", "Path to the gRPC server certificate")
+	cmd.Flags().StringVar(&clientCert, "client-cert", "", "Path to the client certificate presented to the data node which verifies the clients")
+	cmd.Flags().StringVar(&clientKey, "client-key", "", "Path to the key of the client certificate")
 	cmd.Flags().StringVar(&streamRoot, "stream-root-path", "/tmp", "Root directory for stream catalog")
 	cmd.Flags().StringVar(&measureRoot, "measure-root-path", "/tmp", "Root directory for measure catalog")
 	cmd.Flags().StringVar(&propertyRoot, "property-root-path", "/tmp", "Root directory for property catalog")
@@ -118,7 +122,7 @@ func NewBackupCommand() *cobra.Command {
 	return cmd
 }
 
-func backupAction(dest, gRPCAddr string, enableTLS, insecure bool, cert,
+func backupAction(dest, gRPCAddr string, enableTLS, insecure bool, cert, clientCert, clientKey,
 	streamRoot, measureRoot, propertyRoot, timeStyle string,
 ) error {
 	if dest == "" {
@@ -131,7 +135,7 @@ func backupAction(dest, gRPCAddr string, enableTLS, insecure bool, cert,
 	}
 	defer fs.Close()
 
-	snapshots, err := snapshot.Get(gRPCAddr, enableTLS, insecure, cert)
+	snapshots, err := snapshot.Get(gRPCAddr, enableTLS, insecure, cert, clientCert, clientKey)
 	if err != nil {
 		return err
 	}
@@ -36,6 +36,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/node"
@@ -56,8 +57,11 @@ type lifecycleService struct {
 	metadata         metadata.Repo
 	omr              observability.MetricsRegistry
 	l                *logger.Logger
+	internalTLS      *pub.ClientTLS
 	gRPCAddr         string
 	cert             string
+	clientCert       string
+	clientKey        string
 	streamRoot       string
 	measureRoot      string
 	progressFilePath string
@@ -68,8 +72,9 @@ type lifecycleService struct {
 // NewService creates a new lifecycle service.
 func NewService(meta metadata.Repo, omr observability.MetricsRegistry) run.Unit {
 	return &lifecycleService{
-		metadata: meta,
-		omr:      omr,
+		metadata:    meta,
+		omr:         omr,
+		internalTLS: pub.NewClientTLS(),
 	}
 }
 
@@ -80,14 +85,17 @@ func (l *lifecycleService) FlagSet() *run.FlagSet {
 	flagS.BoolVar(&l.enableTLS, "enable-tls", false, "Enable TLS for gRPC connection")
 	flagS.BoolVar(&l.insecure, "insecure", false, "Skip server certificate verification")
 	flagS.StringVar(&l.cert, "cert", "", "Path to the gRPC server certificate")
+	flagS.StringVar(&l.clientCert, "client-cert", "", "Path to the client certificate presented to the data node which verifies the clients")
+	flagS.StringVar(&l.clientKey, "client-key", "", "Path to the key of the client certificate")
 	flagS.StringVar(&l.streamRoot, "stream-root-path", "/tmp", "Root directory for stream catalog")
 	flagS.StringVar(&l.measureRoot, "measure-root-path", "/tmp", "Root directory for measure catalog")
 	flagS.StringVar(&l.progressFilePath, "progress-file", "/tmp/lifecycle-progress.json", "Path to store progress for crash recovery")
+	flagS.AddFlagSet(l.internalTLS.FlagSet().FlagSet)
 	return flagS
 }
 
 func (l *lifecycleService) Validate() error {
-	return nil
+	return l.internalTLS.Validate()
 }
 
 func (l *lifecycleService) GracefulStop() {
@@ -195,7 +203,7 @@ func (l *lifecycleService) getGroupsToProcess(ctx context.Context, progress *Pro
 func (l *lifecycleService) processStreamGroup(ctx context.Context, g *commonv1.Group, streamSVC stream.Service,
 	nodes []*databasev1.Node, labels map[string]string, progress *Progress,
 ) {
-	shardNum, selector, client, err := parseGroup(ctx, g, labels, nodes, l.l, l.metadata)
+	shardNum, selector, client, err := parseGroup(ctx, g, labels, nodes, l.l, l.metadata, l.internalTLS)
 	if err != nil {
 		l.l.Error().Err(err).Msgf("failed to parse group %s", g.Metadata.Name)
 		return
@@ -277,7 +285,7 @@ func (l *lifecycleService) deleteExpiredStreamSegments(ctx context.Context, g *c
 		return
 	}
 
-	resp, err := snapshot.Conn(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, func(conn *grpc.ClientConn) (*streamv1.DeleteExpiredSegmentsResponse, error) {
+	resp, err := snapshot.Conn(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, l.clientCert, l.clientKey, func(conn *grpc.ClientConn) (*streamv1.DeleteExpiredSegmentsResponse, error) {
 		client := streamv1.NewStreamServiceClient(conn)
 		return client.DeleteExpiredSegments(ctx, &streamv1.DeleteExpiredSegmentsRequest{
 			Group: g.Metadata.Name,
@@ -300,7 +308,7 @@ func (l *lifecycleService) deleteExpiredStreamSegments(ctx context.Context, g *c
 func (l *lifecycleService) processMeasureGroup(ctx context.Context, g *commonv1.Group, measureSVC measure.Service,
 	nodes []*databasev1.Node, labels map[string]string, progress *Progress,
 ) {
-	shardNum, selector, client, err := parseGroup(ctx, g, labels, nodes, l.l, l.metadata)
+	shardNum, selector, client, err := parseGroup(ctx, g, labels, nodes, l.l, l.metadata, l.internalTLS)
 	if err != nil {
 		l.l.Error().Err(err).Msgf("failed to parse group %s", g.Metadata.Name)
 		return
@@ -387,7 +395,7 @@ func (l *lifecycleService) deleteExpiredMeasureSegments(ctx context.Context, g *
 		return
 	}
 
-	resp, err := snapshot.Conn(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, func(conn *grpc.ClientConn) (*measurev1.DeleteExpiredSegmentsResponse, error) {
+	resp, err := snapshot.Conn(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, l.clientCert, l.clientKey, func(conn *grpc.ClientConn) (*measurev1.DeleteExpiredSegmentsResponse, error) {
 		client := measurev1.NewMeasureServiceClient(conn)
 		return client.DeleteExpiredSegments(ctx, &measurev1.DeleteExpiredSegmentsRequest{
 			Group: g.Metadata.Name,
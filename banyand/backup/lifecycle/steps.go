@@ -59,7 +59,7 @@ func (l *lifecycleService) getSnapshots(groups []*commonv1.Group) (streamDir str
 			Catalog: group.Catalog,
 		})
 	}
-	snn, err := snapshot.Get(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, snapshotGroups...)
+	snn, err := snapshot.Get(l.gRPCAddr, l.enableTLS, l.insecure, l.cert, l.clientCert, l.clientKey, snapshotGroups...)
 	if err != nil {
 		return "", "", err
 	}
@@ -110,7 +110,7 @@ func (l *lifecycleService) setupQuerySvc(ctx context.Context, streamDir, measure
 }
 
 func parseGroup(ctx context.Context, g *commonv1.Group, nodeLabels map[string]string, nodes []*databasev1.Node,
-	l *logger.Logger, metadata metadata.Repo,
+	l *logger.Logger, metadata metadata.Repo, clientTLS *pub.ClientTLS,
 ) (uint32, node.Selector, queue.Client, error) {
 	ro := g.ResourceOpts
 	if ro == nil {
@@ -147,7 +147,10 @@ func parseGroup(ctx context.Context, g *commonv1.Group, nodeLabels map[string]st
 	if err = nodeSel.PreRun(ctx); err != nil {
 		return 0, nil, nil, errors.WithMessage(err, "failed to run node selector")
 	}
-	client := pub.NewWithoutMetadata()
+	client, err := pub.NewWithTLS(clientTLS)
+	if err != nil {
+		return 0, nil, nil, errors.WithMessage(err, "failed to create the queue client")
+	}
 	if g.Catalog == commonv1.Catalog_CATALOG_STREAM {
 		_ = grpc.NewClusterNodeRegistry(data.TopicStreamWrite, client, nodeSel)
 	} else {
@@ -170,6 +173,7 @@ func parseGroup(ctx context.Context, g *commonv1.Group, nodeLabels map[string]st
 		}
 	}
 	if !existed {
+		client.GracefulStop()
 		return 0, nil, nil, errors.New("no nodes matched")
 	}
 	return nst.ShardNum, nodeSel, client, nil
@@ -591,7 +591,7 @@ func TestParseGroup(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := metadata.NewMockRepo(ctrl)
 			mockRepo.EXPECT().RegisterHandler("", schema.KindGroup, gomock.Any()).MaxTimes(1)
-			shardNum, selector, client, err := parseGroup(context.Background(), tt.group, tt.nodeLabels, tt.nodes, l, mockRepo)
+			shardNum, selector, client, err := parseGroup(context.Background(), tt.group, tt.nodeLabels, tt.nodes, l, mockRepo, nil)
 
 			if tt.expectError {
 				require.Error(t, err)
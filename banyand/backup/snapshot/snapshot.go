@@ -20,12 +20,14 @@ package snapshot
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"path/filepath"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
@@ -35,8 +37,12 @@ import (
 )
 
 // Conn connects to the gRPC server and executes the given function.
-func Conn[T any](gRPCAddr string, enableTLS, insecure bool, cert string, delFn func(conn *grpc.ClientConn) (T, error)) (T, error) {
-	opts, err := grpchelper.SecureOptions(nil, enableTLS, insecure, cert)
+// The client certificate is presented to the server if clientCert and clientKey are set, which is required
+// if the data node verifies the clients.
+func Conn[T any](gRPCAddr string, enableTLS, insecure bool, cert, clientCert, clientKey string,
+	delFn func(conn *grpc.ClientConn) (T, error),
+) (T, error) {
+	opts, err := secureOptions(enableTLS, insecure, cert, clientCert, clientKey)
 	if err != nil {
 		var zero T
 		return zero, err
@@ -50,9 +56,27 @@ func Conn[T any](gRPCAddr string, enableTLS, insecure bool, cert string, delFn f
 	return delFn(connection)
 }
 
+func secureOptions(enableTLS, insecure bool, cert, clientCert, clientKey string) ([]grpc.DialOption, error) {
+	if !enableTLS || clientCert == "" {
+		return grpchelper.SecureOptions(nil, enableTLS, insecure, cert)
+	}
+	config, err := grpchelper.TLSConfig(insecure, cert)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+	}
+	config.Certificates = []tls.Certificate{certificate}
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(config))}, nil
+}
+
 // Get retrieves the snapshots from the gRPC server.
-func Get(gRPCAddr string, enableTLS, insecure bool, cert string, groups ...*databasev1.SnapshotRequest_Group) ([]*databasev1.Snapshot, error) {
-	return Conn(gRPCAddr, enableTLS, insecure, cert, func(conn *grpc.ClientConn) ([]*databasev1.Snapshot, error) {
+func Get(gRPCAddr string, enableTLS, insecure bool, cert, clientCert, clientKey string,
+	groups ...*databasev1.SnapshotRequest_Group,
+) ([]*databasev1.Snapshot, error) {
+	return Conn(gRPCAddr, enableTLS, insecure, cert, clientCert, clientKey, func(conn *grpc.ClientConn) ([]*databasev1.Snapshot, error) {
 		ctx := context.Background()
 		client := databasev1.NewSnapshotServiceClient(conn)
 		snapshotResp, err := client.Snapshot(ctx, &databasev1.SnapshotRequest{Groups: groups})
@@ -38,6 +38,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/slowquery"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/schema"
 )
@@ -63,9 +64,15 @@ type queryService struct {
 	mqp                  *measureQueryProcessor
 	tqp                  *topNQueryProcessor
 	closer               *run.Closer
+	slowQueryLog         *slowquery.Log
+	slqp                 *slowQueryListProcessor
 	nodeID               string
 	hotStageNodeSelector string
+	slowQueryLogRootPath string
 	slowQuery            time.Duration
+	memoryBudget         run.Bytes
+	slowQueryLogSize     int
+	slowQuerySampleRate  float64
 }
 
 // NewService return a new query service.
@@ -89,6 +96,10 @@ func NewService(metaService metadata.Repo, pipeline queue.Server, broadcaster bu
 		queryService: svc,
 		broadcaster:  broadcaster,
 	}
+	svc.slqp = &slowQueryListProcessor{
+		queryService: svc,
+		broadcaster:  broadcaster,
+	}
 	return svc, nil
 }
 
@@ -99,10 +110,22 @@ func (q *queryService) Name() string {
 func (q *queryService) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("distributed-query")
 	fs.DurationVar(&q.slowQuery, "dst-slow-query", 5*time.Second, "distributed slow query threshold, 0 means no slow query log")
+	fs.VarP(&q.memoryBudget, "dst-query-memory-budget", "", "the max memory a distributed query can reserve to merge the responses of data nodes, 0 means no limit")
+	fs.StringVar(&q.slowQueryLogRootPath, "dst-slow-query-log-root-path", "",
+		"the root path of the distributed slow query log files, empty means no slow query log files")
+	fs.IntVar(&q.slowQueryLogSize, "dst-slow-query-log-size", 100, "the number of the recent distributed slow queries kept in memory")
+	fs.Float64Var(&q.slowQuerySampleRate, "dst-slow-query-trace-sample-rate", 0,
+		"the ratio of the distributed queries to collect traces for the slow query log, in [0, 1]")
 	return fs
 }
 
 func (q *queryService) Validate() error {
+	if q.slowQueryLogSize <= 0 {
+		return errors.New("dst-slow-query-log-size should be positive")
+	}
+	if q.slowQuerySampleRate < 0 || q.slowQuerySampleRate > 1 {
+		return errors.New("dst-slow-query-trace-sample-rate should be in [0, 1]")
+	}
 	return nil
 }
 
@@ -124,16 +147,29 @@ func (q *queryService) PreRun(ctx context.Context) error {
 	q.mqp.measureService = measure.NewPortableRepository(q.metaService, q.log,
 		schema.NewMetrics(q.omr.With(measureScope)))
 	q.tqp.measureService = q.mqp.measureService
+	var err error
+	if q.slowQueryLog, err = slowquery.New(q.nodeID, slowquery.Options{
+		RootPath:        q.slowQueryLogRootPath,
+		Threshold:       q.slowQuery,
+		Size:            q.slowQueryLogSize,
+		TraceSampleRate: q.slowQuerySampleRate,
+	}, q.log.Named("slow-query")); err != nil {
+		return err
+	}
 	return multierr.Combine(
 		q.pipeline.Subscribe(data.TopicStreamQuery, q.sqp),
 		q.pipeline.Subscribe(data.TopicMeasureQuery, q.mqp),
 		q.pipeline.Subscribe(data.TopicTopNQuery, q.tqp),
+		q.pipeline.Subscribe(data.TopicSlowQueryList, q.slqp),
 	)
 }
 
 func (q *queryService) GracefulStop() {
 	q.sqp.streamService.Close()
 	q.mqp.measureService.Close()
+	if err := q.slowQueryLog.Close(); err != nil {
+		q.log.Error().Err(err).Msg("failed to close the slow query log")
+	}
 	q.closer.Done()
 	q.closer.CloseThenWait()
 }
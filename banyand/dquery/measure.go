@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/dustin/go-humanize"
+
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
@@ -104,21 +106,33 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("no stage found"))
 		return
 	}
+	budget := executor.NewMemoryBudget(uint64(p.memoryBudget))
+	ctx = executor.WithMemoryBudget(ctx, budget)
+	stats := &executor.QueryStats{}
+	ctx = executor.WithQueryStats(ctx, stats)
 	var tracer *query.Tracer
 	var span *query.Span
-	if queryCriteria.Trace {
+	defer func() {
+		p.slowQueryLog.RecordQuery(commonv1.Catalog_CATALOG_MEASURE, queryCriteria, n, stats, tracer, resp)
+	}()
+	if queryCriteria.Trace || p.slowQueryLog.Sampled() {
 		tracer, ctx = query.NewTracer(ctx, n.Format(time.RFC3339Nano))
 		span, ctx = tracer.StartSpan(ctx, "distributed-%s", p.queryService.nodeID)
 		span.Tag("plan", plan.String())
 		span.Tagf("nodeSelectors", "%v", nodeSelectors)
 		defer func() {
+			span.Tag("peak_memory", humanize.Bytes(budget.Peak()))
 			data := resp.Data()
 			switch d := data.(type) {
 			case *measurev1.QueryResponse:
-				d.Trace = tracer.ToProto()
+				if queryCriteria.Trace {
+					d.Trace = tracer.ToProto()
+				}
 			case *common.Error:
 				span.Error(errors.New(d.Error()))
-				resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Trace: tracer.ToProto()})
+				if queryCriteria.Trace {
+					resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Trace: tracer.ToProto()})
+				}
 			default:
 				panic("unexpected data type")
 			}
@@ -133,7 +147,7 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 	}))
 	if err != nil {
 		ml.Error().Err(err).Dur("latency", time.Since(n)).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to query")
-		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the query plan for measure %s: %v", meta.GetName(), err))
+		resp = bus.NewMessage(bus.MessageID(now), executor.NewExecutionError(err, "fail to execute the query plan for measure %s: %v", meta.GetName(), err))
 		return
 	}
 	defer func() {
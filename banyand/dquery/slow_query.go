@@ -0,0 +1,67 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/query/slowquery"
+)
+
+const defaultSlowQueryListTimeout = 10 * time.Second
+
+var _ bus.MessageListener = (*slowQueryListProcessor)(nil)
+
+// slowQueryListProcessor merges the slow queries of the liaison and the data nodes.
+type slowQueryListProcessor struct {
+	*queryService
+	broadcaster bus.Broadcaster
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *slowQueryListProcessor) Rev(_ context.Context, message bus.Message) (resp bus.Message) {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*databasev1.SlowQueryServiceListRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+	}
+	limit := int(req.Limit)
+	lists := [][]*databasev1.SlowQuery{p.slowQueryLog.List(limit)}
+	ff, err := p.broadcaster.Broadcast(defaultSlowQueryListTimeout, data.TopicSlowQueryList, bus.NewMessage(bus.MessageID(now), req))
+	if err != nil {
+		p.log.Warn().Err(err).Msg("fail to list the slow queries of data nodes")
+	}
+	for _, f := range ff {
+		m, getErr := f.Get()
+		if getErr != nil {
+			p.log.Warn().Err(getErr).Msg("fail to list the slow queries of a data node")
+			continue
+		}
+		if d, ok := m.Data().(*databasev1.SlowQueryServiceListResponse); ok {
+			lists = append(lists, d.SlowQueries)
+		}
+	}
+	return bus.NewMessage(bus.MessageID(now), &databasev1.SlowQueryServiceListResponse{
+		SlowQueries: slowquery.Merge(limit, lists...),
+	})
+}
@@ -22,6 +22,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/dustin/go-humanize"
+
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
@@ -100,21 +102,33 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("no stage found"))
 		return
 	}
-	if queryCriteria.Trace {
-		var tracer *query.Tracer
+	budget := executor.NewMemoryBudget(uint64(p.memoryBudget))
+	ctx = executor.WithMemoryBudget(ctx, budget)
+	stats := &executor.QueryStats{}
+	ctx = executor.WithQueryStats(ctx, stats)
+	var tracer *query.Tracer
+	defer func() {
+		p.slowQueryLog.RecordQuery(commonv1.Catalog_CATALOG_STREAM, queryCriteria, n, stats, tracer, resp)
+	}()
+	if queryCriteria.Trace || p.slowQueryLog.Sampled() {
 		var span *query.Span
 		tracer, ctx = query.NewTracer(ctx, n.Format(time.RFC3339Nano))
 		span, ctx = tracer.StartSpan(ctx, "distributed-%s", p.queryService.nodeID)
 		span.Tag("plan", plan.String())
 		span.Tagf("nodeSelectors", "%v", nodeSelectors)
 		defer func() {
+			span.Tag("peak_memory", humanize.Bytes(budget.Peak()))
 			data := resp.Data()
 			switch d := data.(type) {
 			case *streamv1.QueryResponse:
-				d.Trace = tracer.ToProto()
+				if queryCriteria.Trace {
+					d.Trace = tracer.ToProto()
+				}
 			case *common.Error:
 				span.Error(errors.New(d.Error()))
-				resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Trace: tracer.ToProto()})
+				if queryCriteria.Trace {
+					resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Trace: tracer.ToProto()})
+				}
 			default:
 				panic("unexpected data type")
 			}
@@ -130,11 +144,19 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 	}))
 	if err != nil {
 		p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
-		resp = bus.NewMessage(bus.MessageID(now), common.NewError("execute the query plan for stream %s: %v", meta.GetName(), err))
+		resp = bus.NewMessage(bus.MessageID(now), executor.NewExecutionError(err, "execute the query plan for stream %s: %v", meta.GetName(), err))
 		return
 	}
 
-	resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Elements: entities})
+	var token []byte
+	if pg, ok := plan.(logical_stream.Paginator); ok {
+		if token, err = pg.ContinuationToken(); err != nil {
+			p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to build the continuation token")
+			resp = bus.NewMessage(bus.MessageID(now), common.NewError("build the continuation token for stream %s: %v", meta.GetName(), err))
+			return
+		}
+	}
+	resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Elements: entities, ContinuationToken: token})
 	if !queryCriteria.Trace && p.slowQuery > 0 {
 		latency := time.Since(n)
 		if latency > p.slowQuery {
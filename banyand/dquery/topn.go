@@ -20,12 +20,14 @@ package dquery
 import (
 	"context"
 	"errors"
+	"strconv"
 	"time"
 
 	"go.uber.org/multierr"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
@@ -87,20 +89,31 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 		resp = bus.NewMessage(now, common.NewError("no stage found"))
 		return
 	}
-	if request.Trace {
-		var tracer *pkgquery.Tracer
+	var tracer *pkgquery.Tracer
+	var span *pkgquery.Span
+	defer func() {
+		t.slowQueryLog.RecordQuery(commonv1.Catalog_CATALOG_MEASURE, request, n, nil, tracer, resp)
+	}()
+	traced := request.Trace
+	if traced || t.slowQueryLog.Sampled() {
 		tracer, ctx = pkgquery.NewTracer(ctx, n.Format(time.RFC3339Nano))
-		span, _ := tracer.StartSpan(ctx, "distributed-client")
+		span, _ = tracer.StartSpan(ctx, "distributed-client")
 		span.Tag("request", convert.BytesToString(logger.Proto(request)))
 		span.Tagf("nodeSelectors", "%v", nodeSelectors)
+		// The data nodes respond their traces which are merged into the sampled one.
+		request.Trace = true
 		defer func() {
 			data := resp.Data()
 			switch d := data.(type) {
 			case *measurev1.TopNResponse:
-				d.Trace = tracer.ToProto()
+				if traced {
+					d.Trace = tracer.ToProto()
+				}
 			case *common.Error:
 				span.Error(errors.New(d.Error()))
-				resp = bus.NewMessage(now, &measurev1.TopNResponse{Trace: tracer.ToProto()})
+				if traced {
+					resp = bus.NewMessage(now, &measurev1.TopNResponse{Trace: tracer.ToProto()})
+				}
 			default:
 				panic("unexpected data type")
 			}
@@ -117,7 +130,7 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 	var allErr error
 	aggregator := query.CreateTopNPostAggregator(request.GetTopN(),
 		agg, request.GetFieldValueSort())
-	var tags []string
+	merger := newTopNMerger(aggregator)
 	for _, f := range ff {
 		if m, getErr := f.Get(); getErr != nil {
 			allErr = multierr.Append(allErr, getErr)
@@ -127,36 +140,25 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 				continue
 			}
 			topNResp := d.(*measurev1.TopNResponse)
-			for _, l := range topNResp.Lists {
-				for _, tn := range l.Items {
-					if tags == nil {
-						tags = make([]string, 0, len(tn.Entity))
-						for _, e := range tn.Entity {
-							tags = append(tags, e.Key)
-						}
-					}
-					entityValues := make(pbv1.EntityValues, 0, len(tn.Entity))
-					for _, e := range tn.Entity {
-						entityValues = append(entityValues, e.Value)
-					}
-					_ = aggregator.Put(entityValues, tn.Value.GetInt().GetValue(), uint64(l.Timestamp.AsTime().UnixMilli()))
-				}
+			if span != nil {
+				span.AddSubTrace(topNResp.Trace)
 			}
+			merger.put(topNResp.Lists)
 		}
 	}
 	if allErr != nil {
 		resp = bus.NewMessage(now, common.NewError("execute the query %s: %v", request.GetName(), allErr))
 		return
 	}
-	if tags == nil {
+	if merger.tags == nil {
 		resp = bus.NewMessage(now, &measurev1.TopNResponse{})
 		return
 	}
-	lists := aggregator.Val(tags)
+	lists := aggregator.Val(merger.tags)
 	resp = bus.NewMessage(now, &measurev1.TopNResponse{
 		Lists: lists,
 	})
-	if !request.Trace && t.slowQuery > 0 {
+	if !traced && t.slowQuery > 0 {
 		latency := time.Since(n)
 		if latency > t.slowQuery {
 			t.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(request)).Int("resp_count", len(lists)).Msg("top_n slow query")
@@ -165,6 +167,45 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 	return
 }
 
+// topNMerger puts the items responded by the data nodes into the aggregator.
+// The replicas of a shard respond the same items, which are put only once.
+type topNMerger struct {
+	aggregator query.PostProcessor
+	seen       map[string]struct{}
+	tags       []string
+}
+
+func newTopNMerger(aggregator query.PostProcessor) *topNMerger {
+	return &topNMerger{
+		aggregator: aggregator,
+		seen:       make(map[string]struct{}),
+	}
+}
+
+func (m *topNMerger) put(lists []*measurev1.TopNList) {
+	for _, l := range lists {
+		ts := uint64(l.Timestamp.AsTime().UnixMilli())
+		for _, tn := range l.Items {
+			if m.tags == nil {
+				m.tags = make([]string, 0, len(tn.Entity))
+				for _, e := range tn.Entity {
+					m.tags = append(m.tags, e.Key)
+				}
+			}
+			entityValues := make(pbv1.EntityValues, 0, len(tn.Entity))
+			for _, e := range tn.Entity {
+				entityValues = append(entityValues, e.Value)
+			}
+			key := strconv.FormatUint(ts, 10) + "|" + entityValues.String()
+			if _, ok := m.seen[key]; ok {
+				continue
+			}
+			m.seen[key] = struct{}{}
+			_ = m.aggregator.Put(entityValues, tn.Value.GetInt().GetValue(), ts)
+		}
+	}
+}
+
 var _ sort.Comparable = (*comparableTopNItem)(nil)
 
 type comparableTopNItem struct {
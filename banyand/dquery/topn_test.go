@@ -0,0 +1,123 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/query"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query/slowquery"
+)
+
+func topNItem(service string, value int64) *measurev1.TopNList_Item {
+	return &measurev1.TopNList_Item{
+		Entity: []*modelv1.Tag{{Key: "service", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: service}}}}},
+		Value:  &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: value}}},
+	}
+}
+
+func TestTopNMergerDeduplicateReplicas(t *testing.T) {
+	t1, t2 := timestamppb.New(time.UnixMilli(60_000)), timestamppb.New(time.UnixMilli(120_000))
+	merger := newTopNMerger(query.CreateTopNPostAggregator(10,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, modelv1.Sort_SORT_DESC))
+	// Both nodes hold the replicas of the shard of "a", and "b" and "c" live in the other shards.
+	merger.put([]*measurev1.TopNList{
+		{Timestamp: t1, Items: []*measurev1.TopNList_Item{topNItem("a", 10), topNItem("b", 5)}},
+		{Timestamp: t2, Items: []*measurev1.TopNList_Item{topNItem("a", 20)}},
+	})
+	merger.put([]*measurev1.TopNList{
+		{Timestamp: t1, Items: []*measurev1.TopNList_Item{topNItem("a", 10), topNItem("c", 8)}},
+		{Timestamp: t2, Items: []*measurev1.TopNList_Item{topNItem("a", 20), topNItem("c", 1)}},
+	})
+	require.Equal(t, []string{"service"}, merger.tags)
+	lists := merger.aggregator.Val(merger.tags)
+	require.Len(t, lists, 1)
+	got := make(map[string]int64)
+	for _, item := range lists[0].Items {
+		got[item.Entity[0].Value.GetStr().GetValue()] = item.Value.GetInt().GetValue()
+	}
+	assert.Equal(t, map[string]int64{"a": 30, "b": 5, "c": 9}, got)
+}
+
+type topNFuture struct {
+	resp *measurev1.TopNResponse
+}
+
+func (f topNFuture) Get() (bus.Message, error) {
+	return bus.NewMessage(bus.MessageID(0), f.resp), nil
+}
+
+func (f topNFuture) GetAll() ([]bus.Message, error) {
+	m, err := f.Get()
+	return []bus.Message{m}, err
+}
+
+// traceBroadcaster responds a trace if the data node is asked for it.
+type traceBroadcaster struct {
+	traced bool
+}
+
+func (b *traceBroadcaster) Broadcast(_ time.Duration, _ bus.Topic, message bus.Message) ([]bus.Future, error) {
+	b.traced = message.Data().(*measurev1.TopNRequest).Trace
+	resp := &measurev1.TopNResponse{Lists: []*measurev1.TopNList{
+		{Timestamp: timestamppb.New(time.UnixMilli(60_000)), Items: []*measurev1.TopNList_Item{topNItem("a", 10)}},
+	}}
+	if b.traced {
+		resp.Trace = &commonv1.Trace{Spans: []*commonv1.Span{{Message: "data-node-1"}}}
+	}
+	return []bus.Future{topNFuture{resp: resp}}, nil
+}
+
+func TestTopNSampledTrace(t *testing.T) {
+	l := logger.GetLogger("test")
+	sl, err := slowquery.New("liaison", slowquery.Options{Threshold: time.Nanosecond, Size: 1, TraceSampleRate: 1}, l)
+	require.NoError(t, err)
+	b := &traceBroadcaster{}
+	p := &topNQueryProcessor{broadcaster: b, queryService: &queryService{log: l, slowQueryLog: sl}}
+	resp := p.Rev(context.Background(), bus.NewMessage(bus.MessageID(1), &measurev1.TopNRequest{
+		Name:           "service_cpm_minute_top_bottom_100",
+		TimeRange:      &modelv1.TimeRange{Begin: timestamppb.New(time.UnixMilli(0)), End: timestamppb.New(time.UnixMilli(120_000))},
+		TopN:           10,
+		Agg:            modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+		FieldValueSort: modelv1.Sort_SORT_DESC,
+	}))
+
+	// The sampled trace asks the data nodes for their traces, but it's not returned to the client.
+	assert.True(t, b.traced)
+	topNResp := resp.Data().(*measurev1.TopNResponse)
+	require.Len(t, topNResp.Lists, 1)
+	assert.Nil(t, topNResp.Trace)
+	list := sl.List(0)
+	require.Len(t, list, 1)
+	require.Len(t, list[0].Trace.GetSpans(), 1)
+	root := list[0].Trace.Spans[0]
+	assert.Equal(t, "distributed-client", root.Message)
+	require.Len(t, root.Children, 1)
+	assert.Equal(t, "data-node-1", root.Children[0].Message)
+}
@@ -0,0 +1,42 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirSize(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "shard-0", "seg-20250101"), DirPerm))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "shard-0", "seg-20250101", "part"), make([]byte, 100), FilePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "metadata"), make([]byte, 20), FilePerm))
+
+	size, err := DirSize(root)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(120), size)
+
+	size, err = DirSize(filepath.Join(root, "absent"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), size)
+}
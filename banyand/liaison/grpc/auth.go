@@ -0,0 +1,315 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"strings"
+
+	"github.com/pkg/errors"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+)
+
+// publicMethods are called without any credential.
+var publicMethods = map[string]struct{}{
+	"/banyandb.common.v1.Service/GetAPIVersion": {},
+	"/grpc.health.v1.Health/Check":              {},
+	"/grpc.health.v1.Health/Watch":              {},
+}
+
+// serviceCatalogs are the catalogs of the data accessed by the services.
+// The other services access the data of any catalog, or operate the cluster.
+var serviceCatalogs = map[string]string{
+	"banyandb.stream.v1.StreamService":                    auth.CatalogStream,
+	"banyandb.measure.v1.MeasureService":                  auth.CatalogMeasure,
+	"banyandb.property.v1.PropertyService":                auth.CatalogProperty,
+	"banyandb.database.v1.StreamRegistryService":          auth.CatalogStream,
+	"banyandb.database.v1.MeasureRegistryService":         auth.CatalogMeasure,
+	"banyandb.database.v1.TopNAggregationRegistryService": auth.CatalogMeasure,
+	"banyandb.database.v1.PropertyRegistryService":        auth.CatalogProperty,
+}
+
+var dataServices = map[string]struct{}{
+	"banyandb.stream.v1.StreamService":     {},
+	"banyandb.measure.v1.MeasureService":   {},
+	"banyandb.property.v1.PropertyService": {},
+}
+
+var readMethodPrefixes = []string{"Get", "List", "Exist", "Query", "TopN", "Check"}
+
+// filteredMethods return the results filtered by the tenant of the caller.
+var filteredMethods = map[string]struct{}{
+	"/banyandb.database.v1.GroupRegistryService/List": {},
+}
+
+func serviceOf(fullMethod string) string {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return service
+}
+
+// isDataMethod returns whether the method queries or writes the data.
+func isDataMethod(fullMethod string) bool {
+	_, ok := dataServices[serviceOf(fullMethod)]
+	return ok
+}
+
+// accessOf returns the access of the call. The groups are read from the request.
+func accessOf(fullMethod string, req proto.Message) auth.Access {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	a := auth.Access{
+		Verb:    auth.VerbSchemaAdmin,
+		Catalog: serviceCatalogs[service],
+	}
+	for _, p := range readMethodPrefixes {
+		if strings.HasPrefix(method, p) {
+			a.Verb = auth.VerbRead
+			break
+		}
+	}
+	if _, ok := dataServices[service]; ok && a.Verb != auth.VerbRead {
+		// Writing, applying and deleting the data, including the expired segments.
+		a.Verb = auth.VerbWrite
+	}
+	_, a.Filtered = filteredMethods[fullMethod]
+	if req != nil {
+		a.Groups = groupsOf(req.ProtoReflect(), 0)
+	}
+	return a
+}
+
+const maxGroupDepth = 2
+
+// groupsOf finds the groups in the fields named "groups" or "group", or the group of the "metadata".
+// It looks into the nested messages, such as the schema of a created resource, if there is no such field.
+func groupsOf(m protoreflect.Message, depth int) []string {
+	fields := m.Descriptor().Fields()
+	if f := fields.ByName("groups"); f != nil && f.IsList() && f.Kind() == protoreflect.StringKind {
+		list := m.Get(f).List()
+		groups := make([]string, list.Len())
+		for i := range groups {
+			groups[i] = list.Get(i).String()
+		}
+		return groups
+	}
+	if f := fields.ByName("group"); f != nil && !f.IsList() {
+		switch f.Kind() {
+		case protoreflect.StringKind:
+			if g := m.Get(f).String(); g != "" {
+				return []string{g}
+			}
+		case protoreflect.MessageKind:
+			// A group is named by its metadata.
+			if m.Has(f) {
+				if name := stringField(m.Get(f).Message(), "metadata", "name"); name != "" {
+					return []string{name}
+				}
+			}
+		default:
+		}
+	}
+	if g := stringField(m, "metadata", "group"); g != "" {
+		return []string{g}
+	}
+	if depth >= maxGroupDepth {
+		return nil
+	}
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if f.Kind() != protoreflect.MessageKind || f.IsList() || f.IsMap() || !m.Has(f) {
+			continue
+		}
+		if groups := groupsOf(m.Get(f).Message(), depth+1); len(groups) > 0 {
+			return groups
+		}
+	}
+	return nil
+}
+
+func stringField(m protoreflect.Message, message, name string) string {
+	f := m.Descriptor().Fields().ByName(protoreflect.Name(message))
+	if f == nil || f.Kind() != protoreflect.MessageKind || f.IsList() || !m.Has(f) {
+		return ""
+	}
+	sub := m.Get(f).Message()
+	sf := sub.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if sf == nil || sf.Kind() != protoreflect.StringKind || sf.IsList() {
+		return ""
+	}
+	return sub.Get(sf).String()
+}
+
+// authenticate returns the user of the call, which is either authenticated by the HTTP gateway,
+// or identified by the authorization metadata or the client certificate.
+func (s *server) authenticate(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if key := firstValue(md, auth.GatewayKeyHeader); key != "" {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(s.auth.GatewayKey())) != 1 {
+			return "", errors.WithMessage(auth.ErrUnauthenticated, "invalid gateway key")
+		}
+		return firstValue(md, auth.GatewayUserHeader), nil
+	}
+	var chains [][]*x509.Certificate
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, isTLS := p.AuthInfo.(credentials.TLSInfo); isTLS {
+			chains = info.State.VerifiedChains
+		}
+	}
+	return s.auth.Authenticate(firstValue(md, "authorization"), chains)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if vv := md.Get(key); len(vv) > 0 {
+		return vv[0]
+	}
+	return ""
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+func (s *server) authorize(ctx context.Context, fullMethod, user string, req proto.Message) (auth.Access, error) {
+	a := accessOf(fullMethod, req)
+	if err := s.auth.Authorize(user, a); err != nil {
+		s.auth.Audit(fullMethod, user, peerAddr(ctx), a, err)
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			return a, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return a, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return a, nil
+}
+
+func (s *server) authUnaryInterceptor(ctx context.Context, req any, info *grpclib.UnaryServerInfo,
+	handler grpclib.UnaryHandler,
+) (any, error) {
+	if !s.auth.Enabled() {
+		return handler(ctx, req)
+	}
+	if _, ok := publicMethods[info.FullMethod]; ok {
+		return handler(ctx, req)
+	}
+	user, err := s.authenticate(ctx)
+	if err != nil {
+		s.auth.Audit(info.FullMethod, "", peerAddr(ctx), accessOf(info.FullMethod, nil), err)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	msg, _ := req.(proto.Message)
+	a, err := s.authorize(ctx, info.FullMethod, user, msg)
+	if err != nil {
+		return nil, err
+	}
+	tl := s.tenants.of(user)
+	if tl == nil {
+		return handler(ctx, req)
+	}
+	ctx = withTenant(ctx, tl)
+	if isDataMethod(info.FullMethod) {
+		switch a.Verb {
+		case auth.VerbRead:
+			release, errAcquire := tl.acquireQuery()
+			if errAcquire != nil {
+				return nil, errAcquire
+			}
+			defer release()
+		case auth.VerbWrite:
+			if err = tl.waitWrite(ctx); err != nil {
+				return nil, err
+			}
+		default:
+		}
+	}
+	resp, err := handler(ctx, req)
+	if err == nil {
+		tl.filter(resp)
+	}
+	return resp, err
+}
+
+func (s *server) authStreamInterceptor(srv any, ss grpclib.ServerStream, info *grpclib.StreamServerInfo,
+	handler grpclib.StreamHandler,
+) error {
+	if !s.auth.Enabled() {
+		return handler(srv, ss)
+	}
+	if _, ok := publicMethods[info.FullMethod]; ok {
+		return handler(srv, ss)
+	}
+	ctx := ss.Context()
+	user, err := s.authenticate(ctx)
+	if err != nil {
+		s.auth.Audit(info.FullMethod, "", peerAddr(ctx), accessOf(info.FullMethod, nil), err)
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	tl := s.tenants.of(user)
+	if tl != nil {
+		ctx = withTenant(ctx, tl)
+		if isDataMethod(info.FullMethod) && accessOf(info.FullMethod, nil).Verb == auth.VerbRead {
+			release, errAcquire := tl.acquireQuery()
+			if errAcquire != nil {
+				return errAcquire
+			}
+			defer release()
+		}
+	}
+	return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx, s: s, method: info.FullMethod, user: user, tenant: tl})
+}
+
+// authorizedStream authorizes every received message, since the messages of a stream might touch different groups.
+// The writes of a tenant are throttled to the write rate of the tenant.
+type authorizedStream struct {
+	grpclib.ServerStream
+	ctx    context.Context
+	s      *server
+	tenant *tenantLimiter
+	method string
+	user   string
+}
+
+func (as *authorizedStream) Context() context.Context {
+	return as.ctx
+}
+
+func (as *authorizedStream) RecvMsg(m any) error {
+	if err := as.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	msg, _ := m.(proto.Message)
+	a, err := as.s.authorize(as.ctx, as.method, as.user, msg)
+	if err != nil {
+		return err
+	}
+	if as.tenant != nil && a.Verb == auth.VerbWrite && isDataMethod(as.method) {
+		return as.tenant.waitWrite(as.ctx)
+	}
+	return nil
+}
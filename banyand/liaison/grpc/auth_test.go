@@ -0,0 +1,98 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+)
+
+func TestAccessOf(t *testing.T) {
+	tests := []struct {
+		req    proto.Message
+		name   string
+		method string
+		want   auth.Access
+	}{
+		{
+			name:   "query",
+			method: "/banyandb.measure.v1.MeasureService/Query",
+			req:    &measurev1.QueryRequest{Groups: []string{"g1", "g2"}},
+			want:   auth.Access{Verb: auth.VerbRead, Catalog: auth.CatalogMeasure, Groups: []string{"g1", "g2"}},
+		},
+		{
+			name:   "write",
+			method: "/banyandb.measure.v1.MeasureService/Write",
+			req:    &measurev1.WriteRequest{Metadata: &commonv1.Metadata{Group: "g1", Name: "m"}},
+			want:   auth.Access{Verb: auth.VerbWrite, Catalog: auth.CatalogMeasure, Groups: []string{"g1"}},
+		},
+		{
+			name:   "apply a property",
+			method: "/banyandb.property.v1.PropertyService/Apply",
+			req:    &propertyv1.ApplyRequest{Property: &propertyv1.Property{Metadata: &commonv1.Metadata{Group: "g1"}}},
+			want:   auth.Access{Verb: auth.VerbWrite, Catalog: auth.CatalogProperty, Groups: []string{"g1"}},
+		},
+		{
+			name:   "create a measure",
+			method: "/banyandb.database.v1.MeasureRegistryService/Create",
+			req: &databasev1.MeasureRegistryServiceCreateRequest{
+				Measure: &databasev1.Measure{Metadata: &commonv1.Metadata{Group: "g1", Name: "m"}},
+			},
+			want: auth.Access{Verb: auth.VerbSchemaAdmin, Catalog: auth.CatalogMeasure, Groups: []string{"g1"}},
+		},
+		{
+			name:   "get a stream",
+			method: "/banyandb.database.v1.StreamRegistryService/Get",
+			req:    &databasev1.StreamRegistryServiceGetRequest{Metadata: &commonv1.Metadata{Group: "g1", Name: "s"}},
+			want:   auth.Access{Verb: auth.VerbRead, Catalog: auth.CatalogStream, Groups: []string{"g1"}},
+		},
+		{
+			name:   "create a group",
+			method: "/banyandb.database.v1.GroupRegistryService/Create",
+			req: &databasev1.GroupRegistryServiceCreateRequest{
+				Group: &commonv1.Group{Metadata: &commonv1.Metadata{Name: "g1"}},
+			},
+			want: auth.Access{Verb: auth.VerbSchemaAdmin, Groups: []string{"g1"}},
+		},
+		{
+			name:   "list the groups",
+			method: "/banyandb.database.v1.GroupRegistryService/List",
+			req:    &databasev1.GroupRegistryServiceListRequest{},
+			want:   auth.Access{Verb: auth.VerbRead, Filtered: true},
+		},
+		{
+			name:   "drain a node",
+			method: "/banyandb.database.v1.NodeService/Drain",
+			req:    &databasev1.NodeServiceDrainRequest{Node: "data-1"},
+			want:   auth.Access{Verb: auth.VerbSchemaAdmin},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, accessOf(tt.method, tt.req))
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+)
+
+type nodeServer struct {
+	databasev1.UnimplementedNodeServiceServer
+	// shards moves the shards off the drained node, and serializes the drains with the rebalances.
+	shards *shardServer
+}
+
+// Drain marks the node as draining, so that the selectors of every liaison stop routing the writes to it.
+// Then the shards placed on it are moved to the remaining nodes picked by the selectors of this liaison,
+// which are the data nodes of the same stage. Every shard is verified by looking for the identities of its data
+// on the new nodes, and the node is deregistered only if all the shards pass the verification.
+// A node holding the shards of the property groups isn't drained, since they can't be moved yet.
+func (n *nodeServer) Drain(ctx context.Context, req *databasev1.NodeServiceDrainRequest) (*databasev1.NodeServiceDrainResponse, error) {
+	nr := n.shards.nodeRegistries
+	if _, ok := nr.StreamNodeRegistry.(localNodeService); ok {
+		return nil, status.Error(codes.FailedPrecondition, "only the data nodes of a cluster can be drained")
+	}
+	registry := n.shards.schemaRegistry.NodeRegistry()
+	target, err := registry.GetNode(ctx, req.GetNode())
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(target.GetRoles(), databasev1.Role_ROLE_DATA) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s isn't a data node", req.GetNode())
+	}
+	n.shards.mu.Lock()
+	defer n.shards.mu.Unlock()
+	groups, err := n.shards.schemaRegistry.GroupRegistry().ListGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The property shards can't be moved yet, whose data would be unreachable once the node is deregistered.
+	group, err := n.propertyGroupOn(ctx, groups, req.GetNode())
+	if err != nil {
+		return nil, err
+	}
+	if group != "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s holds the shards of the property group %s, which can't be drained", req.GetNode(), group)
+	}
+	if !node.IsDraining(target) {
+		if target.Labels == nil {
+			target.Labels = make(map[string]string)
+		}
+		target.Labels[node.LabelDraining] = "true"
+		if err = registry.UpdateNode(ctx, target); err != nil {
+			return nil, errors.WithMessagef(err, "failed to mark %s as draining", req.GetNode())
+		}
+	}
+	// The registry notifies the selectors asynchronously, so they're updated here before moving the shards.
+	for _, r := range []NodeRegistry{nr.StreamNodeRegistry, nr.MeasureNodeRegistry, nr.PropertyNodeRegistry} {
+		r.Exclude(target)
+	}
+	resp := &databasev1.NodeServiceDrainResponse{}
+	for _, g := range groups {
+		var shards []*databasev1.DrainedShard
+		switch g.Catalog {
+		case commonv1.Catalog_CATALOG_STREAM:
+			shards, err = n.drainGroup(ctx, target, g.Metadata.Name, nr.StreamNodeRegistry, data.TopicStreamMigrateShard, data.TopicStreamCountShard)
+		case commonv1.Catalog_CATALOG_MEASURE:
+			shards, err = n.drainGroup(ctx, target, g.Metadata.Name, nr.MeasureNodeRegistry, data.TopicMeasureMigrateShard, data.TopicMeasureCountShard)
+		default:
+			continue
+		}
+		resp.Shards = append(resp.Shards, shards...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err = registry.DeleteNode(ctx, req.GetNode()); err != nil {
+		return nil, errors.WithMessagef(err, "failed to deregister %s", req.GetNode())
+	}
+	return resp, nil
+}
+
+// propertyGroupOn returns the first property group which has a copy of a shard on the node.
+// The persisted placements are checked, since the selectors skip the node once it's draining.
+func (n *nodeServer) propertyGroupOn(ctx context.Context, groups []*commonv1.Group, name string) (string, error) {
+	for _, g := range groups {
+		if g.Catalog != commonv1.Catalog_CATALOG_PROPERTY {
+			continue
+		}
+		shards, err := n.shards.schemaRegistry.ShardRegistry().ListShard(ctx, schema.ListOpt{Group: g.Metadata.Name})
+		if err != nil {
+			return "", err
+		}
+		for _, s := range shards {
+			if s.Node == name {
+				return g.Metadata.Name, nil
+			}
+			nodes, errLocate := n.shards.nodeRegistries.PropertyNodeRegistry.LocateReplicas(g.Metadata.Name, "", uint32(s.Id))
+			if errLocate != nil {
+				return "", errLocate
+			}
+			if slices.Contains(nodes, name) {
+				return g.Metadata.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func (n *nodeServer) drainGroup(ctx context.Context, target *databasev1.Node, group string, nr NodeRegistry,
+	migrateTopic, countTopic bus.Topic,
+) ([]*databasev1.DrainedShard, error) {
+	name := target.GetMetadata().GetName()
+	moves, err := nr.Rebalance(group)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	var shards []*databasev1.DrainedShard
+	var nodes []*databasev1.Node
+	for _, m := range moves {
+		if !slices.Contains(m.From, name) {
+			continue
+		}
+		var targets []*databasev1.Node
+		if targets, nodes, err = n.shards.targets(ctx, m, nodes); err != nil {
+			return nil, err
+		}
+		var sent map[string]uint64
+		if len(targets) > 0 {
+			if sent, err = n.shards.collect(migrateTopic, &databasev1.MigrateShardRequest{
+				Group:   group,
+				ShardId: m.ShardID,
+				Targets: targets,
+			}); err != nil {
+				return nil, errors.WithMessagef(err, "failed to migrate the shard %d of %s", m.ShardID, group)
+			}
+		}
+		if err = nr.Assign(ctx, group, m.ShardID, m.To[0]); err != nil {
+			return nil, err
+		}
+		ds := &databasev1.DrainedShard{
+			Group:   group,
+			ShardId: m.ShardID,
+			To:      m.To,
+		}
+		shards = append(shards, ds)
+		if len(targets) == 0 {
+			continue
+		}
+		// The drained node catches up with the writes routed to it by the liaisons not yet aware of the draining mark.
+		caughtUp, errMigrate := n.shards.collect(migrateTopic, &databasev1.MigrateShardRequest{
+			Group:   group,
+			ShardId: m.ShardID,
+			Targets: targets,
+			CatchUp: true,
+		})
+		if errMigrate != nil {
+			return shards, errors.WithMessagef(errMigrate, "failed to catch up with the writes to the shard %d of %s", m.ShardID, group)
+		}
+		if _, ok := caughtUp[name]; !ok {
+			return shards, status.Errorf(codes.Unavailable, "%s didn't send its data of the shard %d of %s", name, m.ShardID, group)
+		}
+		ds.Migrated = sent[name] + caughtUp[name]
+		if err = n.verify(countTopic, name, ds); err != nil {
+			return shards, err
+		}
+	}
+	return shards, nil
+}
+
+// verifyBucketSize is the number of the identities which a data node responds for a bucket of a shard roughly.
+const verifyBucketSize = 1 << 16
+
+// verify checks that every new node of the shard holds all the elements or data points of the drained node by their identities.
+// The identities are split into buckets by their hashes, which bounds the size of every response.
+// The new nodes might hold more, which are written to them after the shard is moved.
+func (n *nodeServer) verify(countTopic bus.Topic, drained string, ds *databasev1.DrainedShard) error {
+	counts, err := n.shards.collect(countTopic, &databasev1.CountShardRequest{
+		Group:   ds.Group,
+		ShardId: ds.ShardId,
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to count the shard %d of %s", ds.ShardId, ds.Group)
+	}
+	largest := counts[drained]
+	for _, to := range ds.To {
+		largest = max(largest, counts[to])
+	}
+	buckets := uint32(largest/verifyBucketSize) + 1
+	found := make(map[string]uint64, len(ds.To))
+	var held uint64
+	for bucket := uint32(0); bucket < buckets; bucket++ {
+		identities, errList := n.list(countTopic, &databasev1.CountShardRequest{
+			Group:   ds.Group,
+			ShardId: ds.ShardId,
+			Buckets: buckets,
+			Bucket:  bucket,
+			List:    true,
+		})
+		if errList != nil {
+			return errors.WithMessagef(errList, "failed to list the shard %d of %s", ds.ShardId, ds.Group)
+		}
+		held += uint64(len(identities[drained]))
+		for _, to := range ds.To {
+			for id := range identities[drained] {
+				if _, ok := identities[to][id]; ok {
+					found[to]++
+				}
+			}
+		}
+	}
+	for i, to := range ds.To {
+		if i == 0 || found[to] < ds.Counted {
+			ds.Counted = found[to]
+		}
+		if found[to] < held {
+			return status.Errorf(codes.DataLoss, "node %s holds %d of the %d elements or data points of the shard %d of %s",
+				to, found[to], held, ds.ShardId, ds.Group)
+		}
+	}
+	return nil
+}
+
+// list broadcasts the request listing the identities of a shard, and returns the identities responded by every node.
+func (n *nodeServer) list(countTopic bus.Topic, req *databasev1.CountShardRequest) (map[string]map[uint64]struct{}, error) {
+	ff, err := n.shards.broadcaster.Broadcast(shardMigrationTimeout, countTopic, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req))
+	if err != nil {
+		return nil, err
+	}
+	identities := make(map[string]map[uint64]struct{}, len(ff))
+	for _, f := range ff {
+		m, errGet := f.Get()
+		if errGet != nil {
+			err = multierr.Append(err, errGet)
+			continue
+		}
+		switch d := m.Data().(type) {
+		case *databasev1.CountShardResponse:
+			ids := identities[d.Node]
+			if ids == nil {
+				ids = make(map[uint64]struct{}, len(d.Identities))
+				identities[d.Node] = ids
+			}
+			for _, id := range d.Identities {
+				ids[id] = struct{}{}
+			}
+		case *common.Error:
+			err = multierr.Append(err, errors.New(d.Error()))
+		}
+	}
+	return identities, err
+}
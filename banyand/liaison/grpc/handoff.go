@@ -0,0 +1,772 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const (
+	handoffSegmentSuffix   = ".seg"
+	handoffCursorFile      = "cursor"
+	handoffReplayBatchSize = 100
+	// The header of a record contains its length, the time it's queued and the length of its topic.
+	handoffRecordHeaderSize = 4 + 8 + 2
+)
+
+var (
+	handoffSegmentSize int64 = 4 << 20
+
+	handoffInitBackoff = time.Second
+	handoffMaxBackoff  = 20 * time.Second
+
+	// handoffTopic registers the controller to the queue client, which notifies it of the active data nodes.
+	handoffTopic = bus.UniTopic("hinted-handoff")
+
+	errHandoffFull          = errors.New("the hinted handoff queue is full")
+	errInvalidHandoffRecord = errors.New("invalid hinted handoff record")
+)
+
+// handoffRecord is a write which can't be delivered to a data node.
+type handoffRecord struct {
+	message  proto.Message
+	topic    bus.Topic
+	end      handoffPosition
+	enqueued int64
+	size     int64
+}
+
+func (r handoffRecord) marshal() ([]byte, error) {
+	body, err := proto.Marshal(r.message)
+	if err != nil {
+		return nil, err
+	}
+	topic := r.topic.String()
+	b := make([]byte, handoffRecordHeaderSize, handoffRecordHeaderSize+len(topic)+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+2+len(topic)+len(body)))
+	binary.BigEndian.PutUint64(b[4:], uint64(r.enqueued))
+	binary.BigEndian.PutUint16(b[12:], uint16(len(topic)))
+	b = append(b, topic...)
+	return append(b, body...), nil
+}
+
+// readHandoffRecord reads the record at the offset of the segment. It returns the size of the record.
+func readHandoffRecord(f *os.File, offset int64) (handoffRecord, int64, error) {
+	var header [handoffRecordHeaderSize]byte
+	if _, err := f.ReadAt(header[:], offset); err != nil {
+		return handoffRecord{}, 0, err
+	}
+	size := int64(binary.BigEndian.Uint32(header[:]))
+	topicLen := int64(binary.BigEndian.Uint16(header[12:]))
+	if size < 8+2+topicLen {
+		return handoffRecord{}, 0, errors.WithMessagef(errInvalidHandoffRecord, "size %d", size)
+	}
+	payload := make([]byte, size-8-2)
+	if _, err := f.ReadAt(payload, offset+handoffRecordHeaderSize); err != nil {
+		return handoffRecord{}, 0, err
+	}
+	topic, ok := data.TopicMap[string(payload[:topicLen])]
+	if !ok {
+		return handoffRecord{}, 0, errors.WithMessagef(errInvalidHandoffRecord, "unknown topic %s", payload[:topicLen])
+	}
+	message := data.TopicRequestMap[topic]()
+	if err := proto.Unmarshal(payload[topicLen:], message); err != nil {
+		return handoffRecord{}, 0, errors.WithMessage(errInvalidHandoffRecord, err.Error())
+	}
+	return handoffRecord{
+		message:  message,
+		topic:    topic,
+		enqueued: int64(binary.BigEndian.Uint64(header[4:])),
+	}, 4 + size, nil
+}
+
+// handoffPosition locates a record in the segments.
+type handoffPosition struct {
+	segment uint64
+	offset  int64
+}
+
+// handoffQueue is an on-disk FIFO queue of the writes to a data node.
+// The records are appended to the segment files, which are removed once all their records are replayed.
+// The cursor file keeps the position of the next record to replay.
+type handoffQueue struct {
+	writer    *os.File
+	dir       string
+	segments  []uint64
+	head      handoffPosition
+	oldest    int64
+	size      int64
+	tailSize  int64
+	count     int
+	mu        sync.Mutex
+	replaying bool
+}
+
+func openHandoffQueue(dir string) (*handoffQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the hinted handoff directory %s", dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	q := &handoffQueue{dir: dir}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), handoffSegmentSuffix) {
+			continue
+		}
+		id, errParse := strconv.ParseUint(strings.TrimSuffix(e.Name(), handoffSegmentSuffix), 16, 64)
+		if errParse != nil {
+			continue
+		}
+		q.segments = append(q.segments, id)
+	}
+	slices.Sort(q.segments)
+	if err = q.readCursor(); err != nil {
+		return nil, err
+	}
+	if err = q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *handoffQueue) segmentPath(id uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%016x%s", id, handoffSegmentSuffix))
+}
+
+func (q *handoffQueue) readCursor() error {
+	b, err := os.ReadFile(filepath.Join(q.dir, handoffCursorFile))
+	if errors.Is(err, os.ErrNotExist) || len(b) != 16 {
+		if len(q.segments) > 0 {
+			q.head = handoffPosition{segment: q.segments[0]}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	q.head = handoffPosition{segment: binary.BigEndian.Uint64(b), offset: int64(binary.BigEndian.Uint64(b[8:]))}
+	for len(q.segments) > 0 && q.segments[0] < q.head.segment {
+		if err = os.Remove(q.segmentPath(q.segments[0])); err != nil {
+			return err
+		}
+		q.segments = q.segments[1:]
+	}
+	if len(q.segments) == 0 || q.segments[0] != q.head.segment {
+		q.head = handoffPosition{}
+		if len(q.segments) > 0 {
+			q.head.segment = q.segments[0]
+		}
+	}
+	return nil
+}
+
+// load counts the pending records. A record torn by a crash is cut off from the last segment.
+func (q *handoffQueue) load() error {
+	for i, id := range q.segments {
+		f, err := os.Open(q.segmentPath(id))
+		if err != nil {
+			return err
+		}
+		var offset int64
+		if id == q.head.segment {
+			offset = q.head.offset
+		}
+		for {
+			r, n, errRead := readHandoffRecord(f, offset)
+			if errRead != nil {
+				break
+			}
+			if q.count == 0 {
+				q.oldest = r.enqueued
+			}
+			q.count++
+			q.size += n
+			offset += n
+		}
+		_ = f.Close()
+		if i < len(q.segments)-1 {
+			continue
+		}
+		q.tailSize = offset
+		if q.writer, err = os.OpenFile(q.segmentPath(id), os.O_WRONLY, 0o600); err != nil {
+			return err
+		}
+		if err = q.writer.Truncate(offset); err != nil {
+			return err
+		}
+		if _, err = q.writer.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// append queues the record. It fails if the pending records exceed the max size.
+func (q *handoffQueue) append(r handoffRecord, maxSize int64) error {
+	b, err := r.marshal()
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.size+int64(len(b)) > maxSize {
+		return errors.WithMessagef(errHandoffFull, "%d bytes are pending", q.size)
+	}
+	if q.writer == nil || (q.tailSize > 0 && q.tailSize+int64(len(b)) > handoffSegmentSize) {
+		if err = q.rotate(); err != nil {
+			return err
+		}
+	}
+	if _, err = q.writer.Write(b); err != nil {
+		return errors.Wrapf(err, "failed to write to the hinted handoff segment %s", q.writer.Name())
+	}
+	if q.count == 0 {
+		q.oldest = r.enqueued
+	}
+	q.tailSize += int64(len(b))
+	q.size += int64(len(b))
+	q.count++
+	return nil
+}
+
+func (q *handoffQueue) rotate() error {
+	var id uint64
+	if len(q.segments) > 0 {
+		id = q.segments[len(q.segments)-1] + 1
+	}
+	if q.writer != nil {
+		if err := q.writer.Close(); err != nil {
+			return err
+		}
+	}
+	w, err := os.OpenFile(q.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if len(q.segments) == 0 {
+		q.head = handoffPosition{segment: id}
+	}
+	q.writer = w
+	q.segments = append(q.segments, id)
+	q.tailSize = 0
+	return nil
+}
+
+// peek reads at most n records from the head of the queue. It returns the position after them and their size.
+// Each record also tells the position after it, which commits the records up to it.
+func (q *handoffQueue) peek(n int) ([]handoffRecord, handoffPosition, int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pos := q.head
+	var records []handoffRecord
+	var total int64
+	for i := 0; i < len(q.segments) && len(records) < n; i++ {
+		id := q.segments[i]
+		if id != pos.segment {
+			pos = handoffPosition{segment: id}
+		}
+		end := q.tailSize
+		if i < len(q.segments)-1 {
+			fi, err := os.Stat(q.segmentPath(id))
+			if err != nil {
+				return nil, q.head, 0, err
+			}
+			end = fi.Size()
+		}
+		if pos.offset >= end {
+			continue
+		}
+		f, err := os.Open(q.segmentPath(id))
+		if err != nil {
+			return nil, q.head, 0, err
+		}
+		for pos.offset < end && len(records) < n {
+			r, size, errRead := readHandoffRecord(f, pos.offset)
+			if errRead != nil {
+				_ = f.Close()
+				return nil, q.head, 0, errRead
+			}
+			pos.offset += size
+			total += size
+			r.end, r.size = pos, size
+			records = append(records, r)
+		}
+		_ = f.Close()
+	}
+	return records, pos, total, nil
+}
+
+// commit removes the records before the position, which are replayed.
+func (q *handoffQueue) commit(pos handoffPosition, count int, size int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.segments) > 1 && q.segments[0] < pos.segment {
+		if err := os.Remove(q.segmentPath(q.segments[0])); err != nil {
+			return err
+		}
+		q.segments = q.segments[1:]
+	}
+	q.head = pos
+	q.count -= count
+	q.size -= size
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:], pos.segment)
+	binary.BigEndian.PutUint64(b[8:], uint64(pos.offset))
+	if err := os.WriteFile(filepath.Join(q.dir, handoffCursorFile), b[:], 0o600); err != nil {
+		return err
+	}
+	if q.count == 0 {
+		q.oldest = 0
+		return nil
+	}
+	f, err := os.Open(q.segmentPath(pos.segment))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if r, _, errRead := readHandoffRecord(f, pos.offset); errRead == nil {
+		q.oldest = r.enqueued
+	} else if len(q.segments) > 1 {
+		fn, errOpen := os.Open(q.segmentPath(q.segments[1]))
+		if errOpen != nil {
+			return errOpen
+		}
+		defer fn.Close()
+		if r, _, errRead = readHandoffRecord(fn, 0); errRead == nil {
+			q.oldest = r.enqueued
+		}
+	}
+	return nil
+}
+
+func (q *handoffQueue) stats() (count int, size int64, oldest int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count, q.size, q.oldest
+}
+
+func (q *handoffQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closeWriter()
+}
+
+func (q *handoffQueue) closeWriter() error {
+	if q.writer == nil {
+		return nil
+	}
+	err := q.writer.Close()
+	q.writer = nil
+	return err
+}
+
+// drop closes the queue and removes all its records. It returns the number of the dropped records.
+func (q *handoffQueue) drop() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	count := q.count
+	err := multierr.Append(q.closeWriter(), os.RemoveAll(q.dir))
+	q.segments = q.segments[:0]
+	q.head = handoffPosition{}
+	q.count, q.size, q.tailSize, q.oldest = 0, 0, 0, 0
+	return count, err
+}
+
+// handoffController queues the writes which can't be delivered to the data nodes,
+// and replays them in order once the nodes are back.
+// It learns whether a node is active from the queue client, which notifies it once the node passes the health check
+// and once the node becomes unhealthy or is deregistered.
+type handoffController struct {
+	schema.UnimplementedOnInitHandler
+	pipeline     queue.Client
+	log          *logger.Logger
+	metrics      *metrics
+	queues       map[string]*handoffQueue
+	active       map[string]bool
+	expiring     map[string]*time.Timer
+	closer       *run.Closer
+	root         string
+	maxSize      int64
+	timeout      time.Duration
+	expiry       time.Duration
+	mu           sync.Mutex
+	sloppyQuorum bool
+}
+
+func newHandoffController(root string, maxSize int64, timeout, expiry time.Duration, sloppyQuorum bool,
+	pipeline queue.Client, m *metrics, l *logger.Logger,
+) (*handoffController, error) {
+	h := &handoffController{
+		pipeline:     pipeline,
+		log:          l,
+		metrics:      m,
+		queues:       make(map[string]*handoffQueue),
+		active:       make(map[string]bool),
+		expiring:     make(map[string]*time.Timer),
+		closer:       run.NewCloser(1),
+		root:         root,
+		maxSize:      maxSize,
+		timeout:      timeout,
+		expiry:       expiry,
+		sloppyQuorum: sloppyQuorum,
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the hinted handoff directory %s", root)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		node, errUnescape := url.PathUnescape(e.Name())
+		if errUnescape != nil {
+			continue
+		}
+		q, errOpen := h.queue(node)
+		if errOpen != nil {
+			return nil, errOpen
+		}
+		h.updateMetrics(node, q)
+		h.mu.Lock()
+		h.expireLater(node)
+		h.mu.Unlock()
+	}
+	// The active nodes are notified at once, which replay the loaded queues.
+	pipeline.Register(handoffTopic, h)
+	return h, nil
+}
+
+func (h *handoffController) queue(node string) (*handoffQueue, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if q, ok := h.queues[node]; ok {
+		return q, nil
+	}
+	q, err := openHandoffQueue(filepath.Join(h.root, url.PathEscape(node)))
+	if err != nil {
+		return nil, err
+	}
+	h.queues[node] = q
+	return q, nil
+}
+
+// handOff queues the message for the node. It's safe to call it on a nil controller, which rejects the message.
+func (h *handoffController) handOff(node string, topic bus.Topic, message proto.Message) bool {
+	if h == nil {
+		return false
+	}
+	if err := h.enqueue(node, topic, message); err != nil {
+		h.log.Error().Err(err).Str("node", node).Msg("failed to hand off the write")
+		return false
+	}
+	return true
+}
+
+// handOffFunc returns a function handing off the messages of the topic, which returns true if the message is queued.
+func (h *handoffController) handOffFunc(topic bus.Topic) func(node string, message proto.Message) bool {
+	return func(node string, message proto.Message) bool {
+		return h.handOff(node, topic, message)
+	}
+}
+
+// counts returns true if the handed off writes to a shard with the copies count toward the write quorum.
+// They always count if the shard has no replicas, whose only copy is the queued one while its node is unreachable.
+func (h *handoffController) counts(copies int) bool {
+	if h == nil {
+		return false
+	}
+	return h.sloppyQuorum || copies == 1
+}
+
+func (h *handoffController) enqueue(node string, topic bus.Topic, message proto.Message) error {
+	q, err := h.queue(node)
+	if err != nil {
+		return err
+	}
+	if err = q.append(handoffRecord{message: message, topic: topic, enqueued: time.Now().UnixNano()}, h.maxSize); err != nil {
+		if errors.Is(err, errHandoffFull) {
+			h.metrics.totalHandoffRejected.Inc(1, node)
+		}
+		return err
+	}
+	h.metrics.totalHandoff.Inc(1, node)
+	h.updateMetrics(node, q)
+	h.mu.Lock()
+	if !h.active[node] {
+		h.expireLater(node)
+	}
+	h.mu.Unlock()
+	h.startReplay(node, q)
+	return nil
+}
+
+// OnAddOrUpdate replays the queued writes once the node becomes active.
+func (h *handoffController) OnAddOrUpdate(md schema.Metadata) {
+	node := handoffNodeName(md)
+	if node == "" {
+		return
+	}
+	h.mu.Lock()
+	h.active[node] = true
+	if t, ok := h.expiring[node]; ok {
+		t.Stop()
+		delete(h.expiring, node)
+	}
+	q, ok := h.queues[node]
+	h.mu.Unlock()
+	if ok {
+		h.startReplay(node, q)
+	}
+}
+
+// OnDelete stops replaying the queued writes once the node becomes inactive.
+// The queue is dropped if the node stays inactive for the expiry.
+func (h *handoffController) OnDelete(md schema.Metadata) {
+	node := handoffNodeName(md)
+	if node == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.active, node)
+	if _, ok := h.queues[node]; ok {
+		h.expireLater(node)
+	}
+}
+
+func handoffNodeName(md schema.Metadata) string {
+	if md.Kind != schema.KindNode {
+		return ""
+	}
+	n, ok := md.Spec.(*databasev1.Node)
+	if !ok {
+		return ""
+	}
+	return n.Metadata.GetName()
+}
+
+func (h *handoffController) isActive(node string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.active[node]
+}
+
+// expireLater drops the queue of the node after the expiry unless the node becomes active. The caller holds h.mu.
+func (h *handoffController) expireLater(node string) {
+	if _, ok := h.expiring[node]; ok || h.expiry <= 0 {
+		return
+	}
+	h.expiring[node] = time.AfterFunc(h.expiry, func() {
+		h.expire(node)
+	})
+}
+
+func (h *handoffController) expire(node string) {
+	h.mu.Lock()
+	delete(h.expiring, node)
+	q, ok := h.queues[node]
+	if h.active[node] || !ok {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.queues, node)
+	h.mu.Unlock()
+	count, err := q.drop()
+	if err != nil {
+		h.log.Warn().Err(err).Str("node", node).Msg("failed to drop the hinted handoff queue")
+	}
+	if count > 0 {
+		h.log.Warn().Str("node", node).Int("count", count).Dur("expiry", h.expiry).
+			Msg("the node has been inactive for longer than the expiry, drop its hinted handoff writes")
+	}
+	h.metrics.totalHandoffExpired.Inc(float64(count), node)
+	h.updateMetrics(node, q)
+}
+
+func (h *handoffController) startReplay(node string, q *handoffQueue) {
+	if !h.isActive(node) {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.replaying || q.count == 0 {
+		return
+	}
+	if !h.closer.AddRunning() {
+		return
+	}
+	q.replaying = true
+	go func() {
+		defer h.closer.Done()
+		backoff := handoffInitBackoff
+		for {
+			done, err := h.replay(node, q)
+			if done {
+				return
+			}
+			if err == nil {
+				backoff = handoffInitBackoff
+				continue
+			}
+			h.log.Warn().Err(err).Str("node", node).Dur("backoff", backoff).Msg("failed to replay the hinted handoff writes")
+			h.updateMetrics(node, q)
+			if h.stopReplay(node, q) {
+				return
+			}
+			select {
+			case <-time.After(backoff):
+			case <-h.closer.CloseNotify():
+				q.mu.Lock()
+				q.replaying = false
+				q.mu.Unlock()
+				return
+			}
+			if backoff < handoffMaxBackoff {
+				backoff *= 2
+			} else {
+				backoff = handoffMaxBackoff
+			}
+		}
+	}()
+}
+
+// stopReplay stops replaying if the node becomes inactive. The replay resumes once the node is active again.
+func (h *handoffController) stopReplay(node string, q *handoffQueue) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if h.isActive(node) {
+		return false
+	}
+	q.replaying = false
+	return true
+}
+
+// replay sends the writes at the head of the queue to the node. It returns true if the queue is drained.
+// A batch is acknowledged as a whole, so every write is sent in its own batch and committed once it's acknowledged.
+// A failure resends the unacknowledged write only.
+func (h *handoffController) replay(node string, q *handoffQueue) (bool, error) {
+	records, _, _, err := q.peek(handoffReplayBatchSize)
+	if err != nil {
+		return false, err
+	}
+	if len(records) == 0 {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.count > 0 {
+			return false, errors.WithMessagef(errInvalidHandoffRecord, "%d records are missing", q.count)
+		}
+		q.replaying = false
+		return true, nil
+	}
+	for _, r := range records {
+		if err = h.send(node, r); err != nil {
+			return false, err
+		}
+		if err = q.commit(r.end, 1, r.size); err != nil {
+			return false, err
+		}
+		h.metrics.totalHandoffReplayed.Inc(1, node)
+	}
+	h.updateMetrics(node, q)
+	return false, nil
+}
+
+func (h *handoffController) send(node string, r handoffRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	publisher := h.pipeline.NewBatchPublisher(h.timeout)
+	if _, err := publisher.Publish(ctx, r.topic, bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), node, r.message)); err != nil {
+		_, _ = publisher.Close()
+		return err
+	}
+	cee, err := publisher.Close()
+	if err != nil {
+		return err
+	}
+	if ce, ok := cee[node]; ok {
+		return ce
+	}
+	return nil
+}
+
+func (h *handoffController) updateMetrics(node string, q *handoffQueue) {
+	count, size, oldest := q.stats()
+	h.metrics.handoffBacklogCount.Set(float64(count), node)
+	h.metrics.handoffBacklogSize.Set(float64(size), node)
+	if count == 0 {
+		h.metrics.handoffBacklogAge.Set(0, node)
+		return
+	}
+	h.metrics.handoffBacklogAge.Set(time.Since(time.Unix(0, oldest)).Seconds(), node)
+}
+
+func (h *handoffController) close() {
+	h.closer.Done()
+	h.closer.CloseThenWait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for node, t := range h.expiring {
+		t.Stop()
+		delete(h.expiring, node)
+	}
+	for node, q := range h.queues {
+		if err := q.close(); err != nil {
+			h.log.Warn().Err(err).Str("node", node).Msg("failed to close the hinted handoff queue")
+		}
+	}
+}
+
+// isUndeliverable returns true if the write fails because the node is unreachable,
+// rather than rejected by the node.
+func isUndeliverable(err error) bool {
+	var ce *common.Error
+	if errors.As(err, &ce) {
+		return ce.Status() == modelv1.Status_STATUS_INTERNAL_ERROR
+	}
+	return true
+}
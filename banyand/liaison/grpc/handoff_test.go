@@ -0,0 +1,310 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func newTestHandoffController(t *testing.T, pipeline *queue.MockClient, maxSize int64, sloppyQuorum bool) *handoffController {
+	return openTestHandoffController(t, t.TempDir(), pipeline, maxSize, time.Hour, sloppyQuorum)
+}
+
+func openTestHandoffController(t *testing.T, root string, pipeline *queue.MockClient, maxSize int64,
+	expiry time.Duration, sloppyQuorum bool,
+) *handoffController {
+	pipeline.EXPECT().Register(handoffTopic, gomock.Any()).AnyTimes()
+	h, err := newHandoffController(root, maxSize, time.Second, expiry, sloppyQuorum, pipeline,
+		newMetrics(&observability.Factory{}), logger.GetLogger("test-handoff"))
+	require.NoError(t, err)
+	t.Cleanup(h.close)
+	return h
+}
+
+func handoffNode(name string) schema.Metadata {
+	return schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindNode, Name: name},
+		Spec:     &databasev1.Node{Metadata: &commonv1.Metadata{Name: name}, Roles: []databasev1.Role{databasev1.Role_ROLE_DATA}},
+	}
+}
+
+func handoffShardIDs(records []handoffRecord) []uint32 {
+	ids := make([]uint32, len(records))
+	for i, r := range records {
+		ids[i] = r.message.(*measurev1.InternalWriteRequest).ShardId
+	}
+	return ids
+}
+
+func TestHandoffQueue(t *testing.T) {
+	defer func(size int64) { handoffSegmentSize = size }(handoffSegmentSize)
+	handoffSegmentSize = 128
+	dir := t.TempDir()
+	q, err := openHandoffQueue(dir)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, q.append(handoffRecord{
+			message:  &measurev1.InternalWriteRequest{ShardId: uint32(i)},
+			topic:    data.TopicMeasureWrite,
+			enqueued: int64(i + 1),
+		}, 1<<20))
+	}
+	count, size, oldest := q.stats()
+	assert.Equal(t, 10, count)
+	assert.Equal(t, int64(1), oldest)
+	assert.Greater(t, len(q.segments), 1, "the records should be spread across the segments")
+
+	records, pos, replayed, err := q.peek(4)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0, 1, 2, 3}, handoffShardIDs(records))
+	require.NoError(t, q.commit(pos, len(records), replayed))
+	_, _, oldest = q.stats()
+	assert.Equal(t, int64(5), oldest)
+
+	// The cursor survives a restart.
+	require.NoError(t, q.close())
+	q, err = openHandoffQueue(dir)
+	require.NoError(t, err)
+	count, remaining, oldest := q.stats()
+	assert.Equal(t, 6, count)
+	assert.Equal(t, size-replayed, remaining)
+	assert.Equal(t, int64(5), oldest)
+	records, pos, replayed, err = q.peek(100)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{4, 5, 6, 7, 8, 9}, handoffShardIDs(records))
+	require.NoError(t, q.commit(pos, len(records), replayed))
+	count, remaining, _ = q.stats()
+	assert.Zero(t, count)
+	assert.Zero(t, remaining)
+	assert.Len(t, q.segments, 1, "the replayed segments should be removed")
+
+	records, _, _, err = q.peek(100)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	require.NoError(t, q.append(handoffRecord{message: &streamv1.InternalWriteRequest{ShardId: 10}, topic: data.TopicStreamWrite}, 1<<20))
+	records, _, _, err = q.peek(100)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, data.TopicStreamWrite, records[0].topic)
+	assert.Equal(t, uint32(10), records[0].message.(*streamv1.InternalWriteRequest).ShardId)
+	require.NoError(t, q.close())
+}
+
+func TestHandoffQueueTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openHandoffQueue(dir)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, q.append(handoffRecord{message: &measurev1.InternalWriteRequest{ShardId: uint32(i)}, topic: data.TopicMeasureWrite}, 1<<20))
+	}
+	segment := q.segmentPath(q.segments[0])
+	require.NoError(t, q.close())
+	f, err := os.OpenFile(segment, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 1, 0, 0})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, err = openHandoffQueue(dir)
+	require.NoError(t, err)
+	count, _, _ := q.stats()
+	assert.Equal(t, 2, count)
+	require.NoError(t, q.append(handoffRecord{message: &measurev1.InternalWriteRequest{ShardId: 2}, topic: data.TopicMeasureWrite}, 1<<20))
+	records, _, _, err := q.peek(100)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0, 1, 2}, handoffShardIDs(records))
+	require.NoError(t, q.close())
+}
+
+func TestHandoffQueueFull(t *testing.T) {
+	q, err := openHandoffQueue(filepath.Join(t.TempDir(), "node"))
+	require.NoError(t, err)
+	defer q.close()
+	r := handoffRecord{message: &measurev1.InternalWriteRequest{ShardId: 1}, topic: data.TopicMeasureWrite}
+	b, err := r.marshal()
+	require.NoError(t, err)
+	maxSize := int64(len(b) * 2)
+	require.NoError(t, q.append(r, maxSize))
+	require.NoError(t, q.append(r, maxSize))
+	assert.ErrorIs(t, q.append(r, maxSize), errHandoffFull)
+}
+
+func TestHandoffControllerReplay(t *testing.T) {
+	defer func(backoff time.Duration) { handoffInitBackoff = backoff }(handoffInitBackoff)
+	handoffInitBackoff = 10 * time.Millisecond
+	ctrl := gomock.NewController(t)
+	pipeline := queue.NewMockClient(ctrl)
+	publisher := queue.NewMockBatchPublisher(ctrl)
+	pipeline.EXPECT().NewBatchPublisher(gomock.Any()).Return(publisher).AnyTimes()
+	publisher.EXPECT().Close().Return(nil, nil).AnyTimes()
+
+	var mu sync.Mutex
+	available := false
+	var replayed []uint32
+	publisher.EXPECT().Publish(gomock.Any(), data.TopicMeasureWrite, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ bus.Topic, messages ...bus.Message) (bus.Future, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !available {
+				return nil, errors.New("node1 is unavailable")
+			}
+			assert.Equal(t, "node1", messages[0].Node())
+			replayed = append(replayed, messages[0].Data().(*measurev1.InternalWriteRequest).ShardId)
+			return nil, nil
+		}).AnyTimes()
+
+	h := newTestHandoffController(t, pipeline, 1<<20, false)
+	h.OnAddOrUpdate(handoffNode("node1"))
+	for i := 0; i < 250; i++ {
+		require.True(t, h.handOff("node1", data.TopicMeasureWrite, &measurev1.InternalWriteRequest{ShardId: uint32(i)}))
+	}
+	q, err := h.queue("node1")
+	require.NoError(t, err)
+	// The replay stops once the node becomes inactive.
+	h.OnDelete(handoffNode("node1"))
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return !q.replaying
+	}, 10*time.Second, 10*time.Millisecond)
+	count, _, _ := q.stats()
+	assert.Equal(t, 250, count)
+
+	// The replay resumes once the node becomes active.
+	mu.Lock()
+	available = true
+	mu.Unlock()
+	h.OnAddOrUpdate(handoffNode("node1"))
+	assert.Eventually(t, func() bool {
+		count, _, _ := q.stats()
+		return count == 0
+	}, 10*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, replayed, 250)
+	for i, id := range replayed {
+		assert.Equal(t, uint32(i), id)
+	}
+}
+
+func TestHandoffControllerReplayAcknowledged(t *testing.T) {
+	defer func(backoff time.Duration) { handoffInitBackoff = backoff }(handoffInitBackoff)
+	handoffInitBackoff = 10 * time.Millisecond
+	ctrl := gomock.NewController(t)
+	pipeline := queue.NewMockClient(ctrl)
+	publisher := queue.NewMockBatchPublisher(ctrl)
+	pipeline.EXPECT().NewBatchPublisher(gomock.Any()).Return(publisher).AnyTimes()
+
+	var mu sync.Mutex
+	var sent, replayed []uint32
+	failed := false
+	var current uint32
+	publisher.EXPECT().Publish(gomock.Any(), data.TopicMeasureWrite, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ bus.Topic, messages ...bus.Message) (bus.Future, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			current = messages[0].Data().(*measurev1.InternalWriteRequest).ShardId
+			sent = append(sent, current)
+			return nil, nil
+		}).AnyTimes()
+	// The node rejects the 4th write once, after it accepts the first 3 ones.
+	publisher.EXPECT().Close().DoAndReturn(func() (map[string]*common.Error, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if current == 3 && !failed {
+			failed = true
+			return map[string]*common.Error{"node1": common.NewErrorWithStatus(modelv1.Status_STATUS_INTERNAL_ERROR, "timeout")}, nil
+		}
+		replayed = append(replayed, current)
+		return nil, nil
+	}).AnyTimes()
+
+	h := newTestHandoffController(t, pipeline, 1<<20, false)
+	for i := 0; i < 10; i++ {
+		require.True(t, h.handOff("node1", data.TopicMeasureWrite, &measurev1.InternalWriteRequest{ShardId: uint32(i)}))
+	}
+	q, err := h.queue("node1")
+	require.NoError(t, err)
+	h.OnAddOrUpdate(handoffNode("node1"))
+	assert.Eventually(t, func() bool {
+		count, _, _ := q.stats()
+		return count == 0
+	}, 10*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []uint32{0, 1, 2, 3, 3, 4, 5, 6, 7, 8, 9}, sent, "only the unacknowledged write should be resent")
+	assert.Equal(t, []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, replayed)
+}
+
+func TestHandoffControllerExpiry(t *testing.T) {
+	defer func(backoff time.Duration) { handoffInitBackoff = backoff }(handoffInitBackoff)
+	handoffInitBackoff = 10 * time.Millisecond
+	ctrl := gomock.NewController(t)
+	pipeline := queue.NewMockClient(ctrl)
+	publisher := queue.NewMockBatchPublisher(ctrl)
+	pipeline.EXPECT().NewBatchPublisher(gomock.Any()).Return(publisher).AnyTimes()
+	publisher.EXPECT().Publish(gomock.Any(), data.TopicMeasureWrite, gomock.Any()).Return(nil, errors.New("node is unavailable")).AnyTimes()
+	publisher.EXPECT().Close().Return(nil, nil).AnyTimes()
+	root := t.TempDir()
+	h := openTestHandoffController(t, root, pipeline, 1<<20, 200*time.Millisecond, false)
+	queued := func(node string) func() bool {
+		return func() bool {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			_, ok := h.queues[node]
+			return ok
+		}
+	}
+
+	require.True(t, h.handOff("node1", data.TopicMeasureWrite, &measurev1.InternalWriteRequest{ShardId: 1}))
+	assert.Eventually(t, func() bool { return !queued("node1")() }, 10*time.Second, 10*time.Millisecond,
+		"the queue of the inactive node should expire")
+	_, err := os.Stat(filepath.Join(root, "node1"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// The queue of an active node doesn't expire even if the replay fails.
+	h.OnAddOrUpdate(handoffNode("node2"))
+	require.True(t, h.handOff("node2", data.TopicMeasureWrite, &measurev1.InternalWriteRequest{ShardId: 2}))
+	assert.Never(t, func() bool { return !queued("node2")() }, 500*time.Millisecond, 10*time.Millisecond)
+	// The queue of a deregistered node expires.
+	h.OnDelete(handoffNode("node2"))
+	assert.Eventually(t, func() bool { return !queued("node2")() }, 10*time.Second, 10*time.Millisecond)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/api/data"
@@ -47,9 +48,13 @@ type measureService struct {
 	pipeline           queue.Client
 	broadcaster        queue.Client
 	*discoveryService
-	sampled      *logger.Logger
-	metrics      *metrics
-	writeTimeout time.Duration
+	sampled        *logger.Logger
+	metrics        *metrics
+	queryCache     *queryCache
+	handoff        *handoffController
+	writeQuorum    writeQuorum
+	writeTimeout   time.Duration
+	queryBatchSize uint32
 }
 
 func (ms *measureService) setLogger(log *logger.Logger) {
@@ -82,14 +87,9 @@ func (ms *measureService) Write(measure measurev1.MeasureService_WriteServer) er
 	var succeedSent []succeedSentMessage
 	defer func() {
 		cee, err := publisher.Close()
+		handOff := ms.handoff.handOffFunc(data.TopicMeasureWrite)
 		for _, s := range succeedSent {
-			code := modelv1.Status_STATUS_SUCCEED
-			if cee != nil {
-				if ce, ok := cee[s.node]; ok {
-					code = ce.Status()
-				}
-			}
-			reply(s.metadata, code, s.messageID, measure, ms.sampled)
+			reply(s.metadata, s.status(cee, handOff), s.messageID, measure, ms.sampled)
 		}
 		if err != nil {
 			ms.sampled.Error().Err(err).Msg("failed to close the publisher")
@@ -138,6 +138,14 @@ func (ms *measureService) Write(measure measurev1.MeasureService_WriteServer) er
 			reply(writeRequest.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeRequest.GetMessageId(), measure, ms.sampled)
 			continue
 		}
+		seriesHash := pbv1.HashEntity(entity)
+		if tl := tenantFrom(ctx); tl != nil {
+			if st := tl.admitWrite(writeRequest.GetMetadata().GetGroup(), seriesHash); st != modelv1.Status_STATUS_SUCCEED {
+				ms.sampled.Warn().Str("tenant", tl.tenant.Name).RawJSON("written", logger.Proto(writeRequest)).Msg("the tenant exceeds its limits")
+				reply(writeRequest.GetMetadata(), st, writeRequest.GetMessageId(), measure, ms.sampled)
+				continue
+			}
+		}
 		if writeRequest.DataPoint.Version == 0 {
 			if writeRequest.MessageId == 0 {
 				writeRequest.MessageId = uint64(time.Now().UnixNano())
@@ -152,19 +160,19 @@ func (ms *measureService) Write(measure measurev1.MeasureService_WriteServer) er
 		iwr := &measurev1.InternalWriteRequest{
 			Request:      writeRequest,
 			ShardId:      uint32(shardID),
-			SeriesHash:   pbv1.HashEntity(entity),
+			SeriesHash:   seriesHash,
 			EntityValues: tagValues[1:].Encode(),
 		}
-		nodeID, errPickNode := ms.nodeRegistry.Locate(writeRequest.GetMetadata().GetGroup(), writeRequest.GetMetadata().GetName(), uint32(shardID))
+		nodeIDs, errPickNode := ms.nodeRegistry.LocateReplicas(writeRequest.GetMetadata().GetGroup(), writeRequest.GetMetadata().GetName(), uint32(shardID))
 		if errPickNode != nil {
 			ms.sampled.Error().Err(errPickNode).RawJSON("written", logger.Proto(writeRequest)).Msg("failed to pick an available node")
 			reply(writeRequest.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeRequest.GetMessageId(), measure, ms.sampled)
 			continue
 		}
-		message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, iwr)
-		_, errWritePub := publisher.Publish(ctx, data.TopicMeasureWrite, message)
+		quorum := ms.writeQuorum.of(len(nodeIDs))
+		sentNodeIDs, handedOff, errWritePub := publishReplicas(ctx, publisher, data.TopicMeasureWrite, nodeIDs, quorum, iwr, ms.handoff)
 		if errWritePub != nil {
-			ms.sampled.Error().Err(errWritePub).RawJSON("written", logger.Proto(writeRequest)).Str("nodeID", nodeID).Msg("failed to send a message")
+			ms.sampled.Error().Err(errWritePub).RawJSON("written", logger.Proto(writeRequest)).Strs("nodeIDs", nodeIDs).Msg("failed to send a message")
 			var ce *common.Error
 			if errors.As(errWritePub, &ce) {
 				reply(writeRequest.GetMetadata(), ce.Status(), writeRequest.GetMessageId(), measure, ms.sampled)
@@ -174,9 +182,13 @@ func (ms *measureService) Write(measure measurev1.MeasureService_WriteServer) er
 			continue
 		}
 		succeedSent = append(succeedSent, succeedSentMessage{
-			metadata:  writeRequest.GetMetadata(),
-			messageID: writeRequest.GetMessageId(),
-			node:      nodeID,
+			metadata:      writeRequest.GetMetadata(),
+			messageID:     writeRequest.GetMessageId(),
+			message:       iwr,
+			nodes:         sentNodeIDs,
+			quorum:        quorum,
+			handedOff:     handedOff,
+			handoffCounts: ms.handoff.counts(len(nodeIDs)),
 		})
 	}
 }
@@ -215,7 +227,14 @@ func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest
 			span.Stop()
 		}()
 	}
-	feat, err := ms.broadcaster.Publish(ctx, data.TopicMeasureQuery, bus.NewMessage(bus.MessageID(now.UnixNano()), req))
+	if ms.queryCache == nil || req.Trace {
+		return ms.query(ctx, req)
+	}
+	return ms.queryCache.queryMeasure(ctx, req, ms.query)
+}
+
+func (ms *measureService) query(ctx context.Context, req *measurev1.QueryRequest) (*measurev1.QueryResponse, error) {
+	feat, err := ms.broadcaster.Publish(ctx, data.TopicMeasureQuery, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req))
 	if err != nil {
 		return nil, err
 	}
@@ -231,11 +250,112 @@ func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest
 	case *measurev1.QueryResponse:
 		return d, nil
 	case *common.Error:
-		return nil, errors.WithMessage(errQueryMsg, d.Error())
+		return nil, queryError(d)
 	}
 	return nil, nil
 }
 
+// QueryStream sends the data points in batches.
+// The raw data points are paged by a cursor, which narrows the query to the data points after the last batch,
+// and the next page isn't requested from the data nodes until the previous one has been sent.
+// The aggregated results are bounded by the groups, so they are queried at once.
+func (ms *measureService) QueryStream(req *measurev1.QueryRequest, server measurev1.MeasureService_QueryStreamServer) (err error) {
+	for _, g := range req.Groups {
+		ms.metrics.totalStarted.Inc(1, g, "measure", "query_stream")
+	}
+	start := time.Now()
+	defer func() {
+		for _, g := range req.Groups {
+			ms.metrics.totalFinished.Inc(1, g, "measure", "query_stream")
+			if err != nil {
+				ms.metrics.totalErr.Inc(1, g, "measure", "query_stream")
+			}
+			ms.metrics.totalLatency.Inc(time.Since(start).Seconds(), g, "measure", "query_stream")
+		}
+	}()
+	if req.Trace {
+		return status.Error(codes.InvalidArgument, "tracing is not supported by the streaming query")
+	}
+	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
+	}
+	ctx := server.Context()
+	if req.GetGroupBy() != nil || req.GetAgg() != nil || len(req.GetAggregations()) > 0 || req.GetTop() != nil {
+		resp, errQuery := ms.query(ctx, req)
+		if errQuery != nil {
+			return errQuery
+		}
+		dataPoints := resp.GetDataPoints()
+		for len(dataPoints) > 0 {
+			n := min(len(dataPoints), int(ms.queryBatchSize))
+			if err = server.Send(&measurev1.QueryResponse{DataPoints: dataPoints[:n]}); err != nil {
+				return err
+			}
+			dataPoints = dataPoints[n:]
+		}
+		return nil
+	}
+	cursor, err := ms.newCursor(ctx, req)
+	if err != nil {
+		return err
+	}
+	page := proto.Clone(req).(*measurev1.QueryRequest)
+	var sent uint32
+	for {
+		if err = ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		size := batchSize(ms.queryBatchSize, req.GetLimit(), sent)
+		if sent == 0 {
+			page.Limit = size
+		} else {
+			page = cursor.seek(req, size)
+		}
+		resp, errQuery := ms.query(ctx, page)
+		if errQuery != nil {
+			return errQuery
+		}
+		dataPoints, errSkip := cursor.skip(resp.DataPoints)
+		if errSkip != nil {
+			return status.Error(codes.FailedPrecondition, errSkip.Error())
+		}
+		if len(dataPoints) > int(size) {
+			dataPoints = dataPoints[:size]
+		}
+		if len(dataPoints) > 0 {
+			if err = server.Send(&measurev1.QueryResponse{DataPoints: dataPoints}); err != nil {
+				return err
+			}
+			sent += uint32(len(dataPoints))
+		}
+		if len(dataPoints) == 0 || len(resp.DataPoints) < int(page.Limit) || (req.GetLimit() > 0 && sent >= req.GetLimit()) {
+			return nil
+		}
+		if err = cursor.advance(dataPoints); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+	}
+}
+
+// newCursor creates the cursor of the raw data points, which are ordered by time or the tag of an index rule.
+func (ms *measureService) newCursor(ctx context.Context, req *measurev1.QueryRequest) (*measureCursor, error) {
+	ruleName := req.GetOrderBy().GetIndexRuleName()
+	if ruleName == "" {
+		return newMeasureCursor(req.GetOrderBy(), ""), nil
+	}
+	if len(req.GetGroups()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "groups is empty")
+	}
+	rule, err := ms.metadataRepo.IndexRuleRegistry().GetIndexRule(ctx, &commonv1.Metadata{Name: ruleName, Group: req.Groups[0]})
+	if err != nil {
+		return nil, err
+	}
+	if len(rule.GetTags()) != 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "index rule %s should have only one tag", ruleName)
+	}
+	return newMeasureCursor(req.GetOrderBy(), rule.GetTags()[0]), nil
+}
+
 func (ms *measureService) TopN(ctx context.Context, topNRequest *measurev1.TopNRequest) (resp *measurev1.TopNResponse, err error) {
 	if err = timestamp.CheckTimeRange(topNRequest.GetTimeRange()); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", topNRequest.GetTimeRange(), err)
@@ -255,7 +375,14 @@ func (ms *measureService) TopN(ctx context.Context, topNRequest *measurev1.TopNR
 			span.Stop()
 		}()
 	}
-	message := bus.NewMessage(bus.MessageID(now.UnixNano()), topNRequest)
+	if ms.queryCache == nil || topNRequest.Trace {
+		return ms.topN(ctx, topNRequest)
+	}
+	return ms.queryCache.queryTopN(ctx, topNRequest, ms.topN)
+}
+
+func (ms *measureService) topN(ctx context.Context, topNRequest *measurev1.TopNRequest) (*measurev1.TopNResponse, error) {
+	message := bus.NewMessage(bus.MessageID(time.Now().UnixNano()), topNRequest)
 	feat, errQuery := ms.broadcaster.Publish(ctx, data.TopicTopNQuery, message)
 	if errQuery != nil {
 		return nil, errQuery
@@ -277,9 +404,3 @@ func (ms *measureService) TopN(ctx context.Context, topNRequest *measurev1.TopNR
 func (ms *measureService) Close() error {
 	return ms.ingestionAccessLog.Close()
 }
-
-type succeedSentMessage struct {
-	metadata  *commonv1.Metadata
-	node      string
-	messageID uint64
-}
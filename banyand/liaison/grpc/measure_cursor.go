@@ -0,0 +1,163 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+var errSortTagNotProjected = errors.New("the sort tag should be projected to stream the data points ordered by an index rule")
+
+type dataPointKey struct {
+	sid       uint64
+	timestamp int64
+}
+
+// measureCursor is the position of a streaming query over the raw data points.
+// All the data points before the sort value have been sent,
+// and so have the ones with the sort value whose keys are recorded.
+type measureCursor struct {
+	sortValue *modelv1.TagValue
+	sent      map[dataPointKey]struct{}
+	// sortTag is the tag of the index rule which orders the data points. They're ordered by time if it's empty.
+	sortTag string
+	desc    bool
+}
+
+func newMeasureCursor(orderBy *modelv1.QueryOrder, sortTag string) *measureCursor {
+	return &measureCursor{
+		sortTag: sortTag,
+		desc:    orderBy.GetSort() == modelv1.Sort_SORT_DESC,
+		sent:    make(map[dataPointKey]struct{}),
+	}
+}
+
+func (c *measureCursor) valueOf(dp *measurev1.DataPoint) (*modelv1.TagValue, error) {
+	if c.sortTag == "" {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: dp.Timestamp.AsTime().UnixNano()}}}, nil
+	}
+	for _, tf := range dp.TagFamilies {
+		for _, t := range tf.Tags {
+			if t.Key == c.sortTag {
+				return t.Value, nil
+			}
+		}
+	}
+	return nil, errors.WithMessage(errSortTagNotProjected, c.sortTag)
+}
+
+// skip drops the data points which have been sent in the previous batches.
+func (c *measureCursor) skip(dataPoints []*measurev1.DataPoint) ([]*measurev1.DataPoint, error) {
+	if c.sortValue == nil {
+		return dataPoints, nil
+	}
+	result := dataPoints[:0]
+	for _, dp := range dataPoints {
+		v, err := c.valueOf(dp)
+		if err != nil {
+			return nil, err
+		}
+		if proto.Equal(v, c.sortValue) {
+			if _, ok := c.sent[keyOf(dp)]; ok {
+				continue
+			}
+		}
+		result = append(result, dp)
+	}
+	return result, nil
+}
+
+// advance moves the cursor past the sent data points.
+func (c *measureCursor) advance(dataPoints []*measurev1.DataPoint) error {
+	if len(dataPoints) == 0 {
+		return nil
+	}
+	last, err := c.valueOf(dataPoints[len(dataPoints)-1])
+	if err != nil {
+		return err
+	}
+	if !proto.Equal(c.sortValue, last) {
+		c.sortValue = last
+		clear(c.sent)
+	}
+	for i := len(dataPoints) - 1; i >= 0; i-- {
+		v, err := c.valueOf(dataPoints[i])
+		if err != nil {
+			return err
+		}
+		if !proto.Equal(v, last) {
+			break
+		}
+		c.sent[keyOf(dataPoints[i])] = struct{}{}
+	}
+	return nil
+}
+
+// seek narrows the query to start from the cursor. The data points with the cursor's sort value are still selected,
+// so the limit covers the ones which have been sent and have to be skipped.
+func (c *measureCursor) seek(req *measurev1.QueryRequest, size uint32) *measurev1.QueryRequest {
+	page := proto.Clone(req).(*measurev1.QueryRequest)
+	page.Offset, page.Limit = 0, size+uint32(len(c.sent))
+	if c.sortTag == "" {
+		ts := timestamppb.New(time.Unix(0, c.sortValue.GetInt().GetValue()))
+		if c.desc {
+			page.TimeRange.End = ts
+		} else {
+			page.TimeRange.Begin = ts
+		}
+		return page
+	}
+	op := modelv1.Condition_BINARY_OP_GE
+	if c.desc {
+		op = modelv1.Condition_BINARY_OP_LE
+	}
+	cond := &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Condition{
+			Condition: &modelv1.Condition{
+				Name:  c.sortTag,
+				Op:    op,
+				Value: c.sortValue,
+			},
+		},
+	}
+	if page.Criteria == nil {
+		page.Criteria = cond
+		return page
+	}
+	page.Criteria = &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Le{
+			Le: &modelv1.LogicalExpression{
+				Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+				Left:  cond,
+				Right: page.Criteria,
+			},
+		},
+	}
+	return page
+}
+
+func keyOf(dp *measurev1.DataPoint) dataPointKey {
+	return dataPointKey{sid: dp.Sid, timestamp: dp.Timestamp.AsTime().UnixNano()}
+}
@@ -43,6 +43,25 @@ type metrics struct {
 	totalRegistryFinished meter.Counter
 	totalRegistryErr      meter.Counter
 	totalRegistryLatency  meter.Counter
+
+	totalQueryCacheHit  meter.Counter
+	totalQueryCacheMiss meter.Counter
+
+	totalHandoff         meter.Counter
+	totalHandoffReplayed meter.Counter
+	totalHandoffRejected meter.Counter
+	totalHandoffExpired  meter.Counter
+	handoffBacklogCount  meter.Gauge
+	handoffBacklogSize   meter.Gauge
+	handoffBacklogAge    meter.Gauge
+
+	totalTenantWriteReceived  meter.Counter
+	totalTenantWriteThrottled meter.Counter
+	totalTenantWriteRejected  meter.Counter
+	totalTenantQueryRejected  meter.Counter
+	tenantActiveSeries        meter.Gauge
+	tenantRunningQueries      meter.Gauge
+	tenantStorageBytes        meter.Gauge
 }
 
 func newMetrics(factory *observability.Factory) *metrics {
@@ -64,5 +83,21 @@ func newMetrics(factory *observability.Factory) *metrics {
 		totalRegistryFinished:     factory.NewCounter("total_registry_finished", "group", "service", "method"),
 		totalRegistryErr:          factory.NewCounter("total_registry_err", "group", "service", "method"),
 		totalRegistryLatency:      factory.NewCounter("total_registry_latency", "group", "service", "method"),
+		totalQueryCacheHit:        factory.NewCounter("total_query_cache_hit", "service", "method"),
+		totalQueryCacheMiss:       factory.NewCounter("total_query_cache_miss", "service", "method"),
+		totalHandoff:              factory.NewCounter("total_handoff", "node"),
+		totalHandoffReplayed:      factory.NewCounter("total_handoff_replayed", "node"),
+		totalHandoffRejected:      factory.NewCounter("total_handoff_rejected", "node"),
+		totalHandoffExpired:       factory.NewCounter("total_handoff_expired", "node"),
+		handoffBacklogCount:       factory.NewGauge("handoff_backlog_count", "node"),
+		handoffBacklogSize:        factory.NewGauge("handoff_backlog_size", "node"),
+		handoffBacklogAge:         factory.NewGauge("handoff_backlog_age", "node"),
+		totalTenantWriteReceived:  factory.NewCounter("total_tenant_write_received", "tenant"),
+		totalTenantWriteThrottled: factory.NewCounter("total_tenant_write_throttled", "tenant"),
+		totalTenantWriteRejected:  factory.NewCounter("total_tenant_write_rejected", "tenant", "reason"),
+		totalTenantQueryRejected:  factory.NewCounter("total_tenant_query_rejected", "tenant"),
+		tenantActiveSeries:        factory.NewGauge("tenant_active_series", "tenant"),
+		tenantRunningQueries:      factory.NewGauge("tenant_running_queries", "tenant"),
+		tenantStorageBytes:        factory.NewGauge("tenant_storage_bytes", "tenant"),
 	}
 }
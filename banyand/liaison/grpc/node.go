@@ -18,6 +18,7 @@
 package grpc
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -40,9 +41,19 @@ var (
 // together with the shardID calculated from the incoming data.
 type NodeRegistry interface {
 	Locate(group, name string, shardID uint32) (string, error)
+	// LocateReplicas returns the data nodes holding the copies of the shard.
+	LocateReplicas(group, name string, shardID uint32) ([]string, error)
+	// Rebalance returns the shards of the group which should be moved to spread the shards evenly over the data nodes.
+	Rebalance(group string) ([]node.Move, error)
+	// Assign routes the shard of the group to the data node.
+	Assign(ctx context.Context, group string, shardID uint32, nodeID string) error
+	// Exclude stops routing the data to the data node, which is being drained.
+	Exclude(node *databasev1.Node)
 	fmt.Stringer
 }
 
+var errRebalanceUnsupported = errors.New("the node selector doesn't support rebalancing the shards")
+
 type clusterNodeService struct {
 	schema.UnimplementedOnInitHandler
 	pipeline queue.Client
@@ -74,6 +85,38 @@ func (n *clusterNodeService) Locate(group, name string, shardID uint32) (string,
 	return nodeID, nil
 }
 
+func (n *clusterNodeService) LocateReplicas(group, name string, shardID uint32) ([]string, error) {
+	nodeIDs, err := n.sel.PickReplicas(group, name, shardID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to locate the replicas of %s/%s(%d)", group, name, shardID)
+	}
+	return nodeIDs, nil
+}
+
+func (n *clusterNodeService) Rebalance(group string) ([]node.Move, error) {
+	b, ok := n.sel.(node.Balancer)
+	if !ok {
+		return nil, errRebalanceUnsupported
+	}
+	moves, err := b.Rebalance(group)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "fail to rebalance %s", group)
+	}
+	return moves, nil
+}
+
+func (n *clusterNodeService) Assign(ctx context.Context, group string, shardID uint32, nodeID string) error {
+	b, ok := n.sel.(node.Balancer)
+	if !ok {
+		return errRebalanceUnsupported
+	}
+	return b.Assign(ctx, group, shardID, nodeID)
+}
+
+func (n *clusterNodeService) Exclude(node *databasev1.Node) {
+	n.sel.RemoveNode(node)
+}
+
 func (n *clusterNodeService) OnAddOrUpdate(metadata schema.Metadata) {
 	switch metadata.Kind {
 	case schema.KindNode:
@@ -117,3 +160,21 @@ func NewLocalNodeRegistry() NodeRegistry {
 func (localNodeService) Locate(_, _ string, _ uint32) (string, error) {
 	return "local", nil
 }
+
+// LocateReplicas of localNodeService always returns local.
+func (localNodeService) LocateReplicas(_, _ string, _ uint32) ([]string, error) {
+	return []string{"local"}, nil
+}
+
+// Rebalance of localNodeService never moves the shards.
+func (localNodeService) Rebalance(_ string) ([]node.Move, error) {
+	return nil, nil
+}
+
+// Assign of localNodeService does nothing.
+func (localNodeService) Assign(_ context.Context, _ string, _ uint32, _ string) error {
+	return nil
+}
+
+// Exclude of localNodeService does nothing.
+func (localNodeService) Exclude(_ *databasev1.Node) {}
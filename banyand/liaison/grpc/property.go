@@ -128,8 +128,8 @@ func (ps *propertyServer) Apply(ctx context.Context, req *propertyv1.ApplyReques
 		found := false
 		for _, ts := range propSchema.Tags {
 			if ts.Name == tag.Key {
-				typ := databasev1.TagType(pbv1.MustTagValueToValueType(tag.Value))
-				if typ != databasev1.TagType_TAG_TYPE_UNSPECIFIED && ts.Type != typ {
+				typ, isNull := pbv1.TagValueTypeConv(tag.Value)
+				if !isNull && ts.Type != typ {
 					return nil, errors.Errorf("property %s tag %s type mismatch", property.Metadata.Name, tag.Key)
 				}
 				found = true
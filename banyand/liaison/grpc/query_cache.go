@@ -0,0 +1,250 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+)
+
+// defaultQueryLimit mirrors the limit the measure query plans apply when a request leaves it empty.
+const defaultQueryLimit uint32 = 100
+
+var (
+	errInvalidCacheSize = errors.New("query cache size should be positive")
+	errInvalidHorizon   = errors.New("query cache flush horizon should be positive")
+
+	_ schema.EventHandler = (*queryCache)(nil)
+)
+
+type measureFetcher func(context.Context, *measurev1.QueryRequest) (*measurev1.QueryResponse, error)
+
+type topNFetcher func(context.Context, *measurev1.TopNRequest) (*measurev1.TopNResponse, error)
+
+// queryCache caches the results of queries whose time range is older than the flush horizon.
+// Data points before the horizon have been flushed to disk and are regarded as immutable,
+// so the results can be reused until the schema of the groups they touched changes.
+type queryCache struct {
+	schema.UnimplementedOnInitHandler
+	entries      *lru.Cache
+	metrics      *metrics
+	flushHorizon time.Duration
+}
+
+type queryCacheEntry struct {
+	value  proto.Message
+	groups []string
+}
+
+func newQueryCache(size int, flushHorizon time.Duration) (*queryCache, error) {
+	if size <= 0 {
+		return nil, errInvalidCacheSize
+	}
+	if flushHorizon <= 0 {
+		return nil, errInvalidHorizon
+	}
+	entries, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &queryCache{
+		entries:      entries,
+		flushHorizon: flushHorizon,
+	}, nil
+}
+
+// horizon returns the boundary between the immutable and the live data.
+// It is aligned to the flush horizon so that the split point stays stable between
+// consecutive dashboard refreshes, which keeps the historical part hittable.
+func (qc *queryCache) horizon(now time.Time) time.Time {
+	return now.Add(-qc.flushHorizon).Truncate(qc.flushHorizon)
+}
+
+func (qc *queryCache) get(key string) (proto.Message, bool) {
+	v, ok := qc.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return v.(*queryCacheEntry).value, true
+}
+
+func (qc *queryCache) put(key string, groups []string, value proto.Message) {
+	qc.entries.Add(key, &queryCacheEntry{
+		groups: groups,
+		value:  value,
+	})
+}
+
+// invalidate drops all the entries which touch the group.
+func (qc *queryCache) invalidate(group string) {
+	for _, k := range qc.entries.Keys() {
+		v, ok := qc.entries.Peek(k)
+		if !ok {
+			continue
+		}
+		if slices.Contains(v.(*queryCacheEntry).groups, group) {
+			qc.entries.Remove(k)
+		}
+	}
+}
+
+// OnAddOrUpdate implements schema.EventHandler.
+func (qc *queryCache) OnAddOrUpdate(metadata schema.Metadata) {
+	qc.onSchemaChanged(metadata)
+}
+
+// OnDelete implements schema.EventHandler.
+func (qc *queryCache) OnDelete(metadata schema.Metadata) {
+	qc.onSchemaChanged(metadata)
+}
+
+func (qc *queryCache) onSchemaChanged(metadata schema.Metadata) {
+	switch metadata.Kind {
+	case schema.KindGroup:
+		qc.invalidate(metadata.Name)
+	case schema.KindMeasure, schema.KindIndexRule, schema.KindIndexRuleBinding, schema.KindTopNAggregation:
+		qc.invalidate(metadata.Group)
+	default:
+	}
+}
+
+func (qc *queryCache) queryMeasure(ctx context.Context, req *measurev1.QueryRequest, fetch measureFetcher) (*measurev1.QueryResponse, error) {
+	horizon := qc.horizon(time.Now())
+	begin, end := req.GetTimeRange().GetBegin().AsTime(), req.GetTimeRange().GetEnd().AsTime()
+	if !begin.Before(horizon) {
+		return fetch(ctx, req)
+	}
+	if end.Before(horizon) {
+		return qc.fetchMeasure(ctx, req, fetch)
+	}
+	if !isSplittable(req) {
+		return fetch(ctx, req)
+	}
+	limit := req.GetLimit()
+	if limit == 0 {
+		limit = defaultQueryLimit
+	}
+	historical := proto.Clone(req).(*measurev1.QueryRequest)
+	historical.Offset, historical.Limit = 0, req.GetOffset()+limit
+	// Both edges of the time range are inclusive in the measure query plans.
+	historical.TimeRange = &modelv1.TimeRange{
+		Begin: req.GetTimeRange().GetBegin(),
+		End:   timestamppb.New(horizon.Add(-time.Nanosecond)),
+	}
+	live := proto.Clone(req).(*measurev1.QueryRequest)
+	live.Offset, live.Limit = 0, req.GetOffset()+limit
+	live.TimeRange = &modelv1.TimeRange{
+		Begin: timestamppb.New(horizon),
+		End:   req.GetTimeRange().GetEnd(),
+	}
+	historicalResp, err := qc.fetchMeasure(ctx, historical, fetch)
+	if err != nil {
+		return nil, err
+	}
+	liveResp, err := fetch(ctx, live)
+	if err != nil {
+		return nil, err
+	}
+	first, second := historicalResp.GetDataPoints(), liveResp.GetDataPoints()
+	if req.GetOrderBy().GetSort() == modelv1.Sort_SORT_DESC {
+		first, second = second, first
+	}
+	dataPoints := make([]*measurev1.DataPoint, 0, len(first)+len(second))
+	dataPoints = append(dataPoints, first...)
+	dataPoints = append(dataPoints, second...)
+	if int(req.GetOffset()) >= len(dataPoints) {
+		return emptyMeasureQueryResponse, nil
+	}
+	dataPoints = dataPoints[req.GetOffset():]
+	if int(limit) < len(dataPoints) {
+		dataPoints = dataPoints[:limit]
+	}
+	return &measurev1.QueryResponse{DataPoints: dataPoints}, nil
+}
+
+func (qc *queryCache) fetchMeasure(ctx context.Context, req *measurev1.QueryRequest, fetch measureFetcher) (*measurev1.QueryResponse, error) {
+	normalized := proto.Clone(req).(*measurev1.QueryRequest)
+	slices.Sort(normalized.Groups)
+	key, err := cacheKey("measure", normalized)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := qc.get(key); ok {
+		qc.metrics.totalQueryCacheHit.Inc(1, "measure", "query")
+		return v.(*measurev1.QueryResponse), nil
+	}
+	qc.metrics.totalQueryCacheMiss.Inc(1, "measure", "query")
+	resp, err := fetch(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	qc.put(key, normalized.Groups, resp)
+	return resp, nil
+}
+
+func (qc *queryCache) queryTopN(ctx context.Context, req *measurev1.TopNRequest, fetch topNFetcher) (*measurev1.TopNResponse, error) {
+	// The TopN lists are aggregated over the whole time range, so they can not be split.
+	if !req.GetTimeRange().GetEnd().AsTime().Before(qc.horizon(time.Now())) {
+		return fetch(ctx, req)
+	}
+	normalized := proto.Clone(req).(*measurev1.TopNRequest)
+	slices.Sort(normalized.Groups)
+	key, err := cacheKey("topn", normalized)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := qc.get(key); ok {
+		qc.metrics.totalQueryCacheHit.Inc(1, "measure", "topn")
+		return v.(*measurev1.TopNResponse), nil
+	}
+	qc.metrics.totalQueryCacheMiss.Inc(1, "measure", "topn")
+	resp, err := fetch(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	qc.put(key, normalized.Groups, resp)
+	return resp, nil
+}
+
+// isSplittable checks whether the result of a query equals
+// the concatenation of the results of its sub time ranges.
+// It holds only if data points are returned in the time order without any further processing.
+func isSplittable(req *measurev1.QueryRequest) bool {
+	if req.GetGroupBy() != nil || req.GetAgg() != nil || len(req.GetAggregations()) > 0 || req.GetTop() != nil {
+		return false
+	}
+	return req.GetOrderBy().GetIndexRuleName() == ""
+}
+
+func cacheKey(kind string, req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to marshal the request as the cache key")
+	}
+	return kind + ":" + string(data), nil
+}
@@ -0,0 +1,168 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+)
+
+type fakeMeasureFetcher struct {
+	requests []*measurev1.QueryRequest
+	step     time.Duration
+}
+
+// fetch returns a data point per step within the requested time range.
+func (f *fakeMeasureFetcher) fetch(_ context.Context, req *measurev1.QueryRequest) (*measurev1.QueryResponse, error) {
+	f.requests = append(f.requests, req)
+	resp := &measurev1.QueryResponse{}
+	begin, end := req.GetTimeRange().GetBegin().AsTime(), req.GetTimeRange().GetEnd().AsTime()
+	for t := begin; !t.After(end); t = t.Add(f.step) {
+		resp.DataPoints = append(resp.DataPoints, &measurev1.DataPoint{Timestamp: timestamppb.New(t)})
+	}
+	if req.GetOrderBy().GetSort() == modelv1.Sort_SORT_DESC {
+		for i, j := 0, len(resp.DataPoints)-1; i < j; i, j = i+1, j-1 {
+			resp.DataPoints[i], resp.DataPoints[j] = resp.DataPoints[j], resp.DataPoints[i]
+		}
+	}
+	if int(req.GetLimit()) < len(resp.DataPoints) {
+		resp.DataPoints = resp.DataPoints[:req.GetLimit()]
+	}
+	return resp, nil
+}
+
+func newTestQueryCache(t *testing.T) *queryCache {
+	qc, err := newQueryCache(10, time.Hour)
+	require.NoError(t, err)
+	qc.metrics = newMetrics(&observability.Factory{})
+	return qc
+}
+
+func newTestQueryRequest(begin, end time.Time) *measurev1.QueryRequest {
+	return &measurev1.QueryRequest{
+		Groups:    []string{"sw_metric"},
+		Name:      "service_cpm_minute",
+		TimeRange: &modelv1.TimeRange{Begin: timestamppb.New(begin), End: timestamppb.New(end)},
+		Limit:     1000,
+	}
+}
+
+func TestQueryCacheHistoricalRange(t *testing.T) {
+	qc := newTestQueryCache(t)
+	f := &fakeMeasureFetcher{step: time.Minute}
+	end := qc.horizon(time.Now()).Add(-time.Hour)
+	req := newTestQueryRequest(end.Add(-10*time.Minute), end)
+
+	resp, err := qc.queryMeasure(context.Background(), req, f.fetch)
+	require.NoError(t, err)
+	assert.Len(t, resp.DataPoints, 11)
+	resp, err = qc.queryMeasure(context.Background(), req, f.fetch)
+	require.NoError(t, err)
+	assert.Len(t, resp.DataPoints, 11)
+	assert.Len(t, f.requests, 1)
+
+	qc.OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindGroup, Name: "sw_metric"},
+		Spec:     &commonv1.Group{Metadata: &commonv1.Metadata{Name: "sw_metric"}},
+	})
+	_, err = qc.queryMeasure(context.Background(), req, f.fetch)
+	require.NoError(t, err)
+	assert.Len(t, f.requests, 2)
+}
+
+func TestQueryCacheLiveRange(t *testing.T) {
+	qc := newTestQueryCache(t)
+	f := &fakeMeasureFetcher{step: time.Minute}
+	now := time.Now()
+	req := newTestQueryRequest(now.Add(-10*time.Minute), now)
+
+	for i := 0; i < 2; i++ {
+		_, err := qc.queryMeasure(context.Background(), req, f.fetch)
+		require.NoError(t, err)
+	}
+	assert.Len(t, f.requests, 2)
+	assert.Equal(t, 0, qc.entries.Len())
+}
+
+func TestQueryCacheSplitRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   modelv1.Sort
+		offset uint32
+		limit  uint32
+	}{
+		{name: "asc", sort: modelv1.Sort_SORT_ASC, limit: 1000},
+		{name: "desc", sort: modelv1.Sort_SORT_DESC, limit: 1000},
+		{name: "asc with pagination", sort: modelv1.Sort_SORT_ASC, offset: 30, limit: 50},
+		{name: "desc with pagination", sort: modelv1.Sort_SORT_DESC, offset: 30, limit: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qc := newTestQueryCache(t)
+			horizon := qc.horizon(time.Now())
+			req := newTestQueryRequest(horizon.Add(-2*time.Hour), horizon.Add(30*time.Minute))
+			req.OrderBy = &modelv1.QueryOrder{Sort: tt.sort}
+			req.Offset, req.Limit = tt.offset, tt.limit
+			expected, err := (&fakeMeasureFetcher{step: time.Minute}).fetch(context.Background(), &measurev1.QueryRequest{
+				TimeRange: req.TimeRange,
+				OrderBy:   req.OrderBy,
+				Limit:     tt.offset + tt.limit,
+			})
+			require.NoError(t, err)
+			expected.DataPoints = expected.DataPoints[tt.offset:]
+
+			f := &fakeMeasureFetcher{step: time.Minute}
+			for i := 0; i < 2; i++ {
+				resp, err := qc.queryMeasure(context.Background(), req, f.fetch)
+				require.NoError(t, err)
+				require.Len(t, resp.DataPoints, len(expected.DataPoints))
+				for j := range expected.DataPoints {
+					assert.Equal(t, expected.DataPoints[j].Timestamp.AsTime(), resp.DataPoints[j].Timestamp.AsTime())
+				}
+			}
+			// The historical part is fetched once, and the live part is fetched every time.
+			assert.Len(t, f.requests, 3)
+			assert.Equal(t, 1, qc.entries.Len())
+		})
+	}
+}
+
+func TestQueryCacheUnsplittable(t *testing.T) {
+	qc := newTestQueryCache(t)
+	f := &fakeMeasureFetcher{step: time.Minute}
+	horizon := qc.horizon(time.Now())
+	req := newTestQueryRequest(horizon.Add(-2*time.Hour), horizon.Add(30*time.Minute))
+	req.Agg = &measurev1.QueryRequest_Aggregation{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, FieldName: "total"}
+
+	_, err := qc.queryMeasure(context.Background(), req, f.fetch)
+	require.NoError(t, err)
+	require.Len(t, f.requests, 1)
+	assert.Equal(t, req.TimeRange, f.requests[0].TimeRange)
+	assert.Equal(t, 0, qc.entries.Len())
+}
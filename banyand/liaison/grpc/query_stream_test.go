@@ -0,0 +1,244 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+type fakeFuture struct {
+	data any
+}
+
+func (f fakeFuture) Get() (bus.Message, error) {
+	return bus.NewMessage(0, f.data), nil
+}
+
+func (f fakeFuture) GetAll() ([]bus.Message, error) {
+	return []bus.Message{bus.NewMessage(0, f.data)}, nil
+}
+
+// fakeQueryBroadcaster serves the queries over a fixed number of rows.
+// A stream page ends with a continuation token which is the index of the next row unless it's sorted by the sort keys.
+type fakeQueryBroadcaster struct {
+	queue.Client
+	base     time.Time
+	requests []any
+	total    int
+}
+
+func (f *fakeQueryBroadcaster) Publish(_ context.Context, _ bus.Topic, messages ...bus.Message) (bus.Future, error) {
+	req := messages[0].Data()
+	f.requests = append(f.requests, req)
+	switch r := req.(type) {
+	case *streamv1.QueryRequest:
+		begin := 0
+		if len(r.ContinuationToken) > 0 {
+			begin, _ = strconv.Atoi(string(r.ContinuationToken))
+		}
+		end := min(begin+int(r.Limit), f.total)
+		resp := &streamv1.QueryResponse{}
+		for i := begin; i < end; i++ {
+			resp.Elements = append(resp.Elements, &streamv1.Element{ElementId: strconv.Itoa(i)})
+		}
+		if end-begin == int(r.Limit) && len(r.SortKeys) == 0 {
+			resp.ContinuationToken = []byte(strconv.Itoa(end))
+		}
+		return fakeFuture{data: resp}, nil
+	case *measurev1.QueryRequest:
+		// Every three data points share a timestamp, which are ordered by time in the time range.
+		var selected []*measurev1.DataPoint
+		for i := 0; i < f.total; i++ {
+			ts := f.base.Add(time.Duration(i/3) * time.Second)
+			if ts.Before(r.TimeRange.Begin.AsTime()) || ts.After(r.TimeRange.End.AsTime()) {
+				continue
+			}
+			selected = append(selected, &measurev1.DataPoint{Sid: uint64(i), Timestamp: timestamppb.New(ts)})
+		}
+		if r.GetOrderBy().GetSort() == modelv1.Sort_SORT_DESC {
+			slices.Reverse(selected)
+		}
+		begin := min(int(r.Offset), len(selected))
+		end := min(begin+int(r.Limit), len(selected))
+		return fakeFuture{data: &measurev1.QueryResponse{DataPoints: selected[begin:end]}}, nil
+	}
+	return nil, nil
+}
+
+type fakeQueryStreamServer[T any] struct {
+	grpc.ServerStream
+	ctx     context.Context
+	batches []*T
+}
+
+func (f *fakeQueryStreamServer[T]) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeQueryStreamServer[T]) Send(resp *T) error {
+	f.batches = append(f.batches, resp)
+	return nil
+}
+
+func newTestTimeRange() *modelv1.TimeRange {
+	now := time.Now().Truncate(time.Millisecond)
+	return &modelv1.TimeRange{Begin: timestamppb.New(now.Add(-time.Hour)), End: timestamppb.New(now)}
+}
+
+func TestStreamQueryStream(t *testing.T) {
+	sortKeys := []*modelv1.SortKey{{TagName: "duration", Sort: modelv1.Sort_SORT_DESC}}
+	tests := []struct {
+		name     string
+		sortKeys []*modelv1.SortKey
+		batches  []int
+		limit    uint32
+		requests int
+	}{
+		{name: "all", limit: 0, batches: []int{10, 10, 5}, requests: 3},
+		{name: "limited", limit: 15, batches: []int{10, 5}, requests: 2},
+		// The sort keys are queried at once, whose elements are split into the batches.
+		{name: "sort keys", limit: 25, sortKeys: sortKeys, batches: []int{10, 10, 5}, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &fakeQueryBroadcaster{total: 25}
+			s := &streamService{broadcaster: b, metrics: newMetrics(&observability.Factory{}), queryBatchSize: 10}
+			server := &fakeQueryStreamServer[streamv1.QueryResponse]{ctx: context.Background()}
+			err := s.QueryStream(&streamv1.QueryRequest{
+				Groups: []string{"default"}, Limit: tt.limit, TimeRange: newTestTimeRange(), SortKeys: tt.sortKeys,
+			}, server)
+			require.NoError(t, err)
+			require.Len(t, b.requests, tt.requests)
+			require.Len(t, server.batches, len(tt.batches))
+			id := 0
+			for i, n := range tt.batches {
+				require.Len(t, server.batches[i].Elements, n)
+				for _, e := range server.batches[i].Elements {
+					assert.Equal(t, strconv.Itoa(id), e.ElementId)
+					id++
+				}
+			}
+		})
+	}
+}
+
+func TestStreamQueryStreamCanceled(t *testing.T) {
+	b := &fakeQueryBroadcaster{total: 25}
+	s := &streamService{broadcaster: b, metrics: newMetrics(&observability.Factory{}), queryBatchSize: 10}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	server := &fakeQueryStreamServer[streamv1.QueryResponse]{ctx: ctx}
+	err := s.QueryStream(&streamv1.QueryRequest{Groups: []string{"default"}, TimeRange: newTestTimeRange()}, server)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Empty(t, b.requests)
+}
+
+func TestMeasureQueryStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		order modelv1.Sort
+		want  []uint64
+	}{
+		{name: "asc", order: modelv1.Sort_SORT_ASC, want: sequence(3, 25, 1)},
+		{name: "desc", order: modelv1.Sort_SORT_DESC, want: sequence(21, -1, -1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTestTimeRange()
+			b := &fakeQueryBroadcaster{total: 25, base: tr.Begin.AsTime()}
+			ms := &measureService{broadcaster: b, metrics: newMetrics(&observability.Factory{}), queryBatchSize: 10}
+			server := &fakeQueryStreamServer[measurev1.QueryResponse]{ctx: context.Background()}
+			err := ms.QueryStream(&measurev1.QueryRequest{
+				Groups: []string{"sw_metric"}, Offset: 3, TimeRange: tr,
+				OrderBy: &modelv1.QueryOrder{Sort: tt.order},
+			}, server)
+			require.NoError(t, err)
+			require.Len(t, server.batches, 3)
+			var got []uint64
+			for _, batch := range server.batches {
+				for _, dp := range batch.DataPoints {
+					got = append(got, dp.Sid)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+			// The following pages start from the cursor instead of re-scanning the previous ones by the offset.
+			require.Len(t, b.requests, 3)
+			for _, r := range b.requests[1:] {
+				assert.Zero(t, r.(*measurev1.QueryRequest).Offset)
+			}
+		})
+	}
+}
+
+func sequence(from, to, step int) []uint64 {
+	var result []uint64
+	for i := from; i != to; i += step {
+		result = append(result, uint64(i))
+	}
+	return result
+}
+
+func TestMeasureCursorOrderedByIndex(t *testing.T) {
+	dp := func(sid uint64, latency int64) *measurev1.DataPoint {
+		return &measurev1.DataPoint{
+			Sid:       sid,
+			Timestamp: timestamppb.New(time.Unix(1, 0)),
+			TagFamilies: []*modelv1.TagFamily{{Name: "default", Tags: []*modelv1.Tag{
+				{Key: "latency", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: latency}}}},
+			}}},
+		}
+	}
+	c := newMeasureCursor(&modelv1.QueryOrder{IndexRuleName: "latency", Sort: modelv1.Sort_SORT_DESC}, "latency")
+	require.NoError(t, c.advance([]*measurev1.DataPoint{dp(1, 30), dp(2, 20), dp(3, 20)}))
+	req := &measurev1.QueryRequest{Groups: []string{"sw_metric"}, Offset: 3, TimeRange: newTestTimeRange()}
+	page := c.seek(req, 10)
+	assert.Zero(t, page.Offset)
+	assert.Equal(t, uint32(12), page.Limit, "the limit covers the data points sent with the cursor's sort value")
+	cond := page.Criteria.GetCondition()
+	require.NotNil(t, cond)
+	assert.Equal(t, "latency", cond.Name)
+	assert.Equal(t, modelv1.Condition_BINARY_OP_LE, cond.Op)
+	assert.Equal(t, int64(20), cond.Value.GetInt().GetValue())
+
+	dataPoints, err := c.skip([]*measurev1.DataPoint{dp(3, 20), dp(2, 20), dp(4, 20), dp(5, 10)})
+	require.NoError(t, err)
+	require.Len(t, dataPoints, 2)
+	assert.Equal(t, uint64(4), dataPoints[0].Sid)
+	assert.Equal(t, uint64(5), dataPoints[1].Sid)
+
+	_, err = c.skip([]*measurev1.DataPoint{{Sid: 6, Timestamp: timestamppb.New(time.Unix(1, 0))}})
+	assert.ErrorIs(t, err, errSortTagNotProjected)
+}
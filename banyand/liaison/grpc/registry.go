@@ -77,6 +77,27 @@ func (rs *streamRegistryServer) Update(ctx context.Context,
 	}, nil
 }
 
+func (rs *streamRegistryServer) CheckCompatibility(ctx context.Context,
+	req *databasev1.StreamRegistryServiceCheckCompatibilityRequest,
+) (*databasev1.StreamRegistryServiceCheckCompatibilityResponse, error) {
+	g := req.Stream.GetMetadata().GetGroup()
+	rs.metrics.totalRegistryStarted.Inc(1, g, "stream", "checkCompatibility")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "stream", "checkCompatibility")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "stream", "checkCompatibility")
+	}()
+	incompatibilities, err := rs.schemaRegistry.StreamRegistry().CheckStreamCompatibility(ctx, req.GetStream())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "stream", "checkCompatibility")
+		return nil, err
+	}
+	return &databasev1.StreamRegistryServiceCheckCompatibilityResponse{
+		Compatible:        len(incompatibilities) == 0,
+		Incompatibilities: incompatibilities,
+	}, nil
+}
+
 func (rs *streamRegistryServer) Delete(ctx context.Context,
 	req *databasev1.StreamRegistryServiceDeleteRequest,
 ) (*databasev1.StreamRegistryServiceDeleteResponse, error) {
@@ -486,6 +507,27 @@ func (rs *measureRegistryServer) Update(ctx context.Context,
 	}, nil
 }
 
+func (rs *measureRegistryServer) CheckCompatibility(ctx context.Context,
+	req *databasev1.MeasureRegistryServiceCheckCompatibilityRequest,
+) (*databasev1.MeasureRegistryServiceCheckCompatibilityResponse, error) {
+	g := req.Measure.GetMetadata().GetGroup()
+	rs.metrics.totalRegistryStarted.Inc(1, g, "measure", "checkCompatibility")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "measure", "checkCompatibility")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "measure", "checkCompatibility")
+	}()
+	incompatibilities, err := rs.schemaRegistry.MeasureRegistry().CheckMeasureCompatibility(ctx, req.GetMeasure())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "measure", "checkCompatibility")
+		return nil, err
+	}
+	return &databasev1.MeasureRegistryServiceCheckCompatibilityResponse{
+		Compatible:        len(incompatibilities) == 0,
+		Incompatibilities: incompatibilities,
+	}, nil
+}
+
 func (rs *measureRegistryServer) Delete(ctx context.Context,
 	req *databasev1.MeasureRegistryServiceDeleteRequest) (
 	*databasev1.MeasureRegistryServiceDeleteResponse, error,
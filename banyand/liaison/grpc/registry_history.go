@@ -0,0 +1,475 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+)
+
+func (rs *streamRegistryServer) ListRevisions(ctx context.Context, req *databasev1.StreamRegistryServiceListRevisionsRequest) (
+	*databasev1.StreamRegistryServiceListRevisionsResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "stream", "listRevisions")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "stream", "listRevisions")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "stream", "listRevisions")
+	}()
+	revisions, err := rs.schemaRegistry.HistoryRegistry().ListRevisions(ctx, schema.KindStream, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "stream", "listRevisions")
+		return nil, err
+	}
+	entities := make([]*databasev1.Stream, 0, len(revisions))
+	for _, revision := range revisions {
+		entities = append(entities, revision.Spec.(*databasev1.Stream))
+	}
+	return &databasev1.StreamRegistryServiceListRevisionsResponse{
+		Stream: entities,
+	}, nil
+}
+
+func (rs *streamRegistryServer) GetRevision(ctx context.Context, req *databasev1.StreamRegistryServiceGetRevisionRequest) (
+	*databasev1.StreamRegistryServiceGetRevisionResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "stream", "getRevision")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "stream", "getRevision")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "stream", "getRevision")
+	}()
+	revision, err := rs.schemaRegistry.HistoryRegistry().GetRevision(ctx, schema.KindStream, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "stream", "getRevision")
+		return nil, err
+	}
+	return &databasev1.StreamRegistryServiceGetRevisionResponse{
+		Stream: revision.Spec.(*databasev1.Stream),
+	}, nil
+}
+
+func (rs *streamRegistryServer) Rollback(ctx context.Context, req *databasev1.StreamRegistryServiceRollbackRequest) (
+	*databasev1.StreamRegistryServiceRollbackResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "stream", "rollback")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "stream", "rollback")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "stream", "rollback")
+	}()
+	modRevision, err := rs.schemaRegistry.HistoryRegistry().Rollback(ctx, schema.KindStream, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "stream", "rollback")
+		return nil, err
+	}
+	return &databasev1.StreamRegistryServiceRollbackResponse{
+		ModRevision: modRevision,
+	}, nil
+}
+
+func (rs *indexRuleBindingRegistryServer) ListRevisions(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceListRevisionsRequest) (
+	*databasev1.IndexRuleBindingRegistryServiceListRevisionsResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "indexRuleBinding", "listRevisions")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "indexRuleBinding", "listRevisions")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "indexRuleBinding", "listRevisions")
+	}()
+	revisions, err := rs.schemaRegistry.HistoryRegistry().ListRevisions(ctx, schema.KindIndexRuleBinding, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRuleBinding", "listRevisions")
+		return nil, err
+	}
+	entities := make([]*databasev1.IndexRuleBinding, 0, len(revisions))
+	for _, revision := range revisions {
+		entities = append(entities, revision.Spec.(*databasev1.IndexRuleBinding))
+	}
+	return &databasev1.IndexRuleBindingRegistryServiceListRevisionsResponse{
+		IndexRuleBinding: entities,
+	}, nil
+}
+
+func (rs *indexRuleBindingRegistryServer) GetRevision(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceGetRevisionRequest) (
+	*databasev1.IndexRuleBindingRegistryServiceGetRevisionResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "indexRuleBinding", "getRevision")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "indexRuleBinding", "getRevision")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "indexRuleBinding", "getRevision")
+	}()
+	revision, err := rs.schemaRegistry.HistoryRegistry().GetRevision(ctx, schema.KindIndexRuleBinding, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRuleBinding", "getRevision")
+		return nil, err
+	}
+	return &databasev1.IndexRuleBindingRegistryServiceGetRevisionResponse{
+		IndexRuleBinding: revision.Spec.(*databasev1.IndexRuleBinding),
+	}, nil
+}
+
+func (rs *indexRuleBindingRegistryServer) Rollback(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceRollbackRequest) (
+	*databasev1.IndexRuleBindingRegistryServiceRollbackResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "indexRuleBinding", "rollback")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "indexRuleBinding", "rollback")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "indexRuleBinding", "rollback")
+	}()
+	modRevision, err := rs.schemaRegistry.HistoryRegistry().Rollback(ctx, schema.KindIndexRuleBinding, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRuleBinding", "rollback")
+		return nil, err
+	}
+	return &databasev1.IndexRuleBindingRegistryServiceRollbackResponse{
+		ModRevision: modRevision,
+	}, nil
+}
+
+func (rs *indexRuleRegistryServer) ListRevisions(ctx context.Context, req *databasev1.IndexRuleRegistryServiceListRevisionsRequest) (
+	*databasev1.IndexRuleRegistryServiceListRevisionsResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "indexRule", "listRevisions")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "indexRule", "listRevisions")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "indexRule", "listRevisions")
+	}()
+	revisions, err := rs.schemaRegistry.HistoryRegistry().ListRevisions(ctx, schema.KindIndexRule, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRule", "listRevisions")
+		return nil, err
+	}
+	entities := make([]*databasev1.IndexRule, 0, len(revisions))
+	for _, revision := range revisions {
+		entities = append(entities, revision.Spec.(*databasev1.IndexRule))
+	}
+	return &databasev1.IndexRuleRegistryServiceListRevisionsResponse{
+		IndexRule: entities,
+	}, nil
+}
+
+func (rs *indexRuleRegistryServer) GetRevision(ctx context.Context, req *databasev1.IndexRuleRegistryServiceGetRevisionRequest) (
+	*databasev1.IndexRuleRegistryServiceGetRevisionResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "indexRule", "getRevision")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "indexRule", "getRevision")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "indexRule", "getRevision")
+	}()
+	revision, err := rs.schemaRegistry.HistoryRegistry().GetRevision(ctx, schema.KindIndexRule, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRule", "getRevision")
+		return nil, err
+	}
+	return &databasev1.IndexRuleRegistryServiceGetRevisionResponse{
+		IndexRule: revision.Spec.(*databasev1.IndexRule),
+	}, nil
+}
+
+func (rs *indexRuleRegistryServer) Rollback(ctx context.Context, req *databasev1.IndexRuleRegistryServiceRollbackRequest) (
+	*databasev1.IndexRuleRegistryServiceRollbackResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "indexRule", "rollback")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "indexRule", "rollback")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "indexRule", "rollback")
+	}()
+	modRevision, err := rs.schemaRegistry.HistoryRegistry().Rollback(ctx, schema.KindIndexRule, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRule", "rollback")
+		return nil, err
+	}
+	return &databasev1.IndexRuleRegistryServiceRollbackResponse{
+		ModRevision: modRevision,
+	}, nil
+}
+
+func (rs *measureRegistryServer) ListRevisions(ctx context.Context, req *databasev1.MeasureRegistryServiceListRevisionsRequest) (
+	*databasev1.MeasureRegistryServiceListRevisionsResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "measure", "listRevisions")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "measure", "listRevisions")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "measure", "listRevisions")
+	}()
+	revisions, err := rs.schemaRegistry.HistoryRegistry().ListRevisions(ctx, schema.KindMeasure, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "measure", "listRevisions")
+		return nil, err
+	}
+	entities := make([]*databasev1.Measure, 0, len(revisions))
+	for _, revision := range revisions {
+		entities = append(entities, revision.Spec.(*databasev1.Measure))
+	}
+	return &databasev1.MeasureRegistryServiceListRevisionsResponse{
+		Measure: entities,
+	}, nil
+}
+
+func (rs *measureRegistryServer) GetRevision(ctx context.Context, req *databasev1.MeasureRegistryServiceGetRevisionRequest) (
+	*databasev1.MeasureRegistryServiceGetRevisionResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "measure", "getRevision")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "measure", "getRevision")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "measure", "getRevision")
+	}()
+	revision, err := rs.schemaRegistry.HistoryRegistry().GetRevision(ctx, schema.KindMeasure, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "measure", "getRevision")
+		return nil, err
+	}
+	return &databasev1.MeasureRegistryServiceGetRevisionResponse{
+		Measure: revision.Spec.(*databasev1.Measure),
+	}, nil
+}
+
+func (rs *measureRegistryServer) Rollback(ctx context.Context, req *databasev1.MeasureRegistryServiceRollbackRequest) (
+	*databasev1.MeasureRegistryServiceRollbackResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "measure", "rollback")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "measure", "rollback")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "measure", "rollback")
+	}()
+	modRevision, err := rs.schemaRegistry.HistoryRegistry().Rollback(ctx, schema.KindMeasure, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "measure", "rollback")
+		return nil, err
+	}
+	return &databasev1.MeasureRegistryServiceRollbackResponse{
+		ModRevision: modRevision,
+	}, nil
+}
+
+func (rs *groupRegistryServer) ListRevisions(ctx context.Context, req *databasev1.GroupRegistryServiceListRevisionsRequest) (
+	*databasev1.GroupRegistryServiceListRevisionsResponse, error,
+) {
+	g := ""
+	rs.metrics.totalRegistryStarted.Inc(1, g, "group", "listRevisions")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "group", "listRevisions")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "group", "listRevisions")
+	}()
+	revisions, err := rs.schemaRegistry.HistoryRegistry().ListRevisions(ctx, schema.KindGroup, &commonv1.Metadata{Name: req.GetGroup()})
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "group", "listRevisions")
+		return nil, err
+	}
+	entities := make([]*commonv1.Group, 0, len(revisions))
+	for _, revision := range revisions {
+		entities = append(entities, revision.Spec.(*commonv1.Group))
+	}
+	return &databasev1.GroupRegistryServiceListRevisionsResponse{
+		Group: entities,
+	}, nil
+}
+
+func (rs *groupRegistryServer) GetRevision(ctx context.Context, req *databasev1.GroupRegistryServiceGetRevisionRequest) (
+	*databasev1.GroupRegistryServiceGetRevisionResponse, error,
+) {
+	g := ""
+	rs.metrics.totalRegistryStarted.Inc(1, g, "group", "getRevision")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "group", "getRevision")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "group", "getRevision")
+	}()
+	revision, err := rs.schemaRegistry.HistoryRegistry().GetRevision(ctx, schema.KindGroup, &commonv1.Metadata{Name: req.GetGroup()}, req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "group", "getRevision")
+		return nil, err
+	}
+	return &databasev1.GroupRegistryServiceGetRevisionResponse{
+		Group: revision.Spec.(*commonv1.Group),
+	}, nil
+}
+
+func (rs *groupRegistryServer) Rollback(ctx context.Context, req *databasev1.GroupRegistryServiceRollbackRequest) (
+	*databasev1.GroupRegistryServiceRollbackResponse, error,
+) {
+	g := ""
+	rs.metrics.totalRegistryStarted.Inc(1, g, "group", "rollback")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "group", "rollback")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "group", "rollback")
+	}()
+	modRevision, err := rs.schemaRegistry.HistoryRegistry().Rollback(ctx, schema.KindGroup, &commonv1.Metadata{Name: req.GetGroup()}, req.GetModRevision())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "group", "rollback")
+		return nil, err
+	}
+	return &databasev1.GroupRegistryServiceRollbackResponse{
+		ModRevision: modRevision,
+	}, nil
+}
+
+func (ts *topNAggregationRegistryServer) ListRevisions(ctx context.Context, req *databasev1.TopNAggregationRegistryServiceListRevisionsRequest) (
+	*databasev1.TopNAggregationRegistryServiceListRevisionsResponse, error,
+) {
+	g := req.Metadata.Group
+	ts.metrics.totalRegistryStarted.Inc(1, g, "topn_aggregation", "listRevisions")
+	start := time.Now()
+	defer func() {
+		ts.metrics.totalRegistryFinished.Inc(1, g, "topn_aggregation", "listRevisions")
+		ts.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "topn_aggregation", "listRevisions")
+	}()
+	revisions, err := ts.schemaRegistry.HistoryRegistry().ListRevisions(ctx, schema.KindTopNAggregation, req.GetMetadata())
+	if err != nil {
+		ts.metrics.totalRegistryErr.Inc(1, g, "topn_aggregation", "listRevisions")
+		return nil, err
+	}
+	entities := make([]*databasev1.TopNAggregation, 0, len(revisions))
+	for _, revision := range revisions {
+		entities = append(entities, revision.Spec.(*databasev1.TopNAggregation))
+	}
+	return &databasev1.TopNAggregationRegistryServiceListRevisionsResponse{
+		TopNAggregation: entities,
+	}, nil
+}
+
+func (ts *topNAggregationRegistryServer) GetRevision(ctx context.Context, req *databasev1.TopNAggregationRegistryServiceGetRevisionRequest) (
+	*databasev1.TopNAggregationRegistryServiceGetRevisionResponse, error,
+) {
+	g := req.Metadata.Group
+	ts.metrics.totalRegistryStarted.Inc(1, g, "topn_aggregation", "getRevision")
+	start := time.Now()
+	defer func() {
+		ts.metrics.totalRegistryFinished.Inc(1, g, "topn_aggregation", "getRevision")
+		ts.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "topn_aggregation", "getRevision")
+	}()
+	revision, err := ts.schemaRegistry.HistoryRegistry().GetRevision(ctx, schema.KindTopNAggregation, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		ts.metrics.totalRegistryErr.Inc(1, g, "topn_aggregation", "getRevision")
+		return nil, err
+	}
+	return &databasev1.TopNAggregationRegistryServiceGetRevisionResponse{
+		TopNAggregation: revision.Spec.(*databasev1.TopNAggregation),
+	}, nil
+}
+
+func (ts *topNAggregationRegistryServer) Rollback(ctx context.Context, req *databasev1.TopNAggregationRegistryServiceRollbackRequest) (
+	*databasev1.TopNAggregationRegistryServiceRollbackResponse, error,
+) {
+	g := req.Metadata.Group
+	ts.metrics.totalRegistryStarted.Inc(1, g, "topn_aggregation", "rollback")
+	start := time.Now()
+	defer func() {
+		ts.metrics.totalRegistryFinished.Inc(1, g, "topn_aggregation", "rollback")
+		ts.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "topn_aggregation", "rollback")
+	}()
+	modRevision, err := ts.schemaRegistry.HistoryRegistry().Rollback(ctx, schema.KindTopNAggregation, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		ts.metrics.totalRegistryErr.Inc(1, g, "topn_aggregation", "rollback")
+		return nil, err
+	}
+	return &databasev1.TopNAggregationRegistryServiceRollbackResponse{
+		ModRevision: modRevision,
+	}, nil
+}
+
+func (ps *propertyRegistryServer) ListRevisions(ctx context.Context, req *databasev1.PropertyRegistryServiceListRevisionsRequest) (
+	*databasev1.PropertyRegistryServiceListRevisionsResponse, error,
+) {
+	g := req.Metadata.Group
+	ps.metrics.totalRegistryStarted.Inc(1, g, "property", "listRevisions")
+	start := time.Now()
+	defer func() {
+		ps.metrics.totalRegistryFinished.Inc(1, g, "property", "listRevisions")
+		ps.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "property", "listRevisions")
+	}()
+	revisions, err := ps.schemaRegistry.HistoryRegistry().ListRevisions(ctx, schema.KindProperty, req.GetMetadata())
+	if err != nil {
+		ps.metrics.totalRegistryErr.Inc(1, g, "property", "listRevisions")
+		return nil, err
+	}
+	entities := make([]*databasev1.Property, 0, len(revisions))
+	for _, revision := range revisions {
+		entities = append(entities, revision.Spec.(*databasev1.Property))
+	}
+	return &databasev1.PropertyRegistryServiceListRevisionsResponse{
+		Property: entities,
+	}, nil
+}
+
+func (ps *propertyRegistryServer) GetRevision(ctx context.Context, req *databasev1.PropertyRegistryServiceGetRevisionRequest) (
+	*databasev1.PropertyRegistryServiceGetRevisionResponse, error,
+) {
+	g := req.Metadata.Group
+	ps.metrics.totalRegistryStarted.Inc(1, g, "property", "getRevision")
+	start := time.Now()
+	defer func() {
+		ps.metrics.totalRegistryFinished.Inc(1, g, "property", "getRevision")
+		ps.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "property", "getRevision")
+	}()
+	revision, err := ps.schemaRegistry.HistoryRegistry().GetRevision(ctx, schema.KindProperty, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		ps.metrics.totalRegistryErr.Inc(1, g, "property", "getRevision")
+		return nil, err
+	}
+	return &databasev1.PropertyRegistryServiceGetRevisionResponse{
+		Property: revision.Spec.(*databasev1.Property),
+	}, nil
+}
+
+func (ps *propertyRegistryServer) Rollback(ctx context.Context, req *databasev1.PropertyRegistryServiceRollbackRequest) (
+	*databasev1.PropertyRegistryServiceRollbackResponse, error,
+) {
+	g := req.Metadata.Group
+	ps.metrics.totalRegistryStarted.Inc(1, g, "property", "rollback")
+	start := time.Now()
+	defer func() {
+		ps.metrics.totalRegistryFinished.Inc(1, g, "property", "rollback")
+		ps.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "property", "rollback")
+	}()
+	modRevision, err := ps.schemaRegistry.HistoryRegistry().Rollback(ctx, schema.KindProperty, req.GetMetadata(), req.GetModRevision())
+	if err != nil {
+		ps.metrics.totalRegistryErr.Inc(1, g, "property", "rollback")
+		return nil, err
+	}
+	return &databasev1.PropertyRegistryServiceRollbackResponse{
+		ModRevision: modRevision,
+	}, nil
+}
@@ -186,7 +186,7 @@ func setupForRegistry() func() {
 		MeasureNodeRegistry:  nr,
 		StreamNodeRegistry:   nr,
 		PropertyNodeRegistry: nr,
-	}, metricSvc)
+	}, metricSvc, nil)
 	preloadStreamSvc := &preloadStreamService{metaSvc: metaSvc}
 	var flags []string
 	metaPath, metaDeferFunc, err := test.NewSpace()
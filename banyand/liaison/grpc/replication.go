@@ -0,0 +1,169 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+const (
+	writeQuorumOne      = "one"
+	writeQuorumMajority = "majority"
+	writeQuorumAll      = "all"
+)
+
+var (
+	errInvalidWriteQuorum = errors.New("write quorum should be one, majority or all")
+	errQuorumNotReached   = errors.New("the write quorum isn't reached")
+)
+
+// writeQuorum decides how many copies of a shard should accept a write before it's acknowledged.
+type writeQuorum string
+
+func (q writeQuorum) validate() error {
+	switch q {
+	case writeQuorumOne, writeQuorumMajority, writeQuorumAll:
+		return nil
+	}
+	return errors.WithMessagef(errInvalidWriteQuorum, "got %q", string(q))
+}
+
+// of returns the number of the copies which should accept the write.
+func (q writeQuorum) of(copies int) int {
+	switch q {
+	case writeQuorumOne:
+		return min(1, copies)
+	case writeQuorumAll:
+		return copies
+	default:
+		return copies/2 + 1
+	}
+}
+
+// publishReplicas sends the message to the nodes holding the copies of the shard.
+// The message to an unreachable node is handed off only if the write is acknowledged, since the client retries the failed writes.
+// The handed off messages count as accepted if the handoff counts toward the quorum of the copies.
+// It returns the nodes which accept the message and the number of the handed off ones counting toward the quorum.
+// An error is returned if they are fewer than the quorum, which is the one of the first failed node if it tells the status.
+func publishReplicas(ctx context.Context, publisher queue.BatchPublisher, topic bus.Topic,
+	nodes []string, quorum int, message proto.Message, handoff *handoffController,
+) ([]string, int, error) {
+	sent := make([]string, 0, len(nodes))
+	var undelivered []nodeError
+	var errs error
+	var firstErr error
+	fail := func(n string, err error) {
+		errs = multierr.Append(errs, errors.WithMessagef(err, "node %s", n))
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, n := range nodes {
+		if _, err := publisher.Publish(ctx, topic, bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), n, message)); err != nil {
+			if handoff != nil && isUndeliverable(err) {
+				undelivered = append(undelivered, nodeError{node: n, err: err})
+				continue
+			}
+			fail(n, err)
+			continue
+		}
+		sent = append(sent, n)
+	}
+	counts := handoff.counts(len(nodes))
+	handedOff := 0
+	if len(sent) >= quorum || (counts && len(sent)+len(undelivered) >= quorum) {
+		for _, u := range undelivered {
+			if !handoff.handOff(u.node, topic, message) {
+				fail(u.node, u.err)
+				continue
+			}
+			if counts {
+				handedOff++
+			}
+		}
+	} else {
+		for _, u := range undelivered {
+			fail(u.node, u.err)
+		}
+	}
+	if len(sent)+handedOff >= quorum {
+		return sent, handedOff, nil
+	}
+	var ce *common.Error
+	if errors.As(firstErr, &ce) {
+		return sent, handedOff, ce
+	}
+	return sent, handedOff, errors.WithMessagef(errQuorumNotReached, "%d/%d nodes: %v", len(sent)+handedOff, quorum, errs)
+}
+
+type nodeError struct {
+	err  error
+	node string
+}
+
+type succeedSentMessage struct {
+	metadata      *commonv1.Metadata
+	message       proto.Message
+	nodes         []string
+	messageID     uint64
+	quorum        int
+	handedOff     int
+	handoffCounts bool
+}
+
+// status returns the status of the message after the nodes respond.
+// The message succeeds if the nodes without errors and the handed off ones counting toward the quorum reach it.
+// The message to a node which becomes unreachable is handed off by the handoff function only if the message succeeds,
+// which returns true if the message is queued.
+func (m succeedSentMessage) status(cee map[string]*common.Error, handoff func(node string, message proto.Message) bool) modelv1.Status {
+	acked := m.handedOff
+	var undelivered []string
+	failure := modelv1.Status_STATUS_SUCCEED
+	for _, n := range m.nodes {
+		if ce, ok := cee[n]; ok {
+			if isUndeliverable(ce) {
+				undelivered = append(undelivered, n)
+			}
+			failure = ce.Status()
+			continue
+		}
+		acked++
+	}
+	if acked >= m.quorum || (m.handoffCounts && acked+len(undelivered) >= m.quorum) {
+		for _, n := range undelivered {
+			if handoff(n, m.message) && m.handoffCounts {
+				acked++
+			}
+		}
+	}
+	if acked >= m.quorum {
+		return modelv1.Status_STATUS_SUCCEED
+	}
+	return failure
+}
@@ -0,0 +1,171 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+func TestWriteQuorum(t *testing.T) {
+	assert.NoError(t, writeQuorum(writeQuorumMajority).validate())
+	assert.ErrorIs(t, writeQuorum("two").validate(), errInvalidWriteQuorum)
+	assert.Equal(t, 1, writeQuorum(writeQuorumOne).of(3))
+	assert.Equal(t, 2, writeQuorum(writeQuorumMajority).of(3))
+	assert.Equal(t, 2, writeQuorum(writeQuorumMajority).of(2))
+	assert.Equal(t, 1, writeQuorum(writeQuorumMajority).of(1))
+	assert.Equal(t, 3, writeQuorum(writeQuorumAll).of(3))
+}
+
+func TestPublishReplicas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	publisher := queue.NewMockBatchPublisher(ctrl)
+	publisher.EXPECT().Publish(gomock.Any(), data.TopicMeasureWrite, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ bus.Topic, messages ...bus.Message) (bus.Future, error) {
+			if messages[0].Node() == "node2" {
+				return nil, errors.New("node2 is unavailable")
+			}
+			return nil, nil
+		}).MinTimes(9)
+	nodes := []string{"node1", "node2", "node3"}
+	message := &measurev1.InternalWriteRequest{ShardId: 1}
+
+	sent, handedOff, err := publishReplicas(context.Background(), publisher, data.TopicMeasureWrite, nodes, 2, message, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node1", "node3"}, sent)
+	assert.Zero(t, handedOff)
+
+	sent, _, err = publishReplicas(context.Background(), publisher, data.TopicMeasureWrite, nodes, 3, message, nil)
+	assert.ErrorIs(t, err, errQuorumNotReached)
+	assert.Equal(t, []string{"node1", "node3"}, sent)
+
+	// The queued write isn't replayed while node2 is unavailable.
+	pipeline := queue.NewMockClient(ctrl)
+	pipeline.EXPECT().NewBatchPublisher(gomock.Any()).Return(publisher).AnyTimes()
+	publisher.EXPECT().Close().Return(nil, nil).AnyTimes()
+	// The handed off write doesn't count toward the quorum unless the quorum is sloppy.
+	h := newTestHandoffController(t, pipeline, 1<<20, false)
+	sent, handedOff, err = publishReplicas(context.Background(), publisher, data.TopicMeasureWrite, nodes, 3, message, h)
+	assert.ErrorIs(t, err, errQuorumNotReached)
+	assert.Equal(t, []string{"node1", "node3"}, sent)
+	assert.Zero(t, handedOff)
+	// The failed write isn't queued, since the client retries it.
+	assert.Equal(t, 0, queuedWrites(t, h, "node2"))
+	sent, handedOff, err = publishReplicas(context.Background(), publisher, data.TopicMeasureWrite, nodes, 2, message, h)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node1", "node3"}, sent)
+	assert.Zero(t, handedOff)
+	assert.Equal(t, 1, queuedWrites(t, h, "node2"))
+
+	h = newTestHandoffController(t, pipeline, 1<<20, true)
+	sent, handedOff, err = publishReplicas(context.Background(), publisher, data.TopicMeasureWrite, nodes, 3, message, h)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node1", "node3"}, sent)
+	assert.Equal(t, 1, handedOff)
+	assert.Equal(t, 1, queuedWrites(t, h, "node2"))
+}
+
+func TestPublishReplicasWithoutReplicas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	publisher := queue.NewMockBatchPublisher(ctrl)
+	publisher.EXPECT().Publish(gomock.Any(), data.TopicMeasureWrite, gomock.Any()).Return(nil, errors.New("node1 is unavailable")).AnyTimes()
+	pipeline := queue.NewMockClient(ctrl)
+	pipeline.EXPECT().NewBatchPublisher(gomock.Any()).Return(publisher).AnyTimes()
+	publisher.EXPECT().Close().Return(nil, nil).AnyTimes()
+	message := &measurev1.InternalWriteRequest{ShardId: 1}
+
+	// The default quorum of the shard without replicas accepts the handed off write, which isn't retried by the client.
+	h := newTestHandoffController(t, pipeline, 1<<20, false)
+	quorum := writeQuorum(writeQuorumMajority).of(1)
+	sent, handedOff, err := publishReplicas(context.Background(), publisher, data.TopicMeasureWrite, []string{"node1"}, quorum, message, h)
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+	assert.Equal(t, 1, handedOff)
+	assert.Equal(t, 1, queuedWrites(t, h, "node1"))
+
+	// The write fails if it can't be queued.
+	h = newTestHandoffController(t, pipeline, 1, false)
+	_, _, err = publishReplicas(context.Background(), publisher, data.TopicMeasureWrite, []string{"node1"}, quorum, message, h)
+	assert.ErrorIs(t, err, errQuorumNotReached)
+	assert.Equal(t, 0, queuedWrites(t, h, "node1"))
+}
+
+func queuedWrites(t *testing.T, h *handoffController, node string) int {
+	q, err := h.queue(node)
+	require.NoError(t, err)
+	count, _, _ := q.stats()
+	return count
+}
+
+func TestSucceedSentMessageStatus(t *testing.T) {
+	var handedOff []string
+	handOff := func(node string, _ proto.Message) bool {
+		handedOff = append(handedOff, node)
+		return true
+	}
+	noHandOff := func(string, proto.Message) bool { return false }
+	m := succeedSentMessage{nodes: []string{"node1", "node2", "node3"}, quorum: 2}
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, m.status(nil, noHandOff))
+	diskFull := common.NewErrorWithStatus(modelv1.Status_STATUS_DISK_FULL, "disk full")
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, m.status(map[string]*common.Error{"node2": diskFull}, noHandOff))
+	assert.Equal(t, modelv1.Status_STATUS_DISK_FULL, m.status(map[string]*common.Error{"node1": diskFull, "node2": diskFull}, handOff))
+	assert.Empty(t, handedOff, "the rejected writes shouldn't be handed off")
+
+	unreachable := common.NewErrorWithStatus(modelv1.Status_STATUS_INTERNAL_ERROR, "unreachable")
+	cee := map[string]*common.Error{"node1": unreachable, "node2": unreachable}
+	assert.Equal(t, modelv1.Status_STATUS_INTERNAL_ERROR, m.status(cee, noHandOff))
+	// The writes of the failed message aren't handed off unless they count toward the quorum.
+	assert.Equal(t, modelv1.Status_STATUS_INTERNAL_ERROR, m.status(cee, handOff))
+	assert.Empty(t, handedOff)
+	m.handoffCounts = true
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, m.status(cee, handOff))
+	assert.Equal(t, []string{"node1", "node2"}, handedOff)
+	assert.Equal(t, modelv1.Status_STATUS_INTERNAL_ERROR, m.status(cee, noHandOff))
+
+	// The write to the node which becomes unreachable is handed off if the others reach the quorum.
+	handedOff = nil
+	m.handoffCounts = false
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, m.status(map[string]*common.Error{"node2": unreachable}, handOff))
+	assert.Equal(t, []string{"node2"}, handedOff)
+
+	// The handed off write to the shard without replicas counts toward the quorum of the default configuration.
+	pipeline := queue.NewMockClient(gomock.NewController(t))
+	h := newTestHandoffController(t, pipeline, 1<<20, false)
+	m = succeedSentMessage{nodes: []string{"node1"}, quorum: writeQuorum(writeQuorumMajority).of(1), handoffCounts: h.counts(1)}
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, m.status(map[string]*common.Error{"node1": unreachable}, h.handOffFunc(data.TopicMeasureWrite)))
+	assert.Equal(t, 1, queuedWrites(t, h, "node1"))
+	assert.False(t, h.counts(3))
+	sloppy := newTestHandoffController(t, pipeline, 1<<20, true)
+	assert.True(t, sloppy.counts(3))
+
+	m = succeedSentMessage{nodes: []string{"node1"}, quorum: 2, handedOff: 1}
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, m.status(nil, noHandOff))
+}
@@ -35,21 +35,27 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
+	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/auth"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
 )
 
-const defaultRecvSize = 10 << 20
+const (
+	defaultRecvSize       = 10 << 20
+	defaultHandoffMaxSize = 1 << 30
+)
 
 var (
 	errServerCert        = errors.New("invalid server cert file")
@@ -57,10 +63,24 @@ var (
 	errNoAddr            = errors.New("no address")
 	errQueryMsg          = errors.New("invalid query message")
 	errAccessLogRootPath = errors.New("access log root path is required")
+	errInvalidBatchSize  = errors.New("query stream batch size should be positive")
+
+	errInvalidHandoffMaxSize = errors.New("hinted handoff max size should be positive")
+	errInvalidHandoffExpiry  = errors.New("hinted handoff expiry should be positive")
+	errInvalidTenantInterval = errors.New("tenant usage interval and series window should be positive")
 
 	liaisonGrpcScope = observability.RootScope.SubScope("liaison_grpc")
 )
 
+// queryError converts the error returned by the query processors.
+// The resource exhausted status is kept so that clients can tell an oversized query from a failed one.
+func queryError(e *common.Error) error {
+	if e.Status() == modelv1.Status_STATUS_RESOURCE_EXHAUSTED {
+		return status.Error(codes.ResourceExhausted, e.Error())
+	}
+	return errors.WithMessage(errQueryMsg, e.Error())
+}
+
 // Server defines the gRPC server.
 type Server interface {
 	run.Unit
@@ -78,6 +98,8 @@ type server struct {
 	databasev1.UnimplementedSnapshotServiceServer
 	tlsReloader *pkgtls.Reloader
 	omr         observability.MetricsRegistry
+	auth        *auth.Service
+	tenants     *tenantRegistry
 	measureSVC  *measureService
 	ser         *grpclib.Server
 	log         *logger.Logger
@@ -89,23 +111,39 @@ type server struct {
 	*measureRegistryServer
 	streamSVC *streamService
 	*streamRegistryServer
+	slowQuerySVC *slowQueryServer
+	shardSVC     *shardServer
+	nodeSVC      *nodeServer
 	*indexRuleBindingRegistryServer
 	*propertyRegistryServer
 	metrics                  *metrics
 	keyFile                  string
 	certFile                 string
 	accessLogRootPath        string
+	handoffRootPath          string
+	writeQuorum              string
 	addr                     string
 	host                     string
 	accessLogRecorders       []accessLogRecorder
 	maxRecvMsgSize           run.Bytes
+	handoffMaxSize           run.Bytes
+	handoff                  *handoffController
+	handoffExpiry            time.Duration
+	queryCacheFlushHorizon   time.Duration
+	tenantUsageInterval      time.Duration
+	tenantSeriesWindow       time.Duration
+	queryCacheSize           int
 	port                     uint32
+	queryBatchSize           uint32
 	enableIngestionAccessLog bool
 	tls                      bool
+	handoffSloppyQuorum      bool
 }
 
 // NewServer returns a new gRPC server.
-func NewServer(_ context.Context, pipeline, broadcaster queue.Client, schemaRegistry metadata.Repo, nr NodeRegistries, omr observability.MetricsRegistry) Server {
+func NewServer(_ context.Context, pipeline, broadcaster queue.Client, schemaRegistry metadata.Repo, nr NodeRegistries,
+	omr observability.MetricsRegistry, authSvc *auth.Service,
+) Server {
 	streamSVC := &streamService{
 		discoveryService: newDiscoveryService(schema.KindStream, schemaRegistry, nr.StreamNodeRegistry),
 		pipeline:         pipeline,
@@ -117,6 +155,7 @@ func NewServer(_ context.Context, pipeline, broadcaster queue.Client, schemaRegi
 		broadcaster:      broadcaster,
 	}
 	s := &server{
+		auth:       authSvc,
 		omr:        omr,
 		streamSVC:  streamSVC,
 		measureSVC: measureSVC,
@@ -146,7 +185,16 @@ func NewServer(_ context.Context, pipeline, broadcaster queue.Client, schemaRegi
 		propertyRegistryServer: &propertyRegistryServer{
 			schemaRegistry: schemaRegistry,
 		},
+		slowQuerySVC: &slowQueryServer{
+			pipeline: pipeline,
+		},
+		shardSVC: &shardServer{
+			schemaRegistry: schemaRegistry,
+			broadcaster:    pipeline,
+			nodeRegistries: nr,
+		},
 	}
+	s.nodeSVC = &nodeServer{shards: s.shardSVC}
 	s.accessLogRecorders = []accessLogRecorder{streamSVC, measureSVC}
 	return s
 }
@@ -185,6 +233,35 @@ func (s *server) PreRun(_ context.Context) error {
 	s.groupRegistryServer.metrics = metrics
 	s.topNAggregationRegistryServer.metrics = metrics
 	s.propertyRegistryServer.metrics = metrics
+	s.streamSVC.queryBatchSize = s.queryBatchSize
+	s.measureSVC.queryBatchSize = s.queryBatchSize
+	s.streamSVC.writeQuorum = writeQuorum(s.writeQuorum)
+	s.measureSVC.writeQuorum = writeQuorum(s.writeQuorum)
+	if s.handoffRootPath != "" {
+		var err error
+		s.handoff, err = newHandoffController(s.handoffRootPath, int64(s.handoffMaxSize),
+			max(s.streamSVC.writeTimeout, s.measureSVC.writeTimeout), s.handoffExpiry, s.handoffSloppyQuorum,
+			s.streamSVC.pipeline, metrics, s.log.Named("handoff"))
+		if err != nil {
+			return err
+		}
+		s.streamSVC.handoff = s.handoff
+		s.measureSVC.handoff = s.handoff
+	}
+
+	s.tenants = newTenantRegistry(s.auth, s.shardSVC.schemaRegistry, s.shardSVC.broadcaster, metrics,
+		s.tenantUsageInterval, s.tenantSeriesWindow, s.log.Named("tenant"))
+
+	if s.queryCacheSize > 0 {
+		qc, err := newQueryCache(s.queryCacheSize, s.queryCacheFlushHorizon)
+		if err != nil {
+			return err
+		}
+		qc.metrics = metrics
+		s.measureSVC.metadataRepo.RegisterHandler("liaison-query-cache",
+			schema.KindGroup|schema.KindMeasure|schema.KindIndexRuleBinding|schema.KindIndexRule|schema.KindTopNAggregation, qc)
+		s.measureSVC.queryCache = qc
+	}
 
 	if s.tls {
 		var err error
@@ -223,6 +300,23 @@ func (s *server) FlagSet() *run.FlagSet {
 	fs.StringVar(&s.accessLogRootPath, "access-log-root-path", "", "access log root path")
 	fs.DurationVar(&s.streamSVC.writeTimeout, "stream-write-timeout", 15*time.Second, "stream write timeout")
 	fs.DurationVar(&s.measureSVC.writeTimeout, "measure-write-timeout", 15*time.Second, "measure write timeout")
+	fs.StringVar(&s.writeQuorum, "write-quorum", writeQuorumMajority,
+		"the number of the shard copies which should accept a write before it's acknowledged: one, majority or all")
+	fs.StringVar(&s.handoffRootPath, "handoff-root-path", "",
+		"the root path of the hinted handoff queues, which keep the writes to the unreachable data nodes. Empty disables the hinted handoff")
+	s.handoffMaxSize = defaultHandoffMaxSize
+	fs.VarP(&s.handoffMaxSize, "handoff-max-size", "", "the max size of the hinted handoff queue of a data node, after which the writes are rejected")
+	fs.DurationVar(&s.handoffExpiry, "handoff-expiry", 24*time.Hour,
+		"the duration after which the hinted handoff queue of an inactive or deregistered data node is dropped")
+	fs.BoolVar(&s.handoffSloppyQuorum, "handoff-sloppy-quorum", false,
+		"count the handed off writes to the replicated shards toward the write quorum, which acknowledges a write before enough copies accept it")
+	fs.IntVar(&s.queryCacheSize, "query-cache-size", 0, "the max number of cached measure and topN query results, 0 disables the cache")
+	fs.DurationVar(&s.queryCacheFlushHorizon, "query-cache-flush-horizon", 30*time.Minute,
+		"the age after which data is regarded as immutable and its query results can be cached")
+	fs.Uint32Var(&s.queryBatchSize, "query-stream-batch-size", 1000, "the max number of elements or data points in a batch of the streaming query")
+	fs.DurationVar(&s.tenantUsageInterval, "tenant-usage-interval", time.Minute, "the interval of collecting the storage of the tenants from the data nodes")
+	fs.DurationVar(&s.tenantSeriesWindow, "tenant-series-window", time.Hour,
+		"the window of the active series of the tenants. A series is inactive if it isn't written in the last two windows")
 	return fs
 }
 
@@ -234,6 +328,24 @@ func (s *server) Validate() error {
 	if s.enableIngestionAccessLog && s.accessLogRootPath == "" {
 		return errAccessLogRootPath
 	}
+	if s.queryCacheSize > 0 && s.queryCacheFlushHorizon <= 0 {
+		return errInvalidHorizon
+	}
+	if s.queryBatchSize == 0 {
+		return errInvalidBatchSize
+	}
+	if s.tenantUsageInterval <= 0 || s.tenantSeriesWindow <= 0 {
+		return errInvalidTenantInterval
+	}
+	if err := writeQuorum(s.writeQuorum).validate(); err != nil {
+		return err
+	}
+	if s.handoffRootPath != "" && s.handoffMaxSize <= 0 {
+		return errInvalidHandoffMaxSize
+	}
+	if s.handoffRootPath != "" && s.handoffExpiry <= 0 {
+		return errInvalidHandoffExpiry
+	}
 	if !s.tls {
 		return nil
 	}
@@ -255,7 +367,7 @@ func (s *server) Serve() run.StopNotify {
 			return s.stopCh
 		}
 		s.log.Info().Str("certFile", s.certFile).Str("keyFile", s.keyFile).Msg("Starting TLS file monitoring")
-		tlsConfig := s.tlsReloader.GetTLSConfig()
+		tlsConfig := s.auth.ClientTLSConfig(s.tlsReloader.GetTLSConfig())
 		creds := credentials.NewTLS(tlsConfig)
 		opts = append(opts, grpclib.Creds(creds))
 	}
@@ -266,10 +378,12 @@ func (s *server) Serve() run.StopNotify {
 	}
 
 	streamChain := []grpclib.StreamServerInterceptor{
+		s.authStreamInterceptor,
 		grpc_validator.StreamServerInterceptor(),
 		recovery.StreamServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
 	}
 	unaryChain := []grpclib.UnaryServerInterceptor{
+		s.authUnaryInterceptor,
 		grpc_validator.UnaryServerInterceptor(),
 		recovery.UnaryServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
 	}
@@ -291,9 +405,13 @@ func (s *server) Serve() run.StopNotify {
 	propertyv1.RegisterPropertyServiceServer(s.ser, s.propertyServer)
 	databasev1.RegisterTopNAggregationRegistryServiceServer(s.ser, s.topNAggregationRegistryServer)
 	databasev1.RegisterSnapshotServiceServer(s.ser, s)
+	databasev1.RegisterSlowQueryServiceServer(s.ser, s.slowQuerySVC)
+	databasev1.RegisterShardServiceServer(s.ser, s.shardSVC)
+	databasev1.RegisterNodeServiceServer(s.ser, s.nodeSVC)
 	databasev1.RegisterPropertyRegistryServiceServer(s.ser, s.propertyRegistryServer)
 	grpc_health_v1.RegisterHealthServer(s.ser, health.NewServer())
 
+	s.tenants.start()
 	s.stopCh = make(chan struct{})
 	s.log.Info().Str("addr", s.addr).Msg("Starting gRPC server")
 	go func() {
@@ -326,6 +444,10 @@ func (s *server) GracefulStop() {
 				_ = alr.Close()
 			}
 		}
+		if s.handoff != nil {
+			s.handoff.close()
+		}
+		s.tenants.close()
 		close(stopped)
 	}()
 
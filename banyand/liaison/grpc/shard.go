@@ -0,0 +1,185 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+)
+
+// shardMigrationTimeout bounds the time a data node spends on sending its data of a shard to the new nodes.
+const shardMigrationTimeout = 30 * time.Minute
+
+type shardServer struct {
+	databasev1.UnimplementedShardServiceServer
+	schemaRegistry metadata.Repo
+	broadcaster    bus.Broadcaster
+	nodeRegistries NodeRegistries
+	// mu serializes the rebalances.
+	mu sync.Mutex
+}
+
+func (s *shardServer) List(ctx context.Context, req *databasev1.ShardServiceListRequest) (*databasev1.ShardServiceListResponse, error) {
+	shards, err := s.schemaRegistry.ShardRegistry().ListShard(ctx, schema.ListOpt{Group: req.GetGroup()})
+	if err != nil {
+		return nil, err
+	}
+	return &databasev1.ShardServiceListResponse{Shards: shards}, nil
+}
+
+// Rebalance moves the shards one by one. The data of a shard is sent to its new nodes before the writes are routed to them,
+// and the catch-up after that only sends the parts which the old nodes introduced in the meantime.
+// The old nodes keep their copies until the retention reclaims them, which are deduplicated by the queries.
+func (s *shardServer) Rebalance(ctx context.Context, req *databasev1.ShardServiceRebalanceRequest) (*databasev1.ShardServiceRebalanceResponse, error) {
+	g, err := s.schemaRegistry.GroupRegistry().GetGroup(ctx, req.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+	var nr NodeRegistry
+	var topic bus.Topic
+	switch g.Catalog {
+	case commonv1.Catalog_CATALOG_STREAM:
+		nr, topic = s.nodeRegistries.StreamNodeRegistry, data.TopicStreamMigrateShard
+	case commonv1.Catalog_CATALOG_MEASURE:
+		nr, topic = s.nodeRegistries.MeasureNodeRegistry, data.TopicMeasureMigrateShard
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "the shards of the %s group %s can't be rebalanced", g.Catalog, req.GetGroup())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	moves, err := nr.Rebalance(req.GetGroup())
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	resp := &databasev1.ShardServiceRebalanceResponse{}
+	var nodes []*databasev1.Node
+	for _, m := range moves {
+		sm := &databasev1.ShardMove{
+			ShardId: m.ShardID,
+			From:    m.From,
+			To:      m.To,
+		}
+		resp.Moves = append(resp.Moves, sm)
+		if req.GetDryRun() {
+			continue
+		}
+		var targets []*databasev1.Node
+		if targets, nodes, err = s.targets(ctx, m, nodes); err != nil {
+			return nil, err
+		}
+		if len(targets) > 0 {
+			if sm.Migrated, err = s.migrate(topic, req.GetGroup(), m.ShardID, targets, false); err != nil {
+				return nil, errors.WithMessagef(err, "failed to migrate the shard %d", m.ShardID)
+			}
+		}
+		if err = nr.Assign(ctx, req.GetGroup(), m.ShardID, m.To[0]); err != nil {
+			return nil, err
+		}
+		if len(targets) > 0 {
+			var migrated uint64
+			migrated, err = s.migrate(topic, req.GetGroup(), m.ShardID, targets, true)
+			sm.Migrated += migrated
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to catch up with the writes to the shard %d", m.ShardID)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// targets returns the nodes which hold no copy of the shard before the move.
+func (s *shardServer) targets(ctx context.Context, m node.Move, nodes []*databasev1.Node) ([]*databasev1.Node, []*databasev1.Node, error) {
+	var targets []*databasev1.Node
+	for _, name := range m.To {
+		if slices.Contains(m.From, name) {
+			continue
+		}
+		if nodes == nil {
+			var err error
+			if nodes, err = s.schemaRegistry.NodeRegistry().ListNode(ctx, databasev1.Role_ROLE_DATA); err != nil {
+				return nil, nil, err
+			}
+		}
+		i := slices.IndexFunc(nodes, func(n *databasev1.Node) bool {
+			return n.GetMetadata().GetName() == name
+		})
+		if i < 0 {
+			return nil, nodes, errors.Errorf("data node %s isn't registered", name)
+		}
+		targets = append(targets, nodes[i])
+	}
+	return targets, nodes, nil
+}
+
+// migrate asks every data node to send its data of the shard to the targets.
+// A catch-up only sends the data which arrived after the previous migration of the shard.
+func (s *shardServer) migrate(topic bus.Topic, group string, shardID uint32, targets []*databasev1.Node, catchUp bool) (uint64, error) {
+	counts, err := s.collect(topic, &databasev1.MigrateShardRequest{
+		Group:   group,
+		ShardId: shardID,
+		Targets: targets,
+		CatchUp: catchUp,
+	})
+	var migrated uint64
+	for _, n := range counts {
+		migrated += n
+	}
+	return migrated, err
+}
+
+// collect broadcasts the request about a shard to the data nodes, and returns the numbers of the data reported by every node.
+func (s *shardServer) collect(topic bus.Topic, req proto.Message) (map[string]uint64, error) {
+	ff, err := s.broadcaster.Broadcast(shardMigrationTimeout, topic, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req))
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]uint64, len(ff))
+	for _, f := range ff {
+		m, errGet := f.Get()
+		if errGet != nil {
+			err = multierr.Append(err, errGet)
+			continue
+		}
+		switch d := m.Data().(type) {
+		case *databasev1.MigrateShardResponse:
+			counts[d.Node] += d.Migrated
+		case *databasev1.CountShardResponse:
+			counts[d.Node] += d.Count
+		case *common.Error:
+			err = multierr.Append(err, errors.New(d.Error()))
+		}
+	}
+	return counts, err
+}
@@ -0,0 +1,59 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type slowQueryServer struct {
+	databasev1.UnimplementedSlowQueryServiceServer
+	pipeline queue.Client
+}
+
+func (s *slowQueryServer) List(ctx context.Context, req *databasev1.SlowQueryServiceListRequest) (*databasev1.SlowQueryServiceListResponse, error) {
+	f, err := s.pipeline.Publish(ctx, data.TopicSlowQueryList, bus.NewMessage(bus.MessageID(0), req))
+	if errors.Is(err, bus.ErrTopicNotExist) {
+		return nil, fmt.Errorf("this server does not support listing slow queries")
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := f.Get()
+	if err != nil {
+		return nil, err
+	}
+	switch d := m.Data().(type) {
+	case *databasev1.SlowQueryServiceListResponse:
+		return d, nil
+	case *common.Error:
+		return nil, errors.New(d.Error())
+	default:
+		logger.Panicf("invalid data type %T", d)
+	}
+	return nil, nil
+}
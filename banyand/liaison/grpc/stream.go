@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/api/data"
@@ -47,9 +48,12 @@ type streamService struct {
 	pipeline           queue.Client
 	broadcaster        queue.Client
 	*discoveryService
-	sampled      *logger.Logger
-	metrics      *metrics
-	writeTimeout time.Duration
+	sampled        *logger.Logger
+	metrics        *metrics
+	handoff        *handoffController
+	writeQuorum    writeQuorum
+	writeTimeout   time.Duration
+	queryBatchSize uint32
 }
 
 func (s *streamService) setLogger(log *logger.Logger) {
@@ -81,14 +85,9 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 	var succeedSent []succeedSentMessage
 	defer func() {
 		cee, err := publisher.Close()
+		handOff := s.handoff.handOffFunc(data.TopicStreamWrite)
 		for _, ssm := range succeedSent {
-			code := modelv1.Status_STATUS_SUCCEED
-			if cee != nil {
-				if ce, ok := cee[ssm.node]; ok {
-					code = ce.Status()
-				}
-			}
-			reply(ssm.metadata, code, ssm.messageID, stream, s.sampled)
+			reply(ssm.metadata, ssm.status(cee, handOff), ssm.messageID, stream, s.sampled)
 		}
 		if err != nil {
 			s.sampled.Error().Err(err).Msg("failed to close the publisher")
@@ -138,6 +137,14 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetMessageId(), stream, s.sampled)
 			continue
 		}
+		seriesHash := pbv1.HashEntity(entity)
+		if tl := tenantFrom(ctx); tl != nil {
+			if st := tl.admitWrite(writeEntity.GetMetadata().GetGroup(), seriesHash); st != modelv1.Status_STATUS_SUCCEED {
+				s.sampled.Warn().Str("tenant", tl.tenant.Name).RawJSON("written", logger.Proto(writeEntity)).Msg("the tenant exceeds its limits")
+				reply(writeEntity.GetMetadata(), st, writeEntity.GetMessageId(), stream, s.sampled)
+				continue
+			}
+		}
 		if s.ingestionAccessLog != nil {
 			if errAccessLog := s.ingestionAccessLog.Write(writeEntity); errAccessLog != nil {
 				s.sampled.Error().Err(errAccessLog).Msg("failed to write ingestion access log")
@@ -146,31 +153,35 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 		iwr := &streamv1.InternalWriteRequest{
 			Request:      writeEntity,
 			ShardId:      uint32(shardID),
-			SeriesHash:   pbv1.HashEntity(entity),
+			SeriesHash:   seriesHash,
 			EntityValues: tagValues[1:].Encode(),
 		}
-		nodeID, errPickNode := s.nodeRegistry.Locate(writeEntity.GetMetadata().GetGroup(), writeEntity.GetMetadata().GetName(), uint32(shardID))
+		nodeIDs, errPickNode := s.nodeRegistry.LocateReplicas(writeEntity.GetMetadata().GetGroup(), writeEntity.GetMetadata().GetName(), uint32(shardID))
 		if errPickNode != nil {
 			s.sampled.Error().Err(errPickNode).RawJSON("written", logger.Proto(writeEntity)).Msg("failed to pick an available node")
 			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetMessageId(), stream, s.sampled)
 			continue
 		}
-		message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, iwr)
-		_, errWritePub := publisher.Publish(ctx, data.TopicStreamWrite, message)
+		quorum := s.writeQuorum.of(len(nodeIDs))
+		sentNodeIDs, handedOff, errWritePub := publishReplicas(ctx, publisher, data.TopicStreamWrite, nodeIDs, quorum, iwr, s.handoff)
 		if errWritePub != nil {
 			var ce *common.Error
 			if errors.As(errWritePub, &ce) {
 				reply(writeEntity.GetMetadata(), ce.Status(), writeEntity.GetMessageId(), stream, s.sampled)
 				continue
 			}
-			s.sampled.Error().Err(errWritePub).RawJSON("written", logger.Proto(writeEntity)).Str("nodeID", nodeID).Msg("failed to send a message")
+			s.sampled.Error().Err(errWritePub).RawJSON("written", logger.Proto(writeEntity)).Strs("nodeIDs", nodeIDs).Msg("failed to send a message")
 			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetMessageId(), stream, s.sampled)
 			continue
 		}
 		succeedSent = append(succeedSent, succeedSentMessage{
-			metadata:  writeEntity.GetMetadata(),
-			messageID: writeEntity.GetMessageId(),
-			node:      nodeID,
+			metadata:      writeEntity.GetMetadata(),
+			messageID:     writeEntity.GetMessageId(),
+			message:       iwr,
+			nodes:         sentNodeIDs,
+			quorum:        quorum,
+			handedOff:     handedOff,
+			handoffCounts: s.handoff.counts(len(nodeIDs)),
 		})
 	}
 }
@@ -213,7 +224,11 @@ func (s *streamService) Query(ctx context.Context, req *streamv1.QueryRequest) (
 			span.Stop()
 		}()
 	}
-	message := bus.NewMessage(bus.MessageID(now.UnixNano()), req)
+	return s.query(ctx, req)
+}
+
+func (s *streamService) query(ctx context.Context, req *streamv1.QueryRequest) (*streamv1.QueryResponse, error) {
+	message := bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req)
 	feat, errQuery := s.broadcaster.Publish(ctx, data.TopicStreamQuery, message)
 	if errQuery != nil {
 		if errors.Is(errQuery, io.EOF) {
@@ -230,11 +245,94 @@ func (s *streamService) Query(ctx context.Context, req *streamv1.QueryRequest) (
 	case *streamv1.QueryResponse:
 		return d, nil
 	case *common.Error:
-		return nil, errors.WithMessage(errQueryMsg, d.Error())
+		return nil, queryError(d)
 	}
 	return nil, nil
 }
 
+// QueryStream pages through the elements with the continuation token and sends a page per batch.
+// The next page isn't requested from the data nodes until the previous one has been sent,
+// so a slow client holds back the data nodes instead of piling the elements up in the liaison.
+func (s *streamService) QueryStream(req *streamv1.QueryRequest, server streamv1.StreamService_QueryStreamServer) (err error) {
+	for _, g := range req.Groups {
+		s.metrics.totalStarted.Inc(1, g, "stream", "query_stream")
+	}
+	start := time.Now()
+	defer func() {
+		for _, g := range req.Groups {
+			s.metrics.totalFinished.Inc(1, g, "stream", "query_stream")
+			if err != nil {
+				s.metrics.totalErr.Inc(1, g, "stream", "query_stream")
+			}
+			s.metrics.totalLatency.Inc(time.Since(start).Seconds(), g, "stream", "query_stream")
+		}
+	}()
+	if req.Trace {
+		return status.Error(codes.InvalidArgument, "tracing is not supported by the streaming query")
+	}
+	if req.GetTimeRange() == nil {
+		req.TimeRange = timestamp.DefaultTimeRange
+	}
+	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
+	}
+	ctx := server.Context()
+	// The elements sorted by the sort keys can't be paginated, which are queried at once and bounded by the limit.
+	if len(req.GetSortKeys()) > 0 {
+		resp, errQuery := s.query(ctx, req)
+		if errQuery != nil {
+			return errQuery
+		}
+		elements := resp.GetElements()
+		for len(elements) > 0 {
+			n := min(len(elements), int(s.queryBatchSize))
+			if err = server.Send(&streamv1.QueryResponse{Elements: elements[:n]}); err != nil {
+				return err
+			}
+			elements = elements[n:]
+		}
+		return nil
+	}
+	page := proto.Clone(req).(*streamv1.QueryRequest)
+	var sent uint32
+	for {
+		if err = ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		size := batchSize(s.queryBatchSize, req.GetLimit(), sent)
+		page.Limit = size
+		resp, errQuery := s.query(ctx, page)
+		if errQuery != nil {
+			return errQuery
+		}
+		if len(resp.Elements) > 0 {
+			if err = server.Send(&streamv1.QueryResponse{Elements: resp.Elements}); err != nil {
+				return err
+			}
+			sent += uint32(len(resp.Elements))
+		}
+		if req.GetLimit() > 0 && sent >= req.GetLimit() {
+			return nil
+		}
+		if len(resp.ContinuationToken) == 0 {
+			// A full page without the token means the stream can't be paginated.
+			if len(resp.Elements) >= int(size) {
+				return status.Error(codes.FailedPrecondition, "the sort tag should be projected to stream the elements ordered by an index rule")
+			}
+			return nil
+		}
+		page.ContinuationToken = resp.ContinuationToken
+	}
+}
+
+// batchSize returns the size of the next batch. The limit 0 means no limit.
+func batchSize(maxSize, limit, sent uint32) uint32 {
+	if limit > 0 && limit-sent < maxSize {
+		return limit - sent
+	}
+	return maxSize
+}
+
 func (s *streamService) Close() error {
 	return s.ingestionAccessLog.Close()
 }
@@ -0,0 +1,319 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const groupSizeTimeout = 30 * time.Second
+
+type tenantKey struct{}
+
+func withTenant(ctx context.Context, t *tenantLimiter) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// tenantFrom returns the tenant of the caller, or nil if the caller doesn't belong to a tenant.
+func tenantFrom(ctx context.Context) *tenantLimiter {
+	t, _ := ctx.Value(tenantKey{}).(*tenantLimiter)
+	return t
+}
+
+// tenantLimiter enforces the limits of a tenant on this liaison.
+type tenantLimiter struct {
+	tenant  *auth.Tenant
+	metrics *metrics
+	writes  *rate.Limiter
+	queries chan struct{}
+	// series and prevSeries are the series written in the current and the previous windows.
+	series          map[uint64]struct{}
+	prevSeries      map[uint64]struct{}
+	mu              sync.Mutex
+	storageExceeded atomic.Bool
+}
+
+func newTenantLimiter(t *auth.Tenant, m *metrics) *tenantLimiter {
+	tl := &tenantLimiter{
+		tenant:     t,
+		metrics:    m,
+		series:     make(map[uint64]struct{}),
+		prevSeries: make(map[uint64]struct{}),
+	}
+	if t.Limits.WriteRate > 0 {
+		// The burst allows the writes of a second at once.
+		tl.writes = rate.NewLimiter(rate.Limit(t.Limits.WriteRate), t.Limits.WriteRate)
+	}
+	if t.Limits.MaxConcurrentQueries > 0 {
+		tl.queries = make(chan struct{}, t.Limits.MaxConcurrentQueries)
+	}
+	return tl
+}
+
+// waitWrite throttles the writes of the tenant to its write rate.
+func (tl *tenantLimiter) waitWrite(ctx context.Context) error {
+	tl.metrics.totalTenantWriteReceived.Inc(1, tl.tenant.Name)
+	if tl.writes == nil {
+		return nil
+	}
+	r := tl.writes.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	tl.metrics.totalTenantWriteThrottled.Inc(1, tl.tenant.Name)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// admitWrite checks the storage and the active series of the tenant before writing to the series of the group.
+func (tl *tenantLimiter) admitWrite(group string, seriesHash []byte) modelv1.Status {
+	if tl.storageExceeded.Load() {
+		tl.metrics.totalTenantWriteRejected.Inc(1, tl.tenant.Name, "storage")
+		return modelv1.Status_STATUS_RESOURCE_EXHAUSTED
+	}
+	maxSeries := tl.tenant.Limits.MaxSeries
+	if maxSeries == 0 {
+		return modelv1.Status_STATUS_SUCCEED
+	}
+	key := convert.HashStr(group) ^ convert.Hash(seriesHash)
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	if _, ok := tl.series[key]; ok {
+		return modelv1.Status_STATUS_SUCCEED
+	}
+	if _, ok := tl.prevSeries[key]; ok {
+		delete(tl.prevSeries, key)
+		tl.series[key] = struct{}{}
+		return modelv1.Status_STATUS_SUCCEED
+	}
+	if len(tl.series)+len(tl.prevSeries) >= maxSeries {
+		tl.metrics.totalTenantWriteRejected.Inc(1, tl.tenant.Name, "series")
+		return modelv1.Status_STATUS_RESOURCE_EXHAUSTED
+	}
+	tl.series[key] = struct{}{}
+	tl.metrics.tenantActiveSeries.Set(float64(len(tl.series)+len(tl.prevSeries)), tl.tenant.Name)
+	return modelv1.Status_STATUS_SUCCEED
+}
+
+// rotateSeries starts a new window. The series not written in the last two windows are inactive.
+func (tl *tenantLimiter) rotateSeries() {
+	if tl.tenant.Limits.MaxSeries == 0 {
+		return
+	}
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.prevSeries = tl.series
+	tl.series = make(map[uint64]struct{}, len(tl.prevSeries))
+	tl.metrics.tenantActiveSeries.Set(float64(len(tl.prevSeries)), tl.tenant.Name)
+}
+
+// acquireQuery holds a slot of the concurrent queries of the tenant until the returned function is called.
+func (tl *tenantLimiter) acquireQuery() (func(), error) {
+	if tl.queries != nil {
+		select {
+		case tl.queries <- struct{}{}:
+		default:
+			tl.metrics.totalTenantQueryRejected.Inc(1, tl.tenant.Name)
+			return nil, status.Errorf(codes.ResourceExhausted, "the tenant %s is running %d queries",
+				tl.tenant.Name, tl.tenant.Limits.MaxConcurrentQueries)
+		}
+	}
+	tl.metrics.tenantRunningQueries.Add(1, tl.tenant.Name)
+	return func() {
+		tl.metrics.tenantRunningQueries.Add(-1, tl.tenant.Name)
+		if tl.queries != nil {
+			<-tl.queries
+		}
+	}, nil
+}
+
+// filter removes the groups of the other namespaces from the results.
+func (tl *tenantLimiter) filter(resp any) {
+	if r, ok := resp.(*databasev1.GroupRegistryServiceListResponse); ok {
+		groups := r.Group[:0]
+		for _, g := range r.Group {
+			if tl.tenant.Owns(g.GetMetadata().GetName()) {
+				groups = append(groups, g)
+			}
+		}
+		r.Group = groups
+	}
+}
+
+func (tl *tenantLimiter) updateStorage(size uint64) {
+	tl.metrics.tenantStorageBytes.Set(float64(size), tl.tenant.Name)
+	maxBytes := tl.tenant.Limits.MaxStorageBytes
+	tl.storageExceeded.Store(maxBytes > 0 && size >= maxBytes)
+}
+
+// tenantRegistry holds the limiters of the tenants, and collects their storage from the data nodes.
+type tenantRegistry struct {
+	auth           *auth.Service
+	schemaRegistry metadata.Repo
+	broadcaster    bus.Broadcaster
+	limiters       map[string]*tenantLimiter
+	closer         *run.Closer
+	l              *logger.Logger
+	usageInterval  time.Duration
+	seriesWindow   time.Duration
+}
+
+func newTenantRegistry(authSvc *auth.Service, schemaRegistry metadata.Repo, broadcaster bus.Broadcaster, m *metrics,
+	usageInterval, seriesWindow time.Duration, l *logger.Logger,
+) *tenantRegistry {
+	tr := &tenantRegistry{
+		auth:           authSvc,
+		schemaRegistry: schemaRegistry,
+		broadcaster:    broadcaster,
+		limiters:       make(map[string]*tenantLimiter),
+		usageInterval:  usageInterval,
+		seriesWindow:   seriesWindow,
+		l:              l,
+	}
+	for _, t := range authSvc.Tenants() {
+		tr.limiters[t.Name] = newTenantLimiter(t, m)
+	}
+	return tr
+}
+
+// of returns the limiter of the tenant of the user, or nil if the user doesn't belong to a tenant.
+func (tr *tenantRegistry) of(user string) *tenantLimiter {
+	if tr == nil {
+		return nil
+	}
+	if t := tr.auth.TenantOf(user); t != nil {
+		return tr.limiters[t.Name]
+	}
+	return nil
+}
+
+func (tr *tenantRegistry) start() {
+	if len(tr.limiters) == 0 {
+		return
+	}
+	tr.closer = run.NewCloser(1)
+	go func() {
+		defer tr.closer.Done()
+		usage := time.NewTicker(tr.usageInterval)
+		defer usage.Stop()
+		series := time.NewTicker(tr.seriesWindow)
+		defer series.Stop()
+		tr.collectStorage(tr.closer.Ctx())
+		for {
+			select {
+			case <-tr.closer.CloseNotify():
+				return
+			case <-usage.C:
+				tr.collectStorage(tr.closer.Ctx())
+			case <-series.C:
+				for _, tl := range tr.limiters {
+					tl.rotateSeries()
+				}
+			}
+		}
+	}()
+}
+
+func (tr *tenantRegistry) close() {
+	if tr != nil && tr.closer != nil {
+		tr.closer.CloseThenWait()
+	}
+}
+
+// collectStorage sums up the sizes of the groups of every tenant on all the data nodes.
+func (tr *tenantRegistry) collectStorage(ctx context.Context) {
+	groups, err := tr.schemaRegistry.GroupRegistry().ListGroup(ctx)
+	if err != nil {
+		tr.l.Warn().Err(err).Msg("failed to list the groups to collect the storage of the tenants")
+		return
+	}
+	requests := make(map[bus.Topic]*databasev1.GroupSizeRequest)
+	for _, g := range groups {
+		if tr.auth.GroupTenant(g.GetMetadata().GetName()) == nil {
+			continue
+		}
+		var topic bus.Topic
+		switch g.GetCatalog() {
+		case commonv1.Catalog_CATALOG_STREAM:
+			topic = data.TopicStreamGroupSize
+		case commonv1.Catalog_CATALOG_MEASURE:
+			topic = data.TopicMeasureGroupSize
+		default:
+			continue
+		}
+		if requests[topic] == nil {
+			requests[topic] = &databasev1.GroupSizeRequest{}
+		}
+		requests[topic].Groups = append(requests[topic].Groups, g.GetMetadata().GetName())
+	}
+	usage := make(map[string]uint64, len(tr.limiters))
+	for topic, req := range requests {
+		ff, errBroadcast := tr.broadcaster.Broadcast(groupSizeTimeout, topic, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req))
+		if errBroadcast != nil {
+			tr.l.Warn().Err(errBroadcast).Stringer("topic", topic).Msg("failed to collect the sizes of the groups")
+			continue
+		}
+		for _, f := range ff {
+			m, errGet := f.Get()
+			if errGet != nil {
+				tr.l.Warn().Err(errGet).Stringer("topic", topic).Msg("failed to collect the sizes of the groups")
+				continue
+			}
+			switch d := m.Data().(type) {
+			case *databasev1.GroupSizeResponse:
+				for g, size := range d.Sizes {
+					if t := tr.auth.GroupTenant(g); t != nil {
+						usage[t.Name] += size
+					}
+				}
+			case *common.Error:
+				tr.l.Warn().Str("error", d.Error()).Stringer("topic", topic).Msg("failed to collect the sizes of the groups")
+			}
+		}
+	}
+	for name, tl := range tr.limiters {
+		tl.updateStorage(usage[name])
+	}
+}
@@ -0,0 +1,108 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+)
+
+func newTestTenantLimiter(limits auth.Limits) *tenantLimiter {
+	return newTenantLimiter(&auth.Tenant{Name: "team-a", Limits: limits}, newMetrics(&observability.Factory{}))
+}
+
+func TestTenantSeries(t *testing.T) {
+	tl := newTestTenantLimiter(auth.Limits{MaxSeries: 2})
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, tl.admitWrite("team-a.g", []byte("s1")))
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, tl.admitWrite("team-a.g", []byte("s2")))
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, tl.admitWrite("team-a.g", []byte("s1")))
+	assert.Equal(t, modelv1.Status_STATUS_RESOURCE_EXHAUSTED, tl.admitWrite("team-a.g", []byte("s3")))
+	assert.Equal(t, modelv1.Status_STATUS_RESOURCE_EXHAUSTED, tl.admitWrite("team-a.other", []byte("s1")))
+
+	// s1 stays active since it's written in the new window, and s2 becomes inactive in the next window.
+	tl.rotateSeries()
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, tl.admitWrite("team-a.g", []byte("s1")))
+	assert.Equal(t, modelv1.Status_STATUS_RESOURCE_EXHAUSTED, tl.admitWrite("team-a.g", []byte("s3")))
+	tl.rotateSeries()
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, tl.admitWrite("team-a.g", []byte("s3")))
+	assert.Equal(t, modelv1.Status_STATUS_RESOURCE_EXHAUSTED, tl.admitWrite("team-a.g", []byte("s2")))
+}
+
+func TestTenantStorage(t *testing.T) {
+	tl := newTestTenantLimiter(auth.Limits{MaxStorageBytes: 100})
+	tl.updateStorage(99)
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, tl.admitWrite("team-a.g", []byte("s1")))
+	tl.updateStorage(100)
+	assert.Equal(t, modelv1.Status_STATUS_RESOURCE_EXHAUSTED, tl.admitWrite("team-a.g", []byte("s1")))
+	tl.updateStorage(10)
+	assert.Equal(t, modelv1.Status_STATUS_SUCCEED, tl.admitWrite("team-a.g", []byte("s1")))
+}
+
+func TestTenantQueries(t *testing.T) {
+	tl := newTestTenantLimiter(auth.Limits{MaxConcurrentQueries: 1})
+	release, err := tl.acquireQuery()
+	require.NoError(t, err)
+	_, err = tl.acquireQuery()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	release()
+	release, err = tl.acquireQuery()
+	require.NoError(t, err)
+	release()
+}
+
+func TestTenantWriteRate(t *testing.T) {
+	tl := newTestTenantLimiter(auth.Limits{WriteRate: 10})
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		require.NoError(t, tl.waitWrite(context.Background()))
+	}
+	// The burst covers the first 10 writes, and the rest are throttled to 10 per second.
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(tl.waitWrite(ctx)))
+}
+
+func TestTenantFilter(t *testing.T) {
+	tl := newTestTenantLimiter(auth.Limits{})
+	resp := &databasev1.GroupRegistryServiceListResponse{
+		Group: []*commonv1.Group{
+			{Metadata: &commonv1.Metadata{Name: "team-a.g1"}},
+			{Metadata: &commonv1.Metadata{Name: "team-b.g1"}},
+			{Metadata: &commonv1.Metadata{Name: "team-a"}},
+			{Metadata: &commonv1.Metadata{Name: "team-a.g2"}},
+		},
+	}
+	tl.filter(resp)
+	require.Len(t, resp.Group, 2)
+	assert.Equal(t, "team-a.g1", resp.Group[0].Metadata.Name)
+	assert.Equal(t, "team-a.g2", resp.Group[1].Metadata.Name)
+}
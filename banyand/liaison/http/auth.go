@@ -0,0 +1,84 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+)
+
+// publicPaths are requested without any credential.
+var publicPaths = map[string]struct{}{
+	"/healthz":               {},
+	"/v1/common/api/version": {},
+}
+
+type userKey struct{}
+
+// authenticated authenticates the callers of the gateway. The authorization is left to the gRPC server,
+// which trusts the users forwarded by the gateway with the gateway key.
+func (p *server) authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !p.auth.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// The callers can't forward the gateway headers on their own.
+		for k := range r.Header {
+			lk := strings.ToLower(k)
+			if strings.HasSuffix(lk, auth.GatewayKeyHeader) || strings.HasSuffix(lk, auth.GatewayUserHeader) {
+				r.Header.Del(k)
+			}
+		}
+		if _, ok := publicPaths[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var user string
+		var err error
+		if r.TLS != nil {
+			user, err = p.auth.Authenticate(r.Header.Get("Authorization"), r.TLS.VerifiedChains)
+		} else {
+			user, err = p.auth.Authenticate(r.Header.Get("Authorization"), nil)
+		}
+		if err != nil {
+			p.auth.Audit(r.Method+" "+r.URL.Path, "", r.RemoteAddr, auth.Access{}, err)
+			w.Header().Set("WWW-Authenticate", `Basic realm="banyandb"`)
+			runtime.DefaultHTTPErrorHandler(r.Context(), p.gwMux, &runtime.JSONPb{}, w, r, status.Error(codes.Unauthenticated, err.Error()))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+// gatewayMetadata forwards the authenticated user to the gRPC server.
+func (p *server) gatewayMetadata(ctx context.Context, _ *http.Request) metadata.MD {
+	user, ok := ctx.Value(userKey{}).(string)
+	if !ok {
+		return nil
+	}
+	return metadata.Pairs(auth.GatewayKeyHeader, p.auth.GatewayKey(), auth.GatewayUserHeader, user)
+}
@@ -41,6 +41,7 @@ import (
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/auth"
 	"github.com/apache/skywalking-banyandb/pkg/healthcheck"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
@@ -57,8 +58,9 @@ var (
 )
 
 // NewServer return a http service.
-func NewServer() Server {
+func NewServer(authSvc *auth.Service) Server {
 	return &server{
+		auth:   authSvc,
 		stopCh: make(chan struct{}),
 	}
 }
@@ -81,6 +83,7 @@ type server struct {
 	grpcClient      *healthcheck.Client
 	grpcCtx         context.Context
 	grpcCancel      context.CancelFunc
+	auth            *auth.Service
 	host            string
 	listenAddr      string
 	grpcAddr        string
@@ -237,7 +240,7 @@ func (p *server) PreRun(_ context.Context) error {
 		IdleTimeout:       60 * time.Second,
 	}
 	if p.tls {
-		p.srv.TLSConfig = p.tlsReloader.GetTLSConfig()
+		p.srv.TLSConfig = p.auth.ClientTLSConfig(p.tlsReloader.GetTLSConfig())
 	}
 
 	return nil
@@ -328,7 +331,7 @@ func (p *server) initGRPCClient() error {
 	}
 
 	// Create gateway mux with health endpoint
-	p.gwMux = runtime.NewServeMux(runtime.WithHealthzEndpoint(p.grpcClient))
+	p.gwMux = runtime.NewServeMux(runtime.WithHealthzEndpoint(p.grpcClient), runtime.WithMetadata(p.gatewayMetadata))
 
 	// Register all service handlers
 	err = multierr.Combine(
@@ -340,6 +343,9 @@ func (p *server) initGRPCClient() error {
 		databasev1.RegisterGroupRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterTopNAggregationRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterSnapshotServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterSlowQueryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterShardServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterNodeServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterPropertyRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		streamv1.RegisterStreamServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		measurev1.RegisterMeasureServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
@@ -354,7 +360,7 @@ func (p *server) initGRPCClient() error {
 	newMux := chi.NewRouter()
 
 	// Mount the gateway mux to the HTTP server
-	newMux.Mount("/api", http.StripPrefix("/api", p.gwMux))
+	newMux.Mount("/api", http.StripPrefix("/api", p.authenticated(p.gwMux)))
 
 	// Replace the old mux with the new one
 	if err := p.setRootPath(newMux); err != nil {
@@ -97,8 +97,8 @@ func (b *block) mustInitFromTagsAndFields(tagFamilies [][]nameValues, fields []n
 		for j, t := range f.values {
 			columns[j].name = t.name
 			columns[j].resizeValues(dataPointsLen)
-			columns[j].valueType = t.valueType
-			columns[j].values[i] = t.marshal()
+			columns[j].codec = t.codec
+			columns[j].setValue(i, t.valueType, t.marshal(), true)
 		}
 	}
 }
@@ -116,8 +116,7 @@ func (b *block) processTags(tf nameValues, columnFamilyIdx, i int, dataPointsLen
 	for j, t := range tf.values {
 		columns[j].name = t.name
 		columns[j].resizeValues(dataPointsLen)
-		columns[j].valueType = t.valueType
-		columns[j].values[i] = t.marshal()
+		columns[j].setValue(i, t.valueType, t.marshal(), false)
 	}
 }
 
@@ -153,7 +152,7 @@ func (b *block) mustWriteTo(sid common.SeriesID, bm *blockMetadata, ww *writers)
 	cc := f.columns
 	cmm := bm.field.resizeColumnMetadata(len(cc))
 	for i := range cc {
-		cc[i].mustWriteTo(&cmm[i], &ww.fieldValuesWriter)
+		cc[i].mustWriteTo(&cmm[i], &ww.fieldValuesWriter, true)
 	}
 }
 
@@ -189,7 +188,7 @@ func (b *block) marshalTagFamily(tf columnFamily, bm *blockMetadata, ww *writers
 	cfm := generateColumnFamilyMetadata()
 	cmm := cfm.resizeColumnMetadata(len(cc))
 	for i := range cc {
-		cc[i].mustWriteTo(&cmm[i], w)
+		cc[i].mustWriteTo(&cmm[i], w, false)
 	}
 	bb := bigValuePool.Generate()
 	defer bigValuePool.Release(bb)
@@ -440,6 +439,8 @@ type blockCursor struct {
 	columnValuesDecoder encoding.BytesBlockDecoder
 	tagProjection       []model.TagProjection
 	fieldProjection     []string
+	tagTypes            map[string]pbv1.ValueType
+	fieldTypes          map[string]pbv1.ValueType
 	bm                  blockMetadata
 	idx                 int
 	minTimestamp        int64
@@ -454,6 +455,8 @@ func (bc *blockCursor) reset() {
 	bc.maxTimestamp = 0
 	bc.tagProjection = bc.tagProjection[:0]
 	bc.fieldProjection = bc.fieldProjection[:0]
+	bc.tagTypes = nil
+	bc.fieldTypes = nil
 
 	bc.timestamps = bc.timestamps[:0]
 	bc.versions = bc.versions[:0]
@@ -474,6 +477,8 @@ func (bc *blockCursor) init(p *part, bm *blockMetadata, queryOpts queryOptions)
 	bc.maxTimestamp = queryOpts.maxTimestamp
 	bc.tagProjection = queryOpts.TagProjection
 	bc.fieldProjection = queryOpts.FieldProjection
+	bc.tagTypes = queryOpts.tagTypes
+	bc.fieldTypes = queryOpts.fieldTypes
 }
 
 func (bc *blockCursor) copyAllTo(r *model.MeasureResult, storedIndexValue map[common.SeriesID]map[string]*modelv1.TagValue,
@@ -675,12 +680,17 @@ func (bc *blockCursor) replace(r *model.MeasureResult, storedIndexValue map[comm
 					}
 				}
 			}
-			for _, c := range cf.columns {
-				if c.name == tagName {
-					r.TagFamilies[i].Tags[j].Values[len(r.TagFamilies[i].Tags[j].Values)-1] = mustDecodeTagValue(c.valueType, c.values[bc.idx])
-					break
+			// The tag is null if the part is written before the tag is added.
+			value := pbv1.NullTagValue
+			if cf != nil {
+				for _, c := range cf.columns {
+					if c.name == tagName {
+						value = mustDecodeTagValue(c.valueType, c.values[bc.idx])
+						break
+					}
 				}
 			}
+			r.TagFamilies[i].Tags[j].Values[len(r.TagFamilies[i].Tags[j].Values)-1] = value
 		}
 	}
 	for i, c := range bc.fields.columns {
@@ -743,6 +753,7 @@ NEXT_FIELD:
 				logger.Panicf("unexpected number of values for tags %q: got %d; want %d", cf.columns[i].name, len(cf.columns[i].values), len(tmpBlock.timestamps))
 			}
 			column.values = append(column.values, cf.columns[i].values[start:end+1]...)
+			column.widen(bc.tagTypes[column.name], false)
 			tf.columns = append(tf.columns, column)
 		}
 		bc.tagFamilies = append(bc.tagFamilies, tf)
@@ -762,6 +773,7 @@ NEXT_FIELD:
 		}
 
 		c.values = append(c.values, tmpBlock.field.columns[i].values[start:end+1]...)
+		c.widen(bc.fieldTypes[c.name], true)
 		bc.fields.columns = append(bc.fields.columns, c)
 	}
 	return true
@@ -863,7 +875,7 @@ func fastTagAppend(bi, b *blockPointer, offset int) error {
 					bi.tagFamilies[i].name, b.tagFamilies[i].columns[j].name, bi.tagFamilies[i].columns[j].name)
 			}
 			assertIdxAndOffset(b.tagFamilies[i].columns[j].name, len(b.tagFamilies[i].columns[j].values), b.idx, offset)
-			bi.tagFamilies[i].columns[j].values = append(bi.tagFamilies[i].columns[j].values, b.tagFamilies[i].columns[j].values[b.idx:offset]...)
+			bi.tagFamilies[i].columns[j].appendValues(&b.tagFamilies[i].columns[j], b.idx, offset, false)
 		}
 	}
 	return nil
@@ -875,7 +887,7 @@ func fullTagAppend(bi, b *blockPointer, offset int) {
 		tagFamily := columnFamily{name: tf.name}
 		for i := range tf.columns {
 			assertIdxAndOffset(tf.columns[i].name, len(tf.columns[i].values), b.idx, offset)
-			col := column{name: tf.columns[i].name, valueType: tf.columns[i].valueType}
+			col := column{name: tf.columns[i].name, valueType: tf.columns[i].valueType, codec: tf.columns[i].codec}
 			for j := 0; j < existDataSize; j++ {
 				col.values = append(col.values, nil)
 			}
@@ -906,10 +918,10 @@ func fullTagAppend(bi, b *blockPointer, offset int) {
 			for _, c := range tf.columns {
 				if existingColumn, exists := columnMap[c.name]; exists {
 					assertIdxAndOffset(c.name, len(c.values), b.idx, offset)
-					existingColumn.values = append(existingColumn.values, c.values[b.idx:offset]...)
+					existingColumn.appendValues(&c, b.idx, offset, false)
 				} else {
 					assertIdxAndOffset(c.name, len(c.values), b.idx, offset)
-					col := column{name: c.name, valueType: c.valueType}
+					col := column{name: c.name, valueType: c.valueType, codec: c.codec}
 					for j := 0; j < existDataSize; j++ {
 						col.values = append(col.values, nil)
 					}
@@ -961,7 +973,7 @@ func fastFieldAppend(bi, b *blockPointer, offset int) error {
 			return fmt.Errorf("unexpected field name: got %q; want %q", b.field.columns[i].name, bi.field.columns[i].name)
 		}
 		assertIdxAndOffset(b.field.columns[i].name, len(b.field.columns[i].values), b.idx, offset)
-		bi.field.columns[i].values = append(bi.field.columns[i].values, b.field.columns[i].values[b.idx:offset]...)
+		bi.field.columns[i].appendValues(&b.field.columns[i], b.idx, offset, true)
 	}
 	return nil
 }
@@ -969,7 +981,7 @@ func fastFieldAppend(bi, b *blockPointer, offset int) error {
 func fullFieldAppend(bi, b *blockPointer, offset int) {
 	existDataSize := len(bi.timestamps)
 	appendFields := func(c column) {
-		col := column{name: c.name, valueType: c.valueType}
+		col := column{name: c.name, valueType: c.valueType, codec: c.codec}
 		for j := 0; j < existDataSize; j++ {
 			col.values = append(col.values, nil)
 		}
@@ -991,7 +1003,7 @@ func fullFieldAppend(bi, b *blockPointer, offset int) {
 	for _, c := range b.field.columns {
 		if existingField, exists := fieldMap[c.name]; exists {
 			assertIdxAndOffset(c.name, len(c.values), b.idx, offset)
-			existingField.values = append(existingField.values, c.values[b.idx:offset]...)
+			existingField.appendValues(&c, b.idx, offset, true)
 		} else {
 			appendFields(c)
 		}
@@ -18,7 +18,10 @@
 package measure
 
 import (
+	"math"
+
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -29,10 +32,12 @@ type column struct {
 	name      string
 	values    [][]byte
 	valueType pbv1.ValueType
+	codec     encoding.Codec
 }
 
 func (c *column) reset() {
 	c.name = ""
+	c.codec = encoding.Codec{}
 
 	values := c.values
 	for i := range values {
@@ -51,17 +56,25 @@ func (c *column) resizeValues(valuesLen int) [][]byte {
 	return values
 }
 
-func (c *column) mustWriteTo(cm *columnMetadata, columnWriter *writer) {
+func (c *column) mustWriteTo(cm *columnMetadata, columnWriter *writer, isField bool) {
 	cm.reset()
 
 	cm.name = c.name
 	cm.valueType = c.valueType
+	cm.codec = c.codec
+	if c.valueType == pbv1.ValueTypeStr && (c.codec.IsDefault() || c.codec.Encoding == encoding.ColumnEncodingDictionary) {
+		cm.codec = encoding.AdaptiveCodec(c.values)
+	}
+	// The tags are encoded differently from the fields, so only the fields record the min and max values.
+	if isField {
+		cm.min, cm.max = columnMinMax(c.valueType, c.values)
+	}
 
 	bb := bigValuePool.Generate()
 	defer bigValuePool.Release(bb)
 
 	// marshal values
-	bb.Buf = encoding.EncodeBytesBlock(bb.Buf[:0], c.values)
+	bb.Buf = encoding.EncodeColumn(bb.Buf[:0], c.values, cm.codec)
 	cm.size = uint64(len(bb.Buf))
 	if cm.size > maxValuesBlockSize {
 		logger.Panicf("too valuesSize: %d bytes; mustn't exceed %d bytes", cm.size, maxValuesBlockSize)
@@ -70,9 +83,124 @@ func (c *column) mustWriteTo(cm *columnMetadata, columnWriter *writer) {
 	columnWriter.MustWrite(bb.Buf)
 }
 
+// columnMinMax returns the min and max values of a numeric column, which are nil
+// if the column isn't numeric, all the values are null, or there is a NaN.
+func columnMinMax(valueType pbv1.ValueType, values [][]byte) ([]byte, []byte) {
+	switch valueType {
+	case pbv1.ValueTypeInt64:
+		var minV, maxV int64
+		var found bool
+		for _, v := range values {
+			if len(v) != 8 {
+				continue
+			}
+			n := convert.BytesToInt64(v)
+			if !found {
+				minV, maxV, found = n, n, true
+				continue
+			}
+			minV, maxV = min(minV, n), max(maxV, n)
+		}
+		if found {
+			return convert.Int64ToBytes(minV), convert.Int64ToBytes(maxV)
+		}
+	case pbv1.ValueTypeFloat64:
+		var minV, maxV float64
+		var found bool
+		for _, v := range values {
+			if len(v) != 8 {
+				continue
+			}
+			f := convert.BytesToFloat64(v)
+			if math.IsNaN(f) {
+				return nil, nil
+			}
+			if !found {
+				minV, maxV, found = f, f, true
+				continue
+			}
+			minV, maxV = min(minV, f), max(maxV, f)
+		}
+		if found {
+			return convert.Float64ToBytes(minV), convert.Float64ToBytes(maxV)
+		}
+	}
+	return nil, nil
+}
+
+// setValue sets the i-th value of the column. The data points written before and after
+// the schema widens the type of the tag or the field could be in the same block.
+func (c *column) setValue(i int, valueType pbv1.ValueType, value []byte, isField bool) {
+	if i > 0 && c.valueType != valueType {
+		switch {
+		case pbv1.CanWiden(c.valueType, valueType):
+			for j := 0; j < i; j++ {
+				c.values[j] = widenValue(c.valueType, valueType, c.values[j], isField)
+			}
+		case pbv1.CanWiden(valueType, c.valueType):
+			c.values[i] = widenValue(valueType, c.valueType, value, isField)
+			return
+		}
+	}
+	c.valueType = valueType
+	c.values[i] = value
+}
+
+// appendValues appends the values of the other column in [idx, offset).
+// The narrower one of the columns is widened if their types are different,
+// which happens once the schema widens the type of the tag or the field.
+func (c *column) appendValues(other *column, idx, offset int, isField bool) {
+	values := other.values[idx:offset]
+	if c.valueType != other.valueType {
+		switch {
+		case pbv1.CanWiden(c.valueType, other.valueType):
+			c.widen(other.valueType, isField)
+			c.codec = other.codec
+		case pbv1.CanWiden(other.valueType, c.valueType):
+			for _, v := range values {
+				c.values = append(c.values, widenValue(other.valueType, c.valueType, v, isField))
+			}
+			return
+		}
+	}
+	c.values = append(c.values, values...)
+}
+
+// widen converts the values to the wider type.
+func (c *column) widen(valueType pbv1.ValueType, isField bool) {
+	if c.valueType == valueType || !pbv1.CanWiden(c.valueType, valueType) {
+		return
+	}
+	for i := range c.values {
+		c.values[i] = widenValue(c.valueType, valueType, c.values[i], isField)
+	}
+	c.valueType = valueType
+}
+
+// widenValue converts the value to the wider type. The tags and the fields encode floats differently.
+func widenValue(from, to pbv1.ValueType, value []byte, isField bool) []byte {
+	if value == nil {
+		return nil
+	}
+	switch {
+	case from == pbv1.ValueTypeInt64 && to == pbv1.ValueTypeFloat64:
+		f := float64(convert.BytesToInt64(value))
+		if isField {
+			return convert.Float64ToBytes(f)
+		}
+		return convert.Float64ToSortableBytes(f)
+	case from == pbv1.ValueTypeStr && to == pbv1.ValueTypeStrArr:
+		return marshalVarArray(nil, value)
+	default:
+		// An int64 array is encoded as the concatenated int64 values.
+		return value
+	}
+}
+
 func (c *column) mustReadValues(decoder *encoding.BytesBlockDecoder, reader fs.Reader, cm columnMetadata, count uint64) {
 	c.name = cm.name
 	c.valueType = cm.valueType
+	c.codec = cm.codec
 	if c.valueType == pbv1.ValueTypeUnknown {
 		for i := uint64(0); i < count; i++ {
 			c.values = append(c.values, nil)
@@ -89,7 +217,7 @@ func (c *column) mustReadValues(decoder *encoding.BytesBlockDecoder, reader fs.R
 	bb.Buf = bytes.ResizeOver(bb.Buf, int(valuesSize))
 	fs.MustReadData(reader, int64(cm.offset), bb.Buf)
 	var err error
-	c.values, err = decoder.Decode(c.values[:0], bb.Buf, count)
+	c.values, err = decoder.DecodeColumn(c.values[:0], bb.Buf, count, cm.codec)
 	if err != nil {
 		logger.Panicf("%s: cannot decode values: %v", reader.Path(), err)
 	}
@@ -98,6 +226,7 @@ func (c *column) mustReadValues(decoder *encoding.BytesBlockDecoder, reader fs.R
 func (c *column) mustSeqReadValues(decoder *encoding.BytesBlockDecoder, reader *seqReader, cm columnMetadata, count uint64) {
 	c.name = cm.name
 	c.valueType = cm.valueType
+	c.codec = cm.codec
 	if cm.offset != reader.bytesRead {
 		logger.Panicf("%s: offset mismatch: %d vs %d", reader.Path(), cm.offset, reader.bytesRead)
 	}
@@ -112,7 +241,7 @@ func (c *column) mustSeqReadValues(decoder *encoding.BytesBlockDecoder, reader *
 	bb.Buf = bytes.ResizeOver(bb.Buf, int(valuesSize))
 	reader.mustReadFull(bb.Buf)
 	var err error
-	c.values, err = decoder.Decode(c.values[:0], bb.Buf, count)
+	c.values, err = decoder.DecodeColumn(c.values[:0], bb.Buf, count, cm.codec)
 	if err != nil {
 		logger.Panicf("%s: cannot decode values: %v", reader.Path(), err)
 	}
@@ -18,6 +18,7 @@
 package measure
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/apache/skywalking-banyandb/pkg/convert"
@@ -26,27 +27,59 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 )
 
+// columnCodecFlag marks the value type of a column which isn't encoded by the default codec.
+// The codec follows the value type, so the parts written before the codec was introduced stay readable.
+const columnCodecFlag = 0x80
+
+// columnMinMaxFlag marks the value type of a column which records the min and max values.
+// They follow the codec.
+const columnMinMaxFlag = 0x40
+
 type columnMetadata struct {
 	name string
+	// min and max are the encoded min and max values of a numeric column. They are nil if unknown.
+	min []byte
+	max []byte
 	dataBlock
 	valueType pbv1.ValueType
+	codec     encoding.Codec
 }
 
 func (cm *columnMetadata) reset() {
 	cm.name = ""
+	cm.min = nil
+	cm.max = nil
 	cm.valueType = 0
+	cm.codec = encoding.Codec{}
 	cm.dataBlock.reset()
 }
 
 func (cm *columnMetadata) copyFrom(src *columnMetadata) {
 	cm.name = src.name
+	cm.min = src.min
+	cm.max = src.max
 	cm.valueType = src.valueType
+	cm.codec = src.codec
 	cm.dataBlock.copyFrom(&src.dataBlock)
 }
 
 func (cm *columnMetadata) marshal(dst []byte) []byte {
 	dst = encoding.EncodeBytes(dst, convert.StringToBytes(cm.name))
-	dst = append(dst, byte(cm.valueType))
+	flag := byte(cm.valueType)
+	if !cm.codec.IsDefault() {
+		flag |= columnCodecFlag
+	}
+	if cm.min != nil {
+		flag |= columnMinMaxFlag
+	}
+	dst = append(dst, flag)
+	if !cm.codec.IsDefault() {
+		dst = cm.codec.Marshal(dst)
+	}
+	if cm.min != nil {
+		dst = encoding.EncodeBytes(dst, cm.min)
+		dst = encoding.EncodeBytes(dst, cm.max)
+	}
 	dst = cm.dataBlock.marshal(dst)
 	return dst
 }
@@ -60,8 +93,26 @@ func (cm *columnMetadata) unmarshal(src []byte) ([]byte, error) {
 	if len(src) < 1 {
 		return nil, fmt.Errorf("cannot unmarshal columnMetadata.valueType: src is too short")
 	}
-	cm.valueType = pbv1.ValueType(src[0])
+	cm.valueType = pbv1.ValueType(src[0] &^ (columnCodecFlag | columnMinMaxFlag))
+	hasCodec := src[0]&columnCodecFlag != 0
+	hasMinMax := src[0]&columnMinMaxFlag != 0
 	src = src[1:]
+	if hasCodec {
+		if src, cm.codec, err = encoding.UnmarshalCodec(src); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal columnMetadata.codec: %w", err)
+		}
+	}
+	if hasMinMax {
+		var v []byte
+		if src, v, err = encoding.DecodeBytes(src); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal columnMetadata.min: %w", err)
+		}
+		cm.min = bytes.Clone(v)
+		if src, v, err = encoding.DecodeBytes(src); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal columnMetadata.max: %w", err)
+		}
+		cm.max = bytes.Clone(v)
+	}
 	src = cm.dataBlock.unmarshal(src)
 	return src, nil
 }
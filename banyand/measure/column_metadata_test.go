@@ -22,6 +22,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -70,6 +72,43 @@ func Test_columnMetadata_marshal(t *testing.T) {
 	assert.Equal(t, original, unmarshaled)
 }
 
+func Test_columnMetadata_marshalCodec(t *testing.T) {
+	original := &columnMetadata{
+		name:      "test",
+		valueType: pbv1.ValueTypeFloat64,
+		dataBlock: dataBlock{offset: 1, size: 10},
+		codec:     encoding.Codec{Encoding: encoding.ColumnEncodingGorilla, Compression: encoding.ColumnCompressionZSTD, Level: 3},
+	}
+
+	marshaled := original.marshal(nil)
+	unmarshaled := &columnMetadata{}
+	tail, err := unmarshaled.unmarshal(marshaled)
+	assert.Nil(t, err)
+	assert.Empty(t, tail)
+	assert.Equal(t, original, unmarshaled)
+
+	// The default codec keeps the layout written before the codec was introduced.
+	original.codec = encoding.Codec{}
+	assert.Equal(t, len(marshaled)-3, len(original.marshal(nil)))
+}
+
+func Test_columnMetadata_marshalMinMax(t *testing.T) {
+	original := &columnMetadata{
+		name:      "test",
+		valueType: pbv1.ValueTypeInt64,
+		dataBlock: dataBlock{offset: 1, size: 10},
+		min:       convert.Int64ToBytes(-3),
+		max:       convert.Int64ToBytes(42),
+	}
+
+	marshaled := original.marshal(nil)
+	unmarshaled := &columnMetadata{}
+	tail, err := unmarshaled.unmarshal(marshaled)
+	assert.Nil(t, err)
+	assert.Empty(t, tail)
+	assert.Equal(t, original, unmarshaled)
+}
+
 func Test_columnFamilyMetadata_reset(t *testing.T) {
 	cfm := &columnFamilyMetadata{
 		columnMetadata: []columnMetadata{
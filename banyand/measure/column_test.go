@@ -21,8 +21,13 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
@@ -66,7 +71,7 @@ func TestColumn_mustWriteTo_mustReadValues(t *testing.T) {
 	buf := &bytes.Buffer{}
 	w := &writer{}
 	w.init(buf)
-	original.mustWriteTo(cm, w)
+	original.mustWriteTo(cm, w, true)
 	assert.Equal(t, w.bytesWritten, cm.size)
 	assert.Equal(t, uint64(len(buf.Buf)), cm.size)
 	assert.Equal(t, uint64(0), cm.offset)
@@ -84,6 +89,121 @@ func TestColumn_mustWriteTo_mustReadValues(t *testing.T) {
 	assert.Equal(t, original.values, unmarshaled.values)
 }
 
+func TestColumn_mustWriteTo_mustReadValuesWithCodec(t *testing.T) {
+	original := &column{
+		name:      "test",
+		valueType: pbv1.ValueTypeInt64,
+		values:    [][]byte{convert.Int64ToBytes(1), nil, convert.Int64ToBytes(3), convert.Int64ToBytes(5)},
+		codec:     encoding.Codec{Encoding: encoding.ColumnEncodingDeltaOfDelta, Compression: encoding.ColumnCompressionLZ4},
+	}
+
+	cm := &columnMetadata{}
+	buf := &bytes.Buffer{}
+	w := &writer{}
+	w.init(buf)
+	original.mustWriteTo(cm, w, true)
+	assert.Equal(t, original.codec, cm.codec)
+
+	unmarshaled := &column{}
+	unmarshaled.mustReadValues(&encoding.BytesBlockDecoder{}, buf, *cm, uint64(len(original.values)))
+	assert.Equal(t, original.codec, unmarshaled.codec)
+	assert.Equal(t, original.values, unmarshaled.values)
+}
+
+func TestColumn_mustWriteTo_mustReadValuesWithHistogram(t *testing.T) {
+	bounds := []float64{10, 100, 1000}
+	h1 := &modelv1.Histogram{Bounds: bounds, Counts: []uint64{1, 2, 3, 4}, Sum: 4321, Count: 10}
+	h2 := &modelv1.Histogram{Bounds: bounds, Counts: []uint64{5, 0, 0, 0}, Sum: 20, Count: 5}
+	h3 := &modelv1.Histogram{Scale: 2, Positive: &modelv1.HistogramBuckets{Offset: 3, Counts: []uint64{1, 1}}, Count: 2}
+	nv := encodeFieldValue("latency", databasev1.FieldType_FIELD_TYPE_HISTOGRAM,
+		&modelv1.FieldValue{Value: &modelv1.FieldValue_Histogram{Histogram: h1}})
+	original := &column{
+		name:      nv.name,
+		valueType: nv.valueType,
+		values:    [][]byte{nv.value, pbv1.MarshalHistogram(nil, h2), nil, pbv1.MarshalHistogram(nil, h3)},
+		codec:     fieldCodec(&databasev1.FieldSpec{FieldType: databasev1.FieldType_FIELD_TYPE_HISTOGRAM}),
+	}
+	assert.Equal(t, encoding.ColumnEncodingHistogram, original.codec.Encoding)
+
+	cm := &columnMetadata{}
+	buf := &bytes.Buffer{}
+	w := &writer{}
+	w.init(buf)
+	original.mustWriteTo(cm, w, true)
+
+	unmarshaled := &column{}
+	unmarshaled.mustReadValues(&encoding.BytesBlockDecoder{}, buf, *cm, uint64(len(original.values)))
+	assert.Equal(t, original.values, unmarshaled.values)
+	for i, want := range []*modelv1.Histogram{h1, h2, nil, h3} {
+		got := mustDecodeFieldValue(unmarshaled.valueType, unmarshaled.values[i])
+		if want == nil {
+			assert.Equal(t, pbv1.NullFieldValue, got)
+			continue
+		}
+		assert.True(t, proto.Equal(want, got.GetHistogram()), "histogram %d", i)
+	}
+}
+
+func TestColumn_mustWriteTo_minMax(t *testing.T) {
+	field := &column{
+		name:      "value",
+		valueType: pbv1.ValueTypeFloat64,
+		values:    [][]byte{convert.Float64ToBytes(-1.5), nil, convert.Float64ToBytes(2.5)},
+	}
+	tag := &column{
+		name:      "ratio",
+		valueType: pbv1.ValueTypeFloat64,
+		values:    [][]byte{convert.Float64ToSortableBytes(-1.5), nil, convert.Float64ToSortableBytes(2.5)},
+	}
+
+	w := &writer{}
+	w.init(&bytes.Buffer{})
+	fm := &columnMetadata{}
+	field.mustWriteTo(fm, w, true)
+	assert.Equal(t, -1.5, convert.BytesToFloat64(fm.min))
+	assert.Equal(t, 2.5, convert.BytesToFloat64(fm.max))
+
+	tm := &columnMetadata{}
+	tag.mustWriteTo(tm, w, false)
+	assert.Nil(t, tm.min)
+	assert.Nil(t, tm.max)
+}
+
+func TestColumn_widen(t *testing.T) {
+	c := &column{
+		name:      "value",
+		valueType: pbv1.ValueTypeInt64,
+		values:    make([][]byte, 2),
+	}
+	c.setValue(0, pbv1.ValueTypeInt64, convert.Int64ToBytes(1), true)
+	// The schema widens the field after the first data point is written.
+	c.setValue(1, pbv1.ValueTypeFloat64, convert.Float64ToBytes(2.5), true)
+	assert.Equal(t, pbv1.ValueTypeFloat64, c.valueType)
+	assert.Equal(t, [][]byte{convert.Float64ToBytes(1), convert.Float64ToBytes(2.5)}, c.values)
+
+	narrower := &column{
+		name:      "value",
+		valueType: pbv1.ValueTypeInt64,
+		values:    [][]byte{convert.Int64ToBytes(3), nil},
+	}
+	c.appendValues(narrower, 0, 2, true)
+	assert.Equal(t, pbv1.ValueTypeFloat64, c.valueType)
+	assert.Equal(t, [][]byte{convert.Float64ToBytes(1), convert.Float64ToBytes(2.5), convert.Float64ToBytes(3), nil}, c.values)
+
+	tag := &column{
+		name:      "layer",
+		valueType: pbv1.ValueTypeStr,
+		values:    [][]byte{[]byte("a"), nil},
+	}
+	tag.widen(pbv1.ValueTypeStrArr, false)
+	assert.Equal(t, pbv1.ValueTypeStrArr, tag.valueType)
+	assert.Equal(t, [][]byte{marshalVarArray(nil, []byte("a")), nil}, tag.values)
+
+	// A narrowing is ignored.
+	tag.widen(pbv1.ValueTypeStr, false)
+	assert.Equal(t, pbv1.ValueTypeStrArr, tag.valueType)
+}
+
 func TestColumnFamily_reset(t *testing.T) {
 	cf := &columnFamily{
 		name: "test",
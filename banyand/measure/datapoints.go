@@ -24,6 +24,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/pool"
@@ -35,12 +36,14 @@ type nameValue struct {
 	value     []byte
 	valueArr  [][]byte
 	valueType pbv1.ValueType
+	codec     encoding.Codec
 }
 
 func (n *nameValue) reset() {
 	n.name = ""
 	n.value = nil
 	n.valueArr = nil
+	n.codec = encoding.Codec{}
 }
 
 func generateNameValue() *nameValue {
@@ -223,6 +226,7 @@ type dataPointsInTable struct {
 	tsTable    *tsTable
 	dataPoints *dataPoints
 	timeRange  timestamp.TimeRange
+	shardID    common.ShardID
 }
 
 type dataPointsInGroup struct {
@@ -0,0 +1,131 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"cmp"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+// pruneBlocks drops the blocks whose field ranges can't match the predicates.
+// A block overlapping another block of the same series is kept, because its data points
+// might be newer versions of the ones in the other block, which shouldn't show up again.
+func pruneBlocks(data []*blockCursor, predicates []model.FieldPredicate) []*blockCursor {
+	if len(predicates) == 0 {
+		return data
+	}
+	series := make(map[common.SeriesID][]int)
+	for i, bc := range data {
+		series[bc.bm.seriesID] = append(series[bc.bm.seriesID], i)
+	}
+	skipped := make([]bool, len(data))
+	for i, bc := range data {
+		skipped[i] = !blockMatches(&bc.bm, predicates) && !overlapsSeries(data, series[bc.bm.seriesID], i)
+	}
+	kept := data[:0]
+	for i, bc := range data {
+		if skipped[i] {
+			releaseBlockCursor(bc)
+			continue
+		}
+		kept = append(kept, bc)
+	}
+	for i := len(kept); i < len(data); i++ {
+		data[i] = nil
+	}
+	return kept
+}
+
+func overlapsSeries(data []*blockCursor, series []int, i int) bool {
+	bm := &data[i].bm
+	for _, j := range series {
+		if j == i {
+			continue
+		}
+		if other := data[j]; other.bm.timestamps.min <= bm.timestamps.max && bm.timestamps.min <= other.bm.timestamps.max {
+			return true
+		}
+	}
+	return false
+}
+
+func blockMatches(bm *blockMetadata, predicates []model.FieldPredicate) bool {
+	for _, p := range predicates {
+		for i := range bm.field.columnMetadata {
+			if cm := &bm.field.columnMetadata[i]; cm.name == p.Name && !rangeMatches(cm, p) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// rangeMatches reports whether a value between the min and max values of the column might match the predicate.
+// An int column is compared as floats with a float value.
+func rangeMatches(cm *columnMetadata, p model.FieldPredicate) bool {
+	if cm.min == nil || cm.max == nil {
+		return true
+	}
+	var cmpMin, cmpMax int
+	switch cm.valueType {
+	case pbv1.ValueTypeInt64:
+		minV, maxV := convert.BytesToInt64(cm.min), convert.BytesToInt64(cm.max)
+		switch v := p.Value.GetValue().(type) {
+		case *modelv1.FieldValue_Int:
+			cmpMin, cmpMax = cmp.Compare(minV, v.Int.GetValue()), cmp.Compare(maxV, v.Int.GetValue())
+		case *modelv1.FieldValue_Float:
+			cmpMin, cmpMax = cmp.Compare(float64(minV), v.Float.GetValue()), cmp.Compare(float64(maxV), v.Float.GetValue())
+		default:
+			return true
+		}
+	case pbv1.ValueTypeFloat64:
+		minV, maxV := convert.BytesToFloat64(cm.min), convert.BytesToFloat64(cm.max)
+		var f float64
+		switch v := p.Value.GetValue().(type) {
+		case *modelv1.FieldValue_Int:
+			f = float64(v.Int.GetValue())
+		case *modelv1.FieldValue_Float:
+			f = v.Float.GetValue()
+		default:
+			return true
+		}
+		cmpMin, cmpMax = cmp.Compare(minV, f), cmp.Compare(maxV, f)
+	default:
+		return true
+	}
+	switch p.Op {
+	case modelv1.Condition_BINARY_OP_EQ:
+		return cmpMin <= 0 && cmpMax >= 0
+	case modelv1.Condition_BINARY_OP_NE:
+		return cmpMin != 0 || cmpMax != 0
+	case modelv1.Condition_BINARY_OP_LT:
+		return cmpMin < 0
+	case modelv1.Condition_BINARY_OP_LE:
+		return cmpMin <= 0
+	case modelv1.Condition_BINARY_OP_GT:
+		return cmpMax > 0
+	case modelv1.Condition_BINARY_OP_GE:
+		return cmpMax >= 0
+	}
+	return true
+}
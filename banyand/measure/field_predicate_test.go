@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+func intPredicate(op modelv1.Condition_BinaryOp, v int64) model.FieldPredicate {
+	return model.FieldPredicate{Name: "v", Op: op, Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v}}}}
+}
+
+func floatPredicate(op modelv1.Condition_BinaryOp, v float64) model.FieldPredicate {
+	return model.FieldPredicate{Name: "v", Op: op, Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: v}}}}
+}
+
+func Test_rangeMatches(t *testing.T) {
+	ints := &columnMetadata{name: "v", valueType: pbv1.ValueTypeInt64, min: convert.Int64ToBytes(10), max: convert.Int64ToBytes(20)}
+	floats := &columnMetadata{name: "v", valueType: pbv1.ValueTypeFloat64, min: convert.Float64ToBytes(1.5), max: convert.Float64ToBytes(1.5)}
+	tests := []struct {
+		cm   *columnMetadata
+		name string
+		p    model.FieldPredicate
+		want bool
+	}{
+		{name: "eq in range", cm: ints, p: intPredicate(modelv1.Condition_BINARY_OP_EQ, 15), want: true},
+		{name: "eq out of range", cm: ints, p: intPredicate(modelv1.Condition_BINARY_OP_EQ, 21)},
+		{name: "lt min", cm: ints, p: intPredicate(modelv1.Condition_BINARY_OP_LT, 10)},
+		{name: "le min", cm: ints, p: intPredicate(modelv1.Condition_BINARY_OP_LE, 10), want: true},
+		{name: "gt max", cm: ints, p: intPredicate(modelv1.Condition_BINARY_OP_GT, 20)},
+		{name: "ge max", cm: ints, p: intPredicate(modelv1.Condition_BINARY_OP_GE, 20), want: true},
+		{name: "int column with float value", cm: ints, p: floatPredicate(modelv1.Condition_BINARY_OP_GT, 19.5), want: true},
+		{name: "ne single value", cm: floats, p: floatPredicate(modelv1.Condition_BINARY_OP_NE, 1.5)},
+		{name: "ne other value", cm: floats, p: intPredicate(modelv1.Condition_BINARY_OP_NE, 1), want: true},
+		{name: "no min max", cm: &columnMetadata{name: "v", valueType: pbv1.ValueTypeInt64}, p: intPredicate(modelv1.Condition_BINARY_OP_EQ, 0), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rangeMatches(tt.cm, tt.p))
+		})
+	}
+}
+
+func Test_pruneBlocks(t *testing.T) {
+	newCursor := func(sid common.SeriesID, minTS, maxTS, minV, maxV int64) *blockCursor {
+		bc := generateBlockCursor()
+		bc.bm.seriesID = sid
+		bc.bm.timestamps.min, bc.bm.timestamps.max = minTS, maxTS
+		bc.bm.field.columnMetadata = []columnMetadata{
+			{name: "v", valueType: pbv1.ValueTypeInt64, min: convert.Int64ToBytes(minV), max: convert.Int64ToBytes(maxV)},
+		}
+		return bc
+	}
+	matched := newCursor(1, 1, 10, 50, 100)
+	unmatched := newCursor(1, 11, 20, 0, 10)
+	// The block overlaps with the next one, which might hold older versions of its data points.
+	overlapped := newCursor(2, 1, 10, 0, 10)
+	other := newCursor(2, 5, 15, 50, 100)
+	data := []*blockCursor{matched, unmatched, overlapped, other}
+
+	got := pruneBlocks(data, []model.FieldPredicate{intPredicate(modelv1.Condition_BINARY_OP_GT, 20)})
+	assert.Equal(t, []*blockCursor{matched, overlapped, other}, got)
+	assert.Equal(t, got, pruneBlocks(got, nil))
+}
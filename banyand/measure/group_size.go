@@ -0,0 +1,57 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+// groupSizeListener measures the disk size of the groups on this node, which is the storage used by their tenants.
+type groupSizeListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+func (g *groupSizeListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	req := message.Data().(*databasev1.GroupSizeRequest)
+	resp := &databasev1.GroupSizeResponse{
+		Node:  g.s.nodeID,
+		Sizes: make(map[string]uint64, len(req.Groups)),
+	}
+	for _, group := range req.Groups {
+		// Only the loaded groups are measured, which rejects the names escaping the data path.
+		if _, ok := g.s.schemaRepo.LoadGroup(group); !ok {
+			continue
+		}
+		size, err := storage.DirSize(filepath.Join(g.s.dataPath, group))
+		if err != nil {
+			g.s.l.Error().Err(err).Str("group", group).Msg("failed to measure the size of the group")
+			return bus.NewMessage(bus.MessageID(time.Now().UnixNano()),
+				common.NewError("failed to measure the size of %s: %v", group, err))
+		}
+		resp.Sizes[group] = size
+	}
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), resp)
+}
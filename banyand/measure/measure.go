@@ -28,6 +28,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/partition"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
@@ -73,6 +74,9 @@ type measure struct {
 	l           *logger.Logger
 	schema      *databasev1.Measure
 	schemaRepo  *schemaRepo
+	counters    *counterNormalizer
+	tagTypes    map[string]pbv1.ValueType
+	fieldTypes  map[string]pbv1.ValueType
 	name        string
 	group       string
 	interval    time.Duration
@@ -105,6 +109,20 @@ func (s *measure) parseSpec() (err error) {
 	var is indexSchema
 	is.parse(s.schema)
 	s.indexSchema.Store(is)
+	// The columns written before the schema widens their types are read as the current types.
+	s.tagTypes = make(map[string]pbv1.ValueType)
+	for _, tf := range s.schema.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			if t.GetType() != databasev1.TagType_TAG_TYPE_UNSPECIFIED {
+				s.tagTypes[t.GetName()] = pbv1.MustTagValueSpecToValueType(t.GetType())
+			}
+		}
+	}
+	s.fieldTypes = make(map[string]pbv1.ValueType, len(s.schema.GetFields()))
+	for _, f := range s.schema.GetFields() {
+		s.fieldTypes[f.GetName()] = pbv1.FieldTypeToValueType(f.GetFieldType())
+	}
+	s.counters = s.schemaRepo.loadCounterNormalizer(s.schema.GetMetadata(), s.schema.GetTemporality())
 	return err
 }
 
@@ -52,6 +52,8 @@ const (
 	TopNTagFamily = "_topN"
 	// TopNFieldName is the field name of the topN result measure.
 	TopNFieldName = "value"
+	// DataPointTypeFieldName is the name of the internal field which stores the type of data points.
+	DataPointTypeFieldName = "_data_point_type"
 )
 
 var (
@@ -78,6 +80,7 @@ type schemaRepo struct {
 	pipeline         queue.Queue
 	l                *logger.Logger
 	topNProcessorMap sync.Map
+	counters         sync.Map
 	path             string
 }
 
@@ -129,6 +132,27 @@ func (sr *schemaRepo) Measure(metadata *commonv1.Metadata) (Measure, error) {
 	return sm, nil
 }
 
+// loadCounterNormalizer returns the counter normalizer of the measure. The running totals are kept
+// while the schema of the measure is updated, unless the temporality is changed.
+func (sr *schemaRepo) loadCounterNormalizer(md *commonv1.Metadata, temporality databasev1.Temporality) *counterNormalizer {
+	if temporality == databasev1.Temporality_TEMPORALITY_UNSPECIFIED {
+		if sr != nil {
+			sr.counters.Delete(getKey(md))
+		}
+		return nil
+	}
+	if sr == nil {
+		return newCounterNormalizer(temporality)
+	}
+	key := getKey(md)
+	if v, ok := sr.counters.Load(key); ok && v.(*counterNormalizer).temporality == temporality {
+		return v.(*counterNormalizer)
+	}
+	cn := newCounterNormalizer(temporality)
+	sr.counters.Store(key, cn)
+	return cn
+}
+
 func (sr *schemaRepo) GetRemovalSegmentsTimeRange(group string) *timestamp.TimeRange {
 	g, ok := sr.LoadGroup(group)
 	if !ok {
@@ -239,6 +263,7 @@ func (sr *schemaRepo) OnDelete(metadata schema.Metadata) {
 			Metadata: m,
 		})
 		sr.stopSteamingManager(m.GetMetadata())
+		sr.counters.Delete(getKey(m.GetMetadata()))
 	case schema.KindIndexRuleBinding:
 		if binding, ok := metadata.Spec.(*databasev1.IndexRuleBinding); ok {
 			if binding.GetSubject().Catalog == commonv1.Catalog_CATALOG_MEASURE {
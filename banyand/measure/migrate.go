@@ -0,0 +1,308 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+// migrateShardListener sends the data points of a shard on this node to the new nodes of the shard.
+// The data points stay on this node, and they're reclaimed by the retention.
+type migrateShardListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+func (m *migrateShardListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	req := message.Data().(*databasev1.MigrateShardRequest)
+	migrated, err := m.s.migrateShard(ctx, req)
+	if err != nil {
+		m.s.l.Error().Err(err).Str("group", req.Group).Uint32("shard", req.ShardId).Msg("failed to migrate the shard")
+		return bus.NewMessage(bus.MessageID(time.Now().UnixNano()),
+			common.NewError("failed to migrate the shard %d of %s: %v", req.ShardId, req.Group, err))
+	}
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), &databasev1.MigrateShardResponse{
+		Node:     m.s.nodeID,
+		Migrated: migrated,
+	})
+}
+
+func (s *service) migrateShard(ctx context.Context, req *databasev1.MigrateShardRequest) (uint64, error) {
+	targets := make([]string, 0, len(req.Targets))
+	client, err := pub.NewWithTLS(s.clientTLS)
+	if err != nil {
+		return 0, err
+	}
+	defer client.GracefulStop()
+	for _, n := range req.Targets {
+		if n.GetMetadata().GetName() == s.nodeID {
+			continue
+		}
+		targets = append(targets, n.Metadata.Name)
+		client.OnAddOrUpdate(schema.Metadata{
+			TypeMeta: schema.TypeMeta{
+				Kind: schema.KindNode,
+			},
+			Spec: n,
+		})
+	}
+	if len(targets) == 0 {
+		return 0, nil
+	}
+	g, ok := s.schemaRepo.LoadGroup(req.Group)
+	if !ok || g.SupplyTSDB() == nil {
+		// This node holds none of the data points of the group.
+		return 0, nil
+	}
+	tsdb := g.SupplyTSDB().(storage.TSDB[*tsTable, option])
+	key := migrationKey{group: req.Group, shardID: common.ShardID(req.ShardId)}
+	scan := &model.ShardScan{ID: key.shardID}
+	if req.CatchUp {
+		if marks, loaded := s.migrationMarks.LoadAndDelete(key); loaded {
+			scan.Marks = marks.(map[string]uint64)
+		}
+	}
+	// The marks are taken ahead of the pass, so the parts introduced during the pass are sent by the catch-up again.
+	marks, err := shardMarks(tsdb, key.shardID)
+	if err != nil {
+		return 0, err
+	}
+	mm, err := s.metadata.MeasureRegistry().ListMeasure(ctx, schema.ListOpt{Group: req.Group})
+	if err != nil {
+		return 0, err
+	}
+	batch := client.NewBatchPublisher(30 * time.Second)
+	var migrated uint64
+	send := func(iwr *measurev1.InternalWriteRequest) error {
+		for _, n := range targets {
+			if _, errPublish := batch.Publish(ctx, data.TopicMeasureWrite,
+				bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), n, iwr)); errPublish != nil {
+				return errors.WithMessagef(errPublish, "failed to send the data point to node %s", n)
+			}
+		}
+		migrated++
+		return nil
+	}
+	for _, m := range mm {
+		if err = s.scanMeasureShard(ctx, m, g.GetSchema().GetResourceOpts().GetShardNum(), scan, send); err != nil {
+			break
+		}
+	}
+	cee, errClose := batch.Close()
+	if err != nil {
+		return migrated, err
+	}
+	if errClose != nil {
+		return migrated, errClose
+	}
+	for n, ce := range cee {
+		return migrated, errors.Errorf("node %s rejects the data points: %v", n, ce)
+	}
+	if !req.CatchUp {
+		s.migrationMarks.Store(key, marks)
+	}
+	return migrated, nil
+}
+
+// migrationKey identifies a shard of a group whose migration is in progress.
+type migrationKey struct {
+	group   string
+	shardID common.ShardID
+}
+
+// shardMarks returns the id of the last part of every segment of the shard.
+// A catch-up skips these parts. The parts merged from them get new ids, so the catch-up sends
+// their data points again, which the targets take as the copies of the ones they have received.
+func shardMarks(tsdb storage.TSDB[*tsTable, option], shardID common.ShardID) (map[string]uint64, error) {
+	segments, err := tsdb.SelectSegments(timestamp.NewInclusiveTimeRange(time.Unix(0, 0), time.Unix(0, timestamp.MaxNanoTime)))
+	if err != nil {
+		return nil, err
+	}
+	scan := &model.ShardScan{ID: shardID}
+	marks := make(map[string]uint64, len(segments))
+	for _, segment := range segments {
+		for _, tst := range segment.Tables() {
+			if inShard, _ := tst.shardScan(scan); inShard {
+				marks[tst.p.Segment] = tst.lastPartID()
+			}
+		}
+		segment.DecRef()
+	}
+	return marks, nil
+}
+
+// scanMeasureShard visits the data points of the shard as the requests to write them to the new nodes.
+func (s *service) scanMeasureShard(ctx context.Context, m *databasev1.Measure, shardNum uint32, scan *model.ShardScan,
+	visit func(*measurev1.InternalWriteRequest) error,
+) error {
+	mq, err := s.Measure(m.Metadata)
+	if err != nil {
+		return err
+	}
+	tagProjection := make([]model.TagProjection, len(m.TagFamilies))
+	for i, tf := range m.TagFamilies {
+		tagProjection[i] = model.TagProjection{
+			Family: tf.Name,
+			Names:  make([]string, len(tf.Tags)),
+		}
+		for j, t := range tf.Tags {
+			tagProjection[i].Names[j] = t.Name
+		}
+	}
+	fieldProjection := make([]string, len(m.Fields))
+	for i, f := range m.Fields {
+		fieldProjection[i] = f.Name
+	}
+	entity := make([]*modelv1.TagValue, len(m.Entity.TagNames))
+	for i := range entity {
+		entity[i] = pbv1.AnyTagValue
+	}
+	tr := timestamp.NewInclusiveTimeRange(time.Unix(0, 0), time.Unix(0, timestamp.MaxNanoTime))
+	result, err := mq.Query(ctx, model.MeasureQueryOptions{
+		Name:            m.Metadata.Name,
+		TagProjection:   tagProjection,
+		FieldProjection: fieldProjection,
+		Entities:        [][]*modelv1.TagValue{entity},
+		TimeRange:       &tr,
+		Shard:           scan,
+	})
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	defer result.Release()
+	entityLocator := partition.NewEntityLocator(m.TagFamilies, m.Entity, 0)
+	// The liaisons place the data points by the sharding key if the measure has one.
+	shardLocator := entityLocator
+	if len(m.GetShardingKey().GetTagNames()) > 0 {
+		shardLocator = partition.NewShardingKeyLocator(m.TagFamilies, m.ShardingKey)
+	}
+	for mr := result.Pull(); mr != nil; mr = result.Pull() {
+		if mr.Error != nil {
+			return mr.Error
+		}
+		for i := range mr.Timestamps {
+			dp := &measurev1.DataPointValue{
+				Timestamp: timestamppb.New(time.Unix(0, mr.Timestamps[i])),
+				Version:   mr.Versions[i],
+			}
+			for _, tf := range mr.TagFamilies {
+				tfw := &modelv1.TagFamilyForWrite{}
+				for _, tag := range tf.Tags {
+					tfw.Tags = append(tfw.Tags, tag.Values[i])
+				}
+				dp.TagFamilies = append(dp.TagFamilies, tfw)
+			}
+			for _, field := range mr.Fields {
+				dp.Fields = append(dp.Fields, field.Values[i])
+			}
+			e, tagValues, _, errLocate := entityLocator.Locate(m.Metadata.Name, dp.TagFamilies, shardNum)
+			if errLocate != nil {
+				return errLocate
+			}
+			_, _, id, errLocate := shardLocator.Locate(m.Metadata.Name, dp.TagFamilies, shardNum)
+			if errLocate != nil {
+				return errLocate
+			}
+			if id != scan.ID {
+				continue
+			}
+			iwr := &measurev1.InternalWriteRequest{
+				Request: &measurev1.WriteRequest{
+					Metadata:  m.Metadata,
+					DataPoint: dp,
+					MessageId: uint64(time.Now().UnixNano()),
+				},
+				ShardId:      uint32(scan.ID),
+				SeriesHash:   pbv1.HashEntity(e),
+				EntityValues: tagValues[1:].Encode(),
+			}
+			if err = visit(iwr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dataPointIdentity identifies a data point by its series and timestamp.
+// A newer version of the data point takes the place of the older one.
+func dataPointIdentity(iwr *measurev1.InternalWriteRequest) uint64 {
+	return convert.Hash(append(convert.Int64ToBytes(iwr.Request.DataPoint.Timestamp.AsTime().UnixNano()), iwr.SeriesHash...))
+}
+
+// countShardListener counts the distinct data points of a shard on this node, which verifies a migration.
+type countShardListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+func (c *countShardListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	req := message.Data().(*databasev1.CountShardRequest)
+	resp, err := c.s.countShard(ctx, req)
+	if err != nil {
+		c.s.l.Error().Err(err).Str("group", req.Group).Uint32("shard", req.ShardId).Msg("failed to count the shard")
+		return bus.NewMessage(bus.MessageID(time.Now().UnixNano()),
+			common.NewError("failed to count the shard %d of %s: %v", req.ShardId, req.Group, err))
+	}
+	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), resp)
+}
+
+func (s *service) countShard(ctx context.Context, req *databasev1.CountShardRequest) (*databasev1.CountShardResponse, error) {
+	counter := partition.NewShardCounter(req)
+	g, ok := s.schemaRepo.LoadGroup(req.Group)
+	if !ok || g.SupplyTSDB() == nil {
+		return counter.Response(s.nodeID), nil
+	}
+	mm, err := s.metadata.MeasureRegistry().ListMeasure(ctx, schema.ListOpt{Group: req.Group})
+	if err != nil {
+		return nil, err
+	}
+	count := func(iwr *measurev1.InternalWriteRequest) error {
+		counter.Add(dataPointIdentity(iwr))
+		return nil
+	}
+	for _, m := range mm {
+		if err = s.scanMeasureShard(ctx, m, g.GetSchema().GetResourceOpts().GetShardNum(),
+			&model.ShardScan{ID: common.ShardID(req.ShardId)}, count); err != nil {
+			return nil, err
+		}
+	}
+	return counter.Response(s.nodeID), nil
+}
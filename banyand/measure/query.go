@@ -23,8 +23,10 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
@@ -36,6 +38,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/index/posting/roaring"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
 	resourceSchema "github.com/apache/skywalking-banyandb/pkg/schema"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
@@ -65,6 +68,8 @@ type Measure interface {
 var _ Measure = (*measure)(nil)
 
 type queryOptions struct {
+	tagTypes   map[string]pbv1.ValueType
+	fieldTypes map[string]pbv1.ValueType
 	model.MeasureQueryOptions
 	minTimestamp int64
 	maxTimestamp int64
@@ -140,14 +145,20 @@ func (s *measure) Query(ctx context.Context, mqo model.MeasureQueryOptions) (mqr
 		MeasureQueryOptions: mqo,
 		minTimestamp:        mqo.TimeRange.Start.UnixNano(),
 		maxTimestamp:        mqo.TimeRange.End.UnixNano(),
+		tagTypes:            s.tagTypes,
+		fieldTypes:          s.fieldTypes,
 	}
 	var n int
 	for i := range tables {
+		inShard, afterPartID := tables[i].shardScan(mqo.Shard)
+		if !inShard {
+			continue
+		}
 		s := tables[i].currentSnapshot()
 		if s == nil {
 			continue
 		}
-		parts, n = s.getParts(parts, qo.minTimestamp, qo.maxTimestamp)
+		parts, n = s.getParts(parts, qo.minTimestamp, qo.maxTimestamp, afterPartID)
 		if n < 1 {
 			s.decRef()
 			continue
@@ -373,7 +384,7 @@ func (s *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 		return fmt.Errorf("cannot init tstIter: %w", tstIter.Error())
 	}
 	var hit int
-	var totalBlockBytes uint64
+	var totalBlockBytes, totalRows uint64
 	for tstIter.nextBlock() {
 		if hit%checkDoneEvery == 0 {
 			select {
@@ -387,11 +398,16 @@ func (s *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 		p := tstIter.piHeap[0]
 		bc.init(p.p, p.curBlock, qo)
 		result.data = append(result.data, bc)
-		totalBlockBytes += bc.bm.uncompressedSizeBytes
 	}
 	if tstIter.Error() != nil {
 		return fmt.Errorf("cannot iterate tstIter: %w", tstIter.Error())
 	}
+	result.data = pruneBlocks(result.data, qo.FieldPredicates)
+	for _, bc := range result.data {
+		totalBlockBytes += bc.bm.uncompressedSizeBytes
+		totalRows += bc.bm.count
+	}
+	executor.QueryStatsFromContext(ctx).AddScanned(totalRows)
 	if err := s.pm.AcquireResource(ctx, totalBlockBytes); err != nil {
 		return err
 	}
@@ -431,6 +447,12 @@ func mustDecodeTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValu
 			values = append(values, string(bb.Buf))
 		}
 		return strArrTagValue(values)
+	case pbv1.ValueTypeFloat64:
+		return float64TagValue(convert.SortableBytesToFloat64(value))
+	case pbv1.ValueTypeBool:
+		return boolTagValue(pbv1.BytesToBool(value))
+	case pbv1.ValueTypeTimestamp:
+		return timestampTagValue(convert.BytesToInt64(value))
 	default:
 		logger.Panicf("unsupported value type: %v", valueType)
 		return nil
@@ -487,6 +509,34 @@ func strArrTagValue(values []string) *modelv1.TagValue {
 	}
 }
 
+func float64TagValue(value float64) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Float{
+			Float: &modelv1.Float{
+				Value: value,
+			},
+		},
+	}
+}
+
+func boolTagValue(value bool) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Bool{
+			Bool: &modelv1.Bool{
+				Value: value,
+			},
+		},
+	}
+}
+
+func timestampTagValue(value int64) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Timestamp{
+			Timestamp: timestamppb.New(time.Unix(0, value)),
+		},
+	}
+}
+
 func mustDecodeFieldValue(valueType pbv1.ValueType, value []byte) *modelv1.FieldValue {
 	if value == nil {
 		switch valueType {
@@ -507,6 +557,12 @@ func mustDecodeFieldValue(valueType pbv1.ValueType, value []byte) *modelv1.Field
 		return strFieldValue(string(value))
 	case pbv1.ValueTypeBinaryData:
 		return binaryDataFieldValue(value)
+	case pbv1.ValueTypeHistogram:
+		h, err := pbv1.UnmarshalHistogram(value)
+		if err != nil {
+			logger.Panicf("cannot decode histogram: %v", err)
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Histogram{Histogram: h}}
 	default:
 		logger.Panicf("unsupported value type: %v", valueType)
 		return nil
@@ -22,6 +22,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -36,6 +37,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -69,14 +71,18 @@ type service struct {
 	omr                 observability.MetricsRegistry
 	metadata            metadata.Repo
 	pm                  *protector.Memory
+	clientTLS           *pub.ClientTLS
 	schemaRepo          *schemaRepo
 	l                   *logger.Logger
 	root                string
+	nodeID              string
 	snapshotDir         string
 	dataPath            string
 	option              option
 	maxDiskUsagePercent int
 	maxFileSnapshotNum  int
+	// migrationMarks holds the marks of the shards whose migrations wait for a catch-up.
+	migrationMarks sync.Map
 }
 
 func (s *service) Measure(metadata *commonv1.Metadata) (Measure, error) {
@@ -148,6 +154,7 @@ func (s *service) PreRun(ctx context.Context) error {
 		return errors.New("node id is empty")
 	}
 	node := val.(common.Node)
+	s.nodeID = node.NodeID
 	s.schemaRepo = newSchemaRepo(s.dataPath, s, node.Labels)
 	if s.pipeline == nil {
 		return nil
@@ -164,6 +171,15 @@ func (s *service) PreRun(ctx context.Context) error {
 	if err := s.pipeline.Subscribe(data.TopicMeasureDeleteExpiredSegments, &deleteStreamSegmentsListener{s: s}); err != nil {
 		return err
 	}
+	if err := s.pipeline.Subscribe(data.TopicMeasureMigrateShard, &migrateShardListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicMeasureCountShard, &countShardListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicMeasureGroupSize, &groupSizeListener{s: s}); err != nil {
+		return err
+	}
 
 	s.writeListener = setUpWriteCallback(s.l, s.schemaRepo, s.maxDiskUsagePercent)
 	// only subscribe metricPipeline for data node
@@ -192,13 +208,17 @@ func (s *service) GracefulStop() {
 }
 
 // NewService returns a new service.
-func NewService(metadata metadata.Repo, pipeline queue.Server, metricPipeline queue.Server, omr observability.MetricsRegistry, pm *protector.Memory) (Service, error) {
+// The clientTLS configures the connections to the new nodes of the migrated shards. They're not encrypted if it's nil.
+func NewService(metadata metadata.Repo, pipeline queue.Server, metricPipeline queue.Server, omr observability.MetricsRegistry, pm *protector.Memory,
+	clientTLS *pub.ClientTLS,
+) (Service, error) {
 	return &service{
 		metadata:       metadata,
 		pipeline:       pipeline,
 		metricPipeline: metricPipeline,
 		omr:            omr,
 		pm:             pm,
+		clientTLS:      clientTLS,
 	}, nil
 }
 
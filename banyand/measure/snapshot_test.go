@@ -39,10 +39,11 @@ func TestSnapshotGetParts(t *testing.T) {
 	tests := []struct {
 		snapshot *snapshot
 		name     string
-		dst      []*part
-		expected []*part
-		opts     queryOptions
-		count    int
+		dst         []*part
+		expected    []*part
+		opts        queryOptions
+		count       int
+		afterPartID uint64
 	}{
 		{
 			name: "Test with empty snapshot",
@@ -118,11 +119,46 @@ func TestSnapshotGetParts(t *testing.T) {
 			},
 			count: 2,
 		},
+		{
+			name: "Test with the parts after a part id",
+			snapshot: &snapshot{
+				parts: []*partWrapper{
+					{
+						p: &part{partMetadata: partMetadata{
+							ID:           1,
+							MinTimestamp: 0,
+							MaxTimestamp: 5,
+						}},
+					},
+					{
+						p: &part{partMetadata: partMetadata{
+							ID:           2,
+							MinTimestamp: 6,
+							MaxTimestamp: 10,
+						}},
+					},
+				},
+			},
+			dst: []*part{},
+			opts: queryOptions{
+				minTimestamp: 0,
+				maxTimestamp: 10,
+			},
+			afterPartID: 1,
+			expected: []*part{
+				{partMetadata: partMetadata{
+					ID:           2,
+					MinTimestamp: 6,
+					MaxTimestamp: 10,
+				}},
+			},
+			count: 1,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, count := tt.snapshot.getParts(tt.dst, tt.opts.minTimestamp, tt.opts.maxTimestamp)
+			result, count := tt.snapshot.getParts(tt.dst, tt.opts.minTimestamp, tt.opts.maxTimestamp, tt.afterPartID)
 			assert.Equal(t, tt.expected, result)
 			assert.Equal(t, tt.count, count)
 		})
@@ -0,0 +1,249 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package measure
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+const (
+	// counterCacheSize is the max number of series whose running totals are kept per measure.
+	counterCacheSize = 100_000
+	// counterStateTTL is how long the running totals of an idle series are kept.
+	counterStateTTL = time.Hour
+)
+
+// counterNormalizer converts the numeric fields of data points to the temporality of a measure.
+// It keeps the running totals of the recent series in memory, which are bounded by counterCacheSize and counterStateTTL.
+//
+// The data points which can't be converted exactly are stored as their own types,
+// which the rate and increase functions count correctly:
+//   - The first data point of a series since the node starts or the series is evicted is the baseline.
+//     A cumulative one is kept as it is, and a delta one starts a new running total like a counter reset.
+//   - A data point older than the latest one of its series becomes a zero delta,
+//     since its increase is counted by the later data points.
+//   - A data point at the same time as the latest one of its series replaces it.
+type counterNormalizer struct {
+	states      *simplelru.LRU
+	now         func() time.Time
+	temporality databasev1.Temporality
+	mu          sync.Mutex
+}
+
+// counterState is the running totals of a series.
+type counterState struct {
+	updated time.Time
+	// last is the running totals at ts.
+	last []counterValue
+	// previous is the running totals before ts. It's nil if the data point at ts is the baseline.
+	previous []counterValue
+	ts       int64
+}
+
+// counterValue is the value of a numeric field. The zero value means the field has no numeric value.
+type counterValue struct {
+	f  float64
+	i  int64
+	ok bool
+}
+
+func newCounterNormalizer(temporality databasev1.Temporality) *counterNormalizer {
+	// NewLRU fails only if the size isn't positive.
+	states, _ := simplelru.NewLRU(counterCacheSize, nil)
+	return &counterNormalizer{
+		temporality: temporality,
+		states:      states,
+		now:         time.Now,
+	}
+}
+
+// normalize returns the data point at ts in the temporality of the measure. The original data point is left untouched.
+func (cn *counterNormalizer) normalize(sid common.SeriesID, ts int64, specs []*databasev1.FieldSpec,
+	dp *measurev1.DataPointValue,
+) *measurev1.DataPointValue {
+	if cn == nil || cn.temporality == databasev1.Temporality_TEMPORALITY_UNSPECIFIED {
+		return dp
+	}
+	target := measurev1.DataPointValue_Type(cn.temporality)
+	if dp.Type == target {
+		return dp
+	}
+	if dp.Type == measurev1.DataPointValue_TYPE_UNSPECIFIED {
+		return withFields(dp, target, dp.Fields)
+	}
+	values := counterValuesOf(specs, dp.Fields)
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	now := cn.now()
+	st := cn.load(sid, now)
+	if st == nil {
+		cn.states.Add(sid, &counterState{updated: now, last: values, ts: ts})
+		if target == measurev1.DataPointValue_TYPE_DELTA {
+			return dp
+		}
+		return withFields(dp, target, fieldsOf(specs, dp.Fields, values))
+	}
+	st.updated = now
+	if target == measurev1.DataPointValue_TYPE_DELTA {
+		switch {
+		case ts > st.ts:
+			deltas := deltasOf(values, st.last)
+			st.previous, st.last, st.ts = st.last, values, ts
+			return withFields(dp, target, fieldsOf(specs, dp.Fields, deltas))
+		case ts == st.ts:
+			st.last = values
+			if st.previous == nil {
+				return dp
+			}
+			return withFields(dp, target, fieldsOf(specs, dp.Fields, deltasOf(values, st.previous)))
+		default:
+			return withFields(dp, target, fieldsOf(specs, dp.Fields, zerosOf(values)))
+		}
+	}
+	switch {
+	case ts > st.ts:
+		totals := sumOf(st.last, values)
+		st.previous, st.last, st.ts = st.last, totals, ts
+		return withFields(dp, target, fieldsOf(specs, dp.Fields, totals))
+	case ts == st.ts:
+		st.last = sumOf(st.previous, values)
+		return withFields(dp, target, fieldsOf(specs, dp.Fields, st.last))
+	default:
+		st.last = sumOf(st.last, values)
+		if st.previous != nil {
+			st.previous = sumOf(st.previous, values)
+		}
+		return withFields(dp, measurev1.DataPointValue_TYPE_DELTA, fieldsOf(specs, dp.Fields, zerosOf(values)))
+	}
+}
+
+// load returns the running totals of the series, or nil if they're missing or expired.
+func (cn *counterNormalizer) load(sid common.SeriesID, now time.Time) *counterState {
+	v, ok := cn.states.Get(sid)
+	if !ok {
+		return nil
+	}
+	st := v.(*counterState)
+	if now.Sub(st.updated) > counterStateTTL {
+		cn.states.Remove(sid)
+		return nil
+	}
+	return st
+}
+
+func withFields(dp *measurev1.DataPointValue, tp measurev1.DataPointValue_Type, fields []*modelv1.FieldValue) *measurev1.DataPointValue {
+	return &measurev1.DataPointValue{
+		Timestamp:   dp.Timestamp,
+		TagFamilies: dp.TagFamilies,
+		Fields:      fields,
+		Version:     dp.Version,
+		Type:        tp,
+	}
+}
+
+func counterValuesOf(specs []*databasev1.FieldSpec, fields []*modelv1.FieldValue) []counterValue {
+	values := make([]counterValue, len(fields))
+	for i, v := range fields {
+		if i >= len(specs) {
+			break
+		}
+		switch specs[i].FieldType {
+		case databasev1.FieldType_FIELD_TYPE_INT:
+			if v.GetInt() != nil {
+				values[i] = counterValue{i: v.GetInt().Value, ok: true}
+			}
+		case databasev1.FieldType_FIELD_TYPE_FLOAT:
+			if v.GetFloat() != nil {
+				values[i] = counterValue{f: v.GetFloat().Value, ok: true}
+			}
+		}
+	}
+	return values
+}
+
+// fieldsOf replaces the numeric fields with the values.
+func fieldsOf(specs []*databasev1.FieldSpec, fields []*modelv1.FieldValue, values []counterValue) []*modelv1.FieldValue {
+	result := make([]*modelv1.FieldValue, len(fields))
+	for i, v := range fields {
+		result[i] = v
+		if i >= len(values) || !values[i].ok {
+			continue
+		}
+		if specs[i].FieldType == databasev1.FieldType_FIELD_TYPE_INT {
+			result[i] = int64FieldValue(values[i].i)
+		} else {
+			result[i] = float64FieldValue(values[i].f)
+		}
+	}
+	return result
+}
+
+func sumOf(a, b []counterValue) []counterValue {
+	result := make([]counterValue, max(len(a), len(b)))
+	for i := range result {
+		x, y := valueAt(a, i), valueAt(b, i)
+		result[i] = counterValue{i: x.i + y.i, f: x.f + y.f, ok: x.ok || y.ok}
+	}
+	return result
+}
+
+func deltasOf(cur, prev []counterValue) []counterValue {
+	result := make([]counterValue, len(cur))
+	for i, c := range cur {
+		if !c.ok {
+			continue
+		}
+		p := valueAt(prev, i)
+		result[i] = counterValue{i: deltaOf(c.i, p.i, p.ok), f: deltaOf(c.f, p.f, p.ok), ok: true}
+	}
+	return result
+}
+
+func zerosOf(values []counterValue) []counterValue {
+	result := make([]counterValue, len(values))
+	for i, v := range values {
+		result[i].ok = v.ok
+	}
+	return result
+}
+
+func valueAt(values []counterValue, i int) counterValue {
+	if i < len(values) {
+		return values[i]
+	}
+	return counterValue{}
+}
+
+// deltaOf returns the change between two cumulative values. A decreased value means the counter was reset,
+// so the current value is the change since the reset. The first value has no change.
+func deltaOf[N int64 | float64](cur, prev N, hasPrev bool) N {
+	if !hasPrev {
+		return 0
+	}
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
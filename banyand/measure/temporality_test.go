@@ -0,0 +1,182 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package measure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
+)
+
+var temporalityFieldSpecs = []*databasev1.FieldSpec{
+	{Name: "count", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+	{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+	{Name: "label", FieldType: databasev1.FieldType_FIELD_TYPE_STRING},
+}
+
+func temporalityDataPoint(tp measurev1.DataPointValue_Type, count int64, total float64) *measurev1.DataPointValue {
+	return &measurev1.DataPointValue{
+		Type: tp,
+		Fields: []*modelv1.FieldValue{
+			int64FieldValue(count),
+			float64FieldValue(total),
+			{Value: &modelv1.FieldValue_Str{Str: &modelv1.Str{Value: "a"}}},
+		},
+	}
+}
+
+func TestCounterNormalizer_toDelta(t *testing.T) {
+	cn := newCounterNormalizer(databasev1.Temporality_TEMPORALITY_DELTA)
+	sid := common.SeriesID(1)
+	// The first cumulative value is kept as the baseline.
+	dp := cn.normalize(sid, 1, temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_CUMULATIVE, 10, 1))
+	assert.Equal(t, measurev1.DataPointValue_TYPE_CUMULATIVE, dp.Type)
+	assert.Equal(t, int64(10), dp.Fields[0].GetInt().GetValue())
+	want := [][2]float64{{5, 1.5}, {3, 0.5}, {2, 1}}
+	for i, in := range [][2]float64{{15, 2.5}, {3, 0.5}, {5, 1.5}} {
+		dp = cn.normalize(sid, int64(i+2), temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_CUMULATIVE, int64(in[0]), in[1]))
+		assert.Equal(t, measurev1.DataPointValue_TYPE_DELTA, dp.Type)
+		assert.Equal(t, int64(want[i][0]), dp.Fields[0].GetInt().GetValue(), "point %d", i)
+		assert.Equal(t, want[i][1], dp.Fields[1].GetFloat().GetValue(), "point %d", i)
+		assert.Equal(t, "a", dp.Fields[2].GetStr().GetValue())
+	}
+}
+
+func TestCounterNormalizer_toDeltaOutOfOrder(t *testing.T) {
+	cn := newCounterNormalizer(databasev1.Temporality_TEMPORALITY_DELTA)
+	normalize := func(ts, count int64) *measurev1.DataPointValue {
+		return cn.normalize(1, ts, temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_CUMULATIVE, count, 0))
+	}
+	normalize(1, 10)
+	assert.Equal(t, int64(5), normalize(3, 15).Fields[0].GetInt().GetValue())
+	// The increase of a late value is counted by the later one.
+	late := normalize(2, 12)
+	assert.Equal(t, measurev1.DataPointValue_TYPE_DELTA, late.Type)
+	assert.Equal(t, int64(0), late.Fields[0].GetInt().GetValue())
+	// A value at the same time replaces the latest one.
+	assert.Equal(t, int64(6), normalize(3, 16).Fields[0].GetInt().GetValue())
+	assert.Equal(t, int64(4), normalize(4, 20).Fields[0].GetInt().GetValue())
+}
+
+func TestCounterNormalizer_toCumulative(t *testing.T) {
+	cn := newCounterNormalizer(databasev1.Temporality_TEMPORALITY_CUMULATIVE)
+	want := []int64{10, 15, 18}
+	for i, in := range []int64{10, 5, 3} {
+		dp := cn.normalize(1, int64(i), temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_DELTA, in, 0))
+		assert.Equal(t, measurev1.DataPointValue_TYPE_CUMULATIVE, dp.Type)
+		assert.Equal(t, want[i], dp.Fields[0].GetInt().GetValue())
+	}
+	dp := cn.normalize(2, 0, temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_DELTA, 7, 0))
+	assert.Equal(t, int64(7), dp.Fields[0].GetInt().GetValue())
+}
+
+func TestCounterNormalizer_toCumulativeOutOfOrder(t *testing.T) {
+	cn := newCounterNormalizer(databasev1.Temporality_TEMPORALITY_CUMULATIVE)
+	normalize := func(ts, count int64) *measurev1.DataPointValue {
+		return cn.normalize(1, ts, temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_DELTA, count, 0))
+	}
+	normalize(1, 10)
+	assert.Equal(t, int64(15), normalize(3, 5).Fields[0].GetInt().GetValue())
+	// A late delta is added to the running total, which is counted by the later data points.
+	late := normalize(2, 2)
+	assert.Equal(t, measurev1.DataPointValue_TYPE_DELTA, late.Type)
+	assert.Equal(t, int64(0), late.Fields[0].GetInt().GetValue())
+	assert.Equal(t, int64(18), normalize(4, 1).Fields[0].GetInt().GetValue())
+	// A delta at the same time replaces the latest one.
+	assert.Equal(t, int64(20), normalize(4, 3).Fields[0].GetInt().GetValue())
+}
+
+func TestCounterNormalizer_expire(t *testing.T) {
+	cn := newCounterNormalizer(databasev1.Temporality_TEMPORALITY_DELTA)
+	now := time.Now()
+	cn.now = func() time.Time { return now }
+	cn.normalize(1, 1, temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_CUMULATIVE, 10, 0))
+	now = now.Add(counterStateTTL + time.Second)
+	dp := cn.normalize(1, 2, temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_CUMULATIVE, 15, 0))
+	assert.Equal(t, measurev1.DataPointValue_TYPE_CUMULATIVE, dp.Type)
+	assert.Equal(t, int64(15), dp.Fields[0].GetInt().GetValue())
+}
+
+// TestCounterNormalizer_increase checks that the increase of the stored data points is exact
+// although the node restarts and the data points arrive out of order.
+func TestCounterNormalizer_increase(t *testing.T) {
+	for _, temporality := range []databasev1.Temporality{databasev1.Temporality_TEMPORALITY_DELTA, databasev1.Temporality_TEMPORALITY_CUMULATIVE} {
+		t.Run(temporality.String(), func(t *testing.T) {
+			input := measurev1.DataPointValue_TYPE_CUMULATIVE
+			if temporality == databasev1.Temporality_TEMPORALITY_CUMULATIVE {
+				input = measurev1.DataPointValue_TYPE_DELTA
+			}
+			// The cumulative values at ts 1 to 9, whose deltas are sent if the measure is cumulative.
+			totals := []int64{10, 13, 20, 21, 30, 32, 40, 45, 50}
+			stored := make(map[int64]*measurev1.DataPointValue)
+			cn := newCounterNormalizer(temporality)
+			for i, ts := range []int64{1, 2, 4, 3, 5, 6, 8, 7, 9} {
+				if i == 5 {
+					// restart
+					cn = newCounterNormalizer(temporality)
+				}
+				v := totals[ts-1]
+				if input == measurev1.DataPointValue_TYPE_DELTA && ts > 1 {
+					v -= totals[ts-2]
+				}
+				stored[ts] = cn.normalize(1, ts, temporalityFieldSpecs, temporalityDataPoint(input, v, 0))
+			}
+			counter := aggregation.NewCounter[int64]()
+			for ts, dp := range stored {
+				counter.In(1, ts, dp.Type == measurev1.DataPointValue_TYPE_DELTA, dp.Fields[0].GetInt().GetValue())
+			}
+			// The first data point is the baseline.
+			assert.Equal(t, totals[len(totals)-1]-totals[0], counter.Increase())
+		})
+	}
+}
+
+func TestCounterNormalizer_passThrough(t *testing.T) {
+	cumulative := temporalityDataPoint(measurev1.DataPointValue_TYPE_CUMULATIVE, 10, 1)
+	var unspecified *counterNormalizer
+	assert.Same(t, cumulative, unspecified.normalize(1, 1, temporalityFieldSpecs, cumulative))
+
+	cn := newCounterNormalizer(databasev1.Temporality_TEMPORALITY_CUMULATIVE)
+	assert.Same(t, cumulative, cn.normalize(1, 1, temporalityFieldSpecs, cumulative))
+
+	dp := cn.normalize(1, 1, temporalityFieldSpecs, temporalityDataPoint(measurev1.DataPointValue_TYPE_UNSPECIFIED, 3, 1))
+	assert.Equal(t, measurev1.DataPointValue_TYPE_CUMULATIVE, dp.Type)
+	assert.Equal(t, int64(3), dp.Fields[0].GetInt().GetValue())
+}
+
+func TestLoadCounterNormalizer(t *testing.T) {
+	sr := &schemaRepo{}
+	md := &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm"}
+	cn := sr.loadCounterNormalizer(md, databasev1.Temporality_TEMPORALITY_DELTA)
+	require.NotNil(t, cn)
+	// The running totals are kept while the schema is updated.
+	assert.Same(t, cn, sr.loadCounterNormalizer(md, databasev1.Temporality_TEMPORALITY_DELTA))
+	changed := sr.loadCounterNormalizer(md, databasev1.Temporality_TEMPORALITY_CUMULATIVE)
+	assert.NotSame(t, cn, changed)
+	assert.Equal(t, databasev1.Temporality_TEMPORALITY_CUMULATIVE, changed.temporality)
+	assert.Nil(t, sr.loadCounterNormalizer(md, databasev1.Temporality_TEMPORALITY_UNSPECIFIED))
+	assert.NotSame(t, changed, sr.loadCounterNormalizer(md, databasev1.Temporality_TEMPORALITY_CUMULATIVE))
+}
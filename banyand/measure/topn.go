@@ -583,6 +583,12 @@ func Stringify(tagValue *modelv1.TagValue) string {
 		return strconv.FormatInt(v.Int.GetValue(), 10)
 	case *modelv1.TagValue_BinaryData:
 		return base64.StdEncoding.EncodeToString(v.BinaryData)
+	case *modelv1.TagValue_Float:
+		return strconv.FormatFloat(v.Float.GetValue(), 'f', -1, 64)
+	case *modelv1.TagValue_Bool:
+		return strconv.FormatBool(v.Bool.GetValue())
+	case *modelv1.TagValue_Timestamp:
+		return v.Timestamp.AsTime().Format(time.RFC3339Nano)
 	case *modelv1.TagValue_IntArray:
 		return strings.Join(transform(v.IntArray.GetValue(), func(num int64) string {
 			return strconv.FormatInt(num, 10)
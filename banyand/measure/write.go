@@ -33,6 +33,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/partition"
@@ -99,9 +100,10 @@ func (w *writeCallback) handle(dst map[string]*dataPointsInGroup, writeEvent *me
 		dpg.latestTS = ts
 	}
 
+	shardID := common.ShardID(writeEvent.ShardId)
 	var dpt *dataPointsInTable
 	for i := range dpg.tables {
-		if dpg.tables[i].timeRange.Contains(ts) {
+		if dpg.tables[i].shardID == shardID && dpg.tables[i].timeRange.Contains(ts) {
 			dpt = dpg.tables[i]
 			break
 		}
@@ -123,7 +125,6 @@ func (w *writeCallback) handle(dst map[string]*dataPointsInGroup, writeEvent *me
 			len(is.indexRuleLocators.TagFamilyTRule), len(stm.GetSchema().GetTagFamilies()))
 	}
 
-	shardID := common.ShardID(writeEvent.ShardId)
 	if dpt == nil {
 		if dpt, err = w.newDpt(tsdb, dpg, t, ts, shardID, stm.schema.IndexMode); err != nil {
 			return nil, fmt.Errorf("cannot create data points in table: %w", err)
@@ -158,7 +159,14 @@ func (w *writeCallback) handle(dst map[string]*dataPointsInGroup, writeEvent *me
 		return dst, nil
 	}
 
-	fields := appendDataPoints(dpt, ts, series.ID, stm.GetSchema(), req, is.indexRuleLocators)
+	if err := validateHistograms(stm.GetSchema().GetFields(), req.DataPoint.GetFields()); err != nil {
+		return nil, err
+	}
+	fields := appendDataPoints(dpt, ts, series.ID, stm.GetSchema(), &measurev1.WriteRequest{
+		Metadata:  req.Metadata,
+		DataPoint: stm.counters.normalize(series.ID, ts, stm.GetSchema().GetFields(), req.DataPoint),
+		MessageId: req.MessageId,
+	}, is.indexRuleLocators)
 
 	doc := index.Document{
 		DocID:        uint64(series.ID),
@@ -203,16 +211,23 @@ func appendDataPoints(dest *dataPointsInTable, ts int64, sid common.SeriesID, sc
 	field := nameValues{}
 	for i := range schema.GetFields() {
 		var v *modelv1.FieldValue
-		if len(req.DataPoint.Fields) <= i {
+		// The values of the deprecated fields are dropped.
+		if len(req.DataPoint.Fields) <= i || schema.GetFields()[i].GetDeprecated() {
 			v = pbv1.NullFieldValue
 		} else {
 			v = req.DataPoint.Fields[i]
 		}
-		field.values = append(field.values, encodeFieldValue(
+		nv := encodeFieldValue(
 			schema.GetFields()[i].GetName(),
 			schema.GetFields()[i].FieldType,
 			v,
-		))
+		)
+		nv.codec = fieldCodec(schema.GetFields()[i])
+		field.values = append(field.values, nv)
+	}
+	// The type of data points is kept only if the measure normalizes them to a temporality.
+	if schema.GetTemporality() != databasev1.Temporality_TEMPORALITY_UNSPECIFIED {
+		field.values = append(field.values, encodeDataPointType(req.DataPoint.Type))
 	}
 	dataPoints.fields = append(dataPoints.fields, field)
 
@@ -240,6 +255,7 @@ func (w *writeCallback) newDpt(tsdb storage.TSDB[*tsTable, option], dpg *dataPoi
 	if indexMode {
 		return &dataPointsInTable{
 			timeRange: segment.GetTimeRange(),
+			shardID:   shardID,
 		}, nil
 	}
 
@@ -250,6 +266,7 @@ func (w *writeCallback) newDpt(tsdb storage.TSDB[*tsTable, option], dpg *dataPoi
 	dpt := &dataPointsInTable{
 		timeRange: segment.GetTimeRange(),
 		tsTable:   tstb,
+		shardID:   shardID,
 	}
 	dpg.tables = append(dpg.tables, dpt)
 	return dpt, nil
@@ -272,14 +289,15 @@ func handleTagFamily(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 			name: tagFamilySpec.Name,
 		}
 		for j := range tagFamilySpec.Tags {
+			t := tagFamilySpec.Tags[j]
 			var tagValue *modelv1.TagValue
-			if tagFamily == pbv1.NullTagFamily || len(tagFamily.Tags) <= j {
+			// The values of the deprecated tags are dropped.
+			if tagFamily == pbv1.NullTagFamily || len(tagFamily.Tags) <= j || t.Deprecated {
 				tagValue = pbv1.NullTagValue
 			} else {
 				tagValue = tagFamily.Tags[j]
 			}
 
-			t := tagFamilySpec.Tags[j]
 			encodeTagValue := encodeTagValue(
 				t.Name,
 				t.Type,
@@ -333,14 +351,15 @@ func handleIndexMode(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 		tfr := locator.TagFamilyTRule[i]
 		tagFamilySpec := schema.GetTagFamilies()[i]
 		for j := range tagFamilySpec.Tags {
+			t := tagFamilySpec.Tags[j]
 			var tagValue *modelv1.TagValue
-			if tagFamily == pbv1.NullTagFamily || len(tagFamily.Tags) <= j {
+			// The values of the deprecated tags are dropped.
+			if tagFamily == pbv1.NullTagFamily || len(tagFamily.Tags) <= j || t.Deprecated {
 				tagValue = pbv1.NullTagValue
 			} else {
 				tagValue = tagFamily.Tags[j]
 			}
 
-			t := tagFamilySpec.Tags[j]
 			encodeTagValue := encodeTagValue(
 				t.Name,
 				t.Type,
@@ -494,12 +513,69 @@ func encodeFieldValue(name string, fieldType databasev1.FieldType, fieldValue *m
 		if fieldValue.GetBinaryData() != nil {
 			nv.value = bytes.Clone(fieldValue.GetBinaryData())
 		}
+	case databasev1.FieldType_FIELD_TYPE_HISTOGRAM:
+		nv.valueType = pbv1.ValueTypeHistogram
+		if fieldValue.GetHistogram() != nil {
+			nv.value = pbv1.MarshalHistogram(nil, fieldValue.GetHistogram())
+		}
 	default:
 		logger.Panicf("unsupported field value type: %T", fieldValue.GetValue())
 	}
 	return nv
 }
 
+// fieldCodec returns the codec of a field column. The unspecified methods keep the default ones,
+// except that the histograms are encoded by encoding.ColumnEncodingHistogram.
+func fieldCodec(spec *databasev1.FieldSpec) encoding.Codec {
+	var c encoding.Codec
+	if spec.GetFieldType() == databasev1.FieldType_FIELD_TYPE_HISTOGRAM {
+		c.Encoding = encoding.ColumnEncodingHistogram
+	}
+	switch spec.GetEncodingMethod() {
+	case databasev1.EncodingMethod_ENCODING_METHOD_GORILLA:
+		c.Encoding = encoding.ColumnEncodingGorilla
+	case databasev1.EncodingMethod_ENCODING_METHOD_DELTA_OF_DELTA:
+		c.Encoding = encoding.ColumnEncodingDeltaOfDelta
+	case databasev1.EncodingMethod_ENCODING_METHOD_PLAIN:
+		c.Encoding = encoding.ColumnEncodingPlain
+	}
+	switch spec.GetCompressionMethod() {
+	case databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD:
+		c.Compression = encoding.ColumnCompressionZSTD
+		c.Level = int8(spec.GetCompressionLevel())
+	case databasev1.CompressionMethod_COMPRESSION_METHOD_NONE:
+		c.Compression = encoding.ColumnCompressionNone
+	case databasev1.CompressionMethod_COMPRESSION_METHOD_LZ4:
+		c.Compression = encoding.ColumnCompressionLZ4
+	case databasev1.CompressionMethod_COMPRESSION_METHOD_SNAPPY:
+		c.Compression = encoding.ColumnCompressionSnappy
+	}
+	return c
+}
+
+func validateHistograms(specs []*databasev1.FieldSpec, values []*modelv1.FieldValue) error {
+	for i, spec := range specs {
+		if i >= len(values) {
+			break
+		}
+		if h := values[i].GetHistogram(); h != nil && spec.GetFieldType() == databasev1.FieldType_FIELD_TYPE_HISTOGRAM {
+			if err := pbv1.ValidateHistogram(h); err != nil {
+				return fmt.Errorf("invalid field %s: %w", spec.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// encodeDataPointType keeps the type of a data point in an internal field. The unspecified type is stored as null.
+func encodeDataPointType(t measurev1.DataPointValue_Type) *nameValue {
+	nv := &nameValue{name: DataPointTypeFieldName, valueType: pbv1.ValueTypeInt64}
+	if t != measurev1.DataPointValue_TYPE_UNSPECIFIED {
+		nv.value = convert.Int64ToBytes(int64(t))
+	}
+	return nv
+}
+
 func encodeTagValue(name string, tagType databasev1.TagType, tagValue *modelv1.TagValue) *nameValue {
 	nv := generateNameValue()
 	nv.name = name
@@ -537,6 +613,21 @@ func encodeTagValue(name string, tagType databasev1.TagType, tagValue *modelv1.T
 		for i := range tagValue.GetStrArray().Value {
 			nv.valueArr[i] = []byte(tagValue.GetStrArray().Value[i])
 		}
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		nv.valueType = pbv1.ValueTypeFloat64
+		if tagValue.GetFloat() != nil {
+			nv.value = convert.Float64ToSortableBytes(tagValue.GetFloat().GetValue())
+		}
+	case databasev1.TagType_TAG_TYPE_BOOL:
+		nv.valueType = pbv1.ValueTypeBool
+		if tagValue.GetBool() != nil {
+			nv.value = pbv1.BoolToBytes(tagValue.GetBool().GetValue())
+		}
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		nv.valueType = pbv1.ValueTypeTimestamp
+		if tagValue.GetTimestamp() != nil {
+			nv.value = convert.Int64ToBytes(tagValue.GetTimestamp().AsTime().UnixNano())
+		}
 	default:
 		logger.Panicf("unsupported tag value type: %T", tagValue.GetValue())
 	}
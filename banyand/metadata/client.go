@@ -19,8 +19,11 @@ package metadata
 
 import (
 	"context"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -42,6 +45,25 @@ const (
 	DefaultNamespace = "banyandb"
 	// FlagEtcdEndpointsName is the default flag name for etcd endpoints.
 	FlagEtcdEndpointsName = "etcd-endpoints"
+	// FlagSchemaRegistryModeName is the flag name for the backend of the schema registry.
+	FlagSchemaRegistryModeName = "schema-registry-mode"
+	// FlagSchemaRegistryRootPathName is the flag name for the root path of the file or raft schema registry.
+	FlagSchemaRegistryRootPathName = "schema-registry-root-path"
+	// FlagSchemaRegistryRaftIDName is the flag name for the id of the local member of the raft schema registry.
+	FlagSchemaRegistryRaftIDName = "schema-registry-raft-id"
+	// FlagSchemaRegistryRaftPeersName is the flag name for the members of the raft schema registry.
+	FlagSchemaRegistryRaftPeersName = "schema-registry-raft-peers"
+	// FlagSchemaHistoryLimitName is the flag name for the number of the previous revisions kept for a schema.
+	FlagSchemaHistoryLimitName = "schema-history-limit"
+)
+
+const (
+	// RegistryModeEtcd keeps the schema in etcd.
+	RegistryModeEtcd = "etcd"
+	// RegistryModeFile keeps the schema in a local file, which only serves a standalone server.
+	RegistryModeFile = "file"
+	// RegistryModeRaft replicates the schema across a Raft group embedded in the liaison and data nodes.
+	RegistryModeRaft = "raft"
 )
 
 const flagEtcdUsername = "etcd-username"
@@ -73,9 +95,15 @@ type clientService struct {
 	etcdUsername         string
 	etcdTLSKeyFile       string
 	namespace            string
+	registryMode         string
+	registryRootPath     string
+	raftPeerList         []string
 	endpoints            []string
+	raftPeers            map[uint64]string
 	registryTimeout      time.Duration
 	etcdFullSyncInterval time.Duration
+	historyLimit         int
+	raftID               uint64
 	nodeInfoMux          sync.Mutex
 	forceRegisterNode    bool
 	toRegisterNode       bool
@@ -88,6 +116,14 @@ func (s *clientService) SchemaRegistry() schema.Registry {
 func (s *clientService) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("metadata")
 	fs.StringVar(&s.namespace, "namespace", DefaultNamespace, "The namespace of the metadata stored in etcd")
+	fs.StringVar(&s.registryMode, FlagSchemaRegistryModeName, RegistryModeEtcd,
+		"The backend of the schema registry: 'etcd', 'file' or 'raft'. The 'file' backend only serves a standalone server")
+	fs.StringVar(&s.registryRootPath, FlagSchemaRegistryRootPathName, "", "The root path of the 'file' or 'raft' schema registry")
+	fs.Uint64Var(&s.raftID, FlagSchemaRegistryRaftIDName, 0, "The id of the local member of the 'raft' schema registry")
+	fs.StringSliceVar(&s.raftPeerList, FlagSchemaRegistryRaftPeersName, nil,
+		"A comma-delimited list of the members of the 'raft' schema registry, each one is <id>=<peer url>")
+	fs.IntVar(&s.historyLimit, FlagSchemaHistoryLimitName, schema.DefaultHistoryLimit,
+		"The number of the previous revisions kept for a schema, 0 disables the history")
 	fs.StringSliceVar(&s.endpoints, FlagEtcdEndpointsName, []string{"http://localhost:2379"}, "A comma-delimited list of etcd endpoints")
 	fs.StringVar(&s.etcdUsername, flagEtcdUsername, "", "A username of etcd")
 	fs.StringVar(&s.etcdPassword, flagEtcdPassword, "", "A password of etcd user")
@@ -100,12 +136,55 @@ func (s *clientService) FlagSet() *run.FlagSet {
 }
 
 func (s *clientService) Validate() error {
-	if s.endpoints == nil {
-		return errors.New("endpoints is empty")
+	if s.historyLimit < 0 {
+		return errors.New("the schema history limit should not be negative")
+	}
+	switch s.registryMode {
+	case RegistryModeEtcd:
+		if s.endpoints == nil {
+			return errors.New("endpoints is empty")
+		}
+	case RegistryModeFile:
+		if s.registryRootPath == "" {
+			return errors.New("the root path of the file schema registry is empty")
+		}
+	case RegistryModeRaft:
+		if s.registryRootPath == "" {
+			return errors.New("the root path of the raft schema registry is empty")
+		}
+		peers, err := parseRaftPeers(s.raftPeerList)
+		if err != nil {
+			return err
+		}
+		if _, ok := peers[s.raftID]; !ok {
+			return errors.Errorf("the raft member %d is not one of the peers", s.raftID)
+		}
+		s.raftPeers = peers
+	default:
+		return errors.Errorf("unknown schema registry mode %q", s.registryMode)
 	}
 	return nil
 }
 
+func parseRaftPeers(list []string) (map[uint64]string, error) {
+	peers := make(map[uint64]string, len(list))
+	for _, p := range list {
+		id, peerURL, ok := strings.Cut(p, "=")
+		if !ok || peerURL == "" {
+			return nil, errors.Errorf("invalid raft peer %q, it should be <id>=<peer url>", p)
+		}
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil || n == 0 {
+			return nil, errors.Errorf("invalid id of the raft peer %q", p)
+		}
+		if _, err = url.Parse(peerURL); err != nil {
+			return nil, errors.Wrapf(err, "invalid url of the raft peer %q", p)
+		}
+		peers[n] = peerURL
+	}
+	return peers, nil
+}
+
 func (s *clientService) PreRun(ctx context.Context) error {
 	stopCh := make(chan struct{})
 	sn := make(chan os.Signal, 1)
@@ -124,6 +203,31 @@ func (s *clientService) PreRun(ctx context.Context) error {
 
 	for {
 		var err error
+		if s.registryMode == RegistryModeFile {
+			s.schemaRegistry, err = schema.NewFileSchemaRegistry(
+				schema.Namespace(s.namespace),
+				schema.ConfigureFileRoot(s.registryRootPath),
+				schema.ConfigureWatchCheckInterval(s.etcdFullSyncInterval),
+				schema.ConfigureHistoryLimit(s.historyLimit),
+			)
+			if err != nil {
+				return err
+			}
+			break
+		}
+		if s.registryMode == RegistryModeRaft {
+			s.schemaRegistry, err = schema.NewRaftSchemaRegistry(
+				schema.Namespace(s.namespace),
+				schema.ConfigureFileRoot(s.registryRootPath),
+				schema.ConfigureRaftPeers(s.raftID, s.raftPeers),
+				schema.ConfigureWatchCheckInterval(s.etcdFullSyncInterval),
+				schema.ConfigureHistoryLimit(s.historyLimit),
+			)
+			if err != nil {
+				return err
+			}
+			break
+		}
 		s.schemaRegistry, err = schema.NewEtcdSchemaRegistry(
 			schema.Namespace(s.namespace),
 			schema.ConfigureServerEndpoints(s.endpoints),
@@ -131,6 +235,7 @@ func (s *clientService) PreRun(ctx context.Context) error {
 			schema.ConfigureEtcdTLSCAFile(s.etcdTLSCAFile),
 			schema.ConfigureEtcdTLSCertAndKey(s.etcdTLSCertFile, s.etcdTLSKeyFile),
 			schema.ConfigureWatchCheckInterval(s.etcdFullSyncInterval),
+			schema.ConfigureHistoryLimit(s.historyLimit),
 		)
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 			select {
@@ -259,6 +364,14 @@ func (s *clientService) PropertyRegistry() schema.Property {
 	return s.schemaRegistry
 }
 
+func (s *clientService) HistoryRegistry() schema.History {
+	return s.schemaRegistry
+}
+
+func (s *clientService) ShardRegistry() schema.Shard {
+	return s.schemaRegistry
+}
+
 func (s *clientService) Name() string {
 	return "metadata"
 }
@@ -21,6 +21,9 @@ package embeddedserver
 import (
 	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +33,7 @@ import (
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/embeddedetcd"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
@@ -41,6 +45,8 @@ type server struct {
 	scheduler               *timestamp.Scheduler
 	ecli                    *clientv3.Client
 	rootDir                 string
+	registryMode            string
+	historyLimit            int
 	defragCron              string
 	autoCompactionMode      string
 	autoCompactionRetention string
@@ -60,6 +66,10 @@ func (s *server) Role() databasev1.Role {
 func (s *server) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("metadata")
 	fs.StringVar(&s.rootDir, "metadata-root-path", "/tmp", "the root path of metadata")
+	fs.StringVar(&s.registryMode, metadata.FlagSchemaRegistryModeName, metadata.RegistryModeEtcd,
+		"The backend of the schema registry: 'etcd' runs an embedded etcd server, 'file' keeps the schema in a local file")
+	fs.IntVar(&s.historyLimit, metadata.FlagSchemaHistoryLimitName, schema.DefaultHistoryLimit,
+		"The number of the previous revisions kept for a schema, 0 disables the history")
 	fs.StringVar(&s.autoCompactionMode, "etcd-auto-compaction-mode", "periodic", "auto compaction mode: 'periodic' or 'revision'")
 	fs.StringVar(&s.autoCompactionRetention, "etcd-auto-compaction-retention", "1h", "auto compaction retention: e.g. '1h', '30m', '24h' for periodic; '1000' for revision")
 	fs.StringVar(&s.defragCron, "etcd-defrag-cron", "@daily", "defragmentation cron: e.g. '@daily', '@hourly', '0 0 * * 0', '0 */6 * * *'")
@@ -88,7 +98,23 @@ func (s *server) Validate() error {
 	if s.autoCompactionRetention == "" {
 		return errors.New("autoCompactionRetention is empty")
 	}
-	if err := s.Service.FlagSet().Set(metadata.FlagEtcdEndpointsName,
+	if s.registryMode != metadata.RegistryModeEtcd && s.registryMode != metadata.RegistryModeFile {
+		return fmt.Errorf("the standalone server doesn't support the schema registry mode %q", s.registryMode)
+	}
+	fs := s.Service.FlagSet()
+	if err := fs.Set(metadata.FlagSchemaRegistryModeName, s.registryMode); err != nil {
+		return err
+	}
+	if err := fs.Set(metadata.FlagSchemaHistoryLimitName, strconv.Itoa(s.historyLimit)); err != nil {
+		return err
+	}
+	if s.registryMode == metadata.RegistryModeFile {
+		if err := fs.Set(metadata.FlagSchemaRegistryRootPathName, filepath.Join(s.rootDir, "schema")); err != nil {
+			return err
+		}
+		return s.Service.Validate()
+	}
+	if err := fs.Set(metadata.FlagEtcdEndpointsName,
 		strings.Join(s.listenClientURL, ",")); err != nil {
 		return err
 	}
@@ -96,6 +122,9 @@ func (s *server) Validate() error {
 }
 
 func (s *server) PreRun(ctx context.Context) error {
+	if s.registryMode == metadata.RegistryModeFile {
+		return s.Service.PreRun(ctx)
+	}
 	var err error
 	s.metaServer, err = embeddedetcd.NewServer(embeddedetcd.RootDir(s.rootDir), embeddedetcd.ConfigureListener(s.listenClientURL, s.listenPeerURL),
 		embeddedetcd.AutoCompactionMode(s.autoCompactionMode), embeddedetcd.AutoCompactionRetention(s.autoCompactionRetention),
@@ -108,7 +137,10 @@ func (s *server) PreRun(ctx context.Context) error {
 }
 
 func (s *server) Serve() run.StopNotify {
-	_ = s.Service.Serve()
+	stopNotify := s.Service.Serve()
+	if s.metaServer == nil {
+		return stopNotify
+	}
 	s.registerDefrag()
 	return s.metaServer.StoppingNotify()
 }
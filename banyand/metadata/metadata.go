@@ -50,6 +50,8 @@ type Repo interface {
 	RegisterHandler(string, schema.Kind, schema.EventHandler)
 	NodeRegistry() schema.Node
 	PropertyRegistry() schema.Property
+	HistoryRegistry() schema.History
+	ShardRegistry() schema.Shard
 }
 
 // Service is the metadata repository.
@@ -0,0 +1,240 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/api/validate"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// The schema of a stream or a measure evolves safely if the data written with the previous schema
+// is still readable with the new one. The safe changes are:
+//   - appending tag families, tags and fields;
+//   - deprecating or undeprecating tags and fields;
+//   - widening the type of a tag or a field, which is neither in the entity nor indexed.
+// The tag families, tags and fields keep their positions since the writes refer to them by positions.
+
+func canWidenTag(from, to databasev1.TagType) bool {
+	if from == databasev1.TagType_TAG_TYPE_UNSPECIFIED || to == databasev1.TagType_TAG_TYPE_UNSPECIFIED {
+		return false
+	}
+	return pbv1.CanWiden(pbv1.MustTagValueSpecToValueType(from), pbv1.MustTagValueSpecToValueType(to))
+}
+
+func canWidenField(from, to databasev1.FieldType) bool {
+	return pbv1.CanWiden(pbv1.FieldTypeToValueType(from), pbv1.FieldTypeToValueType(to))
+}
+
+func (e *schemaRegistry) CheckStreamCompatibility(ctx context.Context, stream *databasev1.Stream) ([]string, error) {
+	if err := validate.Stream(stream); err != nil {
+		return nil, err
+	}
+	prev, err := e.GetStream(ctx, stream.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	indexedTags, err := e.indexedTags(ctx, stream.GetMetadata(), commonv1.Catalog_CATALOG_STREAM)
+	if err != nil {
+		return nil, err
+	}
+	return streamIncompatibilities(prev, stream, indexedTags), nil
+}
+
+func (e *schemaRegistry) CheckMeasureCompatibility(ctx context.Context, measure *databasev1.Measure) ([]string, error) {
+	if err := validate.Measure(measure); err != nil {
+		return nil, err
+	}
+	prev, err := e.GetMeasure(ctx, measure.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	indexedTags, err := e.indexedTags(ctx, measure.GetMetadata(), commonv1.Catalog_CATALOG_MEASURE)
+	if err != nil {
+		return nil, err
+	}
+	return measureIncompatibilities(prev, measure, indexedTags), nil
+}
+
+// indexedTags returns the tags indexed by the rules bound to the subject.
+func (e *schemaRegistry) indexedTags(ctx context.Context, subject *commonv1.Metadata, catalog commonv1.Catalog) (map[string]struct{}, error) {
+	bindings, err := e.ListIndexRuleBinding(ctx, ListOpt{Group: subject.GetGroup()})
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]struct{})
+	for _, binding := range bindings {
+		if binding.GetSubject().GetName() != subject.GetName() || binding.GetSubject().GetCatalog() != catalog {
+			continue
+		}
+		for _, name := range binding.GetRules() {
+			rule, err := e.GetIndexRule(ctx, &commonv1.Metadata{Group: subject.GetGroup(), Name: name})
+			if errors.Is(err, ErrGRPCResourceNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, tag := range rule.GetTags() {
+				tags[tag] = struct{}{}
+			}
+		}
+	}
+	return tags, nil
+}
+
+func incompatibilityError(incompatibilities []string) error {
+	if len(incompatibilities) == 0 {
+		return nil
+	}
+	return errors.WithMessagef(ErrInputInvalid, "validation failed: %s", strings.Join(incompatibilities, "; "))
+}
+
+func streamIncompatibilities(prev, next *databasev1.Stream, indexedTags map[string]struct{}) []string {
+	var result []string
+	if prev.GetEntity().String() != next.GetEntity().String() {
+		result = append(result, fmt.Sprintf("entity is different: %s != %s", prev.GetEntity().String(), next.GetEntity().String()))
+	}
+	return append(result, tagFamiliesIncompatibilities(prev.GetTagFamilies(), next.GetTagFamilies(),
+		fixedTags(indexedTags, prev.GetEntity().GetTagNames()))...)
+}
+
+func measureIncompatibilities(prev, next *databasev1.Measure, indexedTags map[string]struct{}) []string {
+	var result []string
+	if prev.GetInterval() != next.GetInterval() {
+		result = append(result, fmt.Sprintf("interval is different: %s != %s", prev.GetInterval(), next.GetInterval()))
+	}
+	if prev.GetEntity().String() != next.GetEntity().String() {
+		result = append(result, fmt.Sprintf("entity is different: %s != %s", prev.GetEntity().String(), next.GetEntity().String()))
+	}
+	if prev.GetIndexMode() != next.GetIndexMode() {
+		result = append(result, fmt.Sprintf("index mode is different: %v != %v", prev.GetIndexMode(), next.GetIndexMode()))
+	}
+	fixed := fixedTags(indexedTags, prev.GetEntity().GetTagNames(), prev.GetShardingKey().GetTagNames())
+	if prev.GetIndexMode() {
+		// All tags of a measure in the index mode are stored in the index.
+		for _, tf := range prev.GetTagFamilies() {
+			for _, tag := range tf.GetTags() {
+				if _, ok := fixed[tag.GetName()]; !ok {
+					fixed[tag.GetName()] = false
+				}
+			}
+		}
+	}
+	result = append(result, tagFamiliesIncompatibilities(prev.GetTagFamilies(), next.GetTagFamilies(), fixed)...)
+	if len(prev.GetFields()) > len(next.GetFields()) {
+		for _, field := range prev.GetFields()[len(next.GetFields()):] {
+			result = append(result, fmt.Sprintf("field %s is removed", field.GetName()))
+		}
+	}
+	for i := 0; i < len(prev.GetFields()) && i < len(next.GetFields()); i++ {
+		result = append(result, fieldIncompatibilities(prev.GetFields()[i], next.GetFields()[i])...)
+	}
+	return result
+}
+
+// fixedTags returns the tags whose types can't be changed. The value is true if the tag can't be deprecated either.
+func fixedTags(indexedTags map[string]struct{}, keys ...[]string) map[string]bool {
+	fixed := make(map[string]bool, len(indexedTags))
+	for tag := range indexedTags {
+		fixed[tag] = false
+	}
+	for _, k := range keys {
+		for _, tag := range k {
+			fixed[tag] = true
+		}
+	}
+	return fixed
+}
+
+func tagFamiliesIncompatibilities(prev, next []*databasev1.TagFamilySpec, fixed map[string]bool) []string {
+	var result []string
+	if len(prev) > len(next) {
+		for _, tf := range prev[len(next):] {
+			result = append(result, fmt.Sprintf("tag family %s is removed", tf.GetName()))
+		}
+	}
+	for i := 0; i < len(prev) && i < len(next); i++ {
+		if prev[i].GetName() != next[i].GetName() {
+			result = append(result, fmt.Sprintf("tag family name is different: %s != %s", prev[i].GetName(), next[i].GetName()))
+			continue
+		}
+		prevTags, nextTags := prev[i].GetTags(), next[i].GetTags()
+		if len(prevTags) > len(nextTags) {
+			for _, tag := range prevTags[len(nextTags):] {
+				result = append(result, fmt.Sprintf("tag %s in tag family %s is removed", tag.GetName(), prev[i].GetName()))
+			}
+		}
+		for j := 0; j < len(prevTags) && j < len(nextTags); j++ {
+			result = append(result, tagIncompatibilities(prev[i].GetName(), prevTags[j], nextTags[j], fixed)...)
+		}
+	}
+	return result
+}
+
+func tagIncompatibilities(family string, prev, next *databasev1.TagSpec, fixed map[string]bool) []string {
+	if prev.GetName() != next.GetName() {
+		return []string{fmt.Sprintf("tag name in tag family %s is different: %s != %s", family, prev.GetName(), next.GetName())}
+	}
+	var result []string
+	name := prev.GetName()
+	if prev.GetIndexedOnly() != next.GetIndexedOnly() {
+		result = append(result, fmt.Sprintf("indexed_only of tag %s in tag family %s is different: %v != %v",
+			name, family, prev.GetIndexedOnly(), next.GetIndexedOnly()))
+	}
+	if prev.GetType() != next.GetType() {
+		_, isFixed := fixed[name]
+		switch {
+		case !canWidenTag(prev.GetType(), next.GetType()):
+			result = append(result, fmt.Sprintf("type of tag %s in tag family %s can't be changed from %s to %s",
+				name, family, prev.GetType(), next.GetType()))
+		case isFixed || prev.GetIndexedOnly():
+			result = append(result, fmt.Sprintf("type of tag %s in tag family %s can't be widened since it's in the entity or indexed",
+				name, family))
+		}
+	}
+	if next.GetDeprecated() && !prev.GetDeprecated() && fixed[name] {
+		result = append(result, fmt.Sprintf("tag %s in tag family %s can't be deprecated since it's in the entity", name, family))
+	}
+	return result
+}
+
+func fieldIncompatibilities(prev, next *databasev1.FieldSpec) []string {
+	if prev.GetName() != next.GetName() {
+		return []string{fmt.Sprintf("field name is different: %s != %s", prev.GetName(), next.GetName())}
+	}
+	if prev.GetFieldType() != next.GetFieldType() {
+		if !canWidenField(prev.GetFieldType(), next.GetFieldType()) {
+			return []string{fmt.Sprintf("type of field %s can't be changed from %s to %s", prev.GetName(), prev.GetFieldType(), next.GetFieldType())}
+		}
+		// The encoding and the compression follow the widened type.
+		return nil
+	}
+	if prev.GetEncodingMethod() != next.GetEncodingMethod() || prev.GetCompressionMethod() != next.GetCompressionMethod() ||
+		prev.GetCompressionLevel() != next.GetCompressionLevel() {
+		return []string{fmt.Sprintf("encoding or compression of field %s is different", prev.GetName())}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func newTestMeasure() *databasev1.Measure {
+	return &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Name: "evolution", Group: "evolution"},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{
+				Name: "default",
+				Tags: []*databasev1.TagSpec{
+					{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "code", Type: databasev1.TagType_TAG_TYPE_INT},
+					{Name: "layer", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "score", Type: databasev1.TagType_TAG_TYPE_INT},
+				},
+			},
+		},
+		Fields: []*databasev1.FieldSpec{
+			{
+				Name:              "total",
+				FieldType:         databasev1.FieldType_FIELD_TYPE_INT,
+				EncodingMethod:    databasev1.EncodingMethod_ENCODING_METHOD_GORILLA,
+				CompressionMethod: databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD,
+			},
+		},
+		Entity:   &databasev1.Entity{TagNames: []string{"id"}},
+		Interval: "1m",
+	}
+}
+
+func TestCheckMeasureCompatibility(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+	registry := openFileRegistry(t, root)
+	defer registry.Close()
+	ctx := context.Background()
+
+	req.NoError(registry.CreateGroup(ctx, newTestGroup("evolution")))
+	_, err := registry.CreateMeasure(ctx, newTestMeasure())
+	req.NoError(err)
+	req.NoError(registry.CreateIndexRule(ctx, &databasev1.IndexRule{
+		Metadata: &commonv1.Metadata{Name: "code", Group: "evolution"},
+		Tags:     []string{"code"},
+		Type:     databasev1.IndexRule_TYPE_INVERTED,
+	}))
+	req.NoError(registry.CreateIndexRuleBinding(ctx, &databasev1.IndexRuleBinding{
+		Metadata: &commonv1.Metadata{Name: "evolution", Group: "evolution"},
+		Rules:    []string{"code"},
+		Subject:  &databasev1.Subject{Name: "evolution", Catalog: commonv1.Catalog_CATALOG_MEASURE},
+	}))
+
+	tests := []struct {
+		update     func(m *databasev1.Measure)
+		name       string
+		compatible bool
+	}{
+		{
+			name: "append a tag, a field and a tag family",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags = append(m.TagFamilies[0].Tags, &databasev1.TagSpec{Name: "region", Type: databasev1.TagType_TAG_TYPE_STRING})
+				m.TagFamilies = append(m.TagFamilies, &databasev1.TagFamilySpec{
+					Name: "extra",
+					Tags: []*databasev1.TagSpec{{Name: "zone", Type: databasev1.TagType_TAG_TYPE_STRING}},
+				})
+				m.Fields = append(m.Fields, proto.Clone(m.Fields[0]).(*databasev1.FieldSpec))
+				m.Fields[1].Name = "value"
+			},
+			compatible: true,
+		},
+		{
+			name: "deprecate a tag and a field",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags[2].Deprecated = true
+				m.Fields[0].Deprecated = true
+			},
+			compatible: true,
+		},
+		{
+			name: "widen a tag and a field",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags[2].Type = databasev1.TagType_TAG_TYPE_STRING_ARRAY
+				m.TagFamilies[0].Tags[3].Type = databasev1.TagType_TAG_TYPE_FLOAT
+				m.Fields[0].FieldType = databasev1.FieldType_FIELD_TYPE_FLOAT
+			},
+			compatible: true,
+		},
+		{
+			name: "narrow a tag",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags[2].Type = databasev1.TagType_TAG_TYPE_INT
+			},
+		},
+		{
+			name: "widen an indexed tag",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags[1].Type = databasev1.TagType_TAG_TYPE_INT_ARRAY
+			},
+		},
+		{
+			name: "widen an entity tag",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags[0].Type = databasev1.TagType_TAG_TYPE_STRING_ARRAY
+			},
+		},
+		{
+			name: "deprecate an entity tag",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags[0].Deprecated = true
+			},
+		},
+		{
+			name: "remove a tag",
+			update: func(m *databasev1.Measure) {
+				m.TagFamilies[0].Tags = m.TagFamilies[0].Tags[:3]
+			},
+		},
+		{
+			name: "rename a field",
+			update: func(m *databasev1.Measure) {
+				m.Fields[0].Name = "sum"
+			},
+		},
+		{
+			name: "change the interval",
+			update: func(m *databasev1.Measure) {
+				m.Interval = "1h"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			measure, err := registry.GetMeasure(ctx, &commonv1.Metadata{Name: "evolution", Group: "evolution"})
+			require.NoError(t, err)
+			tt.update(measure)
+			incompatibilities, err := registry.CheckMeasureCompatibility(ctx, measure)
+			require.NoError(t, err)
+			if tt.compatible {
+				require.Empty(t, incompatibilities)
+				return
+			}
+			require.NotEmpty(t, incompatibilities)
+			_, err = registry.UpdateMeasure(ctx, measure)
+			require.ErrorIs(t, err, schema.ErrInputInvalid)
+		})
+	}
+
+	// The check doesn't update the measure.
+	measure, err := registry.GetMeasure(ctx, &commonv1.Metadata{Name: "evolution", Group: "evolution"})
+	req.NoError(err)
+	req.Equal(databasev1.TagType_TAG_TYPE_INT, measure.TagFamilies[0].Tags[3].Type)
+	req.Len(measure.TagFamilies, 1)
+}
@@ -0,0 +1,518 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	mvccpb "go.etcd.io/etcd/api/v3/mvccpb"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const (
+	fileStoreName       = "schema.log"
+	fileRecordHeaderLen = 4 + 4 + 8
+	fileLeaseCheckTick  = time.Second
+)
+
+// fileRewriteThreshold is the size which the log could grow beyond its last rewrite.
+var fileRewriteThreshold int64 = 4 << 20
+
+// ConfigureFileRoot sets the directory where the file registry keeps the schema.
+func ConfigureFileRoot(root string) RegistryOption {
+	return func(config *registryConfig) {
+		config.root = root
+	}
+}
+
+// NewFileSchemaRegistry returns a Registry which keeps the schema in a local file.
+// It serves a single process only, for instance, a standalone server.
+func NewFileSchemaRegistry(options ...RegistryOption) (Registry, error) {
+	registryConfig := &registryConfig{historyLimit: DefaultHistoryLimit}
+	for _, opt := range options {
+		opt(registryConfig)
+	}
+	if registryConfig.root == "" {
+		return nil, errors.New("root path is not set")
+	}
+	kv, err := openFileKV(registryConfig.root, logger.GetLogger("schema-file-store"))
+	if err != nil {
+		return nil, err
+	}
+	return newSchemaRegistry(kv, registryConfig), nil
+}
+
+// fileKV is a kvStore which applies the changes to the memory,
+// and appends them to a log file. A record of the log holds the changes of a revision:
+//
+//	|length u32|crc32 u32|revision i64|event length uvarint|event|...
+//
+// The log is rewritten to a single record of the live keys once it grows large.
+// The leases live in the memory only, so the keys attached to them are removed
+// when the store is opened again.
+type fileKV struct {
+	f      *os.File
+	closer *run.Closer
+	l      *logger.Logger
+	path   string
+	kvState
+	size          int64
+	rewrittenSize int64
+	mu            sync.Mutex
+}
+
+func openFileKV(root string, l *logger.Logger) (*fileKV, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	s := &fileKV{
+		path:    filepath.Join(root, fileStoreName),
+		closer:  run.NewCloser(1),
+		l:       l,
+		kvState: newKVState(),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	var leased []string
+	for key, kv := range s.kvs {
+		if kv.Lease != 0 {
+			leased = append(leased, key)
+		}
+	}
+	if len(leased) > 0 {
+		sort.Strings(leased)
+		if err := s.commit(s.deleteEvents(leased)); err != nil {
+			_ = s.f.Close()
+			return nil, err
+		}
+	}
+	if s.closer.AddRunning() {
+		go s.expireLeases()
+	}
+	return s, nil
+}
+
+func (s *fileKV) load() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	var offset int64
+	r := bufio.NewReader(f)
+	for {
+		revision, events, n, readErr := readFileRecord(r)
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				s.l.Warn().Err(readErr).Int64("offset", offset).Msg("truncate the torn tail of the schema log")
+			}
+			break
+		}
+		for _, ev := range events {
+			s.apply(ev)
+		}
+		s.revision = revision
+		offset += n
+	}
+	if err = f.Truncate(offset); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.f = f
+	s.size = offset
+	s.rewrittenSize = offset
+	s.compactRevision = s.revision
+	return nil
+}
+
+func readFileRecord(r io.Reader) (int64, []*mvccpb.Event, int64, error) {
+	var header [fileRecordHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, 0, errors.New("short record header")
+		}
+		return 0, nil, 0, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size < 8 {
+		return 0, nil, 0, errors.Errorf("invalid record length %d", size)
+	}
+	body := make([]byte, size-8)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, 0, errors.Wrap(err, "short record body")
+	}
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write(header[8:])
+	_, _ = crc.Write(body)
+	if crc.Sum32() != binary.BigEndian.Uint32(header[4:]) {
+		return 0, nil, 0, errors.New("record checksum mismatch")
+	}
+	revision := int64(binary.BigEndian.Uint64(header[8:]))
+	var events []*mvccpb.Event
+	for len(body) > 0 {
+		l, n := binary.Uvarint(body)
+		if n <= 0 || uint64(len(body)-n) < l {
+			return 0, nil, 0, errors.New("invalid event length")
+		}
+		ev := &mvccpb.Event{}
+		if err := ev.Unmarshal(body[n : n+int(l)]); err != nil {
+			return 0, nil, 0, err
+		}
+		events = append(events, ev)
+		body = body[n+int(l):]
+	}
+	return revision, events, int64(size) + 8, nil
+}
+
+func encodeFileRecord(revision int64, events []*mvccpb.Event) ([]byte, error) {
+	b := make([]byte, fileRecordHeaderLen, fileRecordHeaderLen+64*len(events))
+	for _, ev := range events {
+		persisted := &mvccpb.Event{Type: ev.Type, Kv: ev.Kv}
+		if ev.Type == mvccpb.DELETE {
+			persisted.Kv = &mvccpb.KeyValue{Key: ev.Kv.Key}
+		}
+		data, err := persisted.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = binary.AppendUvarint(b, uint64(len(data)))
+		b = append(b, data...)
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)-8))
+	binary.BigEndian.PutUint64(b[8:], uint64(revision))
+	binary.BigEndian.PutUint32(b[4:], crc32.ChecksumIEEE(b[8:]))
+	return b, nil
+}
+
+// commit persists the events of the next revision, and then applies them.
+func (s *fileKV) commit(events []*mvccpb.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	revision := s.stamp(events)
+	b, err := encodeFileRecord(revision, events)
+	if err != nil {
+		return err
+	}
+	if _, err = s.f.Write(b); err != nil {
+		return s.rollback(err)
+	}
+	if err = s.f.Sync(); err != nil {
+		return s.rollback(err)
+	}
+	s.size += int64(len(b))
+	s.record(revision, events)
+	if s.size-s.rewrittenSize > fileRewriteThreshold {
+		if err = s.rewrite(); err != nil {
+			s.l.Error().Err(err).Msg("failed to rewrite the schema log")
+		}
+	}
+	return nil
+}
+
+func (s *fileKV) rollback(err error) error {
+	if truncErr := s.f.Truncate(s.size); truncErr != nil {
+		return errors.WithMessagef(err, "failed to truncate the schema log: %v", truncErr)
+	}
+	if _, seekErr := s.f.Seek(s.size, io.SeekStart); seekErr != nil {
+		return errors.WithMessagef(err, "failed to seek the schema log: %v", seekErr)
+	}
+	return err
+}
+
+// rewrite replaces the log with a record holding the live keys.
+func (s *fileKV) rewrite() error {
+	keys := make([]string, 0, len(s.kvs))
+	for key := range s.kvs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	events := make([]*mvccpb.Event, 0, len(keys))
+	for _, key := range keys {
+		events = append(events, &mvccpb.Event{Type: mvccpb.PUT, Kv: s.kvs[key]})
+	}
+	b, err := encodeFileRecord(s.revision, events)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekEnd); err != nil {
+		_ = f.Close()
+		return err
+	}
+	_ = s.f.Close()
+	s.f = f
+	s.size = int64(len(b))
+	s.rewrittenSize = s.size
+	return syncDir(filepath.Dir(s.path))
+}
+
+func writeFileSync(name string, b []byte) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *fileKV) Get(_ context.Context, key string) (*mvccpb.KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return nil, ErrClosed
+	}
+	return s.kvs[key], nil
+}
+
+func (s *fileKV) List(_ context.Context, prefix string) ([]*mvccpb.KeyValue, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return nil, 0, ErrClosed
+	}
+	return s.list(prefix), s.revision, nil
+}
+
+func (s *fileKV) Put(_ context.Context, key string, val []byte, lease int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkPut(lease); err != nil {
+		return 0, err
+	}
+	if err := s.commit([]*mvccpb.Event{s.putEvent(key, val, lease)}); err != nil {
+		return 0, err
+	}
+	return s.revision, nil
+}
+
+func (s *fileKV) PutIf(_ context.Context, key string, val []byte, modRevision, lease int64, others ...*mvccpb.KeyValue) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkPut(lease); err != nil {
+		return 0, false, err
+	}
+	events := s.putIfEvents(key, val, modRevision, lease, others)
+	if events == nil {
+		return 0, false, nil
+	}
+	if err := s.commit(events); err != nil {
+		return 0, false, err
+	}
+	return s.revision, true, nil
+}
+
+func (s *fileKV) checkPut(lease int64) error {
+	if s.closer.Closed() {
+		return ErrClosed
+	}
+	return s.checkLease(lease)
+}
+
+func (s *fileKV) Delete(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return false, ErrClosed
+	}
+	events := s.deleteEvents([]string{key})
+	if err := s.commit(events); err != nil {
+		return false, err
+	}
+	return len(events) > 0, nil
+}
+
+func (s *fileKV) DeletePrefixes(_ context.Context, prefixes ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return ErrClosed
+	}
+	return s.commit(s.deleteEvents(s.keysWithPrefixes(prefixes)))
+}
+
+func (s *fileKV) Grant(_ context.Context, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return 0, ErrClosed
+	}
+	return s.newLease(ttl), nil
+}
+
+// KeepAlive keeps the lease alive without refreshing it periodically,
+// because the holder of the lease lives in the same process with the store.
+func (s *fileKV) KeepAlive(ctx context.Context, lease int64) (<-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return nil, ErrClosed
+	}
+	l, ok := s.leases[lease]
+	if !ok {
+		return nil, errLeaseNotFound
+	}
+	l.kept = true
+	if done := ctx.Done(); done != nil && s.closer.AddRunning() {
+		go func() {
+			defer s.closer.Done()
+			select {
+			case <-done:
+				s.mu.Lock()
+				l.kept = false
+				l.expireAt = time.Now().Add(l.ttl)
+				s.mu.Unlock()
+			case <-l.lost:
+			case <-s.closer.CloseNotify():
+			}
+		}()
+	}
+	return l.lost, nil
+}
+
+func (s *fileKV) Revoke(_ context.Context, lease int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return ErrClosed
+	}
+	return s.revokeLocked(lease)
+}
+
+func (s *fileKV) revokeLocked(lease int64) error {
+	l, ok := s.leases[lease]
+	if !ok {
+		return errLeaseNotFound
+	}
+	if err := s.commit(s.deleteEvents(s.leaseKeys(l))); err != nil {
+		return err
+	}
+	delete(s.leases, lease)
+	close(l.lost)
+	return nil
+}
+
+func (s *fileKV) expireLeases() {
+	defer s.closer.Done()
+	ticker := time.NewTicker(fileLeaseCheckTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closer.CloseNotify():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for id, l := range s.leases {
+				if l.kept || now.Before(l.expireAt) {
+					continue
+				}
+				if err := s.revokeLocked(id); err != nil {
+					s.l.Error().Err(err).Int64("lease", id).Msg("failed to revoke the expired lease")
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *fileKV) Watch(ctx context.Context, prefix string, revision int64) <-chan watchResponse {
+	ch := make(chan watchResponse, kvWatchBufferSize)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		close(ch)
+		return ch
+	}
+	w := s.watch(ch, prefix, revision)
+	if w == nil {
+		return ch
+	}
+	if s.closer.AddRunning() {
+		go func() {
+			defer s.closer.Done()
+			select {
+			case <-ctx.Done():
+			case <-s.closer.CloseNotify():
+			}
+			s.mu.Lock()
+			s.removeWatch(w)
+			s.mu.Unlock()
+		}()
+	}
+	return ch
+}
+
+func (s *fileKV) Compact(_ context.Context, revision int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return ErrClosed
+	}
+	return s.compact(revision)
+}
+
+func (s *fileKV) Close() error {
+	s.closer.Done()
+	s.closer.CloseThenWait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeWatches()
+	return s.f.Close()
+}
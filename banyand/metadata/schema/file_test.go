@@ -0,0 +1,209 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+func openFileRegistry(t *testing.T, root string) schema.Registry {
+	registry, err := schema.NewFileSchemaRegistry(schema.Namespace("test"), schema.ConfigureFileRoot(root))
+	require.NoError(t, err)
+	return registry
+}
+
+func newTestGroup(name string) *commonv1.Group {
+	return &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: name},
+		Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        1,
+			SegmentInterval: &commonv1.IntervalRule{Num: 1, Unit: commonv1.IntervalRule_UNIT_DAY},
+			Ttl:             &commonv1.IntervalRule{Num: 3, Unit: commonv1.IntervalRule_UNIT_DAY},
+		},
+	}
+}
+
+func TestFileRegistry(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	registry := openFileRegistry(t, root)
+	req.NoError(preloadSchema(registry))
+	stream, err := registry.GetStream(context.Background(), &commonv1.Metadata{Name: "sw", Group: "default"})
+	req.NoError(err)
+	_, err = registry.CreateStream(context.Background(), stream)
+	req.ErrorIs(err, schema.ErrGRPCAlreadyExists)
+
+	group, err := registry.GetGroup(context.Background(), "default")
+	req.NoError(err)
+	group.ResourceOpts.Ttl.Num++
+	req.NoError(registry.UpdateGroup(context.Background(), group))
+	updated, err := registry.GetGroup(context.Background(), "default")
+	req.NoError(err)
+	req.Greater(updated.Metadata.ModRevision, group.Metadata.ModRevision)
+	req.Equal(group.Metadata.CreateRevision, updated.Metadata.CreateRevision)
+	rules, err := registry.ListIndexRule(context.Background(), schema.ListOpt{Group: "default"})
+	req.NoError(err)
+	req.NotEmpty(rules)
+	req.NoError(registry.Close())
+
+	registry = openFileRegistry(t, root)
+	defer registry.Close()
+	reloaded, err := registry.GetGroup(context.Background(), "default")
+	req.NoError(err)
+	req.Equal(updated.Metadata.ModRevision, reloaded.Metadata.ModRevision)
+	req.Equal(updated.Metadata.CreateRevision, reloaded.Metadata.CreateRevision)
+	req.Equal(group.ResourceOpts.Ttl.Num, reloaded.ResourceOpts.Ttl.Num)
+	_, err = registry.GetStream(context.Background(), &commonv1.Metadata{Name: "sw", Group: "default"})
+	req.NoError(err)
+	reloadedRules, err := registry.ListIndexRule(context.Background(), schema.ListOpt{Group: "default"})
+	req.NoError(err)
+	req.Len(reloadedRules, len(rules))
+
+	deleted, err := registry.DeleteGroup(context.Background(), "default")
+	req.NoError(err)
+	req.True(deleted)
+	_, err = registry.GetStream(context.Background(), &commonv1.Metadata{Name: "sw", Group: "default"})
+	req.ErrorIs(err, schema.ErrGRPCResourceNotFound)
+	reloadedRules, err = registry.ListIndexRule(context.Background(), schema.ListOpt{Group: "default"})
+	req.NoError(err)
+	req.Empty(reloadedRules)
+}
+
+func TestFileRegistryTornTail(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	registry := openFileRegistry(t, root)
+	req.NoError(preloadSchema(registry))
+	req.NoError(registry.Close())
+
+	f, err := os.OpenFile(filepath.Join(root, "schema.log"), os.O_APPEND|os.O_WRONLY, 0o600)
+	req.NoError(err)
+	_, err = f.Write([]byte{0, 0, 1, 0, 42, 42})
+	req.NoError(err)
+	req.NoError(f.Close())
+
+	registry = openFileRegistry(t, root)
+	defer registry.Close()
+	_, err = registry.GetStream(context.Background(), &commonv1.Metadata{Name: "sw", Group: "default"})
+	req.NoError(err)
+	req.NoError(registry.CreateGroup(context.Background(), newTestGroup("another")))
+}
+
+func TestFileRegistryRegister(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	registry := openFileRegistry(t, root)
+	node := &databasev1.Node{Metadata: &commonv1.Metadata{Name: "standalone"}, Roles: []databasev1.Role{databasev1.Role_ROLE_DATA}}
+	req.NoError(registry.RegisterNode(context.Background(), node, false))
+	req.ErrorIs(registry.RegisterNode(context.Background(), node, false), schema.ErrGRPCAlreadyExists)
+	nodes, err := registry.ListNode(context.Background(), databasev1.Role_ROLE_DATA)
+	req.NoError(err)
+	req.Len(nodes, 1)
+	req.NoError(registry.Close())
+
+	registry = openFileRegistry(t, root)
+	defer registry.Close()
+	_, err = registry.GetNode(context.Background(), "standalone")
+	req.ErrorIs(err, schema.ErrGRPCResourceNotFound)
+	req.NoError(registry.RegisterNode(context.Background(), node, false))
+}
+
+func TestFileRegistryUpdateAndDeleteNode(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	registry := openFileRegistry(t, root)
+	node := &databasev1.Node{Metadata: &commonv1.Metadata{Name: "data-1"}, Roles: []databasev1.Role{databasev1.Role_ROLE_DATA}}
+	req.NoError(registry.RegisterNode(context.Background(), node, false))
+	node.Labels = map[string]string{"draining": "true"}
+	req.NoError(registry.UpdateNode(context.Background(), node))
+	updated, err := registry.GetNode(context.Background(), "data-1")
+	req.NoError(err)
+	req.Equal("true", updated.Labels["draining"])
+	req.NoError(registry.Close())
+
+	// The updated node is still bound to the lease of its registration.
+	registry = openFileRegistry(t, root)
+	defer registry.Close()
+	_, err = registry.GetNode(context.Background(), "data-1")
+	req.ErrorIs(err, schema.ErrGRPCResourceNotFound)
+
+	req.NoError(registry.RegisterNode(context.Background(), node, false))
+	deleted, err := registry.DeleteNode(context.Background(), "data-1")
+	req.NoError(err)
+	req.True(deleted)
+	nodes, err := registry.ListNode(context.Background(), databasev1.Role_ROLE_DATA)
+	req.NoError(err)
+	req.Empty(nodes)
+}
+
+func TestFileRegistryWatcher(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	registry := openFileRegistry(t, root)
+	defer registry.Close()
+	group := newTestGroup("property-group")
+	req.NoError(registry.CreateGroup(context.Background(), group))
+
+	handler := newMockedHandler()
+	watcher := registry.NewWatcher("test", schema.KindGroup, 0)
+	watcher.AddHandler(handler)
+	watcher.Start()
+	defer watcher.Close()
+	assert.Eventually(t, func() bool {
+		return handler.addOrUpdateCalledNum.Load() == 1
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+
+	// Discard the history which the watcher has consumed.
+	group, err := registry.GetGroup(context.Background(), group.Metadata.Name)
+	req.NoError(err)
+	req.NoError(registry.Compact(context.Background(), group.Metadata.ModRevision))
+
+	req.NoError(registry.CreateGroup(context.Background(), newTestGroup("another")))
+	deleted, err := registry.DeleteGroup(context.Background(), group.Metadata.Name)
+	req.NoError(err)
+	req.True(deleted)
+	assert.Eventually(t, func() bool {
+		data := handler.Data()
+		_, ok := data["another"]
+		return handler.addOrUpdateCalledNum.Load() == 2 && handler.deleteCalledNum.Load() == 1 && len(data) == 1 && ok
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+}
@@ -22,7 +22,6 @@ import (
 	"path"
 
 	"github.com/pkg/errors"
-	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -32,7 +31,7 @@ import (
 
 var groupsKeyPrefix = "/groups/"
 
-func (e *etcdSchemaRegistry) GetGroup(ctx context.Context, group string) (*commonv1.Group, error) {
+func (e *schemaRegistry) GetGroup(ctx context.Context, group string) (*commonv1.Group, error) {
 	var entity commonv1.Group
 	err := e.get(ctx, formatGroupKey(group), &entity)
 	if err != nil {
@@ -41,7 +40,7 @@ func (e *etcdSchemaRegistry) GetGroup(ctx context.Context, group string) (*commo
 	return &entity, nil
 }
 
-func (e *etcdSchemaRegistry) ListGroup(ctx context.Context) ([]*commonv1.Group, error) {
+func (e *schemaRegistry) ListGroup(ctx context.Context) ([]*commonv1.Group, error) {
 	messages, err := e.listWithPrefix(ctx, groupsKeyPrefix, KindGroup)
 	if err != nil {
 		return nil, err
@@ -53,28 +52,28 @@ func (e *etcdSchemaRegistry) ListGroup(ctx context.Context) ([]*commonv1.Group,
 	return entities, nil
 }
 
-func (e *etcdSchemaRegistry) DeleteGroup(ctx context.Context, group string) (bool, error) {
+func (e *schemaRegistry) DeleteGroup(ctx context.Context, group string) (bool, error) {
 	_, err := e.GetGroup(ctx, group)
 	if err != nil {
 		return false, errors.Wrap(err, group)
 	}
 	keysToDelete := allKeys()
-	deleteOPs := make([]clientv3.Op, 0, len(keysToDelete)+1)
+	prefixes := make([]string, 0, len(keysToDelete)+1)
 	for _, key := range keysToDelete {
-		deleteOPs = append(deleteOPs, clientv3.OpDelete(e.prependNamespace(listPrefixesForEntity(group, key)), clientv3.WithPrefix()))
+		prefixes = append(prefixes, listPrefixesForEntity(group, key))
 	}
-	deleteOPs = append(deleteOPs, clientv3.OpDelete(e.prependNamespace(formatGroupKey(group)), clientv3.WithPrefix()))
-	txnResponse, err := e.client.Txn(ctx).Then(deleteOPs...).Commit()
-	if err != nil {
-		return false, err
+	prefixes = append(prefixes, formatGroupKey(group))
+	for _, key := range keysToDelete {
+		prefixes = append(prefixes, historyPrefix(listPrefixesForEntity(group, key)))
 	}
-	if !txnResponse.Succeeded {
-		return false, errConcurrentModification
+	prefixes = append(prefixes, historyPrefix(formatGroupKey(group)))
+	if err = e.deletePrefixes(ctx, prefixes...); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
-func (e *etcdSchemaRegistry) CreateGroup(ctx context.Context, group *commonv1.Group) error {
+func (e *schemaRegistry) CreateGroup(ctx context.Context, group *commonv1.Group) error {
 	if err := validate.Group(group); err != nil {
 		return err
 	}
@@ -91,7 +90,7 @@ func (e *etcdSchemaRegistry) CreateGroup(ctx context.Context, group *commonv1.Gr
 	return err
 }
 
-func (e *etcdSchemaRegistry) UpdateGroup(ctx context.Context, group *commonv1.Group) error {
+func (e *schemaRegistry) UpdateGroup(ctx context.Context, group *commonv1.Group) error {
 	if err := validate.Group(group); err != nil {
 		return err
 	}
@@ -0,0 +1,224 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	mvccpb "go.etcd.io/etcd/api/v3/mvccpb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+const (
+	historyKeyPrefix = "/history"
+	// DefaultHistoryLimit is the default number of the previous revisions kept for a schema.
+	DefaultHistoryLimit = 10
+)
+
+// ConfigureHistoryLimit sets the number of the previous revisions kept for a schema.
+// Zero disables the history.
+func ConfigureHistoryLimit(limit int) RegistryOption {
+	return func(config *registryConfig) {
+		if limit >= 0 {
+			config.historyLimit = limit
+		}
+	}
+}
+
+func (k Kind) hasHistory() bool {
+	switch k {
+	case KindGroup, KindStream, KindMeasure, KindIndexRuleBinding, KindIndexRule, KindTopNAggregation, KindProperty:
+		return true
+	default:
+		return false
+	}
+}
+
+// historyPrefix returns the prefix of the previous revisions of the entity with the key.
+func historyPrefix(key string) string {
+	return path.Join(historyKeyPrefix, key) + "/"
+}
+
+func formatHistoryKey(key string, modRevision int64) string {
+	// The padding keeps the revisions sorted by the keys.
+	return fmt.Sprintf("%s%020d", historyPrefix(key), modRevision)
+}
+
+func (e *schemaRegistry) historyRecord(metadata Metadata, key string, prev *mvccpb.KeyValue) []*mvccpb.KeyValue {
+	if e.historyLimit < 1 || !metadata.Kind.hasHistory() {
+		return nil
+	}
+	return []*mvccpb.KeyValue{{
+		Key:   []byte(e.prependNamespace(formatHistoryKey(key, prev.ModRevision))),
+		Value: prev.Value,
+	}}
+}
+
+// trimHistory removes the oldest revisions beyond the limit.
+func (e *schemaRegistry) trimHistory(ctx context.Context, key string) error {
+	kvs, _, err := e.kv.List(ctx, e.prependNamespace(historyPrefix(key)))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(kvs)-e.historyLimit; i++ {
+		if _, err = e.kv.Delete(ctx, string(kvs[i].Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *schemaRegistry) ListRevisions(ctx context.Context, kind Kind, metadata *commonv1.Metadata) ([]Metadata, error) {
+	if !e.closer.AddRunning() {
+		return nil, ErrClosed
+	}
+	defer e.closer.Done()
+	key, current, err := e.current(ctx, kind, metadata)
+	if err != nil {
+		return nil, err
+	}
+	kvs, _, err := e.kv.List(ctx, e.prependNamespace(historyPrefix(key)))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Metadata, 0, len(kvs)+1)
+	result = append(result, current)
+	for i := len(kvs) - 1; i >= 0; i-- {
+		md, err := unmarshalRevision(kind, kvs[i], current)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, md)
+	}
+	return result, nil
+}
+
+func (e *schemaRegistry) GetRevision(ctx context.Context, kind Kind, metadata *commonv1.Metadata, modRevision int64) (Metadata, error) {
+	if !e.closer.AddRunning() {
+		return Metadata{}, ErrClosed
+	}
+	defer e.closer.Done()
+	return e.getRevision(ctx, kind, metadata, modRevision)
+}
+
+func (e *schemaRegistry) getRevision(ctx context.Context, kind Kind, metadata *commonv1.Metadata, modRevision int64) (Metadata, error) {
+	key, current, err := e.current(ctx, kind, metadata)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if current.ModRevision == modRevision {
+		return current, nil
+	}
+	kv, err := e.kv.Get(ctx, e.prependNamespace(formatHistoryKey(key, modRevision)))
+	if err != nil {
+		return Metadata{}, err
+	}
+	if kv == nil {
+		return Metadata{}, errors.WithMessagef(ErrGRPCResourceNotFound, "revision %d of %s %s", modRevision, kind, metadata.GetName())
+	}
+	return unmarshalRevision(kind, kv, current)
+}
+
+func (e *schemaRegistry) Rollback(ctx context.Context, kind Kind, metadata *commonv1.Metadata, modRevision int64) (int64, error) {
+	if !e.closer.AddRunning() {
+		return 0, ErrClosed
+	}
+	md, err := e.getRevision(ctx, kind, metadata, modRevision)
+	e.closer.Done()
+	if err != nil {
+		return 0, err
+	}
+	// Update the schema based on the current revision.
+	md.Spec.(HasMetadata).GetMetadata().ModRevision = 0
+	switch spec := md.Spec.(type) {
+	case *commonv1.Group:
+		err = e.UpdateGroup(ctx, spec)
+	case *databasev1.Stream:
+		_, err = e.UpdateStream(ctx, spec)
+	case *databasev1.Measure:
+		_, err = e.UpdateMeasure(ctx, spec)
+	case *databasev1.IndexRuleBinding:
+		err = e.UpdateIndexRuleBinding(ctx, spec)
+	case *databasev1.IndexRule:
+		err = e.UpdateIndexRule(ctx, spec)
+	case *databasev1.TopNAggregation:
+		err = e.UpdateTopNAggregation(ctx, spec)
+	case *databasev1.Property:
+		err = e.UpdateProperty(ctx, spec)
+	default:
+		return 0, errUnsupportedEntityType
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, current, err := e.current(ctx, kind, metadata)
+	if err != nil {
+		return 0, err
+	}
+	return current.ModRevision, nil
+}
+
+// current returns the key and the current revision of the entity.
+func (e *schemaRegistry) current(ctx context.Context, kind Kind, metadata *commonv1.Metadata) (string, Metadata, error) {
+	if !kind.hasHistory() {
+		return "", Metadata{}, errUnsupportedEntityType
+	}
+	key, err := Metadata{TypeMeta: TypeMeta{Kind: kind, Group: metadata.GetGroup(), Name: metadata.GetName()}}.key()
+	if err != nil {
+		return "", Metadata{}, err
+	}
+	kv, err := e.kv.Get(ctx, e.prependNamespace(key))
+	if err != nil {
+		return "", Metadata{}, err
+	}
+	if kv == nil {
+		return "", Metadata{}, errors.WithMessagef(ErrGRPCResourceNotFound, "%s %s", kind, metadata.GetName())
+	}
+	md, err := kind.Unmarshal(kv)
+	if err != nil {
+		return "", Metadata{}, err
+	}
+	md.ModRevision = kv.ModRevision
+	return key, md, nil
+}
+
+func unmarshalRevision(kind Kind, kv *mvccpb.KeyValue, current Metadata) (Metadata, error) {
+	key := string(kv.Key)
+	modRevision, err := strconv.ParseInt(key[strings.LastIndex(key, "/")+1:], 10, 64)
+	if err != nil {
+		return Metadata{}, errors.Wrapf(err, "invalid history key %s", key)
+	}
+	md, err := kind.Unmarshal(&mvccpb.KeyValue{
+		Key:            kv.Key,
+		Value:          kv.Value,
+		CreateRevision: current.Spec.(HasMetadata).GetMetadata().GetCreateRevision(),
+		ModRevision:    modRevision,
+	})
+	if err != nil {
+		return Metadata{}, err
+	}
+	md.ModRevision = modRevision
+	return md, nil
+}
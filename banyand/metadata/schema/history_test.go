@@ -0,0 +1,82 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func TestHistory(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+	registry, err := schema.NewFileSchemaRegistry(schema.Namespace("test"), schema.ConfigureFileRoot(root),
+		schema.ConfigureHistoryLimit(2))
+	req.NoError(err)
+	defer registry.Close()
+	ctx := context.Background()
+	md := &commonv1.Metadata{Name: "history"}
+
+	req.NoError(registry.CreateGroup(ctx, newTestGroup("history")))
+	var revisions []int64
+	for i := 0; i < 3; i++ {
+		group, getErr := registry.GetGroup(ctx, "history")
+		req.NoError(getErr)
+		revisions = append(revisions, group.Metadata.ModRevision)
+		group.ResourceOpts.Ttl.Num++
+		req.NoError(registry.UpdateGroup(ctx, group))
+	}
+
+	// The oldest revision is beyond the limit.
+	list, err := registry.ListRevisions(ctx, schema.KindGroup, md)
+	req.NoError(err)
+	req.Len(list, 3)
+	req.Equal(uint32(6), list[0].Spec.(*commonv1.Group).ResourceOpts.Ttl.Num)
+	req.Equal(revisions[2], list[1].ModRevision)
+	req.Equal(revisions[1], list[2].ModRevision)
+	_, err = registry.GetRevision(ctx, schema.KindGroup, md, revisions[0])
+	req.ErrorIs(err, schema.ErrGRPCResourceNotFound)
+
+	revision, err := registry.GetRevision(ctx, schema.KindGroup, md, revisions[1])
+	req.NoError(err)
+	req.Equal(uint32(4), revision.Spec.(*commonv1.Group).ResourceOpts.Ttl.Num)
+	modRevision, err := registry.Rollback(ctx, schema.KindGroup, md, revisions[1])
+	req.NoError(err)
+	req.Greater(modRevision, list[0].ModRevision)
+	group, err := registry.GetGroup(ctx, "history")
+	req.NoError(err)
+	req.Equal(uint32(4), group.ResourceOpts.Ttl.Num)
+	req.Equal(modRevision, group.Metadata.ModRevision)
+
+	// Deleting the group drops its history.
+	_, err = registry.DeleteGroup(ctx, "history")
+	req.NoError(err)
+	req.NoError(registry.CreateGroup(ctx, newTestGroup("history")))
+	list, err = registry.ListRevisions(ctx, schema.KindGroup, md)
+	req.NoError(err)
+	req.Len(list, 1)
+	_, err = registry.ListRevisions(ctx, schema.KindNode, md)
+	req.Error(err)
+}
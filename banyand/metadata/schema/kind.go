@@ -41,10 +41,11 @@ const (
 	KindTopNAggregation
 	KindNode
 	KindProperty
+	KindShard
 	KindMask = KindGroup | KindStream | KindMeasure |
 		KindIndexRuleBinding | KindIndexRule |
-		KindTopNAggregation | KindNode | KindProperty
-	KindSize = 8
+		KindTopNAggregation | KindNode | KindProperty | KindShard
+	KindSize = 9
 )
 
 func (k Kind) key() string {
@@ -63,6 +64,8 @@ func (k Kind) key() string {
 		return topNAggregationKeyPrefix
 	case KindNode:
 		return nodeKeyPrefix
+	case KindShard:
+		return shardKeyPrefix
 	default:
 		return "unknown"
 	}
@@ -91,6 +94,8 @@ func (k Kind) Unmarshal(kv *mvccpb.KeyValue) (Metadata, error) {
 		m = &databasev1.Node{}
 	case KindProperty:
 		m = &databasev1.Property{}
+	case KindShard:
+		m = &databasev1.Shard{}
 	default:
 		return Metadata{}, errUnsupportedEntityType
 	}
@@ -133,6 +138,8 @@ func (k Kind) String() string {
 		return "topNAggregation"
 	case KindNode:
 		return "node"
+	case KindShard:
+		return "shard"
 	default:
 		return "unknown"
 	}
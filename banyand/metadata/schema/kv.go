@@ -0,0 +1,346 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	mvccpb "go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+const kvWatchBufferSize = 256
+
+// kvHistorySize is the number of the events kept for the watchers to resume from.
+var kvHistorySize = 10000
+
+var (
+	errCompacted      = errors.New("required revision has been compacted")
+	errFutureRevision = errors.New("required revision is a future revision")
+	errLeaseNotFound  = errors.New("requested lease not found")
+)
+
+// kvStore is the key-value store which the registry keeps the schema in.
+// It follows the data model of etcd: every change bumps the revision of the store,
+// and a key carries the revisions it was created and last modified at.
+type kvStore interface {
+	// Get returns the key-value pair of the key, or nil if the key is absent.
+	Get(ctx context.Context, key string) (*mvccpb.KeyValue, error)
+	// List returns the key-value pairs whose keys start with the prefix, sorted by key,
+	// and the revision of the store they are read at.
+	List(ctx context.Context, prefix string) ([]*mvccpb.KeyValue, int64, error)
+	// Put writes the value of the key. A non-zero lease attaches the key to the lease.
+	Put(ctx context.Context, key string, val []byte, lease int64) (int64, error)
+	// PutIf writes the value only if the key was last modified at modRevision.
+	// A zero modRevision requires the key to be absent.
+	// The others are written along with the key in the same revision.
+	PutIf(ctx context.Context, key string, val []byte, modRevision, lease int64, others ...*mvccpb.KeyValue) (int64, bool, error)
+	// Delete removes the key, and reports whether it existed.
+	Delete(ctx context.Context, key string) (bool, error)
+	// DeletePrefixes removes all the keys under the prefixes in one revision.
+	DeletePrefixes(ctx context.Context, prefixes ...string) error
+	// Grant creates a lease which expires after the ttl unless it is kept alive.
+	Grant(ctx context.Context, ttl time.Duration) (int64, error)
+	// KeepAlive keeps the lease alive until the context is done.
+	// The returned channel is closed once the lease is lost.
+	KeepAlive(ctx context.Context, lease int64) (<-chan struct{}, error)
+	// Revoke revokes the lease and removes the keys attached to it.
+	Revoke(ctx context.Context, lease int64) error
+	// Watch streams the changes under the prefix from the revision, with the previous values of the keys.
+	// The channel is closed when the watching ends. The watcher should list the prefix again to recover.
+	Watch(ctx context.Context, prefix string, revision int64) <-chan watchResponse
+	// Compact discards the history before the revision.
+	Compact(ctx context.Context, revision int64) error
+	Close() error
+}
+
+type watchResponse struct {
+	err      error
+	events   []*mvccpb.Event
+	revision int64
+}
+
+// kvState is the in-memory state of the keys, the leases and the watchers,
+// which the stores apply the committed changes to.
+type kvState struct {
+	kvs             map[string]*mvccpb.KeyValue
+	leases          map[int64]*kvLease
+	watches         map[*kvWatch]struct{}
+	history         []*mvccpb.Event
+	revision        int64
+	compactRevision int64
+	lastLease       int64
+}
+
+type kvLease struct {
+	keys     map[string]struct{}
+	lost     chan struct{}
+	expireAt time.Time
+	ttl      time.Duration
+	kept     bool
+}
+
+type kvWatch struct {
+	ch     chan watchResponse
+	prefix string
+}
+
+func newKVState() kvState {
+	return kvState{
+		kvs:     make(map[string]*mvccpb.KeyValue),
+		leases:  make(map[int64]*kvLease),
+		watches: make(map[*kvWatch]struct{}),
+	}
+}
+
+func (s *kvState) apply(ev *mvccpb.Event) {
+	key := string(ev.Kv.Key)
+	if prev, ok := s.kvs[key]; ok && prev.Lease != 0 {
+		if l, ok := s.leases[prev.Lease]; ok {
+			delete(l.keys, key)
+		}
+	}
+	switch ev.Type {
+	case mvccpb.PUT:
+		s.kvs[key] = ev.Kv
+		if l, ok := s.leases[ev.Kv.Lease]; ok {
+			l.keys[key] = struct{}{}
+		}
+	case mvccpb.DELETE:
+		delete(s.kvs, key)
+	}
+}
+
+// stamp sets the revisions of the events to the next revision, and returns it.
+func (s *kvState) stamp(events []*mvccpb.Event) int64 {
+	revision := s.revision + 1
+	for _, ev := range events {
+		ev.Kv.ModRevision = revision
+		if ev.Type == mvccpb.PUT && ev.Kv.CreateRevision == 0 {
+			ev.Kv.CreateRevision = revision
+		}
+	}
+	return revision
+}
+
+// record applies the stamped events, keeps them in the history and notifies the watchers.
+func (s *kvState) record(revision int64, events []*mvccpb.Event) {
+	s.revision = revision
+	for _, ev := range events {
+		s.apply(ev)
+	}
+	s.history = append(s.history, events...)
+	if len(s.history) > kvHistorySize {
+		s.compactLocked(s.history[len(s.history)-kvHistorySize].Kv.ModRevision - 1)
+	}
+	s.notify(revision, events)
+}
+
+func (s *kvState) notify(revision int64, events []*mvccpb.Event) {
+	for w := range s.watches {
+		var matched []*mvccpb.Event
+		for _, ev := range events {
+			if strings.HasPrefix(string(ev.Kv.Key), w.prefix) {
+				matched = append(matched, ev)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		select {
+		case w.ch <- watchResponse{revision: revision, events: matched}:
+		default:
+			// The watcher falls behind. Closing the channel makes it list the keys again.
+			s.removeWatch(w)
+		}
+	}
+}
+
+func (s *kvState) removeWatch(w *kvWatch) {
+	if _, ok := s.watches[w]; ok {
+		delete(s.watches, w)
+		close(w.ch)
+	}
+}
+
+func (s *kvState) putEvent(key string, val []byte, lease int64) *mvccpb.Event {
+	kv := &mvccpb.KeyValue{Key: []byte(key), Value: val, Lease: lease, Version: 1}
+	ev := &mvccpb.Event{Type: mvccpb.PUT, Kv: kv}
+	if prev, ok := s.kvs[key]; ok {
+		kv.CreateRevision = prev.CreateRevision
+		kv.Version = prev.Version + 1
+		ev.PrevKv = prev
+	}
+	return ev
+}
+
+func (s *kvState) deleteEvents(keys []string) []*mvccpb.Event {
+	events := make([]*mvccpb.Event, 0, len(keys))
+	for _, key := range keys {
+		if prev, ok := s.kvs[key]; ok {
+			events = append(events, &mvccpb.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(key)}, PrevKv: prev})
+		}
+	}
+	return events
+}
+
+// putIfEvents returns the events of a conditional put, or nil if the key was not last modified at modRevision.
+func (s *kvState) putIfEvents(key string, val []byte, modRevision, lease int64, others []*mvccpb.KeyValue) []*mvccpb.Event {
+	var current int64
+	if prev, ok := s.kvs[key]; ok {
+		current = prev.ModRevision
+	}
+	if current != modRevision {
+		return nil
+	}
+	events := make([]*mvccpb.Event, 0, len(others)+1)
+	events = append(events, s.putEvent(key, val, lease))
+	for _, kv := range others {
+		events = append(events, s.putEvent(string(kv.Key), kv.Value, 0))
+	}
+	return events
+}
+
+func (s *kvState) checkLease(lease int64) error {
+	if lease == 0 {
+		return nil
+	}
+	if _, ok := s.leases[lease]; !ok {
+		return errLeaseNotFound
+	}
+	return nil
+}
+
+func (s *kvState) list(prefix string) []*mvccpb.KeyValue {
+	var kvs []*mvccpb.KeyValue
+	for key, kv := range s.kvs {
+		if strings.HasPrefix(key, prefix) {
+			kvs = append(kvs, kv)
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return string(kvs[i].Key) < string(kvs[j].Key)
+	})
+	return kvs
+}
+
+func (s *kvState) keysWithPrefixes(prefixes []string) []string {
+	var keys []string
+	for key := range s.kvs {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *kvState) leaseKeys(l *kvLease) []string {
+	keys := make([]string, 0, len(l.keys))
+	for key := range l.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *kvState) newLease(ttl time.Duration) int64 {
+	s.lastLease++
+	s.leases[s.lastLease] = &kvLease{
+		keys:     make(map[string]struct{}),
+		lost:     make(chan struct{}),
+		ttl:      ttl,
+		expireAt: time.Now().Add(ttl),
+	}
+	return s.lastLease
+}
+
+// watch registers a watcher of the prefix after sending it the history from the revision.
+// It returns nil if the watcher can't resume from the revision, in which case the channel is closed.
+func (s *kvState) watch(ch chan watchResponse, prefix string, revision int64) *kvWatch {
+	if revision > 0 && revision <= s.compactRevision {
+		ch <- watchResponse{revision: s.revision, err: errCompacted}
+		close(ch)
+		return nil
+	}
+	if revision > 0 {
+		var resp *watchResponse
+		for _, ev := range s.history {
+			if ev.Kv.ModRevision < revision || !strings.HasPrefix(string(ev.Kv.Key), prefix) {
+				continue
+			}
+			if resp != nil && resp.revision != ev.Kv.ModRevision {
+				if !s.sendHistory(ch, resp) {
+					return nil
+				}
+				resp = nil
+			}
+			if resp == nil {
+				resp = &watchResponse{revision: ev.Kv.ModRevision}
+			}
+			resp.events = append(resp.events, ev)
+		}
+		if resp != nil && !s.sendHistory(ch, resp) {
+			return nil
+		}
+	}
+	w := &kvWatch{ch: ch, prefix: prefix}
+	s.watches[w] = struct{}{}
+	return w
+}
+
+func (s *kvState) sendHistory(ch chan watchResponse, resp *watchResponse) bool {
+	if len(ch) < cap(ch)-1 {
+		ch <- *resp
+		return true
+	}
+	ch <- watchResponse{revision: s.revision, err: errCompacted}
+	close(ch)
+	return false
+}
+
+func (s *kvState) compact(revision int64) error {
+	if revision > s.revision {
+		return errFutureRevision
+	}
+	if revision <= s.compactRevision {
+		return errCompacted
+	}
+	s.compactLocked(revision)
+	return nil
+}
+
+func (s *kvState) compactLocked(revision int64) {
+	i := sort.Search(len(s.history), func(i int) bool {
+		return s.history[i].Kv.ModRevision > revision
+	})
+	s.history = append(s.history[:0:0], s.history[i:]...)
+	s.compactRevision = revision
+}
+
+func (s *kvState) closeWatches() {
+	for w := range s.watches {
+		s.removeWatch(w)
+	}
+}
@@ -19,7 +19,6 @@ package schema
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -35,7 +34,7 @@ const (
 	tagTypeID        = "id"
 )
 
-func (e *etcdSchemaRegistry) GetMeasure(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Measure, error) {
+func (e *schemaRegistry) GetMeasure(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Measure, error) {
 	var entity databasev1.Measure
 	if err := e.get(ctx, formatMeasureKey(metadata), &entity); err != nil {
 		return nil, err
@@ -43,7 +42,7 @@ func (e *etcdSchemaRegistry) GetMeasure(ctx context.Context, metadata *commonv1.
 	return &entity, nil
 }
 
-func (e *etcdSchemaRegistry) ListMeasure(ctx context.Context, opt ListOpt) ([]*databasev1.Measure, error) {
+func (e *schemaRegistry) ListMeasure(ctx context.Context, opt ListOpt) ([]*databasev1.Measure, error) {
 	if opt.Group == "" {
 		return nil, BadRequest("group", "group should not be empty")
 	}
@@ -58,7 +57,7 @@ func (e *etcdSchemaRegistry) ListMeasure(ctx context.Context, opt ListOpt) ([]*d
 	return entities, nil
 }
 
-func (e *etcdSchemaRegistry) CreateMeasure(ctx context.Context, measure *databasev1.Measure) (int64, error) {
+func (e *schemaRegistry) CreateMeasure(ctx context.Context, measure *databasev1.Measure) (int64, error) {
 	if measure.UpdatedAt != nil {
 		measure.UpdatedAt = timestamppb.Now()
 	}
@@ -88,7 +87,7 @@ func (e *etcdSchemaRegistry) CreateMeasure(ctx context.Context, measure *databas
 	})
 }
 
-func (e *etcdSchemaRegistry) UpdateMeasure(ctx context.Context, measure *databasev1.Measure) (int64, error) {
+func (e *schemaRegistry) UpdateMeasure(ctx context.Context, measure *databasev1.Measure) (int64, error) {
 	if measure.UpdatedAt != nil {
 		measure.UpdatedAt = timestamppb.Now()
 	}
@@ -115,8 +114,12 @@ func (e *etcdSchemaRegistry) UpdateMeasure(ctx context.Context, measure *databas
 	if prev == nil {
 		return 0, errors.WithMessagef(ErrGRPCResourceNotFound, "measure %s not found", measure.GetMetadata().GetName())
 	}
-	if err := validateEqualExceptAppendTagsAndFields(prev, measure); err != nil {
-		return 0, errors.WithMessagef(ErrInputInvalid, "validation failed: %s", err)
+	indexedTags, err := e.indexedTags(ctx, measure.GetMetadata(), commonv1.Catalog_CATALOG_MEASURE)
+	if err != nil {
+		return 0, err
+	}
+	if err = incompatibilityError(measureIncompatibilities(prev, measure, indexedTags)); err != nil {
+		return 0, err
 	}
 	return e.update(ctx, Metadata{
 		TypeMeta: TypeMeta{
@@ -129,44 +132,7 @@ func (e *etcdSchemaRegistry) UpdateMeasure(ctx context.Context, measure *databas
 	})
 }
 
-func validateEqualExceptAppendTagsAndFields(prevMeasure, newMeasure *databasev1.Measure) error {
-	if prevMeasure.GetInterval() != newMeasure.GetInterval() {
-		return fmt.Errorf("interval is different: %s != %s", prevMeasure.GetInterval(), newMeasure.GetInterval())
-	}
-	if prevMeasure.GetEntity().String() != newMeasure.GetEntity().String() {
-		return fmt.Errorf("entity is different: %s != %s", prevMeasure.GetEntity().String(), newMeasure.GetEntity().String())
-	}
-	if prevMeasure.GetIndexMode() != newMeasure.GetIndexMode() {
-		return fmt.Errorf("index mode is different: %v != %v", prevMeasure.GetIndexMode(), newMeasure.GetIndexMode())
-	}
-	if len(prevMeasure.GetTagFamilies()) > len(newMeasure.GetTagFamilies()) {
-		return fmt.Errorf("number of tag families is less in the new measure")
-	}
-	if len(prevMeasure.GetFields()) > len(newMeasure.GetFields()) {
-		return fmt.Errorf("number of fields is less in the new measure")
-	}
-	for i, tagFamily := range prevMeasure.GetTagFamilies() {
-		if tagFamily.Name != newMeasure.GetTagFamilies()[i].Name {
-			return fmt.Errorf("tag family name is different: %s != %s", tagFamily.Name, newMeasure.GetTagFamilies()[i].Name)
-		}
-		if len(tagFamily.Tags) > len(newMeasure.GetTagFamilies()[i].Tags) {
-			return fmt.Errorf("number of tags in tag family %s is less in the new measure", tagFamily.Name)
-		}
-		for j, tag := range tagFamily.Tags {
-			if tag.String() != newMeasure.GetTagFamilies()[i].Tags[j].String() {
-				return fmt.Errorf("tag %s in tag family %s is different: %s != %s", tag.Name, tagFamily.Name, tag.String(), newMeasure.GetTagFamilies()[i].Tags[j].String())
-			}
-		}
-	}
-	for i, field := range prevMeasure.GetFields() {
-		if field.String() != newMeasure.GetFields()[i].String() {
-			return fmt.Errorf("field is different: %s != %s", field.String(), newMeasure.GetFields()[i].String())
-		}
-	}
-	return nil
-}
-
-func (e *etcdSchemaRegistry) DeleteMeasure(ctx context.Context, metadata *commonv1.Metadata) (bool, error) {
+func (e *schemaRegistry) DeleteMeasure(ctx context.Context, metadata *commonv1.Metadata) (bool, error) {
 	return e.delete(ctx, Metadata{
 		TypeMeta: TypeMeta{
 			Kind:  KindMeasure,
@@ -176,7 +142,7 @@ func (e *etcdSchemaRegistry) DeleteMeasure(ctx context.Context, metadata *common
 	})
 }
 
-func (e *etcdSchemaRegistry) TopNAggregations(ctx context.Context, metadata *commonv1.Metadata) ([]*databasev1.TopNAggregation, error) {
+func (e *schemaRegistry) TopNAggregations(ctx context.Context, metadata *commonv1.Metadata) ([]*databasev1.TopNAggregation, error) {
 	aggregations, err := e.ListTopNAggregation(ctx, ListOpt{Group: metadata.GetGroup()})
 	if err != nil {
 		return nil, err
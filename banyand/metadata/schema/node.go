@@ -28,7 +28,7 @@ import (
 
 var nodeKeyPrefix = "/nodes/"
 
-func (e *etcdSchemaRegistry) ListNode(ctx context.Context, role databasev1.Role) ([]*databasev1.Node, error) {
+func (e *schemaRegistry) ListNode(ctx context.Context, role databasev1.Role) ([]*databasev1.Node, error) {
 	if role == databasev1.Role_ROLE_UNSPECIFIED {
 		return nil, BadRequest("group", "group should not be empty")
 	}
@@ -49,7 +49,7 @@ func (e *etcdSchemaRegistry) ListNode(ctx context.Context, role databasev1.Role)
 	return entities, nil
 }
 
-func (e *etcdSchemaRegistry) RegisterNode(ctx context.Context, node *databasev1.Node, forced bool) error {
+func (e *schemaRegistry) RegisterNode(ctx context.Context, node *databasev1.Node, forced bool) error {
 	return e.Register(ctx, Metadata{
 		TypeMeta: TypeMeta{
 			Kind: KindNode,
@@ -59,7 +59,7 @@ func (e *etcdSchemaRegistry) RegisterNode(ctx context.Context, node *databasev1.
 	}, forced)
 }
 
-func (e *etcdSchemaRegistry) UpdateNode(ctx context.Context, node *databasev1.Node) error {
+func (e *schemaRegistry) UpdateNode(ctx context.Context, node *databasev1.Node) error {
 	_, err := e.update(ctx, Metadata{
 		TypeMeta: TypeMeta{
 			Kind: KindNode,
@@ -70,11 +70,20 @@ func (e *etcdSchemaRegistry) UpdateNode(ctx context.Context, node *databasev1.No
 	return err
 }
 
+func (e *schemaRegistry) DeleteNode(ctx context.Context, node string) (bool, error) {
+	return e.delete(ctx, Metadata{
+		TypeMeta: TypeMeta{
+			Kind: KindNode,
+			Name: node,
+		},
+	})
+}
+
 func formatNodeKey(name string) string {
 	return path.Join(nodeKeyPrefix, name)
 }
 
-func (e *etcdSchemaRegistry) GetNode(ctx context.Context, node string) (*databasev1.Node, error) {
+func (e *schemaRegistry) GetNode(ctx context.Context, node string) (*databasev1.Node, error) {
 	var entity databasev1.Node
 	err := e.get(ctx, formatNodeKey(node), &entity)
 	if err != nil {
@@ -30,7 +30,7 @@ import (
 
 const propertyKeyPrefix = "/properties/"
 
-func (e *etcdSchemaRegistry) CreateProperty(ctx context.Context, property *databasev1.Property) error {
+func (e *schemaRegistry) CreateProperty(ctx context.Context, property *databasev1.Property) error {
 	if property.UpdatedAt == nil {
 		property.UpdatedAt = timestamppb.Now()
 	}
@@ -54,7 +54,7 @@ func (e *etcdSchemaRegistry) CreateProperty(ctx context.Context, property *datab
 	return err
 }
 
-func (e *etcdSchemaRegistry) DeleteProperty(ctx context.Context, metadata *commonv1.Metadata) (bool, error) {
+func (e *schemaRegistry) DeleteProperty(ctx context.Context, metadata *commonv1.Metadata) (bool, error) {
 	return e.delete(ctx, Metadata{
 		TypeMeta: TypeMeta{
 			Kind:  KindProperty,
@@ -64,7 +64,7 @@ func (e *etcdSchemaRegistry) DeleteProperty(ctx context.Context, metadata *commo
 	})
 }
 
-func (e *etcdSchemaRegistry) GetProperty(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Property, error) {
+func (e *schemaRegistry) GetProperty(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Property, error) {
 	var entity databasev1.Property
 	if err := e.get(ctx, formatPropertyKey(metadata), &entity); err != nil {
 		return nil, err
@@ -72,7 +72,7 @@ func (e *etcdSchemaRegistry) GetProperty(ctx context.Context, metadata *commonv1
 	return &entity, nil
 }
 
-func (e *etcdSchemaRegistry) ListProperty(ctx context.Context, opt ListOpt) ([]*databasev1.Property, error) {
+func (e *schemaRegistry) ListProperty(ctx context.Context, opt ListOpt) ([]*databasev1.Property, error) {
 	if opt.Group == "" {
 		return nil, BadRequest("group", "group should not be empty")
 	}
@@ -87,7 +87,7 @@ func (e *etcdSchemaRegistry) ListProperty(ctx context.Context, opt ListOpt) ([]*
 	return entities, nil
 }
 
-func (e *etcdSchemaRegistry) UpdateProperty(ctx context.Context, property *databasev1.Property) error {
+func (e *schemaRegistry) UpdateProperty(ctx context.Context, property *databasev1.Property) error {
 	if property.UpdatedAt == nil {
 		property.UpdatedAt = timestamppb.Now()
 	}
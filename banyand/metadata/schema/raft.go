@@ -0,0 +1,819 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	mvccpb "go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/pkg/v3/idutil"
+	"go.etcd.io/etcd/pkg/v3/wait"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	stats "go.etcd.io/etcd/server/v3/etcdserver/api/v2stats"
+	"go.etcd.io/etcd/server/v3/wal"
+	"go.etcd.io/etcd/server/v3/wal/walpb"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const (
+	raftClusterID         = 0x62796462
+	raftTickInterval      = 100 * time.Millisecond
+	raftElectionTicks     = 10
+	raftRequestTimeout    = 10 * time.Second
+	raftProposeRetry      = 100 * time.Millisecond
+	raftLeaseCheckTick    = time.Second
+	raftMaxSizePerMsg     = 1 << 20
+	raftMaxInflightMsgs   = 256
+	raftMaxUncommitedSize = 1 << 30
+)
+
+var (
+	// raftSnapshotCount is the number of the applied entries which trigger a snapshot of the store.
+	raftSnapshotCount uint64 = 10000
+	// raftSnapshotCatchUp is the number of the entries kept after a snapshot for the slow followers to catch up.
+	raftSnapshotCatchUp uint64 = 5000
+)
+
+// ConfigureRaftPeers sets the id of the local member and the peer URLs of all the members of the Raft group.
+func ConfigureRaftPeers(id uint64, peers map[uint64]string) RegistryOption {
+	return func(config *registryConfig) {
+		config.raftID = id
+		config.raftPeers = peers
+	}
+}
+
+// NewRaftSchemaRegistry returns a Registry which replicates the schema across a static Raft group
+// embedded in the liaison and data nodes. The local member listens to its peer URL for the traffic
+// of the group, and keeps the log and the snapshots under the root path.
+func NewRaftSchemaRegistry(options ...RegistryOption) (Registry, error) {
+	registryConfig := &registryConfig{historyLimit: DefaultHistoryLimit}
+	for _, opt := range options {
+		opt(registryConfig)
+	}
+	if registryConfig.root == "" {
+		return nil, errors.New("root path is not set")
+	}
+	if _, ok := registryConfig.raftPeers[registryConfig.raftID]; !ok {
+		return nil, errors.Errorf("the raft member %d is not one of the peers", registryConfig.raftID)
+	}
+	kv, err := openRaftKV(registryConfig.root, registryConfig.raftID, registryConfig.raftPeers, logger.GetLogger("schema-raft-store"))
+	if err != nil {
+		return nil, err
+	}
+	return newSchemaRegistry(kv, registryConfig), nil
+}
+
+type raftOp uint8
+
+const (
+	raftOpPut raftOp = iota + 1
+	raftOpPutIf
+	raftOpDelete
+	raftOpDeletePrefixes
+	raftOpGrant
+	raftOpKeepAlive
+	raftOpRevoke
+)
+
+// raftCommand is a change proposed to the Raft group. The members apply it to their state
+// in the order of the log, so the conditions of a change are checked when it is applied.
+type raftCommand struct {
+	Key         string             `json:"key,omitempty"`
+	Value       []byte             `json:"value,omitempty"`
+	Others      []*mvccpb.KeyValue `json:"others,omitempty"`
+	Prefixes    []string           `json:"prefixes,omitempty"`
+	ID          uint64             `json:"id"`
+	Lease       int64              `json:"lease,omitempty"`
+	ModRevision int64              `json:"mod_revision,omitempty"`
+	TTL         time.Duration      `json:"ttl,omitempty"`
+	Op          raftOp             `json:"op"`
+}
+
+type raftResult struct {
+	err      error
+	revision int64
+	lease    int64
+	ok       bool
+}
+
+// raftSnapshot is the state of the store kept in a Raft snapshot.
+// The history is not part of it, so the watchers list the keys again after a snapshot is installed.
+type raftSnapshot struct {
+	Kvs       []*mvccpb.KeyValue `json:"kvs"`
+	Leases    []raftLease        `json:"leases"`
+	Revision  int64              `json:"revision"`
+	LastLease int64              `json:"last_lease"`
+}
+
+type raftLease struct {
+	ID  int64         `json:"id"`
+	TTL time.Duration `json:"ttl"`
+}
+
+// raftKV is a kvStore replicated by a Raft group. The changes are proposed to the group,
+// and applied once they are committed. The reads and the watches are served by the local member,
+// which has applied all the changes the member proposed.
+//
+// The leases are part of the replicated state, but their deadlines are kept by each member.
+// Only the leader revokes the expired leases, and it extends all the leases once it is elected.
+type raftKV struct {
+	waits       wait.Wait
+	node        raft.Node
+	closer      *run.Closer
+	l           *logger.Logger
+	zl          *zap.Logger
+	storage     *raft.MemoryStorage
+	wal         *wal.WAL
+	snapshotter *snap.Snapshotter
+	transport   *rafthttp.Transport
+	server      *http.Server
+	reqIDs      *idutil.Generator
+	kvState
+	confState     raftpb.ConfState
+	snapshotIndex uint64
+	appliedIndex  uint64
+	id            uint64
+	mu            sync.Mutex
+	leading       atomic.Bool
+}
+
+func openRaftKV(root string, id uint64, peers map[uint64]string, l *logger.Logger) (*raftKV, error) {
+	zl, err := l.ToZapConfig().Build()
+	if err != nil {
+		return nil, err
+	}
+	snapDir := filepath.Join(root, "snap")
+	walDir := filepath.Join(root, "wal")
+	if err = os.MkdirAll(snapDir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &raftKV{
+		waits:       wait.New(),
+		closer:      run.NewCloser(1),
+		l:           l,
+		zl:          zl,
+		storage:     raft.NewMemoryStorage(),
+		snapshotter: snap.New(zl, snapDir),
+		reqIDs:      idutil.NewGenerator(uint16(id), time.Now()),
+		kvState:     newKVState(),
+		id:          id,
+	}
+	restart := wal.Exist(walDir)
+	if err = s.replayWAL(walDir); err != nil {
+		return nil, err
+	}
+	c := &raft.Config{
+		ID:                        id,
+		ElectionTick:              raftElectionTicks,
+		HeartbeatTick:             1,
+		Storage:                   s.storage,
+		Applied:                   s.appliedIndex,
+		MaxSizePerMsg:             raftMaxSizePerMsg,
+		MaxInflightMsgs:           raftMaxInflightMsgs,
+		MaxUncommittedEntriesSize: raftMaxUncommitedSize,
+		CheckQuorum:               true,
+		PreVote:                   true,
+		Logger:                    etcdserver.NewRaftLoggerZap(zl),
+	}
+	if restart {
+		s.node = raft.RestartNode(c)
+	} else {
+		members := make([]raft.Peer, 0, len(peers))
+		for peer := range peers {
+			members = append(members, raft.Peer{ID: peer})
+		}
+		s.node = raft.StartNode(c, members)
+	}
+	if err = s.startTransport(peers); err != nil {
+		s.node.Stop()
+		_ = s.wal.Close()
+		return nil, err
+	}
+	if s.closer.AddRunning() {
+		go s.run()
+	}
+	if s.closer.AddRunning() {
+		go s.expireLeases()
+	}
+	return s, nil
+}
+
+// replayWAL loads the newest snapshot and the entries after it into the raft storage.
+// The entries are applied once the node delivers them as committed.
+func (s *raftKV) replayWAL(walDir string) error {
+	if !wal.Exist(walDir) {
+		w, err := wal.Create(s.zl, walDir, nil)
+		if err != nil {
+			return err
+		}
+		if err = w.Close(); err != nil {
+			return err
+		}
+	}
+	walSnaps, err := wal.ValidSnapshotEntries(s.zl, walDir)
+	if err != nil {
+		return err
+	}
+	snapshot, err := s.snapshotter.LoadNewestAvailable(walSnaps)
+	if err != nil && !errors.Is(err, snap.ErrNoSnapshot) {
+		return err
+	}
+	walSnap := walpb.Snapshot{}
+	if snapshot != nil {
+		walSnap.Index, walSnap.Term = snapshot.Metadata.Index, snapshot.Metadata.Term
+	}
+	if s.wal, err = wal.Open(s.zl, walDir, walSnap); err != nil {
+		return err
+	}
+	_, hardState, entries, err := s.wal.ReadAll()
+	if err != nil {
+		_ = s.wal.Close()
+		return err
+	}
+	if snapshot != nil {
+		if err = s.storage.ApplySnapshot(*snapshot); err != nil {
+			_ = s.wal.Close()
+			return err
+		}
+		if err = s.restore(snapshot.Data); err != nil {
+			_ = s.wal.Close()
+			return err
+		}
+		s.confState = snapshot.Metadata.ConfState
+		s.snapshotIndex = snapshot.Metadata.Index
+		s.appliedIndex = snapshot.Metadata.Index
+	}
+	if err = s.storage.SetHardState(hardState); err != nil {
+		_ = s.wal.Close()
+		return err
+	}
+	if err = s.storage.Append(entries); err != nil {
+		_ = s.wal.Close()
+		return err
+	}
+	return nil
+}
+
+func (s *raftKV) startTransport(peers map[uint64]string) error {
+	u, err := url.Parse(peers[s.id])
+	if err != nil {
+		return errors.Wrapf(err, "invalid peer url of the raft member %d", s.id)
+	}
+	s.transport = &rafthttp.Transport{
+		Logger:      s.zl,
+		ID:          types.ID(s.id),
+		ClusterID:   raftClusterID,
+		Raft:        s,
+		ServerStats: stats.NewServerStats("", ""),
+		LeaderStats: stats.NewLeaderStats(s.zl, strconv.FormatUint(s.id, 10)),
+		ErrorC:      make(chan error, 1),
+	}
+	if err = s.transport.Start(); err != nil {
+		return err
+	}
+	for peer, peerURL := range peers {
+		if peer != s.id {
+			s.transport.AddPeer(types.ID(peer), []string{peerURL})
+		}
+	}
+	ln, err := net.Listen("tcp", u.Host)
+	if err != nil {
+		s.transport.Stop()
+		return err
+	}
+	s.server = &http.Server{Handler: s.transport.Handler(), ReadHeaderTimeout: raftRequestTimeout}
+	go func() {
+		if serveErr := s.server.Serve(ln); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			s.l.Error().Err(serveErr).Msg("the raft peer server stopped")
+		}
+	}()
+	return nil
+}
+
+func (s *raftKV) run() {
+	defer s.closer.Done()
+	ticker := time.NewTicker(raftTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.node.Tick()
+		case rd := <-s.node.Ready():
+			if err := s.ready(rd); err != nil {
+				s.l.Fatal().Err(err).Msg("failed to persist the raft state")
+			}
+			s.node.Advance()
+		case err := <-s.transport.ErrorC:
+			s.l.Error().Err(err).Msg("the raft transport fails")
+		case <-s.closer.CloseNotify():
+			return
+		}
+	}
+}
+
+func (s *raftKV) ready(rd raft.Ready) error {
+	if rd.SoftState != nil {
+		s.lead(rd.SoftState.RaftState == raft.StateLeader)
+	}
+	if !raft.IsEmptySnap(rd.Snapshot) {
+		if err := s.saveSnapshot(rd.Snapshot); err != nil {
+			return err
+		}
+	}
+	if err := s.wal.Save(rd.HardState, rd.Entries); err != nil {
+		return err
+	}
+	if !raft.IsEmptySnap(rd.Snapshot) {
+		if err := s.storage.ApplySnapshot(rd.Snapshot); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		err := s.restore(rd.Snapshot.Data)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		s.confState = rd.Snapshot.Metadata.ConfState
+		s.snapshotIndex = rd.Snapshot.Metadata.Index
+		s.appliedIndex = rd.Snapshot.Metadata.Index
+	}
+	if err := s.storage.Append(rd.Entries); err != nil {
+		return err
+	}
+	for i := range rd.Messages {
+		if rd.Messages[i].Type == raftpb.MsgSnap {
+			rd.Messages[i].Snapshot.Metadata.ConfState = s.confState
+		}
+	}
+	s.transport.Send(rd.Messages)
+	if err := s.applyEntries(rd.CommittedEntries); err != nil {
+		return err
+	}
+	return s.maybeSnapshot()
+}
+
+func (s *raftKV) applyEntries(entries []raftpb.Entry) error {
+	for _, ent := range entries {
+		if ent.Index <= s.appliedIndex {
+			continue
+		}
+		switch ent.Type {
+		case raftpb.EntryNormal:
+			if len(ent.Data) == 0 {
+				break
+			}
+			cmd := &raftCommand{}
+			if err := json.Unmarshal(ent.Data, cmd); err != nil {
+				return errors.Wrapf(err, "failed to decode the raft entry %d", ent.Index)
+			}
+			s.mu.Lock()
+			result := s.apply(cmd)
+			s.mu.Unlock()
+			s.waits.Trigger(cmd.ID, result)
+		case raftpb.EntryConfChange:
+			var cc raftpb.ConfChange
+			if err := cc.Unmarshal(ent.Data); err != nil {
+				return errors.Wrapf(err, "failed to decode the raft entry %d", ent.Index)
+			}
+			s.confState = *s.node.ApplyConfChange(cc)
+		}
+		s.appliedIndex = ent.Index
+	}
+	return nil
+}
+
+// apply changes the state by the command. The caller holds s.mu.
+func (s *raftKV) apply(cmd *raftCommand) *raftResult {
+	result := &raftResult{}
+	var events []*mvccpb.Event
+	switch cmd.Op {
+	case raftOpPut:
+		if result.err = s.checkLease(cmd.Lease); result.err != nil {
+			return result
+		}
+		events = []*mvccpb.Event{s.putEvent(cmd.Key, cmd.Value, cmd.Lease)}
+	case raftOpPutIf:
+		if result.err = s.checkLease(cmd.Lease); result.err != nil {
+			return result
+		}
+		events = s.putIfEvents(cmd.Key, cmd.Value, cmd.ModRevision, cmd.Lease, cmd.Others)
+	case raftOpDelete:
+		events = s.deleteEvents([]string{cmd.Key})
+	case raftOpDeletePrefixes:
+		events = s.deleteEvents(s.keysWithPrefixes(cmd.Prefixes))
+	case raftOpGrant:
+		result.lease = s.newLease(cmd.TTL)
+	case raftOpKeepAlive:
+		l, ok := s.leases[cmd.Lease]
+		if !ok {
+			result.err = errLeaseNotFound
+			return result
+		}
+		l.expireAt = time.Now().Add(l.ttl)
+	case raftOpRevoke:
+		l, ok := s.leases[cmd.Lease]
+		if !ok {
+			result.err = errLeaseNotFound
+			return result
+		}
+		events = s.deleteEvents(s.leaseKeys(l))
+		delete(s.leases, cmd.Lease)
+		close(l.lost)
+	default:
+		result.err = errors.Errorf("unknown raft operation %d", cmd.Op)
+		return result
+	}
+	result.ok = len(events) > 0
+	if result.ok {
+		s.record(s.stamp(events), events)
+	}
+	result.revision = s.revision
+	return result
+}
+
+func (s *raftKV) lead(leading bool) {
+	if s.leading.Swap(leading) || !leading {
+		return
+	}
+	// The deadlines kept by the previous leader are lost. Extends all the leases
+	// to give their holders the time to keep them alive through the new leader.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, l := range s.leases {
+		l.expireAt = now.Add(l.ttl)
+	}
+}
+
+func (s *raftKV) saveSnapshot(snapshot raftpb.Snapshot) error {
+	walSnap := walpb.Snapshot{
+		Index:     snapshot.Metadata.Index,
+		Term:      snapshot.Metadata.Term,
+		ConfState: &snapshot.Metadata.ConfState,
+	}
+	if err := s.snapshotter.SaveSnap(snapshot); err != nil {
+		return err
+	}
+	if err := s.wal.SaveSnapshot(walSnap); err != nil {
+		return err
+	}
+	return s.wal.ReleaseLockTo(snapshot.Metadata.Index)
+}
+
+func (s *raftKV) maybeSnapshot() error {
+	if s.appliedIndex-s.snapshotIndex <= raftSnapshotCount {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := s.snapshotData()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	snapshot, err := s.storage.CreateSnapshot(s.appliedIndex, &s.confState, data)
+	if err != nil {
+		return err
+	}
+	if err = s.saveSnapshot(snapshot); err != nil {
+		return err
+	}
+	if s.appliedIndex > raftSnapshotCatchUp {
+		if err = s.storage.Compact(s.appliedIndex - raftSnapshotCatchUp); err != nil && !errors.Is(err, raft.ErrCompacted) {
+			return err
+		}
+	}
+	s.snapshotIndex = s.appliedIndex
+	return nil
+}
+
+func (s *raftKV) snapshotData() ([]byte, error) {
+	snapshot := raftSnapshot{
+		Kvs:       s.list(""),
+		Revision:  s.revision,
+		LastLease: s.lastLease,
+	}
+	for id, l := range s.leases {
+		snapshot.Leases = append(snapshot.Leases, raftLease{ID: id, TTL: l.ttl})
+	}
+	return json.Marshal(snapshot)
+}
+
+// restore replaces the state with the snapshot. The caller holds s.mu if the store is open.
+func (s *raftKV) restore(data []byte) error {
+	var snapshot raftSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return errors.Wrap(err, "failed to decode the raft snapshot")
+	}
+	state := newKVState()
+	state.revision = snapshot.Revision
+	state.compactRevision = snapshot.Revision
+	state.lastLease = snapshot.LastLease
+	now := time.Now()
+	for _, sl := range snapshot.Leases {
+		l := &kvLease{keys: make(map[string]struct{}), ttl: sl.TTL, expireAt: now.Add(sl.TTL)}
+		if prev, ok := s.leases[sl.ID]; ok {
+			l.lost = prev.lost
+		} else {
+			l.lost = make(chan struct{})
+		}
+		state.leases[sl.ID] = l
+	}
+	for id, l := range s.leases {
+		if _, ok := state.leases[id]; !ok {
+			close(l.lost)
+		}
+	}
+	for _, kv := range snapshot.Kvs {
+		state.apply(&mvccpb.Event{Type: mvccpb.PUT, Kv: kv})
+	}
+	// The history before the snapshot is unknown. Closing the watches makes the watchers list the keys again.
+	s.closeWatches()
+	s.kvState = state
+	return nil
+}
+
+// propose replicates the command, and waits for the local member to apply it.
+func (s *raftKV) propose(ctx context.Context, cmd *raftCommand) (*raftResult, error) {
+	if !s.closer.AddRunning() {
+		return nil, ErrClosed
+	}
+	defer s.closer.Done()
+	cmd.ID = s.reqIDs.Next()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, raftRequestTimeout)
+	defer cancel()
+	ch := s.waits.Register(cmd.ID)
+	for {
+		err = s.node.Propose(ctx, data)
+		if !errors.Is(err, raft.ErrProposalDropped) {
+			break
+		}
+		// There is no leader to forward the proposal to. Retries after the election.
+		select {
+		case <-time.After(raftProposeRetry):
+			continue
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-s.closer.CloseNotify():
+			err = ErrClosed
+		}
+		break
+	}
+	if err != nil {
+		s.waits.Trigger(cmd.ID, nil)
+		return nil, err
+	}
+	select {
+	case x := <-ch:
+		result := x.(*raftResult)
+		return result, result.err
+	case <-ctx.Done():
+		s.waits.Trigger(cmd.ID, nil)
+		return nil, ctx.Err()
+	case <-s.closer.CloseNotify():
+		s.waits.Trigger(cmd.ID, nil)
+		return nil, ErrClosed
+	}
+}
+
+func (s *raftKV) Get(_ context.Context, key string) (*mvccpb.KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return nil, ErrClosed
+	}
+	return s.kvs[key], nil
+}
+
+func (s *raftKV) List(_ context.Context, prefix string) ([]*mvccpb.KeyValue, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return nil, 0, ErrClosed
+	}
+	return s.list(prefix), s.revision, nil
+}
+
+func (s *raftKV) Put(ctx context.Context, key string, val []byte, lease int64) (int64, error) {
+	result, err := s.propose(ctx, &raftCommand{Op: raftOpPut, Key: key, Value: val, Lease: lease})
+	if err != nil {
+		return 0, err
+	}
+	return result.revision, nil
+}
+
+func (s *raftKV) PutIf(ctx context.Context, key string, val []byte, modRevision, lease int64, others ...*mvccpb.KeyValue) (int64, bool, error) {
+	result, err := s.propose(ctx, &raftCommand{
+		Op: raftOpPutIf, Key: key, Value: val, ModRevision: modRevision, Lease: lease, Others: others,
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	if !result.ok {
+		return 0, false, nil
+	}
+	return result.revision, true, nil
+}
+
+func (s *raftKV) Delete(ctx context.Context, key string) (bool, error) {
+	result, err := s.propose(ctx, &raftCommand{Op: raftOpDelete, Key: key})
+	if err != nil {
+		return false, err
+	}
+	return result.ok, nil
+}
+
+func (s *raftKV) DeletePrefixes(ctx context.Context, prefixes ...string) error {
+	_, err := s.propose(ctx, &raftCommand{Op: raftOpDeletePrefixes, Prefixes: prefixes})
+	return err
+}
+
+func (s *raftKV) Grant(ctx context.Context, ttl time.Duration) (int64, error) {
+	result, err := s.propose(ctx, &raftCommand{Op: raftOpGrant, TTL: ttl})
+	if err != nil {
+		return 0, err
+	}
+	return result.lease, nil
+}
+
+// KeepAlive refreshes the lease through the group a few times in a ttl.
+// The lease is lost once it is revoked, or it can't be refreshed within a ttl.
+func (s *raftKV) KeepAlive(ctx context.Context, lease int64) (<-chan struct{}, error) {
+	s.mu.Lock()
+	l, ok := s.leases[lease]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errLeaseNotFound
+	}
+	if !s.closer.AddRunning() {
+		return nil, ErrClosed
+	}
+	lost := make(chan struct{})
+	go func() {
+		defer s.closer.Done()
+		defer close(lost)
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		refreshed := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-l.lost:
+				return
+			case <-s.closer.CloseNotify():
+				return
+			case now := <-ticker.C:
+				refreshCtx, cancel := context.WithTimeout(ctx, l.ttl/3)
+				_, err := s.propose(refreshCtx, &raftCommand{Op: raftOpKeepAlive, Lease: lease})
+				cancel()
+				if err == nil {
+					refreshed = now
+					continue
+				}
+				if errors.Is(err, errLeaseNotFound) || time.Since(refreshed) > l.ttl {
+					s.l.Warn().Err(err).Int64("lease", lease).Msg("the lease is lost")
+					return
+				}
+			}
+		}
+	}()
+	return lost, nil
+}
+
+func (s *raftKV) Revoke(ctx context.Context, lease int64) error {
+	_, err := s.propose(ctx, &raftCommand{Op: raftOpRevoke, Lease: lease})
+	return err
+}
+
+func (s *raftKV) expireLeases() {
+	defer s.closer.Done()
+	ticker := time.NewTicker(raftLeaseCheckTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closer.CloseNotify():
+			return
+		case now := <-ticker.C:
+			if !s.leading.Load() {
+				continue
+			}
+			var expired []int64
+			s.mu.Lock()
+			for id, l := range s.leases {
+				if now.After(l.expireAt) {
+					expired = append(expired, id)
+				}
+			}
+			s.mu.Unlock()
+			for _, id := range expired {
+				if err := s.Revoke(context.Background(), id); err != nil && !errors.Is(err, errLeaseNotFound) {
+					s.l.Error().Err(err).Int64("lease", id).Msg("failed to revoke the expired lease")
+				}
+			}
+		}
+	}
+}
+
+func (s *raftKV) Watch(ctx context.Context, prefix string, revision int64) <-chan watchResponse {
+	ch := make(chan watchResponse, kvWatchBufferSize)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		close(ch)
+		return ch
+	}
+	w := s.watch(ch, prefix, revision)
+	if w == nil {
+		return ch
+	}
+	if s.closer.AddRunning() {
+		go func() {
+			defer s.closer.Done()
+			select {
+			case <-ctx.Done():
+			case <-s.closer.CloseNotify():
+			}
+			s.mu.Lock()
+			s.removeWatch(w)
+			s.mu.Unlock()
+		}()
+	}
+	return ch
+}
+
+// Compact discards the history of the local member only.
+func (s *raftKV) Compact(_ context.Context, revision int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer.Closed() {
+		return ErrClosed
+	}
+	return s.compact(revision)
+}
+
+func (s *raftKV) Close() error {
+	s.closer.Done()
+	s.closer.CloseThenWait()
+	s.node.Stop()
+	s.transport.Stop()
+	err := s.server.Close()
+	s.mu.Lock()
+	s.closeWatches()
+	s.mu.Unlock()
+	return multierr.Append(err, s.wal.Close())
+}
+
+// Process implements rafthttp.Raft.
+func (s *raftKV) Process(ctx context.Context, m raftpb.Message) error {
+	return s.node.Step(ctx, m)
+}
+
+// IsIDRemoved implements rafthttp.Raft.
+func (s *raftKV) IsIDRemoved(uint64) bool { return false }
+
+// ReportUnreachable implements rafthttp.Raft.
+func (s *raftKV) ReportUnreachable(id uint64) { s.node.ReportUnreachable(id) }
+
+// ReportSnapshot implements rafthttp.Raft.
+func (s *raftKV) ReportSnapshot(id uint64, status raft.SnapshotStatus) {
+	s.node.ReportSnapshot(id, status)
+}
@@ -0,0 +1,141 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+func openTestRaftKVs(t *testing.T, root string, members int) (map[uint64]string, map[uint64]*raftKV) {
+	ports, err := test.AllocateFreePorts(members)
+	require.NoError(t, err)
+	peers := make(map[uint64]string, members)
+	for i, port := range ports {
+		peers[uint64(i+1)] = fmt.Sprintf("http://127.0.0.1:%d", port)
+	}
+	kvs := make(map[uint64]*raftKV, members)
+	for id := range peers {
+		kvs[id] = openTestRaftKV(t, root, id, peers)
+	}
+	return peers, kvs
+}
+
+func openTestRaftKV(t *testing.T, root string, id uint64, peers map[uint64]string) *raftKV {
+	kv, err := openRaftKV(filepath.Join(root, fmt.Sprint(id)), id, peers, logger.GetLogger("test-raft"))
+	require.NoError(t, err)
+	return kv
+}
+
+func TestRaftKVLeaseExpiry(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	_, kvs := openTestRaftKVs(t, root, 3)
+	defer func() {
+		_ = kvs[1].Close()
+		_ = kvs[2].Close()
+	}()
+
+	ctx := context.Background()
+	lease, err := kvs[3].Grant(ctx, 2*time.Second)
+	req.NoError(err)
+	_, err = kvs[3].Put(ctx, "/node/3", []byte("3"), lease)
+	req.NoError(err)
+	lost, err := kvs[3].KeepAlive(ctx, lease)
+	req.NoError(err)
+	assert.Never(t, func() bool {
+		select {
+		case <-lost:
+			return true
+		default:
+			return false
+		}
+	}, 3*time.Second, 100*time.Millisecond)
+
+	// The member stops without revoking the lease, so the leader revokes it once it expires.
+	req.NoError(kvs[3].Close())
+	assert.Eventually(t, func() bool {
+		kv, getErr := kvs[1].Get(ctx, "/node/3")
+		return getErr == nil && kv == nil
+	}, flags.EventuallyTimeout, 100*time.Millisecond)
+	_, err = kvs[1].Put(ctx, "/node/3", []byte("3"), lease)
+	req.ErrorIs(err, errLeaseNotFound)
+}
+
+func TestRaftKVSnapshot(t *testing.T) {
+	defer func(count, catchUp uint64) {
+		raftSnapshotCount, raftSnapshotCatchUp = count, catchUp
+	}(raftSnapshotCount, raftSnapshotCatchUp)
+	raftSnapshotCount, raftSnapshotCatchUp = 10, 5
+
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	peers, kvs := openTestRaftKVs(t, root, 3)
+	defer func() {
+		for _, kv := range kvs {
+			_ = kv.Close()
+		}
+	}()
+	ctx := context.Background()
+	_, err := kvs[1].Put(ctx, "/key/0", []byte("0"), 0)
+	req.NoError(err)
+	req.NoError(kvs[3].Close())
+	delete(kvs, 3)
+
+	// The log is compacted beyond the entries the stopped member has.
+	for i := 1; i < 50; i++ {
+		_, err = kvs[1].Put(ctx, fmt.Sprintf("/key/%d", i), []byte(fmt.Sprint(i)), 0)
+		req.NoError(err)
+	}
+	revision := kvs[1].revisionOf(t)
+
+	kvs[3] = openTestRaftKV(t, root, 3, peers)
+	assert.Eventually(t, func() bool {
+		return kvs[3].revisionOf(t) == revision
+	}, flags.EventuallyTimeout, 100*time.Millisecond)
+	list, _, err := kvs[3].List(ctx, "/key/")
+	req.NoError(err)
+	req.Len(list, 50)
+
+	// The member restarts from its own snapshot.
+	req.NoError(kvs[1].Close())
+	kvs[1] = openTestRaftKV(t, root, 1, peers)
+	assert.Eventually(t, func() bool {
+		return kvs[1].revisionOf(t) == revision
+	}, flags.EventuallyTimeout, 100*time.Millisecond)
+}
+
+func (s *raftKV) revisionOf(t *testing.T) int64 {
+	_, revision, err := s.List(context.Background(), "/")
+	require.NoError(t, err)
+	return revision
+}
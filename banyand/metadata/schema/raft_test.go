@@ -0,0 +1,182 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+type raftGroup struct {
+	peers      map[uint64]string
+	root       string
+	registries map[uint64]schema.Registry
+}
+
+func newRaftGroup(t *testing.T, root string, members int) *raftGroup {
+	ports, err := test.AllocateFreePorts(members)
+	require.NoError(t, err)
+	g := &raftGroup{
+		root:       root,
+		peers:      make(map[uint64]string, members),
+		registries: make(map[uint64]schema.Registry, members),
+	}
+	for i, port := range ports {
+		g.peers[uint64(i+1)] = fmt.Sprintf("http://127.0.0.1:%d", port)
+	}
+	for id := range g.peers {
+		g.open(t, id)
+	}
+	return g
+}
+
+func (g *raftGroup) open(t *testing.T, id uint64) schema.Registry {
+	registry, err := schema.NewRaftSchemaRegistry(
+		schema.Namespace("test"),
+		schema.ConfigureFileRoot(filepath.Join(g.root, fmt.Sprint(id))),
+		schema.ConfigureRaftPeers(id, g.peers),
+	)
+	require.NoError(t, err)
+	g.registries[id] = registry
+	return registry
+}
+
+func (g *raftGroup) close(t *testing.T, id uint64) {
+	if registry, ok := g.registries[id]; ok {
+		require.NoError(t, registry.Close())
+		delete(g.registries, id)
+	}
+}
+
+func (g *raftGroup) closeAll(t *testing.T) {
+	for id := range g.registries {
+		g.close(t, id)
+	}
+}
+
+func TestRaftRegistry(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	g := newRaftGroup(t, root, 3)
+	defer g.closeAll(t)
+
+	handler := newMockedHandler()
+	watcher := g.registries[3].NewWatcher("test", schema.KindGroup, 0)
+	watcher.AddHandler(handler)
+	watcher.Start()
+	defer watcher.Close()
+
+	req.NoError(g.registries[1].CreateGroup(context.Background(), newTestGroup("replicated")))
+	err := g.registries[2].CreateGroup(context.Background(), newTestGroup("replicated"))
+	req.ErrorIs(err, schema.ErrGRPCAlreadyExists)
+	assert.Eventually(t, func() bool {
+		_, getErr := g.registries[3].GetGroup(context.Background(), "replicated")
+		return getErr == nil
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		_, ok := handler.Data()["replicated"]
+		return ok
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+
+	group, err := g.registries[2].GetGroup(context.Background(), "replicated")
+	req.NoError(err)
+	group.ResourceOpts.Ttl.Num++
+	req.NoError(g.registries[2].UpdateGroup(context.Background(), group))
+	updated, err := g.registries[2].GetGroup(context.Background(), "replicated")
+	req.NoError(err)
+	req.Greater(updated.Metadata.ModRevision, group.Metadata.ModRevision)
+
+	deleted, err := g.registries[3].DeleteGroup(context.Background(), "replicated")
+	req.NoError(err)
+	req.True(deleted)
+	assert.Eventually(t, func() bool {
+		_, getErr := g.registries[1].GetGroup(context.Background(), "replicated")
+		return handler.deleteCalledNum.Load() == 1 && getErr != nil
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+}
+
+func TestRaftRegistryRestart(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	g := newRaftGroup(t, root, 3)
+	defer g.closeAll(t)
+	req.NoError(preloadSchema(g.registries[1]))
+	g.closeAll(t)
+
+	// A quorum of the members restarts from their logs.
+	g.open(t, 1)
+	g.open(t, 2)
+	assert.Eventually(t, func() bool {
+		_, err := g.registries[2].GetStream(context.Background(), &commonv1.Metadata{Name: "sw", Group: "default"})
+		return err == nil
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+	req.NoError(g.registries[2].CreateGroup(context.Background(), newTestGroup("another")))
+
+	// The lagging member catches up once it is back.
+	g.open(t, 3)
+	assert.Eventually(t, func() bool {
+		_, err := g.registries[3].GetGroup(context.Background(), "another")
+		return err == nil
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+}
+
+func TestRaftRegistryNodeLease(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+
+	g := newRaftGroup(t, root, 3)
+	defer g.closeAll(t)
+
+	node := &databasev1.Node{Metadata: &commonv1.Metadata{Name: "data-3"}, Roles: []databasev1.Role{databasev1.Role_ROLE_DATA}}
+	req.NoError(g.registries[3].RegisterNode(context.Background(), node, false))
+	req.ErrorIs(g.registries[1].RegisterNode(context.Background(), node, false), schema.ErrGRPCAlreadyExists)
+	assert.Eventually(t, func() bool {
+		nodes, err := g.registries[1].ListNode(context.Background(), databasev1.Role_ROLE_DATA)
+		return err == nil && len(nodes) == 1
+	}, flags.EventuallyTimeout, 10*time.Millisecond)
+
+	// The lease is kept alive beyond its ttl.
+	time.Sleep(6 * time.Second)
+	nodes, err := g.registries[1].ListNode(context.Background(), databasev1.Role_ROLE_DATA)
+	req.NoError(err)
+	req.Len(nodes, 1)
+
+	// The node revokes its lease when it stops.
+	g.close(t, 3)
+	assert.Eventually(t, func() bool {
+		nodes, err = g.registries[1].ListNode(context.Background(), databasev1.Role_ROLE_DATA)
+		return err == nil && len(nodes) == 0
+	}, 3*flags.EventuallyTimeout, 100*time.Millisecond)
+}
@@ -0,0 +1,507 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const (
+	minCheckInterval     = time.Second * 5
+	defaultCheckInterval = time.Minute * 10
+)
+
+var (
+	_ Stream           = (*schemaRegistry)(nil)
+	_ IndexRuleBinding = (*schemaRegistry)(nil)
+	_ IndexRule        = (*schemaRegistry)(nil)
+	_ Measure          = (*schemaRegistry)(nil)
+	_ Group            = (*schemaRegistry)(nil)
+
+	errUnexpectedNumberOfEntities = errors.New("unexpected number of entities")
+	errConcurrentModification     = errors.New("concurrent modification of entities")
+)
+
+// HasMetadata allows getting Metadata.
+type HasMetadata interface {
+	GetMetadata() *commonv1.Metadata
+	proto.Message
+}
+
+// RegistryOption is the option to create Registry.
+type RegistryOption func(*registryConfig)
+
+// Namespace sets the namespace of the registry.
+func Namespace(namespace string) RegistryOption {
+	return func(config *registryConfig) {
+		config.namespace = namespace
+	}
+}
+
+// ConfigureWatchCheckInterval sets the interval to check the watcher.
+func ConfigureWatchCheckInterval(d time.Duration) RegistryOption {
+	return func(config *registryConfig) {
+		if d >= minCheckInterval {
+			config.checkInterval = d
+		}
+	}
+}
+
+// CheckInterval sets the interval to check the watcher.
+func CheckInterval(d time.Duration) WatcherOption {
+	return func(wc *watcherConfig) {
+		if d >= minCheckInterval {
+			wc.checkInterval = d
+		}
+	}
+}
+
+type schemaRegistry struct {
+	kv            kvStore
+	closer        *run.Closer
+	l             *logger.Logger
+	watchers      map[Kind]*watcher
+	namespace     string
+	checkInterval time.Duration
+	historyLimit  int
+	mux           sync.RWMutex
+}
+
+type registryConfig struct {
+	namespace       string
+	username        string
+	password        string
+	tlsCAFile       string
+	tlsCertFile     string
+	tlsKeyFile      string
+	root            string
+	serverEndpoints []string
+	raftPeers       map[uint64]string
+	checkInterval   time.Duration
+	raftID          uint64
+	historyLimit    int
+}
+
+func newSchemaRegistry(kv kvStore, config *registryConfig) *schemaRegistry {
+	return &schemaRegistry{
+		namespace:     config.namespace,
+		kv:            kv,
+		closer:        run.NewCloser(1),
+		l:             logger.GetLogger("schema-registry"),
+		checkInterval: config.checkInterval,
+		historyLimit:  config.historyLimit,
+		watchers:      make(map[Kind]*watcher),
+	}
+}
+
+func (e *schemaRegistry) RegisterHandler(name string, kind Kind, handler EventHandler) {
+	// Validate kind
+	if kind&KindMask != kind {
+		panic(fmt.Sprintf("invalid kind %d", kind))
+	}
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	var kinds []Kind
+	for i := 0; i < KindSize; i++ {
+		ki := Kind(1 << i)
+		if kind&ki > 0 {
+			kinds = append(kinds, ki)
+		}
+	}
+	e.l.Info().Str("name", name).Interface("kinds", kinds).Msg("initializing schema cache")
+	ok, revisions := handler.OnInit(kinds)
+	if ok {
+		if len(revisions) != len(kinds) {
+			logger.Panicf("invalid number of revisions for %s", name)
+			return
+		}
+		for i := range kinds {
+			e.registerToWatcher(name, kinds[i], revisions[i], handler)
+		}
+		return
+	}
+	for i := range kinds {
+		e.registerToWatcher(name, kinds[i], -1, handler)
+	}
+}
+
+func (e *schemaRegistry) registerToWatcher(name string, kind Kind, revision int64, handler EventHandler) {
+	if w, ok := e.watchers[kind]; ok {
+		e.l.Info().Str("name", name).Stringer("kind", kind).Msg("registering to an existing watcher")
+		w.AddHandler(handler)
+		if w.revision > revision {
+			w.revision = revision
+		}
+		return
+	}
+	e.l.Info().Str("name", name).Stringer("kind", kind).Msg("registering to a new watcher")
+	w := e.NewWatcher(name, kind, revision, CheckInterval(e.checkInterval))
+	w.AddHandler(handler)
+	e.watchers[kind] = w
+}
+
+func (e *schemaRegistry) Compact(ctx context.Context, revision int64) error {
+	if !e.closer.AddRunning() {
+		return ErrClosed
+	}
+	defer e.closer.Done()
+	return e.kv.Compact(ctx, revision)
+}
+
+func (e *schemaRegistry) StartWatcher() {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+	for _, w := range e.watchers {
+		w.Start()
+	}
+}
+
+func (e *schemaRegistry) Close() error {
+	e.closer.Done()
+	e.closer.CloseThenWait()
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+	for i := range e.watchers {
+		e.watchers[i].Close()
+	}
+	return e.kv.Close()
+}
+
+func (e *schemaRegistry) prependNamespace(key string) string {
+	if e.namespace == "" {
+		return key
+	}
+	return path.Join("/", e.namespace, key)
+}
+
+func (e *schemaRegistry) get(ctx context.Context, key string, message proto.Message) error {
+	if !e.closer.AddRunning() {
+		return ErrClosed
+	}
+	defer e.closer.Done()
+	key = e.prependNamespace(key)
+	kv, err := e.kv.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if kv == nil {
+		return ErrGRPCResourceNotFound
+	}
+	if err = proto.Unmarshal(kv.Value, message); err != nil {
+		return err
+	}
+	if messageWithMetadata, ok := message.(HasMetadata); ok {
+		// Assign readonly fields
+		messageWithMetadata.GetMetadata().CreateRevision = kv.CreateRevision
+		messageWithMetadata.GetMetadata().ModRevision = kv.ModRevision
+	}
+	return nil
+}
+
+// update will first ensure the existence of the entity with the metadata,
+// and overwrite the existing value if so.
+// Otherwise, it will return ErrGRPCResourceNotFound.
+func (e *schemaRegistry) update(ctx context.Context, metadata Metadata) (int64, error) {
+	if !e.closer.AddRunning() {
+		return 0, ErrClosed
+	}
+	defer e.closer.Done()
+	rawKey, err := metadata.key()
+	if err != nil {
+		return 0, err
+	}
+	key := e.prependNamespace(rawKey)
+	kv, err := e.kv.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	val, err := proto.Marshal(metadata.Spec.(proto.Message))
+	if err != nil {
+		return 0, err
+	}
+	if kv == nil {
+		return 0, ErrGRPCResourceNotFound
+	}
+	existingVal, innerErr := metadata.Kind.Unmarshal(kv)
+	if innerErr != nil {
+		return 0, innerErr
+	}
+	// directly return if we have the same entity
+	if metadata.equal(existingVal) {
+		return 0, nil
+	}
+
+	modRevision := metadata.ModRevision
+	if modRevision == 0 {
+		modRevision = kv.ModRevision
+	}
+	history := e.historyRecord(metadata, rawKey, kv)
+	// Keep the lease of the key, otherwise the key of a node outlives the node.
+	revision, succeeded, err := e.kv.PutIf(ctx, key, val, modRevision, kv.Lease, history...)
+	if err != nil {
+		return 0, err
+	}
+	if !succeeded {
+		return 0, errConcurrentModification
+	}
+	if len(history) > 0 {
+		if err = e.trimHistory(ctx, rawKey); err != nil {
+			e.l.Warn().Err(err).Str("key", key).Msg("failed to trim the history")
+		}
+	}
+	return revision, nil
+}
+
+// create will put the value if the entity with the metadata does not exist.
+// Otherwise, it will return ErrGRPCAlreadyExists.
+func (e *schemaRegistry) create(ctx context.Context, metadata Metadata) (int64, error) {
+	if !e.closer.AddRunning() {
+		return 0, ErrClosed
+	}
+	defer e.closer.Done()
+	key, err := metadata.key()
+	if err != nil {
+		return 0, err
+	}
+	key = e.prependNamespace(key)
+	val, err := proto.Marshal(metadata.Spec.(proto.Message))
+	if err != nil {
+		return 0, err
+	}
+	revision, succeeded, err := e.kv.PutIf(ctx, key, val, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	if !succeeded {
+		return 0, ErrGRPCAlreadyExists
+	}
+	return revision, nil
+}
+
+func (e *schemaRegistry) listWithPrefix(ctx context.Context, prefix string, kind Kind) ([]proto.Message, error) {
+	if !e.closer.AddRunning() {
+		return nil, ErrClosed
+	}
+	defer e.closer.Done()
+	prefix = e.prependNamespace(prefix)
+	kvs, _, err := e.kv.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]proto.Message, len(kvs))
+	for i := range kvs {
+		md, err := kind.Unmarshal(kvs[i])
+		if err != nil {
+			return nil, err
+		}
+		entities[i] = md.Spec.(proto.Message)
+	}
+	return entities, nil
+}
+
+func (e *schemaRegistry) delete(ctx context.Context, metadata Metadata) (bool, error) {
+	if !e.closer.AddRunning() {
+		return false, ErrClosed
+	}
+	defer e.closer.Done()
+	key, err := metadata.key()
+	if err != nil {
+		return false, err
+	}
+	deleted, err := e.kv.Delete(ctx, e.prependNamespace(key))
+	if err != nil || !metadata.Kind.hasHistory() {
+		return deleted, err
+	}
+	return deleted, e.kv.DeletePrefixes(ctx, e.prependNamespace(historyPrefix(key)))
+}
+
+func (e *schemaRegistry) deletePrefixes(ctx context.Context, prefixes ...string) error {
+	if !e.closer.AddRunning() {
+		return ErrClosed
+	}
+	defer e.closer.Done()
+	for i := range prefixes {
+		prefixes[i] = e.prependNamespace(prefixes[i])
+	}
+	return e.kv.DeletePrefixes(ctx, prefixes...)
+}
+
+const leaseDuration = 5 * time.Second
+
+func (e *schemaRegistry) Register(ctx context.Context, metadata Metadata, forced bool) error {
+	if !e.closer.AddRunning() {
+		return ErrClosed
+	}
+	defer e.closer.Done()
+
+	key, err := e.prepareKey(metadata)
+	if err != nil {
+		return err
+	}
+
+	val, err := proto.Marshal(metadata.Spec.(proto.Message))
+	if err != nil {
+		return err
+	}
+
+	lease, err := e.kv.Grant(ctx, leaseDuration)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to grant lease for key %s", key)
+	}
+
+	if err := e.putKeyVal(ctx, key, val, lease, forced); err != nil {
+		return err
+	}
+
+	//nolint:contextcheck
+	if err := e.keepLeaseAlive(lease, key, val); err != nil {
+		return fmt.Errorf("failed to keep lease alive for key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (e *schemaRegistry) prepareKey(metadata Metadata) (string, error) {
+	key, err := metadata.key()
+	if err != nil {
+		return "", err
+	}
+	return e.prependNamespace(key), nil
+}
+
+func (e *schemaRegistry) putKeyVal(ctx context.Context, key string, val []byte, lease int64, forced bool) error {
+	if forced {
+		if _, err := e.kv.Put(ctx, key, val, lease); err != nil {
+			return fmt.Errorf("failed to forcefully put key-value pair for key %s: %w", key, err)
+		}
+		return nil
+	}
+	_, succeeded, err := e.kv.PutIf(ctx, key, val, 0, lease)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction for key %s: %w", key, err)
+	}
+	if !succeeded {
+		return errors.Wrapf(ErrGRPCAlreadyExists, "key %s", key)
+	}
+	return nil
+}
+
+func (e *schemaRegistry) keepLeaseAlive(lease int64, key string, val []byte) error {
+	lost, err := e.kv.KeepAlive(context.Background(), lease)
+	if err != nil {
+		return fmt.Errorf("failed to keep lease alive for key %s: %w", key, err)
+	}
+
+	go func() {
+		if !e.closer.AddRunning() {
+			return
+		}
+		defer func() {
+			e.revokeLease(lease)
+			e.closer.Done()
+		}()
+
+		for {
+			select {
+			case <-e.closer.CloseNotify():
+				return
+			case <-lost:
+				lease, lost = e.revokeAndReconnectLease(lease, key, val)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (e *schemaRegistry) revokeAndReconnectLease(lease int64, key string, val []byte) (int64, <-chan struct{}) {
+	for {
+		e.revokeLease(lease)
+		select {
+		case <-e.closer.CloseNotify():
+			return 0, nil
+		default:
+			var err error
+			lease, err = e.kv.Grant(context.Background(), leaseDuration)
+			if err != nil {
+				e.l.Error().Err(err).Msg("failed to grant lease")
+				time.Sleep(leaseDuration)
+				continue
+			}
+			_, err = e.kv.Put(context.Background(), key, val, lease)
+			if err != nil {
+				e.l.Error().Err(err).Msg("failed to put key-value pair")
+				time.Sleep(leaseDuration)
+				continue
+			}
+			lost, err := e.kv.KeepAlive(context.Background(), lease)
+			if err != nil {
+				e.l.Error().Err(err).Msg("failed to keep alive")
+				time.Sleep(leaseDuration)
+			} else {
+				return lease, lost
+			}
+		}
+	}
+}
+
+func (e *schemaRegistry) revokeLease(lease int64) {
+	if lease == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), leaseDuration)
+	defer cancel()
+	err := e.kv.Revoke(ctx, lease)
+	if err != nil && e.l.Debug().Enabled() {
+		e.l.Debug().Err(err).Msgf("failed to revoke lease %d", lease)
+	}
+}
+
+func (e *schemaRegistry) NewWatcher(name string, kind Kind, revision int64, opts ...WatcherOption) *watcher {
+	wc := watcherConfig{
+		key:           e.prependNamespace(kind.key()),
+		kind:          kind,
+		revision:      revision,
+		checkInterval: 5 * time.Minute, // Default value
+	}
+	for _, opt := range opts {
+		opt(&wc)
+	}
+	return newWatcher(e.kv, wc, e.l.Named(fmt.Sprintf("watcher-%s[%s]", name, kind.String())))
+}
+
+func listPrefixesForEntity(group, entityPrefix string) string {
+	return path.Join(entityPrefix, group)
+}
+
+func formatKey(entityPrefix string, metadata *commonv1.Metadata) string {
+	return path.Join(
+		listPrefixesForEntity(metadata.GetGroup(), entityPrefix),
+		metadata.GetName())
+}
@@ -65,6 +65,8 @@ type Registry interface {
 	TopNAggregation
 	Node
 	Property
+	History
+	Shard
 	RegisterHandler(string, Kind, EventHandler)
 	NewWatcher(string, Kind, int64, ...WatcherOption) *watcher
 	Register(context.Context, Metadata, bool) error
@@ -126,6 +128,11 @@ func (m Metadata) key() (string, error) {
 			Group: m.Group,
 			Name:  m.Name,
 		}), nil
+	case KindShard:
+		return formatShardKey(&commonv1.Metadata{
+			Group: m.Group,
+			Name:  m.Name,
+		}), nil
 	default:
 		return "", errUnsupportedEntityType
 	}
@@ -149,6 +156,8 @@ type Stream interface {
 	ListStream(ctx context.Context, opt ListOpt) ([]*databasev1.Stream, error)
 	CreateStream(ctx context.Context, stream *databasev1.Stream) (int64, error)
 	UpdateStream(ctx context.Context, stream *databasev1.Stream) (int64, error)
+	// CheckStreamCompatibility returns the reasons why the stream can't be updated to the proposed one.
+	CheckStreamCompatibility(ctx context.Context, stream *databasev1.Stream) ([]string, error)
 	DeleteStream(ctx context.Context, metadata *commonv1.Metadata) (bool, error)
 }
 
@@ -176,6 +185,8 @@ type Measure interface {
 	ListMeasure(ctx context.Context, opt ListOpt) ([]*databasev1.Measure, error)
 	CreateMeasure(ctx context.Context, measure *databasev1.Measure) (int64, error)
 	UpdateMeasure(ctx context.Context, measure *databasev1.Measure) (int64, error)
+	// CheckMeasureCompatibility returns the reasons why the measure can't be updated to the proposed one.
+	CheckMeasureCompatibility(ctx context.Context, measure *databasev1.Measure) ([]string, error)
 	DeleteMeasure(ctx context.Context, metadata *commonv1.Metadata) (bool, error)
 	TopNAggregations(ctx context.Context, metadata *commonv1.Metadata) ([]*databasev1.TopNAggregation, error)
 }
@@ -205,6 +216,8 @@ type Node interface {
 	RegisterNode(ctx context.Context, node *databasev1.Node, forced bool) error
 	GetNode(ctx context.Context, node string) (*databasev1.Node, error)
 	UpdateNode(ctx context.Context, node *databasev1.Node) error
+	// DeleteNode removes the node from the registry. The node is registered again once it reconnects to the registry.
+	DeleteNode(ctx context.Context, node string) (bool, error)
 }
 
 // Property allows CRUD property schemas in a group.
@@ -215,3 +228,21 @@ type Property interface {
 	UpdateProperty(ctx context.Context, property *databasev1.Property) error
 	DeleteProperty(ctx context.Context, metadata *commonv1.Metadata) (bool, error)
 }
+
+// Shard allows persisting the placements of the shards on the data nodes.
+type Shard interface {
+	// ListShard returns the placements of the shards in the group, or all the placements if the group is empty.
+	ListShard(ctx context.Context, opt ListOpt) ([]*databasev1.Shard, error)
+	CreateShard(ctx context.Context, shard *databasev1.Shard) error
+	UpdateShard(ctx context.Context, shard *databasev1.Shard) error
+}
+
+// History allows reading and restoring the previous revisions of the schemas.
+type History interface {
+	// ListRevisions returns the current and the previous revisions of the schema, from the newest to the oldest.
+	ListRevisions(ctx context.Context, kind Kind, metadata *commonv1.Metadata) ([]Metadata, error)
+	// GetRevision returns the schema at the revision.
+	GetRevision(ctx context.Context, kind Kind, metadata *commonv1.Metadata, modRevision int64) (Metadata, error)
+	// Rollback updates the schema to its definition at the revision, and returns the new revision.
+	Rollback(ctx context.Context, kind Kind, metadata *commonv1.Metadata, modRevision int64) (int64, error)
+}
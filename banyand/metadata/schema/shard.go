@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/api/validate"
+)
+
+var shardKeyPrefix = "/shards/"
+
+// ShardName returns the name of the placement of the shard in its group.
+func ShardName(id uint32) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func (e *schemaRegistry) ListShard(ctx context.Context, opt ListOpt) ([]*databasev1.Shard, error) {
+	prefix := shardKeyPrefix
+	if opt.Group != "" {
+		prefix = listPrefixesForEntity(opt.Group, shardKeyPrefix)
+	}
+	messages, err := e.listWithPrefix(ctx, prefix, KindShard)
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]*databasev1.Shard, 0, len(messages))
+	for _, message := range messages {
+		entities = append(entities, message.(*databasev1.Shard))
+	}
+	return entities, nil
+}
+
+func (e *schemaRegistry) CreateShard(ctx context.Context, shard *databasev1.Shard) error {
+	if err := validate.Shard(shard); err != nil {
+		return err
+	}
+	now := timestamppb.Now()
+	shard.CreatedAt, shard.UpdatedAt = now, now
+	_, err := e.create(ctx, Metadata{
+		TypeMeta: TypeMeta{
+			Kind:  KindShard,
+			Group: shard.GetMetadata().GetGroup(),
+			Name:  shard.GetMetadata().GetName(),
+		},
+		Spec: shard,
+	})
+	return err
+}
+
+func (e *schemaRegistry) UpdateShard(ctx context.Context, shard *databasev1.Shard) error {
+	if err := validate.Shard(shard); err != nil {
+		return err
+	}
+	shard.UpdatedAt = timestamppb.Now()
+	_, err := e.update(ctx, Metadata{
+		TypeMeta: TypeMeta{
+			Kind:        KindShard,
+			Group:       shard.GetMetadata().GetGroup(),
+			Name:        shard.GetMetadata().GetName(),
+			ModRevision: shard.GetMetadata().GetModRevision(),
+		},
+		Spec: shard,
+	})
+	return err
+}
+
+func formatShardKey(metadata *commonv1.Metadata) string {
+	return formatKey(shardKeyPrefix, metadata)
+}
@@ -0,0 +1,72 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func TestShard(t *testing.T) {
+	req := require.New(t)
+	root, defFn := test.Space(req)
+	defer defFn()
+	registry := openFileRegistry(t, root)
+	defer registry.Close()
+	ctx := context.Background()
+
+	req.NoError(registry.CreateGroup(ctx, newTestGroup("placed")))
+	req.NoError(registry.CreateGroup(ctx, newTestGroup("other")))
+	newShard := func(group string, node string) *databasev1.Shard {
+		return &databasev1.Shard{
+			Metadata: &commonv1.Metadata{Group: group, Name: schema.ShardName(0)},
+			Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+			Node:     node,
+			Total:    1,
+		}
+	}
+	req.NoError(registry.CreateShard(ctx, newShard("placed", "node1")))
+	req.NoError(registry.CreateShard(ctx, newShard("other", "node1")))
+	req.ErrorIs(registry.CreateShard(ctx, newShard("placed", "node2")), schema.ErrGRPCAlreadyExists)
+	invalid := newShard("placed", "node2")
+	invalid.Id = 1
+	req.Error(registry.CreateShard(ctx, invalid))
+
+	req.NoError(registry.UpdateShard(ctx, newShard("placed", "node2")))
+	shards, err := registry.ListShard(ctx, schema.ListOpt{Group: "placed"})
+	req.NoError(err)
+	req.Len(shards, 1)
+	req.Equal("node2", shards[0].Node)
+	shards, err = registry.ListShard(ctx, schema.ListOpt{})
+	req.NoError(err)
+	req.Len(shards, 2)
+
+	// The placements are removed with their group.
+	_, err = registry.DeleteGroup(ctx, "placed")
+	req.NoError(err)
+	shards, err = registry.ListShard(ctx, schema.ListOpt{})
+	req.NoError(err)
+	req.Len(shards, 1)
+}
@@ -19,7 +19,6 @@ package schema
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -31,7 +30,7 @@ import (
 
 var streamKeyPrefix = "/streams/"
 
-func (e *etcdSchemaRegistry) GetStream(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Stream, error) {
+func (e *schemaRegistry) GetStream(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Stream, error) {
 	var entity databasev1.Stream
 	if err := e.get(ctx, formatStreamKey(metadata), &entity); err != nil {
 		return nil, err
@@ -39,7 +38,7 @@ func (e *etcdSchemaRegistry) GetStream(ctx context.Context, metadata *commonv1.M
 	return &entity, nil
 }
 
-func (e *etcdSchemaRegistry) ListStream(ctx context.Context, opt ListOpt) ([]*databasev1.Stream, error) {
+func (e *schemaRegistry) ListStream(ctx context.Context, opt ListOpt) ([]*databasev1.Stream, error) {
 	if opt.Group == "" {
 		return nil, BadRequest("group", "group should not be empty")
 	}
@@ -54,7 +53,7 @@ func (e *etcdSchemaRegistry) ListStream(ctx context.Context, opt ListOpt) ([]*da
 	return entities, nil
 }
 
-func (e *etcdSchemaRegistry) UpdateStream(ctx context.Context, stream *databasev1.Stream) (int64, error) {
+func (e *schemaRegistry) UpdateStream(ctx context.Context, stream *databasev1.Stream) (int64, error) {
 	if stream.UpdatedAt != nil {
 		stream.UpdatedAt = timestamppb.Now()
 	}
@@ -76,8 +75,12 @@ func (e *etcdSchemaRegistry) UpdateStream(ctx context.Context, stream *databasev
 	if prev == nil {
 		return 0, errors.WithMessagef(ErrGRPCResourceNotFound, "stream %s not found", stream.GetMetadata().GetName())
 	}
-	if err := validateEqualExceptAppendTags(prev, stream); err != nil {
-		return 0, errors.WithMessagef(ErrInputInvalid, "validation failed: %s", err)
+	indexedTags, err := e.indexedTags(ctx, stream.GetMetadata(), commonv1.Catalog_CATALOG_STREAM)
+	if err != nil {
+		return 0, err
+	}
+	if err = incompatibilityError(streamIncompatibilities(prev, stream, indexedTags)); err != nil {
+		return 0, err
 	}
 	return e.update(ctx, Metadata{
 		TypeMeta: TypeMeta{
@@ -90,30 +93,7 @@ func (e *etcdSchemaRegistry) UpdateStream(ctx context.Context, stream *databasev
 	})
 }
 
-func validateEqualExceptAppendTags(prevStream, newStream *databasev1.Stream) error {
-	if prevStream.GetEntity().String() != newStream.GetEntity().String() {
-		return fmt.Errorf("entity is different: %s != %s", prevStream.GetEntity().String(), newStream.GetEntity().String())
-	}
-	if len(prevStream.GetTagFamilies()) > len(newStream.GetTagFamilies()) {
-		return fmt.Errorf("number of tag families is less in the new stream")
-	}
-	for i, tagFamily := range prevStream.GetTagFamilies() {
-		if tagFamily.Name != newStream.GetTagFamilies()[i].Name {
-			return fmt.Errorf("tag family name is different: %s != %s", tagFamily.Name, newStream.GetTagFamilies()[i].Name)
-		}
-		if len(tagFamily.Tags) > len(newStream.GetTagFamilies()[i].Tags) {
-			return fmt.Errorf("number of tags in tag family %s is less in the new stream", tagFamily.Name)
-		}
-		for j, tag := range tagFamily.Tags {
-			if tag.String() != newStream.GetTagFamilies()[i].Tags[j].String() {
-				return fmt.Errorf("tag %s in tag family %s is different: %s != %s", tag.Name, tagFamily.Name, tag.String(), newStream.GetTagFamilies()[i].Tags[j].String())
-			}
-		}
-	}
-	return nil
-}
-
-func (e *etcdSchemaRegistry) CreateStream(ctx context.Context, stream *databasev1.Stream) (int64, error) {
+func (e *schemaRegistry) CreateStream(ctx context.Context, stream *databasev1.Stream) (int64, error) {
 	if stream.UpdatedAt != nil {
 		stream.UpdatedAt = timestamppb.Now()
 	}
@@ -138,7 +118,7 @@ func (e *etcdSchemaRegistry) CreateStream(ctx context.Context, stream *databasev
 	})
 }
 
-func (e *etcdSchemaRegistry) DeleteStream(ctx context.Context, metadata *commonv1.Metadata) (bool, error) {
+func (e *schemaRegistry) DeleteStream(ctx context.Context, metadata *commonv1.Metadata) (bool, error) {
 	return e.delete(ctx, Metadata{
 		TypeMeta: TypeMeta{
 			Kind:  KindStream,
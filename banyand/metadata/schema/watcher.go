@@ -24,8 +24,6 @@ import (
 
 	"github.com/pkg/errors"
 	mvccpb "go.etcd.io/etcd/api/v3/mvccpb"
-	v3rpc "go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
-	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -49,7 +47,7 @@ type cacheEntry struct {
 }
 
 type watcher struct {
-	cli           *clientv3.Client
+	kv            kvStore
 	closer        *run.Closer
 	l             *logger.Logger
 	ticker        *time.Ticker
@@ -63,12 +61,12 @@ type watcher struct {
 	startOnce     sync.Once
 }
 
-func newWatcher(cli *clientv3.Client, wc watcherConfig, l *logger.Logger) *watcher {
+func newWatcher(kv kvStore, wc watcherConfig, l *logger.Logger) *watcher {
 	if wc.checkInterval == 0 {
 		wc.checkInterval = 5 * time.Minute
 	}
 	w := &watcher{
-		cli:           cli,
+		kv:            kv,
 		key:           wc.key,
 		kind:          wc.kind,
 		revision:      wc.revision,
@@ -106,7 +104,6 @@ func (w *watcher) watch(revision int64) {
 		return
 	}
 	defer w.closer.Done()
-	cli := w.cli
 
 	w.ticker = time.NewTicker(w.checkInterval)
 	defer w.ticker.Stop()
@@ -126,11 +123,7 @@ OUTER:
 			continue
 		}
 
-		wch := cli.Watch(w.closer.Ctx(), w.key,
-			clientv3.WithPrefix(),
-			clientv3.WithRev(revision+1),
-			clientv3.WithPrevKV(),
-		)
+		wch := w.kv.Watch(w.closer.Ctx(), w.key, revision+1)
 		if wch == nil {
 			continue
 		}
@@ -152,23 +145,23 @@ OUTER:
 						continue OUTER
 					}
 				}
-				if err := watchResp.Err(); err != nil {
-					if errors.Is(err, v3rpc.ErrCompacted) {
+				if err := watchResp.err; err != nil {
+					if errors.Is(err, errCompacted) {
 						revision = -1
 						continue OUTER
 					}
 					continue
 				}
-				w.revision = watchResp.Header.Revision
-				for _, event := range watchResp.Events {
-					w.handle(event, &watchResp)
+				w.revision = watchResp.revision
+				for _, event := range watchResp.events {
+					w.handle(event, watchResp.revision)
 				}
 			}
 		}
 	}
 }
 
-func (w *watcher) handle(watchEvent *clientv3.Event, watchResp *clientv3.WatchResponse) {
+func (w *watcher) handle(watchEvent *mvccpb.Event, revision int64) {
 	keyStr := string(watchEvent.Kv.Key)
 	entry := cacheEntry{
 		valueHash:   convert.Hash(watchEvent.Kv.Value),
@@ -191,7 +184,7 @@ func (w *watcher) handle(watchEvent *clientv3.Event, watchResp *clientv3.WatchRe
 
 			md, err := w.kind.Unmarshal(watchEvent.Kv)
 			if err != nil {
-				w.l.Error().Stringer("event_header", &watchResp.Header).AnErr("err", err).Msg("failed to unmarshal message")
+				w.l.Error().Int64("revision", revision).AnErr("err", err).Msg("failed to unmarshal message")
 				return
 			}
 			for i := range handlers {
@@ -202,7 +195,7 @@ func (w *watcher) handle(watchEvent *clientv3.Event, watchResp *clientv3.WatchRe
 		delete(w.cache, keyStr)
 		md, err := w.kind.Unmarshal(watchEvent.PrevKv)
 		if err != nil {
-			w.l.Error().Stringer("event_header", &watchResp.Header).AnErr("err", err).Msg("failed to unmarshal message")
+			w.l.Error().Int64("revision", revision).AnErr("err", err).Msg("failed to unmarshal message")
 			return
 		}
 		for i := range handlers {
@@ -212,7 +205,7 @@ func (w *watcher) handle(watchEvent *clientv3.Event, watchResp *clientv3.WatchRe
 }
 
 func (w *watcher) periodicSync() {
-	resp, err := w.cli.Get(w.closer.Ctx(), w.key, clientv3.WithPrefix())
+	kvs, revision, err := w.kv.List(w.closer.Ctx(), w.key)
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
 			w.l.Error().Err(err).Msg("periodic sync failed to fetch keys")
@@ -220,8 +213,8 @@ func (w *watcher) periodicSync() {
 		return
 	}
 
-	currentState := make(map[string]cacheEntry, len(resp.Kvs))
-	for _, kv := range resp.Kvs {
+	currentState := make(map[string]cacheEntry, len(kvs))
+	for _, kv := range kvs {
 		currentState[string(kv.Key)] = cacheEntry{
 			valueHash:   convert.Hash(kv.Value),
 			modRevision: kv.ModRevision,
@@ -235,6 +228,8 @@ func (w *watcher) periodicSync() {
 	}
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	// The watching resumes from the revision the keys are listed at.
+	w.revision = revision
 
 	// Detect deletions and changes
 	for cachedKey, cachedEntry := range w.cache {
@@ -275,13 +270,13 @@ func (w *watcher) periodicSync() {
 }
 
 func (w *watcher) getFromStore(key string) (*Metadata, error) {
-	resp, err := w.cli.Get(w.closer.Ctx(), key)
+	kv, err := w.kv.Get(w.closer.Ctx(), key)
 	if err != nil {
 		return nil, err
 	}
-	if len(resp.Kvs) == 0 {
+	if kv == nil {
 		return nil, errors.New("key not found")
 	}
-	md, err := w.kind.Unmarshal(resp.Kvs[0])
+	md, err := w.kind.Unmarshal(kv)
 	return &md, err
 }
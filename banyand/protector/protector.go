@@ -37,6 +37,25 @@ import (
 
 var scope = observability.RootScope.SubScope("memory_protector")
 
+// Budget caps the memory reserved by a single query.
+type Budget interface {
+	Acquire(size uint64) error
+	Release(size uint64)
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a new context with the memory budget of the query.
+func WithBudget(ctx context.Context, b Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+// BudgetFromContext returns the memory budget from context.Context. It returns nil if there is no budget.
+func BudgetFromContext(ctx context.Context) Budget {
+	b, _ := ctx.Value(budgetKey{}).(Budget)
+	return b
+}
+
 // Memory is a protector that stops the query services when the memory usage exceeds the limit.
 type Memory struct {
 	omr            observability.MetricsRegistry
@@ -67,7 +86,24 @@ func NewMemory(omr observability.MetricsRegistry) *Memory {
 }
 
 // AcquireResource attempts to acquire a `size` amount of memory.
+// The memory is charged to the budget of the query carried by the context first,
+// which fails immediately if the query exceeds its budget.
 func (m *Memory) AcquireResource(ctx context.Context, size uint64) error {
+	budget := BudgetFromContext(ctx)
+	if budget == nil {
+		return m.acquire(ctx, size)
+	}
+	if err := budget.Acquire(size); err != nil {
+		return err
+	}
+	if err := m.acquire(ctx, size); err != nil {
+		budget.Release(size)
+		return err
+	}
+	return nil
+}
+
+func (m *Memory) acquire(ctx context.Context, size uint64) error {
 	if m.limit == 0 {
 		return nil
 	}
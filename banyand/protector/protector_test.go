@@ -0,0 +1,66 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errExceeded = errors.New("exceeded")
+
+type fakeBudget struct {
+	limit uint64
+	used  uint64
+}
+
+func (b *fakeBudget) Acquire(size uint64) error {
+	if b.used+size > b.limit {
+		return errExceeded
+	}
+	b.used += size
+	return nil
+}
+
+func (b *fakeBudget) Release(size uint64) {
+	b.used -= size
+}
+
+func TestAcquireResourceWithBudget(t *testing.T) {
+	m := &Memory{blockedChan: make(chan struct{}, 1), limit: 100}
+	require.NoError(t, m.AcquireResource(context.Background(), 10))
+
+	b := &fakeBudget{limit: 50}
+	ctx := WithBudget(context.Background(), b)
+	assert.Same(t, b, BudgetFromContext(ctx))
+	require.NoError(t, m.AcquireResource(ctx, 40))
+	assert.ErrorIs(t, m.AcquireResource(ctx, 20), errExceeded)
+	assert.Equal(t, uint64(40), b.used)
+
+	// The reservation is returned to the budget if the protector rejects it.
+	m.usage = 100
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m.AcquireResource(ctx, 10), context.DeadlineExceeded)
+	assert.Equal(t, uint64(40), b.used)
+}
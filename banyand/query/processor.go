@@ -24,11 +24,15 @@ import (
 	"runtime/debug"
 	"time"
 
+	"github.com/dustin/go-humanize"
+	"google.golang.org/protobuf/proto"
+
 	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -45,6 +49,7 @@ const (
 
 var (
 	_ run.PreRunner       = (*queryService)(nil)
+	_ run.Service         = (*queryService)(nil)
 	_ bus.MessageListener = (*streamQueryProcessor)(nil)
 	_ bus.MessageListener = (*measureQueryProcessor)(nil)
 	_ bus.MessageListener = (*topNQueryProcessor)(nil)
@@ -92,7 +97,12 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 		return
 	}
 
-	plan, err := logical_stream.Analyze(ctx, queryCriteria, meta, s)
+	token, err := logical_stream.ParseContinuationToken(queryCriteria.ContinuationToken, queryCriteria.OrderBy)
+	if err != nil {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to parse the continuation token for stream %s: %v", meta.GetName(), err))
+		return
+	}
+	plan, err := logical_stream.Analyze(ctx, queryCriteria, meta, s, token.Cursor(p.queryService.nodeID))
 	if err != nil {
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to analyze the query request for stream %s: %v", meta.GetName(), err))
 		return
@@ -101,20 +111,32 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 	if p.log.Debug().Enabled() {
 		p.log.Debug().Str("plan", plan.String()).Msg("query plan")
 	}
+	budget := executor.NewMemoryBudget(uint64(p.memoryBudget))
+	ctx = protector.WithBudget(ctx, budget)
+	stats := &executor.QueryStats{}
+	ctx = executor.WithQueryStats(ctx, stats)
 	var tracer *query.Tracer
 	var span *query.Span
-	if queryCriteria.Trace {
+	defer func() {
+		p.slowQueryLog.RecordQuery(commonv1.Catalog_CATALOG_STREAM, queryCriteria, n, stats, tracer, resp)
+	}()
+	if queryCriteria.Trace || p.slowQueryLog.Sampled() {
 		tracer, ctx = query.NewTracer(ctx, n.Format(time.RFC3339Nano))
 		span, ctx = tracer.StartSpan(ctx, "data-%s", p.queryService.nodeID)
 		span.Tag("plan", plan.String())
 		defer func() {
+			span.Tag("peak_memory", humanize.Bytes(budget.Peak()))
 			data := resp.Data()
 			switch d := data.(type) {
 			case *streamv1.QueryResponse:
-				d.Trace = tracer.ToProto()
+				if queryCriteria.Trace {
+					d.Trace = tracer.ToProto()
+				}
 			case *common.Error:
 				span.Error(errors.New(d.Error()))
-				resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Trace: tracer.ToProto()})
+				if queryCriteria.Trace {
+					resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Trace: tracer.ToProto()})
+				}
 			default:
 				panic("unexpected data type")
 			}
@@ -124,13 +146,26 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 	se := plan.(executor.StreamExecutable)
 	defer se.Close()
 	entities, err := se.Execute(executor.WithStreamExecutionContext(ctx, ec))
+	if err == nil {
+		var size int
+		for _, e := range entities {
+			size += proto.Size(e)
+		}
+		err = budget.Acquire(uint64(size))
+	}
 	if err != nil {
 		p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
-		resp = bus.NewMessage(bus.MessageID(now), common.NewError("execute the query plan for stream %s: %v", meta.GetName(), err))
+		resp = bus.NewMessage(bus.MessageID(now), executor.NewExecutionError(err, "execute the query plan for stream %s: %v", meta.GetName(), err))
 		return
 	}
 
-	resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Elements: entities})
+	nextToken, err := logical_stream.NextContinuationToken(p.queryService.nodeID, queryCriteria, s, token, entities)
+	if err != nil {
+		p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to build the continuation token")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("build the continuation token for stream %s: %v", meta.GetName(), err))
+		return
+	}
+	resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Elements: entities, ContinuationToken: nextToken})
 
 	if !queryCriteria.Trace && p.slowQuery > 0 {
 		latency := time.Since(n)
@@ -191,20 +226,32 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to analyze the query request for measure %s: %v", meta.GetName(), err))
 		return
 	}
+	budget := executor.NewMemoryBudget(uint64(p.memoryBudget))
+	ctx = protector.WithBudget(ctx, budget)
+	stats := &executor.QueryStats{}
+	ctx = executor.WithQueryStats(ctx, stats)
 	var tracer *query.Tracer
 	var span *query.Span
-	if queryCriteria.Trace {
+	defer func() {
+		p.slowQueryLog.RecordQuery(commonv1.Catalog_CATALOG_MEASURE, queryCriteria, n, stats, tracer, resp)
+	}()
+	if queryCriteria.Trace || p.slowQueryLog.Sampled() {
 		tracer, ctx = query.NewTracer(ctx, n.Format(time.RFC3339Nano))
 		span, ctx = tracer.StartSpan(ctx, "data-%s", p.queryService.nodeID)
 		span.Tag("plan", plan.String())
 		defer func() {
+			span.Tag("peak_memory", humanize.Bytes(budget.Peak()))
 			data := resp.Data()
 			switch d := data.(type) {
 			case *measurev1.QueryResponse:
-				d.Trace = tracer.ToProto()
+				if queryCriteria.Trace {
+					d.Trace = tracer.ToProto()
+				}
 			case *common.Error:
 				span.Error(errors.New(d.Error()))
-				resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Trace: tracer.ToProto()})
+				if queryCriteria.Trace {
+					resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Trace: tracer.ToProto()})
+				}
 			default:
 				panic("unexpected data type")
 			}
@@ -219,7 +266,7 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 	mIterator, err := plan.(executor.MeasureExecutable).Execute(executor.WithMeasureExecutionContext(ctx, ec))
 	if err != nil {
 		ml.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to query")
-		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to execute the query plan for measure %s: %v", meta.GetName(), err))
+		resp = bus.NewMessage(bus.MessageID(now), executor.NewExecutionError(err, "fail to execute the query plan for measure %s: %v", meta.GetName(), err))
 		return
 	}
 	defer func() {
@@ -232,7 +279,7 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 	}()
 
 	result := make([]*measurev1.DataPoint, 0)
-	func() {
+	err = func() error {
 		var r int
 		if tracer != nil {
 			iterSpan, _ := tracer.StartSpan(ctx, "iterator")
@@ -246,10 +293,19 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 			r++
 			current := mIterator.Current()
 			if len(current) > 0 {
+				if errAcquire := budget.Acquire(uint64(proto.Size(current[0]))); errAcquire != nil {
+					return errAcquire
+				}
 				result = append(result, current[0])
 			}
 		}
+		return nil
 	}()
+	if err != nil {
+		ml.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to iterate the query result")
+		resp = bus.NewMessage(bus.MessageID(now), executor.NewExecutionError(err, "fail to iterate the query result for measure %s: %v", meta.GetName(), err))
+		return
+	}
 	qr := &measurev1.QueryResponse{DataPoints: result}
 	if e := ml.Debug(); e.Enabled() {
 		e.RawJSON("ret", logger.Proto(qr)).Msg("got a measure")
@@ -122,7 +122,7 @@ func (t *topNQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp
 				d.Trace = tracer.ToProto()
 			case *common.Error:
 				span.Error(errors.New(d.Error()))
-				resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Trace: tracer.ToProto()})
+				resp = bus.NewMessage(bus.MessageID(now), &measurev1.TopNResponse{Trace: tracer.ToProto()})
 			default:
 				panic("unexpected data type")
 			}
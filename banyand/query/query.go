@@ -32,18 +32,25 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query/slowquery"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 )
 
 type queryService struct {
-	metaService metadata.Repo
-	pipeline    queue.Server
-	log         *logger.Logger
-	sqp         *streamQueryProcessor
-	mqp         *measureQueryProcessor
-	tqp         *topNQueryProcessor
-	nodeID      string
-	slowQuery   time.Duration
+	metaService          metadata.Repo
+	pipeline             queue.Server
+	log                  *logger.Logger
+	sqp                  *streamQueryProcessor
+	mqp                  *measureQueryProcessor
+	tqp                  *topNQueryProcessor
+	slowQueryLog         *slowquery.Log
+	closer               *run.Closer
+	nodeID               string
+	slowQueryLogRootPath string
+	slowQuery            time.Duration
+	memoryBudget         run.Bytes
+	slowQueryLogSize     int
+	slowQuerySampleRate  float64
 }
 
 // NewService return a new query service.
@@ -53,6 +60,7 @@ func NewService(_ context.Context, streamService stream.Service, measureService
 	svc := &queryService{
 		metaService: metaService,
 		pipeline:    pipeline,
+		closer:      run.NewCloser(1),
 	}
 	// measure query processor
 	svc.mqp = &measureQueryProcessor{
@@ -84,19 +92,51 @@ func (q *queryService) PreRun(ctx context.Context) error {
 	node := val.(common.Node)
 	q.nodeID = node.NodeID
 	q.log = logger.GetLogger(moduleName)
+	var err error
+	if q.slowQueryLog, err = slowquery.New(q.nodeID, slowquery.Options{
+		RootPath:        q.slowQueryLogRootPath,
+		Threshold:       q.slowQuery,
+		Size:            q.slowQueryLogSize,
+		TraceSampleRate: q.slowQuerySampleRate,
+	}, q.log.Named("slow-query")); err != nil {
+		return err
+	}
 	return multierr.Combine(
 		q.pipeline.Subscribe(data.TopicStreamQuery, q.sqp),
 		q.pipeline.Subscribe(data.TopicMeasureQuery, q.mqp),
 		q.pipeline.Subscribe(data.TopicTopNQuery, q.tqp),
+		q.pipeline.Subscribe(data.TopicSlowQueryList, &slowQueryListProcessor{queryService: q}),
 	)
 }
 
+func (q *queryService) Serve() run.StopNotify {
+	return q.closer.CloseNotify()
+}
+
+func (q *queryService) GracefulStop() {
+	if err := q.slowQueryLog.Close(); err != nil {
+		q.log.Error().Err(err).Msg("failed to close the slow query log")
+	}
+	q.closer.Done()
+	q.closer.CloseThenWait()
+}
+
 func (q *queryService) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("query")
 	fs.DurationVar(&q.slowQuery, "slow-query", 0, "slow query threshold, 0 means no slow query log")
+	fs.VarP(&q.memoryBudget, "query-memory-budget", "", "the max memory a query can reserve, 0 means no limit")
+	fs.StringVar(&q.slowQueryLogRootPath, "slow-query-log-root-path", "", "the root path of the slow query log files, empty means no slow query log files")
+	fs.IntVar(&q.slowQueryLogSize, "slow-query-log-size", 100, "the number of the recent slow queries kept in memory")
+	fs.Float64Var(&q.slowQuerySampleRate, "slow-query-trace-sample-rate", 0, "the ratio of the queries to collect traces for the slow query log, in [0, 1]")
 	return fs
 }
 
 func (q *queryService) Validate() error {
+	if q.slowQueryLogSize <= 0 {
+		return errors.New("slow-query-log-size should be positive")
+	}
+	if q.slowQuerySampleRate < 0 || q.slowQuerySampleRate > 1 {
+		return errors.New("slow-query-trace-sample-rate should be in [0, 1]")
+	}
 	return nil
 }
@@ -0,0 +1,45 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+var _ bus.MessageListener = (*slowQueryListProcessor)(nil)
+
+type slowQueryListProcessor struct {
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *slowQueryListProcessor) Rev(_ context.Context, message bus.Message) (resp bus.Message) {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*databasev1.SlowQueryServiceListRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+	}
+	return bus.NewMessage(bus.MessageID(now), &databasev1.SlowQueryServiceListResponse{
+		SlowQueries: p.slowQueryLog.List(int(req.Limit)),
+	})
+}
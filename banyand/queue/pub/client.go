@@ -20,6 +20,7 @@ package pub
 import (
 	"context"
 	"fmt"
+	"maps"
 	"time"
 
 	"google.golang.org/grpc"
@@ -101,7 +102,12 @@ func (p *pub) OnAddOrUpdate(md schema.Metadata) {
 
 	p.registerNode(node)
 
-	if _, ok := p.active[name]; ok {
+	if c, ok := p.active[name]; ok {
+		// The handlers learn the new labels of the node, for example, the draining mark.
+		if old, isNode := c.md.Spec.(*databasev1.Node); isNode && !maps.Equal(old.Labels, node.Labels) {
+			c.md = md
+			p.addClient(md)
+		}
 		return
 	}
 	if _, ok := p.evictable[name]; ok {
@@ -278,7 +284,7 @@ func (p *pub) checkClientHealthAndReconnect(conn *grpc.ClientConn, md schema.Met
 					}()
 					return
 				}
-				if errEvict != nil {
+				if errEvict == nil {
 					_ = connEvict.Close()
 				}
 				if _, ok := p.registered[name]; !ok {
@@ -361,18 +367,19 @@ func (p *pub) failover(node string, ce *common.Error, topic bus.Topic) {
 }
 
 func (p *pub) checkWritable(n string, topic bus.Topic) (bool, *common.Error) {
-	h, ok := p.handlers[topic]
-	if !ok {
-		return false, nil
-	}
 	node, ok := p.active[n]
 	if !ok {
-		return false, nil
+		return false, common.NewErrorWithStatus(modelv1.Status_STATUS_INTERNAL_ERROR, fmt.Sprintf("node %s isn't active", n))
 	}
 	err := p.checkServiceHealth(topic.String(), node.conn)
 	if err == nil {
 		return true, nil
 	}
+	// The clients sending data to the given nodes, e.g. the shard migrations, don't watch the health of the topic.
+	h, ok := p.handlers[topic]
+	if !ok {
+		return false, err
+	}
 	h.OnDelete(node.md)
 	if !p.closer.AddRunning() {
 		return false, err
@@ -70,6 +70,37 @@ var _ = ginkgo.Describe("publish clients register/unregister", func() {
 		verifyClients(p, 0, 0, 1, 2)
 	})
 
+	ginkgo.It("should notify the handlers of the new labels", func() {
+		addr1 := getAddress()
+		closeFn := setup(addr1, codes.OK, 200*time.Millisecond)
+		p := newPub()
+		defer func() {
+			p.GracefulStop()
+			closeFn()
+		}()
+		p.OnAddOrUpdate(getDataNode("node1", addr1))
+		verifyClients(p, 1, 0, 1, 0)
+		p.OnAddOrUpdate(getDataNode("node1", addr1))
+		verifyClients(p, 1, 0, 1, 0)
+		p.OnAddOrUpdate(getDataNodeWithLabels("node1", addr1, map[string]string{"draining": "true"}))
+		verifyClients(p, 1, 0, 2, 0)
+	})
+
+	ginkgo.It("should notify the late handlers of the active nodes", func() {
+		addr1 := getAddress()
+		closeFn := setup(addr1, codes.OK, 200*time.Millisecond)
+		p := newPub()
+		defer func() {
+			p.GracefulStop()
+			closeFn()
+		}()
+		p.OnAddOrUpdate(getDataNode("node1", addr1))
+		verifyClients(p, 1, 0, 1, 0)
+		h := &mockHandler{}
+		p.Register(data.TopicPropertyUpdate, h)
+		verifyClientsWithGomega(gomega.Default, p, data.TopicPropertyUpdate, 1, 0, 1, 0)
+	})
+
 	ginkgo.It("should move back to active queue", func() {
 		addr1 := getAddress()
 		node1 := getDataNode("node1", addr1)
@@ -87,6 +118,18 @@ var _ = ginkgo.Describe("publish clients register/unregister", func() {
 		verifyClients(p, 1, 0, 1, 1)
 	})
 
+	ginkgo.It("should close the connections of the failed reconnections", func() {
+		addr1 := getAddress()
+		node1 := getDataNode("node1", addr1)
+		p := newPub()
+		defer p.GracefulStop()
+		p.OnAddOrUpdate(node1)
+		verifyClients(p, 0, 1, 0, 1)
+		// Wait for the first reconnection, whose connection is left open if it isn't closed on the failure.
+		time.Sleep(initBackoff + time.Second)
+		verifyClients(p, 0, 1, 0, 1)
+	})
+
 	ginkgo.It("should be removed", func() {
 		addr1 := getAddress()
 		node1 := getDataNode("node1", addr1)
@@ -29,6 +29,7 @@ import (
 	"go.uber.org/multierr"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -45,6 +46,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
+	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
 )
 
 var (
@@ -55,35 +57,61 @@ var (
 
 type pub struct {
 	schema.UnimplementedOnInitHandler
-	metadata   metadata.Repo
-	handlers   map[bus.Topic]schema.EventHandler
-	log        *logger.Logger
-	registered map[string]*databasev1.Node
-	active     map[string]*client
-	evictable  map[string]evictNode
-	closer     *run.Closer
-	caCertPath string
-	mu         sync.RWMutex
-	tlsEnabled bool
+	metadata       metadata.Repo
+	handlers       map[bus.Topic]schema.EventHandler
+	log            *logger.Logger
+	registered     map[string]*databasev1.Node
+	active         map[string]*client
+	evictable      map[string]evictNode
+	closer         *run.Closer
+	clientCert     *pkgtls.Reloader
+	caCertPath     string
+	clientCertPath string
+	clientKeyPath  string
+	mu             sync.RWMutex
+	tlsEnabled     bool
 }
 
 func (p *pub) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("queue-client")
-	fs.BoolVar(&p.tlsEnabled, "internal-tls", false, "enable internal TLS")
-	fs.StringVar(&p.caCertPath, "internal-ca-cert", "", "CA certificate file to verify the internal data server")
+	registerTLSFlags(fs, &p.tlsEnabled, &p.caCertPath, &p.clientCertPath, &p.clientKeyPath)
 	return fs
 }
 
 func (p *pub) Validate() error {
+	return validateTLS(p.tlsEnabled, p.caCertPath, p.clientCertPath, p.clientKeyPath)
+}
+
+func registerTLSFlags(fs *run.FlagSet, tlsEnabled *bool, caCertPath, clientCertPath, clientKeyPath *string) {
+	fs.BoolVar(tlsEnabled, "internal-tls", false, "enable internal TLS")
+	fs.StringVar(caCertPath, "internal-ca-cert", "", "CA certificate file to verify the internal data server")
+	fs.StringVar(clientCertPath, "internal-client-cert", "", "certificate file presented to the internal data server which verifies the clients")
+	fs.StringVar(clientKeyPath, "internal-client-key", "", "key file of the certificate presented to the internal data server")
+}
+
+func validateTLS(tlsEnabled bool, caCertPath, clientCertPath, clientKeyPath string) error {
 	// simple sanity‑check: if TLS is on, a CA bundle must be provided
-	if p.tlsEnabled && p.caCertPath == "" {
+	if tlsEnabled && caCertPath == "" {
 		return fmt.Errorf("TLS is enabled (--internal-tls), but no CA certificate file was provided (--internal-ca-cert is required)")
 	}
+	if (clientCertPath == "") != (clientKeyPath == "") {
+		return fmt.Errorf("--internal-client-cert and --internal-client-key must be provided together")
+	}
+	if clientCertPath != "" && !tlsEnabled {
+		return fmt.Errorf("the client certificate (--internal-client-cert) requires TLS (--internal-tls)")
+	}
 	return nil
 }
 
+// Register adds the handler of the topic, which is notified when a data node becomes active or inactive.
+// The handler learns the nodes which are already active.
 func (p *pub) Register(topic bus.Topic, handler schema.EventHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.handlers[topic] = handler
+	for _, c := range p.active {
+		handler.OnAddOrUpdate(c.md)
+	}
 }
 
 func (p *pub) GracefulStop() {
@@ -99,6 +127,9 @@ func (p *pub) GracefulStop() {
 		_ = c.conn.Close()
 	}
 	p.active = nil
+	if p.clientCert != nil {
+		p.clientCert.Stop()
+	}
 }
 
 // Serve implements run.Service.
@@ -207,6 +238,7 @@ type publishResult struct {
 func (p *pub) publish(timeout time.Duration, topic bus.Topic, messages ...bus.Message) (bus.Future, error) {
 	var err error
 	f := &future{}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
 	handleMessage := func(m bus.Message, err error) error {
 		r, errSend := messageToRequest(topic, m)
 		if errSend != nil {
@@ -219,7 +251,7 @@ func (p *pub) publish(timeout time.Duration, topic bus.Topic, messages ...bus.Me
 		if !ok {
 			return multierr.Append(err, fmt.Errorf("failed to get client for node %s", node))
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		ctx, cancel := context.WithTimeout(f.ctx, timeout)
 		f.cancelFn = append(f.cancelFn, cancel)
 		stream, errCreateStream := client.client.Send(ctx)
 		if errCreateStream != nil {
@@ -231,6 +263,7 @@ func (p *pub) publish(timeout time.Duration, topic bus.Topic, messages ...bus.Me
 		}
 		f.clients = append(f.clients, stream)
 		f.topics = append(f.topics, topic)
+		f.nodes = append(f.nodes, node)
 		return err
 	}
 	for _, m := range messages {
@@ -263,6 +296,54 @@ func NewWithoutMetadata() queue.Client {
 	return p
 }
 
+// NewWithTLS returns a new queue client without metadata, which connects to the data nodes with the TLS config.
+// The client connects without TLS if the config is nil.
+func NewWithTLS(config *ClientTLS) (queue.Client, error) {
+	p := NewWithoutMetadata().(*pub)
+	if config == nil {
+		return p, nil
+	}
+	p.tlsEnabled = config.tlsEnabled
+	p.caCertPath = config.caCertPath
+	p.clientCertPath = config.clientCertPath
+	p.clientKeyPath = config.clientKeyPath
+	if err := p.loadClientCert(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ClientTLS is the TLS config of the queue clients which a node creates on its own to send data to the data nodes,
+// e.g. the clients migrating the shards of a data node.
+type ClientTLS struct {
+	caCertPath     string
+	clientCertPath string
+	clientKeyPath  string
+	tlsEnabled     bool
+}
+
+// NewClientTLS returns a new ClientTLS, which is configured by the same flags as the queue client.
+func NewClientTLS() *ClientTLS {
+	return &ClientTLS{}
+}
+
+// Name implements run.Unit.
+func (*ClientTLS) Name() string {
+	return "internal-tls"
+}
+
+// FlagSet implements run.Config.
+func (c *ClientTLS) FlagSet() *run.FlagSet {
+	fs := run.NewFlagSet("internal-tls")
+	registerTLSFlags(fs, &c.tlsEnabled, &c.caCertPath, &c.clientCertPath, &c.clientKeyPath)
+	return fs
+}
+
+// Validate implements run.Config.
+func (c *ClientTLS) Validate() error {
+	return validateTLS(c.tlsEnabled, c.caCertPath, c.clientCertPath, c.clientKeyPath)
+}
+
 func (*pub) Name() string {
 	return "queue-client"
 }
@@ -272,7 +353,18 @@ func (p *pub) PreRun(context.Context) error {
 		p.metadata.RegisterHandler("queue-client", schema.KindNode, p)
 	}
 	p.log = logger.GetLogger("server-queue-pub")
-	return nil
+	return p.loadClientCert()
+}
+
+func (p *pub) loadClientCert() error {
+	if p.clientCertPath == "" {
+		return nil
+	}
+	var err error
+	if p.clientCert, err = pkgtls.NewReloader(p.clientCertPath, p.clientKeyPath, p.log); err != nil {
+		return errors.WithMessage(err, "failed to load the client certificate")
+	}
+	return p.clientCert.Start()
 }
 
 func messageToRequest(topic bus.Topic, m bus.Message) (*clusterv1.SendRequest, error) {
@@ -293,10 +385,15 @@ func messageToRequest(topic bus.Topic, m bus.Message) (*clusterv1.SendRequest, e
 	return r, nil
 }
 
+var _ bus.CancelableFuture = (*future)(nil)
+
 type future struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
 	clients  []clusterv1.Service_SendClient
 	cancelFn []func()
 	topics   []bus.Topic
+	nodes    []string
 }
 
 func (l *future) Get() (bus.Message, error) {
@@ -305,21 +402,29 @@ func (l *future) Get() (bus.Message, error) {
 	}
 	c := l.clients[0]
 	t := l.topics[0]
+	n := l.nodes[0]
 	defer func() {
 		l.clients = l.clients[1:]
 		l.topics = l.topics[1:]
+		l.nodes = l.nodes[1:]
 		l.cancelFn[0]()
 		l.cancelFn = l.cancelFn[1:]
+		if len(l.clients) == 0 {
+			l.cancel()
+		}
 	}()
 	resp, err := c.Recv()
 	if err != nil {
 		return bus.Message{}, err
 	}
 	if resp.Error != "" {
+		if resp.Status != modelv1.Status_STATUS_UNSPECIFIED {
+			return bus.Message{}, common.NewErrorWithStatus(resp.Status, resp.Error)
+		}
 		return bus.Message{}, errors.New(resp.Error)
 	}
 	if resp.Body == nil {
-		return bus.NewMessage(bus.MessageID(resp.MessageId), nil), nil
+		return bus.NewMessageWithNode(bus.MessageID(resp.MessageId), n, nil), nil
 	}
 	if messageSupplier, ok := data.TopicResponseMap[t]; ok {
 		m := messageSupplier()
@@ -327,14 +432,21 @@ func (l *future) Get() (bus.Message, error) {
 		if err != nil {
 			return bus.Message{}, err
 		}
-		return bus.NewMessage(
+		return bus.NewMessageWithNode(
 			bus.MessageID(resp.MessageId),
+			n,
 			m,
 		), nil
 	}
 	return bus.Message{}, fmt.Errorf("invalid topic %s", t)
 }
 
+// Cancel aborts the streams whose responses haven't been received.
+// It's safe to call it concurrently with Get.
+func (l *future) Cancel() {
+	l.cancel()
+}
+
 func (l *future) GetAll() ([]bus.Message, error) {
 	var globalErr error
 	ret := make([]bus.Message, 0, len(l.clients))
@@ -360,9 +472,17 @@ func isFailoverError(err error) bool {
 }
 
 func (p *pub) getClientTransportCredentials() ([]grpc.DialOption, error) {
-	opts, err := grpchelper.SecureOptions(nil, p.tlsEnabled, false, p.caCertPath)
+	if p.clientCert == nil {
+		opts, err := grpchelper.SecureOptions(nil, p.tlsEnabled, false, p.caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS config: %w", err)
+		}
+		return opts, nil
+	}
+	config, err := grpchelper.TLSConfig(false, p.caCertPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load TLS config: %w", err)
 	}
-	return opts, nil
+	config.GetClientCertificate = p.clientCert.GetClientCertificate
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(config))}, nil
 }
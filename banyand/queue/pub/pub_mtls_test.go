@@ -0,0 +1,182 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pub
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
+)
+
+func writeCert(dir, name string, dnsNames []string) (certFile, keyFile string) {
+	certPEM, keyPEM, err := pkgtls.GenerateSelfSignedCert(name, dnsNames)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	gomega.Expect(os.WriteFile(certFile, certPEM, 0o600)).Should(gomega.Succeed())
+	gomega.Expect(os.WriteFile(keyFile, keyPEM, 0o600)).Should(gomega.Succeed())
+	return certFile, keyFile
+}
+
+// mtlsServer requires the clients to present the certificates issued by the client CA, and to match the allowed SANs.
+func mtlsServer(addr, certFile, keyFile, clientCAFile string, allowedSANs []string) func() {
+	l := logger.GetLogger("queue-sub-mtls")
+	certReloader, err := pkgtls.NewReloader(certFile, keyFile, l)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	caReloader, err := pkgtls.NewClientCertReloader(clientCAFile, l)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(caReloader.Start()).Should(gomega.Succeed())
+
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(certReloader.GetMutualTLSConfig(caReloader, allowedSANs))))
+	hs := health.NewServer()
+	clusterv1.RegisterServiceServer(s, &mockServer{
+		code:         codes.OK,
+		statusCode:   modelv1.Status_STATUS_SUCCEED,
+		healthServer: hs,
+	})
+	grpc_health_v1.RegisterHealthServer(s, hs)
+	lis, err := net.Listen("tcp", addr)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	return func() {
+		s.GracefulStop()
+		certReloader.Stop()
+		caReloader.Stop()
+	}
+}
+
+func newMTLSPub(caCertFile, clientCertFile, clientKeyFile string) *pub {
+	p := NewWithoutMetadata().(*pub)
+	p.tlsEnabled = true
+	p.caCertPath = caCertFile
+	p.clientCertPath = clientCertFile
+	p.clientKeyPath = clientKeyFile
+	gomega.Expect(p.Validate()).Should(gomega.Succeed())
+	gomega.Expect(p.PreRun(context.Background())).Should(gomega.Succeed())
+	return p
+}
+
+func activeNodes(p *pub) func() int {
+	return func() int {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return len(p.active)
+	}
+}
+
+var _ = ginkgo.Describe("Broadcast over mutual TLS", func() {
+	var goods []gleak.Goroutine
+	var serverCert, liaisonCert, liaisonKey, intruderCert, intruderKey, addr string
+	var closeFn func()
+
+	ginkgo.BeforeEach(func() {
+		goods = gleak.Goroutines()
+		dir := ginkgo.GinkgoT().TempDir()
+		var serverKey string
+		serverCert, serverKey = writeCert(dir, "localhost", nil)
+		liaisonCert, liaisonKey = writeCert(dir, "liaison-0", []string{"liaison-0.liaison.banyandb"})
+		intruderCert, intruderKey = writeCert(dir, "intruder", nil)
+		clientCA := filepath.Join(dir, "client-ca.crt")
+		liaisonPEM, err := os.ReadFile(liaisonCert)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		intruderPEM, err := os.ReadFile(intruderCert)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(os.WriteFile(clientCA, append(liaisonPEM, intruderPEM...), 0o600)).Should(gomega.Succeed())
+		addr = getAddress()
+		closeFn = mtlsServer(addr, serverCert, serverKey, clientCA, []string{"*.liaison.banyandb"})
+	})
+	ginkgo.AfterEach(func() {
+		closeFn()
+		gomega.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+	})
+
+	ginkgo.It("broadcasts with the client certificate", func() {
+		p := newMTLSPub(serverCert, liaisonCert, liaisonKey)
+		defer p.GracefulStop()
+		p.OnAddOrUpdate(getDataNode("node-mtls", addr))
+		gomega.Eventually(activeNodes(p), flags.EventuallyTimeout).Should(gomega.Equal(1))
+
+		futures, err := p.Broadcast(flags.EventuallyTimeout, data.TopicStreamQuery,
+			bus.NewMessage(bus.MessageID(1), &streamv1.QueryRequest{}))
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(futures).Should(gomega.HaveLen(1))
+		msgs, err := futures[0].GetAll()
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(msgs).Should(gomega.HaveLen(1))
+		_, ok := msgs[0].Data().(*streamv1.QueryResponse)
+		gomega.Expect(ok).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("broadcasts with the client certificate of the internal TLS flags", func() {
+		config := NewClientTLS()
+		gomega.Expect(config.FlagSet().Parse([]string{
+			"--internal-tls=true", "--internal-ca-cert=" + serverCert,
+			"--internal-client-cert=" + liaisonCert, "--internal-client-key=" + liaisonKey,
+		})).Should(gomega.Succeed())
+		gomega.Expect(config.Validate()).Should(gomega.Succeed())
+		c, err := NewWithTLS(config)
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		defer c.GracefulStop()
+		p := c.(*pub)
+		p.OnAddOrUpdate(getDataNode("node-mtls", addr))
+		gomega.Eventually(activeNodes(p), flags.EventuallyTimeout).Should(gomega.Equal(1))
+
+		futures, err := p.Broadcast(flags.EventuallyTimeout, data.TopicStreamQuery,
+			bus.NewMessage(bus.MessageID(1), &streamv1.QueryRequest{}))
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(futures).Should(gomega.HaveLen(1))
+		_, err = futures[0].GetAll()
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("is rejected without a client certificate", func() {
+		p := newMTLSPub(serverCert, "", "")
+		defer p.GracefulStop()
+		p.OnAddOrUpdate(getDataNode("node-mtls", addr))
+		gomega.Consistently(activeNodes(p), 3*time.Second).Should(gomega.Equal(0))
+	})
+
+	ginkgo.It("is rejected if the SANs aren't allowed", func() {
+		p := newMTLSPub(serverCert, intruderCert, intruderKey)
+		defer p.GracefulStop()
+		p.OnAddOrUpdate(getDataNode("node-mtls", addr))
+		gomega.Consistently(activeNodes(p), 3*time.Second).Should(gomega.Equal(0))
+	})
+})
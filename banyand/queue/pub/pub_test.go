@@ -78,6 +78,27 @@ var _ = ginkgo.Describe("Publish and Broadcast", func() {
 			gomega.Expect(cee).Should(gomega.HaveLen(0))
 		})
 
+		ginkgo.It("should publish messages without the handler of the topic", func() {
+			addr1 := getAddress()
+			closeFn1 := setup(addr1, codes.OK, 10*time.Millisecond)
+			p := NewWithoutMetadata().(*pub)
+			defer func() {
+				p.GracefulStop()
+				closeFn1()
+			}()
+			p.OnAddOrUpdate(getDataNode("node1", addr1))
+
+			bp := p.NewBatchPublisher(3 * time.Second)
+			_, err := bp.Publish(context.TODO(), data.TopicStreamWrite,
+				bus.NewBatchMessageWithNode(bus.MessageID(1), "node1", &streamv1.InternalWriteRequest{}),
+			)
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(bp.(*batchPublisher).streams).Should(gomega.HaveKey("node1"))
+			cee, err := bp.Close()
+			gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+			gomega.Expect(cee).Should(gomega.BeEmpty())
+		})
+
 		ginkgo.It("should go to evict queue when node is unavailable", func() {
 			addr1 := getAddress()
 			addr2 := getAddress()
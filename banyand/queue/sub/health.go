@@ -20,6 +20,12 @@ package sub
 import (
 	"context"
 
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
 	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 )
@@ -46,3 +52,20 @@ func (s *server) HealthCheck(_ context.Context, req *clusterv1.HealthCheckReques
 		Status:      modelv1.Status_STATUS_NOT_FOUND,
 	}, nil
 }
+
+// localHealthClient checks the health server in the process.
+type localHealthClient struct {
+	server *health.Server
+}
+
+func (c localHealthClient) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest,
+	_ ...grpclib.CallOption,
+) (*grpc_health_v1.HealthCheckResponse, error) {
+	return c.server.Check(ctx, req)
+}
+
+func (c localHealthClient) Watch(_ context.Context, _ *grpc_health_v1.HealthCheckRequest,
+	_ ...grpclib.CallOption,
+) (grpc_health_v1.Health_WatchClient, error) {
+	return nil, status.Error(codes.Unimplemented, "unimplemented")
+}
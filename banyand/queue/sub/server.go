@@ -19,6 +19,7 @@ package sub
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"runtime/debug"
@@ -34,7 +35,6 @@ import (
 	grpclib "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
@@ -46,18 +46,20 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
-	"github.com/apache/skywalking-banyandb/pkg/healthcheck"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/meter"
 	"github.com/apache/skywalking-banyandb/pkg/run"
+	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
 )
 
 const defaultRecvSize = 10 << 20
 
 var (
-	errServerCert = errors.New("invalid server cert file")
-	errServerKey  = errors.New("invalid server key file")
-	errNoAddr     = errors.New("no address")
+	errServerCert           = errors.New("invalid server cert file")
+	errServerKey            = errors.New("invalid server key file")
+	errNoAddr               = errors.New("no address")
+	errClientCAWithoutTLS   = errors.New("the client CA file requires TLS")
+	errAllowedSANsWithoutCA = errors.New("the allowed client SANs require the client CA file")
 
 	_ run.PreRunner = (*server)(nil)
 	_ run.Service   = (*server)(nil)
@@ -68,7 +70,6 @@ var (
 type server struct {
 	databasev1.UnimplementedSnapshotServiceServer
 	streamv1.UnimplementedStreamServiceServer
-	creds     credentials.TransportCredentials
 	omr       observability.MetricsRegistry
 	httpSrv   *http.Server
 	log       *logger.Logger
@@ -76,18 +77,22 @@ type server struct {
 	listeners map[bus.Topic][]bus.MessageListener
 	topicMap  map[string]bus.Topic
 	clusterv1.UnimplementedServiceServer
-	metrics        *metrics
-	clientCloser   context.CancelFunc
-	host           string
-	addr           string
-	httpAddr       string
-	certFile       string
-	keyFile        string
-	maxRecvMsgSize run.Bytes
-	listenersLock  sync.RWMutex
-	port           uint32
-	httpPort       uint32
-	tls            bool
+	metrics          *metrics
+	tlsReloader      *pkgtls.Reloader
+	clientCAReloader *pkgtls.Reloader
+	clientCloser     context.CancelFunc
+	host             string
+	addr             string
+	httpAddr         string
+	certFile         string
+	keyFile          string
+	clientCAFile     string
+	allowedSANs      []string
+	maxRecvMsgSize   run.Bytes
+	listenersLock    sync.RWMutex
+	port             uint32
+	httpPort         uint32
+	tls              bool
 }
 
 // NewServer returns a new gRPC server.
@@ -102,6 +107,20 @@ func NewServer(omr observability.MetricsRegistry) queue.Server {
 func (s *server) PreRun(_ context.Context) error {
 	s.log = logger.GetLogger("server-queue-sub")
 	s.metrics = newMetrics(s.omr.With(queueSubScope))
+	if !s.tls {
+		return nil
+	}
+	var err error
+	s.tlsReloader, err = pkgtls.NewReloader(s.certFile, s.keyFile, s.log)
+	if err != nil {
+		return errors.Wrap(err, "failed to load cert and key")
+	}
+	if s.clientCAFile != "" {
+		s.clientCAReloader, err = pkgtls.NewClientCertReloader(s.clientCAFile, s.log)
+		if err != nil {
+			return errors.Wrap(err, "failed to load the client CA file")
+		}
+	}
 	return nil
 }
 
@@ -124,6 +143,10 @@ func (s *server) FlagSet() *run.FlagSet {
 	fs.BoolVar(&s.tls, "tls", false, "connection uses TLS if true, else plain TCP")
 	fs.StringVar(&s.certFile, "cert-file", "", "the TLS cert file")
 	fs.StringVar(&s.keyFile, "key-file", "", "the TLS key file")
+	fs.StringVar(&s.clientCAFile, "client-ca-file", "",
+		"the CA bundle verifying the certificates of the liaison nodes. The liaison nodes are required to present certificates if it's set")
+	fs.StringSliceVar(&s.allowedSANs, "allowed-client-sans", nil,
+		"the patterns of the DNS, IP or URI SANs allowed in the certificates of the liaison nodes, e.g. *.liaison.banyandb.svc. Empty allows any verified certificate")
 	fs.StringVar(&s.host, "grpc-host", "", "the host of banyand listens")
 	fs.Uint32Var(&s.port, "grpc-port", 17912, "the port of banyand listens")
 	fs.Uint32Var(&s.httpPort, "http-port", 17913, "the port of banyand http api listens")
@@ -139,7 +162,13 @@ func (s *server) Validate() error {
 	if s.httpAddr == ":" {
 		return errNoAddr
 	}
+	if len(s.allowedSANs) > 0 && s.clientCAFile == "" {
+		return errAllowedSANsWithoutCA
+	}
 	if !s.tls {
+		if s.clientCAFile != "" {
+			return errClientCAWithoutTLS
+		}
 		return nil
 	}
 	if s.certFile == "" {
@@ -148,18 +177,20 @@ func (s *server) Validate() error {
 	if s.keyFile == "" {
 		return errServerKey
 	}
-	creds, errTLS := credentials.NewServerTLSFromFile(s.certFile, s.keyFile)
-	if errTLS != nil {
-		return errors.Wrap(errTLS, "failed to load cert and key")
-	}
-	s.creds = creds
-	return nil
+	return pkgtls.ValidateSANs(s.allowedSANs)
 }
 
 func (s *server) Serve() run.StopNotify {
+	stopCh := make(chan struct{})
 	var opts []grpclib.ServerOption
 	if s.tls {
-		opts = []grpclib.ServerOption{grpclib.Creds(s.creds)}
+		tlsConfig, err := s.serverTLSConfig()
+		if err != nil {
+			s.log.Error().Err(err).Msg("Failed to start TLS file monitoring")
+			close(stopCh)
+			return stopCh
+		}
+		opts = []grpclib.ServerOption{grpclib.Creds(credentials.NewTLS(tlsConfig))}
 	}
 	grpcPanicRecoveryHandler := func(p any) (err error) {
 		s.log.Error().Interface("panic", p).Str("stack", string(debug.Stack())).Msg("recovered from panic")
@@ -181,28 +212,18 @@ func (s *server) Serve() run.StopNotify {
 	)
 	s.ser = grpclib.NewServer(opts...)
 	clusterv1.RegisterServiceServer(s.ser, s)
-	grpc_health_v1.RegisterHealthServer(s.ser, health.NewServer())
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(s.ser, healthServer)
 	databasev1.RegisterSnapshotServiceServer(s.ser, s)
 	streamv1.RegisterStreamServiceServer(s.ser, &streamService{ser: s})
 	measurev1.RegisterMeasureServiceServer(s.ser, &measureService{ser: s})
 
 	var ctx context.Context
 	ctx, s.clientCloser = context.WithCancel(context.Background())
-	clientOpts := make([]grpclib.DialOption, 0, 1)
-	if s.creds == nil {
-		clientOpts = append(clientOpts, grpclib.WithTransportCredentials(insecure.NewCredentials()))
-	} else {
-		clientOpts = append(clientOpts, grpclib.WithTransportCredentials(s.creds))
-	}
-	stopCh := make(chan struct{})
-	client, err := healthcheck.NewClient(ctx, s.log, s.addr, clientOpts)
-	if err != nil {
-		s.log.Error().Err(err).Msg("Failed to health check client")
-		close(stopCh)
-		return stopCh
-	}
-	gwMux := runtime.NewServeMux(runtime.WithHealthzEndpoint(client))
-	if err := databasev1.RegisterSnapshotServiceHandlerFromEndpoint(ctx, gwMux, s.addr, clientOpts); err != nil {
+	// The HTTP gateway calls the services in the process rather than dialing the gRPC port,
+	// which might require the certificates of the liaison nodes.
+	gwMux := runtime.NewServeMux(runtime.WithHealthzEndpoint(localHealthClient{server: healthServer}))
+	if err := databasev1.RegisterSnapshotServiceHandlerServer(ctx, gwMux, s); err != nil {
 		s.log.Error().Err(err).Msg("Failed to register snapshot service")
 		close(stopCh)
 		return stopCh
@@ -246,8 +267,30 @@ func (s *server) Serve() run.StopNotify {
 	return stopCh
 }
 
+// serverTLSConfig starts monitoring the TLS files, and requires the client certificates if the client CA file is set.
+func (s *server) serverTLSConfig() (*tls.Config, error) {
+	if err := s.tlsReloader.Start(); err != nil {
+		return nil, err
+	}
+	s.log.Info().Str("certFile", s.certFile).Str("keyFile", s.keyFile).Msg("Starting TLS file monitoring")
+	if s.clientCAReloader == nil {
+		return s.tlsReloader.GetTLSConfig(), nil
+	}
+	if err := s.clientCAReloader.Start(); err != nil {
+		return nil, err
+	}
+	s.log.Info().Str("clientCAFile", s.clientCAFile).Strs("allowedSANs", s.allowedSANs).Msg("Verifying the client certificates")
+	return s.tlsReloader.GetMutualTLSConfig(s.clientCAReloader, s.allowedSANs), nil
+}
+
 func (s *server) GracefulStop() {
 	s.log.Info().Msg("stopping")
+	if s.tlsReloader != nil {
+		s.tlsReloader.Stop()
+	}
+	if s.clientCAReloader != nil {
+		s.clientCAReloader.Stop()
+	}
 	stopped := make(chan struct{})
 	s.clientCloser()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -26,6 +26,7 @@ import (
 	"github.com/apache/skywalking-banyandb/api/common"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/index/posting"
@@ -101,8 +102,7 @@ func (b *block) processTags(tf tagValues, tagFamilyIdx, i int, elementsLen int)
 	for j, t := range tf.values {
 		tags[j].name = t.tag
 		tags[j].resizeValues(elementsLen)
-		tags[j].valueType = t.valueType
-		tags[j].values[i] = t.marshal()
+		tags[j].setValue(i, t.valueType, t.marshal())
 	}
 }
 
@@ -387,6 +387,8 @@ type blockCursor struct {
 	tagFamilies      []tagFamily
 	tagValuesDecoder encoding.BytesBlockDecoder
 	tagProjection    []model.TagProjection
+	tagPredicates    []model.TagPredicate
+	tagTypes         map[string]pbv1.ValueType
 	bm               blockMetadata
 	idx              int
 	minTimestamp     int64
@@ -400,6 +402,8 @@ func (bc *blockCursor) reset() {
 	bc.minTimestamp = 0
 	bc.maxTimestamp = 0
 	bc.tagProjection = bc.tagProjection[:0]
+	bc.tagPredicates = nil
+	bc.tagTypes = nil
 
 	bc.timestamps = bc.timestamps[:0]
 	bc.elementIDs = bc.elementIDs[:0]
@@ -418,6 +422,8 @@ func (bc *blockCursor) init(p *part, bm *blockMetadata, opts queryOptions) {
 	bc.minTimestamp = opts.minTimestamp
 	bc.maxTimestamp = opts.maxTimestamp
 	bc.tagProjection = opts.TagProjection
+	bc.tagPredicates = opts.TagPredicates
+	bc.tagTypes = opts.tagTypes
 	bc.elementFilter = opts.elementFilter
 }
 
@@ -552,6 +558,25 @@ func (bc *blockCursor) loadData(tmpBlock *block) bool {
 		bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[s:e+1]...)
 		bc.elementIDs = append(bc.elementIDs, tmpBlock.elementIDs[s:e+1]...)
 	}
+	if len(bc.tagPredicates) > 0 {
+		if len(idxList) == 0 {
+			for i := start; i <= end; i++ {
+				idxList = append(idxList, i)
+			}
+		}
+		idxList = matchTagPredicates(tmpBlock, bc.tagPredicates, idxList)
+		if len(idxList) == 0 {
+			bc.timestamps = bc.timestamps[:0]
+			bc.elementIDs = bc.elementIDs[:0]
+			return false
+		}
+		bc.timestamps = bc.timestamps[:0]
+		bc.elementIDs = bc.elementIDs[:0]
+		for _, idx := range idxList {
+			bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[idx])
+			bc.elementIDs = append(bc.elementIDs, tmpBlock.elementIDs[idx])
+		}
+	}
 
 	for i, projection := range bc.bm.tagProjection {
 		tf := tagFamily{
@@ -573,6 +598,7 @@ func (bc *blockCursor) loadData(tmpBlock *block) bool {
 			} else {
 				t.values = append(t.values, tmpBlock.tagFamilies[i].tags[j].values[start:end+1]...)
 			}
+			t.widen(bc.tagTypes[name])
 			tf.tags = append(tf.tags, t)
 		}
 		bc.tagFamilies = append(bc.tagFamilies, tf)
@@ -580,6 +606,45 @@ func (bc *blockCursor) loadData(tmpBlock *block) bool {
 	return len(bc.timestamps) > 0
 }
 
+// matchTagPredicates returns the rows in idxList whose tags match all the predicates.
+// A dictionary-encoded tag evaluates a predicate once per distinct value instead of once per row.
+func matchTagPredicates(b *block, predicates []model.TagPredicate, idxList []int) []int {
+	for _, p := range predicates {
+		t := b.findTag(p.Family, p.Name)
+		if t == nil || t.valueType != pbv1.ValueTypeStr {
+			continue
+		}
+		match := func(v []byte) bool {
+			return v != nil && slices.Contains(p.Values, convert.BytesToString(v))
+		}
+		if t.dict.Len() == len(t.values) && t.dict.Len() > 0 {
+			matched := t.dict.Match(match)
+			idxList = slices.DeleteFunc(idxList, func(idx int) bool {
+				return !matched[t.dict.IDs[idx]]
+			})
+			continue
+		}
+		idxList = slices.DeleteFunc(idxList, func(idx int) bool {
+			return !match(t.values[idx])
+		})
+	}
+	return idxList
+}
+
+func (b *block) findTag(family, name string) *tag {
+	for i := range b.tagFamilies {
+		if b.tagFamilies[i].name != family {
+			continue
+		}
+		for j := range b.tagFamilies[i].tags {
+			if b.tagFamilies[i].tags[j].name == name {
+				return &b.tagFamilies[i].tags[j]
+			}
+		}
+	}
+	return nil
+}
+
 var blockCursorPool = pool.Register[*blockCursor]("stream-blockCursor")
 
 func generateBlockCursor() *blockCursor {
@@ -664,7 +729,7 @@ func fastTagAppend(bi, b *blockPointer, offset int) error {
 					bi.tagFamilies[i].name, b.tagFamilies[i].tags[j].name, bi.tagFamilies[i].tags[j].name)
 			}
 			assertIdxAndOffset(b.tagFamilies[i].tags[j].name, len(b.tagFamilies[i].tags[j].values), b.idx, offset)
-			bi.tagFamilies[i].tags[j].values = append(bi.tagFamilies[i].tags[j].values, b.tagFamilies[i].tags[j].values[b.idx:offset]...)
+			bi.tagFamilies[i].tags[j].appendValues(&b.tagFamilies[i].tags[j], b.idx, offset)
 		}
 	}
 	return nil
@@ -707,7 +772,7 @@ func fullTagAppend(bi, b *blockPointer, offset int) {
 			for _, c := range tf.tags {
 				if existingColumn, exists := columnMap[c.name]; exists {
 					assertIdxAndOffset(c.name, len(c.values), b.idx, offset)
-					existingColumn.values = append(existingColumn.values, c.values[b.idx:offset]...)
+					existingColumn.appendValues(&c, b.idx, offset)
 				} else {
 					assertIdxAndOffset(c.name, len(c.values), b.idx, offset)
 					col := tag{name: c.name, valueType: c.valueType}
@@ -33,6 +33,7 @@ import (
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	logicalstream "github.com/apache/skywalking-banyandb/pkg/query/logical/stream"
 )
 
@@ -51,12 +52,18 @@ func (bs *blockScanResult) reset() {
 }
 
 type blockScanResultBatch struct {
-	err error
-	bss []blockScanResult
+	err      error
+	budget   protector.Budget
+	bss      []blockScanResult
+	reserved uint64
 }
 
 func (bsb *blockScanResultBatch) reset() {
 	bsb.err = nil
+	if bsb.budget != nil {
+		bsb.budget.Release(bsb.reserved)
+	}
+	bsb.budget, bsb.reserved = nil, 0
 	for i := range bsb.bss {
 		bsb.bss[i].reset()
 	}
@@ -119,8 +126,13 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 	}()
 	var parts []*part
 	var size, offset int
-	filterIndex := make(map[uint64]posting.List)
+	// The parts are keyed by themselves, since the tables of the shards number their parts on their own.
+	filterIndex := make(map[*part]posting.List)
 	for i := range tabs {
+		inShard, afterPartID := tabs[i].shardScan(qo.Shard)
+		if !inShard {
+			continue
+		}
 		filter, filterTS, err := search(ctx, qo, qo.sortedSids, tabs[i], tr)
 		if err != nil {
 			return nil, err
@@ -130,14 +142,14 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 		}
 		minTimestamp, maxTimestamp := updateTimeRange(filterTS, qo.minTimestamp, qo.maxTimestamp)
 		snp := tabs[i].currentSnapshot()
-		parts, size = snp.getParts(parts, minTimestamp, maxTimestamp)
+		parts, size = snp.getParts(parts, minTimestamp, maxTimestamp, afterPartID)
 		if size < 1 {
 			snp.decRef()
 			continue
 		}
 		finalizers = append(finalizers, snp.decRef)
 		for j := offset; j < offset+size; j++ {
-			filterIndex[parts[j].partMetadata.ID] = filter
+			filterIndex[parts[j]] = filter
 		}
 		offset += size
 	}
@@ -197,7 +209,7 @@ func search(ctx context.Context, qo queryOptions, seriesList []common.SeriesID,
 type scanFinalizer func()
 
 type blockScanner struct {
-	filterIndex map[uint64]posting.List
+	filterIndex map[*part]posting.List
 	l           *logger.Logger
 	pm          *protector.Memory
 	parts       [][]*part
@@ -234,14 +246,16 @@ func (bsn *blockScanner) scan(ctx context.Context, blockCh chan *blockScanResult
 		}
 		return
 	}
+	stats := executor.QueryStatsFromContext(ctx)
 	for ti.nextBlock() {
 		p := ti.piHeap[0]
+		stats.AddScanned(p.curBlock.count)
 		batch.bss = append(batch.bss, blockScanResult{
 			p: p.p,
 		})
 		bs := &batch.bss[len(batch.bss)-1]
 		bs.qo.copyFrom(&bsn.qo)
-		bs.qo.elementFilter = bsn.filterIndex[p.p.partMetadata.ID]
+		bs.qo.elementFilter = bsn.filterIndex[p.p]
 		bs.bm.copyFrom(p.curBlock)
 		if len(batch.bss) >= cap(batch.bss) {
 			var totalBlockBytes uint64
@@ -258,6 +272,8 @@ func (bsn *blockScanner) scan(ctx context.Context, blockCh chan *blockScanResult
 				}
 				return
 			}
+			// The blocks are released with the batch once they are merged.
+			batch.budget, batch.reserved = protector.BudgetFromContext(ctx), totalBlockBytes
 			select {
 			case blockCh <- batch:
 			case <-ctx.Done():
@@ -200,6 +200,7 @@ var elementsPool = pool.Register[*elements]("stream-elements")
 type elementsInTable struct {
 	timeRange timestamp.TimeRange
 	tsTable   *tsTable
+	shardID   common.ShardID
 
 	elements *elements
 
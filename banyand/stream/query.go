@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
@@ -67,7 +68,7 @@ func (s *stream) Query(ctx context.Context, sqo model.StreamQueryOptions) (sqr m
 	}()
 
 	series := prepareSeriesData(sqo)
-	qo := prepareQueryOptions(sqo)
+	qo := prepareQueryOptions(sqo, s.tagTypes)
 	tr := index.NewIntRangeOpts(qo.minTimestamp, qo.maxTimestamp, true, true)
 
 	if sqo.Order == nil || sqo.Order.Index == nil {
@@ -84,6 +85,9 @@ func validateQueryInput(sqo model.StreamQueryOptions) error {
 	if len(sqo.TagProjection) == 0 {
 		return errors.New("invalid query options: tagProjection is required")
 	}
+	if sqo.Shard != nil && sqo.Order != nil && sqo.Order.Index != nil {
+		return errors.New("invalid query options: a shard scan can't be ordered by an index")
+	}
 	return nil
 }
 
@@ -114,9 +118,10 @@ func prepareSeriesData(sqo model.StreamQueryOptions) []*pbv1.Series {
 	return series
 }
 
-func prepareQueryOptions(sqo model.StreamQueryOptions) queryOptions {
+func prepareQueryOptions(sqo model.StreamQueryOptions, tagTypes map[string]pbv1.ValueType) queryOptions {
 	return queryOptions{
 		StreamQueryOptions: sqo,
+		tagTypes:           tagTypes,
 		minTimestamp:       sqo.TimeRange.Start.UnixNano(),
 		maxTimestamp:       sqo.TimeRange.End.UnixNano(),
 	}
@@ -201,6 +206,7 @@ func (s *stream) processSegmentsAndBuildFilters(
 	result.sm = s
 	result.qo = queryOptions{
 		StreamQueryOptions: sqo,
+		tagTypes:           s.tagTypes,
 		seriesToEntity:     make(map[common.SeriesID][]*modelv1.TagValue),
 	}
 
@@ -254,6 +260,7 @@ type queryOptions struct {
 	elementFilter  posting.List
 	seriesToEntity map[common.SeriesID][]*modelv1.TagValue
 	sortedSids     []common.SeriesID
+	tagTypes       map[string]pbv1.ValueType
 	model.StreamQueryOptions
 	minTimestamp int64
 	maxTimestamp int64
@@ -264,6 +271,7 @@ func (qo *queryOptions) reset() {
 	qo.elementFilter = nil
 	qo.seriesToEntity = nil
 	qo.sortedSids = nil
+	qo.tagTypes = nil
 	qo.minTimestamp = 0
 	qo.maxTimestamp = 0
 }
@@ -273,6 +281,7 @@ func (qo *queryOptions) copyFrom(other *queryOptions) {
 	qo.elementFilter = other.elementFilter
 	qo.seriesToEntity = other.seriesToEntity
 	qo.sortedSids = other.sortedSids
+	qo.tagTypes = other.tagTypes
 	qo.minTimestamp = other.minTimestamp
 	qo.maxTimestamp = other.maxTimestamp
 }
@@ -320,7 +329,7 @@ func (s *stream) indexSort(ctx context.Context, sqo model.StreamQueryOptions, ta
 	if err != nil {
 		return nil, err
 	}
-	desc := sqo.Order != nil && sqo.Order.Index == nil && sqo.Order.Sort == modelv1.Sort_SORT_DESC
+	desc := sqo.Order.Sort == modelv1.Sort_SORT_DESC
 	return itersort.NewItemIter[*index.DocumentResult](iters, desc), nil
 }
 
@@ -387,6 +396,12 @@ func mustDecodeTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValu
 			values = append(values, string(bb.Buf))
 		}
 		return strArrTagValue(values)
+	case pbv1.ValueTypeFloat64:
+		return float64TagValue(convert.SortableBytesToFloat64(value))
+	case pbv1.ValueTypeBool:
+		return boolTagValue(pbv1.BytesToBool(value))
+	case pbv1.ValueTypeTimestamp:
+		return timestampTagValue(convert.BytesToInt64(value))
 	default:
 		logger.Panicf("unsupported value type: %v", valueType)
 		return nil
@@ -443,6 +458,34 @@ func strArrTagValue(values []string) *modelv1.TagValue {
 	}
 }
 
+func float64TagValue(value float64) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Float{
+			Float: &modelv1.Float{
+				Value: value,
+			},
+		},
+	}
+}
+
+func boolTagValue(value bool) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Bool{
+			Bool: &modelv1.Bool{
+				Value: value,
+			},
+		},
+	}
+}
+
+func timestampTagValue(value int64) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Timestamp{
+			Timestamp: timestamppb.New(time.Unix(0, value)),
+		},
+	}
+}
+
 func updateTimeRange(filterTS posting.List, minTimestamp, maxTimestamp int64) (int64, int64) {
 	if filterTS != nil && !filterTS.IsEmpty() {
 		if minTS, err := filterTS.Min(); err == nil && int64(minTS) > minTimestamp {
@@ -32,11 +32,13 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/index/posting/roaring"
 	itersort "github.com/apache/skywalking-banyandb/pkg/iter/sort"
 	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
 )
 
 type idxResult struct {
 	sortingIter      itersort.Iterator[*index.DocumentResult]
+	budget           protector.Budget
 	sm               *stream
 	pm               *protector.Memory
 	tabs             []*tsTable
@@ -45,6 +47,7 @@ type idxResult struct {
 	snapshots        []*snapshot
 	segments         []storage.Segment[*tsTable, option]
 	qo               queryOptions
+	reserved         uint64
 	loaded           bool
 	asc              bool
 }
@@ -69,7 +72,7 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 		if s == nil {
 			continue
 		}
-		parts, n = s.getParts(parts, qo.minTimestamp, qo.maxTimestamp)
+		parts, n = s.getParts(parts, qo.minTimestamp, qo.maxTimestamp, 0)
 		if n < 1 {
 			s.decRef()
 			continue
@@ -88,7 +91,7 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 		return fmt.Errorf("cannot init tstIter: %w", ti.Error())
 	}
 	var hit int
-	var totalBlockBytes uint64
+	var totalBlockBytes, totalRows uint64
 	for ti.nextBlock() {
 		if hit%checkDoneEvery == 0 {
 			select {
@@ -103,13 +106,17 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 		bc.init(p.p, p.curBlock, qo)
 		qr.data = append(qr.data, bc)
 		totalBlockBytes += bc.bm.uncompressedSizeBytes
+		totalRows += bc.bm.count
 	}
 	if ti.Error() != nil {
 		return fmt.Errorf("cannot iterate tstIter: %w", ti.Error())
 	}
+	executor.QueryStatsFromContext(ctx).AddScanned(totalRows)
 	if err := qr.pm.AcquireResource(ctx, totalBlockBytes); err != nil {
 		return fmt.Errorf("cannot acquire resource: %w", err)
 	}
+	// The blocks are released with the cursors once the batch is merged.
+	qr.budget, qr.reserved = protector.BudgetFromContext(ctx), totalBlockBytes
 	return nil
 }
 
@@ -182,10 +189,21 @@ func (qr *idxResult) nextValue() *model.StreamResult {
 }
 
 func (qr *idxResult) loadSortingData(ctx context.Context) *model.StreamResult {
+	for {
+		r, ok := qr.loadSortingBatch(ctx)
+		// The tag predicates might filter out all the elements of a batch, then the next batch is loaded.
+		if r != nil || !ok || len(qr.qo.TagPredicates) == 0 {
+			return r
+		}
+	}
+}
+
+func (qr *idxResult) loadSortingBatch(ctx context.Context) (*model.StreamResult, bool) {
 	var qo queryOptions
 	qo.StreamQueryOptions = qr.qo.StreamQueryOptions
 	qo.elementFilter = roaring.NewPostingList()
 	qo.seriesToEntity = qr.qo.seriesToEntity
+	qo.tagTypes = qr.qo.tagTypes
 	qr.elementIDsSorted = qr.elementIDsSorted[:0]
 	count, searchedSize := 1, 0
 	tracer := query.GetTracer(ctx)
@@ -242,9 +260,9 @@ func (qr *idxResult) loadSortingData(ctx context.Context) *model.StreamResult {
 		}
 	}
 	if qo.elementFilter.IsEmpty() {
-		return nil
+		return nil, false
 	}
-	return qr.load(ctx, qo)
+	return qr.load(ctx, qo), true
 }
 
 func (qr *idxResult) releaseParts() {
@@ -261,6 +279,10 @@ func (qr *idxResult) releaseBlockCursor() {
 		qr.data[i] = nil
 	}
 	qr.data = qr.data[:0]
+	if qr.budget != nil {
+		qr.budget.Release(qr.reserved)
+	}
+	qr.budget, qr.reserved = nil, 0
 }
 
 func (qr *idxResult) Release() {
@@ -172,7 +172,8 @@ func loadBlockCursor(bc *blockCursor, tmpBlock *block, qo queryOptions, sm *stre
 	for _, tagFamilyProj := range bc.tagProjection {
 		for j, tagProj := range tagFamilyProj.Names {
 			tagSpec := is.tagMap[tagProj]
-			if tagSpec.IndexedOnly {
+			// A shard scan reads the indexed-only entity tags as well, which place the elements on the new nodes.
+			if tagSpec.IndexedOnly && qo.Shard == nil {
 				continue
 			}
 			entityPos := is.indexRuleLocators.EntitySet[tagProj]
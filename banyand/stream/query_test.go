@@ -0,0 +1,259 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting/roaring"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	"github.com/apache/skywalking-banyandb/pkg/watcher"
+)
+
+func Test_getBlockScanner_filterByShard(t *testing.T) {
+	// The tables of both shards number their first parts as 1.
+	tstA := newQueryTestTable(t, &elements{
+		seriesIDs:   []common.SeriesID{1, 1},
+		timestamps:  []int64{1, 2},
+		elementIDs:  []uint64{11, 12},
+		tagFamilies: make([][]tagValues, 2),
+	})
+	tstB := newQueryTestTable(t, &elements{
+		seriesIDs:   []common.SeriesID{1, 1},
+		timestamps:  []int64{3, 4},
+		elementIDs:  []uint64{21, 22},
+		tagFamilies: make([][]tagValues, 2),
+	})
+	filter := &tableFilter{lists: map[index.Searcher]posting.List{
+		tstA.index.store: roaring.NewPostingListWithInitialData(11),
+		tstB.index.store: roaring.NewPostingListWithInitialData(22),
+	}}
+	qo := queryOptions{
+		StreamQueryOptions: model.StreamQueryOptions{Filter: filter},
+		sortedSids:         []common.SeriesID{1},
+		minTimestamp:       0,
+		maxTimestamp:       10,
+	}
+	bsn, err := getBlockScanner(context.Background(), &tablesSegment{tables: []*tsTable{tstA, tstB}}, qo,
+		logger.GetLogger("test"), nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, bsn)
+	defer bsn.close()
+
+	want := map[*tsTable]uint64{tstA: 11, tstB: 22}
+	blockCh := make(chan *blockScanResultBatch, len(bsn.parts))
+	for len(bsn.parts) > 0 {
+		bsn.scan(context.Background(), blockCh)
+	}
+	close(blockCh)
+	var scanned int
+	for batch := range blockCh {
+		require.NoError(t, batch.err)
+		for _, bs := range batch.bss {
+			tst := tableOfPart(t, bs.p, tstA, tstB)
+			require.NotNil(t, bs.qo.elementFilter)
+			require.Equal(t, []uint64{want[tst]}, bs.qo.elementFilter.ToSlice())
+			scanned++
+		}
+		releaseBlockScanResultBatch(batch)
+	}
+	require.Equal(t, 2, scanned)
+}
+
+func Test_indexSort_desc(t *testing.T) {
+	tstA := newQueryTestTable(t, nil)
+	writeDurations(t, tstA, map[uint64]int64{1: 100, 3: 300})
+	tstB := newQueryTestTable(t, nil)
+	writeDurations(t, tstB, map[uint64]int64{2: 200, 4: 400})
+
+	s := &stream{}
+	iter, err := s.indexSort(context.Background(), durationQueryOptions(modelv1.Sort_SORT_DESC, 10), []*tsTable{tstA, tstB}, []uint64{1})
+	require.NoError(t, err)
+	defer iter.Close()
+	var got []uint64
+	for iter.Next() {
+		got = append(got, iter.Val().DocID)
+	}
+	require.Equal(t, []uint64{4, 3, 2, 1}, got)
+}
+
+func Test_idxResult_releaseBudget(t *testing.T) {
+	tst := newQueryTestTable(t, &elements{
+		seriesIDs:   []common.SeriesID{1, 1, 1, 1},
+		timestamps:  []int64{1, 2, 3, 4},
+		elementIDs:  []uint64{1, 2, 3, 4},
+		tagFamilies: make([][]tagValues, 4),
+	})
+	writeDurations(t, tst, map[uint64]int64{1: 100, 2: 200, 3: 300, 4: 400})
+	s := tst.currentSnapshot()
+	blockSize := s.parts[0].p.partMetadata.UncompressedSizeBytes
+	s.decRef()
+
+	// Every batch of one element scans the whole block, whose sum exceeds the budget.
+	budget := executor.NewMemoryBudget(2 * blockSize)
+	ctx := protector.WithBudget(context.Background(), budget)
+	sqo := durationQueryOptions(modelv1.Sort_SORT_ASC, 1)
+	sm := &stream{}
+	iter, err := sm.indexSort(ctx, sqo, []*tsTable{tst}, []uint64{1})
+	require.NoError(t, err)
+	qr := &idxResult{
+		sortingIter: iter,
+		sm:          sm,
+		pm:          &protector.Memory{},
+		tabs:        []*tsTable{tst},
+		qo:          queryOptions{StreamQueryOptions: sqo},
+	}
+	defer qr.Release()
+	var batches int
+	for r := qr.Pull(ctx); r != nil; r = qr.Pull(ctx) {
+		require.NoError(t, r.Error)
+		batches++
+	}
+	require.Equal(t, 4, batches)
+	require.Equal(t, blockSize, budget.Peak())
+}
+
+var durationRule = &databasev1.IndexRule{
+	Metadata: &commonv1.Metadata{Name: "duration", Id: 1},
+	Tags:     []string{"duration"},
+	Type:     databasev1.IndexRule_TYPE_INVERTED,
+}
+
+// writeDurations indexes the durations of the elements of the series 1, whose timestamps are their ids.
+func writeDurations(t *testing.T, tst *tsTable, durations map[uint64]int64) {
+	docs := make(index.Documents, 0, len(durations))
+	for docID, duration := range durations {
+		docs = append(docs, index.Document{
+			DocID: docID,
+			Fields: []index.Field{index.NewIntField(index.FieldKey{
+				IndexRuleID: durationRule.GetMetadata().GetId(),
+				SeriesID:    1,
+			}, duration)},
+			Timestamp: int64(docID),
+		})
+	}
+	require.NoError(t, tst.index.Write(docs))
+}
+
+func durationQueryOptions(sort modelv1.Sort, maxElementSize int) model.StreamQueryOptions {
+	tr := timestamp.NewInclusiveTimeRange(time.Unix(0, 0), time.Unix(0, 10))
+	return model.StreamQueryOptions{
+		TimeRange:      &tr,
+		Order:          &index.OrderBy{Index: durationRule, Sort: sort},
+		MaxElementSize: maxElementSize,
+	}
+}
+
+func newQueryTestTable(t *testing.T, es *elements) *tsTable {
+	tmpPath, defFn := test.Space(require.New(t))
+	t.Cleanup(defFn)
+	ei, err := newElementIndex(context.TODO(), tmpPath, 0, nil)
+	require.NoError(t, err)
+	tst := &tsTable{
+		index:         ei,
+		loopCloser:    run.NewCloser(2),
+		introductions: make(chan *introduction),
+	}
+	flushCh := make(chan *flusherIntroduction)
+	mergeCh := make(chan *mergerIntroduction)
+	introducerWatcher := make(watcher.Channel, 1)
+	go tst.introducerLoop(flushCh, mergeCh, introducerWatcher, 1)
+	t.Cleanup(func() {
+		_ = tst.Close()
+	})
+	if es != nil {
+		tst.mustAddElements(es)
+	}
+	return tst
+}
+
+func tableOfPart(t *testing.T, p *part, tables ...*tsTable) *tsTable {
+	for _, tst := range tables {
+		s := tst.currentSnapshot()
+		for _, pw := range s.parts {
+			if pw.p == p {
+				s.decRef()
+				return tst
+			}
+		}
+		s.decRef()
+	}
+	require.FailNow(t, "the part isn't in any table")
+	return nil
+}
+
+// tableFilter returns the element ids of the table whose index is searched.
+type tableFilter struct {
+	lists map[index.Searcher]posting.List
+}
+
+func (f *tableFilter) Execute(getSearcher index.GetSearcher, _ common.SeriesID, _ *index.RangeOpts) (posting.List, posting.List, error) {
+	searcher, err := getSearcher(databasev1.IndexRule_TYPE_INVERTED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f.lists[searcher], roaring.NewPostingList(), nil
+}
+
+func (f *tableFilter) String() string {
+	return "table filter"
+}
+
+type tablesSegment struct {
+	tables []*tsTable
+}
+
+func (s *tablesSegment) DecRef() {}
+
+func (s *tablesSegment) GetTimeRange() timestamp.TimeRange {
+	return timestamp.TimeRange{}
+}
+
+func (s *tablesSegment) CreateTSTableIfNotExist(_ common.ShardID) (*tsTable, error) {
+	return nil, nil
+}
+
+func (s *tablesSegment) Tables() []*tsTable {
+	return s.tables
+}
+
+func (s *tablesSegment) Lookup(_ context.Context, _ []*pbv1.Series) (pbv1.SeriesList, error) {
+	return nil, nil
+}
+
+func (s *tablesSegment) IndexDB() storage.IndexDB {
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -36,6 +37,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -68,14 +70,18 @@ type service struct {
 	omr                 observability.MetricsRegistry
 	lfs                 fs.FileSystem
 	pm                  *protector.Memory
+	clientTLS           *pub.ClientTLS
 	l                   *logger.Logger
 	schemaRepo          schemaRepo
 	root                string
+	nodeID              string
 	snapshotDir         string
 	dataPath            string
 	option              option
 	maxDiskUsagePercent int
 	maxFileSnapshotNum  int
+	// migrationMarks holds the marks of the shards whose migrations wait for a catch-up.
+	migrationMarks sync.Map
 }
 
 func (s *service) Stream(metadata *commonv1.Metadata) (Stream, error) {
@@ -141,6 +147,7 @@ func (s *service) PreRun(ctx context.Context) error {
 		return errors.New("node id is empty")
 	}
 	node := val.(common.Node)
+	s.nodeID = node.NodeID
 	if s.dataPath == "" {
 		s.dataPath = filepath.Join(path, storage.DataDir)
 	}
@@ -159,6 +166,15 @@ func (s *service) PreRun(ctx context.Context) error {
 	if err := s.pipeline.Subscribe(data.TopicDeleteExpiredStreamSegments, &deleteStreamSegmentsListener{s: s}); err != nil {
 		return err
 	}
+	if err := s.pipeline.Subscribe(data.TopicStreamMigrateShard, &migrateShardListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicStreamCountShard, &countShardListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicStreamGroupSize, &groupSizeListener{s: s}); err != nil {
+		return err
+	}
 	s.writeListener = setUpWriteCallback(s.l, &s.schemaRepo, s.maxDiskUsagePercent)
 	err := s.pipeline.Subscribe(data.TopicStreamWrite, s.writeListener)
 	if err != nil {
@@ -179,12 +195,16 @@ func (s *service) GracefulStop() {
 }
 
 // NewService returns a new service.
-func NewService(metadata metadata.Repo, pipeline queue.Server, omr observability.MetricsRegistry, pm *protector.Memory) (Service, error) {
+// The clientTLS configures the connections to the new nodes of the migrated shards. They're not encrypted if it's nil.
+func NewService(metadata metadata.Repo, pipeline queue.Server, omr observability.MetricsRegistry, pm *protector.Memory,
+	clientTLS *pub.ClientTLS,
+) (Service, error) {
 	return &service{
-		metadata: metadata,
-		pipeline: pipeline,
-		omr:      omr,
-		pm:       pm,
+		metadata:  metadata,
+		pipeline:  pipeline,
+		omr:       omr,
+		pm:        pm,
+		clientTLS: clientTLS,
 	}, nil
 }
 
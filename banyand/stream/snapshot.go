@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort" // added for sorting parts
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -35,6 +36,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
 	"github.com/apache/skywalking-banyandb/pkg/schema"
 )
 
@@ -49,6 +51,34 @@ func (tst *tsTable) currentSnapshot() *snapshot {
 	return s
 }
 
+// shardScan reports whether the table belongs to the shard which the scan restricts a query to.
+// It returns the id of the last part which the previous pass of a shard migration read as well.
+func (tst *tsTable) shardScan(scan *model.ShardScan) (bool, uint64) {
+	if scan == nil {
+		return true, 0
+	}
+	if tst.p.Shard != strconv.Itoa(int(scan.ID)) {
+		return false, 0
+	}
+	return true, scan.Marks[tst.p.Segment]
+}
+
+// lastPartID returns the id of the last part in the current snapshot.
+func (tst *tsTable) lastPartID() uint64 {
+	s := tst.currentSnapshot()
+	if s == nil {
+		return 0
+	}
+	defer s.decRef()
+	var id uint64
+	for _, p := range s.parts {
+		if p.ID() > id {
+			id = p.ID()
+		}
+	}
+	return id
+}
+
 type snapshotCreator rune
 
 const (
@@ -66,13 +96,16 @@ type snapshot struct {
 	ref int32
 }
 
-func (s *snapshot) getParts(dst []*part, minTimestamp, maxTimestamp int64) ([]*part, int) {
+func (s *snapshot) getParts(dst []*part, minTimestamp, maxTimestamp int64, afterPartID uint64) ([]*part, int) {
 	var count int
 	for _, p := range s.parts {
 		pm := p.p.partMetadata
 		if maxTimestamp < pm.MinTimestamp || minTimestamp > pm.MaxTimestamp {
 			continue
 		}
+		if afterPartID > 0 && p.ID() <= afterPartID {
+			continue
+		}
 		dst = append(dst, p.p)
 		count++
 	}
@@ -29,6 +29,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/partition"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/schema"
@@ -91,6 +92,7 @@ type stream struct {
 	schema      *databasev1.Stream
 	pm          *protector.Memory
 	schemaRepo  *schemaRepo
+	tagTypes    map[string]pbv1.ValueType
 	name        string
 	group       string
 }
@@ -119,6 +121,15 @@ func (s *stream) parseSpec() {
 	var is indexSchema
 	is.parse(s.schema)
 	s.indexSchema.Store(is)
+	// The tags written before the schema widens their types are read as the current types.
+	s.tagTypes = make(map[string]pbv1.ValueType)
+	for _, tf := range s.schema.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			if t.GetType() != databasev1.TagType_TAG_TYPE_UNSPECIFIED {
+				s.tagTypes[t.GetName()] = pbv1.MustTagValueSpecToValueType(t.GetType())
+			}
+		}
+	}
 }
 
 type streamSpec struct {
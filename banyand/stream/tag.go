@@ -19,6 +19,7 @@ package stream
 
 import (
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -28,11 +29,13 @@ import (
 type tag struct {
 	name      string
 	values    [][]byte
+	dict      encoding.Dictionary
 	valueType pbv1.ValueType
 }
 
 func (t *tag) reset() {
 	t.name = ""
+	t.dict.Reset()
 
 	values := t.values
 	for i := range values {
@@ -51,17 +54,85 @@ func (t *tag) resizeValues(valuesLen int) [][]byte {
 	return values
 }
 
+// setValue sets the i-th value of the tag. The elements written before and after
+// the schema widens the type of the tag could be in the same block.
+func (t *tag) setValue(i int, valueType pbv1.ValueType, value []byte) {
+	if i > 0 && t.valueType != valueType {
+		switch {
+		case pbv1.CanWiden(t.valueType, valueType):
+			for j := 0; j < i; j++ {
+				t.values[j] = widenValue(t.valueType, valueType, t.values[j])
+			}
+		case pbv1.CanWiden(valueType, t.valueType):
+			t.values[i] = widenValue(valueType, t.valueType, value)
+			return
+		}
+	}
+	t.valueType = valueType
+	t.values[i] = value
+}
+
+// appendValues appends the values of the other tag in [idx, offset).
+// The narrower one of the tags is widened if their types are different,
+// which happens once the schema widens the type of the tag.
+func (t *tag) appendValues(other *tag, idx, offset int) {
+	values := other.values[idx:offset]
+	if t.valueType != other.valueType {
+		switch {
+		case pbv1.CanWiden(t.valueType, other.valueType):
+			t.widen(other.valueType)
+		case pbv1.CanWiden(other.valueType, t.valueType):
+			for _, v := range values {
+				t.values = append(t.values, widenValue(other.valueType, t.valueType, v))
+			}
+			return
+		}
+	}
+	t.values = append(t.values, values...)
+}
+
+// widen converts the values to the wider type.
+func (t *tag) widen(valueType pbv1.ValueType) {
+	if t.valueType == valueType || !pbv1.CanWiden(t.valueType, valueType) {
+		return
+	}
+	for i := range t.values {
+		t.values[i] = widenValue(t.valueType, valueType, t.values[i])
+	}
+	t.valueType = valueType
+	// The dictionary holds the values of the narrower type.
+	t.dict.Reset()
+}
+
+func widenValue(from, to pbv1.ValueType, value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+	switch {
+	case from == pbv1.ValueTypeInt64 && to == pbv1.ValueTypeFloat64:
+		return convert.Float64ToSortableBytes(float64(convert.BytesToInt64(value)))
+	case from == pbv1.ValueTypeStr && to == pbv1.ValueTypeStrArr:
+		return marshalVarArray(nil, value)
+	default:
+		// An int64 array is encoded as the concatenated int64 values.
+		return value
+	}
+}
+
 func (t *tag) mustWriteTo(tm *tagMetadata, tagWriter *writer) {
 	tm.reset()
 
 	tm.name = t.name
 	tm.valueType = t.valueType
+	if t.valueType == pbv1.ValueTypeStr {
+		tm.codec = encoding.AdaptiveCodec(t.values)
+	}
 
 	bb := bigValuePool.Generate()
 	defer bigValuePool.Release(bb)
 
 	// marshal values
-	bb.Buf = encoding.EncodeBytesBlock(bb.Buf[:0], t.values)
+	bb.Buf = encoding.EncodeColumn(bb.Buf[:0], t.values, tm.codec)
 	tm.size = uint64(len(bb.Buf))
 	if tm.size > maxValuesBlockSize {
 		logger.Panicf("too valuesSize: %d bytes; mustn't exceed %d bytes", tm.size, maxValuesBlockSize)
@@ -89,7 +160,7 @@ func (t *tag) mustReadValues(decoder *encoding.BytesBlockDecoder, reader fs.Read
 	bb.Buf = bytes.ResizeOver(bb.Buf, int(valuesSize))
 	fs.MustReadData(reader, int64(cm.offset), bb.Buf)
 	var err error
-	t.values, err = decoder.Decode(t.values[:0], bb.Buf, count)
+	t.values, err = decoder.DecodeDictionary(&t.dict, t.values[:0], bb.Buf, count, cm.codec)
 	if err != nil {
 		logger.Panicf("%s: cannot decode values: %v", reader.Path(), err)
 	}
@@ -112,7 +183,7 @@ func (t *tag) mustSeqReadValues(decoder *encoding.BytesBlockDecoder, reader *seq
 	bb.Buf = bytes.ResizeOver(bb.Buf, int(valuesSize))
 	reader.mustReadFull(bb.Buf)
 	var err error
-	t.values, err = decoder.Decode(t.values[:0], bb.Buf, count)
+	t.values, err = decoder.DecodeDictionary(&t.dict, t.values[:0], bb.Buf, count, cm.codec)
 	if err != nil {
 		logger.Panicf("%s: cannot decode values: %v", reader.Path(), err)
 	}
@@ -26,27 +26,39 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 )
 
+// tagCodecFlag marks the value type of a tag which isn't encoded by the default codec.
+// The codec follows the value type, so the parts written before the codec was introduced stay readable.
+const tagCodecFlag = 0x80
+
 type tagMetadata struct {
 	name string
 	dataBlock
 	valueType pbv1.ValueType
+	codec     encoding.Codec
 }
 
 func (tm *tagMetadata) reset() {
 	tm.name = ""
 	tm.valueType = 0
+	tm.codec = encoding.Codec{}
 	tm.dataBlock.reset()
 }
 
 func (tm *tagMetadata) copyFrom(src *tagMetadata) {
 	tm.name = src.name
 	tm.valueType = src.valueType
+	tm.codec = src.codec
 	tm.dataBlock.copyFrom(&src.dataBlock)
 }
 
 func (tm *tagMetadata) marshal(dst []byte) []byte {
 	dst = encoding.EncodeBytes(dst, convert.StringToBytes(tm.name))
-	dst = append(dst, byte(tm.valueType))
+	if tm.codec.IsDefault() {
+		dst = append(dst, byte(tm.valueType))
+	} else {
+		dst = append(dst, byte(tm.valueType)|tagCodecFlag)
+		dst = tm.codec.Marshal(dst)
+	}
 	dst = tm.dataBlock.marshal(dst)
 	return dst
 }
@@ -60,8 +72,14 @@ func (tm *tagMetadata) unmarshal(src []byte) ([]byte, error) {
 	if len(src) < 1 {
 		return nil, fmt.Errorf("cannot unmarshal tagMetadata.valueType: src is too short")
 	}
-	tm.valueType = pbv1.ValueType(src[0])
+	tm.valueType = pbv1.ValueType(src[0] &^ tagCodecFlag)
+	hasCodec := src[0]&tagCodecFlag != 0
 	src = src[1:]
+	if hasCodec {
+		if src, tm.codec, err = encoding.UnmarshalCodec(src); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal tagMetadata.codec: %w", err)
+		}
+	}
 	src = tm.dataBlock.unmarshal(src)
 	return src, nil
 }
@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -70,6 +71,20 @@ func Test_tagMetadata_marshal(t *testing.T) {
 	assert.Equal(t, original, unmarshaled)
 }
 
+func Test_tagMetadata_marshalCodec(t *testing.T) {
+	original := &tagMetadata{
+		name:      "test",
+		valueType: pbv1.ValueTypeStr,
+		dataBlock: dataBlock{offset: 1, size: 10},
+		codec:     encoding.Codec{Encoding: encoding.ColumnEncodingDictionary},
+	}
+
+	unmarshaled := &tagMetadata{}
+	_, err := unmarshaled.unmarshal(original.marshal(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, original, unmarshaled)
+}
+
 func Test_tagFamilyMetadata_reset(t *testing.T) {
 	tfm := &tagFamilyMetadata{
 		tagMetadata: []tagMetadata{
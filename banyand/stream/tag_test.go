@@ -23,8 +23,10 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
 )
 
 func TestTag_reset(t *testing.T) {
@@ -54,6 +56,39 @@ func TestTag_resizeValues(t *testing.T) {
 	assert.True(t, cap(values) >= 6) // The capacity is at least 6, but could be more
 }
 
+func TestTag_widen(t *testing.T) {
+	tt := &tag{
+		name:      "score",
+		valueType: pbv1.ValueTypeInt64,
+		values:    make([][]byte, 2),
+	}
+	tt.setValue(0, pbv1.ValueTypeInt64, convert.Int64ToBytes(1))
+	// The schema widens the tag after the first element is written.
+	tt.setValue(1, pbv1.ValueTypeFloat64, convert.Float64ToSortableBytes(2.5))
+	assert.Equal(t, pbv1.ValueTypeFloat64, tt.valueType)
+	assert.Equal(t, [][]byte{convert.Float64ToSortableBytes(1), convert.Float64ToSortableBytes(2.5)}, tt.values)
+
+	narrower := &tag{
+		name:      "score",
+		valueType: pbv1.ValueTypeInt64,
+		values:    [][]byte{convert.Int64ToBytes(3), nil},
+	}
+	tt.appendValues(narrower, 0, 2)
+	assert.Equal(t, [][]byte{
+		convert.Float64ToSortableBytes(1), convert.Float64ToSortableBytes(2.5),
+		convert.Float64ToSortableBytes(3), nil,
+	}, tt.values)
+
+	str := &tag{
+		name:      "layer",
+		valueType: pbv1.ValueTypeStr,
+		values:    [][]byte{[]byte("a")},
+	}
+	str.widen(pbv1.ValueTypeStrArr)
+	assert.Equal(t, pbv1.ValueTypeStrArr, str.valueType)
+	assert.Equal(t, [][]byte{marshalVarArray(nil, []byte("a"))}, str.values)
+}
+
 func TestTag_mustWriteTo_mustReadValues(t *testing.T) {
 	original := &tag{
 		name:      "test",
@@ -84,6 +119,37 @@ func TestTag_mustWriteTo_mustReadValues(t *testing.T) {
 	assert.Equal(t, original.values, unmarshaled.values)
 }
 
+func TestTag_mustWriteTo_dictionary(t *testing.T) {
+	original := &tag{
+		name:      "status",
+		valueType: pbv1.ValueTypeStr,
+		values: [][]byte{
+			[]byte("ok"), []byte("error"), []byte("ok"), nil,
+			[]byte("ok"), []byte("timeout"), []byte("ok"), []byte("error"),
+		},
+	}
+
+	tm := &tagMetadata{}
+	buf := &bytes.Buffer{}
+	w := &writer{}
+	w.init(buf)
+	original.mustWriteTo(tm, w)
+	assert.Equal(t, encoding.ColumnEncodingDictionary, tm.codec.Encoding)
+
+	decoder := &encoding.BytesBlockDecoder{}
+	unmarshaled := &tag{}
+	unmarshaled.mustReadValues(decoder, buf, *tm, uint64(len(original.values)))
+	assert.Equal(t, original.values, unmarshaled.values)
+	assert.Equal(t, len(original.values), unmarshaled.dict.Len())
+
+	b := &block{tagFamilies: []tagFamily{{name: "default", tags: []tag{*unmarshaled}}}}
+	idxList := matchTagPredicates(b, []model.TagPredicate{{Family: "default", Name: "status", Values: []string{"error", "timeout"}}},
+		[]int{0, 1, 2, 3, 4, 5, 6, 7})
+	assert.Equal(t, []int{1, 5, 7}, idxList)
+	idxList = matchTagPredicates(b, []model.TagPredicate{{Family: "default", Name: "unknown", Values: []string{"error"}}}, idxList)
+	assert.Equal(t, []int{1, 5, 7}, idxList)
+}
+
 func TestTagFamily_reset(t *testing.T) {
 	tf := &tagFamily{
 		name: "test",
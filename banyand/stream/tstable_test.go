@@ -142,7 +142,7 @@ func Test_tstIter(t *testing.T) {
 			s = new(snapshot)
 		}
 		defer s.decRef()
-		pp, n := s.getParts(nil, tt.minTimestamp, tt.maxTimestamp)
+		pp, n := s.getParts(nil, tt.minTimestamp, tt.maxTimestamp, 0)
 		require.Equal(t, len(s.parts), n)
 		ti := &tstIter{}
 		ti.init(bma, pp, tt.sids, tt.minTimestamp, tt.maxTimestamp)
@@ -97,14 +97,14 @@ func (w *writeCallback) handle(dst map[string]*elementsInGroup, writeEvent *stre
 		eg.latestTS = ts
 	}
 
+	shardID := common.ShardID(writeEvent.ShardId)
 	var et *elementsInTable
 	for i := range eg.tables {
-		if eg.tables[i].timeRange.Contains(ts) {
+		if eg.tables[i].shardID == shardID && eg.tables[i].timeRange.Contains(ts) {
 			et = eg.tables[i]
 			break
 		}
 	}
-	shardID := common.ShardID(writeEvent.ShardId)
 	if et == nil {
 		var segment storage.Segment[*tsTable, option]
 		for _, seg := range eg.segments {
@@ -126,17 +126,21 @@ func (w *writeCallback) handle(dst map[string]*elementsInGroup, writeEvent *stre
 		et = &elementsInTable{
 			timeRange: segment.GetTimeRange(),
 			tsTable:   tstb,
+			shardID:   shardID,
 			elements:  generateElements(),
 		}
 		et.elements.reset()
 		eg.tables = append(eg.tables, et)
 	}
 	et.elements.timestamps = append(et.elements.timestamps, ts)
-	docIDBuilder.Reset()
-	docIDBuilder.WriteString(req.Metadata.Name)
-	docIDBuilder.WriteByte('|')
-	docIDBuilder.WriteString(req.Element.ElementId)
-	eID := convert.HashStr(docIDBuilder.String())
+	eID := writeEvent.ElementInternalId
+	if eID == 0 {
+		docIDBuilder.Reset()
+		docIDBuilder.WriteString(req.Metadata.Name)
+		docIDBuilder.WriteByte('|')
+		docIDBuilder.WriteString(req.Element.ElementId)
+		eID = convert.HashStr(docIDBuilder.String())
+	}
 	et.elements.elementIDs = append(et.elements.elementIDs, eID)
 	stm, ok := w.schemaRepo.loadStream(writeEvent.GetRequest().GetMetadata())
 	if !ok {
@@ -180,14 +184,15 @@ func (w *writeCallback) handle(dst map[string]*elementsInGroup, writeEvent *stre
 		}
 
 		for j := range tagFamilySpec.Tags {
+			t := tagFamilySpec.Tags[j]
 			var tagValue *modelv1.TagValue
-			if tagFamily == pbv1.NullTagFamily || len(tagFamily.Tags) <= j {
+			// The values of the deprecated tags are dropped.
+			if tagFamily == pbv1.NullTagFamily || len(tagFamily.Tags) <= j || t.Deprecated {
 				tagValue = pbv1.NullTagValue
 			} else {
 				tagValue = tagFamily.Tags[j]
 			}
 
-			t := tagFamilySpec.Tags[j]
 			if r, ok := tfr[t.Name]; ok && tagValue != pbv1.NullTagValue {
 				fields = appendField(fields, index.FieldKey{
 					IndexRuleID: r.GetMetadata().GetId(),
@@ -325,6 +330,21 @@ func encodeTagValue(name string, tagType databasev1.TagType, tagVal *modelv1.Tag
 		for i := range tagVal.GetStrArray().Value {
 			tv.valueArr[i] = []byte(tagVal.GetStrArray().Value[i])
 		}
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		tv.valueType = pbv1.ValueTypeFloat64
+		if tagVal.GetFloat() != nil {
+			tv.value = convert.Float64ToSortableBytes(tagVal.GetFloat().GetValue())
+		}
+	case databasev1.TagType_TAG_TYPE_BOOL:
+		tv.valueType = pbv1.ValueTypeBool
+		if tagVal.GetBool() != nil {
+			tv.value = pbv1.BoolToBytes(tagVal.GetBool().GetValue())
+		}
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		tv.valueType = pbv1.ValueTypeTimestamp
+		if tagVal.GetTimestamp() != nil {
+			tv.value = convert.Int64ToBytes(tagVal.GetTimestamp().AsTime().UnixNano())
+		}
 	default:
 		logger.Panicf("unsupported tag value type: %T", tagVal.GetValue())
 	}
@@ -375,6 +395,30 @@ func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1
 			f.NoSort = noSort
 			dest = append(dest, f)
 		}
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		v := tagVal.GetFloat()
+		if v == nil {
+			return dest
+		}
+		f := index.NewFloatField(fieldKey, v.Value)
+		f.NoSort = noSort
+		dest = append(dest, f)
+	case databasev1.TagType_TAG_TYPE_BOOL:
+		v := tagVal.GetBool()
+		if v == nil {
+			return dest
+		}
+		f := index.NewBytesField(fieldKey, pbv1.BoolToBytes(v.Value))
+		f.NoSort = noSort
+		dest = append(dest, f)
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		v := tagVal.GetTimestamp()
+		if v == nil {
+			return dest
+		}
+		f := index.NewIntField(fieldKey, v.AsTime().UnixNano())
+		f.NoSort = noSort
+		dest = append(dest, f)
 	default:
 		logger.Panicf("unsupported tag value type: %T", tagVal.GetValue())
 	}
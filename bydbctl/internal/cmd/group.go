@@ -134,6 +134,7 @@ func newGroupCmd() *cobra.Command {
 	}
 
 	bindTLSRelatedFlag(createCmd, updateCmd, listCmd, getCmd, deleteCmd)
-	groupCmd.AddCommand(createCmd, updateCmd, listCmd, getCmd, deleteCmd)
+	historyCmd, rollbackCmd := newHistoryCmds("group", "/api/v1/group/schema")
+	groupCmd.AddCommand(createCmd, updateCmd, listCmd, getCmd, deleteCmd, historyCmd, rollbackCmd)
 	return groupCmd
 }
@@ -18,6 +18,7 @@
 package cmd_test
 
 import (
+	"strconv"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -95,6 +96,54 @@ resource_opts:
 		Expect(out).To(ContainSubstring("group group1 is updated"))
 	})
 
+	It("roll back group", func() {
+		rootCmd.SetArgs([]string{"group", "update", "-f", "-"})
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: group1
+catalog: CATALOG_STREAM
+resource_opts:
+  shard_num: 2
+  segment_interval:
+    unit: UNIT_DAY
+    num: 1
+  ttl:
+    unit: UNIT_DAY
+    num: 3`))
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(out).To(ContainSubstring("group group1 is updated"))
+		// history
+		rootCmd.SetArgs([]string{"group", "history", "-g", "group1"})
+		out = capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		historyResp := new(databasev1.GroupRegistryServiceListRevisionsResponse)
+		helpers.UnmarshalYAML([]byte(out), historyResp)
+		Expect(historyResp.Group).To(HaveLen(2))
+		Expect(historyResp.Group[0].ResourceOpts.Ttl.Num).To(Equal(uint32(3)))
+		Expect(historyResp.Group[1].ResourceOpts.Ttl.Num).To(Equal(uint32(7)))
+		revision := strconv.FormatInt(historyResp.Group[1].Metadata.ModRevision, 10)
+		// rollback
+		rootCmd.SetArgs([]string{"group", "rollback", "-g", "group1", "-r", revision})
+		out = capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(out).To(ContainSubstring("group group1 is rolled back to the revision " + revision))
+		rootCmd.SetArgs([]string{"group", "get", "-g", "group1"})
+		out = capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		resp := new(databasev1.GroupRegistryServiceGetResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		Expect(resp.Group.ResourceOpts.Ttl.Num).To(Equal(uint32(7)))
+	})
+
 	It("delete group", func() {
 		rootCmd.SetArgs([]string{"group", "delete", "-g", "group1"})
 		out := capturer.CaptureStdout(func() {
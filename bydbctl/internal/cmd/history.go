@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+var revision int64
+
+// newHistoryCmds returns the commands to list the revisions of a schema and to roll it back.
+// The schema is identified by the group if it is a group, otherwise by the group and the name.
+func newHistoryCmds(kind, schemaPath string) (historyCmd, rollbackCmd *cobra.Command) {
+	isGroup := kind == "group"
+	target := pathTemp
+	usage := "[-g group] -n name"
+	if isGroup {
+		target = "/{group}"
+		usage = "[-g group]"
+	}
+	setPathParams := func(request request) *resty.Request {
+		req := request.req.SetPathParam("group", request.group)
+		if !isGroup {
+			req = req.SetPathParam("name", request.name)
+		}
+		return req
+	}
+	identity := func(reqBody reqBody) string {
+		if isGroup {
+			return reqBody.group
+		}
+		return reqBody.group + "." + reqBody.name
+	}
+
+	historyCmd = &cobra.Command{
+		Use:     "history " + usage + " [-r revision]",
+		Version: version.Build(),
+		Short:   fmt.Sprintf("List the revisions of a %s, or get one of them", kind),
+		RunE: func(_ *cobra.Command, _ []string) (err error) {
+			return rest(parseFromFlags, func(request request) (*resty.Response, error) {
+				req := setPathParams(request)
+				if revision > 0 {
+					return req.SetPathParam("revision", strconv.FormatInt(revision, 10)).
+						Get(getPath(schemaPath + "/revisions" + target + "/{revision}"))
+				}
+				return req.Get(getPath(schemaPath + "/revisions" + target))
+			}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+	historyCmd.Flags().Int64VarP(&revision, "revision", "r", 0, "the revision to get, all revisions are listed if absent")
+
+	rollbackCmd = &cobra.Command{
+		Use:     "rollback " + usage + " -r revision",
+		Version: version.Build(),
+		Short:   fmt.Sprintf("Roll a %s back to one of its revisions", kind),
+		RunE: func(_ *cobra.Command, _ []string) (err error) {
+			if revision < 1 {
+				return errors.New("please specify a positive revision")
+			}
+			return rest(parseFromFlags, func(request request) (*resty.Response, error) {
+				return setPathParams(request).SetBody(fmt.Sprintf(`{"modRevision":"%d"}`, revision)).
+					Post(getPath(schemaPath + "/rollback" + target))
+			}, func(_ int, reqBody reqBody, _ []byte) error {
+				fmt.Printf("%s %s is rolled back to the revision %d", kind, identity(reqBody), revision)
+				fmt.Println()
+				return nil
+			}, enableTLS, insecure, cert)
+		},
+	}
+	rollbackCmd.Flags().Int64VarP(&revision, "revision", "r", 0, "the revision to roll back to")
+	_ = rollbackCmd.MarkFlagRequired("revision")
+
+	if !isGroup {
+		bindNameFlag(historyCmd, rollbackCmd)
+	}
+	bindTLSRelatedFlag(historyCmd, rollbackCmd)
+	return historyCmd, rollbackCmd
+}
@@ -140,6 +140,7 @@ func newIndexRuleCmd() *cobra.Command {
 	bindFileFlag(createCmd, updateCmd)
 
 	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd)
-	indexRuleCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd)
+	historyCmd, rollbackCmd := newHistoryCmds("indexRule", indexRuleSchemaPath)
+	indexRuleCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, historyCmd, rollbackCmd)
 	return indexRuleCmd
 }
@@ -99,6 +99,32 @@ func newMeasureCmd() *cobra.Command {
 		},
 	}
 
+	checkCmd := &cobra.Command{
+		Use:     "check -f [file|dir|-]",
+		Version: version.Build(),
+		Short:   "Check whether measures could be updated to the ones in files",
+		RunE: func(cmd *cobra.Command, _ []string) (err error) {
+			return rest(func() ([]reqBody, error) { return parseNameAndGroupFromYAML(cmd.InOrStdin()) },
+				func(request request) (*resty.Response, error) {
+					s := new(databasev1.Measure)
+					err := protojson.Unmarshal(request.data, s)
+					if err != nil {
+						return nil, err
+					}
+					cr := &databasev1.MeasureRegistryServiceCheckCompatibilityRequest{
+						Measure: s,
+					}
+					b, err := protojson.Marshal(cr)
+					if err != nil {
+						return nil, err
+					}
+					return request.req.SetBody(b).
+						SetPathParam("name", request.name).SetPathParam("group", request.group).
+						Post(getPath(measureSchemaPath + "/compatibility" + pathTemp))
+				}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+
 	getCmd := &cobra.Command{
 		Use:     "get [-g group] -n name",
 		Version: version.Build(),
@@ -149,10 +175,11 @@ func newMeasureCmd() *cobra.Command {
 				}, yamlPrinter, enableTLS, insecure, cert)
 		},
 	}
-	bindFileFlag(createCmd, updateCmd, queryCmd)
+	bindFileFlag(createCmd, updateCmd, checkCmd, queryCmd)
 	bindTimeRangeFlag(queryCmd)
 
-	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
-	measureCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
+	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, checkCmd, listCmd, queryCmd)
+	historyCmd, rollbackCmd := newHistoryCmds("measure", measureSchemaPath)
+	measureCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, checkCmd, listCmd, queryCmd, historyCmd, rollbackCmd)
 	return measureCmd
 }
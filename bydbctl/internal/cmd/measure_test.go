@@ -138,6 +138,30 @@ entity:
 		Expect(resp.Measure.TagFamilies[0].Tags[1].GetName()).To(Equal("tag1"))
 	})
 
+	It("check measure schema compatibility", func() {
+		rootCmd.SetArgs([]string{"measure", "check", "-f", "-"})
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: name1
+  group: group1
+tag_families:
+  - name: default
+    tags:
+      - name: id
+        type: TAG_TYPE_STRING_ARRAY
+entity:
+  tagNames: ["id"]`))
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		resp := new(databasev1.MeasureRegistryServiceCheckCompatibilityResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		Expect(resp.Compatible).To(BeFalse())
+		Expect(resp.Incompatibilities).To(HaveLen(1))
+		Expect(resp.Incompatibilities[0]).To(ContainSubstring("id"))
+	})
+
 	It("delete measure schema", func() {
 		// delete
 		rootCmd.SetArgs([]string{"measure", "delete", "-g", "group1", "-n", "name1"})
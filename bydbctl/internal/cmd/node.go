@@ -0,0 +1,54 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+func newNodeCmd() *cobra.Command {
+	nodeCmd := &cobra.Command{
+		Use:     "node",
+		Version: version.Build(),
+		Short:   "Node operation",
+	}
+
+	drainCmd := &cobra.Command{
+		Use:     "drain id",
+		Version: version.Build(),
+		Short:   "Move the shards of a data node to the remaining data nodes and deregister it",
+		Long: `Mark a data node as draining, so that the new writes aren't routed to it.
+Then the shards of the node are moved to the remaining data nodes of the same stage,
+and the node is deregistered once the new nodes hold all its data of the shards.
+The node can be stopped after it's deregistered.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			return rest(func() ([]reqBody, error) { return []reqBody{{name: args[0]}}, nil },
+				func(request request) (*resty.Response, error) {
+					return request.req.SetPathParam("node", request.name).SetBody("{}").Post(getPath("/api/v1/node/drain/{node}"))
+				}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+
+	bindTLSRelatedFlag(drainCmd)
+	nodeCmd.AddCommand(drainCmd)
+	return nodeCmd
+}
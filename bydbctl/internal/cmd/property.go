@@ -220,7 +220,8 @@ func newPropertyCmd() *cobra.Command {
 	bindTLSRelatedFlag(applyDataCmd, deleteDataCmd, queryDataCmd)
 
 	// Add schema commands to schema subcommand
-	schemaCmd.AddCommand(createSchemaCmd, updateSchemaCmd, getSchemaCmd, deleteSchemaCmd, listSchemaCmd)
+	historyCmd, rollbackCmd := newHistoryCmds("property", propertySchemaPath)
+	schemaCmd.AddCommand(createSchemaCmd, updateSchemaCmd, getSchemaCmd, deleteSchemaCmd, listSchemaCmd, historyCmd, rollbackCmd)
 
 	// Add data commands to data subcommand
 	dataCmd.AddCommand(applyDataCmd, deleteDataCmd, queryDataCmd)
@@ -337,6 +337,12 @@ func rest(pfn paramsFn, fn reqFn, printer printer, enableTLS bool, insecure bool
 			client.SetTLSClientConfig(&config)
 		}
 		req := client.R()
+		switch {
+		case token != "":
+			req.SetAuthToken(token)
+		case username != "":
+			req.SetBasicAuth(username, password)
+		}
 		resp, err := fn(request{
 			reqBody: r,
 			req:     req,
@@ -40,6 +40,9 @@ var (
 	enableTLS bool
 	insecure  bool
 	cert      string
+	token     string
+	username  string
+	password  string
 	rootCmd   = &cobra.Command{
 		DisableAutoGenTag: true,
 		Version:           version.Build(),
@@ -53,6 +56,10 @@ func ResetFlags() {
 	name = ""
 	start = ""
 	end = ""
+	revision = 0
+	token = ""
+	username = ""
+	password = ""
 }
 
 // Execute executes the root command.
@@ -65,12 +72,16 @@ func RootCmdFlags(command *cobra.Command) {
 	command.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.bydbctl.yaml)")
 	command.PersistentFlags().StringP("group", "g", "", "If present, list objects in this group.")
 	command.PersistentFlags().StringP("addr", "a", "", "Server's address, the format is Schema://Domain:Port")
+	command.PersistentFlags().StringVar(&token, "token", "", "The API token sent as the bearer token")
+	command.PersistentFlags().StringVar(&username, "username", "", "The user of the HTTP basic authentication")
+	command.PersistentFlags().StringVar(&password, "password", "", "The password of the HTTP basic authentication")
 	_ = viper.BindPFlag("group", command.PersistentFlags().Lookup("group"))
 	_ = viper.BindPFlag("addr", command.PersistentFlags().Lookup("addr"))
 	viper.SetDefault("addr", "http://localhost:17913")
 
 	command.AddCommand(newGroupCmd(), newUserCmd(), newStreamCmd(), newMeasureCmd(), newTopnCmd(),
-		newIndexRuleCmd(), newIndexRuleBindingCmd(), newPropertyCmd(), newHealthCheckCmd(), newAnalyzeCmd())
+		newIndexRuleCmd(), newIndexRuleBindingCmd(), newPropertyCmd(), newHealthCheckCmd(), newAnalyzeCmd(),
+		newSlowQueryCmd(), newShardCmd(), newNodeCmd())
 }
 
 func init() {
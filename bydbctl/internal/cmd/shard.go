@@ -0,0 +1,68 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+func newShardCmd() *cobra.Command {
+	shardCmd := &cobra.Command{
+		Use:     "shard",
+		Version: version.Build(),
+		Short:   "Shard operation",
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list [-g group]",
+		Version: version.Build(),
+		Short:   "List the placements of the shards on the data nodes",
+		RunE: func(_ *cobra.Command, _ []string) (err error) {
+			return rest(parseGroupFromFlags, func(request request) (*resty.Response, error) {
+				return request.req.SetPathParam("group", request.group).Get(getPath("/api/v1/shard/lists/{group}"))
+			}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+
+	var dryRun bool
+	rebalanceCmd := &cobra.Command{
+		Use:     "rebalance [-g group] [--dry-run]",
+		Version: version.Build(),
+		Short:   "Spread the shards of the group evenly over the data nodes",
+		Long: `Spread the shards of a stream or measure group evenly over the data nodes.
+The data of a moved shard is sent to its new nodes before the writes are routed to them.
+With --dry-run, the moves are listed without migrating the data or changing the placements.`,
+		RunE: func(_ *cobra.Command, _ []string) (err error) {
+			return rest(parseGroupFromFlags, func(request request) (*resty.Response, error) {
+				return request.req.SetPathParam("group", request.group).
+					SetBody(fmt.Sprintf(`{"dryRun":%t}`, dryRun)).
+					Post(getPath("/api/v1/shard/rebalance/{group}"))
+			}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+	rebalanceCmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the moves without migrating the data or changing the placements")
+
+	bindTLSRelatedFlag(listCmd, rebalanceCmd)
+	shardCmd.AddCommand(listCmd, rebalanceCmd)
+	return shardCmd
+}
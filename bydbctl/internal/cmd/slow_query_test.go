@@ -0,0 +1,103 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/zenizh/go-capturer"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/bydbctl/internal/cmd"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/helpers"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	cases_stream_data "github.com/apache/skywalking-banyandb/test/cases/stream/data"
+)
+
+var _ = Describe("Slow Query", func() {
+	var addr, grpcAddr string
+	var deferFunc func()
+	var rootCmd *cobra.Command
+	BeforeEach(func() {
+		grpcAddr, addr, deferFunc = setup.Standalone("--slow-query", "1ns", "--slow-query-trace-sample-rate", "1")
+		addr = httpSchema + addr
+		rootCmd = &cobra.Command{Use: "root"}
+		cmd.RootCmdFlags(rootCmd)
+	})
+
+	It("lists the slow queries", func() {
+		conn, err := grpclib.NewClient(
+			grpcAddr,
+			grpclib.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		now := timestamp.NowMilli()
+		cases_stream_data.Write(conn, "sw", now, -time.Millisecond)
+		rootCmd.SetArgs([]string{"stream", "query", "-a", addr, "-f", "-"})
+		issue := func() string {
+			rootCmd.SetIn(strings.NewReader(fmt.Sprintf(`
+name: sw
+groups: ["default"]
+timeRange:
+  begin: %s
+  end: %s
+projection:
+  tagFamilies:
+    - name: searchable
+      tags:
+        - trace_id`, now.Add(-time.Hour).Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339))))
+			return capturer.CaptureStdout(func() {
+				err := rootCmd.Execute()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		}
+		Eventually(func() int {
+			resp := new(streamv1.QueryResponse)
+			helpers.UnmarshalYAML([]byte(issue()), resp)
+			return len(resp.Elements)
+		}, flags.EventuallyTimeout).Should(Equal(5))
+
+		rootCmd.SetArgs([]string{"slow-query", "list", "-a", addr, "--limit", "1"})
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		resp := new(databasev1.SlowQueryServiceListResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		Expect(resp.SlowQueries).To(HaveLen(1))
+		sq := resp.SlowQueries[0]
+		Expect(sq.Request).To(ContainSubstring("sw"))
+		Expect(sq.RowsReturned).To(Equal(uint64(5)))
+		Expect(sq.RowsScanned).To(BeNumerically(">=", 5))
+		Expect(sq.Trace).NotTo(BeNil())
+	})
+
+	AfterEach(func() {
+		deferFunc()
+	})
+})
@@ -104,6 +104,32 @@ func newStreamCmd() *cobra.Command {
 		},
 	}
 
+	checkCmd := &cobra.Command{
+		Use:     "check -f [file|dir|-]",
+		Version: version.Build(),
+		Short:   "Check whether streams could be updated to the ones in files",
+		RunE: func(cmd *cobra.Command, _ []string) (err error) {
+			return rest(func() ([]reqBody, error) { return parseNameAndGroupFromYAML(cmd.InOrStdin()) },
+				func(request request) (*resty.Response, error) {
+					s := new(databasev1.Stream)
+					err := protojson.Unmarshal(request.data, s)
+					if err != nil {
+						return nil, err
+					}
+					cr := &databasev1.StreamRegistryServiceCheckCompatibilityRequest{
+						Stream: s,
+					}
+					b, err := protojson.Marshal(cr)
+					if err != nil {
+						return nil, err
+					}
+					return request.req.SetBody(b).
+						SetPathParam("name", request.name).SetPathParam("group", request.group).
+						Post(getPath(streamSchemaPath + "/compatibility" + pathTemp))
+				}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+
 	getCmd := &cobra.Command{
 		Use:     "get [-g group] -n name",
 		Version: version.Build(),
@@ -154,10 +180,11 @@ func newStreamCmd() *cobra.Command {
 				}, yamlPrinter, enableTLS, insecure, cert)
 		},
 	}
-	bindFileFlag(createCmd, updateCmd, queryCmd)
+	bindFileFlag(createCmd, updateCmd, checkCmd, queryCmd)
 	bindTimeRangeFlag(queryCmd)
 
-	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
-	streamCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
+	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, checkCmd, listCmd, queryCmd)
+	historyCmd, rollbackCmd := newHistoryCmds("stream", streamSchemaPath)
+	streamCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, checkCmd, listCmd, queryCmd, historyCmd, rollbackCmd)
 	return streamCmd
 }
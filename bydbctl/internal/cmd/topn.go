@@ -158,6 +158,7 @@ func newTopnCmd() *cobra.Command {
 	bindTimeRangeFlag(queryCmd)
 
 	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
-	topnCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
+	historyCmd, rollbackCmd := newHistoryCmds("topn", topnSchemaPath)
+	topnCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd, historyCmd, rollbackCmd)
 	return topnCmd
 }
@@ -0,0 +1,284 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package auth authenticates the callers of the liaison APIs, and authorizes their accesses by the roles granted to them.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const (
+	// GatewayKeyHeader carries the gateway key of the requests forwarded by the HTTP gateway.
+	GatewayKeyHeader = "x-banyandb-gateway-key"
+	// GatewayUserHeader carries the user authenticated by the HTTP gateway.
+	GatewayUserHeader = "x-banyandb-user"
+)
+
+var (
+	_ run.PreRunner = (*Service)(nil)
+	_ run.Config    = (*Service)(nil)
+
+	// ErrUnauthenticated is returned if the caller presents no valid credential.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPermissionDenied is returned if the roles of the caller don't allow the access.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	errClientCAWithoutConfig = errors.New("the client CA file requires the auth config file")
+)
+
+// Service holds the users and the roles of the auth config file.
+// The authentication and the authorization are disabled if there is no auth config file.
+type Service struct {
+	users        map[string]*User
+	tokens       map[string]*User
+	certificates map[string]*User
+	roles        map[string]*Role
+	tenants      map[string]*Tenant
+	// verified caches the digests of the passwords which pass the bcrypt comparison, which is slow by design.
+	verified     sync.Map
+	clientCAs    *x509.CertPool
+	audit        *logger.Logger
+	configFile   string
+	clientCAFile string
+	gatewayKey   string
+}
+
+// NewService returns a new auth service.
+func NewService() *Service {
+	return &Service{}
+}
+
+// Name implements run.Unit.
+func (s *Service) Name() string {
+	return "auth"
+}
+
+// FlagSet implements run.Config.
+func (s *Service) FlagSet() *run.FlagSet {
+	fs := run.NewFlagSet("auth")
+	fs.StringVar(&s.configFile, "auth-config-file", "",
+		"the file of the users and the roles allowed to call the liaison APIs. Empty disables the authentication and the authorization")
+	fs.StringVar(&s.clientCAFile, "auth-client-ca-file", "",
+		"the CA bundle verifying the client certificates, which identify the users by their common names or DNS names")
+	return fs
+}
+
+// Validate implements run.Config.
+func (s *Service) Validate() error {
+	if s.clientCAFile != "" && s.configFile == "" {
+		return errClientCAWithoutConfig
+	}
+	return nil
+}
+
+// PreRun loads the auth config file.
+func (s *Service) PreRun(_ context.Context) error {
+	s.audit = logger.GetLogger("auth-audit")
+	if s.configFile == "" {
+		return nil
+	}
+	c, err := LoadConfig(s.configFile)
+	if err != nil {
+		return err
+	}
+	s.load(c)
+	if s.clientCAFile != "" {
+		pem, errRead := os.ReadFile(s.clientCAFile)
+		if errRead != nil {
+			return errors.Wrapf(errRead, "failed to read the client CA file %s", s.clientCAFile)
+		}
+		s.clientCAs = x509.NewCertPool()
+		if !s.clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate is found in the client CA file %s", s.clientCAFile)
+		}
+	}
+	key := make([]byte, 32)
+	if _, err = rand.Read(key); err != nil {
+		return err
+	}
+	s.gatewayKey = hex.EncodeToString(key)
+	return nil
+}
+
+func (s *Service) load(c *Config) {
+	s.users = make(map[string]*User, len(c.Users))
+	s.tokens = make(map[string]*User)
+	s.certificates = make(map[string]*User)
+	s.roles = make(map[string]*Role, len(c.Roles))
+	s.tenants = make(map[string]*Tenant, len(c.Tenants))
+	for i := range c.Tenants {
+		s.tenants[c.Tenants[i].Name] = &c.Tenants[i]
+	}
+	for i := range c.Roles {
+		s.roles[c.Roles[i].Name] = &c.Roles[i]
+	}
+	for i := range c.Users {
+		u := &c.Users[i]
+		s.users[u.Name] = u
+		for _, t := range u.Tokens {
+			s.tokens[strings.ToLower(t)] = u
+		}
+		for _, cn := range u.Certificates {
+			s.certificates[cn] = u
+		}
+	}
+}
+
+// Enabled returns whether the callers are authenticated and authorized.
+func (s *Service) Enabled() bool {
+	return s != nil && s.users != nil
+}
+
+// GatewayKey returns the secret shared by the servers in this process.
+// A request carrying it is forwarded by the HTTP gateway, which has authenticated the caller.
+func (s *Service) GatewayKey() string {
+	return s.gatewayKey
+}
+
+// ClientTLSConfig asks the clients for their certificates if the client CA is set, and verifies the given ones.
+// The clients without a certificate can still authenticate by the other credentials.
+func (s *Service) ClientTLSConfig(c *tls.Config) *tls.Config {
+	if !s.Enabled() || s.clientCAs == nil {
+		return c
+	}
+	c = c.Clone()
+	c.ClientCAs = s.clientCAs
+	c.ClientAuth = tls.VerifyClientCertIfGiven
+	return c
+}
+
+// Authenticate returns the name of the user identified by the authorization header or the verified client certificates.
+// The authorization header is either "Bearer <token>" or "Basic <base64 of user:password>".
+func (s *Service) Authenticate(authorization string, verifiedChains [][]*x509.Certificate) (string, error) {
+	if authorization != "" {
+		scheme, credential, _ := strings.Cut(authorization, " ")
+		switch strings.ToLower(scheme) {
+		case "bearer":
+			digest := sha256.Sum256([]byte(strings.TrimSpace(credential)))
+			if u, ok := s.tokens[hex.EncodeToString(digest[:])]; ok {
+				return u.Name, nil
+			}
+			return "", errors.WithMessage(ErrUnauthenticated, "invalid token")
+		case "basic":
+			return s.authenticateBasic(strings.TrimSpace(credential))
+		default:
+			return "", errors.WithMessagef(ErrUnauthenticated, "unsupported authorization scheme %q", scheme)
+		}
+	}
+	if s.clientCAs != nil && len(verifiedChains) > 0 && len(verifiedChains[0]) > 0 {
+		cert := verifiedChains[0][0]
+		for _, name := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+			if u, ok := s.certificates[name]; ok {
+				return u.Name, nil
+			}
+		}
+		return "", errors.WithMessagef(ErrUnauthenticated, "no user has the client certificate %s", cert.Subject.CommonName)
+	}
+	return "", errors.WithMessage(ErrUnauthenticated, "no credential")
+}
+
+func (s *Service) authenticateBasic(credential string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(credential)
+	if err != nil {
+		return "", errors.WithMessage(ErrUnauthenticated, "malformed basic credential")
+	}
+	name, password, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return "", errors.WithMessage(ErrUnauthenticated, "malformed basic credential")
+	}
+	u, ok := s.users[name]
+	if !ok || u.Password == "" {
+		return "", errors.WithMessage(ErrUnauthenticated, "invalid user or password")
+	}
+	digest := sha256.Sum256(raw)
+	if v, found := s.verified.Load(name); found && subtle.ConstantTimeCompare(v.([]byte), digest[:]) == 1 {
+		return name, nil
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		return "", errors.WithMessage(ErrUnauthenticated, "invalid user or password")
+	}
+	s.verified.Store(name, digest[:])
+	return name, nil
+}
+
+// Authorize checks whether any role of the user allows the access to every group.
+// The user of a tenant is only allowed to access the groups of the tenant.
+func (s *Service) Authorize(user string, a Access) error {
+	u, ok := s.users[user]
+	if !ok {
+		return errors.WithMessagef(ErrUnauthenticated, "unknown user %s", user)
+	}
+	if err := authorizeTenant(u, a); err != nil {
+		return err
+	}
+	groups := a.Groups
+	if len(groups) == 0 {
+		groups = []string{""}
+	}
+	for _, g := range groups {
+		if !s.allows(u, a, g) {
+			return errors.WithMessagef(ErrPermissionDenied, "%s isn't allowed to %s %s", user, a.Verb, describe(a.Catalog, g))
+		}
+	}
+	return nil
+}
+
+func (s *Service) allows(u *User, a Access, group string) bool {
+	for _, name := range u.Roles {
+		if r, ok := s.roles[name]; ok && r.allows(a, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(catalog, group string) string {
+	switch {
+	case catalog == "" && group == "":
+		return "the cluster"
+	case catalog == "":
+		return "the group " + group
+	case group == "":
+		return "any " + catalog + " group"
+	default:
+		return "the " + catalog + " group " + group
+	}
+}
+
+// Audit logs a denied call.
+func (s *Service) Audit(method, user, peer string, a Access, err error) {
+	s.audit.Warn().Str("method", method).Str("user", user).Str("peer", peer).Str("verb", string(a.Verb)).
+		Str("catalog", a.Catalog).Strs("groups", a.Groups).Err(err).Msg("denied")
+}
@@ -0,0 +1,276 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const token = "sw-token"
+
+func tokenDigest() string {
+	d := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(d[:])
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func newTestService(t *testing.T) *Service {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	path := writeConfig(t, fmt.Sprintf(`
+roles:
+- name: writer
+  rules:
+  - groups: ["sw_metric"]
+    catalogs: ["measure"]
+    verbs: ["read", "write"]
+- name: admin
+  rules:
+  - groups: ["*"]
+    catalogs: ["*"]
+    verbs: ["*"]
+users:
+- name: oap
+  tokens: ["%s"]
+  certificates: ["oap.banyandb"]
+  roles: ["writer"]
+- name: root
+  password: "%s"
+  roles: ["admin"]
+`, tokenDigest(), hash))
+	s := NewService()
+	s.configFile = path
+	require.NoError(t, s.PreRun(context.Background()))
+	s.clientCAs = x509.NewCertPool()
+	return s
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown verb",
+			content: "roles:\n- name: r\n  rules:\n  - verbs: [\"delete\"]\n",
+			wantErr: "unknown verb",
+		},
+		{
+			name:    "unknown catalog",
+			content: "roles:\n- name: r\n  rules:\n  - catalogs: [\"trace\"]\n",
+			wantErr: "unknown catalog",
+		},
+		{
+			name:    "unknown role",
+			content: "users:\n- name: u\n  roles: [\"r\"]\n",
+			wantErr: "unknown role",
+		},
+		{
+			name:    "duplicated user",
+			content: "users:\n- name: u\n- name: u\n",
+			wantErr: "duplicated",
+		},
+		{
+			name:    "plain token",
+			content: "users:\n- name: u\n  tokens: [\"" + token + "\"]\n",
+			wantErr: "SHA-256",
+		},
+		{
+			name:    "unknown tenant",
+			content: "users:\n- name: u\n  tenant: t\n",
+			wantErr: "unknown tenant",
+		},
+		{
+			name:    "tenant with the separator",
+			content: "tenants:\n- name: team.a\n",
+			wantErr: "contains",
+		},
+		{
+			name:    "negative limit",
+			content: "tenants:\n- name: t\n  limits:\n    maxSeries: -1\n",
+			wantErr: "negative limit",
+		},
+		{
+			name:    "unknown field",
+			content: "users:\n- name: u\n  groups: [\"g\"]\n",
+			wantErr: "unknown field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newTestService(t)
+	assert.True(t, s.Enabled())
+
+	user, err := s.Authenticate("Bearer "+token, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "oap", user)
+	_, err = s.Authenticate("Bearer "+tokenDigest(), nil)
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+
+	basic := func(user, password string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	}
+	for i := 0; i < 2; i++ {
+		user, err = s.Authenticate(basic("root", "secret"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "root", user)
+	}
+	_, err = s.Authenticate(basic("root", "wrong"), nil)
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+	_, err = s.Authenticate(basic("oap", ""), nil)
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}, DNSNames: []string{"oap.banyandb"}}
+	user, err = s.Authenticate("", [][]*x509.Certificate{{cert}})
+	require.NoError(t, err)
+	assert.Equal(t, "oap", user)
+	cert = &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}}
+	_, err = s.Authenticate("", [][]*x509.Certificate{{cert}})
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+
+	_, err = s.Authenticate("", nil)
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+}
+
+func TestAuthorize(t *testing.T) {
+	s := newTestService(t)
+	tests := []struct {
+		name    string
+		user    string
+		access  Access
+		allowed bool
+	}{
+		{
+			name:    "write the granted group",
+			user:    "oap",
+			access:  Access{Verb: VerbWrite, Catalog: CatalogMeasure, Groups: []string{"sw_metric"}},
+			allowed: true,
+		},
+		{
+			name:   "write another group",
+			user:   "oap",
+			access: Access{Verb: VerbWrite, Catalog: CatalogMeasure, Groups: []string{"sw_metric", "sw_record"}},
+		},
+		{
+			name:   "write another catalog",
+			user:   "oap",
+			access: Access{Verb: VerbWrite, Catalog: CatalogStream, Groups: []string{"sw_metric"}},
+		},
+		{
+			name:   "change the schemas",
+			user:   "oap",
+			access: Access{Verb: VerbSchemaAdmin, Catalog: CatalogMeasure, Groups: []string{"sw_metric"}},
+		},
+		{
+			name:   "list the groups",
+			user:   "oap",
+			access: Access{Verb: VerbRead},
+		},
+		{
+			name:    "operate the cluster",
+			user:    "root",
+			access:  Access{Verb: VerbSchemaAdmin},
+			allowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Authorize(tt.user, tt.access)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrPermissionDenied))
+		})
+	}
+	assert.True(t, errors.Is(s.Authorize("nobody", Access{Verb: VerbRead}), ErrUnauthenticated))
+}
+
+func TestDisabled(t *testing.T) {
+	s := NewService()
+	require.NoError(t, s.PreRun(context.Background()))
+	assert.False(t, s.Enabled())
+	var nilService *Service
+	assert.False(t, nilService.Enabled())
+}
+
+func TestTenants(t *testing.T) {
+	path := writeConfig(t, fmt.Sprintf(`
+tenants:
+- name: team-a
+  limits:
+    writeRate: 100
+    maxStorageBytes: 1024
+roles:
+- name: admin
+  rules:
+  - groups: ["*"]
+    catalogs: ["*"]
+    verbs: ["*"]
+users:
+- name: a
+  tokens: ["%s"]
+  tenant: team-a
+  roles: ["admin"]
+`, tokenDigest()))
+	s := NewService()
+	s.configFile = path
+	require.NoError(t, s.PreRun(context.Background()))
+
+	tenant := s.TenantOf("a")
+	require.NotNil(t, tenant)
+	assert.Equal(t, 100, tenant.Limits.WriteRate)
+	assert.Equal(t, uint64(1024), tenant.Limits.MaxStorageBytes)
+	assert.Same(t, tenant, s.GroupTenant("team-a.sw_metric"))
+	assert.Nil(t, s.GroupTenant("team-a"))
+	assert.Nil(t, s.GroupTenant("team-b.sw_metric"))
+	assert.Len(t, s.Tenants(), 1)
+
+	assert.NoError(t, s.Authorize("a", Access{Verb: VerbWrite, Catalog: CatalogMeasure, Groups: []string{"team-a.sw_metric"}}))
+	assert.True(t, errors.Is(s.Authorize("a", Access{Verb: VerbWrite, Catalog: CatalogMeasure, Groups: []string{"sw_metric"}}), ErrPermissionDenied))
+	assert.True(t, errors.Is(s.Authorize("a", Access{Verb: VerbSchemaAdmin}), ErrPermissionDenied))
+	assert.NoError(t, s.Authorize("a", Access{Verb: VerbRead, Filtered: true}))
+}
@@ -0,0 +1,177 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the content of the auth config file.
+type Config struct {
+	Users   []User   `json:"users"`
+	Roles   []Role   `json:"roles"`
+	Tenants []Tenant `json:"tenants,omitempty"`
+}
+
+// User is a caller of the liaison APIs, who is identified by any of its credentials.
+type User struct {
+	Name string `json:"name"`
+	// Password is the bcrypt hash of the password of the basic authentication.
+	Password string `json:"password,omitempty"`
+	// Tokens are the hex encoded SHA-256 digests of the static API tokens, which are sent as bearer tokens.
+	Tokens []string `json:"tokens,omitempty"`
+	// Certificates are the common names or the DNS names of the client certificates.
+	Certificates []string `json:"certificates,omitempty"`
+	// Roles are the names of the roles granted to the user.
+	Roles []string `json:"roles"`
+	// Tenant is the name of the tenant of the user, who only accesses the groups of the tenant.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Tenant owns the groups whose names are prefixed by its name and TenantSeparator.
+type Tenant struct {
+	Name   string `json:"name"`
+	Limits Limits `json:"limits"`
+}
+
+// Limits are the limits of a tenant, which are enforced by every liaison on its own. Zero means unlimited.
+type Limits struct {
+	// WriteRate is the max number of the elements, data points and properties written per second.
+	WriteRate int `json:"writeRate,omitempty"`
+	// MaxSeries is the max number of the active series, which are written in the recent windows.
+	MaxSeries int `json:"maxSeries,omitempty"`
+	// MaxConcurrentQueries is the max number of the queries running at the same time.
+	MaxConcurrentQueries int `json:"maxConcurrentQueries,omitempty"`
+	// MaxStorageBytes is the max size of the data of the tenant on all the data nodes, including the replicas.
+	MaxStorageBytes uint64 `json:"maxStorageBytes,omitempty"`
+}
+
+// Role is a set of rules.
+type Role struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// Rule allows the verbs on the catalogs of the groups. "*" matches any group, catalog or verb.
+type Rule struct {
+	Groups   []string `json:"groups"`
+	Catalogs []string `json:"catalogs"`
+	Verbs    []Verb   `json:"verbs"`
+}
+
+// LoadConfig reads and validates the auth config file.
+func LoadConfig(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the auth config file %s", path)
+	}
+	var c Config
+	if err = yaml.UnmarshalStrict(raw, &c); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the auth config file %s", path)
+	}
+	if err = c.validate(); err != nil {
+		return nil, errors.WithMessagef(err, "invalid auth config file %s", path)
+	}
+	return &c, nil
+}
+
+func (c *Config) validate() error {
+	roles := make(map[string]struct{}, len(c.Roles))
+	for _, r := range c.Roles {
+		if r.Name == "" {
+			return errors.New("the name of a role is empty")
+		}
+		if _, ok := roles[r.Name]; ok {
+			return fmt.Errorf("role %s is duplicated", r.Name)
+		}
+		roles[r.Name] = struct{}{}
+		for _, rule := range r.Rules {
+			for _, v := range rule.Verbs {
+				if !slices.Contains(verbs, v) && v != wildcard {
+					return fmt.Errorf("role %s has an unknown verb %s", r.Name, v)
+				}
+			}
+			for _, cat := range rule.Catalogs {
+				if !slices.Contains(catalogs, cat) && cat != wildcard {
+					return fmt.Errorf("role %s has an unknown catalog %s", r.Name, cat)
+				}
+			}
+		}
+	}
+	tenants := make(map[string]struct{}, len(c.Tenants))
+	for _, t := range c.Tenants {
+		if t.Name == "" {
+			return errors.New("the name of a tenant is empty")
+		}
+		if strings.Contains(t.Name, TenantSeparator) {
+			return fmt.Errorf("the name of tenant %s contains %q", t.Name, TenantSeparator)
+		}
+		if _, ok := tenants[t.Name]; ok {
+			return fmt.Errorf("tenant %s is duplicated", t.Name)
+		}
+		tenants[t.Name] = struct{}{}
+		if t.Limits.WriteRate < 0 || t.Limits.MaxSeries < 0 || t.Limits.MaxConcurrentQueries < 0 {
+			return fmt.Errorf("tenant %s has a negative limit", t.Name)
+		}
+	}
+	users := make(map[string]struct{}, len(c.Users))
+	tokens := make(map[string]struct{})
+	certificates := make(map[string]struct{})
+	for _, u := range c.Users {
+		if u.Name == "" {
+			return errors.New("the name of a user is empty")
+		}
+		if _, ok := users[u.Name]; ok {
+			return fmt.Errorf("user %s is duplicated", u.Name)
+		}
+		users[u.Name] = struct{}{}
+		for _, r := range u.Roles {
+			if _, ok := roles[r]; !ok {
+				return fmt.Errorf("user %s has an unknown role %s", u.Name, r)
+			}
+		}
+		if u.Tenant != "" {
+			if _, ok := tenants[u.Tenant]; !ok {
+				return fmt.Errorf("user %s has an unknown tenant %s", u.Name, u.Tenant)
+			}
+		}
+		for _, t := range u.Tokens {
+			if b, err := hex.DecodeString(t); err != nil || len(b) != 32 {
+				return fmt.Errorf("a token of user %s isn't a hex encoded SHA-256 digest", u.Name)
+			}
+			if _, ok := tokens[t]; ok {
+				return fmt.Errorf("a token of user %s is used by another user", u.Name)
+			}
+			tokens[t] = struct{}{}
+		}
+		for _, cn := range u.Certificates {
+			if _, ok := certificates[cn]; ok {
+				return fmt.Errorf("certificate %s of user %s is used by another user", cn, u.Name)
+			}
+			certificates[cn] = struct{}{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"slices"
+)
+
+// Verb is the kind of the access to the data or the schemas.
+type Verb string
+
+const (
+	// VerbRead reads the data or the schemas.
+	VerbRead Verb = "read"
+	// VerbWrite writes the data.
+	VerbWrite Verb = "write"
+	// VerbSchemaAdmin changes the schemas, or operates the cluster.
+	VerbSchemaAdmin Verb = "schema-admin"
+)
+
+// The catalogs of the groups.
+const (
+	CatalogStream   = "stream"
+	CatalogMeasure  = "measure"
+	CatalogProperty = "property"
+)
+
+const wildcard = "*"
+
+var (
+	verbs    = []Verb{VerbRead, VerbWrite, VerbSchemaAdmin}
+	catalogs = []string{CatalogStream, CatalogMeasure, CatalogProperty}
+)
+
+// Access is an access to be authorized.
+type Access struct {
+	Verb Verb
+	// Catalog is empty if the access isn't bound to a catalog, which is allowed only by the rules matching any catalog.
+	Catalog string
+	// Groups are the groups touched by the access.
+	// The access isn't bound to a group if it's empty, which is allowed only by the rules matching any group.
+	Groups []string
+	// Filtered is true if the results are filtered by the tenant of the caller, such as listing the groups.
+	// The users of a tenant are allowed to access them without groups.
+	Filtered bool
+}
+
+func (r Rule) allows(a Access, group string) bool {
+	if !slices.Contains(r.Verbs, a.Verb) && !slices.Contains(r.Verbs, wildcard) {
+		return false
+	}
+	if !slices.Contains(r.Catalogs, wildcard) && (a.Catalog == "" || !slices.Contains(r.Catalogs, a.Catalog)) {
+		return false
+	}
+	return slices.Contains(r.Groups, wildcard) || group != "" && slices.Contains(r.Groups, group)
+}
+
+func (r Role) allows(a Access, group string) bool {
+	for _, rule := range r.Rules {
+		if rule.allows(a, group) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TenantSeparator separates the name of a tenant and the name of a group in the namespace of the tenant,
+// e.g. "team-a.sw_metric" is the group "sw_metric" of the tenant "team-a".
+const TenantSeparator = "."
+
+// Owns returns whether the group is in the namespace of the tenant.
+func (t *Tenant) Owns(group string) bool {
+	return strings.HasPrefix(group, t.Name+TenantSeparator)
+}
+
+// Tenants returns the tenants of the auth config file.
+func (s *Service) Tenants() []*Tenant {
+	if !s.Enabled() {
+		return nil
+	}
+	tt := make([]*Tenant, 0, len(s.tenants))
+	for _, t := range s.tenants {
+		tt = append(tt, t)
+	}
+	return tt
+}
+
+// TenantOf returns the tenant of the user, or nil if the user doesn't belong to a tenant.
+func (s *Service) TenantOf(user string) *Tenant {
+	if !s.Enabled() {
+		return nil
+	}
+	if u, ok := s.users[user]; ok && u.Tenant != "" {
+		return s.tenants[u.Tenant]
+	}
+	return nil
+}
+
+// GroupTenant returns the tenant owning the group, or nil if the group isn't in the namespace of a tenant.
+func (s *Service) GroupTenant(group string) *Tenant {
+	if !s.Enabled() {
+		return nil
+	}
+	name, _, ok := strings.Cut(group, TenantSeparator)
+	if !ok {
+		return nil
+	}
+	return s.tenants[name]
+}
+
+// authorizeTenant confines the user of a tenant to the namespace of the tenant.
+func authorizeTenant(u *User, a Access) error {
+	if u.Tenant == "" {
+		return nil
+	}
+	if len(a.Groups) == 0 && !a.Filtered {
+		return errors.WithMessagef(ErrPermissionDenied, "%s of the tenant %s isn't allowed to %s %s",
+			u.Name, u.Tenant, a.Verb, describe(a.Catalog, ""))
+	}
+	prefix := u.Tenant + TenantSeparator
+	for _, g := range a.Groups {
+		if !strings.HasPrefix(g, prefix) {
+			return errors.WithMessagef(ErrPermissionDenied, "the group %s isn't in the namespace %s of %s", g, prefix, u.Name)
+		}
+	}
+	return nil
+}
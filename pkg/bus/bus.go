@@ -43,8 +43,26 @@ type (
 		Get() (Message, error)
 		GetAll() ([]Message, error)
 	}
+
+	// CancelableFuture is a Future whose pending responses can be abandoned.
+	CancelableFuture interface {
+		Future
+		// Cancel stops waiting for the responses and releases the underlying resources.
+		Cancel()
+	}
 )
 
+// CancelOnDone cancels the futures once the context is done.
+func CancelOnDone(ctx context.Context, futures []Future) {
+	context.AfterFunc(ctx, func() {
+		for _, f := range futures {
+			if cf, ok := f.(CancelableFuture); ok {
+				cf.Cancel()
+			}
+		}
+	})
+}
+
 // Message is send on the bus to all subscribed listeners.
 type Message struct {
 	payload       payload
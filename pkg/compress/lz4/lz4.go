@@ -0,0 +1,83 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+// Package lz4 provides LZ4 compression and decompression.
+package lz4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	blockTypeRaw = 0
+	blockTypeLZ4 = 1
+)
+
+// Compress compresses the src into dst.
+// The block is prefixed with the length of src, which LZ4 needs to decompress it.
+func Compress(dst, src []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	n := len(dst)
+	dst = slices.Grow(dst, 1+lz4.CompressBlockBound(len(src)))
+	var c lz4.Compressor
+	size, err := c.CompressBlock(src, dst[n+1:cap(dst)])
+	if err != nil || size == 0 {
+		// The src is incompressible.
+		dst = append(dst, blockTypeRaw)
+		return append(dst, src...)
+	}
+	dst = dst[:n+1+size]
+	dst[n] = blockTypeLZ4
+	return dst
+}
+
+// Decompress decompresses the src into dst.
+func Decompress(dst, src []byte) ([]byte, error) {
+	l, n := binary.Uvarint(src)
+	if n <= 0 {
+		return dst, fmt.Errorf("cannot decode the length of the LZ4 block")
+	}
+	src = src[n:]
+	if len(src) < 1 {
+		return dst, fmt.Errorf("cannot decode the type of the LZ4 block from empty src")
+	}
+	blockType := src[0]
+	src = src[1:]
+	switch blockType {
+	case blockTypeRaw:
+		if uint64(len(src)) != l {
+			return dst, fmt.Errorf("unexpected length of the raw block: got %d; want %d", len(src), l)
+		}
+		return append(dst, src...), nil
+	case blockTypeLZ4:
+		offset := len(dst)
+		dst = slices.Grow(dst, int(l))
+		size, err := lz4.UncompressBlock(src, dst[offset:offset+int(l)])
+		if err != nil {
+			return dst, fmt.Errorf("cannot decompress the LZ4 block: %w", err)
+		}
+		if uint64(size) != l {
+			return dst, fmt.Errorf("unexpected length of the decompressed block: got %d; want %d", size, l)
+		}
+		return dst[:offset+size], nil
+	default:
+		return dst, fmt.Errorf("unexpected type of the LZ4 block: %d", blockType)
+	}
+}
@@ -0,0 +1,47 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+// Package snappy provides Snappy compression and decompression.
+package snappy
+
+import (
+	"slices"
+
+	"github.com/golang/snappy"
+)
+
+// Compress compresses the src into dst.
+func Compress(dst, src []byte) []byte {
+	offset := len(dst)
+	dst = slices.Grow(dst, snappy.MaxEncodedLen(len(src)))
+	encoded := snappy.Encode(dst[offset:cap(dst)], src)
+	return dst[:offset+len(encoded)]
+}
+
+// Decompress decompresses the src into dst.
+func Decompress(dst, src []byte) ([]byte, error) {
+	l, err := snappy.DecodedLen(src)
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = slices.Grow(dst, l)
+	decoded, err := snappy.Decode(dst[offset:offset+l], src)
+	if err != nil {
+		return dst, err
+	}
+	return dst[:offset+len(decoded)], nil
+}
@@ -87,3 +87,25 @@ func Float64ToBytes(f float64) []byte {
 func BytesToFloat64(b []byte) float64 {
 	return math.Float64frombits(binary.BigEndian.Uint64(b))
 }
+
+// Float64ToSortableBytes converts float64 to bytes, which keep the order of the values when they are compared.
+func Float64ToSortableBytes(f float64) []byte {
+	u := math.Float64bits(f)
+	if u&(1<<63) == 0 {
+		u |= 1 << 63
+	} else {
+		u = ^u
+	}
+	return Uint64ToBytes(u)
+}
+
+// SortableBytesToFloat64 converts bytes encoded by Float64ToSortableBytes to float64.
+func SortableBytesToFloat64(b []byte) float64 {
+	u := binary.BigEndian.Uint64(b)
+	if u&(1<<63) != 0 {
+		u &^= 1 << 63
+	} else {
+		u = ^u
+	}
+	return math.Float64frombits(u)
+}
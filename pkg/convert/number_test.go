@@ -20,6 +20,7 @@ package convert
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -46,3 +47,16 @@ func TestInt64ToBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestFloat64ToSortableBytes(t *testing.T) {
+	values := []float64{math.Inf(-1), -1e10, -2.5, -1, -0.5, 0, 0.5, 1, 2.5, 1e10, math.Inf(1)}
+	for i := range values {
+		b := Float64ToSortableBytes(values[i])
+		if got := SortableBytesToFloat64(b); got != values[i] {
+			t.Errorf("Expected %v, got %v", values[i], got)
+		}
+		if i > 0 && bytes.Compare(Float64ToSortableBytes(values[i-1]), b) >= 0 {
+			t.Errorf("Expected the bytes of %v to be less than the ones of %v", values[i-1], values[i])
+		}
+	}
+}
@@ -0,0 +1,520 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package encoding
+
+import (
+	stdbytes "bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/apache/skywalking-banyandb/pkg/compress/lz4"
+	"github.com/apache/skywalking-banyandb/pkg/compress/snappy"
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
+)
+
+// ColumnEncoding is the encoding of the values in a column.
+type ColumnEncoding byte
+
+// ColumnEncoding values.
+const (
+	// ColumnEncodingDefault is the encoding of EncodeBytesBlock.
+	ColumnEncodingDefault ColumnEncoding = iota
+	ColumnEncodingPlain
+	// ColumnEncodingGorilla XORs each 8-byte value with the previous one.
+	ColumnEncodingGorilla
+	// ColumnEncodingDeltaOfDelta encodes the delta of the deltas of 8-byte int values.
+	ColumnEncodingDeltaOfDelta
+	// ColumnEncodingDictionary stores the distinct values once, and the dictionary id of each value.
+	ColumnEncodingDictionary
+	// ColumnEncodingHistogram splits each value into the length-prefixed layout and the data following it.
+	// The layouts are dictionary-encoded, and the data are stored as plain.
+	ColumnEncodingHistogram
+)
+
+// ColumnCompression is the compression of the encoded values in a column.
+type ColumnCompression byte
+
+// ColumnCompression values.
+const (
+	// ColumnCompressionDefault is ZSTD with the level 1.
+	ColumnCompressionDefault ColumnCompression = iota
+	ColumnCompressionNone
+	ColumnCompressionZSTD
+	ColumnCompressionLZ4
+	ColumnCompressionSnappy
+)
+
+const codecLength = 3
+
+// Codec identifies how the values of a column are encoded and compressed.
+// The zero value is the codec of EncodeBytesBlock.
+type Codec struct {
+	Encoding    ColumnEncoding
+	Compression ColumnCompression
+	// Level is the level of the ZSTD compression.
+	Level int8
+}
+
+// IsDefault reports whether the codec is the one of EncodeBytesBlock.
+func (c Codec) IsDefault() bool {
+	return c == Codec{}
+}
+
+// Marshal appends the codec to dst.
+func (c Codec) Marshal(dst []byte) []byte {
+	return append(dst, byte(c.Encoding), byte(c.Compression), byte(c.Level))
+}
+
+// UnmarshalCodec decodes a codec from src.
+func UnmarshalCodec(src []byte) ([]byte, Codec, error) {
+	if len(src) < codecLength {
+		return src, Codec{}, fmt.Errorf("cannot unmarshal codec from %d bytes; want at least %d bytes", len(src), codecLength)
+	}
+	c := Codec{
+		Encoding:    ColumnEncoding(src[0]),
+		Compression: ColumnCompression(src[1]),
+		Level:       int8(src[2]),
+	}
+	if c.Encoding > ColumnEncodingHistogram {
+		return src, c, fmt.Errorf("unknown column encoding: %d", c.Encoding)
+	}
+	if c.Compression > ColumnCompressionSnappy {
+		return src, c, fmt.Errorf("unknown column compression: %d", c.Compression)
+	}
+	return src[codecLength:], c, nil
+}
+
+// EncodeColumn encodes a block of values with the codec into dst.
+// The gorilla and delta-of-delta encodings fall back to the plain one
+// if a non-null value isn't 8 bytes long, and so do the dictionary and histogram encodings
+// if there are too many distinct values or layouts.
+func EncodeColumn(dst []byte, a [][]byte, c Codec) []byte {
+	if c.IsDefault() {
+		return EncodeBytesBlock(dst, a)
+	}
+	enc := c.Encoding
+	switch enc {
+	case ColumnEncodingDictionary:
+		if !isDictionaryColumn(a) {
+			enc = ColumnEncodingPlain
+		}
+	case ColumnEncodingHistogram:
+		if !isHistogramColumn(a) {
+			enc = ColumnEncodingPlain
+		}
+	default:
+		if !isFixedColumn(a, enc) {
+			enc = ColumnEncodingPlain
+		}
+	}
+
+	bb := bbPool.Generate()
+	defer bbPool.Release(bb)
+	raw := append(bb.Buf[:0], byte(enc))
+	if enc == ColumnEncodingDictionary {
+		bb.Buf = encodeDictionary(raw, a)
+		return compressColumn(dst, bb.Buf, c)
+	}
+	if enc == ColumnEncodingHistogram {
+		layouts := make([][]byte, len(a))
+		data := make([][]byte, len(a))
+		for i, s := range a {
+			n := histogramLayoutLen(s)
+			layouts[i], data[i] = s[:n], s[n:]
+		}
+		raw = encodeDictionary(raw, layouts)
+		a = data
+	}
+
+	u64s := GenerateUint64List(len(a))
+	aLens := u64s.L[:0]
+	for _, s := range a {
+		aLens = append(aLens, uint64(len(s)))
+	}
+	u64s.L = aLens
+	lens := bbPool.Generate()
+	lens.Buf = encodeUint64List(lens.Buf[:0], u64s.L)
+	ReleaseUint64List(u64s)
+	raw = VarUint64ToBytes(raw, uint64(len(lens.Buf)))
+	raw = append(raw, lens.Buf...)
+	bbPool.Release(lens)
+
+	switch enc {
+	case ColumnEncodingGorilla:
+		buf := stdbytes.NewBuffer(raw)
+		w := NewWriter()
+		w.Reset(buf)
+		e := NewXOREncoder(w)
+		for _, s := range a {
+			if len(s) > 0 {
+				e.Write(binary.BigEndian.Uint64(s))
+			}
+		}
+		w.Flush()
+		raw = buf.Bytes()
+	case ColumnEncodingDeltaOfDelta:
+		is := GenerateInt64List(0)
+		for _, s := range a {
+			if len(s) > 0 {
+				is.L = append(is.L, fixedToInt64(s))
+			}
+		}
+		var first int64
+		body := bbPool.Generate()
+		body.Buf, first = int64sDeltaOfDeltaToBytes(body.Buf[:0], is.L)
+		ReleaseInt64List(is)
+		raw = VarInt64ToBytes(raw, first)
+		raw = append(raw, body.Buf...)
+		bbPool.Release(body)
+	default:
+		for _, s := range a {
+			raw = append(raw, s...)
+		}
+	}
+	bb.Buf = raw
+	return compressColumn(dst, bb.Buf, c)
+}
+
+// DecodeColumn decodes a block of values encoded by EncodeColumn from src.
+func (bbd *BytesBlockDecoder) DecodeColumn(dst [][]byte, src []byte, itemsCount uint64, c Codec) ([][]byte, error) {
+	return bbd.DecodeDictionary(nil, dst, src, itemsCount, c)
+}
+
+// DecodeDictionary decodes a block of values like DecodeColumn.
+// If the values are dictionary-encoded, it also fills d with the dictionary, and the values share its distinct ones.
+// Otherwise, d is left empty.
+func (bbd *BytesBlockDecoder) DecodeDictionary(d *Dictionary, dst [][]byte, src []byte, itemsCount uint64, c Codec) ([][]byte, error) {
+	if d != nil {
+		d.Reset()
+	}
+	if c.IsDefault() {
+		return bbd.Decode(dst, src, itemsCount)
+	}
+	bb := bbPool.Generate()
+	defer bbPool.Release(bb)
+	var err error
+	if bb.Buf, err = decompressColumn(bb.Buf[:0], src, c); err != nil {
+		return dst, fmt.Errorf("cannot decompress column: %w", err)
+	}
+	raw := bb.Buf
+	if len(raw) < 1 {
+		return dst, fmt.Errorf("cannot decode column encoding from empty src")
+	}
+	enc := ColumnEncoding(raw[0])
+	if enc == ColumnEncodingDictionary {
+		if d == nil {
+			d = &Dictionary{}
+		}
+		dst, _, err = bbd.decodeDictionary(d, dst, raw[1:], itemsCount)
+		return dst, err
+	}
+	raw = raw[1:]
+	var layouts [][]byte
+	if enc == ColumnEncodingHistogram {
+		if layouts, raw, err = bbd.decodeDictionary(&Dictionary{}, nil, raw, itemsCount); err != nil {
+			return dst, fmt.Errorf("cannot decode histogram layouts: %w", err)
+		}
+	}
+	raw, lensLen := BytesToVarUint64(raw)
+	if uint64(len(raw)) < lensLen {
+		return dst, fmt.Errorf("cannot read value lengths with the size %d bytes from %d bytes", lensLen, len(raw))
+	}
+	u64List := GenerateUint64List(0)
+	defer ReleaseUint64List(u64List)
+	if u64List.L, err = decodeUint64List(u64List.L[:0], raw[:lensLen], itemsCount); err != nil {
+		return dst, fmt.Errorf("cannot decode value lengths: %w", err)
+	}
+	aLens := u64List.L
+	raw = raw[lensLen:]
+
+	dataLen := len(bbd.data)
+	switch enc {
+	case ColumnEncodingPlain:
+		bbd.data = append(bbd.data, raw...)
+	case ColumnEncodingHistogram:
+		for i, l := range aLens {
+			if uint64(len(raw)) < l {
+				return dst, fmt.Errorf("cannot decode histogram data with the length %d bytes from %d bytes", l, len(raw))
+			}
+			bbd.data = append(bbd.data, layouts[i]...)
+			bbd.data = append(bbd.data, raw[:l]...)
+			aLens[i] += uint64(len(layouts[i]))
+			raw = raw[l:]
+		}
+	case ColumnEncodingGorilla:
+		d := NewXORDecoder(NewReader(stdbytes.NewReader(raw)))
+		for _, l := range aLens {
+			if l == 0 {
+				continue
+			}
+			if !d.Next() {
+				return dst, fmt.Errorf("cannot decode gorilla values: %w", d.Err())
+			}
+			bbd.data = binary.BigEndian.AppendUint64(bbd.data, d.Value())
+		}
+	case ColumnEncodingDeltaOfDelta:
+		var first int64
+		if raw, first, err = BytesToVarInt64(raw); err != nil {
+			return dst, fmt.Errorf("cannot decode the first value: %w", err)
+		}
+		var count int
+		for _, l := range aLens {
+			if l > 0 {
+				count++
+			}
+		}
+		is := GenerateInt64List(0)
+		defer ReleaseInt64List(is)
+		if is.L, err = bytesDeltaOfDeltaToInt64s(is.L[:0], raw, first, count); err != nil {
+			return dst, fmt.Errorf("cannot decode delta-of-delta values: %w", err)
+		}
+		for _, v := range is.L {
+			bbd.data = int64ToFixed(bbd.data, v)
+		}
+	default:
+		return dst, fmt.Errorf("unexpected column encoding: %d", enc)
+	}
+
+	data := bbd.data[dataLen:]
+	for _, sLen := range aLens {
+		if uint64(len(data)) < sLen {
+			return dst, fmt.Errorf("cannot decode a value with the length %d bytes from %d bytes", sLen, len(data))
+		}
+		if sLen == 0 {
+			dst = append(dst, nil)
+			continue
+		}
+		dst = append(dst, data[:sLen])
+		data = data[sLen:]
+	}
+	return dst, nil
+}
+
+func isFixedColumn(a [][]byte, enc ColumnEncoding) bool {
+	if enc != ColumnEncodingGorilla && enc != ColumnEncodingDeltaOfDelta {
+		return false
+	}
+	var count int
+	for _, s := range a {
+		if len(s) == 0 {
+			continue
+		}
+		if len(s) != 8 {
+			return false
+		}
+		count++
+	}
+	if enc == ColumnEncodingDeltaOfDelta {
+		return count > 1
+	}
+	return count > 0
+}
+
+// fixedToInt64 converts an 8-byte value, encoded by convert.Int64ToBytes, to the int64.
+// Any 8 bytes have a unique int64, so the value is restored by int64ToFixed as it is.
+func fixedToInt64(s []byte) int64 {
+	return int64(binary.BigEndian.Uint64(s) ^ (1 << 63))
+}
+
+func int64ToFixed(dst []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(v)^(1<<63))
+}
+
+func compressColumn(dst, src []byte, c Codec) []byte {
+	switch c.Compression {
+	case ColumnCompressionNone:
+		return append(dst, src...)
+	case ColumnCompressionLZ4:
+		return lz4.Compress(dst, src)
+	case ColumnCompressionSnappy:
+		return snappy.Compress(dst, src)
+	default:
+		return zstd.Compress(dst, src, zstdLevel(c))
+	}
+}
+
+func decompressColumn(dst, src []byte, c Codec) ([]byte, error) {
+	switch c.Compression {
+	case ColumnCompressionNone:
+		return append(dst, src...), nil
+	case ColumnCompressionLZ4:
+		return lz4.Decompress(dst, src)
+	case ColumnCompressionSnappy:
+		return snappy.Decompress(dst, src)
+	default:
+		return zstd.Decompress(dst, src)
+	}
+}
+
+func zstdLevel(c Codec) int {
+	if c.Compression == ColumnCompressionZSTD && c.Level > 0 {
+		return int(c.Level)
+	}
+	return 1
+}
+
+// maxDictionarySize is the max number of the distinct values in a dictionary, so an id fits in a byte.
+const maxDictionarySize = 256
+
+// AdaptiveCodec returns the dictionary codec if the values repeat a few distinct ones, or the default codec otherwise.
+func AdaptiveCodec(a [][]byte) Codec {
+	if isDictionaryColumn(a) {
+		return Codec{Encoding: ColumnEncodingDictionary}
+	}
+	return Codec{}
+}
+
+// isDictionaryColumn reports whether the distinct values are few enough,
+// which means there are at most maxDictionarySize of them, and every one repeats twice on average.
+func isDictionaryColumn(a [][]byte) bool {
+	if len(a) < 2 {
+		return false
+	}
+	limit := min(maxDictionarySize, len(a)/2)
+	distinct := make(map[string]struct{}, limit)
+	for _, s := range a {
+		if _, ok := distinct[string(s)]; ok {
+			continue
+		}
+		if len(distinct) == limit {
+			return false
+		}
+		distinct[string(s)] = struct{}{}
+	}
+	return true
+}
+
+func encodeDictionary(dst []byte, a [][]byte) []byte {
+	ids := make(map[string]byte, maxDictionarySize)
+	idsOffset := len(dst)
+	dst = append(dst, make([]byte, len(a))...)
+	var values [][]byte
+	for i, s := range a {
+		id, ok := ids[string(s)]
+		if !ok {
+			id = byte(len(values))
+			ids[string(s)] = id
+			values = append(values, s)
+		}
+		dst[idsOffset+i] = id
+	}
+	dst = VarUint64ToBytes(dst, uint64(len(values)))
+	for _, v := range values {
+		dst = EncodeBytes(dst, v)
+	}
+	return dst
+}
+
+func (bbd *BytesBlockDecoder) decodeDictionary(d *Dictionary, dst [][]byte, src []byte, itemsCount uint64) ([][]byte, []byte, error) {
+	if uint64(len(src)) < itemsCount {
+		return dst, src, fmt.Errorf("cannot read %d dictionary ids from %d bytes", itemsCount, len(src))
+	}
+	d.IDs = append(d.IDs[:0], src[:itemsCount]...)
+	src, n := BytesToVarUint64(src[itemsCount:])
+	if n > maxDictionarySize {
+		return dst, src, fmt.Errorf("too many values in the dictionary: %d", n)
+	}
+	dataLen := len(bbd.data)
+	lens := make([]int, 0, n)
+	for i := uint64(0); i < n; i++ {
+		var v []byte
+		var err error
+		if src, v, err = DecodeBytes(src); err != nil {
+			return dst, src, fmt.Errorf("cannot decode the dictionary value %d: %w", i, err)
+		}
+		bbd.data = append(bbd.data, v...)
+		lens = append(lens, len(v))
+	}
+	data := bbd.data[dataLen:]
+	for _, l := range lens {
+		if l == 0 {
+			d.Values = append(d.Values, nil)
+			continue
+		}
+		d.Values = append(d.Values, data[:l])
+		data = data[l:]
+	}
+	for _, id := range d.IDs {
+		if int(id) >= len(d.Values) {
+			return dst, src, fmt.Errorf("dictionary id %d is out of %d values", id, len(d.Values))
+		}
+		dst = append(dst, d.Values[id])
+	}
+	return dst, src, nil
+}
+
+// isHistogramColumn reports whether every non-null value starts with a length-prefixed layout,
+// and there are at most maxDictionarySize distinct layouts.
+func isHistogramColumn(a [][]byte) bool {
+	distinct := make(map[string]struct{})
+	for _, s := range a {
+		if len(s) == 0 {
+			continue
+		}
+		n := histogramLayoutLen(s)
+		if n == 0 {
+			return false
+		}
+		if _, ok := distinct[string(s[:n])]; ok {
+			continue
+		}
+		if len(distinct) == maxDictionarySize {
+			return false
+		}
+		distinct[string(s[:n])] = struct{}{}
+	}
+	return true
+}
+
+// histogramLayoutLen returns the length of the layout, including its length prefix, at the start of s.
+// It returns 0 if s doesn't start with a layout.
+func histogramLayoutLen(s []byte) int {
+	tail, n := BytesToVarUint64(s)
+	if len(tail) == len(s) || uint64(len(tail)) < n {
+		return 0
+	}
+	return len(s) - len(tail) + int(n)
+}
+
+// Dictionary is the dictionary of a dictionary-encoded column.
+type Dictionary struct {
+	// Values are the distinct values. The null value is nil.
+	Values [][]byte
+	// IDs are the indexes of the values in Values.
+	IDs []byte
+}
+
+// Reset resets the dictionary.
+func (d *Dictionary) Reset() {
+	d.Values = d.Values[:0]
+	d.IDs = d.IDs[:0]
+}
+
+// Len returns the number of the values in the column.
+func (d *Dictionary) Len() int {
+	return len(d.IDs)
+}
+
+// Match evaluates the predicate once per distinct value,
+// and returns whether the value of each id matches it.
+func (d *Dictionary) Match(predicate func(value []byte) bool) []bool {
+	matched := make([]bool, len(d.Values))
+	for i, v := range d.Values {
+		matched[i] = predicate(v)
+	}
+	return matched
+}
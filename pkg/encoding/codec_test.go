@@ -0,0 +1,234 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package encoding_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+)
+
+var (
+	columnEncodings = map[string]encoding.ColumnEncoding{
+		"default":        encoding.ColumnEncodingDefault,
+		"plain":          encoding.ColumnEncodingPlain,
+		"gorilla":        encoding.ColumnEncodingGorilla,
+		"delta-of-delta": encoding.ColumnEncodingDeltaOfDelta,
+		"dictionary":     encoding.ColumnEncodingDictionary,
+		"histogram":      encoding.ColumnEncodingHistogram,
+	}
+	columnCompressions = map[string]encoding.Codec{
+		"default": {},
+		"none":    {Compression: encoding.ColumnCompressionNone},
+		"zstd-1":  {Compression: encoding.ColumnCompressionZSTD, Level: 1},
+		"zstd-9":  {Compression: encoding.ColumnCompressionZSTD, Level: 9},
+		"lz4":     {Compression: encoding.ColumnCompressionLZ4},
+		"snappy":  {Compression: encoding.ColumnCompressionSnappy},
+	}
+)
+
+func counterColumn(n int) [][]byte {
+	values := make([][]byte, n)
+	v := rand.Int64N(1000)
+	for i := range values {
+		v += rand.Int64N(10)
+		values[i] = convert.Int64ToBytes(v)
+	}
+	return values
+}
+
+func gaugeColumn(n int) [][]byte {
+	values := make([][]byte, n)
+	v := 50.0
+	for i := range values {
+		v += rand.Float64() - 0.5
+		values[i] = convert.Float64ToBytes(float64(int(v*100)) / 100)
+	}
+	return values
+}
+
+func stringColumn(n int) [][]byte {
+	values := make([][]byte, n)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("service_%d", rand.IntN(10)))
+	}
+	return values
+}
+
+func histogramColumn(n int) [][]byte {
+	layouts := [][]byte{[]byte("layout-a"), []byte("layout-b")}
+	values := make([][]byte, n)
+	for i := range values {
+		values[i] = encoding.EncodeBytes(nil, layouts[rand.IntN(len(layouts))])
+		values[i] = encoding.VarUint64ToBytes(values[i], rand.Uint64N(1000))
+	}
+	return values
+}
+
+func TestEncodeColumnAndDecode(t *testing.T) {
+	counters := counterColumn(1000)
+	counters[3] = nil
+	counters[500] = nil
+	columns := map[string][][]byte{
+		"counter": counters,
+		"gauge":   gaugeColumn(1000),
+		"string":  stringColumn(1000),
+		"hist":    append(histogramColumn(1000), nil),
+		"single":  {convert.Int64ToBytes(-5)},
+		"nulls":   {nil, nil, convert.Int64ToBytes(1), nil},
+		"extreme": {convert.Int64ToBytes(-1 << 63), convert.Int64ToBytes(1<<63 - 1), convert.Int64ToBytes(0)},
+	}
+	for colName, values := range columns {
+		for encName, enc := range columnEncodings {
+			for compName, c := range columnCompressions {
+				c.Encoding = enc
+				t.Run(fmt.Sprintf("%s/%s/%s", colName, encName, compName), func(t *testing.T) {
+					encoded := encoding.EncodeColumn(nil, values, c)
+					decoder := &encoding.BytesBlockDecoder{}
+					decoded, err := decoder.DecodeColumn(nil, encoded, uint64(len(values)), c)
+					require.NoError(t, err)
+					require.Len(t, decoded, len(values))
+					for i := range values {
+						assert.Equal(t, values[i], decoded[i], "value %d", i)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestAdaptiveCodec(t *testing.T) {
+	assert.Equal(t, encoding.Codec{Encoding: encoding.ColumnEncodingDictionary}, encoding.AdaptiveCodec(stringColumn(1000)))
+	assert.True(t, encoding.AdaptiveCodec(counterColumn(1000)).IsDefault())
+	assert.True(t, encoding.AdaptiveCodec([][]byte{[]byte("a")}).IsDefault())
+	assert.True(t, encoding.AdaptiveCodec([][]byte{[]byte("a"), []byte("b"), []byte("c")}).IsDefault())
+}
+
+func TestDecodeDictionary(t *testing.T) {
+	values := [][]byte{[]byte("info"), []byte("warn"), nil, []byte("info"), []byte("error"), []byte("info"), []byte("warn"), nil}
+	c := encoding.AdaptiveCodec(values)
+	encoded := encoding.EncodeColumn(nil, values, c)
+
+	decoder := &encoding.BytesBlockDecoder{}
+	d := &encoding.Dictionary{}
+	decoded, err := decoder.DecodeDictionary(d, nil, encoded, uint64(len(values)), c)
+	require.NoError(t, err)
+	assert.Equal(t, values, decoded)
+	assert.Equal(t, len(values), d.Len())
+	assert.Len(t, d.Values, 4)
+
+	var calls int
+	matched := d.Match(func(v []byte) bool {
+		calls++
+		return string(v) == "info"
+	})
+	assert.Equal(t, 4, calls)
+	for i, id := range d.IDs {
+		assert.Equal(t, string(values[i]) == "info", matched[id])
+	}
+
+	// The values which aren't dictionary-encoded leave the dictionary empty.
+	plain := encoding.Codec{Encoding: encoding.ColumnEncodingPlain}
+	decoded, err = decoder.DecodeDictionary(d, nil, encoding.EncodeColumn(nil, values, plain), uint64(len(values)), plain)
+	require.NoError(t, err)
+	assert.Equal(t, values, decoded)
+	assert.Equal(t, 0, d.Len())
+}
+
+func TestEncodeColumn_histogram(t *testing.T) {
+	values := histogramColumn(1000)
+	c := encoding.Codec{Encoding: encoding.ColumnEncodingHistogram}
+	encoded := encoding.EncodeColumn(nil, values, c)
+	assert.Less(t, len(encoded), len(encoding.EncodeColumn(nil, values, encoding.Codec{Encoding: encoding.ColumnEncodingPlain})))
+
+	// The values without a layout fall back to the plain encoding.
+	malformed := [][]byte{{0xff}, {10, 1}}
+	encoded = encoding.EncodeColumn(nil, malformed, c)
+	decoded, err := (&encoding.BytesBlockDecoder{}).DecodeColumn(nil, encoded, uint64(len(malformed)), c)
+	require.NoError(t, err)
+	assert.Equal(t, malformed, decoded)
+}
+
+func TestCodecMarshal(t *testing.T) {
+	c := encoding.Codec{Encoding: encoding.ColumnEncodingGorilla, Compression: encoding.ColumnCompressionZSTD, Level: 9}
+	src := c.Marshal([]byte("prefix"))
+	tail, got, err := encoding.UnmarshalCodec(src[len("prefix"):])
+	require.NoError(t, err)
+	assert.Empty(t, tail)
+	assert.Equal(t, c, got)
+
+	_, _, err = encoding.UnmarshalCodec([]byte{byte(encoding.ColumnEncodingHistogram + 1), 0, 0})
+	assert.Error(t, err)
+	_, _, err = encoding.UnmarshalCodec([]byte{0})
+	assert.Error(t, err)
+}
+
+func BenchmarkEncodeColumn(b *testing.B) {
+	columns := map[string][][]byte{
+		"counter": counterColumn(8192),
+		"gauge":   gaugeColumn(8192),
+		"string":  stringColumn(8192),
+	}
+	for colName, values := range columns {
+		for encName, enc := range columnEncodings {
+			for compName, c := range columnCompressions {
+				c.Encoding = enc
+				encoded := encoding.EncodeColumn(nil, values, c)
+				b.Run(fmt.Sprintf("%s/%s/%s", colName, encName, compName), func(b *testing.B) {
+					b.ReportMetric(float64(len(encoded))/float64(len(values)), "bytes/value")
+					var buf []byte
+					for i := 0; i < b.N; i++ {
+						buf = encoding.EncodeColumn(buf[:0], values, c)
+					}
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeColumn(b *testing.B) {
+	columns := map[string][][]byte{
+		"counter": counterColumn(8192),
+		"gauge":   gaugeColumn(8192),
+		"string":  stringColumn(8192),
+	}
+	for colName, values := range columns {
+		for encName, enc := range columnEncodings {
+			for compName, c := range columnCompressions {
+				c.Encoding = enc
+				encoded := encoding.EncodeColumn(nil, values, c)
+				b.Run(fmt.Sprintf("%s/%s/%s", colName, encName, compName), func(b *testing.B) {
+					decoder := &encoding.BytesBlockDecoder{}
+					var dst [][]byte
+					var err error
+					for i := 0; i < b.N; i++ {
+						decoder.Reset()
+						if dst, err = decoder.DecodeColumn(dst[:0], encoded, uint64(len(values)), c); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
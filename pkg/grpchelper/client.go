@@ -43,6 +43,18 @@ func SecureOptions(dest []grpc.DialOption, enabled, insecure bool, cert string)
 		dest = append(dest, grpc.WithTransportCredentials(ins.NewCredentials()))
 		return dest, nil
 	}
+	config, err := TLSConfig(insecure, cert)
+	if err != nil {
+		return nil, err
+	}
+	creds := credentials.NewTLS(config)
+	dest = append(dest, grpc.WithTransportCredentials(creds))
+	return dest, nil
+}
+
+// TLSConfig returns the TLS config verifying the server by the certificate.
+// The callers set the client certificate on it if the server verifies the clients.
+func TLSConfig(insecure bool, cert string) (*tls.Config, error) {
 	config := &tls.Config{
 		// #nosec G402
 		InsecureSkipVerify: insecure,
@@ -58,9 +70,7 @@ func SecureOptions(dest []grpc.DialOption, enabled, insecure bool, cert string)
 		}
 		config.RootCAs = certPool
 	}
-	creds := credentials.NewTLS(config)
-	dest = append(dest, grpc.WithTransportCredentials(creds))
-	return dest, nil
+	return config, nil
 }
 
 // Conn returns a gRPC client connection once connecting the server.
@@ -92,6 +92,14 @@ func NewIntField(key FieldKey, value int64) Field {
 	}
 }
 
+// NewFloatField creates a new float field.
+func NewFloatField(key FieldKey, value float64) Field {
+	return Field{
+		term: &FloatTermValue{Value: value},
+		Key:  key,
+	}
+}
+
 // NewBytesField creates a new bytes field.
 func NewBytesField(key FieldKey, value []byte) Field {
 	return Field{
@@ -232,6 +240,16 @@ func NewIntRangeOpts(lower, upper int64, includesLower, includesUpper bool) Rang
 	}
 }
 
+// NewFloatRangeOpts creates a new float range option.
+func NewFloatRangeOpts(lower, upper float64, includesLower, includesUpper bool) RangeOpts {
+	return RangeOpts{
+		Lower:         &FloatTermValue{Value: lower},
+		Upper:         &FloatTermValue{Value: upper},
+		IncludesLower: includesLower,
+		IncludesUpper: includesUpper,
+	}
+}
+
 // NewBytesRangeOpts creates a new bytes range option.
 func NewBytesRangeOpts(lower, upper []byte, includesLower, includesUpper bool) RangeOpts {
 	if len(upper) == 0 {
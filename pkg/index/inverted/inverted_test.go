@@ -19,6 +19,7 @@ package inverted
 
 import (
 	"context"
+	"math"
 	"strings"
 	"testing"
 
@@ -354,6 +355,40 @@ func TestStore_NumericMatch(t *testing.T) {
 	tester.True(roaring.NewPostingListWithInitialData(1).Equal(l))
 }
 
+func TestStore_FloatMatchAndRange(t *testing.T) {
+	tester := assert.New(t)
+	path, fn := setUp(require.New(t))
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	var batch index.Batch
+	latencyName := index.FieldKey{
+		IndexRuleID: 8,
+	}
+	for i, v := range []float64{-1.5, 0.25, 0.5, 12.75} {
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{index.NewFloatField(latencyName, v)},
+			DocID:  uint64(i + 1),
+		})
+	}
+	tester.NoError(s.Batch(batch))
+	l, _, err := s.MatchTerms(index.NewFloatField(latencyName, 0.5))
+	tester.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(3).Equal(l))
+	l, _, err = s.Range(latencyName, index.NewFloatRangeOpts(-1, 1, true, false))
+	tester.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(2, 3).Equal(l))
+	l, _, err = s.Range(latencyName, index.NewFloatRangeOpts(math.Inf(-1), 0.25, false, true))
+	tester.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(1, 2).Equal(l))
+}
+
 func setUp(t *require.Assertions) (tempDir string, deferFunc func()) {
 	t.NoError(logger.Init(logger.Logging{
 		Env:   "dev",
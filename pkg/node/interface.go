@@ -38,6 +38,15 @@ var (
 	ErrNoAvailableNode = errors.New("selector: no available node")
 )
 
+// LabelDraining is the label of a data node which is being drained.
+// The selectors stop routing the writes to a node once its value is "true".
+const LabelDraining = "draining"
+
+// IsDraining returns whether the node is being drained.
+func IsDraining(node *databasev1.Node) bool {
+	return node.GetLabels()[LabelDraining] == "true"
+}
+
 // Selector keeps all data nodes in the memory and can provide different algorithm to pick an available node.
 //
 //go:generate mockgen -destination=mock/node_selector_mock.go -package=mock github.com/apache/skywalking-banyandb/pkg/node Selector
@@ -46,10 +55,32 @@ type Selector interface {
 	RemoveNode(node *databasev1.Node)
 	SetNodeSelector(selector *pub.LabelSelector)
 	Pick(group, name string, shardID uint32) (string, error)
+	// PickReplicas returns the nodes holding the copies of the shard, and the first one is the node returned by Pick.
+	PickReplicas(group, name string, shardID uint32) ([]string, error)
 	run.PreRunner
 	fmt.Stringer
 }
 
+// Balancer is a Selector which keeps the shards on their nodes until they are moved by a rebalance.
+type Balancer interface {
+	Selector
+	// Rebalance returns the shards of the group which should be moved to spread the shards evenly over the nodes.
+	// The shards stay on their nodes until they are assigned to the new ones.
+	Rebalance(group string) ([]Move, error)
+	// Assign places the shard of the group on the node, and persists the placement.
+	Assign(ctx context.Context, group string, shardID uint32, node string) error
+}
+
+// Move is a shard which should be moved by a rebalance.
+type Move struct {
+	Group string
+	// From is the nodes holding the copies of the shard now.
+	From []string
+	// To is the nodes which should hold the copies of the shard, and the first one holds the primary copy.
+	To      []string
+	ShardID uint32
+}
+
 // NewPickFirstSelector returns a simple selector that always returns the first node if exists.
 func NewPickFirstSelector() (Selector, error) {
 	return &pickFirstSelector{
@@ -127,3 +158,11 @@ func (p *pickFirstSelector) Pick(_, _ string, _ uint32) (string, error) {
 	}
 	return p.nodeIDs[0], nil
 }
+
+func (p *pickFirstSelector) PickReplicas(group, name string, shardID uint32) ([]string, error) {
+	n, err := p.Pick(group, name, shardID)
+	if err != nil {
+		return nil, err
+	}
+	return []string{n}, nil
+}
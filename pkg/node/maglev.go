@@ -26,18 +26,28 @@ import (
 
 	"github.com/kkdai/maglev"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 )
 
 const lookupTableSize = 65537
 
-var _ Selector = (*maglevSelector)(nil)
+var (
+	_ Selector            = (*maglevSelector)(nil)
+	_ schema.EventHandler = (*maglevSelector)(nil)
+)
 
 type maglevSelector struct {
-	routers sync.Map
-	nodes   []string
-	mutex   sync.RWMutex
+	schema.UnimplementedOnInitHandler
+	schemaRegistry metadata.Repo
+	routers        sync.Map
+	// replicas records the number of the replicas of each group.
+	replicas sync.Map
+	nodes    []string
+	mutex    sync.RWMutex
 }
 
 func (m *maglevSelector) SetNodeSelector(_ *pub.LabelSelector) {}
@@ -50,7 +60,7 @@ func (m *maglevSelector) String() string {
 		return true
 	})
 	m.mutex.RLock()
-	defer m.mutex.Unlock()
+	defer m.mutex.RUnlock()
 	return fmt.Sprintf("nodes:%s groups:%s", m.nodes, groups)
 }
 
@@ -59,10 +69,32 @@ func (m *maglevSelector) Name() string {
 }
 
 func (m *maglevSelector) PreRun(context.Context) error {
+	if m.schemaRegistry != nil {
+		m.schemaRegistry.RegisterHandler(m.Name(), schema.KindGroup, m)
+	}
 	return nil
 }
 
+func (m *maglevSelector) OnAddOrUpdate(schemaMetadata schema.Metadata) {
+	if schemaMetadata.Kind != schema.KindGroup {
+		return
+	}
+	group := schemaMetadata.Spec.(*commonv1.Group)
+	m.replicas.Store(group.GetMetadata().GetName(), group.GetResourceOpts().GetReplicas())
+}
+
+func (m *maglevSelector) OnDelete(schemaMetadata schema.Metadata) {
+	if schemaMetadata.Kind != schema.KindGroup {
+		return
+	}
+	m.replicas.Delete(schemaMetadata.Spec.(*commonv1.Group).GetMetadata().GetName())
+}
+
 func (m *maglevSelector) AddNode(node *databasev1.Node) {
+	if IsDraining(node) {
+		m.RemoveNode(node)
+		return
+	}
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	for i := range m.nodes {
@@ -113,9 +145,38 @@ func (m *maglevSelector) Pick(group, name string, shardID uint32) (string, error
 	return mTab.Get(formatSearchKey(name, shardID))
 }
 
+// PickReplicas returns the node picked by Pick and the following ones in the sorted nodes,
+// which hold the replicas of the shard. The replicas are capped by the number of the nodes.
+func (m *maglevSelector) PickReplicas(group, name string, shardID uint32) ([]string, error) {
+	primary, err := m.Pick(group, name, shardID)
+	if err != nil {
+		return nil, err
+	}
+	var replicas uint32
+	if v, ok := m.replicas.Load(group); ok {
+		replicas = v.(uint32)
+	}
+	if replicas == 0 {
+		return []string{primary}, nil
+	}
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	start := sort.SearchStrings(m.nodes, primary)
+	if start == len(m.nodes) || m.nodes[start] != primary {
+		// The primary node has been removed since it was picked.
+		return []string{primary}, nil
+	}
+	nodes := make([]string, min(int(replicas)+1, len(m.nodes)))
+	for i := range nodes {
+		nodes[i] = m.nodes[(start+i)%len(m.nodes)]
+	}
+	return nodes, nil
+}
+
 // NewMaglevSelector creates a new backend selector based on Maglev hashing algorithm.
-func NewMaglevSelector() Selector {
-	return &maglevSelector{}
+// The replicas of the groups are read from the schema registry, and no shard has replicas if it's nil.
+func NewMaglevSelector(schemaRegistry metadata.Repo) Selector {
+	return &maglevSelector{schemaRegistry: schemaRegistry}
 }
 
 func formatSearchKey(name string, shardID uint32) string {
@@ -19,6 +19,7 @@ package node
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/google/uuid"
@@ -26,6 +27,7 @@ import (
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 )
 
 const (
@@ -34,7 +36,7 @@ const (
 )
 
 func TestMaglevSelector(t *testing.T) {
-	sel := NewMaglevSelector()
+	sel := NewMaglevSelector(nil)
 	sel.AddNode(&databasev1.Node{
 		Metadata: &commonv1.Metadata{
 			Name: "data-node-1",
@@ -54,7 +56,7 @@ func TestMaglevSelector(t *testing.T) {
 }
 
 func TestMaglevSelector_EvenDistribution(t *testing.T) {
-	sel := NewMaglevSelector()
+	sel := NewMaglevSelector(nil)
 	dataNodeNum := 10
 	for i := 0; i < dataNodeNum; i++ {
 		sel.AddNode(&databasev1.Node{
@@ -81,8 +83,8 @@ func TestMaglevSelector_EvenDistribution(t *testing.T) {
 }
 
 func TestMaglevSelector_DiffNode(t *testing.T) {
-	fullSel := NewMaglevSelector()
-	brokenSel := NewMaglevSelector()
+	fullSel := NewMaglevSelector(nil)
+	brokenSel := NewMaglevSelector(nil)
 	dataNodeNum := 10
 	for i := 0; i < dataNodeNum; i++ {
 		fullSel.AddNode(&databasev1.Node{
@@ -111,8 +113,39 @@ func TestMaglevSelector_DiffNode(t *testing.T) {
 	assert.InEpsilon(t, trialCount/dataNodeNum, diff, targetEpsilon*2)
 }
 
+func TestMaglevSelector_PickReplicas(t *testing.T) {
+	sel := NewMaglevSelector(nil)
+	sel.(*maglevSelector).OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindGroup},
+		Spec: &commonv1.Group{
+			Metadata:     &commonv1.Metadata{Name: "sw_metrics"},
+			Catalog:      commonv1.Catalog_CATALOG_MEASURE,
+			ResourceOpts: &commonv1.ResourceOpts{ShardNum: 2, Replicas: 2},
+		},
+	})
+	sel.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "data-node-1"}})
+	nodes, err := sel.PickReplicas("sw_metrics", "traffic_instance", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"data-node-1"}, nodes, "The replicas should be capped by the number of the nodes")
+	for i := 2; i <= 4; i++ {
+		sel.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: fmt.Sprintf(dataNodeTemplate, i)}})
+	}
+	for shardID := uint32(0); shardID < 2; shardID++ {
+		nodes, err = sel.PickReplicas("sw_metrics", "traffic_instance", shardID)
+		assert.NoError(t, err)
+		assert.Len(t, nodes, 3)
+		assert.ElementsMatch(t, slices.Compact(slices.Sorted(slices.Values(nodes))), nodes)
+		primary, errPick := sel.Pick("sw_metrics", "traffic_instance", shardID)
+		assert.NoError(t, errPick)
+		assert.Equal(t, primary, nodes[0])
+	}
+	nodes, err = sel.PickReplicas("sw_records", "segment", 0)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1, "A group without replicas")
+}
+
 func BenchmarkMaglevSelector_Pick(b *testing.B) {
-	sel := NewMaglevSelector()
+	sel := NewMaglevSelector(nil)
 	dataNodeNum := 10
 	for i := 0; i < dataNodeNum; i++ {
 		sel.AddNode(&databasev1.Node{
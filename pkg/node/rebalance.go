@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+)
+
+// Rebalance caps the shards on every node by the average number of the shards of all the groups.
+// A shard stays on its node if the node isn't over the cap, and the others are moved to the least loaded nodes.
+// The shards which are never placed are included even if they stay, so that their placements are persisted by Assign.
+// The shards placed on a node which is gone or being drained are moved from that node.
+func (r *roundRobinSelector) Rebalance(group string) ([]Move, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.nodes) == 0 {
+		return nil, ErrNoAvailableNode
+	}
+	g, ok := r.groups[group]
+	if !ok {
+		return nil, fmt.Errorf("%s is an unknown group", group)
+	}
+	loads := make([]int, len(r.nodes))
+	var shards []int
+	for i, k := range r.lookupTable {
+		if k.group == group {
+			shards = append(shards, i)
+			continue
+		}
+		loads[r.ownerIndex(i)]++
+	}
+	quota := (len(r.lookupTable) + len(r.nodes) - 1) / len(r.nodes)
+	targets := make([]int, len(shards))
+	for j, i := range shards {
+		targets[j] = -1
+		if owner := r.ownerIndex(i); loads[owner] < quota {
+			loads[owner]++
+			targets[j] = owner
+		}
+	}
+	for j := range targets {
+		if targets[j] >= 0 {
+			continue
+		}
+		least := 0
+		for n := range loads {
+			if loads[n] < loads[least] {
+				least = n
+			}
+		}
+		loads[least]++
+		targets[j] = least
+	}
+	replicas := min(int(g.ResourceOpts.Replicas)+1, len(r.nodes))
+	var moves []Move
+	for j, i := range shards {
+		k := r.lookupTable[i]
+		if owner, assigned := r.assignments[k]; assigned && owner == r.nodes[targets[j]] {
+			continue
+		}
+		from := r.ring(r.ownerIndex(i), replicas)
+		if owner, assigned := r.assignments[k]; assigned {
+			if _, alive := slices.BinarySearch(r.nodes, owner); !alive {
+				from = []string{owner}
+			}
+		}
+		moves = append(moves, Move{
+			Group:   group,
+			ShardID: k.shardID,
+			From:    from,
+			To:      r.ring(targets[j], replicas),
+		})
+	}
+	return moves, nil
+}
+
+func (r *roundRobinSelector) Assign(ctx context.Context, group string, shardID uint32, node string) error {
+	r.mu.RLock()
+	i, err := r.search(group, shardID)
+	if err != nil {
+		r.mu.RUnlock()
+		return err
+	}
+	if _, found := slices.BinarySearch(r.nodes, node); !found {
+		r.mu.RUnlock()
+		return fmt.Errorf("node %s isn't available", node)
+	}
+	shard := r.newShard(r.lookupTable[i], node)
+	r.mu.RUnlock()
+	if shard == nil {
+		return fmt.Errorf("%s is an unknown group", group)
+	}
+	if r.schemaRegistry != nil && r.sticky() {
+		err = r.schemaRegistry.ShardRegistry().UpdateShard(ctx, shard)
+		if errors.Is(err, schema.ErrGRPCResourceNotFound) {
+			err = r.schemaRegistry.ShardRegistry().CreateShard(ctx, shard)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed to persist the placement of %s-%d", group, shardID)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setAssignment(shard)
+	return nil
+}
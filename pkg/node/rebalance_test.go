@@ -0,0 +1,161 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+)
+
+func TestPickStickyAfterNodeJoin(t *testing.T) {
+	sticky := NewRoundRobinSelector("test", nil)
+	anonymous := NewRoundRobinSelector("", nil)
+	for _, s := range []Selector{sticky, anonymous} {
+		setupGroup(s)
+		s.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+		s.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node2"}})
+		node, err := s.Pick("group1", "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, "node1", node)
+		s.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node0"}})
+	}
+	node, err := sticky.Pick("group1", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "node1", node, "the shard should stay on its node")
+	node, err = anonymous.Pick("group1", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "node0", node, "the anonymous selector should compute the placement")
+}
+
+func TestPickFollowsShardEvents(t *testing.T) {
+	selector := NewRoundRobinSelector("test", nil).(*roundRobinSelector)
+	setupGroup(selector)
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node2"}})
+	shard := schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindShard},
+		Spec: &databasev1.Shard{
+			Id:       0,
+			Metadata: &commonv1.Metadata{Group: "group1", Name: schema.ShardName(0)},
+			Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+			Node:     "node2",
+			Total:    2,
+		},
+	}
+	selector.OnAddOrUpdate(shard)
+	node, err := selector.Pick("group1", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "node2", node)
+	selector.OnDelete(shard)
+	selector.RemoveNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+	node, err = selector.Pick("group1", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "node2", node)
+}
+
+func TestRebalance(t *testing.T) {
+	selector := NewRoundRobinSelector("test", nil).(*roundRobinSelector)
+	selector.OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindGroup},
+		Spec: &commonv1.Group{
+			Metadata:     &commonv1.Metadata{Name: "group1"},
+			Catalog:      commonv1.Catalog_CATALOG_MEASURE,
+			ResourceOpts: &commonv1.ResourceOpts{ShardNum: 4},
+		},
+	})
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node2"}})
+	for shardID := uint32(0); shardID < 4; shardID++ {
+		_, err := selector.Pick("group1", "", shardID)
+		require.NoError(t, err)
+	}
+	moves, err := selector.Rebalance("group1")
+	require.NoError(t, err)
+	assert.Empty(t, moves, "the shards are balanced")
+
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node3"}})
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node4"}})
+	moves, err = selector.Rebalance("group1")
+	require.NoError(t, err)
+	require.Len(t, moves, 2)
+	ctx := context.Background()
+	for _, m := range moves {
+		require.Len(t, m.To, 1)
+		assert.Contains(t, []string{"node3", "node4"}, m.To[0])
+		require.NoError(t, selector.Assign(ctx, m.Group, m.ShardID, m.To[0]))
+	}
+	owners := make(map[string]struct{})
+	for shardID := uint32(0); shardID < 4; shardID++ {
+		node, errPick := selector.Pick("group1", "", shardID)
+		require.NoError(t, errPick)
+		owners[node] = struct{}{}
+	}
+	assert.Len(t, owners, 4)
+	moves, err = selector.Rebalance("group1")
+	require.NoError(t, err)
+	assert.Empty(t, moves)
+
+	assert.Error(t, selector.Assign(ctx, "group1", 0, "node5"))
+	_, err = selector.Rebalance("group2")
+	assert.Error(t, err)
+}
+
+func TestRebalanceDrainingNode(t *testing.T) {
+	selector := NewRoundRobinSelector("test", nil).(*roundRobinSelector)
+	selector.OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindGroup},
+		Spec: &commonv1.Group{
+			Metadata:     &commonv1.Metadata{Name: "group1"},
+			Catalog:      commonv1.Catalog_CATALOG_STREAM,
+			ResourceOpts: &commonv1.ResourceOpts{ShardNum: 4},
+		},
+	})
+	for _, n := range []string{"node1", "node2"} {
+		selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: n}})
+	}
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+	assert.Equal(t, []string{"node1", "node2"}, selector.nodes, "adding a node twice should be a no-op")
+	for shardID := uint32(0); shardID < 4; shardID++ {
+		_, err := selector.Pick("group1", "", shardID)
+		require.NoError(t, err)
+	}
+
+	selector.AddNode(&databasev1.Node{
+		Metadata: &commonv1.Metadata{Name: "node1"},
+		Labels:   map[string]string{LabelDraining: "true"},
+	})
+	for shardID := uint32(0); shardID < 4; shardID++ {
+		node, err := selector.Pick("group1", "", shardID)
+		require.NoError(t, err)
+		assert.Equal(t, "node2", node, "the writes shouldn't be routed to the draining node")
+	}
+	moves, err := selector.Rebalance("group1")
+	require.NoError(t, err)
+	require.Len(t, moves, 2)
+	for _, m := range moves {
+		assert.Equal(t, []string{"node1"}, m.From)
+		assert.Equal(t, []string{"node2"}, m.To)
+	}
+}
@@ -35,11 +35,20 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
 )
 
+var _ Balancer = (*roundRobinSelector)(nil)
+
+// roundRobinSelector spreads the shards over the nodes in the round-robin way.
+// A named selector keeps the shards on the nodes where they are placed at first,
+// and persists the placements to the schema registry. They are moved only by a rebalance.
 type roundRobinSelector struct {
 	schemaRegistry metadata.Repo
 	nodeSelector   *pub.LabelSelector
+	groups         map[string]*commonv1.Group
+	assignments    map[key]string
+	l              *logger.Logger
 	name           string
 	lookupTable    []key
 	nodes          []string
@@ -50,14 +59,13 @@ func (r *roundRobinSelector) String() string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	result := make(map[string]string)
-	for _, entry := range r.lookupTable {
-		n, err := r.Pick(entry.group, "", entry.shardID)
+	for i, entry := range r.lookupTable {
 		key := fmt.Sprintf("%s-%d", entry.group, entry.shardID)
-		if err != nil {
-			result[key] = fmt.Sprintf("%v", err)
+		if len(r.nodes) == 0 {
+			result[key] = "no nodes available"
 			continue
 		}
-		result[key] = n
+		result[key] = r.nodes[r.ownerIndex(i)]
 	}
 	if len(result) < 1 {
 		return ""
@@ -76,6 +84,9 @@ func NewRoundRobinSelector(name string, schemaRegistry metadata.Repo) Selector {
 		nodes:          make([]string, 0),
 		schemaRegistry: schemaRegistry,
 		lookupTable:    make([]key, 0),
+		groups:         make(map[string]*commonv1.Group),
+		assignments:    make(map[key]string),
+		l:              logger.GetLogger("round-robin-selector"),
 	}
 	return rrs
 }
@@ -90,10 +101,29 @@ func (r *roundRobinSelector) SetNodeSelector(selector *pub.LabelSelector) {
 
 func (r *roundRobinSelector) PreRun(context.Context) error {
 	r.schemaRegistry.RegisterHandler(r.name, schema.KindGroup, r)
+	if r.sticky() {
+		r.schemaRegistry.RegisterHandler(r.name, schema.KindShard, r)
+	}
 	return nil
 }
 
+// sticky returns true if the selector keeps the placements of the shards.
+// The anonymous selectors, e.g. the ones used by the lifecycle migration, always compute the placements.
+func (r *roundRobinSelector) sticky() bool {
+	return r.name != ""
+}
+
 func (r *roundRobinSelector) OnAddOrUpdate(schemaMetadata schema.Metadata) {
+	if schemaMetadata.Kind == schema.KindShard {
+		shard, ok := schemaMetadata.Spec.(*databasev1.Shard)
+		if !ok || !r.sticky() {
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.setAssignment(shard)
+		return
+	}
 	if schemaMetadata.Kind != schema.KindGroup {
 		return
 	}
@@ -108,9 +138,24 @@ func (r *roundRobinSelector) OnAddOrUpdate(schemaMetadata schema.Metadata) {
 		k := key{group: group.Metadata.Name, shardID: i}
 		r.lookupTable = append(r.lookupTable, k)
 	}
+	r.setGroup(group)
 	r.sortEntries()
 }
 
+func (r *roundRobinSelector) setGroup(group *commonv1.Group) {
+	if r.groups == nil {
+		r.groups = make(map[string]*commonv1.Group)
+	}
+	r.groups[group.Metadata.Name] = group
+}
+
+func (r *roundRobinSelector) setAssignment(shard *databasev1.Shard) {
+	if r.assignments == nil {
+		r.assignments = make(map[key]string)
+	}
+	r.assignments[key{group: shard.Metadata.Group, shardID: uint32(shard.Id)}] = shard.Node
+}
+
 func (r *roundRobinSelector) removeGroup(group string) {
 	for i := 0; i < len(r.lookupTable); {
 		if r.lookupTable[i].group == group {
@@ -123,22 +168,62 @@ func (r *roundRobinSelector) removeGroup(group string) {
 }
 
 func (r *roundRobinSelector) OnDelete(schemaMetadata schema.Metadata) {
-	if schemaMetadata.Kind != schema.KindGroup {
-		return
+	switch schemaMetadata.Kind {
+	case schema.KindShard:
+		shard := schemaMetadata.Spec.(*databasev1.Shard)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.assignments, key{group: shard.Metadata.Group, shardID: uint32(shard.Id)})
+	case schema.KindGroup:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		group := schemaMetadata.Spec.(*commonv1.Group)
+		r.removeGroup(group.Metadata.Name)
+		delete(r.groups, group.Metadata.Name)
+		for k := range r.assignments {
+			if k.group == group.Metadata.Name {
+				delete(r.assignments, k)
+			}
+		}
+	default:
 	}
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	group := schemaMetadata.Spec.(*commonv1.Group)
-	r.removeGroup(group.Metadata.Name)
 }
 
 func (r *roundRobinSelector) OnInit(kinds []schema.Kind) (bool, []int64) {
 	if len(kinds) != 1 {
 		return false, nil
 	}
-	if kinds[0] != schema.KindGroup {
+	switch kinds[0] {
+	case schema.KindGroup:
+		return r.initGroups()
+	case schema.KindShard:
+		return r.initAssignments()
+	default:
 		return false, nil
 	}
+}
+
+func (r *roundRobinSelector) initAssignments() (bool, []int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	ss, err := r.schemaRegistry.ShardRegistry().ListShard(ctx, schema.ListOpt{})
+	if err != nil {
+		panic(fmt.Sprintf("failed to list shards: %v", err))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var revision int64
+	clear(r.assignments)
+	for _, s := range ss {
+		if s.Metadata.ModRevision > revision {
+			revision = s.Metadata.ModRevision
+		}
+		r.setAssignment(s)
+	}
+	return true, []int64{revision}
+}
+
+func (r *roundRobinSelector) initGroups() (bool, []int64) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 	gg, err := r.schemaRegistry.GroupRegistry().ListGroup(ctx)
@@ -149,6 +234,7 @@ func (r *roundRobinSelector) OnInit(kinds []schema.Kind) (bool, []int64) {
 	defer r.mu.Unlock()
 	var revision int64
 	r.lookupTable = r.lookupTable[:0]
+	clear(r.groups)
 	for _, g := range gg {
 		if !validateGroup(g) {
 			continue
@@ -160,17 +246,23 @@ func (r *roundRobinSelector) OnInit(kinds []schema.Kind) (bool, []int64) {
 			k := key{group: g.Metadata.Name, shardID: i}
 			r.lookupTable = append(r.lookupTable, k)
 		}
+		r.setGroup(g)
 	}
 	r.sortEntries()
 	return true, []int64{revision}
 }
 
 func (r *roundRobinSelector) AddNode(node *databasev1.Node) {
-	if r.nodeSelector != nil && !r.nodeSelector.Matches(node.Labels) {
+	if r.nodeSelector != nil && !r.nodeSelector.Matches(node.Labels) || IsDraining(node) {
+		// The node is updated with the labels which exclude it.
+		r.RemoveNode(node)
 		return
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, found := slices.BinarySearch(r.nodes, node.Metadata.Name); found {
+		return
+	}
 	r.nodes = append(r.nodes, node.Metadata.Name)
 	sort.StringSlice(r.nodes).Sort()
 }
@@ -187,11 +279,119 @@ func (r *roundRobinSelector) RemoveNode(node *databasev1.Node) {
 }
 
 func (r *roundRobinSelector) Pick(group, _ string, shardID uint32) (string, error) {
+	nodes, err := r.pick(group, shardID, false)
+	if err != nil {
+		return "", err
+	}
+	return nodes[0], nil
+}
+
+// PickReplicas returns the node picked by Pick and the following ones in the ring,
+// which hold the replicas of the shard. The replicas are capped by the number of the nodes.
+func (r *roundRobinSelector) PickReplicas(group, _ string, shardID uint32) ([]string, error) {
+	return r.pick(group, shardID, true)
+}
+
+func (r *roundRobinSelector) pick(group string, shardID uint32, withReplicas bool) ([]string, error) {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	i, err := r.search(group, shardID)
+	if err != nil {
+		r.mu.RUnlock()
+		return nil, err
+	}
+	if _, ok := r.assignments[r.lookupTable[i]]; ok || !r.sticky() {
+		defer r.mu.RUnlock()
+		return r.replicasOf(i, withReplicas), nil
+	}
+	r.mu.RUnlock()
+	return r.assign(group, shardID, withReplicas)
+}
+
+// assign places the shard which is picked at first on the node computed by the round-robin,
+// and persists the placement in the background.
+func (r *roundRobinSelector) assign(group string, shardID uint32, withReplicas bool) ([]string, error) {
+	r.mu.Lock()
+	i, err := r.search(group, shardID)
+	if err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	k := r.lookupTable[i]
+	if _, ok := r.assignments[k]; ok {
+		defer r.mu.Unlock()
+		return r.replicasOf(i, withReplicas), nil
+	}
+	owner := r.nodes[i%len(r.nodes)]
+	if r.assignments == nil {
+		r.assignments = make(map[key]string)
+	}
+	r.assignments[k] = owner
+	shard := r.newShard(k, owner)
+	nodes := r.replicasOf(i, withReplicas)
+	r.mu.Unlock()
+	if r.schemaRegistry != nil && shard != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			// Another liaison might place the shard at the same time. The placement which is persisted at first wins,
+			// and it's delivered to this selector by the schema watcher.
+			if errCreate := r.schemaRegistry.ShardRegistry().CreateShard(ctx, shard); errCreate != nil &&
+				!errors.Is(errCreate, schema.ErrGRPCAlreadyExists) {
+				r.l.Error().Err(errCreate).Str("group", group).Uint32("shard", shardID).Msg("failed to persist the placement of the shard")
+			}
+		}()
+	}
+	return nodes, nil
+}
+
+func (r *roundRobinSelector) newShard(k key, owner string) *databasev1.Shard {
+	g, ok := r.groups[k.group]
+	if !ok {
+		return nil
+	}
+	return &databasev1.Shard{
+		Id: uint64(k.shardID),
+		Metadata: &commonv1.Metadata{
+			Group: k.group,
+			Name:  schema.ShardName(k.shardID),
+		},
+		Catalog: g.Catalog,
+		Node:    owner,
+		Total:   g.ResourceOpts.ShardNum,
+	}
+}
+
+// ownerIndex returns the index of the node holding the primary copy of the i-th shard in the lookup table.
+// A shard whose node is gone falls back to the round-robin placement until it's rebalanced.
+func (r *roundRobinSelector) ownerIndex(i int) int {
+	if owner, ok := r.assignments[r.lookupTable[i]]; ok {
+		if j, found := slices.BinarySearch(r.nodes, owner); found {
+			return j
+		}
+	}
+	return i % len(r.nodes)
+}
+
+func (r *roundRobinSelector) replicasOf(i int, withReplicas bool) []string {
+	n := 1
+	if withReplicas {
+		n = min(int(r.groups[r.lookupTable[i].group].GetResourceOpts().GetReplicas())+1, len(r.nodes))
+	}
+	return r.ring(r.ownerIndex(i), n)
+}
+
+func (r *roundRobinSelector) ring(start, n int) []string {
+	nodes := make([]string, n)
+	for j := range nodes {
+		nodes[j] = r.nodes[(start+j)%len(r.nodes)]
+	}
+	return nodes
+}
+
+func (r *roundRobinSelector) search(group string, shardID uint32) (int, error) {
 	k := key{group: group, shardID: shardID}
 	if len(r.nodes) == 0 {
-		return "", errors.New("no nodes available")
+		return 0, errors.New("no nodes available")
 	}
 	i := sort.Search(len(r.lookupTable), func(i int) bool {
 		if r.lookupTable[i].group == group {
@@ -200,9 +400,9 @@ func (r *roundRobinSelector) Pick(group, _ string, shardID uint32) (string, erro
 		return r.lookupTable[i].group > group
 	})
 	if i < len(r.lookupTable) && r.lookupTable[i] == k {
-		return r.selectNode(i), nil
+		return i, nil
 	}
-	return "", fmt.Errorf("%s-%d is a unknown shard", group, shardID)
+	return 0, fmt.Errorf("%s-%d is a unknown shard", group, shardID)
 }
 
 func (r *roundRobinSelector) sortEntries() {
@@ -215,11 +415,6 @@ func (r *roundRobinSelector) sortEntries() {
 	})
 }
 
-func (r *roundRobinSelector) selectNode(entry any) string {
-	index := entry.(int)
-	return r.nodes[index%len(r.nodes)]
-}
-
 func validateGroup(group *commonv1.Group) bool {
 	if group.Catalog == commonv1.Catalog_CATALOG_UNSPECIFIED {
 		return false
@@ -158,6 +158,47 @@ func TestChangeShard(t *testing.T) {
 	assert.NotEqual(t, node1, node2)
 }
 
+func TestPickReplicas(t *testing.T) {
+	selector := NewRoundRobinSelector("test", nil)
+	replicated := schema.Metadata{
+		TypeMeta: schema.TypeMeta{
+			Kind: schema.KindGroup,
+		},
+		Spec: &commonv1.Group{
+			Metadata: &commonv1.Metadata{
+				Name: "group1",
+			},
+			Catalog: commonv1.Catalog_CATALOG_MEASURE,
+			ResourceOpts: &commonv1.ResourceOpts{
+				ShardNum: 2,
+				Replicas: 1,
+			},
+		},
+	}
+	selector.(*roundRobinSelector).OnAddOrUpdate(replicated)
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+	nodes, err := selector.PickReplicas("group1", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node1"}, nodes, "The replicas should be capped by the number of the nodes")
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node2"}})
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node3"}})
+	for shardID := uint32(0); shardID < 2; shardID++ {
+		nodes, err = selector.PickReplicas("group1", "", shardID)
+		assert.NoError(t, err)
+		assert.Len(t, nodes, 2)
+		assert.NotEqual(t, nodes[0], nodes[1])
+		node, errPick := selector.Pick("group1", "", shardID)
+		assert.NoError(t, errPick)
+		assert.Equal(t, node, nodes[0])
+	}
+	setupGroup(selector)
+	nodes, err = selector.PickReplicas("group1", "", 1)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	_, err = selector.PickReplicas("group1", "", 2)
+	assert.Error(t, err)
+}
+
 var (
 	groupSchema = schema.Metadata{
 		TypeMeta: schema.TypeMeta{
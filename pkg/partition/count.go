@@ -0,0 +1,65 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package partition
+
+import (
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+// ShardCounter counts the data of a shard, which are picked by a CountShardRequest.
+// The copies of the data are counted once if the request splits them into buckets or lists them.
+type ShardCounter struct {
+	req   *databasev1.CountShardRequest
+	seen  map[uint64]struct{}
+	count uint64
+}
+
+// NewShardCounter returns a ShardCounter for the request.
+func NewShardCounter(req *databasev1.CountShardRequest) *ShardCounter {
+	return &ShardCounter{
+		req:  req,
+		seen: make(map[uint64]struct{}),
+	}
+}
+
+// Add counts the identity if it's in the bucket of the request.
+func (c *ShardCounter) Add(identity uint64) {
+	if c.req.Buckets == 0 && !c.req.List {
+		c.count++
+		return
+	}
+	if c.req.Buckets > 0 && uint32(identity%uint64(c.req.Buckets)) != c.req.Bucket {
+		return
+	}
+	c.seen[identity] = struct{}{}
+}
+
+// Response returns the count, and the identities if the request lists them.
+func (c *ShardCounter) Response(node string) *databasev1.CountShardResponse {
+	resp := &databasev1.CountShardResponse{
+		Node:  node,
+		Count: c.count + uint64(len(c.seen)),
+	}
+	if c.req.List {
+		resp.Identities = make([]uint64, 0, len(c.seen))
+		for id := range c.seen {
+			resp.Identities = append(resp.Identities, id)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,208 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v1
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+)
+
+const (
+	histogramKindExplicit    byte = 1
+	histogramKindExponential byte = 2
+
+	// MinHistogramScale is the min scale of the exponential histogram.
+	MinHistogramScale = -10
+	// MaxHistogramScale is the max scale of the exponential histogram.
+	MaxHistogramScale = 20
+
+	maxHistogramBuckets = 1 << 16
+)
+
+var errMalformedHistogram = errors.New("histogram is malformed")
+
+// IsExponentialHistogram reports whether the histogram holds exponential buckets instead of explicit ones.
+func IsExponentialHistogram(h *modelv1.Histogram) bool {
+	return len(h.GetBounds()) == 0 && len(h.GetCounts()) == 0
+}
+
+// ValidateHistogram checks whether the buckets of the histogram are well-formed.
+func ValidateHistogram(h *modelv1.Histogram) error {
+	if IsExponentialHistogram(h) {
+		if h.GetScale() < MinHistogramScale || h.GetScale() > MaxHistogramScale {
+			return errors.WithMessagef(errMalformedHistogram, "scale %d is out of [%d, %d]", h.GetScale(), MinHistogramScale, MaxHistogramScale)
+		}
+		if len(h.GetPositive().GetCounts()) > maxHistogramBuckets || len(h.GetNegative().GetCounts()) > maxHistogramBuckets {
+			return errors.WithMessagef(errMalformedHistogram, "there are more than %d exponential buckets", maxHistogramBuckets)
+		}
+		return nil
+	}
+	if h.GetScale() != 0 || h.GetZeroCount() != 0 || h.GetPositive() != nil || h.GetNegative() != nil {
+		return errors.WithMessage(errMalformedHistogram, "explicit and exponential buckets are mixed")
+	}
+	bounds := h.GetBounds()
+	if len(bounds) > maxHistogramBuckets {
+		return errors.WithMessagef(errMalformedHistogram, "there are more than %d bounds", maxHistogramBuckets)
+	}
+	if len(h.GetCounts()) != len(bounds)+1 {
+		return errors.WithMessagef(errMalformedHistogram, "%d bounds need %d counts, got %d", len(bounds), len(bounds)+1, len(h.GetCounts()))
+	}
+	for i, b := range bounds {
+		if math.IsNaN(b) || math.IsInf(b, 0) {
+			return errors.WithMessagef(errMalformedHistogram, "bound %d is not finite", i)
+		}
+		if i > 0 && b <= bounds[i-1] {
+			return errors.WithMessagef(errMalformedHistogram, "bounds are not increasing at %d", i)
+		}
+	}
+	return nil
+}
+
+// MarshalHistogram appends the compact encoding of a valid histogram to dst.
+//
+// The encoding starts with the layout, which is prefixed by its length: the kind and bounds of the explicit buckets,
+// or the scale, offsets and sizes of the exponential buckets. The data, which are the count, sum and
+// bucket counts, follow it. The layouts of a series seldom change, so encoding.ColumnEncodingHistogram
+// stores them in a dictionary.
+func MarshalHistogram(dst []byte, h *modelv1.Histogram) []byte {
+	var layout []byte
+	if IsExponentialHistogram(h) {
+		layout = append(layout, histogramKindExponential)
+		layout = encoding.VarInt64ToBytes(layout, int64(h.GetScale()))
+		layout = encoding.VarInt64ToBytes(layout, int64(h.GetPositive().GetOffset()))
+		layout = encoding.VarUint64ToBytes(layout, uint64(len(h.GetPositive().GetCounts())))
+		layout = encoding.VarInt64ToBytes(layout, int64(h.GetNegative().GetOffset()))
+		layout = encoding.VarUint64ToBytes(layout, uint64(len(h.GetNegative().GetCounts())))
+	} else {
+		layout = append(layout, histogramKindExplicit)
+		layout = encoding.VarUint64ToBytes(layout, uint64(len(h.GetBounds())))
+		for _, b := range h.GetBounds() {
+			layout = append(layout, convert.Float64ToBytes(b)...)
+		}
+	}
+	dst = encoding.EncodeBytes(dst, layout)
+	dst = encoding.VarUint64ToBytes(dst, h.GetCount())
+	dst = append(dst, convert.Float64ToBytes(h.GetSum())...)
+	if layout[0] == histogramKindExponential {
+		dst = encoding.VarUint64ToBytes(dst, h.GetZeroCount())
+		dst = encoding.VarUint64sToBytes(dst, h.GetPositive().GetCounts())
+		return encoding.VarUint64sToBytes(dst, h.GetNegative().GetCounts())
+	}
+	return encoding.VarUint64sToBytes(dst, h.GetCounts())
+}
+
+// UnmarshalHistogram decodes a histogram encoded by MarshalHistogram.
+func UnmarshalHistogram(src []byte) (*modelv1.Histogram, error) {
+	src, layout, err := encoding.DecodeBytes(src)
+	if err != nil {
+		return nil, errors.WithMessagef(errMalformedHistogram, "cannot decode layout: %v", err)
+	}
+	if len(layout) == 0 {
+		return nil, errors.WithMessage(errMalformedHistogram, "layout is empty")
+	}
+	h := &modelv1.Histogram{}
+	kind := layout[0]
+	layout = layout[1:]
+	var nPositive, nNegative uint64
+	switch kind {
+	case histogramKindExplicit:
+		var n uint64
+		if layout, n, err = readVarUint64(layout); err != nil {
+			return nil, err
+		}
+		if uint64(len(layout)) != 8*n {
+			return nil, errors.WithMessagef(errMalformedHistogram, "cannot read %d bounds from %d bytes", n, len(layout))
+		}
+		h.Bounds = make([]float64, n)
+		for i := range h.Bounds {
+			h.Bounds[i] = convert.BytesToFloat64(layout[8*i:])
+		}
+	case histogramKindExponential:
+		var scale, positiveOffset, negativeOffset int64
+		if layout, scale, err = encoding.BytesToVarInt64(layout); err != nil {
+			return nil, errors.WithMessagef(errMalformedHistogram, "cannot decode scale: %v", err)
+		}
+		if layout, positiveOffset, err = encoding.BytesToVarInt64(layout); err != nil {
+			return nil, errors.WithMessagef(errMalformedHistogram, "cannot decode positive offset: %v", err)
+		}
+		if layout, nPositive, err = readVarUint64(layout); err != nil {
+			return nil, err
+		}
+		if layout, negativeOffset, err = encoding.BytesToVarInt64(layout); err != nil {
+			return nil, errors.WithMessagef(errMalformedHistogram, "cannot decode negative offset: %v", err)
+		}
+		if _, nNegative, err = readVarUint64(layout); err != nil {
+			return nil, err
+		}
+		h.Scale = int32(scale)
+		if nPositive > 0 || positiveOffset != 0 {
+			h.Positive = &modelv1.HistogramBuckets{Offset: int32(positiveOffset)}
+		}
+		if nNegative > 0 || negativeOffset != 0 {
+			h.Negative = &modelv1.HistogramBuckets{Offset: int32(negativeOffset)}
+		}
+	default:
+		return nil, errors.WithMessagef(errMalformedHistogram, "unknown kind %d", kind)
+	}
+
+	if src, h.Count, err = readVarUint64(src); err != nil {
+		return nil, err
+	}
+	if len(src) < 8 {
+		return nil, errors.WithMessage(errMalformedHistogram, "cannot decode sum")
+	}
+	h.Sum = convert.BytesToFloat64(src[:8])
+	src = src[8:]
+	if kind == histogramKindExplicit {
+		h.Counts = make([]uint64, len(h.Bounds)+1)
+		if _, err = encoding.BytesToVarUint64s(h.Counts, src); err != nil {
+			return nil, errors.WithMessagef(errMalformedHistogram, "cannot decode counts: %v", err)
+		}
+		return h, nil
+	}
+	if src, h.ZeroCount, err = readVarUint64(src); err != nil {
+		return nil, err
+	}
+	if nPositive > maxHistogramBuckets || nNegative > maxHistogramBuckets {
+		return nil, errors.WithMessagef(errMalformedHistogram, "there are more than %d exponential buckets", maxHistogramBuckets)
+	}
+	counts := make([]uint64, nPositive+nNegative)
+	if _, err = encoding.BytesToVarUint64s(counts, src); err != nil {
+		return nil, errors.WithMessagef(errMalformedHistogram, "cannot decode counts: %v", err)
+	}
+	if nPositive > 0 {
+		h.Positive.Counts = counts[:nPositive]
+	}
+	if nNegative > 0 {
+		h.Negative.Counts = counts[nPositive:]
+	}
+	return h, nil
+}
+
+func readVarUint64(src []byte) ([]byte, uint64, error) {
+	tail, v := encoding.BytesToVarUint64(src)
+	if len(tail) == len(src) {
+		return src, 0, errors.WithMessage(errMalformedHistogram, "cannot decode varuint")
+	}
+	return tail, v, nil
+}
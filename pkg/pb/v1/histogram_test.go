@@ -0,0 +1,102 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package v1
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+)
+
+func TestMarshalAndUnmarshalHistogram(t *testing.T) {
+	tests := []struct {
+		src  *modelv1.Histogram
+		name string
+	}{
+		{
+			name: "explicit",
+			src:  &modelv1.Histogram{Bounds: []float64{0.5, 1, 10}, Counts: []uint64{1, 0, 300, 2}, Sum: 1024.5, Count: 303},
+		},
+		{
+			name: "no bound",
+			src:  &modelv1.Histogram{Counts: []uint64{7}, Sum: 3, Count: 7},
+		},
+		{
+			name: "exponential",
+			src: &modelv1.Histogram{
+				Scale: 3, ZeroCount: 2, Sum: -1.5, Count: 9,
+				Positive: &modelv1.HistogramBuckets{Offset: -4, Counts: []uint64{1, 2, 3}},
+				Negative: &modelv1.HistogramBuckets{Offset: 7, Counts: []uint64{1}},
+			},
+		},
+		{
+			name: "empty exponential",
+			src:  &modelv1.Histogram{Scale: -2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, ValidateHistogram(tt.src))
+			got, err := UnmarshalHistogram(MarshalHistogram(nil, tt.src))
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.src, got), "want %v, got %v", tt.src, got)
+		})
+	}
+}
+
+func TestMarshalHistogram_layout(t *testing.T) {
+	a := MarshalHistogram(nil, &modelv1.Histogram{Bounds: []float64{1, 2}, Counts: []uint64{1, 2, 3}, Count: 6})
+	b := MarshalHistogram(nil, &modelv1.Histogram{Bounds: []float64{1, 2}, Counts: []uint64{4, 5, 6}, Count: 15})
+	_, la, err := encoding.DecodeBytes(a)
+	require.NoError(t, err)
+	_, lb, err := encoding.DecodeBytes(b)
+	require.NoError(t, err)
+	assert.Equal(t, la, lb, "the histograms with the same bounds share the layout")
+}
+
+func TestUnmarshalHistogram_malformed(t *testing.T) {
+	src := MarshalHistogram(nil, &modelv1.Histogram{Bounds: []float64{1, 2}, Counts: []uint64{1, 2, 3}, Count: 6})
+	for i := 0; i < len(src); i++ {
+		_, err := UnmarshalHistogram(src[:i])
+		assert.Error(t, err, "truncated to %d bytes", i)
+	}
+}
+
+func TestValidateHistogram(t *testing.T) {
+	tests := []struct {
+		h    *modelv1.Histogram
+		name string
+	}{
+		{name: "missing count", h: &modelv1.Histogram{Bounds: []float64{1}, Counts: []uint64{1}}},
+		{name: "decreasing bounds", h: &modelv1.Histogram{Bounds: []float64{2, 1}, Counts: []uint64{1, 1, 1}}},
+		{name: "infinite bound", h: &modelv1.Histogram{Bounds: []float64{math.Inf(1)}, Counts: []uint64{1, 1}}},
+		{name: "mixed buckets", h: &modelv1.Histogram{Counts: []uint64{1}, ZeroCount: 1}},
+		{name: "too large scale", h: &modelv1.Histogram{Scale: MaxHistogramScale + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, ValidateHistogram(tt.h))
+		})
+	}
+}
@@ -36,7 +36,8 @@ func FindTagByName(families []*databasev1.TagFamilySpec, tagName string) (int, i
 	return 0, 0, nil
 }
 
-func tagValueTypeConv(tagValue *modelv1.TagValue) (tagType databasev1.TagType, isNull bool) {
+// TagValueTypeConv recognizes the tag type from its value.
+func TagValueTypeConv(tagValue *modelv1.TagValue) (tagType databasev1.TagType, isNull bool) {
 	switch tagValue.GetValue().(type) {
 	case *modelv1.TagValue_Int:
 		return databasev1.TagType_TAG_TYPE_INT, false
@@ -48,6 +49,12 @@ func tagValueTypeConv(tagValue *modelv1.TagValue) (tagType databasev1.TagType, i
 		return databasev1.TagType_TAG_TYPE_STRING_ARRAY, false
 	case *modelv1.TagValue_BinaryData:
 		return databasev1.TagType_TAG_TYPE_DATA_BINARY, false
+	case *modelv1.TagValue_Float:
+		return databasev1.TagType_TAG_TYPE_FLOAT, false
+	case *modelv1.TagValue_Bool:
+		return databasev1.TagType_TAG_TYPE_BOOL, false
+	case *modelv1.TagValue_Timestamp:
+		return databasev1.TagType_TAG_TYPE_TIMESTAMP, false
 	case *modelv1.TagValue_Null:
 		return databasev1.TagType_TAG_TYPE_UNSPECIFIED, true
 	}
@@ -65,6 +72,8 @@ func FieldValueTypeConv(fieldValue *modelv1.FieldValue) (tagType databasev1.Fiel
 		return databasev1.FieldType_FIELD_TYPE_STRING, false
 	case *modelv1.FieldValue_BinaryData:
 		return databasev1.FieldType_FIELD_TYPE_DATA_BINARY, false
+	case *modelv1.FieldValue_Histogram:
+		return databasev1.FieldType_FIELD_TYPE_HISTOGRAM, false
 	case *modelv1.FieldValue_Null:
 		return databasev1.FieldType_FIELD_TYPE_UNSPECIFIED, true
 	}
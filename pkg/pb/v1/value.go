@@ -19,10 +19,13 @@ package v1
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
@@ -43,6 +46,9 @@ const (
 	ValueTypeBinaryData
 	ValueTypeStrArr
 	ValueTypeInt64Arr
+	ValueTypeBool
+	ValueTypeTimestamp
+	ValueTypeHistogram
 )
 
 // MustTagValueToValueType converts modelv1.TagValue to ValueType.
@@ -60,6 +66,12 @@ func MustTagValueToValueType(tag *modelv1.TagValue) ValueType {
 		return ValueTypeStrArr
 	case *modelv1.TagValue_IntArray:
 		return ValueTypeInt64Arr
+	case *modelv1.TagValue_Float:
+		return ValueTypeFloat64
+	case *modelv1.TagValue_Bool:
+		return ValueTypeBool
+	case *modelv1.TagValue_Timestamp:
+		return ValueTypeTimestamp
 	default:
 		panic("unknown tag value type")
 	}
@@ -78,11 +90,48 @@ func MustTagValueSpecToValueType(tag databasev1.TagType) ValueType {
 		return ValueTypeStrArr
 	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
 		return ValueTypeInt64Arr
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		return ValueTypeFloat64
+	case databasev1.TagType_TAG_TYPE_BOOL:
+		return ValueTypeBool
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		return ValueTypeTimestamp
 	default:
 		panic("unknown tag value type")
 	}
 }
 
+// FieldTypeToValueType converts databasev1.FieldType to ValueType.
+func FieldTypeToValueType(field databasev1.FieldType) ValueType {
+	switch field {
+	case databasev1.FieldType_FIELD_TYPE_STRING:
+		return ValueTypeStr
+	case databasev1.FieldType_FIELD_TYPE_INT:
+		return ValueTypeInt64
+	case databasev1.FieldType_FIELD_TYPE_FLOAT:
+		return ValueTypeFloat64
+	case databasev1.FieldType_FIELD_TYPE_DATA_BINARY:
+		return ValueTypeBinaryData
+	case databasev1.FieldType_FIELD_TYPE_HISTOGRAM:
+		return ValueTypeHistogram
+	default:
+		return ValueTypeUnknown
+	}
+}
+
+// CanWiden reports whether the values of the type from could be read as the type to without loss.
+// A column written before the schema widens its type keeps the narrower type.
+func CanWiden(from, to ValueType) bool {
+	switch from {
+	case ValueTypeInt64:
+		return to == ValueTypeInt64Arr || to == ValueTypeFloat64
+	case ValueTypeStr:
+		return to == ValueTypeStrArr
+	default:
+		return false
+	}
+}
+
 // MustTagValueToStr converts modelv1.TagValue to string.
 func MustTagValueToStr(tag *modelv1.TagValue) string {
 	switch tag.Value.(type) {
@@ -92,6 +141,12 @@ func MustTagValueToStr(tag *modelv1.TagValue) string {
 		return strconv.FormatInt(tag.GetInt().Value, 10)
 	case *modelv1.TagValue_BinaryData:
 		return fmt.Sprintf("%x", tag.GetBinaryData())
+	case *modelv1.TagValue_Float:
+		return strconv.FormatFloat(tag.GetFloat().Value, 'f', -1, 64)
+	case *modelv1.TagValue_Bool:
+		return strconv.FormatBool(tag.GetBool().Value)
+	case *modelv1.TagValue_Timestamp:
+		return tag.GetTimestamp().AsTime().Format(time.RFC3339Nano)
 	default:
 		panic("unknown tag value type")
 	}
@@ -135,6 +190,12 @@ func marshalTagValue(dest []byte, tv *modelv1.TagValue) ([]byte, error) {
 		dest = marshalEntityValue(dest, encoding.Int64ToBytes(nil, tv.GetInt().Value))
 	case *modelv1.TagValue_BinaryData:
 		dest = marshalEntityValue(dest, tv.GetBinaryData())
+	case *modelv1.TagValue_Float:
+		dest = marshalEntityValue(dest, convert.Float64ToSortableBytes(tv.GetFloat().Value))
+	case *modelv1.TagValue_Bool:
+		dest = marshalEntityValue(dest, BoolToBytes(tv.GetBool().Value))
+	case *modelv1.TagValue_Timestamp:
+		dest = marshalEntityValue(dest, encoding.Int64ToBytes(nil, tv.GetTimestamp().AsTime().UnixNano()))
 	default:
 		return nil, errors.New("unsupported tag value type: " + tv.String())
 	}
@@ -198,6 +259,37 @@ func unmarshalTagValue(dest []byte, src []byte) ([]byte, []byte, *modelv1.TagVal
 				BinaryData: data,
 			},
 		}, nil
+	case ValueTypeFloat64:
+		if dest, src, err = unmarshalEntityValue(dest, src[1:]); err != nil {
+			return nil, nil, nil, errors.WithMessage(err, "unmarshal float tag value")
+		}
+		return dest, src, &modelv1.TagValue{
+			Value: &modelv1.TagValue_Float{
+				Float: &modelv1.Float{
+					Value: convert.SortableBytesToFloat64(dest),
+				},
+			},
+		}, nil
+	case ValueTypeBool:
+		if dest, src, err = unmarshalEntityValue(dest, src[1:]); err != nil {
+			return nil, nil, nil, errors.WithMessage(err, "unmarshal bool tag value")
+		}
+		return dest, src, &modelv1.TagValue{
+			Value: &modelv1.TagValue_Bool{
+				Bool: &modelv1.Bool{
+					Value: BytesToBool(dest),
+				},
+			},
+		}, nil
+	case ValueTypeTimestamp:
+		if dest, src, err = unmarshalEntityValue(dest, src[1:]); err != nil {
+			return nil, nil, nil, errors.WithMessage(err, "unmarshal timestamp tag value")
+		}
+		return dest, src, &modelv1.TagValue{
+			Value: &modelv1.TagValue_Timestamp{
+				Timestamp: timestamppb.New(time.Unix(0, encoding.BytesToInt64(dest))),
+			},
+		}, nil
 	default:
 		return nil, src, nil, fmt.Errorf("unsupported tag value type %d, tag value: %s", vt, src)
 	}
@@ -286,8 +378,35 @@ func MustCompareTagValue(tv1, tv2 *modelv1.TagValue) int {
 		return int(tv1.GetInt().Value - tv2.GetInt().Value)
 	case ValueTypeBinaryData:
 		return bytes.Compare(tv1.GetBinaryData(), tv2.GetBinaryData())
+	case ValueTypeFloat64:
+		return cmp.Compare(tv1.GetFloat().Value, tv2.GetFloat().Value)
+	case ValueTypeBool:
+		b1, b2 := tv1.GetBool().Value, tv2.GetBool().Value
+		switch {
+		case b1 == b2:
+			return 0
+		case b2:
+			return -1
+		default:
+			return 1
+		}
+	case ValueTypeTimestamp:
+		return cmp.Compare(tv1.GetTimestamp().AsTime().UnixNano(), tv2.GetTimestamp().AsTime().UnixNano())
 	default:
 		logger.Panicf("unsupported tag value type: %v", vt1)
 		return 0
 	}
 }
+
+// BoolToBytes converts a bool value to a byte.
+func BoolToBytes(b bool) []byte {
+	if b {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// BytesToBool converts the bytes encoded by BoolToBytes to a bool value.
+func BytesToBool(b []byte) bool {
+	return len(b) > 0 && b[0] == 1
+}
@@ -19,8 +19,10 @@ package v1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 )
@@ -42,6 +44,18 @@ func TestMarshalAndUnmarshalTagValue(t *testing.T) {
 			name: "binary data",
 			src:  &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte("binaryData")}},
 		},
+		{
+			name: "float value",
+			src:  &modelv1.TagValue{Value: &modelv1.TagValue_Float{Float: &modelv1.Float{Value: -12.5}}},
+		},
+		{
+			name: "bool value",
+			src:  &modelv1.TagValue{Value: &modelv1.TagValue_Bool{Bool: &modelv1.Bool{Value: true}}},
+		},
+		{
+			name: "timestamp value",
+			src:  &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(time.Unix(0, 1700000000123456789))}},
+		},
 		{
 			name: "unsupported type",
 			src:  &modelv1.TagValue{Value: &modelv1.TagValue_Null{}},
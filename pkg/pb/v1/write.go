@@ -149,6 +149,12 @@ func ParseTagValue(tagValue *modelv1.TagValue) (TagValue, error) {
 		return *fv, nil
 	case *modelv1.TagValue_BinaryData:
 		return newValue(bytes.Clone(x.BinaryData)), nil
+	case *modelv1.TagValue_Float:
+		return newValue(convert.Float64ToSortableBytes(x.Float.GetValue())), nil
+	case *modelv1.TagValue_Bool:
+		return newValue(BoolToBytes(x.Bool.GetValue())), nil
+	case *modelv1.TagValue_Timestamp:
+		return newValue(convert.Int64ToBytes(x.Timestamp.AsTime().UnixNano())), nil
 	}
 	return TagValue{}, errUnsupportedTagForIndexField
 }
@@ -161,7 +167,7 @@ func EncodeFamily(familySpec *databasev1.TagFamilySpec, family *modelv1.TagFamil
 	data := &modelv1.TagFamilyForWrite{}
 	for ti, tag := range family.GetTags() {
 		tagSpec := familySpec.GetTags()[ti]
-		tType, isNull := tagValueTypeConv(tag)
+		tType, isNull := TagValueTypeConv(tag)
 		if !isNull && tType != tagSpec.GetType() {
 			return nil, errors.Wrapf(errMalformedElement, "tag %s type is unexpected", tagSpec.GetName())
 		}
@@ -199,6 +205,15 @@ func DecodeFieldValue(fieldValue []byte, fieldSpec *databasev1.FieldSpec) (*mode
 		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: convert.BytesToFloat64(fieldValue)}}}, nil
 	case databasev1.FieldType_FIELD_TYPE_DATA_BINARY:
 		return &modelv1.FieldValue{Value: &modelv1.FieldValue_BinaryData{BinaryData: fieldValue}}, nil
+	case databasev1.FieldType_FIELD_TYPE_HISTOGRAM:
+		if len(fieldValue) == 0 {
+			break
+		}
+		h, err := UnmarshalHistogram(fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Histogram{Histogram: h}}, nil
 	}
 	return &modelv1.FieldValue{Value: &modelv1.FieldValue_Null{}}, nil
 }
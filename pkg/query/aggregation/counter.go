@@ -0,0 +1,99 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"sort"
+	"time"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// IsCounterFunc reports whether the function calculates the increase of counters.
+func IsCounterFunc(af modelv1.AggregationFunction) bool {
+	return af == modelv1.AggregationFunction_AGGREGATION_FUNCTION_INCREASE ||
+		af == modelv1.AggregationFunction_AGGREGATION_FUNCTION_RATE
+}
+
+type counterPoint[N Number] struct {
+	ts    int64
+	val   N
+	delta bool
+}
+
+// Counter calculates the increase of counters across several series.
+// A delta value is the increase by itself, while the increase of cumulative values
+// is the difference between the adjacent ones. A decreased cumulative value means the counter was reset.
+// A series may mix both types, in which case a cumulative value is compared to the previous one plus the deltas in between.
+type Counter[N Number] struct {
+	series map[uint64][]counterPoint[N]
+}
+
+// NewCounter returns an empty Counter.
+func NewCounter[N Number]() *Counter[N] {
+	return &Counter[N]{series: make(map[uint64][]counterPoint[N])}
+}
+
+// In adds a value of the series at the timestamp.
+func (c *Counter[N]) In(sid uint64, ts int64, delta bool, val N) {
+	c.series[sid] = append(c.series[sid], counterPoint[N]{ts: ts, val: val, delta: delta})
+}
+
+// Increase returns the sum of the increases of all the series.
+func (c *Counter[N]) Increase() N {
+	var total N
+	for _, points := range c.series {
+		sort.Slice(points, func(i, j int) bool {
+			return points[i].ts < points[j].ts
+		})
+		var prev N
+		var hasPrev bool
+		for _, p := range points {
+			if p.delta {
+				// A delta moves the baseline of the following cumulative value.
+				total += p.val
+				if hasPrev {
+					prev += p.val
+				}
+				continue
+			}
+			if hasPrev {
+				if p.val < prev {
+					total += p.val
+				} else {
+					total += p.val - prev
+				}
+			}
+			prev, hasPrev = p.val, true
+		}
+	}
+	return total
+}
+
+// Reset drops all the values.
+func (c *Counter[N]) Reset() {
+	clear(c.series)
+}
+
+// Rate returns the per-second average of the increase during the duration.
+func Rate[N Number](increase N, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(increase) / d.Seconds()
+}
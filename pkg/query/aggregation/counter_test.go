@@ -0,0 +1,94 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package aggregation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter_Increase(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []counterPoint[int64]
+		want   int64
+	}{
+		{
+			name:   "cumulative",
+			points: []counterPoint[int64]{{ts: 1, val: 10}, {ts: 2, val: 15}, {ts: 3, val: 30}},
+			want:   20,
+		},
+		{
+			name:   "out of order cumulative",
+			points: []counterPoint[int64]{{ts: 3, val: 30}, {ts: 1, val: 10}, {ts: 2, val: 15}},
+			want:   20,
+		},
+		{
+			name:   "reset",
+			points: []counterPoint[int64]{{ts: 1, val: 10}, {ts: 2, val: 15}, {ts: 3, val: 4}, {ts: 4, val: 6}},
+			want:   11,
+		},
+		{
+			name:   "delta",
+			points: []counterPoint[int64]{{ts: 1, val: 10, delta: true}, {ts: 2, val: 5, delta: true}},
+			want:   15,
+		},
+		{
+			name:   "mixed",
+			points: []counterPoint[int64]{{ts: 1, val: 10}, {ts: 2, val: 3, delta: true}, {ts: 3, val: 15}},
+			want:   5,
+		},
+		{
+			name:   "mixed reset",
+			points: []counterPoint[int64]{{ts: 1, val: 10}, {ts: 2, val: 3, delta: true}, {ts: 3, val: 12}},
+			want:   15,
+		},
+		{
+			name:   "delta before cumulative",
+			points: []counterPoint[int64]{{ts: 1, val: 3, delta: true}, {ts: 2, val: 10}, {ts: 3, val: 12}},
+			want:   5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCounter[int64]()
+			for _, p := range tt.points {
+				c.In(1, p.ts, p.delta, p.val)
+			}
+			assert.Equal(t, tt.want, c.Increase())
+			c.Reset()
+			assert.Equal(t, int64(0), c.Increase())
+		})
+	}
+}
+
+func TestCounter_IncreaseAcrossSeries(t *testing.T) {
+	c := NewCounter[float64]()
+	c.In(1, 1, false, 1.5)
+	c.In(1, 2, false, 3)
+	c.In(2, 1, false, 100)
+	c.In(2, 2, false, 101)
+	c.In(3, 1, true, 0.5)
+	assert.Equal(t, 3.0, c.Increase())
+}
+
+func TestRate(t *testing.T) {
+	assert.Equal(t, 2.0, Rate[int64](120, time.Minute))
+	assert.Equal(t, 0.0, Rate[int64](120, 0))
+}
@@ -0,0 +1,208 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"slices"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+var errMismatchedHistogram = errors.New("cannot merge histograms")
+
+// Histogram merges histograms into one, and estimates the quantiles of the merged histogram.
+// The explicit histograms should share the same bounds, while the exponential ones
+// are downscaled to the smallest scale of them.
+type Histogram struct {
+	merged *modelv1.Histogram
+}
+
+// NewHistogram returns an empty Histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// In merges the histogram into the merged one.
+func (h *Histogram) In(v *modelv1.Histogram) error {
+	if v == nil {
+		return nil
+	}
+	if h.merged == nil {
+		h.merged = proto.Clone(v).(*modelv1.Histogram)
+		return nil
+	}
+	m := h.merged
+	if pbv1.IsExponentialHistogram(m) != pbv1.IsExponentialHistogram(v) {
+		return errors.WithMessage(errMismatchedHistogram, "explicit and exponential histograms are mixed")
+	}
+	if !pbv1.IsExponentialHistogram(m) {
+		if !slices.Equal(m.GetBounds(), v.GetBounds()) || len(m.GetCounts()) != len(v.GetCounts()) {
+			return errors.WithMessagef(errMismatchedHistogram, "bounds %v differ from %v", v.GetBounds(), m.GetBounds())
+		}
+		m.Sum += v.GetSum()
+		m.Count += v.GetCount()
+		for i, c := range v.GetCounts() {
+			m.Counts[i] += c
+		}
+		return nil
+	}
+	scale := min(m.GetScale(), v.GetScale())
+	m.Positive = mergeBuckets(m.GetPositive(), m.GetScale()-scale, v.GetPositive(), v.GetScale()-scale)
+	m.Negative = mergeBuckets(m.GetNegative(), m.GetScale()-scale, v.GetNegative(), v.GetScale()-scale)
+	m.ZeroCount += v.GetZeroCount()
+	m.Sum += v.GetSum()
+	m.Count += v.GetCount()
+	m.Scale = scale
+	return nil
+}
+
+// Val returns the merged histogram, which is nil if no histogram is merged.
+func (h *Histogram) Val() *modelv1.Histogram {
+	return h.merged
+}
+
+// Reset drops the merged histogram.
+func (h *Histogram) Reset() {
+	h.merged = nil
+}
+
+// Mean returns the average of the observed values. It returns NaN if there is no value.
+func (h *Histogram) Mean() float64 {
+	if h.merged.GetCount() == 0 {
+		return math.NaN()
+	}
+	return h.merged.GetSum() / float64(h.merged.GetCount())
+}
+
+// Quantile estimates the q-quantile of the observed values by interpolating linearly in the bucket where it falls.
+// A quantile in the last explicit bucket, whose upper bound is +Inf, is the largest bound.
+// It returns NaN if there is no value or the quantile can't be estimated.
+func (h *Histogram) Quantile(q float64) float64 {
+	m := h.merged
+	if m == nil || math.IsNaN(q) || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	var buckets []bucket
+	if pbv1.IsExponentialHistogram(m) {
+		buckets = exponentialBuckets(m)
+	} else {
+		buckets = explicitBuckets(m)
+	}
+	var total uint64
+	for _, b := range buckets {
+		total += b.count
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+	rank := q * float64(total)
+	var cumulative float64
+	for _, b := range buckets {
+		if b.count == 0 {
+			continue
+		}
+		c := float64(b.count)
+		if rank <= cumulative+c {
+			if math.IsInf(b.upper, 1) {
+				return b.lower
+			}
+			return b.lower + (b.upper-b.lower)*(rank-cumulative)/c
+		}
+		cumulative += c
+	}
+	return math.NaN()
+}
+
+type bucket struct {
+	lower, upper float64
+	count        uint64
+}
+
+func explicitBuckets(m *modelv1.Histogram) []bucket {
+	bounds := m.GetBounds()
+	buckets := make([]bucket, 0, len(m.GetCounts()))
+	for i, c := range m.GetCounts() {
+		b := bucket{count: c, upper: math.Inf(1), lower: math.NaN()}
+		if i < len(bounds) {
+			b.upper = bounds[i]
+		}
+		switch {
+		case i > 0:
+			b.lower = bounds[i-1]
+		case b.upper > 0:
+			// The observed values are usually non-negative, such as latencies.
+			b.lower = 0
+		default:
+			b.lower = b.upper
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+func exponentialBuckets(m *modelv1.Histogram) []bucket {
+	// The boundary of the index i is base^i, where base is 2^(2^-scale).
+	step := math.Exp2(-float64(m.GetScale()))
+	boundary := func(index int64) float64 {
+		return math.Exp2(float64(index) * step)
+	}
+	var buckets []bucket
+	negative := m.GetNegative()
+	for i := len(negative.GetCounts()) - 1; i >= 0; i-- {
+		index := int64(negative.GetOffset()) + int64(i)
+		buckets = append(buckets, bucket{lower: -boundary(index + 1), upper: -boundary(index), count: negative.GetCounts()[i]})
+	}
+	buckets = append(buckets, bucket{count: m.GetZeroCount()})
+	positive := m.GetPositive()
+	for i, c := range positive.GetCounts() {
+		index := int64(positive.GetOffset()) + int64(i)
+		buckets = append(buckets, bucket{lower: boundary(index), upper: boundary(index + 1), count: c})
+	}
+	return buckets
+}
+
+// mergeBuckets adds up the exponential buckets, whose indexes are shifted right to downscale them.
+func mergeBuckets(a *modelv1.HistogramBuckets, aShift int32, b *modelv1.HistogramBuckets, bShift int32) *modelv1.HistogramBuckets {
+	sources := [2]struct {
+		b     *modelv1.HistogramBuckets
+		shift int32
+	}{{a, aShift}, {b, bShift}}
+	lo, hi := int64(math.MaxInt64), int64(math.MinInt64)
+	for _, x := range sources {
+		if len(x.b.GetCounts()) == 0 {
+			continue
+		}
+		lo = min(lo, int64(x.b.GetOffset())>>x.shift)
+		hi = max(hi, (int64(x.b.GetOffset())+int64(len(x.b.GetCounts()))-1)>>x.shift)
+	}
+	if lo > hi {
+		return nil
+	}
+	counts := make([]uint64, hi-lo+1)
+	for _, x := range sources {
+		for i, c := range x.b.GetCounts() {
+			counts[((int64(x.b.GetOffset())+int64(i))>>x.shift)-lo] += c
+		}
+	}
+	return &modelv1.HistogramBuckets{Offset: int32(lo), Counts: counts}
+}
@@ -0,0 +1,96 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestHistogram_explicit(t *testing.T) {
+	bounds := []float64{1, 2, 4}
+	h := NewHistogram()
+	require.NoError(t, h.In(&modelv1.Histogram{Bounds: bounds, Counts: []uint64{10, 0, 0, 0}, Sum: 5, Count: 10}))
+	require.NoError(t, h.In(&modelv1.Histogram{Bounds: bounds, Counts: []uint64{0, 10, 0, 0}, Sum: 15, Count: 10}))
+	require.NoError(t, h.In(nil))
+
+	assert.Equal(t, []uint64{10, 10, 0, 0}, h.Val().GetCounts())
+	assert.Equal(t, uint64(20), h.Val().GetCount())
+	assert.InDelta(t, 1.0, h.Mean(), 1e-9)
+	assert.InDelta(t, 0.0, h.Quantile(0), 1e-9)
+	assert.InDelta(t, 1.0, h.Quantile(0.5), 1e-9)
+	assert.InDelta(t, 1.5, h.Quantile(0.75), 1e-9)
+	assert.InDelta(t, 2.0, h.Quantile(1), 1e-9)
+
+	assert.Error(t, h.In(&modelv1.Histogram{Bounds: []float64{1, 3, 4}, Counts: []uint64{1, 0, 0, 0}}))
+	assert.Error(t, h.In(&modelv1.Histogram{Scale: 1, ZeroCount: 1}))
+	assert.Equal(t, uint64(20), h.Val().GetCount(), "a mismatched histogram isn't merged")
+
+	h.Reset()
+	assert.Nil(t, h.Val())
+	assert.True(t, math.IsNaN(h.Quantile(0.5)))
+	assert.True(t, math.IsNaN(h.Mean()))
+}
+
+func TestHistogram_explicitOverflow(t *testing.T) {
+	h := NewHistogram()
+	require.NoError(t, h.In(&modelv1.Histogram{Bounds: []float64{1, 2, 4}, Counts: []uint64{0, 0, 0, 5}, Count: 5}))
+	assert.InDelta(t, 4.0, h.Quantile(0.99), 1e-9, "the quantile in the +Inf bucket is the largest bound")
+}
+
+func TestHistogram_exponential(t *testing.T) {
+	h := NewHistogram()
+	// The buckets of the scale 0 are (1, 2], and the ones of the scale 1 are (1, √2] and (√2, 2].
+	require.NoError(t, h.In(&modelv1.Histogram{Scale: 0, Count: 4, Positive: &modelv1.HistogramBuckets{Counts: []uint64{4}}}))
+	require.NoError(t, h.In(&modelv1.Histogram{Scale: 1, Count: 4, Positive: &modelv1.HistogramBuckets{Counts: []uint64{2, 2}}}))
+
+	m := h.Val()
+	assert.Equal(t, int32(0), m.GetScale())
+	assert.Equal(t, int32(0), m.GetPositive().GetOffset())
+	assert.Equal(t, []uint64{8}, m.GetPositive().GetCounts())
+	assert.InDelta(t, 1.5, h.Quantile(0.5), 1e-9)
+
+	require.NoError(t, h.In(&modelv1.Histogram{Scale: 0, Count: 2, Positive: &modelv1.HistogramBuckets{Offset: 2, Counts: []uint64{2}}}))
+	assert.Equal(t, []uint64{8, 0, 2}, h.Val().GetPositive().GetCounts())
+	assert.InDelta(t, 8.0, h.Quantile(1), 1e-9)
+}
+
+func TestHistogram_exponentialNegative(t *testing.T) {
+	h := NewHistogram()
+	require.NoError(t, h.In(&modelv1.Histogram{
+		Count: 6, ZeroCount: 2,
+		Positive: &modelv1.HistogramBuckets{Counts: []uint64{2}},
+		Negative: &modelv1.HistogramBuckets{Counts: []uint64{2}},
+	}))
+	assert.InDelta(t, -2.0, h.Quantile(0), 1e-9)
+	assert.InDelta(t, 0.0, h.Quantile(0.5), 1e-9)
+	assert.InDelta(t, 2.0, h.Quantile(1), 1e-9)
+}
+
+func TestMergeBuckets(t *testing.T) {
+	// The negative indexes are downscaled to the lower ones.
+	got := mergeBuckets(&modelv1.HistogramBuckets{Offset: -3, Counts: []uint64{1, 1, 1, 1}}, 1, nil, 0)
+	assert.Equal(t, int32(-2), got.GetOffset())
+	assert.Equal(t, []uint64{1, 2, 1}, got.GetCounts())
+	assert.Nil(t, mergeBuckets(nil, 0, &modelv1.HistogramBuckets{Offset: 3}, 0))
+}
@@ -0,0 +1,123 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// ErrMemoryBudgetExceeded is returned when a query reserves more memory than its budget.
+var ErrMemoryBudgetExceeded = errors.New("the memory budget of the query is exceeded")
+
+// NewExecutionError returns the error of executing a query.
+// It's marked with the resource exhausted status if the query or any data node runs out of the memory budget.
+func NewExecutionError(err error, tpl string, args ...any) *common.Error {
+	var ce *common.Error
+	if errors.Is(err, ErrMemoryBudgetExceeded) ||
+		(errors.As(err, &ce) && ce.Status() == modelv1.Status_STATUS_RESOURCE_EXHAUSTED) {
+		return common.NewErrorWithStatus(modelv1.Status_STATUS_RESOURCE_EXHAUSTED, fmt.Sprintf(tpl, args...))
+	}
+	return common.NewError(tpl, args...)
+}
+
+// MemoryBudget tracks the memory reserved by a query and caps it at the limit.
+// A zero limit doesn't cap the query, but the peak usage is still tracked.
+// A nil budget accepts any reservation.
+type MemoryBudget struct {
+	limit uint64
+	used  atomic.Uint64
+	peak  atomic.Uint64
+}
+
+// NewMemoryBudget returns a new MemoryBudget capped at the limit.
+func NewMemoryBudget(limit uint64) *MemoryBudget {
+	return &MemoryBudget{limit: limit}
+}
+
+// Acquire reserves `size` bytes. It fails with ErrMemoryBudgetExceeded if the reservation exceeds the limit.
+func (b *MemoryBudget) Acquire(size uint64) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		used := b.used.Load()
+		next := used + size
+		if b.limit > 0 && next > b.limit {
+			return errors.Wrapf(ErrMemoryBudgetExceeded, "reserved: %s, requested: %s, limit: %s",
+				humanize.Bytes(used), humanize.Bytes(size), humanize.Bytes(b.limit))
+		}
+		if !b.used.CompareAndSwap(used, next) {
+			continue
+		}
+		for {
+			peak := b.peak.Load()
+			if next <= peak || b.peak.CompareAndSwap(peak, next) {
+				return nil
+			}
+		}
+	}
+}
+
+// Release returns `size` bytes to the budget.
+func (b *MemoryBudget) Release(size uint64) {
+	if b == nil {
+		return
+	}
+	for {
+		used := b.used.Load()
+		next := uint64(0)
+		if used > size {
+			next = used - size
+		}
+		if b.used.CompareAndSwap(used, next) {
+			return
+		}
+	}
+}
+
+// Peak returns the max bytes reserved at the same time.
+func (b *MemoryBudget) Peak() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.peak.Load()
+}
+
+// MemoryBudgetKey is the key of the query's memory budget in context.Context.
+type MemoryBudgetKey struct{}
+
+var memoryBudgetKeyInstance = MemoryBudgetKey{}
+
+// WithMemoryBudget returns a new context with the memory budget of the query.
+func WithMemoryBudget(ctx context.Context, b *MemoryBudget) context.Context {
+	return context.WithValue(ctx, memoryBudgetKeyInstance, b)
+}
+
+// MemoryBudgetFromContext returns the memory budget from context.Context. It returns nil if there is no budget.
+func MemoryBudgetFromContext(ctx context.Context) *MemoryBudget {
+	b, _ := ctx.Value(memoryBudgetKeyInstance).(*MemoryBudget)
+	return b
+}
@@ -0,0 +1,69 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	b := NewMemoryBudget(100)
+	require.NoError(t, b.Acquire(60))
+	assert.ErrorIs(t, b.Acquire(50), ErrMemoryBudgetExceeded)
+	b.Release(30)
+	require.NoError(t, b.Acquire(50))
+	assert.Equal(t, uint64(80), b.Peak())
+	b.Release(200)
+	require.NoError(t, b.Acquire(100))
+	assert.Equal(t, uint64(100), b.Peak())
+}
+
+func TestMemoryBudgetUnlimited(t *testing.T) {
+	b := NewMemoryBudget(0)
+	require.NoError(t, b.Acquire(1<<40))
+	assert.Equal(t, uint64(1<<40), b.Peak())
+
+	var nilBudget *MemoryBudget
+	require.NoError(t, nilBudget.Acquire(1<<40))
+	nilBudget.Release(1)
+	assert.Zero(t, nilBudget.Peak())
+}
+
+func TestMemoryBudgetFromContext(t *testing.T) {
+	assert.Nil(t, MemoryBudgetFromContext(context.Background()))
+	b := NewMemoryBudget(10)
+	assert.Same(t, b, MemoryBudgetFromContext(WithMemoryBudget(context.Background(), b)))
+}
+
+func TestNewExecutionError(t *testing.T) {
+	err := NewExecutionError(NewMemoryBudget(1).Acquire(2), "execute %s", "sw")
+	assert.Equal(t, modelv1.Status_STATUS_RESOURCE_EXHAUSTED, err.Status())
+	assert.Contains(t, err.Error(), "execute sw")
+
+	remote := common.NewErrorWithStatus(modelv1.Status_STATUS_RESOURCE_EXHAUSTED, "data node")
+	assert.Equal(t, modelv1.Status_STATUS_RESOURCE_EXHAUSTED, NewExecutionError(remote, "execute").Status())
+
+	assert.Equal(t, modelv1.Status_STATUS_INTERNAL_ERROR, NewExecutionError(context.Canceled, "execute").Status())
+}
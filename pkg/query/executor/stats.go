@@ -0,0 +1,61 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// QueryStats collects the statistics of a query's execution.
+// A nil QueryStats ignores all the records.
+type QueryStats struct {
+	scanned atomic.Uint64
+}
+
+// AddScanned records the number of rows read by the query.
+func (s *QueryStats) AddScanned(n uint64) {
+	if s == nil {
+		return
+	}
+	s.scanned.Add(n)
+}
+
+// Scanned returns the number of rows read by the query.
+func (s *QueryStats) Scanned() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.scanned.Load()
+}
+
+// QueryStatsKey is the key of the query's statistics in context.Context.
+type QueryStatsKey struct{}
+
+var queryStatsKeyInstance = QueryStatsKey{}
+
+// WithQueryStats returns a new context with the statistics of the query.
+func WithQueryStats(ctx context.Context, s *QueryStats) context.Context {
+	return context.WithValue(ctx, queryStatsKeyInstance, s)
+}
+
+// QueryStatsFromContext returns the statistics from context.Context. It returns nil if there are no statistics.
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	s, _ := ctx.Value(queryStatsKeyInstance).(*QueryStats)
+	return s
+}
@@ -32,9 +32,11 @@ var (
 	ErrInvalidCriteriaType = errors.New("invalid criteria type")
 	// ErrInvalidLogicalExpression indicates an invalid logical expression.
 	ErrInvalidLogicalExpression = errors.New("invalid logical expression")
-	errTagNotDefined            = errors.New("tag is not defined")
-	errIndexNotDefined          = errors.New("index is not define for the tag")
-	errIndexSortingUnsupported  = errors.New("index does not support sorting")
+	// ErrInvalidArithmeticExpression indicates an invalid arithmetic expression.
+	ErrInvalidArithmeticExpression = errors.New("invalid arithmetic expression")
+	errTagNotDefined               = errors.New("tag is not defined")
+	errIndexNotDefined             = errors.New("index is not define for the tag")
+	errIndexSortingUnsupported     = errors.New("index does not support sorting")
 )
 
 // Tag represents the combination of  tag family and tag name.
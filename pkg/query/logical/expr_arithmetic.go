@@ -0,0 +1,231 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logical
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// ArithmeticExpr computes a numeric value from the fields of a data point.
+type ArithmeticExpr interface {
+	Expr
+	// FieldType returns the type of the computed value, which is either FIELD_TYPE_INT or FIELD_TYPE_FLOAT.
+	FieldType() databasev1.FieldType
+	// Eval computes the value with the fields looked up by their names.
+	// It returns the null value if an operand is null or a divisor is zero.
+	Eval(field func(name string) *modelv1.FieldValue) *modelv1.FieldValue
+}
+
+// ParseArithmeticExpr parses the expression, whose fields should be int or float fields in the schema.
+func ParseArithmeticExpr(expr *modelv1.Expression, s Schema) (ArithmeticExpr, error) {
+	switch e := expr.GetExp().(type) {
+	case *modelv1.Expression_FieldName:
+		refs, err := s.CreateFieldRef(NewField(e.FieldName))
+		if err != nil {
+			return nil, err
+		}
+		switch refs[0].Spec.Spec.GetFieldType() {
+		case databasev1.FieldType_FIELD_TYPE_INT, databasev1.FieldType_FIELD_TYPE_FLOAT:
+			return &fieldExpr{ref: refs[0]}, nil
+		default:
+			return nil, errors.WithMessagef(ErrInvalidArithmeticExpression, "field %s isn't numeric", e.FieldName)
+		}
+	case *modelv1.Expression_Literal:
+		switch e.Literal.GetValue().(type) {
+		case *modelv1.FieldValue_Int, *modelv1.FieldValue_Float:
+			return &numberLiteral{value: e.Literal}, nil
+		default:
+			return nil, errors.WithMessagef(ErrInvalidArithmeticExpression, "literal %v isn't numeric", e.Literal)
+		}
+	case *modelv1.Expression_Binary_:
+		switch e.Binary.GetOp() {
+		case modelv1.Expression_ARITHMETIC_OP_ADD, modelv1.Expression_ARITHMETIC_OP_SUB,
+			modelv1.Expression_ARITHMETIC_OP_MUL, modelv1.Expression_ARITHMETIC_OP_DIV:
+		default:
+			return nil, errors.WithMessagef(ErrInvalidArithmeticExpression, "unsupported operation %s", e.Binary.GetOp())
+		}
+		left, err := ParseArithmeticExpr(e.Binary.GetLeft(), s)
+		if err != nil {
+			return nil, err
+		}
+		right, err := ParseArithmeticExpr(e.Binary.GetRight(), s)
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: e.Binary.GetOp(), left: left, right: right}, nil
+	}
+	return nil, errors.WithMessage(ErrInvalidArithmeticExpression, "empty expression")
+}
+
+type fieldExpr struct {
+	ref *FieldRef
+}
+
+func (f *fieldExpr) FieldType() databasev1.FieldType {
+	return f.ref.Spec.Spec.GetFieldType()
+}
+
+func (f *fieldExpr) Eval(field func(name string) *modelv1.FieldValue) *modelv1.FieldValue {
+	if v := field(f.ref.Field.Name); v != nil {
+		return v
+	}
+	return pbv1.NullFieldValue
+}
+
+func (f *fieldExpr) String() string {
+	return f.ref.String()
+}
+
+func (f *fieldExpr) Elements() []string {
+	return f.ref.Elements()
+}
+
+func (f *fieldExpr) Equal(expr Expr) bool {
+	if other, ok := expr.(*fieldExpr); ok {
+		return f.ref.Equal(other.ref)
+	}
+	return false
+}
+
+type numberLiteral struct {
+	value *modelv1.FieldValue
+}
+
+func (l *numberLiteral) FieldType() databasev1.FieldType {
+	if _, ok := l.value.GetValue().(*modelv1.FieldValue_Int); ok {
+		return databasev1.FieldType_FIELD_TYPE_INT
+	}
+	return databasev1.FieldType_FIELD_TYPE_FLOAT
+}
+
+func (l *numberLiteral) Eval(func(name string) *modelv1.FieldValue) *modelv1.FieldValue {
+	return l.value
+}
+
+func (l *numberLiteral) String() string {
+	if v, ok := l.value.GetValue().(*modelv1.FieldValue_Int); ok {
+		return strconv.FormatInt(v.Int.GetValue(), 10)
+	}
+	return strconv.FormatFloat(l.value.GetFloat().GetValue(), 'g', -1, 64)
+}
+
+func (l *numberLiteral) Elements() []string {
+	return []string{l.String()}
+}
+
+func (l *numberLiteral) Equal(expr Expr) bool {
+	if other, ok := expr.(*numberLiteral); ok {
+		return l.String() == other.String() && l.FieldType() == other.FieldType()
+	}
+	return false
+}
+
+var arithmeticOpSymbols = map[modelv1.Expression_ArithmeticOp]string{
+	modelv1.Expression_ARITHMETIC_OP_ADD: "+",
+	modelv1.Expression_ARITHMETIC_OP_SUB: "-",
+	modelv1.Expression_ARITHMETIC_OP_MUL: "*",
+	modelv1.Expression_ARITHMETIC_OP_DIV: "/",
+}
+
+type binaryExpr struct {
+	left  ArithmeticExpr
+	right ArithmeticExpr
+	op    modelv1.Expression_ArithmeticOp
+}
+
+func (b *binaryExpr) FieldType() databasev1.FieldType {
+	if b.op != modelv1.Expression_ARITHMETIC_OP_DIV &&
+		b.left.FieldType() == databasev1.FieldType_FIELD_TYPE_INT && b.right.FieldType() == databasev1.FieldType_FIELD_TYPE_INT {
+		return databasev1.FieldType_FIELD_TYPE_INT
+	}
+	return databasev1.FieldType_FIELD_TYPE_FLOAT
+}
+
+func (b *binaryExpr) Eval(field func(name string) *modelv1.FieldValue) *modelv1.FieldValue {
+	left, right := b.left.Eval(field), b.right.Eval(field)
+	if b.FieldType() == databasev1.FieldType_FIELD_TYPE_INT {
+		l, lok := left.GetValue().(*modelv1.FieldValue_Int)
+		r, rok := right.GetValue().(*modelv1.FieldValue_Int)
+		if !lok || !rok {
+			return pbv1.NullFieldValue
+		}
+		x, y := l.Int.GetValue(), r.Int.GetValue()
+		var v int64
+		switch b.op {
+		case modelv1.Expression_ARITHMETIC_OP_ADD:
+			v = x + y
+		case modelv1.Expression_ARITHMETIC_OP_SUB:
+			v = x - y
+		default:
+			v = x * y
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v}}}
+	}
+	x, lok := toFloat(left)
+	y, rok := toFloat(right)
+	if !lok || !rok {
+		return pbv1.NullFieldValue
+	}
+	var v float64
+	switch b.op {
+	case modelv1.Expression_ARITHMETIC_OP_ADD:
+		v = x + y
+	case modelv1.Expression_ARITHMETIC_OP_SUB:
+		v = x - y
+	case modelv1.Expression_ARITHMETIC_OP_MUL:
+		v = x * y
+	default:
+		if y == 0 {
+			return pbv1.NullFieldValue
+		}
+		v = x / y
+	}
+	return &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: v}}}
+}
+
+func toFloat(v *modelv1.FieldValue) (float64, bool) {
+	switch x := v.GetValue().(type) {
+	case *modelv1.FieldValue_Int:
+		return float64(x.Int.GetValue()), true
+	case *modelv1.FieldValue_Float:
+		return x.Float.GetValue(), true
+	}
+	return 0, false
+}
+
+func (b *binaryExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", b.left, arithmeticOpSymbols[b.op], b.right)
+}
+
+func (b *binaryExpr) Elements() []string {
+	return append(append(b.left.Elements(), arithmeticOpSymbols[b.op]), b.right.Elements()...)
+}
+
+func (b *binaryExpr) Equal(expr Expr) bool {
+	if other, ok := expr.(*binaryExpr); ok {
+		return b.op == other.op && b.left.Equal(other.left) && b.right.Equal(other.right)
+	}
+	return false
+}
@@ -18,10 +18,13 @@
 package logical
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/exp/slices"
 
@@ -29,6 +32,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
 var (
@@ -387,6 +391,199 @@ func (s *strArrLiteral) Elements() []string {
 	return s.arr
 }
 
+var (
+	_ LiteralExpr    = (*floatLiteral)(nil)
+	_ ComparableExpr = (*floatLiteral)(nil)
+)
+
+type floatLiteral struct {
+	float64
+}
+
+func newFloatLiteral(val float64) *floatLiteral {
+	return &floatLiteral{
+		float64: val,
+	}
+}
+
+func (f *floatLiteral) Field(key index.FieldKey) index.Field {
+	return index.NewFloatField(key, f.float64)
+}
+
+func (f *floatLiteral) RangeOpts(isUpper bool, includeLower bool, includeUpper bool) index.RangeOpts {
+	if isUpper {
+		return index.NewFloatRangeOpts(math.Inf(-1), f.float64, includeLower, includeUpper)
+	}
+	return index.NewFloatRangeOpts(f.float64, math.Inf(1), includeLower, includeUpper)
+}
+
+func (f *floatLiteral) SubExprs() []LiteralExpr {
+	return []LiteralExpr{f}
+}
+
+func (f *floatLiteral) Compare(other LiteralExpr) (int, bool) {
+	if o, ok := other.(*floatLiteral); ok {
+		return cmp.Compare(f.float64, o.float64), true
+	}
+	return 0, false
+}
+
+func (f *floatLiteral) Contains(other LiteralExpr) bool {
+	return f.Equal(other)
+}
+
+func (f *floatLiteral) BelongTo(other LiteralExpr) bool {
+	return f.Equal(other)
+}
+
+func (f *floatLiteral) Bytes() [][]byte {
+	return [][]byte{convert.Float64ToSortableBytes(f.float64)}
+}
+
+func (f *floatLiteral) Equal(expr Expr) bool {
+	if other, ok := expr.(*floatLiteral); ok {
+		return other.float64 == f.float64
+	}
+	return false
+}
+
+func (f *floatLiteral) String() string {
+	return strconv.FormatFloat(f.float64, 'f', -1, 64)
+}
+
+func (f *floatLiteral) Elements() []string {
+	return []string{f.String()}
+}
+
+var (
+	_ LiteralExpr    = (*boolLiteral)(nil)
+	_ ComparableExpr = (*boolLiteral)(nil)
+)
+
+type boolLiteral struct {
+	bool
+}
+
+func newBoolLiteral(val bool) *boolLiteral {
+	return &boolLiteral{
+		bool: val,
+	}
+}
+
+func (b *boolLiteral) Field(key index.FieldKey) index.Field {
+	return index.NewBytesField(key, pbv1.BoolToBytes(b.bool))
+}
+
+func (b *boolLiteral) RangeOpts(isUpper bool, includeLower bool, includeUpper bool) index.RangeOpts {
+	if isUpper {
+		return index.NewBytesRangeOpts(pbv1.BoolToBytes(false), pbv1.BoolToBytes(b.bool), includeLower, includeUpper)
+	}
+	return index.NewBytesRangeOpts(pbv1.BoolToBytes(b.bool), pbv1.BoolToBytes(true), includeLower, includeUpper)
+}
+
+func (b *boolLiteral) SubExprs() []LiteralExpr {
+	return []LiteralExpr{b}
+}
+
+func (b *boolLiteral) Compare(other LiteralExpr) (int, bool) {
+	if o, ok := other.(*boolLiteral); ok {
+		return bytes.Compare(pbv1.BoolToBytes(b.bool), pbv1.BoolToBytes(o.bool)), true
+	}
+	return 0, false
+}
+
+func (b *boolLiteral) Contains(other LiteralExpr) bool {
+	return b.Equal(other)
+}
+
+func (b *boolLiteral) BelongTo(other LiteralExpr) bool {
+	return b.Equal(other)
+}
+
+func (b *boolLiteral) Bytes() [][]byte {
+	return [][]byte{pbv1.BoolToBytes(b.bool)}
+}
+
+func (b *boolLiteral) Equal(expr Expr) bool {
+	if other, ok := expr.(*boolLiteral); ok {
+		return other.bool == b.bool
+	}
+	return false
+}
+
+func (b *boolLiteral) String() string {
+	return strconv.FormatBool(b.bool)
+}
+
+func (b *boolLiteral) Elements() []string {
+	return []string{b.String()}
+}
+
+var (
+	_ LiteralExpr    = (*timestampLiteral)(nil)
+	_ ComparableExpr = (*timestampLiteral)(nil)
+)
+
+// timestampLiteral holds a timestamp in nanoseconds.
+type timestampLiteral struct {
+	int64
+}
+
+func newTimestampLiteral(val time.Time) *timestampLiteral {
+	return &timestampLiteral{
+		int64: val.UnixNano(),
+	}
+}
+
+func (t *timestampLiteral) Field(key index.FieldKey) index.Field {
+	return index.NewIntField(key, t.int64)
+}
+
+func (t *timestampLiteral) RangeOpts(isUpper bool, includeLower bool, includeUpper bool) index.RangeOpts {
+	if isUpper {
+		return index.NewIntRangeOpts(math.MinInt64, t.int64, includeLower, includeUpper)
+	}
+	return index.NewIntRangeOpts(t.int64, math.MaxInt64, includeLower, includeUpper)
+}
+
+func (t *timestampLiteral) SubExprs() []LiteralExpr {
+	return []LiteralExpr{t}
+}
+
+func (t *timestampLiteral) Compare(other LiteralExpr) (int, bool) {
+	if o, ok := other.(*timestampLiteral); ok {
+		return cmp.Compare(t.int64, o.int64), true
+	}
+	return 0, false
+}
+
+func (t *timestampLiteral) Contains(other LiteralExpr) bool {
+	return t.Equal(other)
+}
+
+func (t *timestampLiteral) BelongTo(other LiteralExpr) bool {
+	return t.Equal(other)
+}
+
+func (t *timestampLiteral) Bytes() [][]byte {
+	return [][]byte{convert.Int64ToBytes(t.int64)}
+}
+
+func (t *timestampLiteral) Equal(expr Expr) bool {
+	if other, ok := expr.(*timestampLiteral); ok {
+		return other.int64 == t.int64
+	}
+	return false
+}
+
+func (t *timestampLiteral) String() string {
+	return time.Unix(0, t.int64).UTC().Format(time.RFC3339Nano)
+}
+
+func (t *timestampLiteral) Elements() []string {
+	return []string{t.String()}
+}
+
 var (
 	_               LiteralExpr    = (*nullLiteral)(nil)
 	_               ComparableExpr = (*nullLiteral)(nil)
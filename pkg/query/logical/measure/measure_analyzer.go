@@ -84,15 +84,23 @@ func Analyze(_ context.Context, criteria *measurev1.QueryRequest, metadata *comm
 		pushedLimit = math.MaxInt
 	}
 
-	if criteria.GetAgg() != nil {
+	if aggregations := requestAggregations(criteria); len(aggregations) > 0 {
 		plan = newUnresolvedAggregation(plan,
-			logical.NewField(criteria.GetAgg().GetFieldName()),
-			criteria.GetAgg().GetFunction(),
+			aggregations,
 			criteria.GetGroupBy() != nil,
+			criteria.GetTimeRange(),
 		)
 		pushedLimit = math.MaxInt
 	}
 
+	if len(criteria.GetComputedFields()) > 0 {
+		plan = newUnresolvedComputed(plan, criteria.GetComputedFields())
+	}
+
+	if criteria.GetHaving() != nil {
+		plan = newUnresolvedHaving(plan, criteria.GetHaving())
+	}
+
 	if criteria.GetTop() != nil {
 		plan = top(plan, criteria.GetTop())
 	}
@@ -138,15 +146,23 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, s logical.Schema) (log
 		pushedLimit = math.MaxInt
 	}
 
-	if criteria.GetAgg() != nil {
+	if aggregations := requestAggregations(criteria); len(aggregations) > 0 {
 		plan = newUnresolvedAggregation(plan,
-			logical.NewField(criteria.GetAgg().GetFieldName()),
-			criteria.GetAgg().GetFunction(),
+			aggregations,
 			criteria.GetGroupBy() != nil,
+			criteria.GetTimeRange(),
 		)
 		pushedLimit = math.MaxInt
 	}
 
+	if len(criteria.GetComputedFields()) > 0 {
+		plan = newUnresolvedComputed(plan, criteria.GetComputedFields())
+	}
+
+	if criteria.GetHaving() != nil {
+		plan = newUnresolvedHaving(plan, criteria.GetHaving())
+	}
+
 	if criteria.GetTop() != nil {
 		plan = top(plan, criteria.GetTop())
 	}
@@ -177,5 +193,5 @@ func parseFields(criteria *measurev1.QueryRequest, metadata *commonv1.Metadata,
 	}
 	timeRange := criteria.GetTimeRange()
 	return indexScan(timeRange.GetBegin().AsTime(), timeRange.GetEnd().AsTime(), metadata,
-		logical.ToTags(criteria.GetTagProjection()), projFields, groupByEntity, criteria.GetCriteria())
+		logical.ToTags(criteria.GetTagProjection()), projFields, groupByEntity, criteria.GetCriteria(), criteria.GetFieldCriteria())
 }
@@ -20,6 +20,9 @@ package measure
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
@@ -27,6 +30,7 @@ import (
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
@@ -36,146 +40,209 @@ var (
 	_ logical.UnresolvedPlan = (*unresolvedAggregation)(nil)
 
 	errUnsupportedAggregationField = errors.New("unsupported aggregation operation on this field")
+	errDuplicatedAggregation       = errors.New("duplicated aggregation alias")
 )
 
 type unresolvedAggregation struct {
-	unresolvedInput  logical.UnresolvedPlan
-	aggregationField *logical.Field
-	aggrFunc         modelv1.AggregationFunction
-	isGroup          bool
+	unresolvedInput logical.UnresolvedPlan
+	timeRange       *modelv1.TimeRange
+	aggregations    []*measurev1.QueryRequest_Aggregation
+	isGroup         bool
 }
 
-func newUnresolvedAggregation(input logical.UnresolvedPlan, aggrField *logical.Field, aggrFunc modelv1.AggregationFunction, isGroup bool) logical.UnresolvedPlan {
+func newUnresolvedAggregation(input logical.UnresolvedPlan, aggregations []*measurev1.QueryRequest_Aggregation,
+	isGroup bool, timeRange *modelv1.TimeRange,
+) logical.UnresolvedPlan {
 	return &unresolvedAggregation{
-		unresolvedInput:  input,
-		aggrFunc:         aggrFunc,
-		aggregationField: aggrField,
-		isGroup:          isGroup,
+		unresolvedInput: input,
+		aggregations:    aggregations,
+		isGroup:         isGroup,
+		timeRange:       timeRange,
 	}
 }
 
+// requestAggregations returns agg followed by aggregations of the request.
+func requestAggregations(criteria *measurev1.QueryRequest) []*measurev1.QueryRequest_Aggregation {
+	if criteria.GetAgg() == nil {
+		return criteria.GetAggregations()
+	}
+	return append([]*measurev1.QueryRequest_Aggregation{criteria.GetAgg()}, criteria.GetAggregations()...)
+}
+
 func (gba *unresolvedAggregation) Analyze(measureSchema logical.Schema) (logical.Plan, error) {
 	prevPlan, err := gba.unresolvedInput.Analyze(measureSchema)
 	if err != nil {
 		return nil, err
 	}
 	// check validity of aggregation fields
-	schema := prevPlan.Schema()
-	aggregationFieldRefs, err := schema.CreateFieldRef(gba.aggregationField)
-	if err != nil {
-		return nil, err
-	}
-	if len(aggregationFieldRefs) == 0 {
-		return nil, errors.Wrap(errFieldNotDefined, "aggregation schema")
-	}
-	fieldRef := aggregationFieldRefs[0]
-	switch fieldRef.Spec.Spec.FieldType {
-	case databasev1.FieldType_FIELD_TYPE_INT:
-		return newAggregationPlan[int64](gba, prevPlan, schema, fieldRef)
-	case databasev1.FieldType_FIELD_TYPE_FLOAT:
-		return newAggregationPlan[float64](gba, prevPlan, schema, fieldRef)
-	default:
-		return nil, errors.WithMessagef(errUnsupportedAggregationField, "field: %s", fieldRef.Spec.Spec)
+	s := prevPlan.Schema()
+	items := make([]aggregationItem, 0, len(gba.aggregations))
+	specs := make([]*databasev1.FieldSpec, 0, len(gba.aggregations))
+	for _, agg := range gba.aggregations {
+		aggregationFieldRefs, err := s.CreateFieldRef(logical.NewField(agg.GetFieldName()))
+		if err != nil {
+			return nil, err
+		}
+		if len(aggregationFieldRefs) == 0 {
+			return nil, errors.Wrap(errFieldNotDefined, "aggregation schema")
+		}
+		fieldRef := aggregationFieldRefs[0]
+		fieldType := fieldRef.Spec.Spec.FieldType
+		var aggr aggregator
+		switch fieldType {
+		case databasev1.FieldType_FIELD_TYPE_INT:
+			aggr, err = newAggregator[int64](agg.GetFunction(), gba.timeRange)
+		case databasev1.FieldType_FIELD_TYPE_FLOAT:
+			aggr, err = newAggregator[float64](agg.GetFunction(), gba.timeRange)
+		case databasev1.FieldType_FIELD_TYPE_HISTOGRAM:
+			aggr, err = newHistogramAggregator(agg.GetFunction(), agg.GetQuantile())
+			if agg.GetFunction() != modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM {
+				// MEAN and QUANTILE estimate a value from the histograms.
+				fieldType = databasev1.FieldType_FIELD_TYPE_FLOAT
+			}
+		default:
+			return nil, errors.WithMessagef(errUnsupportedAggregationField, "field: %s", fieldRef.Spec.Spec)
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := agg.GetAlias()
+		if name == "" {
+			name = agg.GetFieldName()
+		}
+		for _, spec := range specs {
+			if spec.GetName() == name {
+				return nil, errors.WithMessagef(errDuplicatedAggregation, "alias: %s", name)
+			}
+		}
+		specs = append(specs, &databasev1.FieldSpec{Name: name, FieldType: fieldType})
+		items = append(items, aggregationItem{
+			fieldRef: fieldRef,
+			aggr:     aggr,
+			name:     name,
+			aggrType: agg.GetFunction(),
+		})
 	}
+	return newAggregationPlan(gba, prevPlan, s.(*schema).aggregatedFields(specs), items), nil
 }
 
-type aggregationPlan[N aggregation.Number] struct {
+// aggregationItem aggregates a field into the result field named by the alias.
+type aggregationItem struct {
+	fieldRef *logical.FieldRef
+	aggr     aggregator
+	name     string
+	aggrType modelv1.AggregationFunction
+}
+
+type aggregationPlan struct {
 	*logical.Parent
-	schema              logical.Schema
-	aggregationFieldRef *logical.FieldRef
-	aggrFunc            aggregation.Func[N]
-	aggrType            modelv1.AggregationFunction
-	isGroup             bool
+	schema  logical.Schema
+	items   []aggregationItem
+	isGroup bool
 }
 
-func newAggregationPlan[N aggregation.Number](gba *unresolvedAggregation, prevPlan logical.Plan,
-	measureSchema logical.Schema, fieldRef *logical.FieldRef,
-) (*aggregationPlan[N], error) {
-	aggrFunc, err := aggregation.NewFunc[N](gba.aggrFunc)
-	if err != nil {
-		return nil, err
-	}
-	return &aggregationPlan[N]{
+func newAggregationPlan(gba *unresolvedAggregation, prevPlan logical.Plan,
+	measureSchema logical.Schema, items []aggregationItem,
+) *aggregationPlan {
+	return &aggregationPlan{
 		Parent: &logical.Parent{
 			UnresolvedInput: gba.unresolvedInput,
 			Input:           prevPlan,
 		},
-		schema:              measureSchema,
-		aggrFunc:            aggrFunc,
-		aggregationFieldRef: fieldRef,
-		isGroup:             gba.isGroup,
-	}, nil
+		schema:  measureSchema,
+		items:   items,
+		isGroup: gba.isGroup,
+	}
 }
 
-func (g *aggregationPlan[N]) String() string {
-	return fmt.Sprintf("%s aggregation: aggregation{type=%d,field=%s}",
-		g.Input,
-		g.aggrType,
-		g.aggregationFieldRef.Field.Name)
+func (g *aggregationPlan) String() string {
+	aggs := make([]string, 0, len(g.items))
+	for _, item := range g.items {
+		aggs = append(aggs, fmt.Sprintf("aggregation{type=%d,field=%s,alias=%s}", item.aggrType, item.fieldRef.Field.Name, item.name))
+	}
+	return fmt.Sprintf("%s aggregation: %s", g.Input, strings.Join(aggs, ","))
 }
 
-func (g *aggregationPlan[N]) Children() []logical.Plan {
+func (g *aggregationPlan) Children() []logical.Plan {
 	return []logical.Plan{g.Input}
 }
 
-func (g *aggregationPlan[N]) Schema() logical.Schema {
-	return g.schema.ProjFields(g.aggregationFieldRef)
+func (g *aggregationPlan) Schema() logical.Schema {
+	return g.schema
 }
 
-func (g *aggregationPlan[N]) Execute(ec context.Context) (executor.MIterator, error) {
+func (g *aggregationPlan) Execute(ec context.Context) (executor.MIterator, error) {
 	iter, err := g.Parent.Input.(executor.MeasureExecutable).Execute(ec)
 	if err != nil {
 		return nil, err
 	}
 	if g.isGroup {
-		return newAggGroupMIterator(iter, g.aggregationFieldRef, g.aggrFunc), nil
+		return newAggGroupMIterator(iter, g.items), nil
+	}
+	return newAggAllIterator(iter, g.items), nil
+}
+
+func aggregateIn(items []aggregationItem, dp *measurev1.DataPoint) error {
+	for _, item := range items {
+		if err := item.aggr.in(dp, dp.GetFields()[item.fieldRef.Spec.FieldIdx].GetValue()); err != nil {
+			return err
+		}
 	}
-	return newAggAllIterator(iter, g.aggregationFieldRef, g.aggrFunc), nil
+	return nil
 }
 
-type aggGroupIterator[N aggregation.Number] struct {
-	prev                executor.MIterator
-	aggregationFieldRef *logical.FieldRef
-	aggrFunc            aggregation.Func[N]
+func aggregatedFields(items []aggregationItem) ([]*measurev1.DataPoint_Field, error) {
+	fields := make([]*measurev1.DataPoint_Field, 0, len(items))
+	for _, item := range items {
+		val, err := item.aggr.val()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, &measurev1.DataPoint_Field{
+			Name:  item.name,
+			Value: val,
+		})
+	}
+	return fields, nil
+}
 
-	err error
+type aggGroupIterator struct {
+	prev  executor.MIterator
+	err   error
+	items []aggregationItem
 }
 
-func newAggGroupMIterator[N aggregation.Number](
+func newAggGroupMIterator(
 	prev executor.MIterator,
-	aggregationFieldRef *logical.FieldRef,
-	aggrFunc aggregation.Func[N],
+	items []aggregationItem,
 ) executor.MIterator {
-	return &aggGroupIterator[N]{
-		prev:                prev,
-		aggregationFieldRef: aggregationFieldRef,
-		aggrFunc:            aggrFunc,
+	return &aggGroupIterator{
+		prev:  prev,
+		items: items,
 	}
 }
 
-func (ami *aggGroupIterator[N]) Next() bool {
+func (ami *aggGroupIterator) Next() bool {
 	if ami.err != nil {
 		return false
 	}
 	return ami.prev.Next()
 }
 
-func (ami *aggGroupIterator[N]) Current() []*measurev1.DataPoint {
+func (ami *aggGroupIterator) Current() []*measurev1.DataPoint {
 	if ami.err != nil {
 		return nil
 	}
-	ami.aggrFunc.Reset()
+	for _, item := range ami.items {
+		item.aggr.reset()
+	}
 	group := ami.prev.Current()
 	var resultDp *measurev1.DataPoint
 	for _, dp := range group {
-		value := dp.GetFields()[ami.aggregationFieldRef.Spec.FieldIdx].
-			GetValue()
-		v, err := aggregation.FromFieldValue[N](value)
-		if err != nil {
+		if err := aggregateIn(ami.items, dp); err != nil {
 			ami.err = err
 			return nil
 		}
-		ami.aggrFunc.In(v)
 		if resultDp != nil {
 			continue
 		}
@@ -186,46 +253,37 @@ func (ami *aggGroupIterator[N]) Current() []*measurev1.DataPoint {
 	if resultDp == nil {
 		return nil
 	}
-	val, err := aggregation.ToFieldValue(ami.aggrFunc.Val())
+	fields, err := aggregatedFields(ami.items)
 	if err != nil {
 		ami.err = err
 		return nil
 	}
-	resultDp.Fields = []*measurev1.DataPoint_Field{
-		{
-			Name:  ami.aggregationFieldRef.Field.Name,
-			Value: val,
-		},
-	}
+	resultDp.Fields = fields
 	return []*measurev1.DataPoint{resultDp}
 }
 
-func (ami *aggGroupIterator[N]) Close() error {
+func (ami *aggGroupIterator) Close() error {
 	return multierr.Combine(ami.err, ami.prev.Close())
 }
 
-type aggAllIterator[N aggregation.Number] struct {
-	prev                executor.MIterator
-	aggregationFieldRef *logical.FieldRef
-	aggrFunc            aggregation.Func[N]
-
+type aggAllIterator struct {
+	prev   executor.MIterator
 	result *measurev1.DataPoint
 	err    error
+	items  []aggregationItem
 }
 
-func newAggAllIterator[N aggregation.Number](
+func newAggAllIterator(
 	prev executor.MIterator,
-	aggregationFieldRef *logical.FieldRef,
-	aggrFunc aggregation.Func[N],
+	items []aggregationItem,
 ) executor.MIterator {
-	return &aggAllIterator[N]{
-		prev:                prev,
-		aggregationFieldRef: aggregationFieldRef,
-		aggrFunc:            aggrFunc,
+	return &aggAllIterator{
+		prev:  prev,
+		items: items,
 	}
 }
 
-func (ami *aggAllIterator[N]) Next() bool {
+func (ami *aggAllIterator) Next() bool {
 	if ami.result != nil || ami.err != nil {
 		return false
 	}
@@ -233,14 +291,10 @@ func (ami *aggAllIterator[N]) Next() bool {
 	for ami.prev.Next() {
 		group := ami.prev.Current()
 		for _, dp := range group {
-			value := dp.GetFields()[ami.aggregationFieldRef.Spec.FieldIdx].
-				GetValue()
-			v, err := aggregation.FromFieldValue[N](value)
-			if err != nil {
+			if err := aggregateIn(ami.items, dp); err != nil {
 				ami.err = err
 				return false
 			}
-			ami.aggrFunc.In(v)
 			if resultDp != nil {
 				continue
 			}
@@ -252,28 +306,151 @@ func (ami *aggAllIterator[N]) Next() bool {
 	if resultDp == nil {
 		return false
 	}
-	val, err := aggregation.ToFieldValue(ami.aggrFunc.Val())
+	fields, err := aggregatedFields(ami.items)
 	if err != nil {
 		ami.err = err
 		return false
 	}
-	resultDp.Fields = []*measurev1.DataPoint_Field{
-		{
-			Name:  ami.aggregationFieldRef.Field.Name,
-			Value: val,
-		},
-	}
+	resultDp.Fields = fields
 	ami.result = resultDp
 	return true
 }
 
-func (ami *aggAllIterator[N]) Current() []*measurev1.DataPoint {
+func (ami *aggAllIterator) Current() []*measurev1.DataPoint {
 	if ami.result == nil {
 		return nil
 	}
 	return []*measurev1.DataPoint{ami.result}
 }
 
-func (ami *aggAllIterator[N]) Close() error {
-	return ami.prev.Close()
+func (ami *aggAllIterator) Close() error {
+	return multierr.Combine(ami.err, ami.prev.Close())
+}
+
+// aggregator folds the values of the aggregation field into a single field value.
+type aggregator interface {
+	in(dp *measurev1.DataPoint, v *modelv1.FieldValue) error
+	val() (*modelv1.FieldValue, error)
+	reset()
+}
+
+func newAggregator[N aggregation.Number](af modelv1.AggregationFunction, timeRange *modelv1.TimeRange) (aggregator, error) {
+	if !aggregation.IsCounterFunc(af) {
+		f, err := aggregation.NewFunc[N](af)
+		if err != nil {
+			return nil, err
+		}
+		return &funcAggregator[N]{f: f}, nil
+	}
+	ca := &counterAggregator[N]{
+		counter: aggregation.NewCounter[N](),
+		rate:    af == modelv1.AggregationFunction_AGGREGATION_FUNCTION_RATE,
+	}
+	if timeRange != nil {
+		ca.duration = timeRange.GetEnd().AsTime().Sub(timeRange.GetBegin().AsTime())
+	}
+	return ca, nil
+}
+
+type funcAggregator[N aggregation.Number] struct {
+	f aggregation.Func[N]
+}
+
+func (fa *funcAggregator[N]) in(_ *measurev1.DataPoint, v *modelv1.FieldValue) error {
+	n, err := aggregation.FromFieldValue[N](v)
+	if err != nil {
+		return err
+	}
+	fa.f.In(n)
+	return nil
+}
+
+func (fa *funcAggregator[N]) val() (*modelv1.FieldValue, error) {
+	return aggregation.ToFieldValue(fa.f.Val())
+}
+
+func (fa *funcAggregator[N]) reset() {
+	fa.f.Reset()
+}
+
+// counterAggregator calculates the increase or the per-second rate of counters,
+// honoring the type of each data point.
+type counterAggregator[N aggregation.Number] struct {
+	counter  *aggregation.Counter[N]
+	duration time.Duration
+	rate     bool
+}
+
+func (ca *counterAggregator[N]) in(dp *measurev1.DataPoint, v *modelv1.FieldValue) error {
+	n, err := aggregation.FromFieldValue[N](v)
+	if err != nil {
+		return err
+	}
+	ca.counter.In(dp.GetSid(), dp.GetTimestamp().AsTime().UnixNano(),
+		dp.GetType() == measurev1.DataPointValue_TYPE_DELTA, n)
+	return nil
+}
+
+func (ca *counterAggregator[N]) val() (*modelv1.FieldValue, error) {
+	increase := ca.counter.Increase()
+	if ca.rate {
+		return aggregation.ToFieldValue(aggregation.Rate(increase, ca.duration))
+	}
+	return aggregation.ToFieldValue(increase)
+}
+
+func (ca *counterAggregator[N]) reset() {
+	ca.counter.Reset()
+}
+
+// histogramAggregator merges the histograms. SUM returns the merged histogram,
+// while MEAN and QUANTILE return the estimated value, which is null if there is no observed value.
+type histogramAggregator struct {
+	h        *aggregation.Histogram
+	af       modelv1.AggregationFunction
+	quantile float64
+}
+
+func newHistogramAggregator(af modelv1.AggregationFunction, quantile float64) (aggregator, error) {
+	switch af {
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_QUANTILE:
+	default:
+		return nil, errors.WithMessagef(errUnsupportedAggregationField, "%s on histograms", af)
+	}
+	return &histogramAggregator{h: aggregation.NewHistogram(), af: af, quantile: quantile}, nil
+}
+
+func (ha *histogramAggregator) in(_ *measurev1.DataPoint, v *modelv1.FieldValue) error {
+	switch x := v.GetValue().(type) {
+	case *modelv1.FieldValue_Histogram:
+		return ha.h.In(x.Histogram)
+	case *modelv1.FieldValue_Null:
+		return nil
+	}
+	return errors.WithMessagef(errUnsupportedAggregationField, "%T isn't a histogram", v.GetValue())
+}
+
+func (ha *histogramAggregator) val() (*modelv1.FieldValue, error) {
+	var f float64
+	switch ha.af {
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM:
+		if ha.h.Val() == nil {
+			return pbv1.NullFieldValue, nil
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Histogram{Histogram: ha.h.Val()}}, nil
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN:
+		f = ha.h.Mean()
+	default:
+		f = ha.h.Quantile(ha.quantile)
+	}
+	if math.IsNaN(f) {
+		return pbv1.NullFieldValue, nil
+	}
+	return aggregation.ToFieldValue(f)
+}
+
+func (ha *histogramAggregator) reset() {
+	ha.h.Reset()
 }
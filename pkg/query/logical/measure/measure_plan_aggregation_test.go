@@ -0,0 +1,140 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+type groupsPlan struct {
+	s      logical.Schema
+	groups [][]*measurev1.DataPoint
+}
+
+func (p *groupsPlan) Analyze(logical.Schema) (logical.Plan, error) { return p, nil }
+
+func (p *groupsPlan) String() string { return "groups" }
+
+func (p *groupsPlan) Children() []logical.Plan { return nil }
+
+func (p *groupsPlan) Schema() logical.Schema { return p.s }
+
+func (p *groupsPlan) Execute(context.Context) (executor.MIterator, error) {
+	return newGroupIterator(p.groupMap()), nil
+}
+
+func (p *groupsPlan) groupMap() (map[uint64][]*measurev1.DataPoint, []uint64) {
+	m := make(map[uint64][]*measurev1.DataPoint, len(p.groups))
+	keys := make([]uint64, 0, len(p.groups))
+	for i, g := range p.groups {
+		m[uint64(i)] = g
+		keys = append(keys, uint64(i))
+	}
+	return m, keys
+}
+
+func fieldsDataPoint(total int64, latency float64) *measurev1.DataPoint {
+	return &measurev1.DataPoint{Fields: []*measurev1.DataPoint_Field{
+		{Name: "total", Value: intFieldValue(total)},
+		{Name: "latency", Value: floatFieldValue(latency)},
+	}}
+}
+
+func TestAggregation_several(t *testing.T) {
+	s, err := BuildSchema(&databasev1.Measure{
+		Fields: []*databasev1.FieldSpec{
+			{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "latency", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+		},
+	}, nil)
+	require.NoError(t, err)
+	input := &groupsPlan{s: s, groups: [][]*measurev1.DataPoint{
+		{fieldsDataPoint(1, 0.5), fieldsDataPoint(2, 1.5)},
+		{fieldsDataPoint(10, 3)},
+	}}
+	plan, err := newUnresolvedAggregation(input, []*measurev1.QueryRequest_Aggregation{
+		{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, FieldName: "total", Alias: "calls"},
+		{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX, FieldName: "latency"},
+		{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT, FieldName: "total", Alias: "count"},
+	}, true, nil).Analyze(s)
+	require.NoError(t, err)
+
+	refs, err := plan.Schema().CreateFieldRef(logical.NewField("calls"), logical.NewField("latency"), logical.NewField("count"))
+	require.NoError(t, err)
+	for i, ref := range refs {
+		assert.Equal(t, i, ref.Spec.FieldIdx)
+	}
+	assert.Equal(t, databasev1.FieldType_FIELD_TYPE_FLOAT, refs[1].Spec.Spec.GetFieldType())
+	_, err = plan.Schema().CreateFieldRef(logical.NewField("total"))
+	assert.ErrorIs(t, err, errFieldNotDefined, "the aliased field is renamed")
+
+	iter, err := plan.(executor.MeasureExecutable).Execute(context.Background())
+	require.NoError(t, err)
+	var got [][]*measurev1.DataPoint_Field
+	for iter.Next() {
+		for _, dp := range iter.Current() {
+			got = append(got, dp.GetFields())
+		}
+	}
+	require.NoError(t, iter.Close())
+	require.Len(t, got, 2)
+	assert.Equal(t, "calls", got[0][0].GetName())
+	assert.Equal(t, int64(3), got[0][0].GetValue().GetInt().GetValue())
+	assert.Equal(t, 1.5, got[0][1].GetValue().GetFloat().GetValue())
+	assert.Equal(t, int64(2), got[0][2].GetValue().GetInt().GetValue())
+	assert.Equal(t, int64(10), got[1][0].GetValue().GetInt().GetValue())
+	assert.Equal(t, int64(1), got[1][2].GetValue().GetInt().GetValue())
+}
+
+func TestAggregation_duplicatedAlias(t *testing.T) {
+	s, err := BuildSchema(&databasev1.Measure{
+		Fields: []*databasev1.FieldSpec{{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT}},
+	}, nil)
+	require.NoError(t, err)
+	_, err = newUnresolvedAggregation(&groupsPlan{s: s}, []*measurev1.QueryRequest_Aggregation{
+		{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, FieldName: "total"},
+		{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX, FieldName: "total"},
+	}, false, nil).Analyze(s)
+	assert.ErrorIs(t, err, errDuplicatedAggregation)
+}
+
+func TestTopValue(t *testing.T) {
+	floats := []float64{math.Inf(1), 2.5, 0, -0.5, 1e-300, -1e300, math.Inf(-1), 3}
+	values := make([]int64, len(floats))
+	for i, f := range floats {
+		values[i] = topValue(floatFieldValue(f))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	sort.Float64s(floats)
+	for i, f := range floats {
+		assert.Equal(t, topValue(floatFieldValue(f)), values[i], "%v is out of order", f)
+	}
+	assert.Equal(t, int64(-3), topValue(intFieldValue(-3)))
+}
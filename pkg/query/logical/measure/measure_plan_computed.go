@@ -0,0 +1,157 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+var (
+	_ logical.UnresolvedPlan = (*unresolvedComputed)(nil)
+	_ logical.Plan           = (*computed)(nil)
+
+	errDuplicatedField = errors.New("duplicated field name")
+)
+
+type unresolvedComputed struct {
+	unresolvedInput logical.UnresolvedPlan
+	fields          []*measurev1.QueryRequest_ComputedField
+}
+
+func newUnresolvedComputed(input logical.UnresolvedPlan, fields []*measurev1.QueryRequest_ComputedField) logical.UnresolvedPlan {
+	return &unresolvedComputed{
+		unresolvedInput: input,
+		fields:          fields,
+	}
+}
+
+func (uc *unresolvedComputed) Analyze(measureSchema logical.Schema) (logical.Plan, error) {
+	prevPlan, err := uc.unresolvedInput.Analyze(measureSchema)
+	if err != nil {
+		return nil, err
+	}
+	s := prevPlan.Schema()
+	exprs := make([]logical.ArithmeticExpr, 0, len(uc.fields))
+	specs := make([]*databasev1.FieldSpec, 0, len(uc.fields))
+	for _, f := range uc.fields {
+		// a computed field refers to the fields before it, rather than the computed ones.
+		expr, err := logical.ParseArithmeticExpr(f.GetExpression(), s)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "computed field %s", f.GetName())
+		}
+		if _, err := s.CreateFieldRef(logical.NewField(f.GetName())); err == nil {
+			return nil, errors.WithMessagef(errDuplicatedField, "computed field %s", f.GetName())
+		}
+		for _, spec := range specs {
+			if spec.GetName() == f.GetName() {
+				return nil, errors.WithMessagef(errDuplicatedField, "computed field %s", f.GetName())
+			}
+		}
+		exprs = append(exprs, expr)
+		specs = append(specs, &databasev1.FieldSpec{Name: f.GetName(), FieldType: expr.FieldType()})
+	}
+	return &computed{
+		Parent: &logical.Parent{
+			UnresolvedInput: uc.unresolvedInput,
+			Input:           prevPlan,
+		},
+		schema: s.(*schema).computedFields(specs),
+		specs:  specs,
+		exprs:  exprs,
+	}, nil
+}
+
+type computed struct {
+	*logical.Parent
+	schema logical.Schema
+	specs  []*databasev1.FieldSpec
+	exprs  []logical.ArithmeticExpr
+}
+
+func (c *computed) String() string {
+	fields := make([]string, 0, len(c.specs))
+	for i, spec := range c.specs {
+		fields = append(fields, fmt.Sprintf("%s=%s", spec.GetName(), c.exprs[i]))
+	}
+	return fmt.Sprintf("%s computed: %s", c.Input, strings.Join(fields, ","))
+}
+
+func (c *computed) Children() []logical.Plan {
+	return []logical.Plan{c.Input}
+}
+
+func (c *computed) Schema() logical.Schema {
+	return c.schema
+}
+
+func (c *computed) Execute(ec context.Context) (executor.MIterator, error) {
+	iter, err := c.Parent.Input.(executor.MeasureExecutable).Execute(ec)
+	if err != nil {
+		return nil, err
+	}
+	return &computedIterator{prev: iter, plan: c}, nil
+}
+
+type computedIterator struct {
+	prev    executor.MIterator
+	plan    *computed
+	current []*measurev1.DataPoint
+}
+
+func (ci *computedIterator) Next() bool {
+	if !ci.prev.Next() {
+		return false
+	}
+	ci.current = ci.prev.Current()
+	for _, dp := range ci.current {
+		fields := dp.GetFields()
+		value := func(name string) *modelv1.FieldValue {
+			for _, f := range fields {
+				if f.GetName() == name {
+					return f.GetValue()
+				}
+			}
+			return nil
+		}
+		for i, expr := range ci.plan.exprs {
+			dp.Fields = append(dp.Fields, &measurev1.DataPoint_Field{
+				Name:  ci.plan.specs[i].GetName(),
+				Value: expr.Eval(value),
+			})
+		}
+	}
+	return true
+}
+
+func (ci *computedIterator) Current() []*measurev1.DataPoint {
+	return ci.current
+}
+
+func (ci *computedIterator) Close() error {
+	return ci.prev.Close()
+}
@@ -0,0 +1,153 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+func fieldExpression(name string) *modelv1.Expression {
+	return &modelv1.Expression{Exp: &modelv1.Expression_FieldName{FieldName: name}}
+}
+
+func literalExpression(v *modelv1.FieldValue) *modelv1.Expression {
+	return &modelv1.Expression{Exp: &modelv1.Expression_Literal{Literal: v}}
+}
+
+func binaryExpression(op modelv1.Expression_ArithmeticOp, left, right *modelv1.Expression) *modelv1.Expression {
+	return &modelv1.Expression{Exp: &modelv1.Expression_Binary_{Binary: &modelv1.Expression_Binary{Op: op, Left: left, Right: right}}}
+}
+
+func TestParseArithmeticExpr(t *testing.T) {
+	s, err := BuildSchema(&databasev1.Measure{
+		Fields: []*databasev1.FieldSpec{
+			{Name: "errors", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "latency", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+			{Name: "name", FieldType: databasev1.FieldType_FIELD_TYPE_STRING},
+		},
+	}, nil)
+	require.NoError(t, err)
+	values := fieldValues(map[string]*modelv1.FieldValue{
+		"errors":  intFieldValue(3),
+		"total":   intFieldValue(4),
+		"latency": floatFieldValue(1.5),
+	})
+	tests := []struct {
+		expr      *modelv1.Expression
+		want      *modelv1.FieldValue
+		name      string
+		fieldType databasev1.FieldType
+	}{
+		{
+			name: "int ratio is a float",
+			expr: binaryExpression(modelv1.Expression_ARITHMETIC_OP_DIV, fieldExpression("errors"), fieldExpression("total")),
+			want: floatFieldValue(0.75), fieldType: databasev1.FieldType_FIELD_TYPE_FLOAT,
+		},
+		{
+			name: "int arithmetic",
+			expr: binaryExpression(modelv1.Expression_ARITHMETIC_OP_SUB, fieldExpression("total"),
+				binaryExpression(modelv1.Expression_ARITHMETIC_OP_MUL, fieldExpression("errors"), literalExpression(intFieldValue(2)))),
+			want: intFieldValue(-2), fieldType: databasev1.FieldType_FIELD_TYPE_INT,
+		},
+		{
+			name: "int and float",
+			expr: binaryExpression(modelv1.Expression_ARITHMETIC_OP_ADD, fieldExpression("latency"), fieldExpression("total")),
+			want: floatFieldValue(5.5), fieldType: databasev1.FieldType_FIELD_TYPE_FLOAT,
+		},
+		{
+			name: "divided by zero",
+			expr: binaryExpression(modelv1.Expression_ARITHMETIC_OP_DIV, fieldExpression("latency"), literalExpression(floatFieldValue(0))),
+			want: pbv1.NullFieldValue, fieldType: databasev1.FieldType_FIELD_TYPE_FLOAT,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := logical.ParseArithmeticExpr(tt.expr, s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.fieldType, expr.FieldType())
+			got := expr.Eval(values)
+			assert.True(t, proto.Equal(tt.want, got), "want %v, got %v", tt.want, got)
+		})
+	}
+
+	expr, err := logical.ParseArithmeticExpr(binaryExpression(modelv1.Expression_ARITHMETIC_OP_ADD, fieldExpression("errors"), fieldExpression("total")), s)
+	require.NoError(t, err)
+	got := expr.Eval(fieldValues(map[string]*modelv1.FieldValue{"errors": intFieldValue(1), "total": pbv1.NullFieldValue}))
+	assert.True(t, proto.Equal(pbv1.NullFieldValue, got), "a null operand makes the result null")
+
+	for name, e := range map[string]*modelv1.Expression{
+		"undefined field":  fieldExpression("unknown"),
+		"string field":     fieldExpression("name"),
+		"string literal":   literalExpression(&modelv1.FieldValue{Value: &modelv1.FieldValue_Str{Str: &modelv1.Str{Value: "1"}}}),
+		"missing operator": binaryExpression(modelv1.Expression_ARITHMETIC_OP_UNSPECIFIED, fieldExpression("errors"), fieldExpression("total")),
+		"missing operand":  binaryExpression(modelv1.Expression_ARITHMETIC_OP_ADD, fieldExpression("errors"), nil),
+	} {
+		_, err := logical.ParseArithmeticExpr(e, s)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestComputed(t *testing.T) {
+	s, err := BuildSchema(&databasev1.Measure{
+		Fields: []*databasev1.FieldSpec{
+			{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "latency", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+		},
+	}, nil)
+	require.NoError(t, err)
+	input := &groupsPlan{s: s.(*schema).aggregatedFields([]*databasev1.FieldSpec{
+		{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+		{Name: "latency", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+	}), groups: [][]*measurev1.DataPoint{{fieldsDataPoint(2, 3)}}}
+	fields := []*measurev1.QueryRequest_ComputedField{{
+		Name:       "mean",
+		Expression: binaryExpression(modelv1.Expression_ARITHMETIC_OP_DIV, fieldExpression("latency"), fieldExpression("total")),
+	}}
+	plan, err := newUnresolvedComputed(input, fields).Analyze(s)
+	require.NoError(t, err)
+	refs, err := plan.Schema().CreateFieldRef(logical.NewField("mean"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, refs[0].Spec.FieldIdx)
+
+	iter, err := plan.(executor.MeasureExecutable).Execute(context.Background())
+	require.NoError(t, err)
+	require.True(t, iter.Next())
+	dp := iter.Current()[0]
+	assert.Equal(t, "mean", dp.GetFields()[2].GetName())
+	assert.Equal(t, 1.5, dp.GetFields()[2].GetValue().GetFloat().GetValue())
+	assert.Len(t, iter.Current()[0].GetFields(), 3, "the fields are computed once")
+	assert.False(t, iter.Next())
+	require.NoError(t, iter.Close())
+
+	fields = append(fields, &measurev1.QueryRequest_ComputedField{Name: "total", Expression: fieldExpression("latency")})
+	_, err = newUnresolvedComputed(input, fields).Analyze(s)
+	assert.ErrorIs(t, err, errDuplicatedField)
+}
@@ -69,14 +69,12 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 	for i, fieldNameProj := range ud.originalQuery.GetFieldProjection().GetNames() {
 		projectionFields[i] = logical.NewField(fieldNameProj)
 	}
-	if len(projectionFields) > 0 {
-		var err error
-		projFieldRefs, err := s.CreateFieldRef(projectionFields...)
-		if err != nil {
-			return nil, err
-		}
-		s = s.ProjFields(projFieldRefs...)
+	// the fields of data points are in the order of the projection
+	projFieldRefs, err := s.CreateFieldRef(projectionFields...)
+	if err != nil {
+		return nil, err
 	}
+	s = s.ProjFields(projFieldRefs...)
 	limit := ud.originalQuery.GetLimit()
 	if limit == 0 {
 		limit = defaultLimit
@@ -87,6 +85,7 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 		Name:            ud.originalQuery.Name,
 		Groups:          ud.originalQuery.Groups,
 		Criteria:        ud.originalQuery.Criteria,
+		FieldCriteria:   ud.originalQuery.FieldCriteria,
 		Limit:           limit + ud.originalQuery.Offset,
 		OrderBy:         ud.originalQuery.OrderBy,
 	}
@@ -185,6 +184,13 @@ func (t *distributedPlan) Execute(ctx context.Context) (mi executor.MIterator, e
 	if err != nil {
 		return nil, err
 	}
+	// Abandon the responses of the data nodes if the query is canceled, e.g. the client disconnects,
+	// or the responses exceed the memory budget.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	bus.CancelOnDone(ctx, ff)
+	budget := executor.MemoryBudgetFromContext(ctx)
+	stats := executor.QueryStatsFromContext(ctx)
 	var see []sort.Iterator[*comparableDataPoint]
 	for _, f := range ff {
 		if m, getErr := f.Get(); getErr != nil {
@@ -198,6 +204,10 @@ func (t *distributedPlan) Execute(ctx context.Context) (mi executor.MIterator, e
 			if span != nil {
 				span.AddSubTrace(resp.Trace)
 			}
+			if errAcquire := budget.Acquire(uint64(proto.Size(resp))); errAcquire != nil {
+				return nil, errAcquire
+			}
+			stats.AddScanned(uint64(len(resp.DataPoints)))
 			see = append(see,
 				newSortableElements(resp.DataPoints,
 					t.sortByTime, t.sortTagSpec))
@@ -305,7 +315,7 @@ var _ executor.MIterator = (*sortedMIterator)(nil)
 type sortedMIterator struct {
 	sort.Iterator[*comparableDataPoint]
 	data        *list.List
-	uniqueData  map[uint64]*measurev1.DataPoint
+	uniqueData  map[dataPointKey]*measurev1.DataPoint
 	cur         *measurev1.DataPoint
 	initialized bool
 	closed      bool
@@ -321,7 +331,7 @@ func (s *sortedMIterator) init() {
 		return
 	}
 	s.data = list.New()
-	s.uniqueData = make(map[uint64]*measurev1.DataPoint)
+	s.uniqueData = make(map[dataPointKey]*measurev1.DataPoint)
 	s.loadDps()
 }
 
@@ -349,7 +359,7 @@ func (s *sortedMIterator) loadDps() {
 		delete(s.uniqueData, k)
 	}
 	first := s.Iterator.Val()
-	s.uniqueData[hashDataPoint(first.DataPoint)] = first.DataPoint
+	s.uniqueData[keyOfDataPoint(first.DataPoint)] = first.DataPoint
 	for {
 		if !s.Iterator.Next() {
 			s.closed = true
@@ -357,7 +367,7 @@ func (s *sortedMIterator) loadDps() {
 		}
 		v := s.Iterator.Val()
 		if bytes.Equal(first.SortedField(), v.SortedField()) {
-			key := hashDataPoint(v.DataPoint)
+			key := keyOfDataPoint(v.DataPoint)
 			if existed, ok := s.uniqueData[key]; ok {
 				if v.DataPoint.Version > existed.Version {
 					s.uniqueData[key] = v.DataPoint
@@ -378,17 +388,14 @@ func (s *sortedMIterator) Current() []*measurev1.DataPoint {
 	return []*measurev1.DataPoint{s.cur}
 }
 
-const (
-	offset64 = 14695981039346656037
-	prime64  = 1099511628211
-)
+// dataPointKey identifies a data point. The replicas of a shard respond the data points with the same key,
+// and only the one with the highest version is kept.
+type dataPointKey struct {
+	sid     uint64
+	seconds int64
+	nanos   int32
+}
 
-// hashDataPoint calculates the hash value of a data point with fnv64a.
-// https://en.wikipedia.org/wiki/Fowler%E2%80%93Noll%E2%80%93Vo_hash_function
-func hashDataPoint(dp *measurev1.DataPoint) uint64 {
-	h := uint64(offset64)
-	h = (h ^ dp.Sid) * prime64
-	h = (h ^ uint64(dp.Timestamp.Seconds)) * prime64
-	h = (h ^ uint64(dp.Timestamp.Nanos)) * prime64
-	return h
+func keyOfDataPoint(dp *measurev1.DataPoint) dataPointKey {
+	return dataPointKey{sid: dp.Sid, seconds: dp.Timestamp.GetSeconds(), nanos: dp.Timestamp.GetNanos()}
 }
@@ -18,14 +18,22 @@
 package measure
 
 import (
+	"context"
+	"fmt"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/testing/protocmp"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 )
 
 type mockIterator struct {
@@ -158,3 +166,63 @@ func TestSortedMIterator(t *testing.T) {
 		})
 	}
 }
+
+type replicaFuture struct {
+	resp *measurev1.QueryResponse
+}
+
+func (f replicaFuture) Get() (bus.Message, error) {
+	return bus.NewMessage(bus.MessageID(0), f.resp), nil
+}
+
+func (f replicaFuture) GetAll() ([]bus.Message, error) {
+	m, err := f.Get()
+	return []bus.Message{m}, err
+}
+
+// replicaContext responds the data points of the nodes, and the replicas of a shard respond the same data points.
+type replicaContext struct {
+	nodes [][]*measurev1.DataPoint
+}
+
+func (r replicaContext) Broadcast(_ time.Duration, _ bus.Topic, _ bus.Message) ([]bus.Future, error) {
+	ff := make([]bus.Future, 0, len(r.nodes))
+	for _, dps := range r.nodes {
+		ff = append(ff, replicaFuture{resp: &measurev1.QueryResponse{DataPoints: dps}})
+	}
+	return ff, nil
+}
+
+func (replicaContext) TimeRange() *modelv1.TimeRange {
+	return &modelv1.TimeRange{Begin: timestamppb.New(time.Unix(0, 0)), End: timestamppb.New(time.Unix(10, 0))}
+}
+
+func (replicaContext) NodeSelectors() map[string][]string {
+	return nil
+}
+
+func TestDistributedPlanDeduplicateReplicas(t *testing.T) {
+	dp := func(sid uint64, sec int64, version int64) *measurev1.DataPoint {
+		return &measurev1.DataPoint{Sid: sid, Timestamp: &timestamppb.Timestamp{Seconds: sec}, Version: version}
+	}
+	// node-1 holds the shards 0 and 1, and node-2 holds the shards 0 and 2.
+	// The series 1 lives in the shard 0, and node-1 has missed the second version of its data point at 3s.
+	ec := replicaContext{nodes: [][]*measurev1.DataPoint{
+		{dp(1, 3, 1), dp(2, 2, 1), dp(1, 1, 1)},
+		{dp(3, 3, 1), dp(1, 3, 2), dp(3, 2, 1), dp(1, 1, 1)},
+	}}
+	plan := &distributedPlan{queryTemplate: &measurev1.QueryRequest{}, sortByTime: true, desc: true}
+	mi, err := plan.Execute(executor.WithDistributedExecutionContext(context.Background(), ec))
+	require.NoError(t, err)
+	// The data points are formatted as "sid@seconds#version".
+	var got []string
+	for mi.Next() {
+		for _, p := range mi.Current() {
+			got = append(got, fmt.Sprintf("%d@%d#%d", p.Sid, p.Timestamp.Seconds, p.Version))
+		}
+	}
+	require.Len(t, got, 5)
+	assert.ElementsMatch(t, []string{"1@3#2", "3@3#1"}, got[:2])
+	assert.ElementsMatch(t, []string{"2@2#1", "3@2#1"}, got[2:4])
+	assert.Equal(t, "1@1#1", got[4])
+}
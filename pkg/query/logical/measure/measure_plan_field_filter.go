@@ -0,0 +1,191 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+var errUnsupportedFieldCondition = errors.New("unsupported field condition")
+
+// fieldFilter evaluates the field criteria against the field values of a data point.
+type fieldFilter interface {
+	match(value func(name string) *modelv1.FieldValue) bool
+}
+
+type fieldLogicalExpr struct {
+	left  fieldFilter
+	right fieldFilter
+	and   bool
+}
+
+func (f *fieldLogicalExpr) match(value func(name string) *modelv1.FieldValue) bool {
+	if f.and {
+		return f.left.match(value) && f.right.match(value)
+	}
+	return f.left.match(value) || f.right.match(value)
+}
+
+// fieldCondition compares a field with an int or float value. A null field matches no condition.
+type fieldCondition struct {
+	value *modelv1.FieldValue
+	name  string
+	op    modelv1.Condition_BinaryOp
+}
+
+func (f *fieldCondition) match(value func(name string) *modelv1.FieldValue) bool {
+	c, ok := compareFieldValue(value(f.name), f.value)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case modelv1.Condition_BINARY_OP_EQ:
+		return c == 0
+	case modelv1.Condition_BINARY_OP_NE:
+		return c != 0
+	case modelv1.Condition_BINARY_OP_LT:
+		return c < 0
+	case modelv1.Condition_BINARY_OP_LE:
+		return c <= 0
+	case modelv1.Condition_BINARY_OP_GT:
+		return c > 0
+	case modelv1.Condition_BINARY_OP_GE:
+		return c >= 0
+	}
+	return false
+}
+
+// compareFieldValue compares a numeric field value with the condition's value.
+// An int is compared as a float with a float.
+func compareFieldValue(v, literal *modelv1.FieldValue) (int, bool) {
+	var f float64
+	switch x := v.GetValue().(type) {
+	case *modelv1.FieldValue_Int:
+		if i, ok := literal.GetValue().(*modelv1.FieldValue_Int); ok {
+			return cmp.Compare(x.Int.GetValue(), i.Int.GetValue()), true
+		}
+		f = float64(x.Int.GetValue())
+	case *modelv1.FieldValue_Float:
+		f = x.Float.GetValue()
+		if math.IsNaN(f) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	switch l := literal.GetValue().(type) {
+	case *modelv1.FieldValue_Int:
+		return cmp.Compare(f, float64(l.Int.GetValue())), true
+	case *modelv1.FieldValue_Float:
+		return cmp.Compare(f, l.Float.GetValue()), true
+	}
+	return 0, false
+}
+
+// buildFieldFilter builds the filter of the field criteria, and returns the names of the fields it refers to.
+func buildFieldFilter(criteria *modelv1.Criteria, s logical.Schema) (fieldFilter, []string, error) {
+	var names []string
+	var build func(c *modelv1.Criteria) (fieldFilter, error)
+	build = func(c *modelv1.Criteria) (fieldFilter, error) {
+		switch v := c.GetExp().(type) {
+		case *modelv1.Criteria_Le:
+			left, err := build(v.Le.GetLeft())
+			if err != nil {
+				return nil, err
+			}
+			right, err := build(v.Le.GetRight())
+			if err != nil {
+				return nil, err
+			}
+			if left == nil || right == nil {
+				return nil, errors.WithMessage(errUnsupportedFieldCondition, "empty field criteria")
+			}
+			return &fieldLogicalExpr{left: left, right: right, and: v.Le.GetOp() == modelv1.LogicalExpression_LOGICAL_OP_AND}, nil
+		case *modelv1.Criteria_Condition:
+			cond, err := buildFieldCondition(v.Condition, s)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(names, cond.name) {
+				names = append(names, cond.name)
+			}
+			return cond, nil
+		}
+		return nil, nil
+	}
+	filter, err := build(criteria)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filter, names, nil
+}
+
+func buildFieldCondition(cond *modelv1.Condition, s logical.Schema) (*fieldCondition, error) {
+	refs, err := s.CreateFieldRef(logical.NewField(cond.GetName()))
+	if err != nil {
+		return nil, err
+	}
+	switch refs[0].Spec.Spec.GetFieldType() {
+	case databasev1.FieldType_FIELD_TYPE_INT, databasev1.FieldType_FIELD_TYPE_FLOAT:
+	default:
+		return nil, errors.WithMessagef(errUnsupportedFieldCondition, "field %s isn't numeric", cond.GetName())
+	}
+	switch cond.GetOp() {
+	case modelv1.Condition_BINARY_OP_EQ, modelv1.Condition_BINARY_OP_NE,
+		modelv1.Condition_BINARY_OP_LT, modelv1.Condition_BINARY_OP_LE,
+		modelv1.Condition_BINARY_OP_GT, modelv1.Condition_BINARY_OP_GE:
+	default:
+		return nil, errors.WithMessagef(errUnsupportedFieldCondition, "%s on field %s", cond.GetOp(), cond.GetName())
+	}
+	fc := &fieldCondition{name: cond.GetName(), op: cond.GetOp()}
+	switch v := cond.GetValue().GetValue().(type) {
+	case *modelv1.TagValue_Int:
+		fc.value = &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: v.Int}}
+	case *modelv1.TagValue_Float:
+		if math.IsNaN(v.Float.GetValue()) {
+			return nil, errors.WithMessagef(errUnsupportedFieldCondition, "field %s is compared with NaN", cond.GetName())
+		}
+		fc.value = &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: v.Float}}
+	default:
+		return nil, errors.WithMessagef(errUnsupportedFieldCondition, "field %s is compared with %T", cond.GetName(), v)
+	}
+	return fc, nil
+}
+
+// fieldPredicates returns the conditions joined by the top-level AND expressions,
+// which let the storage skip the blocks by the min and max values of the fields.
+func fieldPredicates(filter fieldFilter) []model.FieldPredicate {
+	switch f := filter.(type) {
+	case *fieldLogicalExpr:
+		if f.and {
+			return append(fieldPredicates(f.left), fieldPredicates(f.right)...)
+		}
+	case *fieldCondition:
+		return []model.FieldPredicate{{Name: f.name, Op: f.op, Value: f.value}}
+	}
+	return nil
+}
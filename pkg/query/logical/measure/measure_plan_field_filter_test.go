@@ -0,0 +1,108 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+func fieldCriteria(name string, op modelv1.Condition_BinaryOp, v *modelv1.TagValue) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: name, Op: op, Value: v}}}
+}
+
+func intTagValue(v int64) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}
+}
+
+func floatTagValue(v float64) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Float{Float: &modelv1.Float{Value: v}}}
+}
+
+func fieldValues(values map[string]*modelv1.FieldValue) func(string) *modelv1.FieldValue {
+	return func(name string) *modelv1.FieldValue {
+		return values[name]
+	}
+}
+
+func intFieldValue(v int64) *modelv1.FieldValue {
+	return &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v}}}
+}
+
+func floatFieldValue(v float64) *modelv1.FieldValue {
+	return &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: v}}}
+}
+
+func TestBuildFieldFilter(t *testing.T) {
+	s, err := BuildSchema(&databasev1.Measure{
+		Fields: []*databasev1.FieldSpec{
+			{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "ratio", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+			{Name: "name", FieldType: databasev1.FieldType_FIELD_TYPE_STRING},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	// total > 10 AND (ratio <= 0.5 OR total = 100)
+	criteria := &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op:   modelv1.LogicalExpression_LOGICAL_OP_AND,
+		Left: fieldCriteria("total", modelv1.Condition_BINARY_OP_GT, intTagValue(10)),
+		Right: &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+			Op:    modelv1.LogicalExpression_LOGICAL_OP_OR,
+			Left:  fieldCriteria("ratio", modelv1.Condition_BINARY_OP_LE, floatTagValue(0.5)),
+			Right: fieldCriteria("total", modelv1.Condition_BINARY_OP_EQ, intTagValue(100)),
+		}}},
+	}}}
+	filter, names, err := buildFieldFilter(criteria, s)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"total", "ratio"}, names)
+
+	assert.True(t, filter.match(fieldValues(map[string]*modelv1.FieldValue{"total": intFieldValue(11), "ratio": floatFieldValue(0.5)})))
+	assert.True(t, filter.match(fieldValues(map[string]*modelv1.FieldValue{"total": intFieldValue(100), "ratio": floatFieldValue(0.9)})))
+	assert.False(t, filter.match(fieldValues(map[string]*modelv1.FieldValue{"total": intFieldValue(11), "ratio": floatFieldValue(0.9)})))
+	assert.False(t, filter.match(fieldValues(map[string]*modelv1.FieldValue{"total": intFieldValue(10), "ratio": floatFieldValue(0.1)})))
+	assert.False(t, filter.match(fieldValues(map[string]*modelv1.FieldValue{"ratio": floatFieldValue(0.1)})), "a null field matches nothing")
+
+	predicates := fieldPredicates(filter)
+	require.Len(t, predicates, 1, "the conditions under OR can't prune blocks")
+	assert.Equal(t, model.FieldPredicate{Name: "total", Op: modelv1.Condition_BINARY_OP_GT, Value: intFieldValue(10)}, predicates[0])
+
+	for name, c := range map[string]*modelv1.Criteria{
+		"undefined field":    fieldCriteria("unknown", modelv1.Condition_BINARY_OP_EQ, intTagValue(1)),
+		"string field":       fieldCriteria("name", modelv1.Condition_BINARY_OP_EQ, intTagValue(1)),
+		"string value":       fieldCriteria("total", modelv1.Condition_BINARY_OP_EQ, &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "1"}}}),
+		"unsupported binary": fieldCriteria("total", modelv1.Condition_BINARY_OP_IN, intTagValue(1)),
+	} {
+		_, _, err := buildFieldFilter(c, s)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestFieldCondition_intAndFloat(t *testing.T) {
+	c := &fieldCondition{name: "v", op: modelv1.Condition_BINARY_OP_LT, value: floatFieldValue(1.5)}
+	assert.True(t, c.match(fieldValues(map[string]*modelv1.FieldValue{"v": intFieldValue(1)})))
+	assert.False(t, c.match(fieldValues(map[string]*modelv1.FieldValue{"v": intFieldValue(2)})))
+	c = &fieldCondition{name: "v", op: modelv1.Condition_BINARY_OP_NE, value: intFieldValue(1)}
+	assert.True(t, c.match(fieldValues(map[string]*modelv1.FieldValue{"v": floatFieldValue(1.5)})))
+}
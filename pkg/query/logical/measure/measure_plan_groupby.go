@@ -29,6 +29,7 @@ import (
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 )
@@ -172,6 +173,21 @@ func formatGroupByKey(point *measurev1.DataPoint, groupByTagsRefs [][]*logical.T
 				if innerErr != nil {
 					return 0, innerErr
 				}
+			case *modelv1.TagValue_Float:
+				_, innerErr := hash.Write(convert.Float64ToSortableBytes(v.Float.GetValue()))
+				if innerErr != nil {
+					return 0, innerErr
+				}
+			case *modelv1.TagValue_Bool:
+				_, innerErr := hash.Write(pbv1.BoolToBytes(v.Bool.GetValue()))
+				if innerErr != nil {
+					return 0, innerErr
+				}
+			case *modelv1.TagValue_Timestamp:
+				_, innerErr := hash.Write(convert.Int64ToBytes(v.Timestamp.AsTime().UnixNano()))
+				if innerErr != nil {
+					return 0, innerErr
+				}
 			case *modelv1.TagValue_IntArray, *modelv1.TagValue_StrArray, *modelv1.TagValue_BinaryData:
 				return 0, errors.New("group-by on array/binary tag is not supported")
 			}
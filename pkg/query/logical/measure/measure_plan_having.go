@@ -0,0 +1,143 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+var (
+	_ logical.UnresolvedPlan = (*unresolvedHaving)(nil)
+	_ logical.Plan           = (*having)(nil)
+
+	errHavingWithoutAggregation = errors.New("having requires aggregations")
+)
+
+type unresolvedHaving struct {
+	unresolvedInput logical.UnresolvedPlan
+	criteria        *modelv1.Criteria
+}
+
+func newUnresolvedHaving(input logical.UnresolvedPlan, criteria *modelv1.Criteria) logical.UnresolvedPlan {
+	return &unresolvedHaving{
+		unresolvedInput: input,
+		criteria:        criteria,
+	}
+}
+
+func (uh *unresolvedHaving) Analyze(measureSchema logical.Schema) (logical.Plan, error) {
+	prevPlan, err := uh.unresolvedInput.Analyze(measureSchema)
+	if err != nil {
+		return nil, err
+	}
+	if !aggregated(prevPlan) {
+		return nil, errHavingWithoutAggregation
+	}
+	// the conditions refer to the aggregated fields
+	filter, _, err := buildFieldFilter(uh.criteria, prevPlan.Schema())
+	if err != nil {
+		return nil, err
+	}
+	return &having{
+		Parent: &logical.Parent{
+			UnresolvedInput: uh.unresolvedInput,
+			Input:           prevPlan,
+		},
+		filter:   filter,
+		criteria: uh.criteria,
+	}, nil
+}
+
+// aggregated reports whether the plan outputs the aggregated data points, which might be followed by the computed fields.
+func aggregated(plan logical.Plan) bool {
+	if c, ok := plan.(*computed); ok {
+		plan = c.Input
+	}
+	_, ok := plan.(*aggregationPlan)
+	return ok
+}
+
+type having struct {
+	*logical.Parent
+	filter   fieldFilter
+	criteria *modelv1.Criteria
+}
+
+func (h *having) String() string {
+	return fmt.Sprintf("%s having: %s", h.Input, h.criteria)
+}
+
+func (h *having) Children() []logical.Plan {
+	return []logical.Plan{h.Input}
+}
+
+func (h *having) Schema() logical.Schema {
+	return h.Input.Schema()
+}
+
+func (h *having) Execute(ec context.Context) (executor.MIterator, error) {
+	iter, err := h.Parent.Input.(executor.MeasureExecutable).Execute(ec)
+	if err != nil {
+		return nil, err
+	}
+	return &havingIterator{prev: iter, filter: h.filter}, nil
+}
+
+type havingIterator struct {
+	prev    executor.MIterator
+	filter  fieldFilter
+	current []*measurev1.DataPoint
+}
+
+func (hi *havingIterator) Next() bool {
+	for hi.prev.Next() {
+		hi.current = hi.current[:0]
+		for _, dp := range hi.prev.Current() {
+			if hi.filter.match(func(name string) *modelv1.FieldValue {
+				for _, f := range dp.GetFields() {
+					if f.GetName() == name {
+						return f.GetValue()
+					}
+				}
+				return nil
+			}) {
+				hi.current = append(hi.current, dp)
+			}
+		}
+		if len(hi.current) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (hi *havingIterator) Current() []*measurev1.DataPoint {
+	return hi.current
+}
+
+func (hi *havingIterator) Close() error {
+	return hi.prev.Close()
+}
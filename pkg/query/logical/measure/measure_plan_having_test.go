@@ -0,0 +1,74 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+)
+
+func TestHaving(t *testing.T) {
+	s, err := BuildSchema(&databasev1.Measure{
+		Fields: []*databasev1.FieldSpec{
+			{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "latency", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+		},
+	}, nil)
+	require.NoError(t, err)
+	input := &groupsPlan{s: s, groups: [][]*measurev1.DataPoint{
+		{fieldsDataPoint(1, 0.5), fieldsDataPoint(2, 1.5)},
+		{fieldsDataPoint(10, 3)},
+		{fieldsDataPoint(50, 1), fieldsDataPoint(60, 1)},
+	}}
+	aggregations := []*measurev1.QueryRequest_Aggregation{
+		{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, FieldName: "total", Alias: "calls"},
+		{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX, FieldName: "latency"},
+	}
+	criteria := &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+		Left:  fieldCriteria("calls", modelv1.Condition_BINARY_OP_GT, intTagValue(5)),
+		Right: fieldCriteria("latency", modelv1.Condition_BINARY_OP_LE, floatTagValue(2)),
+	}}}
+	plan, err := newUnresolvedHaving(newUnresolvedAggregation(input, aggregations, true, nil), criteria).Analyze(s)
+	require.NoError(t, err)
+
+	iter, err := plan.(executor.MeasureExecutable).Execute(context.Background())
+	require.NoError(t, err)
+	var got []int64
+	for iter.Next() {
+		for _, dp := range iter.Current() {
+			got = append(got, dp.GetFields()[0].GetValue().GetInt().GetValue())
+		}
+	}
+	require.NoError(t, iter.Close())
+	assert.Equal(t, []int64{110}, got)
+
+	_, err = newUnresolvedHaving(newUnresolvedAggregation(input, aggregations, true, nil),
+		fieldCriteria("total", modelv1.Condition_BINARY_OP_GT, intTagValue(5))).Analyze(s)
+	assert.ErrorIs(t, err, errFieldNotDefined, "having refers to the aliases")
+	_, err = newUnresolvedHaving(input, criteria).Analyze(s)
+	assert.ErrorIs(t, err, errHavingWithoutAggregation)
+}
@@ -20,13 +20,17 @@ package measure
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/pkg/errors"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/index/inverted"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -45,6 +49,7 @@ type unresolvedIndexScan struct {
 	endTime          time.Time
 	metadata         *commonv1.Metadata
 	criteria         *modelv1.Criteria
+	fieldCriteria    *modelv1.Criteria
 	projectionTags   [][]*logical.Tag
 	projectionFields []*logical.Field
 	groupByEntity    bool
@@ -80,9 +85,34 @@ func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error)
 		}
 	}
 
+	var filter fieldFilter
+	var hiddenFields map[string]struct{}
+	if uis.fieldCriteria != nil {
+		var filterFields []string
+		var err error
+		filter, filterFields, err = buildFieldFilter(uis.fieldCriteria, s)
+		if err != nil {
+			return nil, err
+		}
+		// the fields in the criteria but not in the projection are fetched to filter data points, then dropped.
+		for _, name := range filterFields {
+			if slices.Contains(projField, name) {
+				continue
+			}
+			if hiddenFields == nil {
+				hiddenFields = make(map[string]struct{})
+			}
+			hiddenFields[name] = struct{}{}
+			projField = append(projField, name)
+		}
+	}
+
 	tr := timestamp.NewInclusiveTimeRange(uis.startTime, uis.endTime)
 	ms := s.(*schema)
 	if ms.measure.IndexMode {
+		if filter != nil {
+			return nil, errors.WithMessage(errUnsupportedFieldCondition, "a measure in the index mode has no field")
+		}
 		query, err := inverted.BuildIndexModeQuery(uis.metadata.Name, uis.criteria, s)
 		if err != nil {
 			return nil, err
@@ -114,6 +144,10 @@ func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error)
 	if err != nil {
 		return nil, err
 	}
+	if len(projField) > 0 && ms.measure.GetTemporality() != databasev1.Temporality_TEMPORALITY_UNSPECIFIED {
+		// the type of data points is required to calculate the increase of counters.
+		projField = append(projField, measure.DataPointTypeFieldName)
+	}
 
 	return &localIndexScan{
 		timeRange:            tr,
@@ -125,6 +159,8 @@ func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error)
 		metadata:             uis.metadata,
 		query:                query,
 		entities:             entities,
+		filter:               filter,
+		hiddenFields:         hiddenFields,
 		groupByEntity:        uis.groupByEntity,
 		uis:                  uis,
 		l:                    logger.GetLogger("query", "measure", uis.metadata.Group, uis.metadata.Name, "local-index"),
@@ -139,6 +175,7 @@ var (
 type localIndexScan struct {
 	query                index.Query
 	schema               logical.Schema
+	filter               fieldFilter
 	uis                  *unresolvedIndexScan
 	order                *logical.OrderBy
 	metadata             *commonv1.Metadata
@@ -148,6 +185,7 @@ type localIndexScan struct {
 	projectionTagsRefs   [][]*logical.TagRef
 	projectionFieldsRefs []*logical.FieldRef
 	entities             [][]*modelv1.TagValue
+	hiddenFields         map[string]struct{}
 	projectionFields     []string
 	groupByEntity        bool
 }
@@ -187,12 +225,15 @@ func (i *localIndexScan) Execute(ctx context.Context) (mit executor.MIterator, e
 		Order:           orderBy,
 		TagProjection:   i.projectionTags,
 		FieldProjection: i.projectionFields,
+		FieldPredicates: fieldPredicates(i.filter),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to query measure: %w", err)
 	}
 	return &resultMIterator{
-		result: result,
+		result:       result,
+		filter:       i.filter,
+		hiddenFields: i.hiddenFields,
 	}, nil
 }
 
@@ -207,14 +248,16 @@ func (i *localIndexScan) Children() []logical.Plan {
 }
 
 func (i *localIndexScan) Schema() logical.Schema {
-	if len(i.projectionTagsRefs) == 0 {
-		return i.schema
+	s := i.schema
+	if len(i.projectionTagsRefs) > 0 {
+		s = s.ProjTags(i.projectionTagsRefs...)
 	}
-	return i.schema.ProjTags(i.projectionTagsRefs...).ProjFields(i.projectionFieldsRefs...)
+	// the fields of data points are in the order of the projection
+	return s.ProjFields(i.projectionFieldsRefs...)
 }
 
 func indexScan(startTime, endTime time.Time, metadata *commonv1.Metadata, projectionTags [][]*logical.Tag,
-	projectionFields []*logical.Field, groupByEntity bool, criteria *modelv1.Criteria,
+	projectionFields []*logical.Field, groupByEntity bool, criteria, fieldCriteria *modelv1.Criteria,
 ) logical.UnresolvedPlan {
 	return &unresolvedIndexScan{
 		startTime:        startTime,
@@ -224,14 +267,17 @@ func indexScan(startTime, endTime time.Time, metadata *commonv1.Metadata, projec
 		projectionFields: projectionFields,
 		groupByEntity:    groupByEntity,
 		criteria:         criteria,
+		fieldCriteria:    fieldCriteria,
 	}
 }
 
 type resultMIterator struct {
-	result  model.MeasureQueryResult
-	err     error
-	current []*measurev1.DataPoint
-	i       int
+	result       model.MeasureQueryResult
+	filter       fieldFilter
+	err          error
+	hiddenFields map[string]struct{}
+	current      []*measurev1.DataPoint
+	i            int
 }
 
 func (ei *resultMIterator) Next() bool {
@@ -243,17 +289,41 @@ func (ei *resultMIterator) Next() bool {
 		return true
 	}
 
-	r := ei.result.Pull()
-	if r == nil {
-		return false
+	for {
+		r := ei.result.Pull()
+		if r == nil {
+			return false
+		}
+		if r.Error != nil {
+			ei.err = r.Error
+			return false
+		}
+		ei.current = ei.current[:0]
+		ei.i = 0
+		ei.load(r)
+		if len(ei.current) > 0 {
+			return true
+		}
 	}
-	if r.Error != nil {
-		ei.err = r.Error
-		return false
+}
+
+func (ei *resultMIterator) load(r *model.MeasureResult) {
+	var fieldIndex map[string]int
+	if ei.filter != nil {
+		fieldIndex = make(map[string]int, len(r.Fields))
+		for j, f := range r.Fields {
+			fieldIndex[f.Name] = j
+		}
 	}
-	ei.current = ei.current[:0]
-	ei.i = 0
 	for i := range r.Timestamps {
+		if ei.filter != nil && !ei.filter.match(func(name string) *modelv1.FieldValue {
+			if j, ok := fieldIndex[name]; ok {
+				return r.Fields[j].Values[i]
+			}
+			return nil
+		}) {
+			continue
+		}
 		dp := &measurev1.DataPoint{
 			Timestamp: timestamppb.New(time.Unix(0, r.Timestamps[i])),
 			Sid:       uint64(r.SID),
@@ -273,6 +343,13 @@ func (ei *resultMIterator) Next() bool {
 			}
 		}
 		for _, f := range r.Fields {
+			if f.Name == measure.DataPointTypeFieldName {
+				dp.Type = measurev1.DataPointValue_Type(f.Values[i].GetInt().GetValue())
+				continue
+			}
+			if _, ok := ei.hiddenFields[f.Name]; ok {
+				continue
+			}
 			dp.Fields = append(dp.Fields, &measurev1.DataPoint_Field{
 				Name:  f.Name,
 				Value: f.Values[i],
@@ -280,8 +357,6 @@ func (ei *resultMIterator) Next() bool {
 		}
 		ei.current = append(ei.current, dp)
 	}
-
-	return true
 }
 
 func (ei *resultMIterator) Current() []*measurev1.DataPoint {
@@ -20,6 +20,7 @@ package measure
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
@@ -103,16 +104,27 @@ func (g *topOp) Execute(ec context.Context) (mit executor.MIterator, err error)
 	for iter.Next() {
 		dpp := iter.Current()
 		for _, dp := range dpp {
-			value := dp.GetFields()[g.fieldRef.Spec.FieldIdx].
-				GetValue().
-				GetInt().
-				GetValue()
+			value := topValue(dp.GetFields()[g.fieldRef.Spec.FieldIdx].GetValue())
 			g.topNStream.Insert(NewTopElement(dp, value))
 		}
 	}
 	return newTopIterator(g.topNStream.Elements()), nil
 }
 
+// topValue returns the value to rank a data point. A float is mapped to an int64 in the same order.
+func topValue(v *modelv1.FieldValue) int64 {
+	f, ok := v.GetValue().(*modelv1.FieldValue_Float)
+	if !ok {
+		return v.GetInt().GetValue()
+	}
+	bits := math.Float64bits(f.Float.GetValue())
+	if bits>>63 == 1 {
+		// the larger magnitude a negative float has, the smaller it is.
+		return int64(bits ^ math.MaxInt64)
+	}
+	return int64(bits)
+}
+
 type topIterator struct {
 	elements []TopElement
 	index    int
@@ -98,6 +98,41 @@ func (m *schema) ProjFields(fieldRefs ...*logical.FieldRef) logical.Schema {
 	}
 }
 
+// aggregatedFields returns a schema whose fields are the results of aggregations, in the order of specs.
+func (m *schema) aggregatedFields(specs []*databasev1.FieldSpec) logical.Schema {
+	fieldMap := make(map[string]*logical.FieldSpec, len(specs))
+	for i, spec := range specs {
+		fieldMap[spec.GetName()] = &logical.FieldSpec{
+			FieldIdx: i,
+			Spec:     spec,
+		}
+	}
+	return &schema{
+		measure:  m.measure,
+		common:   m.common,
+		fieldMap: fieldMap,
+	}
+}
+
+// computedFields returns a schema whose fields are followed by the computed ones, in the order of specs.
+func (m *schema) computedFields(specs []*databasev1.FieldSpec) logical.Schema {
+	fieldMap := make(map[string]*logical.FieldSpec, len(m.fieldMap)+len(specs))
+	for name, spec := range m.fieldMap {
+		fieldMap[name] = spec
+	}
+	for i, spec := range specs {
+		fieldMap[spec.GetName()] = &logical.FieldSpec{
+			FieldIdx: len(m.fieldMap) + i,
+			Spec:     spec,
+		}
+	}
+	return &schema{
+		measure:  m.measure,
+		common:   m.common,
+		fieldMap: fieldMap,
+	}
+}
+
 func (m *schema) Equal(s2 logical.Schema) bool {
 	if other, ok := s2.(*schema); ok {
 		return cmp.Equal(other.common.TagSpecMap, m.common.TagSpecMap)
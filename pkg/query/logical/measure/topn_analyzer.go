@@ -51,9 +51,9 @@ func TopNAnalyze(criteria *measurev1.TopNRequest, sourceMeasureSchema *databasev
 		groupByTags := [][]*logical.Tag{logical.NewTags(measure.TopNTagFamily, groupByProjectionTags...)}
 		plan = newUnresolvedGroupBy(plan, groupByTags, false)
 		plan = newUnresolvedAggregation(plan,
-			&logical.Field{Name: topNAggSchema.FieldName},
-			criteria.GetAgg(),
-			true)
+			[]*measurev1.QueryRequest_Aggregation{{Function: criteria.GetAgg(), FieldName: topNAggSchema.FieldName}},
+			true,
+			criteria.GetTimeRange())
 	}
 
 	plan = top(plan, &measurev1.QueryRequest_Top{
@@ -121,7 +121,8 @@ func (uls *unresolvedLocalScan) parseGroupByTags() ([]string, error) {
 		}
 		if entityIdx, ok := entityMap[pairQuery.GetName()]; ok {
 			switch pairQuery.GetValue().GetValue().(type) {
-			case *modelv1.TagValue_Str, *modelv1.TagValue_Int, *modelv1.TagValue_Null:
+			case *modelv1.TagValue_Str, *modelv1.TagValue_Int, *modelv1.TagValue_Float,
+				*modelv1.TagValue_Bool, *modelv1.TagValue_Timestamp, *modelv1.TagValue_Null:
 				entity[entityIdx] = measure.Stringify(pairQuery.Value)
 				parsed++
 			default:
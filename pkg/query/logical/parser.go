@@ -83,6 +83,14 @@ func ParseExprOrEntity(entityDict map[string]int, entity []*modelv1.TagValue, co
 			return nil, entities, nil
 		}
 		return newInt64ArrLiteral(v.IntArray.GetValue()), nil, nil
+	case *modelv1.TagValue_Float, *modelv1.TagValue_Bool, *modelv1.TagValue_Timestamp:
+		if ok {
+			parsedEntity := make([]*modelv1.TagValue, len(entity))
+			copy(parsedEntity, entity)
+			parsedEntity[entityIdx] = cond.Value
+			return nil, [][]*modelv1.TagValue{parsedEntity}, nil
+		}
+		return parseScalarLiteral(cond.Value), nil, nil
 	case *modelv1.TagValue_Null:
 		return newNullLiteral(), nil, nil
 	}
@@ -100,12 +108,27 @@ func ParseExpr(cond *modelv1.Condition) (LiteralExpr, error) {
 		return newInt64Literal(v.Int.GetValue()), nil
 	case *modelv1.TagValue_IntArray:
 		return newInt64ArrLiteral(v.IntArray.GetValue()), nil
+	case *modelv1.TagValue_Float, *modelv1.TagValue_Bool, *modelv1.TagValue_Timestamp:
+		return parseScalarLiteral(cond.Value), nil
 	case *modelv1.TagValue_Null:
 		return newNullLiteral(), nil
 	}
 	return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "condition parses %v", cond)
 }
 
+// parseScalarLiteral parses the float, bool and timestamp values.
+func parseScalarLiteral(value *modelv1.TagValue) ComparableExpr {
+	switch v := value.Value.(type) {
+	case *modelv1.TagValue_Float:
+		return newFloatLiteral(v.Float.GetValue())
+	case *modelv1.TagValue_Bool:
+		return newBoolLiteral(v.Bool.GetValue())
+	case *modelv1.TagValue_Timestamp:
+		return newTimestampLiteral(v.Timestamp.AsTime())
+	}
+	return nil
+}
+
 // ParseEntities merges entities based on the logical operation.
 func ParseEntities(op modelv1.LogicalExpression_LogicalOp, input []*modelv1.TagValue, left, right [][]*modelv1.TagValue) [][]*modelv1.TagValue {
 	count := len(input)
@@ -0,0 +1,395 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+const continuationTokenVersion byte = 1
+
+var (
+	errInvalidToken        = errors.New("invalid continuation token")
+	errTokenMismatch       = errors.New("the continuation token doesn't match the order of the query")
+	errSortTagNotProjected = errors.New("the sort tag should be projected to paginate with the continuation token")
+)
+
+// ContinuationToken records where each data node stopped in a paginated query.
+type ContinuationToken struct {
+	cursors       map[string]*Cursor
+	indexRuleName string
+	desc          bool
+}
+
+// Cursor is the position of a data node's iterator.
+// All the elements before the sort value have been returned,
+// and so have the ones with the sort value whose ids are recorded.
+type Cursor struct {
+	sortValue  *modelv1.TagValue
+	elementIDs []string
+}
+
+// ParseContinuationToken decodes the token carried by a query request.
+// It returns nil if the token is empty.
+func ParseContinuationToken(data []byte, orderBy *modelv1.QueryOrder) (*ContinuationToken, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] != continuationTokenVersion {
+		return nil, errors.Wrapf(errInvalidToken, "unknown version %d", data[0])
+	}
+	src := data[1:]
+	var b []byte
+	var err error
+	if src, b, err = encoding.DecodeBytes(src); err != nil {
+		return nil, errors.WithMessage(errInvalidToken, err.Error())
+	}
+	t := &ContinuationToken{
+		indexRuleName: string(b),
+		cursors:       make(map[string]*Cursor),
+	}
+	if len(src) < 1 {
+		return nil, errors.Wrap(errInvalidToken, "missing the sort direction")
+	}
+	t.desc = src[0] == 1
+	src = src[1:]
+	if t.indexRuleName != orderBy.GetIndexRuleName() || t.desc != (orderBy.GetSort() == modelv1.Sort_SORT_DESC) {
+		return nil, errTokenMismatch
+	}
+	var cursorNum uint64
+	src, cursorNum = encoding.BytesToVarUint64(src)
+	for i := uint64(0); i < cursorNum; i++ {
+		var node []byte
+		if src, node, err = encoding.DecodeBytes(src); err != nil {
+			return nil, errors.WithMessage(errInvalidToken, err.Error())
+		}
+		if src, b, err = encoding.DecodeBytes(src); err != nil {
+			return nil, errors.WithMessage(errInvalidToken, err.Error())
+		}
+		c := &Cursor{sortValue: &modelv1.TagValue{}}
+		if err = proto.Unmarshal(b, c.sortValue); err != nil {
+			return nil, errors.WithMessage(errInvalidToken, err.Error())
+		}
+		var idNum uint64
+		src, idNum = encoding.BytesToVarUint64(src)
+		for j := uint64(0); j < idNum; j++ {
+			if src, b, err = encoding.DecodeBytes(src); err != nil {
+				return nil, errors.WithMessage(errInvalidToken, err.Error())
+			}
+			c.elementIDs = append(c.elementIDs, string(b))
+		}
+		t.cursors[string(node)] = c
+	}
+	return t, nil
+}
+
+// Marshal encodes the token. An empty token is returned if there is no cursor.
+func (t *ContinuationToken) Marshal() ([]byte, error) {
+	if t == nil || len(t.cursors) == 0 {
+		return nil, nil
+	}
+	dst := []byte{continuationTokenVersion}
+	dst = encoding.EncodeBytes(dst, []byte(t.indexRuleName))
+	if t.desc {
+		dst = append(dst, 1)
+	} else {
+		dst = append(dst, 0)
+	}
+	nodes := make([]string, 0, len(t.cursors))
+	for n := range t.cursors {
+		nodes = append(nodes, n)
+	}
+	slices.Sort(nodes)
+	dst = encoding.VarUint64ToBytes(dst, uint64(len(nodes)))
+	for _, n := range nodes {
+		c := t.cursors[n]
+		dst = encoding.EncodeBytes(dst, []byte(n))
+		b, err := proto.Marshal(c.sortValue)
+		if err != nil {
+			return nil, err
+		}
+		dst = encoding.EncodeBytes(dst, b)
+		dst = encoding.VarUint64ToBytes(dst, uint64(len(c.elementIDs)))
+		for _, id := range c.elementIDs {
+			dst = encoding.EncodeBytes(dst, []byte(id))
+		}
+	}
+	return dst, nil
+}
+
+// Cursor returns the position of the node. It returns nil if the node has never been visited.
+func (t *ContinuationToken) Cursor(node string) *Cursor {
+	if t == nil {
+		return nil
+	}
+	return t.cursors[node]
+}
+
+func newContinuationToken(orderBy *modelv1.QueryOrder) *ContinuationToken {
+	return &ContinuationToken{
+		indexRuleName: orderBy.GetIndexRuleName(),
+		desc:          orderBy.GetSort() == modelv1.Sort_SORT_DESC,
+		cursors:       make(map[string]*Cursor),
+	}
+}
+
+// advance moves the cursor forward past the returned elements.
+func (c *Cursor) advance(elements []*streamv1.Element, sortValue func(*streamv1.Element) (*modelv1.TagValue, error)) (*Cursor, error) {
+	if len(elements) == 0 {
+		return c, nil
+	}
+	last, err := sortValue(elements[len(elements)-1])
+	if err != nil {
+		return nil, err
+	}
+	next := &Cursor{sortValue: last}
+	if c != nil && proto.Equal(c.sortValue, last) {
+		next.elementIDs = append(next.elementIDs, c.elementIDs...)
+	}
+	for i := len(elements) - 1; i >= 0; i-- {
+		v, err := sortValue(elements[i])
+		if err != nil {
+			return nil, err
+		}
+		if !proto.Equal(v, last) {
+			break
+		}
+		next.elementIDs = append(next.elementIDs, elements[i].ElementId)
+	}
+	return next, nil
+}
+
+// skip checks whether the element has been returned in the previous pages.
+func (c *Cursor) skip(sortValue *modelv1.TagValue, elementID string) bool {
+	return proto.Equal(c.sortValue, sortValue) && slices.Contains(c.elementIDs, elementID)
+}
+
+// seek narrows the query to start from the cursor.
+// The elements with the cursor's sort value are still selected, which have to be skipped by their ids.
+func (c *Cursor) seek(criteria *streamv1.QueryRequest, s logical.Schema) (*streamv1.QueryRequest, error) {
+	req := proto.Clone(criteria).(*streamv1.QueryRequest)
+	desc := criteria.GetOrderBy().GetSort() == modelv1.Sort_SORT_DESC
+	if criteria.GetOrderBy().GetIndexRuleName() == "" {
+		ts := timestamppb.New(time.Unix(0, c.sortValue.GetInt().GetValue()))
+		if req.TimeRange == nil {
+			req.TimeRange = &modelv1.TimeRange{}
+		}
+		if desc {
+			if req.TimeRange.End == nil || ts.AsTime().Before(req.TimeRange.End.AsTime()) {
+				req.TimeRange.End = ts
+			}
+		} else if req.TimeRange.Begin == nil || ts.AsTime().After(req.TimeRange.Begin.AsTime()) {
+			req.TimeRange.Begin = ts
+		}
+		return req, nil
+	}
+	tagName, err := sortTagName(criteria.GetOrderBy(), s)
+	if err != nil {
+		return nil, err
+	}
+	op := modelv1.Condition_BINARY_OP_GE
+	if desc {
+		op = modelv1.Condition_BINARY_OP_LE
+	}
+	cond := &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Condition{
+			Condition: &modelv1.Condition{
+				Name:  tagName,
+				Op:    op,
+				Value: c.sortValue,
+			},
+		},
+	}
+	if req.Criteria == nil {
+		req.Criteria = cond
+		return req, nil
+	}
+	req.Criteria = &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Le{
+			Le: &modelv1.LogicalExpression{
+				Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+				Left:  cond,
+				Right: req.Criteria,
+			},
+		},
+	}
+	return req, nil
+}
+
+func sortTagName(orderBy *modelv1.QueryOrder, s logical.Schema) (string, error) {
+	ok, indexRule := s.IndexRuleDefined(orderBy.GetIndexRuleName())
+	if !ok {
+		return "", fmt.Errorf("index rule %s not found", orderBy.GetIndexRuleName())
+	}
+	if len(indexRule.Tags) != 1 {
+		return "", fmt.Errorf("index rule %s should have only one tag", orderBy.GetIndexRuleName())
+	}
+	return indexRule.Tags[0], nil
+}
+
+// sortValueFunc returns a function to extract the sort value from an element of the projected schema.
+func sortValueFunc(orderBy *modelv1.QueryOrder, s logical.Schema) (func(*streamv1.Element) (*modelv1.TagValue, error), error) {
+	if orderBy.GetIndexRuleName() == "" {
+		return func(e *streamv1.Element) (*modelv1.TagValue, error) {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: e.Timestamp.AsTime().UnixNano()}}}, nil
+		}, nil
+	}
+	tagName, err := sortTagName(orderBy, s)
+	if err != nil {
+		return nil, err
+	}
+	tagSpec := s.FindTagSpecByName(tagName)
+	if tagSpec == nil {
+		return nil, errors.WithMessage(errSortTagNotProjected, tagName)
+	}
+	return func(e *streamv1.Element) (*modelv1.TagValue, error) {
+		if tagSpec.TagFamilyIdx >= len(e.TagFamilies) || tagSpec.TagIdx >= len(e.TagFamilies[tagSpec.TagFamilyIdx].Tags) {
+			return nil, fmt.Errorf("the sort tag %s is absent in the element %s", tagName, e.ElementId)
+		}
+		return e.TagFamilies[tagSpec.TagFamilyIdx].Tags[tagSpec.TagIdx].Value, nil
+	}, nil
+}
+
+// NextContinuationToken builds the token of the next page returned by a data node.
+// The token is empty if the page isn't full, which means there are no more elements,
+// or the elements are sorted by the sort keys, which don't support the continuation token.
+func NextContinuationToken(node string, criteria *streamv1.QueryRequest, s logical.Schema,
+	previous *ContinuationToken, elements []*streamv1.Element,
+) ([]byte, error) {
+	limit := criteria.GetLimit()
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if len(elements) < int(limit) || len(criteria.GetSortKeys()) > 0 {
+		return nil, nil
+	}
+	if len(criteria.GetProjection().GetTagFamilies()) > 0 {
+		projTagsRefs, err := s.CreateTagRef(logical.ToTags(criteria.GetProjection())...)
+		if err != nil {
+			return nil, err
+		}
+		s = s.ProjTags(projTagsRefs...)
+	}
+	sortValue, err := sortValueFunc(criteria.GetOrderBy(), s)
+	if errors.Is(err, errSortTagNotProjected) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := previous.Cursor(node).advance(elements, sortValue)
+	if err != nil {
+		return nil, err
+	}
+	t := newContinuationToken(criteria.GetOrderBy())
+	t.cursors[node] = c
+	return t.Marshal()
+}
+
+var (
+	_ logical.Plan              = (*cursorSkipPlan)(nil)
+	_ executor.StreamExecutable = (*cursorSkipPlan)(nil)
+)
+
+// cursorSkipPlan drops the elements which have been returned in the previous pages.
+type cursorSkipPlan struct {
+	s         logical.Schema
+	parent    logical.Plan
+	cursor    *Cursor
+	sortValue func(*streamv1.Element) (*modelv1.TagValue, error)
+}
+
+func (c *cursorSkipPlan) Close() {
+	c.parent.(executor.StreamExecutable).Close()
+}
+
+func (c *cursorSkipPlan) Execute(ec context.Context) ([]*streamv1.Element, error) {
+	var result []*streamv1.Element
+	for {
+		elements, err := c.parent.(executor.StreamExecutable).Execute(ec)
+		if err != nil {
+			return nil, err
+		}
+		if len(elements) == 0 {
+			break
+		}
+		for _, e := range elements {
+			v, err := c.sortValue(e)
+			if err != nil {
+				return nil, err
+			}
+			if !c.cursor.skip(v, e.ElementId) {
+				result = append(result, e)
+			}
+		}
+		if len(result) > 0 {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (c *cursorSkipPlan) String() string {
+	return fmt.Sprintf("%s cursor-skip:%d", c.parent, len(c.cursor.elementIDs))
+}
+
+func (c *cursorSkipPlan) Children() []logical.Plan {
+	return []logical.Plan{c.parent}
+}
+
+func (c *cursorSkipPlan) Schema() logical.Schema {
+	return c.s
+}
+
+var _ logical.UnresolvedPlan = (*unresolvedCursorSkip)(nil)
+
+type unresolvedCursorSkip struct {
+	input   logical.UnresolvedPlan
+	cursor  *Cursor
+	orderBy *modelv1.QueryOrder
+}
+
+func (u *unresolvedCursorSkip) Analyze(s logical.Schema) (logical.Plan, error) {
+	input, err := u.input.Analyze(s)
+	if err != nil {
+		return nil, err
+	}
+	sortValue, err := sortValueFunc(u.orderBy, input.Schema())
+	if err != nil {
+		return nil, err
+	}
+	return &cursorSkipPlan{
+		s:         input.Schema(),
+		parent:    input,
+		cursor:    u.cursor,
+		sortValue: sortValue,
+	}, nil
+}
@@ -0,0 +1,98 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func intValue(v int64) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}
+}
+
+func timeSortValue(e *streamv1.Element) (*modelv1.TagValue, error) {
+	return intValue(e.Timestamp.AsTime().UnixNano()), nil
+}
+
+func newTestElement(id string, ts int64) *streamv1.Element {
+	return &streamv1.Element{ElementId: id, Timestamp: timestamppb.New(time.Unix(0, ts))}
+}
+
+func TestContinuationTokenRoundTrip(t *testing.T) {
+	orderBy := &modelv1.QueryOrder{IndexRuleName: "duration", Sort: modelv1.Sort_SORT_DESC}
+	token := newContinuationToken(orderBy)
+	token.cursors["node-1"] = &Cursor{sortValue: intValue(100), elementIDs: []string{"a", "b"}}
+	token.cursors["node-2"] = &Cursor{sortValue: intValue(50)}
+	data, err := token.Marshal()
+	require.NoError(t, err)
+
+	parsed, err := ParseContinuationToken(data, orderBy)
+	require.NoError(t, err)
+	require.Len(t, parsed.cursors, 2)
+	assert.True(t, proto.Equal(intValue(100), parsed.Cursor("node-1").sortValue))
+	assert.Equal(t, []string{"a", "b"}, parsed.Cursor("node-1").elementIDs)
+	assert.True(t, proto.Equal(intValue(50), parsed.Cursor("node-2").sortValue))
+	assert.Empty(t, parsed.Cursor("node-2").elementIDs)
+	assert.Nil(t, parsed.Cursor("node-3"))
+
+	_, err = ParseContinuationToken(data, &modelv1.QueryOrder{IndexRuleName: "duration", Sort: modelv1.Sort_SORT_ASC})
+	assert.ErrorIs(t, err, errTokenMismatch)
+	_, err = ParseContinuationToken([]byte{0xff}, orderBy)
+	assert.ErrorIs(t, err, errInvalidToken)
+
+	parsed, err = ParseContinuationToken(nil, orderBy)
+	require.NoError(t, err)
+	assert.Nil(t, parsed)
+	data, err = newContinuationToken(orderBy).Marshal()
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestCursorAdvance(t *testing.T) {
+	var c *Cursor
+	c, err := c.advance([]*streamv1.Element{
+		newTestElement("a", 1),
+		newTestElement("b", 2),
+		newTestElement("c", 2),
+	}, timeSortValue)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(intValue(2), c.sortValue))
+	assert.ElementsMatch(t, []string{"b", "c"}, c.elementIDs)
+	assert.True(t, c.skip(intValue(2), "b"))
+	assert.False(t, c.skip(intValue(2), "d"))
+	assert.False(t, c.skip(intValue(3), "b"))
+
+	// The ids are accumulated while the page ends with the same sort value.
+	c, err = c.advance([]*streamv1.Element{newTestElement("d", 2)}, timeSortValue)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"b", "c", "d"}, c.elementIDs)
+
+	c, err = c.advance([]*streamv1.Element{newTestElement("e", 3)}, timeSortValue)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(intValue(3), c.sortValue))
+	assert.Equal(t, []string{"e"}, c.elementIDs)
+}
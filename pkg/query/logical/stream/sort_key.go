@@ -0,0 +1,273 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"cmp"
+	"container/heap"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+var (
+	errSortKeysConflict     = errors.New("sort_keys conflict with order_by and continuation_token")
+	errInvalidSortKey       = errors.New("invalid sort key")
+	errSortKeyNotProjected  = errors.New("the sort key's tag should be projected")
+	errDuplicatedSortKeyTag = errors.New("duplicated sort key tag")
+)
+
+// sortKey refers to a tag of the projected schema to sort the elements by.
+type sortKey struct {
+	// indexRule backs the sorting if the key leads the others. It's nil if the tag isn't sortable by the index.
+	indexRule *databasev1.IndexRule
+	name      string
+	spec      logical.TagSpec
+	desc      bool
+}
+
+// sortKeys compares the elements by the keys in turn.
+type sortKeys []sortKey
+
+// parseSortKeys resolves the sort keys against the projected schema.
+func parseSortKeys(sks []*modelv1.SortKey, s logical.Schema) (sortKeys, error) {
+	keys := make(sortKeys, 0, len(sks))
+	for _, k := range sks {
+		name := k.GetTagName()
+		if name == "" {
+			return nil, errors.WithMessage(errInvalidSortKey, "empty tag name")
+		}
+		for _, existing := range keys {
+			if existing.name == name {
+				return nil, errors.WithMessage(errDuplicatedSortKeyTag, name)
+			}
+		}
+		spec := s.FindTagSpecByName(name)
+		if spec == nil {
+			return nil, errors.WithMessage(errSortKeyNotProjected, name)
+		}
+		switch spec.Spec.GetType() {
+		case databasev1.TagType_TAG_TYPE_STRING_ARRAY, databasev1.TagType_TAG_TYPE_INT_ARRAY:
+			return nil, errors.WithMessagef(errInvalidSortKey, "tag %s is an array", name)
+		}
+		key := sortKey{
+			name: name,
+			spec: *spec,
+			desc: k.GetSort() == modelv1.Sort_SORT_DESC,
+		}
+		if ok, rule := s.IndexDefined(name); ok && len(rule.GetTags()) == 1 && !rule.GetNoSort() && rule.GetAnalyzer() == "" {
+			key.indexRule = rule
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (sk sortKeys) String() string {
+	ss := make([]string, len(sk))
+	for i, k := range sk {
+		if k.desc {
+			ss[i] = k.name + " DESC"
+		} else {
+			ss[i] = k.name + " ASC"
+		}
+	}
+	return strings.Join(ss, ", ")
+}
+
+// leadingIndex returns the index rule backing the leading key, or nil if the elements should be sorted in memory.
+func (sk sortKeys) leadingIndex() *databasev1.IndexRule {
+	if len(sk) == 0 {
+		return nil
+	}
+	return sk[0].indexRule
+}
+
+// leadingSort returns the order of the leading key.
+func (sk sortKeys) leadingSort() modelv1.Sort {
+	if sk[0].desc {
+		return modelv1.Sort_SORT_DESC
+	}
+	return modelv1.Sort_SORT_ASC
+}
+
+func (sk sortKeys) value(e *streamv1.Element, i int) *modelv1.TagValue {
+	spec := sk[i].spec
+	if spec.TagFamilyIdx >= len(e.TagFamilies) || spec.TagIdx >= len(e.TagFamilies[spec.TagFamilyIdx].Tags) {
+		return nil
+	}
+	return e.TagFamilies[spec.TagFamilyIdx].Tags[spec.TagIdx].Value
+}
+
+// compareLeading compares the elements by the leading key.
+func (sk sortKeys) compareLeading(a, b *streamv1.Element) int {
+	c := compareSortValue(sk.value(a, 0), sk.value(b, 0))
+	if sk[0].desc {
+		return -c
+	}
+	return c
+}
+
+// compare compares the elements by the keys in turn. The ties of all keys are broken by the element ids,
+// which keep the order stable across the data nodes.
+func (sk sortKeys) compare(a, b *streamv1.Element) int {
+	for i := range sk {
+		c := compareSortValue(sk.value(a, i), sk.value(b, i))
+		if sk[i].desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.ElementId, b.ElementId)
+}
+
+// compareSortValue compares two values of a sort tag. A null value is less than any other one.
+func compareSortValue(a, b *modelv1.TagValue) int {
+	aNull, bNull := isNullTagValue(a), isNullTagValue(b)
+	switch {
+	case aNull && bNull:
+		return 0
+	case aNull:
+		return -1
+	case bNull:
+		return 1
+	}
+	if ai, ok := a.GetValue().(*modelv1.TagValue_Int); ok {
+		if bi, ok := b.GetValue().(*modelv1.TagValue_Int); ok {
+			return cmp.Compare(ai.Int.GetValue(), bi.Int.GetValue())
+		}
+	}
+	at, bt := pbv1.MustTagValueToValueType(a), pbv1.MustTagValueToValueType(b)
+	if at != bt {
+		return cmp.Compare(at, bt)
+	}
+	return pbv1.MustCompareTagValue(a, b)
+}
+
+func isNullTagValue(v *modelv1.TagValue) bool {
+	if v == nil {
+		return true
+	}
+	_, ok := v.GetValue().(*modelv1.TagValue_Null)
+	return ok || v.GetValue() == nil
+}
+
+// topKElements keeps the first k elements in the order of the sort keys.
+// The root of the heap is the last one of them, which is evicted by a preceding element.
+type topKElements struct {
+	keys     sortKeys
+	elements []*streamv1.Element
+	k        int
+}
+
+func newTopKElements(keys sortKeys, k int) *topKElements {
+	return &topKElements{keys: keys, k: k}
+}
+
+func (t *topKElements) Len() int { return len(t.elements) }
+
+func (t *topKElements) Less(i, j int) bool {
+	return t.keys.compare(t.elements[i], t.elements[j]) > 0
+}
+
+func (t *topKElements) Swap(i, j int) { t.elements[i], t.elements[j] = t.elements[j], t.elements[i] }
+
+func (t *topKElements) Push(x any) { t.elements = append(t.elements, x.(*streamv1.Element)) }
+
+func (t *topKElements) Pop() any {
+	n := len(t.elements)
+	e := t.elements[n-1]
+	t.elements = t.elements[:n-1]
+	return e
+}
+
+func (t *topKElements) add(e *streamv1.Element) {
+	if len(t.elements) < t.k {
+		heap.Push(t, e)
+		return
+	}
+	if t.keys.compare(e, t.elements[0]) < 0 {
+		t.elements[0] = e
+		heap.Fix(t, 0)
+	}
+}
+
+// sorted returns the kept elements in the order of the sort keys.
+func (t *topKElements) sorted() []*streamv1.Element {
+	result := make([]*streamv1.Element, len(t.elements))
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(t).(*streamv1.Element)
+	}
+	return result
+}
+
+// mergeElements merges the lists of elements sorted by the keys.
+type mergeElements struct {
+	keys  sortKeys
+	lists [][]*streamv1.Element
+}
+
+func (m *mergeElements) Len() int { return len(m.lists) }
+
+func (m *mergeElements) Less(i, j int) bool {
+	return m.keys.compare(m.lists[i][0], m.lists[j][0]) < 0
+}
+
+func (m *mergeElements) Swap(i, j int) { m.lists[i], m.lists[j] = m.lists[j], m.lists[i] }
+
+func (m *mergeElements) Push(x any) { m.lists = append(m.lists, x.([]*streamv1.Element)) }
+
+func (m *mergeElements) Pop() any {
+	n := len(m.lists)
+	l := m.lists[n-1]
+	m.lists = m.lists[:n-1]
+	return l
+}
+
+// mergeSortedElements merges the sorted lists responded by the data nodes into one.
+func mergeSortedElements(keys sortKeys, lists [][]*streamv1.Element) []*streamv1.Element {
+	m := &mergeElements{keys: keys}
+	total := 0
+	for _, l := range lists {
+		if len(l) > 0 {
+			m.lists = append(m.lists, l)
+			total += len(l)
+		}
+	}
+	heap.Init(m)
+	result := make([]*streamv1.Element, 0, total)
+	for m.Len() > 0 {
+		l := m.lists[0]
+		result = append(result, l[0])
+		if len(l) == 1 {
+			heap.Pop(m)
+			continue
+		}
+		m.lists[0] = l[1:]
+		heap.Fix(m, 0)
+	}
+	return result
+}
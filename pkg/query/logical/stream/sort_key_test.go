@@ -0,0 +1,178 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+func strValue(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+func sortKeysSchema(t *testing.T) logical.Schema {
+	s, err := BuildSchema(&databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "sw", Group: "default"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "status", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+				{Name: "endpoints", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+			},
+		}},
+	}, []*databasev1.IndexRule{{
+		Metadata: &commonv1.Metadata{Name: "duration", Group: "default"},
+		Tags:     []string{"duration"},
+		Type:     databasev1.IndexRule_TYPE_INVERTED,
+	}})
+	require.NoError(t, err)
+	refs, err := s.CreateTagRef(logical.NewTags("searchable", "status", "duration", "endpoints"))
+	require.NoError(t, err)
+	return s.ProjTags(refs...)
+}
+
+func sortKeyElement(id, status string, duration int64) *streamv1.Element {
+	return &streamv1.Element{
+		ElementId: id,
+		TagFamilies: []*modelv1.TagFamily{{
+			Name: "searchable",
+			Tags: []*modelv1.Tag{
+				{Key: "status", Value: strValue(status)},
+				{Key: "duration", Value: intValue(duration)},
+				{Key: "endpoints", Value: pbv1.NullTagValue},
+			},
+		}},
+	}
+}
+
+func elementIDs(elements []*streamv1.Element) []string {
+	ids := make([]string, len(elements))
+	for i, e := range elements {
+		ids[i] = e.ElementId
+	}
+	return ids
+}
+
+func TestParseSortKeys(t *testing.T) {
+	s := sortKeysSchema(t)
+	keys, err := parseSortKeys([]*modelv1.SortKey{
+		{TagName: "duration", Sort: modelv1.Sort_SORT_DESC},
+		{TagName: "status"},
+	}, s)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.NotNil(t, keys.leadingIndex())
+	assert.Equal(t, modelv1.Sort_SORT_DESC, keys.leadingSort())
+	assert.Nil(t, keys[1].indexRule)
+	assert.Equal(t, "duration DESC, status ASC", keys.String())
+
+	keys, err = parseSortKeys([]*modelv1.SortKey{{TagName: "status"}, {TagName: "duration"}}, s)
+	require.NoError(t, err)
+	assert.Nil(t, keys.leadingIndex())
+
+	_, err = parseSortKeys([]*modelv1.SortKey{{TagName: "status"}, {TagName: "status"}}, s)
+	assert.ErrorIs(t, err, errDuplicatedSortKeyTag)
+	_, err = parseSortKeys([]*modelv1.SortKey{{TagName: "absent"}}, s)
+	assert.ErrorIs(t, err, errSortKeyNotProjected)
+	_, err = parseSortKeys([]*modelv1.SortKey{{TagName: "endpoints"}}, s)
+	assert.ErrorIs(t, err, errInvalidSortKey)
+	_, err = parseSortKeys([]*modelv1.SortKey{{}}, s)
+	assert.ErrorIs(t, err, errInvalidSortKey)
+}
+
+func TestSortKeysCompare(t *testing.T) {
+	keys, err := parseSortKeys([]*modelv1.SortKey{
+		{TagName: "status"},
+		{TagName: "duration", Sort: modelv1.Sort_SORT_DESC},
+	}, sortKeysSchema(t))
+	require.NoError(t, err)
+	elements := []*streamv1.Element{
+		sortKeyElement("a", "500", 10),
+		sortKeyElement("b", "200", 10),
+		sortKeyElement("c", "200", 30),
+		sortKeyElement("d", "500", 20),
+		sortKeyElement("e", "200", 30),
+	}
+	slices.SortFunc(elements, keys.compare)
+	assert.Equal(t, []string{"c", "e", "b", "d", "a"}, elementIDs(elements))
+
+	assert.Negative(t, compareSortValue(pbv1.NullTagValue, strValue("")))
+	assert.Negative(t, compareSortValue(nil, intValue(-1)))
+	assert.Zero(t, compareSortValue(nil, pbv1.NullTagValue))
+	assert.Negative(t, compareSortValue(intValue(-1<<62), intValue(1<<62)))
+}
+
+func TestTopKElements(t *testing.T) {
+	keys, err := parseSortKeys([]*modelv1.SortKey{
+		{TagName: "status", Sort: modelv1.Sort_SORT_DESC},
+		{TagName: "duration"},
+	}, sortKeysSchema(t))
+	require.NoError(t, err)
+	r := rand.New(rand.NewSource(1))
+	var all []*streamv1.Element
+	top := newTopKElements(keys, 10)
+	for i := 0; i < 200; i++ {
+		e := sortKeyElement(fmt.Sprintf("%03d", i), fmt.Sprintf("%d", r.Intn(5)), int64(r.Intn(20)))
+		all = append(all, e)
+		top.add(e)
+	}
+	slices.SortFunc(all, keys.compare)
+	assert.Equal(t, elementIDs(all[:10]), elementIDs(top.sorted()))
+
+	top = newTopKElements(keys, 10)
+	for _, e := range all[:3] {
+		top.add(e)
+	}
+	assert.Equal(t, elementIDs(all[:3]), elementIDs(top.sorted()))
+}
+
+func TestMergeSortedElements(t *testing.T) {
+	keys, err := parseSortKeys([]*modelv1.SortKey{
+		{TagName: "status"},
+		{TagName: "duration", Sort: modelv1.Sort_SORT_DESC},
+	}, sortKeysSchema(t))
+	require.NoError(t, err)
+	r := rand.New(rand.NewSource(1))
+	lists := make([][]*streamv1.Element, 3)
+	var all []*streamv1.Element
+	for i := 0; i < 60; i++ {
+		e := sortKeyElement(fmt.Sprintf("%03d", i), fmt.Sprintf("%d", r.Intn(3)), int64(r.Intn(10)))
+		lists[i%3] = append(lists[i%3], e)
+		all = append(all, e)
+	}
+	for _, l := range lists {
+		slices.SortFunc(l, keys.compare)
+	}
+	slices.SortFunc(all, keys.compare)
+	assert.Equal(t, elementIDs(all), elementIDs(mergeSortedElements(keys, append(lists, nil))))
+	assert.Empty(t, mergeSortedElements(keys, nil))
+}
@@ -47,16 +47,35 @@ func BuildSchema(sm *databasev1.Stream, indexRules []*databasev1.IndexRule) (log
 }
 
 // Analyze converts logical expressions to executable operation tree represented by Plan.
-func Analyze(_ context.Context, criteria *streamv1.QueryRequest, metadata *commonv1.Metadata, s logical.Schema) (logical.Plan, error) {
+// If the cursor is present, the query resumes from it and the offset is ignored.
+func Analyze(_ context.Context, criteria *streamv1.QueryRequest, metadata *commonv1.Metadata, s logical.Schema, cursor *Cursor) (logical.Plan, error) {
+	if len(criteria.GetSortKeys()) > 0 && (criteria.GetOrderBy() != nil || cursor != nil) {
+		return nil, errSortKeysConflict
+	}
+	offset := criteria.GetOffset()
+	if cursor != nil {
+		var err error
+		if criteria, err = cursor.seek(criteria, s); err != nil {
+			return nil, err
+		}
+		offset = 0
+	}
 	// parse fields
 	plan := parseTags(criteria, metadata)
+	if cursor != nil {
+		plan = &unresolvedCursorSkip{
+			input:   plan,
+			cursor:  cursor,
+			orderBy: criteria.GetOrderBy(),
+		}
+	}
 
 	// parse limit
 	limitParameter := criteria.GetLimit()
 	if limitParameter == 0 {
 		limitParameter = defaultLimit
 	}
-	plan = newLimit(plan, criteria.GetOffset(), limitParameter)
+	plan = newLimit(plan, offset, limitParameter)
 
 	p, err := plan.Analyze(s)
 	if err != nil {
@@ -64,7 +83,7 @@ func Analyze(_ context.Context, criteria *streamv1.QueryRequest, metadata *commo
 	}
 	rules := []logical.OptimizeRule{
 		logical.NewPushDownOrder(criteria.OrderBy),
-		logical.NewPushDownMaxSize(int(limitParameter + criteria.GetOffset())),
+		logical.NewPushDownMaxSize(int(limitParameter + offset)),
 	}
 	if err := logical.ApplyRules(p, rules...); err != nil {
 		return nil, err
@@ -73,7 +92,15 @@ func Analyze(_ context.Context, criteria *streamv1.QueryRequest, metadata *commo
 }
 
 // DistributedAnalyze converts logical expressions to executable operation tree represented by Plan.
+// The root plan implements Paginator to build the continuation token of the next page.
 func DistributedAnalyze(criteria *streamv1.QueryRequest, s logical.Schema) (logical.Plan, error) {
+	if len(criteria.GetSortKeys()) > 0 && (criteria.GetOrderBy() != nil || len(criteria.GetContinuationToken()) > 0) {
+		return nil, errSortKeysConflict
+	}
+	token, err := ParseContinuationToken(criteria.ContinuationToken, criteria.OrderBy)
+	if err != nil {
+		return nil, err
+	}
 	// parse fields
 	plan := newUnresolvedDistributed(criteria)
 
@@ -82,7 +109,7 @@ func DistributedAnalyze(criteria *streamv1.QueryRequest, s logical.Schema) (logi
 	if limitParameter == 0 {
 		limitParameter = defaultLimit
 	}
-	plan = newDistributedLimit(plan, criteria.Offset, limitParameter)
+	plan = newDistributedLimit(plan, criteria.Offset, limitParameter, criteria.OrderBy, token)
 	return plan.Analyze(s)
 }
 
@@ -183,5 +210,5 @@ func newLimit(input logical.UnresolvedPlan, offset, num uint32) logical.Unresolv
 func parseTags(criteria *streamv1.QueryRequest, metadata *commonv1.Metadata) logical.UnresolvedPlan {
 	timeRange := criteria.GetTimeRange()
 	return tagFilter(timeRange.GetBegin().AsTime(), timeRange.GetEnd().AsTime(), metadata,
-		criteria.Criteria, logical.ToTags(criteria.GetProjection()))
+		criteria.Criteria, logical.ToTags(criteria.GetProjection()), criteria.GetSortKeys())
 }
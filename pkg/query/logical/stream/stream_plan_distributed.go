@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/pkg/errors"
 	"go.uber.org/multierr"
 	"google.golang.org/protobuf/proto"
 
@@ -70,12 +71,31 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 		limit = defaultLimit
 	}
 	temp := &streamv1.QueryRequest{
-		Projection: ud.originalQuery.Projection,
-		Name:       ud.originalQuery.Name,
-		Groups:     ud.originalQuery.Groups,
-		Criteria:   ud.originalQuery.Criteria,
-		Limit:      limit + ud.originalQuery.Offset,
-		OrderBy:    ud.originalQuery.OrderBy,
+		Projection:        ud.originalQuery.Projection,
+		Name:              ud.originalQuery.Name,
+		Groups:            ud.originalQuery.Groups,
+		Criteria:          ud.originalQuery.Criteria,
+		Limit:             limit + ud.originalQuery.Offset,
+		OrderBy:           ud.originalQuery.OrderBy,
+		ContinuationToken: ud.originalQuery.ContinuationToken,
+		SortKeys:          ud.originalQuery.SortKeys,
+	}
+	if len(ud.originalQuery.ContinuationToken) > 0 {
+		temp.Limit = limit
+	}
+	if len(ud.originalQuery.SortKeys) > 0 {
+		if len(projectionTags) == 0 {
+			return nil, errors.WithMessage(errSortKeyNotProjected, ud.originalQuery.SortKeys[0].GetTagName())
+		}
+		keys, err := parseSortKeys(ud.originalQuery.SortKeys, s)
+		if err != nil {
+			return nil, err
+		}
+		return &distributedPlan{
+			queryTemplate: temp,
+			s:             s,
+			sortKeys:      keys,
+		}, nil
 	}
 	if ud.originalQuery.OrderBy == nil {
 		return &distributedPlan{
@@ -123,6 +143,10 @@ var _ executor.StreamExecutable = (*distributedPlan)(nil)
 type distributedPlan struct {
 	s              logical.Schema
 	queryTemplate  *streamv1.QueryRequest
+	elementNodes   map[*streamv1.Element]string
+	replicaNodes   map[*streamv1.Element][]string
+	respondedNodes []string
+	sortKeys       sortKeys
 	sortTagSpec    logical.TagSpec
 	sortByTime     bool
 	desc           bool
@@ -157,8 +181,18 @@ func (t *distributedPlan) Execute(ctx context.Context) (ee []*streamv1.Element,
 	if err != nil {
 		return nil, err
 	}
+	// Abandon the responses of the data nodes if the query is canceled, e.g. the client disconnects,
+	// or the responses exceed the memory budget.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	bus.CancelOnDone(ctx, ff)
+	budget := executor.MemoryBudgetFromContext(ctx)
+	stats := executor.QueryStatsFromContext(ctx)
 	var allErr error
 	var see []sort.Iterator[*comparableElement]
+	var lists [][]*streamv1.Element
+	t.elementNodes = make(map[*streamv1.Element]string)
+	t.respondedNodes = t.respondedNodes[:0]
 	for _, f := range ff {
 		if m, getErr := f.Get(); getErr != nil {
 			allErr = multierr.Append(allErr, getErr)
@@ -171,16 +205,54 @@ func (t *distributedPlan) Execute(ctx context.Context) (ee []*streamv1.Element,
 			if span != nil {
 				span.AddSubTrace(resp.Trace)
 			}
+			if errAcquire := budget.Acquire(uint64(proto.Size(resp))); errAcquire != nil {
+				return nil, errAcquire
+			}
+			stats.AddScanned(uint64(len(resp.Elements)))
+			t.respondedNodes = append(t.respondedNodes, m.Node())
+			for _, e := range resp.Elements {
+				t.elementNodes[e] = m.Node()
+			}
+			if len(t.sortKeys) > 0 {
+				lists = append(lists, resp.Elements)
+				continue
+			}
 			see = append(see,
 				newSortableElements(resp.Elements, t.sortByTime, t.sortTagSpec))
 		}
 	}
+	if len(t.sortKeys) > 0 {
+		return t.deduplicate(mergeSortedElements(t.sortKeys, lists)), allErr
+	}
 	iter := sort.NewItemIter[*comparableElement](see, t.desc)
 	var result []*streamv1.Element
 	for iter.Next() {
 		result = append(result, iter.Val().Element)
 	}
-	return result, allErr
+	return t.deduplicate(result), allErr
+}
+
+type elementKey struct {
+	id        string
+	timestamp int64
+}
+
+// deduplicate drops the copies of an element responded by the replicas of a shard.
+// The nodes of the dropped copies are recorded so that their cursors move past the element as well.
+func (t *distributedPlan) deduplicate(elements []*streamv1.Element) []*streamv1.Element {
+	t.replicaNodes = make(map[*streamv1.Element][]string)
+	seen := make(map[elementKey]*streamv1.Element, len(elements))
+	result := elements[:0]
+	for _, e := range elements {
+		k := elementKey{id: e.ElementId, timestamp: e.GetTimestamp().AsTime().UnixNano()}
+		if kept, ok := seen[k]; ok {
+			t.replicaNodes[kept] = append(t.replicaNodes[kept], t.elementNodes[e])
+			continue
+		}
+		seen[k] = e
+		result = append(result, e)
+	}
+	return result
 }
 
 func (t *distributedPlan) String() string {
@@ -273,12 +345,25 @@ func (s *sortableElements) iter(fn func(*streamv1.Element) (*comparableElement,
 	return s.index <= len(s.elements)
 }
 
-var _ executor.StreamExecutable = (*distributedLimit)(nil)
+var (
+	_ executor.StreamExecutable = (*distributedLimit)(nil)
+	_ Paginator                 = (*distributedLimit)(nil)
+)
+
+// Paginator is implemented by the plans which support the continuation token.
+type Paginator interface {
+	// ContinuationToken returns the token of the page produced by the last execution.
+	ContinuationToken() ([]byte, error)
+}
 
 type distributedLimit struct {
 	*Parent
-	limit  uint32
-	offset uint32
+	orderBy   *modelv1.QueryOrder
+	token     *ContinuationToken
+	page      []*streamv1.Element
+	limit     uint32
+	offset    uint32
+	exhausted bool
 }
 
 func (l *distributedLimit) Close() {
@@ -292,7 +377,11 @@ func (l *distributedLimit) Execute(ec context.Context) ([]*streamv1.Element, err
 	}
 
 	start := int(l.offset)
+	if l.token != nil {
+		start = 0
+	}
 	if start > len(entities) {
+		l.page, l.exhausted = nil, true
 		return []*streamv1.Element{}, nil
 	}
 
@@ -300,7 +389,61 @@ func (l *distributedLimit) Execute(ec context.Context) ([]*streamv1.Element, err
 	if end > len(entities) {
 		end = len(entities)
 	}
-	return entities[start:end], nil
+	l.page, l.exhausted = entities[start:end], end-start < int(l.limit)
+	return l.page, nil
+}
+
+// ContinuationToken moves the cursors of the data nodes past the elements of the page.
+// A node which contributes nothing to the page resumes from the last element of the page,
+// because all its elements before that have been merged into the previous pages.
+func (l *distributedLimit) ContinuationToken() ([]byte, error) {
+	if l.exhausted || len(l.page) == 0 {
+		return nil, nil
+	}
+	dp, ok := l.Input.(*distributedPlan)
+	if !ok || len(dp.sortKeys) > 0 {
+		return nil, nil
+	}
+	sortValue, err := sortValueFunc(l.orderBy, dp.s)
+	if errors.Is(err, errSortTagNotProjected) && l.token == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	consumed := make(map[string][]*streamv1.Element)
+	for _, e := range l.page {
+		node := dp.elementNodes[e]
+		consumed[node] = append(consumed[node], e)
+		for _, replica := range dp.replicaNodes[e] {
+			consumed[replica] = append(consumed[replica], e)
+		}
+	}
+	last, err := sortValue(l.page[len(l.page)-1])
+	if err != nil {
+		return nil, err
+	}
+	next := newContinuationToken(l.orderBy)
+	if l.token != nil {
+		for node, c := range l.token.cursors {
+			next.cursors[node] = c
+		}
+	}
+	for _, node := range dp.respondedNodes {
+		previous := l.token.Cursor(node)
+		if ee, ok := consumed[node]; ok {
+			c, errAdvance := previous.advance(ee, sortValue)
+			if errAdvance != nil {
+				return nil, errAdvance
+			}
+			next.cursors[node] = c
+			continue
+		}
+		if previous == nil || !proto.Equal(previous.sortValue, last) {
+			next.cursors[node] = &Cursor{sortValue: last}
+		}
+	}
+	return next.Marshal()
 }
 
 func (l *distributedLimit) Analyze(s logical.Schema) (logical.Plan, error) {
@@ -324,12 +467,14 @@ func (l *distributedLimit) Children() []logical.Plan {
 	return []logical.Plan{l.Input}
 }
 
-func newDistributedLimit(input logical.UnresolvedPlan, offset, limit uint32) logical.UnresolvedPlan {
+func newDistributedLimit(input logical.UnresolvedPlan, offset, limit uint32, orderBy *modelv1.QueryOrder, token *ContinuationToken) logical.UnresolvedPlan {
 	return &distributedLimit{
 		Parent: &Parent{
 			UnresolvedInput: input,
 		},
-		offset: offset,
-		limit:  limit,
+		offset:  offset,
+		limit:   limit,
+		orderBy: orderBy,
+		token:   token,
 	}
 }
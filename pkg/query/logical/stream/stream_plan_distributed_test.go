@@ -0,0 +1,60 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+func TestDistributedPlanDeduplicate(t *testing.T) {
+	a1, a2 := newTestElement("a", 1), newTestElement("a", 1)
+	b1, b2 := newTestElement("b", 2), newTestElement("b", 2)
+	c := newTestElement("c", 2)
+	reused := newTestElement("a", 3)
+	dp := &distributedPlan{
+		elementNodes: map[*streamv1.Element]string{
+			a1: "node-1", a2: "node-2", b1: "node-1", b2: "node-2", c: "node-2", reused: "node-1",
+		},
+		respondedNodes: []string{"node-1", "node-2"},
+	}
+	result := dp.deduplicate([]*streamv1.Element{a1, a2, b2, c, b1, reused})
+	assert.Equal(t, []*streamv1.Element{a1, b2, c, reused}, result)
+	assert.Equal(t, []string{"node-2"}, dp.replicaNodes[a1])
+	assert.Equal(t, []string{"node-1"}, dp.replicaNodes[b2])
+
+	// The cursors of both replicas move past the elements of the page.
+	l := &distributedLimit{Parent: &Parent{Input: dp}, orderBy: &modelv1.QueryOrder{}, page: result[:3], limit: 3}
+	data, err := l.ContinuationToken()
+	require.NoError(t, err)
+	token, err := ParseContinuationToken(data, l.orderBy)
+	require.NoError(t, err)
+	for _, node := range dp.respondedNodes {
+		cursor := token.Cursor(node)
+		require.NotNil(t, cursor, node)
+		assert.True(t, proto.Equal(intValue(2), cursor.sortValue), node)
+	}
+	assert.ElementsMatch(t, []string{"b"}, token.Cursor("node-1").elementIDs)
+	assert.ElementsMatch(t, []string{"b", "c"}, token.Cursor("node-2").elementIDs)
+}
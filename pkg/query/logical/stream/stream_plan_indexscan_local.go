@@ -21,6 +21,8 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -50,12 +52,16 @@ type localIndexScan struct {
 	result            model.StreamQueryResult
 	order             *logical.OrderBy
 	metadata          *commonv1.Metadata
+	tagFilter         logical.TagFilter
 	l                 *logger.Logger
 	timeRange         timestamp.TimeRange
 	projectionTagRefs [][]*logical.TagRef
 	projectionTags    []model.TagProjection
+	tagPredicates     []model.TagPredicate
 	entities          [][]*modelv1.TagValue
+	sortKeys          sortKeys
 	maxElementSize    int
+	sorted            bool
 }
 
 func (i *localIndexScan) Close() {
@@ -78,6 +84,9 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 		return nil, ctx.Err()
 	default:
 	}
+	if len(i.sortKeys) > 0 {
+		return i.executeSortKeys(ctx)
+	}
 	if i.result != nil {
 		return BuildElementsFromStreamResult(ctx, i.result)
 	}
@@ -97,6 +106,7 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 		Filter:         i.filter,
 		Order:          orderBy,
 		TagProjection:  i.projectionTags,
+		TagPredicates:  i.tagPredicates,
 		MaxElementSize: i.maxElementSize,
 	}); err != nil {
 		return nil, err
@@ -107,10 +117,107 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 	return BuildElementsFromStreamResult(ctx, i.result)
 }
 
+// executeSortKeys returns the first elements in the order of the sort keys at once.
+// If the leading key is indexed, the elements are loaded in its order until the ones tied with the last returned element are all loaded,
+// then they are sorted by the other keys. Otherwise, all the elements are scanned and a bounded heap keeps the first ones.
+func (i *localIndexScan) executeSortKeys(ctx context.Context) ([]*streamv1.Element, error) {
+	if i.sorted {
+		return nil, nil
+	}
+	i.sorted = true
+	opts := model.StreamQueryOptions{
+		Name:           i.metadata.GetName(),
+		TimeRange:      &i.timeRange,
+		Entities:       i.entities,
+		Filter:         i.filter,
+		TagProjection:  i.projectionTags,
+		TagPredicates:  i.tagPredicates,
+		MaxElementSize: i.maxElementSize,
+	}
+	leading := i.sortKeys.leadingIndex()
+	if leading != nil {
+		opts.Order = &index.OrderBy{
+			Index: leading,
+			Sort:  i.sortKeys.leadingSort(),
+		}
+	} else {
+		opts.MaxElementSize = math.MaxInt
+	}
+	ec := executor.FromStreamExecutionContext(ctx)
+	var err error
+	if i.result, err = ec.Query(ctx, opts); err != nil {
+		return nil, err
+	}
+	if i.result == nil {
+		return nil, nil
+	}
+	k := i.maxElementSize
+	if k <= 0 {
+		k = int(defaultLimit)
+	}
+	if leading == nil {
+		top := newTopKElements(i.sortKeys, k)
+		for {
+			elements, errPull := i.pull(ctx)
+			if errPull != nil {
+				return nil, errPull
+			}
+			if len(elements) == 0 {
+				return top.sorted(), nil
+			}
+			for _, e := range elements {
+				top.add(e)
+			}
+		}
+	}
+	var result []*streamv1.Element
+	for {
+		elements, errPull := i.pull(ctx)
+		if errPull != nil {
+			return nil, errPull
+		}
+		if len(elements) == 0 {
+			break
+		}
+		result = append(result, elements...)
+		if len(result) > k && i.sortKeys.compareLeading(result[k-1], result[len(result)-1]) != 0 {
+			break
+		}
+	}
+	slices.SortFunc(result, i.sortKeys.compare)
+	if len(result) > k {
+		result = result[:k]
+	}
+	return result, nil
+}
+
+// pull returns the next batch of the elements which match the tag filter.
+func (i *localIndexScan) pull(ctx context.Context) ([]*streamv1.Element, error) {
+	for {
+		elements, err := BuildElementsFromStreamResult(ctx, i.result)
+		if err != nil || len(elements) == 0 || i.tagFilter == nil {
+			return elements, err
+		}
+		matched := elements[:0]
+		for _, e := range elements {
+			ok, errMatch := i.tagFilter.Match(logical.TagFamilies(e.TagFamilies), i.Schema())
+			if errMatch != nil {
+				return nil, errMatch
+			}
+			if ok {
+				matched = append(matched, e)
+			}
+		}
+		if len(matched) > 0 {
+			return matched, nil
+		}
+	}
+}
+
 func (i *localIndexScan) String() string {
-	return fmt.Sprintf("IndexScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; projection=%s; orderBy=%s; limit=%d",
+	return fmt.Sprintf("IndexScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; projection=%s; orderBy=%s; sortKeys=%s; limit=%d",
 		i.timeRange.Start.Unix(), i.timeRange.End.Unix(), i.metadata.GetGroup(), i.metadata.GetName(),
-		i.filter, logical.FormatTagRefs(", ", i.projectionTagRefs...), i.order, i.maxElementSize)
+		i.filter, logical.FormatTagRefs(", ", i.projectionTagRefs...), i.order, i.sortKeys, i.maxElementSize)
 }
 
 func (i *localIndexScan) Children() []logical.Plan {
@@ -20,9 +20,13 @@ package stream
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/pkg/errors"
+
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/pkg/index"
@@ -42,6 +46,7 @@ type unresolvedTagFilter struct {
 	metadata       *commonv1.Metadata
 	criteria       *modelv1.Criteria
 	projectionTags [][]*logical.Tag
+	sortKeys       []*modelv1.SortKey
 }
 
 func (uis *unresolvedTagFilter) Analyze(s logical.Schema) (logical.Plan, error) {
@@ -75,26 +80,42 @@ func (uis *unresolvedTagFilter) Analyze(s logical.Schema) (logical.Plan, error)
 		}
 	}
 	ctx.projectionTags = projTags
-	plan := uis.selectIndexScanner(ctx)
+	ctx.tagPredicates = buildTagPredicates(uis.criteria, s, entityDict, projTags)
+	scan := uis.selectIndexScanner(ctx)
+	if len(uis.sortKeys) > 0 {
+		if len(ctx.projTagsRefs) == 0 {
+			return nil, errors.WithMessage(errSortKeyNotProjected, uis.sortKeys[0].GetTagName())
+		}
+		if scan.sortKeys, err = parseSortKeys(uis.sortKeys, s.ProjTags(ctx.projTagsRefs...)); err != nil {
+			return nil, err
+		}
+	}
+	var plan logical.Plan = scan
 	if uis.criteria != nil {
 		tagFilter, errFilter := logical.BuildTagFilter(uis.criteria, entityDict, s, len(ctx.globalConditions) > 1)
 		if errFilter != nil {
 			return nil, errFilter
 		}
 		if tagFilter != logical.DummyFilter {
-			// create tagFilter with a projected view
-			plan = newTagFilter(s.ProjTags(ctx.projTagsRefs...), plan, tagFilter)
+			if len(scan.sortKeys) > 0 {
+				// The scan filters the elements before sorting them, which keeps the top-k elements accurate.
+				scan.tagFilter = tagFilter
+			} else {
+				// create tagFilter with a projected view
+				plan = newTagFilter(s.ProjTags(ctx.projTagsRefs...), plan, tagFilter)
+			}
 		}
 	}
 	return plan, err
 }
 
-func (uis *unresolvedTagFilter) selectIndexScanner(ctx *analyzeContext) logical.Plan {
+func (uis *unresolvedTagFilter) selectIndexScanner(ctx *analyzeContext) *localIndexScan {
 	return &localIndexScan{
 		timeRange:         timestamp.NewInclusiveTimeRange(uis.startTime, uis.endTime),
 		schema:            ctx.s,
 		projectionTagRefs: ctx.projTagsRefs,
 		projectionTags:    ctx.projectionTags,
+		tagPredicates:     ctx.tagPredicates,
 		metadata:          uis.metadata,
 		filter:            ctx.filter,
 		entities:          ctx.entities,
@@ -103,6 +124,7 @@ func (uis *unresolvedTagFilter) selectIndexScanner(ctx *analyzeContext) logical.
 }
 
 func tagFilter(startTime, endTime time.Time, metadata *commonv1.Metadata, criteria *modelv1.Criteria, projection [][]*logical.Tag,
+	sortKeys []*modelv1.SortKey,
 ) logical.UnresolvedPlan {
 	return &unresolvedTagFilter{
 		startTime:      startTime,
@@ -110,6 +132,7 @@ func tagFilter(startTime, endTime time.Time, metadata *commonv1.Metadata, criter
 		metadata:       metadata,
 		criteria:       criteria,
 		projectionTags: projection,
+		sortKeys:       sortKeys,
 	}
 }
 
@@ -118,6 +141,7 @@ type analyzeContext struct {
 	filter           index.Filter
 	entities         [][]*modelv1.TagValue
 	projectionTags   []model.TagProjection
+	tagPredicates    []model.TagPredicate
 	globalConditions []interface{}
 	projTagsRefs     [][]*logical.TagRef
 }
@@ -129,6 +153,55 @@ func newAnalyzerContext(s logical.Schema) *analyzeContext {
 	}
 }
 
+// buildTagPredicates extracts the equality conditions on the projected and stored string tags,
+// which are joined by AND at the top level of the criteria. The storage evaluates them to skip the unmatched elements early,
+// while the tag filter still evaluates the whole criteria.
+func buildTagPredicates(criteria *modelv1.Criteria, s logical.Schema, entityDict map[string]int, projection []model.TagProjection) []model.TagPredicate {
+	var predicates []model.TagPredicate
+	var walk func(c *modelv1.Criteria)
+	walk = func(c *modelv1.Criteria) {
+		switch v := c.GetExp().(type) {
+		case *modelv1.Criteria_Le:
+			if v.Le.GetOp() == modelv1.LogicalExpression_LOGICAL_OP_AND {
+				walk(v.Le.GetLeft())
+				walk(v.Le.GetRight())
+			}
+		case *modelv1.Criteria_Condition:
+			cond := v.Condition
+			if _, ok := entityDict[cond.GetName()]; ok {
+				return
+			}
+			spec := s.FindTagSpecByName(cond.GetName())
+			if spec == nil || spec.Spec.GetType() != databasev1.TagType_TAG_TYPE_STRING || spec.Spec.GetIndexedOnly() {
+				return
+			}
+			var values []string
+			switch cond.GetOp() {
+			case modelv1.Condition_BINARY_OP_EQ:
+				if cond.GetValue().GetStr() == nil {
+					return
+				}
+				values = []string{cond.GetValue().GetStr().GetValue()}
+			case modelv1.Condition_BINARY_OP_IN:
+				if cond.GetValue().GetStrArray() == nil {
+					return
+				}
+				values = cond.GetValue().GetStrArray().GetValue()
+			default:
+				return
+			}
+			for _, tp := range projection {
+				if slices.Contains(tp.Names, cond.GetName()) {
+					predicates = append(predicates, model.TagPredicate{Family: tp.Family, Name: cond.GetName(), Values: values})
+					return
+				}
+			}
+		}
+	}
+	walk(criteria)
+	return predicates
+}
+
 var (
 	_ logical.Plan              = (*tagFilterPlan)(nil)
 	_ executor.StreamExecutable = (*tagFilterPlan)(nil)
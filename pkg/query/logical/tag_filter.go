@@ -179,6 +179,8 @@ func parseExpr(value *modelv1.TagValue) (ComparableExpr, error) {
 		return &int64ArrLiteral{
 			arr: v.IntArray.GetValue(),
 		}, nil
+	case *modelv1.TagValue_Float, *modelv1.TagValue_Bool, *modelv1.TagValue_Timestamp:
+		return parseScalarLiteral(value), nil
 	case *modelv1.TagValue_Null:
 		return nullLiteralExpr, nil
 	}
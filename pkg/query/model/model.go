@@ -64,6 +64,25 @@ type MeasureQueryOptions struct {
 	Entities        [][]*modelv1.TagValue
 	TagProjection   []TagProjection
 	FieldProjection []string
+	FieldPredicates []FieldPredicate
+	Shard           *ShardScan
+}
+
+// ShardScan restricts a query to the parts of a shard, which a shard migration reads.
+type ShardScan struct {
+	// Marks holds the id of the last part of every segment which the previous pass of the migration read,
+	// keyed by the name of the segment. The parts whose ids aren't greater than the mark are skipped.
+	Marks map[string]uint64
+	ID    common.ShardID
+}
+
+// FieldPredicate is a comparison between a numeric field and an int or float value.
+// The storage skips the blocks whose min and max values of the field can't match it,
+// while the query plan evaluates the field conditions against every data point.
+type FieldPredicate struct {
+	Value *modelv1.FieldValue
+	Name  string
+	Op    modelv1.Condition_BinaryOp
 }
 
 // MeasureResult is the result of a query.
@@ -90,9 +109,21 @@ type StreamQueryOptions struct {
 	Filter         index.Filter
 	Order          *index.OrderBy
 	TagProjection  []TagProjection
+	TagPredicates  []TagPredicate
+	Shard          *ShardScan
 	MaxElementSize int
 }
 
+// TagPredicate is a condition on a string tag, which the storage evaluates before building the tag values.
+// A tag matches it if the tag's value equals one of the Values.
+// The storage evaluates it once per distinct value if the tag is dictionary-encoded.
+// Only the stream evaluates it, since the series index already resolves all tag conditions of a measure.
+type TagPredicate struct {
+	Family string
+	Name   string
+	Values []string
+}
+
 // Reset resets the StreamQueryOptions.
 func (s *StreamQueryOptions) Reset() {
 	s.Name = ""
@@ -101,6 +132,8 @@ func (s *StreamQueryOptions) Reset() {
 	s.Filter = nil
 	s.Order = nil
 	s.TagProjection = nil
+	s.TagPredicates = nil
+	s.Shard = nil
 	s.MaxElementSize = 0
 }
 
@@ -127,6 +160,8 @@ func (s *StreamQueryOptions) CopyFrom(other *StreamQueryOptions) {
 	} else {
 		s.TagProjection = nil
 	}
+	s.TagPredicates = other.TagPredicates
+	s.Shard = other.Shard
 
 	s.MaxElementSize = other.MaxElementSize
 }
@@ -0,0 +1,242 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package slowquery implements the slow query log which keeps the recent slow queries in memory
+// and writes them to rotating files.
+package slowquery
+
+import (
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/accesslog"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+)
+
+const (
+	fileTemplate   = "slow-query-%s.log"
+	rotateInterval = time.Hour
+)
+
+// volatileFields are reset by NormalizeRequest because they don't change the result of a query.
+var volatileFields = []protoreflect.Name{"trace", "continuation_token"}
+
+// Options configures a Log.
+type Options struct {
+	// RootPath is the directory of the log files. No files are written if it's empty.
+	RootPath string
+	// Threshold is the minimal duration of a slow query. Zero disables the log.
+	Threshold time.Duration
+	// Size is the number of the recent slow queries kept in memory.
+	Size int
+	// TraceSampleRate is the ratio of the queries to be traced in case they turn out to be slow.
+	TraceSampleRate float64
+}
+
+// Entry describes an executed query.
+type Entry struct {
+	Start        time.Time
+	Request      proto.Message
+	Trace        *commonv1.Trace
+	Catalog      commonv1.Catalog
+	RowsScanned  uint64
+	RowsReturned uint64
+}
+
+// Log records the queries slower than the threshold.
+// A nil Log ignores all the records.
+type Log struct {
+	file   accesslog.Log
+	l      *logger.Logger
+	node   string
+	recent []*databasev1.SlowQuery
+	opts   Options
+	next   int
+	mu     sync.RWMutex
+}
+
+// New returns a slow query log of the node.
+func New(node string, opts Options, l *logger.Logger) (*Log, error) {
+	if opts.Size <= 0 {
+		return nil, errors.Errorf("the size of the slow query log should be positive, got %d", opts.Size)
+	}
+	if opts.TraceSampleRate < 0 || opts.TraceSampleRate > 1 {
+		return nil, errors.Errorf("the trace sample rate should be in [0, 1], got %f", opts.TraceSampleRate)
+	}
+	sl := &Log{
+		node:   node,
+		opts:   opts,
+		l:      l,
+		recent: make([]*databasev1.SlowQuery, 0, opts.Size),
+	}
+	if opts.Threshold > 0 && opts.RootPath != "" {
+		var err error
+		if sl.file, err = accesslog.NewFileLog(opts.RootPath, fileTemplate, rotateInterval, l); err != nil {
+			return nil, errors.WithMessage(err, "failed to open the slow query log")
+		}
+	}
+	return sl, nil
+}
+
+// Sampled reports whether a query should collect its trace in case it turns out to be slow.
+func (sl *Log) Sampled() bool {
+	if sl == nil || sl.opts.Threshold <= 0 || sl.opts.TraceSampleRate <= 0 {
+		return false
+	}
+	return rand.Float64() < sl.opts.TraceSampleRate
+}
+
+// Record keeps the query if it runs longer than the threshold. It reports whether the query is slow.
+func (sl *Log) Record(e Entry) bool {
+	if sl == nil || sl.opts.Threshold <= 0 {
+		return false
+	}
+	d := time.Since(e.Start)
+	if d <= sl.opts.Threshold {
+		return false
+	}
+	sq := &databasev1.SlowQuery{
+		Node:         sl.node,
+		Catalog:      e.Catalog,
+		Request:      NormalizeRequest(e.Request),
+		StartTime:    timestamppb.New(e.Start),
+		Duration:     durationpb.New(d),
+		RowsScanned:  e.RowsScanned,
+		RowsReturned: e.RowsReturned,
+		Trace:        e.Trace,
+	}
+	sl.mu.Lock()
+	if len(sl.recent) < sl.opts.Size {
+		sl.recent = append(sl.recent, sq)
+	} else {
+		sl.recent[sl.next] = sq
+	}
+	sl.next = (sl.next + 1) % sl.opts.Size
+	sl.mu.Unlock()
+	if sl.file == nil {
+		return true
+	}
+	if err := sl.file.Write(sq); err != nil {
+		sl.l.Warn().Err(err).Msg("failed to write the slow query log")
+	}
+	return true
+}
+
+// RecordQuery records an executed query with its stats, trace and response. It reports whether the query is slow.
+func (sl *Log) RecordQuery(catalog commonv1.Catalog, req proto.Message, start time.Time,
+	stats *executor.QueryStats, tracer *query.Tracer, resp bus.Message,
+) bool {
+	e := Entry{
+		Start:       start,
+		Request:     req,
+		Catalog:     catalog,
+		RowsScanned: stats.Scanned(),
+	}
+	switch d := resp.Data().(type) {
+	case *streamv1.QueryResponse:
+		e.RowsReturned = uint64(len(d.Elements))
+	case *measurev1.QueryResponse:
+		e.RowsReturned = uint64(len(d.DataPoints))
+	case *measurev1.TopNResponse:
+		for _, l := range d.Lists {
+			e.RowsReturned += uint64(len(l.Items))
+		}
+	}
+	if tracer != nil {
+		e.Trace = tracer.ToProto()
+	}
+	return sl.Record(e)
+}
+
+// List returns at most limit recent slow queries, the latest first. Zero limit returns all of them.
+func (sl *Log) List(limit int) []*databasev1.SlowQuery {
+	if sl == nil {
+		return nil
+	}
+	sl.mu.RLock()
+	result := make([]*databasev1.SlowQuery, 0, len(sl.recent))
+	for i := 1; i <= len(sl.recent); i++ {
+		result = append(result, sl.recent[(sl.next-i+len(sl.recent))%len(sl.recent)])
+	}
+	sl.mu.RUnlock()
+	return Truncate(result, limit)
+}
+
+// Close closes the underlying files.
+func (sl *Log) Close() error {
+	if sl == nil || sl.file == nil {
+		return nil
+	}
+	return sl.file.Close()
+}
+
+// Merge sorts the slow queries from several nodes by their start time, the latest first,
+// and returns at most limit of them.
+func Merge(limit int, sqs ...[]*databasev1.SlowQuery) []*databasev1.SlowQuery {
+	var result []*databasev1.SlowQuery
+	for _, s := range sqs {
+		result = append(result, s...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].GetStartTime().AsTime().After(result[j].GetStartTime().AsTime())
+	})
+	return Truncate(result, limit)
+}
+
+// Truncate returns at most limit slow queries. Zero limit returns all of them.
+func Truncate(sqs []*databasev1.SlowQuery, limit int) []*databasev1.SlowQuery {
+	if limit > 0 && len(sqs) > limit {
+		return sqs[:limit]
+	}
+	return sqs
+}
+
+// NormalizeRequest renders the request without the fields that vary between the executions of the same query.
+func NormalizeRequest(req proto.Message) string {
+	if req == nil {
+		return ""
+	}
+	c := proto.Clone(req)
+	m := c.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for _, n := range volatileFields {
+		if fd := fields.ByName(n); fd != nil {
+			m.Clear(fd)
+		}
+	}
+	data, err := protojson.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
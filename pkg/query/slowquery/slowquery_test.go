@@ -0,0 +1,169 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package slowquery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+)
+
+func TestLog(t *testing.T) {
+	sl, err := New("node-1", Options{Threshold: time.Millisecond, Size: 2}, logger.GetLogger("test"))
+	require.NoError(t, err)
+	defer sl.Close()
+
+	start := time.Now()
+	assert.False(t, sl.Record(Entry{Start: start, Request: &streamv1.QueryRequest{Name: "fast"}}))
+	for _, name := range []string{"q1", "q2", "q3"} {
+		assert.True(t, sl.Record(Entry{
+			Start:        start.Add(-time.Second),
+			Request:      &streamv1.QueryRequest{Name: name},
+			Catalog:      commonv1.Catalog_CATALOG_STREAM,
+			RowsScanned:  10,
+			RowsReturned: 1,
+		}))
+	}
+	list := sl.List(0)
+	require.Len(t, list, 2)
+	assert.Contains(t, list[0].Request, "q3")
+	assert.Contains(t, list[1].Request, "q2")
+	assert.Equal(t, "node-1", list[0].Node)
+	assert.Equal(t, uint64(10), list[0].RowsScanned)
+	assert.Equal(t, uint64(1), list[0].RowsReturned)
+	assert.GreaterOrEqual(t, list[0].Duration.AsDuration(), time.Second)
+	assert.Len(t, sl.List(1), 1)
+}
+
+func TestRecordQuery(t *testing.T) {
+	sl, err := New("node-1", Options{Threshold: time.Millisecond, Size: 3}, logger.GetLogger("test"))
+	require.NoError(t, err)
+	defer sl.Close()
+
+	start := time.Now().Add(-time.Second)
+	stats := &executor.QueryStats{}
+	stats.AddScanned(5)
+	tracer, ctx := query.NewTracer(context.Background(), "trace")
+	span, _ := tracer.StartSpan(ctx, "data")
+	span.Stop()
+	assert.True(t, sl.RecordQuery(commonv1.Catalog_CATALOG_STREAM, &streamv1.QueryRequest{Name: "stream"}, start, stats, tracer,
+		bus.NewMessage(1, &streamv1.QueryResponse{Elements: make([]*streamv1.Element, 2)})))
+	assert.True(t, sl.RecordQuery(commonv1.Catalog_CATALOG_MEASURE, &measurev1.QueryRequest{Name: "measure"}, start, nil, nil,
+		bus.NewMessage(1, &measurev1.QueryResponse{DataPoints: make([]*measurev1.DataPoint, 3)})))
+	assert.True(t, sl.RecordQuery(commonv1.Catalog_CATALOG_MEASURE, &measurev1.TopNRequest{Name: "topn"}, start, nil, nil,
+		bus.NewMessage(1, &measurev1.TopNResponse{Lists: []*measurev1.TopNList{
+			{Items: make([]*measurev1.TopNList_Item, 2)},
+			{Items: make([]*measurev1.TopNList_Item, 2)},
+		}})))
+
+	list := sl.List(0)
+	require.Len(t, list, 3)
+	assert.Equal(t, uint64(4), list[0].RowsReturned)
+	assert.Equal(t, uint64(3), list[1].RowsReturned)
+	assert.Nil(t, list[1].Trace)
+	assert.Equal(t, uint64(2), list[2].RowsReturned)
+	assert.Equal(t, uint64(5), list[2].RowsScanned)
+	require.NotNil(t, list[2].Trace)
+	assert.Equal(t, "data", list[2].Trace.Spans[0].Message)
+}
+
+func TestLogDisabled(t *testing.T) {
+	sl, err := New("node-1", Options{Size: 2, TraceSampleRate: 1}, logger.GetLogger("test"))
+	require.NoError(t, err)
+	assert.False(t, sl.Sampled())
+	assert.False(t, sl.Record(Entry{Start: time.Now().Add(-time.Hour)}))
+	assert.Empty(t, sl.List(0))
+
+	var nilLog *Log
+	assert.False(t, nilLog.Sampled())
+	assert.False(t, nilLog.Record(Entry{Start: time.Now().Add(-time.Hour)}))
+	assert.Nil(t, nilLog.List(0))
+	assert.NoError(t, nilLog.Close())
+}
+
+func TestLogInvalidOptions(t *testing.T) {
+	_, err := New("node-1", Options{}, logger.GetLogger("test"))
+	assert.Error(t, err)
+	_, err = New("node-1", Options{Size: 1, TraceSampleRate: 2}, logger.GetLogger("test"))
+	assert.Error(t, err)
+}
+
+func TestLogFile(t *testing.T) {
+	root := t.TempDir()
+	sl, err := New("node-1", Options{RootPath: root, Threshold: time.Millisecond, Size: 1}, logger.GetLogger("test"))
+	require.NoError(t, err)
+	assert.True(t, sl.Record(Entry{Start: time.Now().Add(-time.Second), Request: &streamv1.QueryRequest{Name: "slow"}}))
+	require.Eventually(t, func() bool {
+		files, _ := filepath.Glob(filepath.Join(root, "slow-query-*.log"))
+		if len(files) != 1 {
+			return false
+		}
+		content, _ := os.ReadFile(files[0])
+		return len(content) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, sl.Close())
+}
+
+func TestSampled(t *testing.T) {
+	sl, err := New("node-1", Options{Threshold: time.Second, Size: 1, TraceSampleRate: 1}, logger.GetLogger("test"))
+	require.NoError(t, err)
+	assert.True(t, sl.Sampled())
+	sl, err = New("node-1", Options{Threshold: time.Second, Size: 1}, logger.GetLogger("test"))
+	require.NoError(t, err)
+	assert.False(t, sl.Sampled())
+}
+
+func TestMerge(t *testing.T) {
+	now := time.Now()
+	sq := func(node string, offset time.Duration) *databasev1.SlowQuery {
+		return &databasev1.SlowQuery{Node: node, StartTime: timestamppb.New(now.Add(offset))}
+	}
+	merged := Merge(3,
+		[]*databasev1.SlowQuery{sq("a", -time.Second), sq("a", -3*time.Second)},
+		[]*databasev1.SlowQuery{sq("b", 0), sq("b", -2*time.Second)},
+	)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "b", merged[0].Node)
+	assert.Equal(t, "a", merged[1].Node)
+	assert.Equal(t, "b", merged[2].Node)
+}
+
+func TestNormalizeRequest(t *testing.T) {
+	req := &streamv1.QueryRequest{Name: "sw", Groups: []string{"default"}, Trace: true, ContinuationToken: []byte("token")}
+	got := NormalizeRequest(req)
+	assert.NotContains(t, got, "trace")
+	assert.NotContains(t, got, "continuationToken")
+	assert.Contains(t, got, "sw")
+	assert.True(t, req.Trace, "the original request should not be changed")
+	assert.Empty(t, NormalizeRequest(nil))
+}
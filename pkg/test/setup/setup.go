@@ -204,7 +204,7 @@ func CMD(flags ...string) func() {
 	}
 }
 
-func startDataNode(etcdEndpoint string, flags ...string) (string, string, func(), func()) {
+func startDataNode(etcdEndpoint string, creds credentials.TransportCredentials, flags ...string) (string, string, func(), func()) {
 	path, deferFn, err := test.NewSpace()
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
@@ -229,7 +229,7 @@ func startDataNode(etcdEndpoint string, flags ...string) (string, string, func()
 	closeFn := CMD(flags...)
 
 	gomega.Eventually(
-		helpers.HealthCheck(addr, 10*time.Second, 10*time.Second, grpclib.WithTransportCredentials(insecure.NewCredentials())),
+		helpers.HealthCheck(addr, 10*time.Second, 10*time.Second, grpclib.WithTransportCredentials(creds)),
 		testflags.EventuallyTimeout).Should(gomega.Succeed())
 
 	gomega.Eventually(func() (map[string]*databasev1.Node, error) {
@@ -241,7 +241,7 @@ func startDataNode(etcdEndpoint string, flags ...string) (string, string, func()
 
 // DataNode runs a data node.
 func DataNode(etcdEndpoint string, flags ...string) func() {
-	_, _, closeFn, deferFn := startDataNode(etcdEndpoint, flags...)
+	_, _, closeFn, deferFn := startDataNode(etcdEndpoint, insecure.NewCredentials(), flags...)
 	return func() {
 		closeFn()
 		deferFn()
@@ -250,13 +250,23 @@ func DataNode(etcdEndpoint string, flags ...string) func() {
 
 // DataNodeWithAddrAndDir runs a data node and returns the address and root path.
 func DataNodeWithAddrAndDir(etcdEndpoint string, flags ...string) (string, string, func()) {
-	addr, dir, closeFn, deferFn := startDataNode(etcdEndpoint, flags...)
+	addr, dir, closeFn, deferFn := startDataNode(etcdEndpoint, insecure.NewCredentials(), flags...)
 	return addr, dir, func() {
 		closeFn()
 		deferFn()
 	}
 }
 
+// DataNodeWithTLS runs a data node whose gRPC server is secured by the TLS flags, and returns the address.
+// The health check connects to the data node with the creds.
+func DataNodeWithTLS(etcdEndpoint string, creds credentials.TransportCredentials, flags ...string) (string, func()) {
+	addr, _, closeFn, deferFn := startDataNode(etcdEndpoint, creds, flags...)
+	return addr, func() {
+		closeFn()
+		deferFn()
+	}
+}
+
 // LiaisonNode runs a liaison node.
 func LiaisonNode(etcdEndpoint string, flags ...string) (string, func()) {
 	ports, err := test.AllocateFreePorts(2)
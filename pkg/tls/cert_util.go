@@ -52,7 +52,7 @@ func GenerateSelfSignedCert(commonName string, extraDNSNames []string) (certPEM,
 		NotBefore:             time.Now(),
 		NotAfter:              time.Now().Add(time.Hour * 24),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 		BasicConstraintsValid: true,
 	}
 
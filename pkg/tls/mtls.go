@@ -0,0 +1,88 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// GetMutualTLSConfig returns a TLS config which requires the clients to present certificates issued by the CAs of clientCAs.
+// The CA bundle is read from clientCAs on every handshake, so that a reloaded bundle applies to the new connections.
+// The clients are also required to match any of the allowed SANs if there is any.
+func (r *Reloader) GetMutualTLSConfig(clientCAs *Reloader, allowedSANs []string) *tls.Config {
+	base := r.GetTLSConfig()
+	base.ClientAuth = tls.RequireAndVerifyClientCert
+	base.VerifyConnection = VerifySANs(allowedSANs)
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+			c := base.Clone()
+			c.ClientCAs = clientCAs.GetCertPool()
+			return c, nil
+		},
+	}
+}
+
+// ValidateSANs checks the patterns of the allowed SANs.
+func ValidateSANs(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return errors.Wrapf(err, "invalid SAN pattern %q", p)
+		}
+	}
+	return nil
+}
+
+// VerifySANs returns a function verifying that the certificate of the peer has a DNS, IP or URI SAN
+// matching any of the patterns, such as "*.liaison.banyandb.svc". The patterns are matched by path.Match.
+// Any peer is allowed if there is no pattern.
+func VerifySANs(patterns []string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(patterns) == 0 {
+			return nil
+		}
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("no peer certificate")
+		}
+		leaf := cs.PeerCertificates[0]
+		for _, san := range sansOf(leaf) {
+			for _, p := range patterns {
+				if ok, _ := path.Match(p, san); ok {
+					return nil
+				}
+			}
+		}
+		return errors.Errorf("the certificate of %s doesn't match any allowed SAN", leaf.Subject.CommonName)
+	}
+}
+
+func sansOf(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses)+len(cert.URIs))
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
@@ -0,0 +1,160 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+func writeCert(t *testing.T, dir, name string, dnsNames []string) (certFile, keyFile string, certPEM []byte) {
+	certPEM, keyPEM, err := GenerateSelfSignedCert(name, dnsNames)
+	require.NoError(t, err)
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	return certFile, keyFile, certPEM
+}
+
+// mtlsServer replies "ok" to the clients passing the handshake.
+func mtlsServer(t *testing.T, config *tls.Config) string {
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lis.Close() })
+	go func() {
+		for {
+			conn, errAccept := lis.Accept()
+			if errAccept != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if conn.(*tls.Conn).Handshake() == nil {
+					_, _ = conn.Write([]byte("ok"))
+				}
+			}()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+func dial(addr string, serverCAs *x509.CertPool, certFile, keyFile string) error {
+	config := &tls.Config{
+		RootCAs:    serverCAs,
+		ServerName: "localhost",
+		MinVersion: tls.VersionTLS12,
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	// The server verifies the client after the client finishes the handshake in TLS 1.3.
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(conn, buf)
+	return err
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	log := logger.GetLogger("tls-test")
+	serverCert, serverKey, serverPEM := writeCert(t, dir, "localhost", nil)
+	liaisonCert, liaisonKey, liaisonPEM := writeCert(t, dir, "liaison-0", []string{"liaison-0.liaison.banyandb"})
+	newcomerCert, newcomerKey, newcomerPEM := writeCert(t, dir, "liaison-1", []string{"liaison-1.liaison.banyandb"})
+	intruderCert, intruderKey, intruderPEM := writeCert(t, dir, "intruder", nil)
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, liaisonPEM, 0o600))
+
+	serverReloader, err := NewReloader(serverCert, serverKey, log)
+	require.NoError(t, err)
+	caReloader, err := NewClientCertReloader(caFile, log)
+	require.NoError(t, err)
+	require.NoError(t, caReloader.Start())
+	defer caReloader.Stop()
+
+	addr := mtlsServer(t, serverReloader.GetMutualTLSConfig(caReloader, []string{"*.liaison.banyandb"}))
+	serverCAs := x509.NewCertPool()
+	require.True(t, serverCAs.AppendCertsFromPEM(serverPEM))
+
+	assert.NoError(t, dial(addr, serverCAs, liaisonCert, liaisonKey))
+	assert.Error(t, dial(addr, serverCAs, "", ""), "a client without a certificate")
+	assert.Error(t, dial(addr, serverCAs, newcomerCert, newcomerKey), "a client issued by an unknown CA")
+
+	// Both the newcomer and the intruder are trusted after the CA bundle is reloaded, but the SANs of the intruder aren't allowed.
+	updated := make(chan struct{})
+	go func() {
+		<-caReloader.GetUpdateChannel()
+		close(updated)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, os.WriteFile(caFile, bytes.Join([][]byte{liaisonPEM, newcomerPEM, intruderPEM}, nil), 0o600))
+	select {
+	case <-updated:
+	case <-time.After(flags.EventuallyTimeout):
+		require.Fail(t, "timed out waiting for the CA bundle to be reloaded")
+	}
+	assert.NoError(t, dial(addr, serverCAs, newcomerCert, newcomerKey))
+	assert.NoError(t, dial(addr, serverCAs, liaisonCert, liaisonKey))
+	assert.Error(t, dial(addr, serverCAs, intruderCert, intruderKey), "a client with a SAN not allowed")
+}
+
+func TestVerifySANs(t *testing.T) {
+	certPEM, _, err := GenerateSelfSignedCert("liaison-0", []string{"liaison-0.liaison.banyandb"})
+	require.NoError(t, err)
+	cs := tls.ConnectionState{PeerCertificates: []*x509.Certificate{parseCert(t, certPEM)}}
+
+	assert.NoError(t, VerifySANs(nil)(cs))
+	assert.NoError(t, VerifySANs([]string{"liaison-0"})(cs))
+	assert.NoError(t, VerifySANs([]string{"data-*", "*.liaison.banyandb"})(cs))
+	assert.Error(t, VerifySANs([]string{"*.data.banyandb"})(cs))
+	assert.Error(t, VerifySANs([]string{"*"})(tls.ConnectionState{}))
+
+	assert.NoError(t, ValidateSANs([]string{"*.liaison.banyandb", "spiffe://banyandb/*"}))
+	assert.Error(t, ValidateSANs([]string{"[liaison"}))
+}
+
+func parseCert(t *testing.T, certPEM []byte) *x509.Certificate {
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
@@ -38,6 +38,7 @@ import (
 //nolint:govet
 type Reloader struct {
 	cert          *tls.Certificate
+	certPool      *x509.CertPool
 	watcher       *fsnotify.Watcher
 	log           *logger.Logger
 	debounceTimer *time.Timer
@@ -123,6 +124,7 @@ func NewClientCertReloader(certFile string, log *logger.Logger) (*Reloader, erro
 	tr := &Reloader{
 		certFile: certFile,
 		keyFile:  "", // No key file for client certs
+		certPool: certPool,
 		log:      log,
 		watcher:  watcher,
 		updateCh: make(chan struct{}, 1),
@@ -352,8 +354,11 @@ func (r *Reloader) reloadCertificate(newCertHash, newKeyHash []byte) error {
 			return errors.New("failed to parse PEM certificate")
 		}
 
-		// Update the stored hash
+		// Update the pool and the stored hash
+		r.mu.Lock()
+		r.certPool = certPool
 		r.lastCertHash = newCertHash
+		r.mu.Unlock()
 
 		r.log.Debug().Msg("Client certificate updated in memory")
 		r.notifyUpdate()
@@ -385,6 +390,20 @@ func (r *Reloader) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, err
 	return r.cert, nil
 }
 
+// GetClientCertificate returns the current certificate to the servers verifying the clients.
+func (r *Reloader) GetClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// GetCertPool returns the current pool of the CA certificates monitored by a client certificate reloader.
+func (r *Reloader) GetCertPool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.certPool
+}
+
 // GetUpdateChannel returns a channel that will be triggered when a certificate is updated.
 func (r *Reloader) GetUpdateChannel() <-chan struct{} {
 	return r.updateCh
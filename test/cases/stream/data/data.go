@@ -36,6 +36,7 @@ import (
 	gm "github.com/onsi/gomega"
 	grpclib "google.golang.org/grpc"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"sigs.k8s.io/yaml"
@@ -49,6 +50,8 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/test/helpers"
 )
 
+const defaultLimit uint32 = 20
+
 //go:embed input/*.yaml
 var inputFS embed.FS
 
@@ -100,6 +103,9 @@ var VerifyFn = func(innerGm gm.Gomega, sharedContext helpers.SharedContext, args
 			return strings.Compare(a.ElementId, b.ElementId)
 		})
 	}
+	// The token is opaque, so it is verified by fetching the next page instead of comparing with the wanted one.
+	verifyContinuation(innerGm, c, query, resp)
+	want.ContinuationToken = resp.ContinuationToken
 	var extra []cmp.Option
 	extra = append(extra, protocmp.IgnoreUnknown(),
 		protocmp.IgnoreFields(&streamv1.Element{}, "timestamp"),
@@ -132,6 +138,43 @@ var VerifyFn = func(innerGm gm.Gomega, sharedContext helpers.SharedContext, args
 	innerGm.Expect(resp.Trace.GetSpans()).NotTo(gm.BeEmpty())
 }
 
+// verifyContinuation checks that only a full page carries the continuation token,
+// and the next page fetched by the token doesn't repeat any element of the page.
+func verifyContinuation(innerGm gm.Gomega, c streamv1.StreamServiceClient, query *streamv1.QueryRequest, resp *streamv1.QueryResponse) {
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if len(resp.Elements) < int(limit) {
+		innerGm.Expect(resp.ContinuationToken).To(gm.BeEmpty(), "the last page carries a continuation token")
+		return
+	}
+	if len(query.SortKeys) == 0 && query.OrderBy.GetIndexRuleName() == "" {
+		innerGm.Expect(resp.ContinuationToken).NotTo(gm.BeEmpty(), "a full page ordered by time carries no continuation token")
+	}
+	if len(resp.ContinuationToken) == 0 {
+		return
+	}
+	returned := make(map[string]struct{}, len(resp.Elements))
+	for _, e := range resp.Elements {
+		returned[elementKey(e)] = struct{}{}
+	}
+	next := proto.Clone(query).(*streamv1.QueryRequest)
+	next.ContinuationToken = resp.ContinuationToken
+	nextResp, err := c.Query(context.Background(), next)
+	innerGm.Expect(err).NotTo(gm.HaveOccurred())
+	for _, e := range nextResp.Elements {
+		innerGm.Expect(returned).NotTo(gm.HaveKey(elementKey(e)), "the next page repeats the element %s", e.ElementId)
+	}
+	if len(nextResp.Elements) < int(limit) {
+		innerGm.Expect(nextResp.ContinuationToken).To(gm.BeEmpty(), "the last page carries a continuation token")
+	}
+}
+
+func elementKey(e *streamv1.Element) string {
+	return e.ElementId + "@" + e.Timestamp.AsTime().String()
+}
+
 func loadData(stream streamv1.StreamService_WriteClient, metadata *commonv1.Metadata, dataFile string, baseTime time.Time, interval time.Duration) {
 	var templates []interface{}
 	content, err := dataFS.ReadFile("testdata/" + dataFile)
@@ -0,0 +1,184 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_rebalance_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	casesmeasuredata "github.com/apache/skywalking-banyandb/test/cases/measure/data"
+	casesstreamdata "github.com/apache/skywalking-banyandb/test/cases/stream/data"
+)
+
+var _ = Describe("Rebalance over mutual TLS", func() {
+	var conn *grpc.ClientConn
+	var closeFuncs []func()
+	var now time.Time
+	var etcdEndpoint string
+	var dataNode0 string
+	var dataFlags []string
+	var dataCreds credentials.TransportCredentials
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		serverCert, serverKey := writeCert(dir, "server", []string{"localhost"}, []net.IP{net.ParseIP("127.0.0.1")})
+		liaisonCert, liaisonKey := writeCert(dir, "liaison", []string{"liaison-0.liaison.banyandb"}, nil)
+		dataCert, dataKey := writeCert(dir, "data", []string{"data-0.data.banyandb"}, nil)
+		clientCA := filepath.Join(dir, "client-ca.crt")
+		liaisonPEM, err := os.ReadFile(liaisonCert)
+		Expect(err).NotTo(HaveOccurred())
+		dataPEM, err := os.ReadFile(dataCert)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(clientCA, append(liaisonPEM, dataPEM...), 0o600)).To(Succeed())
+		// The data nodes present their own certificates to the new nodes of the moved shards.
+		dataFlags = []string{
+			"--tls=true", "--cert-file=" + serverCert, "--key-file=" + serverKey,
+			"--client-ca-file=" + clientCA, "--allowed-client-sans=*.liaison.banyandb,*.data.banyandb",
+			"--internal-tls=true", "--internal-ca-cert=" + serverCert,
+			"--internal-client-cert=" + dataCert, "--internal-client-key=" + dataKey,
+		}
+		config, err := grpchelper.TLSConfig(false, serverCert)
+		Expect(err).NotTo(HaveOccurred())
+		pair, err := tls.LoadX509KeyPair(liaisonCert, liaisonKey)
+		Expect(err).NotTo(HaveOccurred())
+		config.Certificates = []tls.Certificate{pair}
+		dataCreds = credentials.NewTLS(config)
+
+		By("Starting etcd server")
+		var closeEtcd func()
+		etcdEndpoint, closeEtcd = startEtcd()
+		closeFuncs = append(closeFuncs, closeEtcd)
+		By("Starting data node 0")
+		dataAddr0, closeDataNode0 := setup.DataNodeWithTLS(etcdEndpoint, dataCreds, dataFlags...)
+		closeFuncs = append(closeFuncs, closeDataNode0)
+		dataNode0 = nodeName(dataAddr0)
+		By("Starting liaison node")
+		liaisonAddr, closeLiaisonNode := setup.LiaisonNode(etcdEndpoint,
+			"--internal-tls=true", "--internal-ca-cert="+serverCert,
+			"--internal-client-cert="+liaisonCert, "--internal-client-key="+liaisonKey)
+		closeFuncs = append(closeFuncs, closeLiaisonNode)
+		conn, err = grpchelper.Conn(liaisonAddr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		ns := timestamp.NowMilli().UnixNano()
+		now = time.Unix(0, ns-ns%int64(time.Minute))
+	})
+
+	AfterEach(func() {
+		Expect(conn.Close()).To(Succeed())
+		for i := len(closeFuncs) - 1; i >= 0; i-- {
+			if closeFuncs[i] != nil {
+				closeFuncs[i]()
+			}
+		}
+		closeFuncs = nil
+	})
+
+	It("drains the node with the certificates of the data nodes", func() {
+		casesstreamdata.Write(conn, "sw", now, 500*time.Millisecond)
+		casesmeasuredata.Write(conn, "service_cpm_minute", measureGroup, "service_cpm_minute_data.json", now, time.Minute)
+		tr := &modelv1.TimeRange{
+			Begin: timestamppb.New(now.Add(-time.Hour)),
+			End:   timestamppb.New(now.Add(time.Hour)),
+		}
+		var elements, dataPoints []string
+		Eventually(func(g Gomega) {
+			elements = queryElements(g, conn, tr)
+			g.Expect(elements).To(HaveLen(5))
+			dataPoints = queryDataPoints(g, conn, tr)
+			g.Expect(dataPoints).To(HaveLen(6))
+		}, flags.EventuallyTimeout).Should(Succeed())
+
+		By("Starting data node 1")
+		dataAddr1, closeDataNode1 := setup.DataNodeWithTLS(etcdEndpoint, dataCreds, dataFlags...)
+		closeFuncs = append(closeFuncs, closeDataNode1)
+		dataNode1 := nodeName(dataAddr1)
+		shards := databasev1.NewShardServiceClient(conn)
+		Eventually(func(g Gomega) {
+			for _, group := range []string{streamGroup, measureGroup} {
+				resp, err := shards.Rebalance(context.Background(), &databasev1.ShardServiceRebalanceRequest{Group: group, DryRun: true})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.Moves).NotTo(BeEmpty())
+			}
+		}, flags.EventuallyTimeout).Should(Succeed())
+		// Data node 0 sends its shards to data node 1, which only accepts the certificates of the allowed SANs.
+		resp, err := databasev1.NewNodeServiceClient(conn).Drain(context.Background(), &databasev1.NodeServiceDrainRequest{Node: dataNode0})
+		Expect(err).NotTo(HaveOccurred())
+		counted := make(map[string]uint64)
+		for _, ds := range resp.Shards {
+			Expect(ds.To).To(Equal([]string{dataNode1}))
+			counted[ds.Group] += ds.Counted
+		}
+		Expect(counted).To(Equal(map[string]uint64{streamGroup: 5, measureGroup: 6}))
+
+		By("Stopping data node 0")
+		closeFuncs[1]()
+		closeFuncs[1] = nil
+		Eventually(func(g Gomega) {
+			g.Expect(queryElements(g, conn, tr)).To(Equal(elements))
+			g.Expect(queryDataPoints(g, conn, tr)).To(Equal(dataPoints))
+		}, flags.EventuallyTimeout).Should(Succeed())
+	})
+})
+
+// writeCert writes a self-signed certificate, which is its own CA, and its key to the dir.
+func writeCert(dir, name string, dnsNames []string, ips []net.IP) (certFile, keyFile string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              dnsNames,
+		IPAddresses:           ips,
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	Expect(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600)).To(Succeed())
+	return certFile, keyFile
+}
@@ -0,0 +1,93 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package integration_rebalance_test is a integration test suite.
+package integration_rebalance_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/embeddedetcd"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	test_measure "github.com/apache/skywalking-banyandb/pkg/test/measure"
+	test_stream "github.com/apache/skywalking-banyandb/pkg/test/stream"
+)
+
+func TestRebalance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Distributed Rebalance Suite")
+}
+
+var goods []gleak.Goroutine
+
+var _ = BeforeSuite(func() {
+	Expect(logger.Init(logger.Logging{
+		Env:   "dev",
+		Level: flags.LogLevel,
+	})).To(Succeed())
+	goods = gleak.Goroutines()
+})
+
+var _ = AfterSuite(func() {
+	Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+	Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+})
+
+// startEtcd starts an etcd server with the schemas of the test streams and measures.
+// Every spec starts its own server, so that the shard placements of a spec don't leak into the others.
+func startEtcd() (string, func()) {
+	ports, err := test.AllocateFreePorts(2)
+	Expect(err).NotTo(HaveOccurred())
+	dir, spaceDef, err := test.NewSpace()
+	Expect(err).NotTo(HaveOccurred())
+	ep := fmt.Sprintf("http://127.0.0.1:%d", ports[0])
+	server, err := embeddedetcd.NewServer(
+		embeddedetcd.ConfigureListener([]string{ep}, []string{fmt.Sprintf("http://127.0.0.1:%d", ports[1])}),
+		embeddedetcd.RootDir(dir),
+		embeddedetcd.AutoCompactionMode("periodic"),
+		embeddedetcd.AutoCompactionRetention("1h"),
+		embeddedetcd.QuotaBackendBytes(2*1024*1024*1024),
+	)
+	Expect(err).ShouldNot(HaveOccurred())
+	<-server.ReadyNotify()
+	schemaRegistry, err := schema.NewEtcdSchemaRegistry(
+		schema.Namespace(metadata.DefaultNamespace),
+		schema.ConfigureServerEndpoints([]string{ep}),
+	)
+	Expect(err).NotTo(HaveOccurred())
+	defer schemaRegistry.Close()
+	ctx := context.Background()
+	test_stream.PreloadSchema(ctx, schemaRegistry)
+	test_measure.PreloadSchema(ctx, schemaRegistry)
+	return ep, func() {
+		_ = server.Close()
+		<-server.StopNotify()
+		spaceDef()
+	}
+}
@@ -0,0 +1,284 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_rebalance_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	casesmeasuredata "github.com/apache/skywalking-banyandb/test/cases/measure/data"
+	casesstreamdata "github.com/apache/skywalking-banyandb/test/cases/stream/data"
+)
+
+const (
+	streamGroup   = "default"
+	measureGroup  = "sw_metric"
+	propertyGroup = "sw_property"
+)
+
+var _ = Describe("Rebalance", func() {
+	var conn *grpc.ClientConn
+	var closeFuncs []func()
+	var now time.Time
+	var etcdEndpoint string
+	var dataNode0 string
+
+	BeforeEach(func() {
+		By("Starting etcd server")
+		var closeEtcd func()
+		etcdEndpoint, closeEtcd = startEtcd()
+		closeFuncs = append(closeFuncs, closeEtcd)
+		By("Starting data node 0")
+		dataAddr0, _, closeDataNode0 := setup.DataNodeWithAddrAndDir(etcdEndpoint)
+		closeFuncs = append(closeFuncs, closeDataNode0)
+		dataNode0 = nodeName(dataAddr0)
+		By("Starting liaison node")
+		liaisonAddr, closeLiaisonNode := setup.LiaisonNode(etcdEndpoint)
+		closeFuncs = append(closeFuncs, closeLiaisonNode)
+		var err error
+		conn, err = grpchelper.Conn(liaisonAddr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		ns := timestamp.NowMilli().UnixNano()
+		now = time.Unix(0, ns-ns%int64(time.Minute))
+	})
+
+	AfterEach(func() {
+		Expect(conn.Close()).To(Succeed())
+		for i := len(closeFuncs) - 1; i >= 0; i-- {
+			if closeFuncs[i] != nil {
+				closeFuncs[i]()
+			}
+		}
+		closeFuncs = nil
+	})
+
+	DescribeTable("keeps the data of the moved shards unique", func(group string, write func(*grpc.ClientConn, time.Time),
+		query func(Gomega, *grpc.ClientConn, *modelv1.TimeRange) []string, total int,
+	) {
+		write(conn, now)
+		tr := &modelv1.TimeRange{
+			Begin: timestamppb.New(now.Add(-time.Hour)),
+			End:   timestamppb.New(now.Add(time.Hour)),
+		}
+		var want []string
+		Eventually(func(g Gomega) {
+			want = query(g, conn, tr)
+			g.Expect(want).To(HaveLen(total))
+		}, flags.EventuallyTimeout).Should(Succeed())
+
+		By("Starting data node 1")
+		dataAddr1, _, closeDataNode1 := setup.DataNodeWithAddrAndDir(etcdEndpoint)
+		closeFuncs = append(closeFuncs, closeDataNode1)
+		dataNode1 := nodeName(dataAddr1)
+		shards := databasev1.NewShardServiceClient(conn)
+		var moves []*databasev1.ShardMove
+		Eventually(func(g Gomega) {
+			resp, errRebalance := shards.Rebalance(context.Background(), &databasev1.ShardServiceRebalanceRequest{Group: group, DryRun: true})
+			g.Expect(errRebalance).NotTo(HaveOccurred())
+			moves = resp.Moves
+			g.Expect(moves).NotTo(BeEmpty())
+			for _, m := range moves {
+				g.Expect(m.To).To(Equal([]string{dataNode1}))
+			}
+		}, flags.EventuallyTimeout).Should(Succeed())
+		resp, err := shards.Rebalance(context.Background(), &databasev1.ShardServiceRebalanceRequest{Group: group})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Moves).To(HaveLen(len(moves)))
+		var migrated int
+		for _, m := range resp.Moves {
+			migrated += int(m.Migrated)
+		}
+		// The catch-up sends nothing as there are no writes during the rebalance.
+		Expect(migrated).To(BeNumerically(">", 0))
+		Expect(query(Default, conn, tr)).To(Equal(want))
+
+		By("Stopping data node 0")
+		closeFuncs[1]()
+		closeFuncs[1] = nil
+		// Only the moved shards are left.
+		Eventually(func(g Gomega) {
+			got := query(g, conn, tr)
+			g.Expect(got).To(HaveLen(migrated))
+			g.Expect(want).To(ContainElements(got))
+		}, flags.EventuallyTimeout).Should(Succeed())
+	},
+		Entry("stream", streamGroup, func(conn *grpc.ClientConn, baseTime time.Time) {
+			casesstreamdata.Write(conn, "sw", baseTime, 500*time.Millisecond)
+		}, queryElements, 5),
+		Entry("measure", measureGroup, func(conn *grpc.ClientConn, baseTime time.Time) {
+			casesmeasuredata.Write(conn, "service_cpm_minute", measureGroup, "service_cpm_minute_data.json", baseTime, time.Minute)
+		}, queryDataPoints, 6),
+	)
+
+	It("verifies the data of the drained node on the new node", func() {
+		casesstreamdata.Write(conn, "sw", now, 500*time.Millisecond)
+		casesmeasuredata.Write(conn, "service_cpm_minute", measureGroup, "service_cpm_minute_data.json", now, time.Minute)
+		tr := &modelv1.TimeRange{
+			Begin: timestamppb.New(now.Add(-time.Hour)),
+			End:   timestamppb.New(now.Add(time.Hour)),
+		}
+		var elements, dataPoints []string
+		Eventually(func(g Gomega) {
+			elements = queryElements(g, conn, tr)
+			g.Expect(elements).To(HaveLen(5))
+			dataPoints = queryDataPoints(g, conn, tr)
+			g.Expect(dataPoints).To(HaveLen(6))
+		}, flags.EventuallyTimeout).Should(Succeed())
+
+		By("Starting data node 1")
+		dataAddr1, _, closeDataNode1 := setup.DataNodeWithAddrAndDir(etcdEndpoint)
+		closeFuncs = append(closeFuncs, closeDataNode1)
+		dataNode1 := nodeName(dataAddr1)
+		shards := databasev1.NewShardServiceClient(conn)
+		Eventually(func(g Gomega) {
+			for _, group := range []string{streamGroup, measureGroup} {
+				resp, err := shards.Rebalance(context.Background(), &databasev1.ShardServiceRebalanceRequest{Group: group, DryRun: true})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.Moves).NotTo(BeEmpty())
+			}
+		}, flags.EventuallyTimeout).Should(Succeed())
+		nodes := databasev1.NewNodeServiceClient(conn)
+		resp, err := nodes.Drain(context.Background(), &databasev1.NodeServiceDrainRequest{Node: dataNode0})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Shards).NotTo(BeEmpty())
+		counted := make(map[string]uint64)
+		for _, ds := range resp.Shards {
+			Expect(ds.To).To(Equal([]string{dataNode1}))
+			Expect(ds.Counted).To(BeNumerically("<=", ds.Migrated))
+			counted[ds.Group] += ds.Counted
+		}
+		Expect(counted).To(Equal(map[string]uint64{streamGroup: 5, measureGroup: 6}))
+
+		By("Stopping data node 0")
+		closeFuncs[1]()
+		closeFuncs[1] = nil
+		Eventually(func(g Gomega) {
+			g.Expect(queryElements(g, conn, tr)).To(Equal(elements))
+			g.Expect(queryDataPoints(g, conn, tr)).To(Equal(dataPoints))
+		}, flags.EventuallyTimeout).Should(Succeed())
+	})
+
+	It("refuses to drain the node holding the property shards", func() {
+		groups := databasev1.NewGroupRegistryServiceClient(conn)
+		_, err := groups.Create(context.Background(), &databasev1.GroupRegistryServiceCreateRequest{Group: &commonv1.Group{
+			Metadata:     &commonv1.Metadata{Name: propertyGroup},
+			Catalog:      commonv1.Catalog_CATALOG_PROPERTY,
+			ResourceOpts: &commonv1.ResourceOpts{ShardNum: 1},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = databasev1.NewPropertyRegistryServiceClient(conn).Create(context.Background(), &databasev1.PropertyRegistryServiceCreateRequest{
+			Property: &databasev1.Property{
+				Metadata: &commonv1.Metadata{Group: propertyGroup, Name: "p"},
+				Tags:     []*databasev1.TagSpec{{Name: "t", Type: databasev1.TagType_TAG_TYPE_STRING}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		properties := propertyv1.NewPropertyServiceClient(conn)
+		Eventually(func(g Gomega) {
+			_, errApply := properties.Apply(context.Background(), &propertyv1.ApplyRequest{Property: &propertyv1.Property{
+				Metadata: &commonv1.Metadata{Group: propertyGroup, Name: "p"},
+				Id:       "1",
+				Tags:     []*modelv1.Tag{{Key: "t", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "v"}}}}},
+			}})
+			g.Expect(errApply).NotTo(HaveOccurred())
+		}, flags.EventuallyTimeout).Should(Succeed())
+		Eventually(func(g Gomega) {
+			resp, errList := databasev1.NewShardServiceClient(conn).List(context.Background(), &databasev1.ShardServiceListRequest{Group: propertyGroup})
+			g.Expect(errList).NotTo(HaveOccurred())
+			g.Expect(resp.Shards).To(HaveLen(1))
+		}, flags.EventuallyTimeout).Should(Succeed())
+
+		By("Starting data node 1")
+		_, _, closeDataNode1 := setup.DataNodeWithAddrAndDir(etcdEndpoint)
+		closeFuncs = append(closeFuncs, closeDataNode1)
+		_, err = databasev1.NewNodeServiceClient(conn).Drain(context.Background(), &databasev1.NodeServiceDrainRequest{Node: dataNode0})
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		// The node isn't labeled as draining, so the property is still routed to it.
+		resp, err := properties.Query(context.Background(), &propertyv1.QueryRequest{Groups: []string{propertyGroup}, Name: "p"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Properties).To(HaveLen(1))
+	})
+})
+
+// nodeName returns the name of the data node listening on the address, which is registered by its node host.
+func nodeName(addr string) string {
+	_, port, err := net.SplitHostPort(addr)
+	Expect(err).NotTo(HaveOccurred())
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+func queryElements(g Gomega, conn *grpc.ClientConn, tr *modelv1.TimeRange) []string {
+	resp, err := streamv1.NewStreamServiceClient(conn).Query(context.Background(), &streamv1.QueryRequest{
+		Groups:    []string{streamGroup},
+		Name:      "sw",
+		TimeRange: tr,
+		Limit:     100,
+		Projection: &modelv1.TagProjection{
+			TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "searchable", Tags: []string{"trace_id"}}},
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	keys := make([]string, 0, len(resp.Elements))
+	for _, e := range resp.Elements {
+		keys = append(keys, fmt.Sprintf("%s@%d", e.ElementId, e.Timestamp.AsTime().UnixNano()))
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func queryDataPoints(g Gomega, conn *grpc.ClientConn, tr *modelv1.TimeRange) []string {
+	resp, err := measurev1.NewMeasureServiceClient(conn).Query(context.Background(), &measurev1.QueryRequest{
+		Groups:    []string{measureGroup},
+		Name:      "service_cpm_minute",
+		TimeRange: tr,
+		Limit:     100,
+		TagProjection: &modelv1.TagProjection{
+			TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"entity_id"}}},
+		},
+		FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{"total", "value"}},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	keys := make([]string, 0, len(resp.DataPoints))
+	for _, dp := range resp.DataPoints {
+		keys = append(keys, fmt.Sprintf("%s@%d: %v", dp.TagFamilies[0].Tags[0].Value.GetStr().GetValue(),
+			dp.Timestamp.AsTime().UnixNano(), dp.Fields))
+	}
+	slices.Sort(keys)
+	return keys
+}